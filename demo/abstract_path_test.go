@@ -0,0 +1,94 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAbstractPath verifies that an Abstract obtained during a walk
+// reports its location, as do its descendants.
+func TestAbstractPath(t *testing.T) {
+	a := assert.New(t)
+	x := &l.ContainerType{
+		ByRefSlice: []l.ByRefType{{Val: "a"}, {Val: "b"}},
+		ByValPtr:   &l.ByValType{Val: "c"},
+	}
+
+	// paths lists the locations of node and its descendants.
+	var paths func(node l.TargetAbstract) []string
+	paths = func(node l.TargetAbstract) []string {
+		ret := []string{node.TargetPath()}
+		for i := 0; i < node.TargetCount(); i++ {
+			if child := node.TargetAt(i); child != nil {
+				ret = append(ret, paths(child)...)
+			}
+		}
+		return ret
+	}
+
+	var found []string
+	_, _, err := l.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if _, ok := x.(*l.ContainerType); ok && ctx.Path() == "ContainerType" {
+			node := l.AbstractTargetAt(ctx)
+			a.Equal(l.TargetTypeContainerType, node.TargetTypeID())
+			found = paths(node)
+			return ctx.Skip()
+		}
+		return ctx.Continue()
+	})
+	a.NoError(err)
+	a.Equal([]string{
+		"ContainerType",
+		"ContainerType/ByRef",
+		"ContainerType/ByRefSlice",
+		"ContainerType/ByRefSlice[0]",
+		"ContainerType/ByRefSlice[1]",
+		"ContainerType/ByVal",
+		"ContainerType/ByValPtr",
+	}, found)
+
+	// Children which are obtained outside of a walk are returned as
+	// structs, which do not record their paths.
+	node := x.TargetAt(0)
+	a.IsType(&l.ByRefType{}, node)
+	a.Equal("", node.TargetPath())
+
+	// Each reports the same paths as At.
+	_, _, err = l.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if _, ok := x.(*l.ContainerType); ok {
+			var each []string
+			l.AbstractTargetAt(ctx).TargetEach(func(_ int, child l.TargetAbstract) bool {
+				each = append(each, child.TargetPath())
+				return true
+			})
+			a.Equal([]string{
+				"ContainerType/ByRef",
+				"ContainerType/ByRefSlice",
+				"ContainerType/ByVal",
+				"ContainerType/ByValPtr",
+			}, each)
+		}
+		return ctx.Skip()
+	})
+	a.NoError(err)
+
+	// Outside of a walk, there is no value to describe.
+	a.Nil(l.AbstractTargetAt(l.TargetContext{}))
+}
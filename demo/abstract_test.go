@@ -0,0 +1,54 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"strings"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbstractKinds(t *testing.T) {
+	a := assert.New(t)
+	data, _ := l.NewContainer(true)
+
+	a.Equal(l.TargetKindStruct, data.TargetKind())
+	a.Equal("struct", data.TargetKind().String())
+	a.Equal("ContainerType", data.TargetTypeName())
+
+	var slices int
+	data.TargetEach(func(index int, child l.TargetAbstract) bool {
+		name := child.TargetTypeName()
+		a.Equal(child.TargetTypeID().String(), name)
+		if strings.HasPrefix(name, "[]") {
+			slices++
+			a.Equal(l.TargetKindSlice, child.TargetKind(), name)
+			a.Equal("slice", child.TargetKind().String())
+		} else {
+			a.Equal(l.TargetKindStruct, child.TargetKind(), name)
+		}
+		return true
+	})
+	a.Equal(7, slices)
+
+	slice := data.TargetAt(3)
+	if a.NotNil(slice) {
+		a.Equal("[]*ByRefType", slice.TargetTypeName())
+		a.Equal("ByRefType", slice.TargetAt(0).TargetTypeName())
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// collectPaths is a reusable walker which records the path of each
+// ByRefType.
+func collectPaths(
+	ctx l.TargetContext, x l.Target, acc *l.TargetAccumulator[[]string],
+) l.TargetDecision {
+	if _, ok := x.(*l.ByRefType); ok {
+		acc.Value = append(acc.Value, ctx.Path())
+	}
+	return ctx.Continue()
+}
+
+// TestWalkAccum verifies that a walker can return its results through
+// an accumulator.
+func TestWalkAccum(t *testing.T) {
+	a := assert.New(t)
+
+	small := &l.ContainerType{ByRefSlice: []l.ByRefType{{Val: "a"}, {Val: "b"}}}
+	large, _ := l.NewContainer(true)
+
+	smallAcc := l.NewTargetAccumulator[[]string]()
+	_, changed, err := l.WalkTargetAccum(small, smallAcc, collectPaths)
+	a.NoError(err)
+	a.False(changed)
+	a.Equal([]string{
+		"ContainerType/ByRef",
+		"ContainerType/ByRefSlice[0]",
+		"ContainerType/ByRefSlice[1]",
+	}, smallAcc.Value)
+
+	// The same walker may be used with another accumulator.
+	largeAcc := l.NewTargetAccumulator[[]string]()
+	_, _, err = l.WalkTargetAccum(large, largeAcc, collectPaths)
+	a.NoError(err)
+	a.Len(largeAcc.Value, 6)
+	a.Len(smallAcc.Value, 3)
+
+	// An accumulating walker may also replace values.
+	count := l.NewTargetAccumulator[int]()
+	out, changed, err := l.WalkTargetAccum(small, count,
+		func(ctx l.TargetContext, x l.Target, acc *l.TargetAccumulator[int]) l.TargetDecision {
+			if ref, ok := x.(*l.ByRefType); ok && ref.Val != "" {
+				acc.Value++
+				return ctx.Continue().Replace(&l.ByRefType{Val: ref.Val + "!"})
+			}
+			return ctx.Continue()
+		})
+	a.NoError(err)
+	a.True(changed)
+	a.Equal(2, count.Value)
+	a.Equal("a!", out.(*l.ContainerType).ByRefSlice[0].Val)
+}
@@ -0,0 +1,85 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// upperVisitor is a hand-written visitor, of the kind which may
+// already exist in a codebase.
+type upperVisitor struct {
+	pre, post int
+}
+
+var _ l.TargetVisitor = &upperVisitor{}
+
+// VisitPre replaces by-value types and does not descend into
+// containers which refer to themselves.
+func (v *upperVisitor) VisitPre(x l.Target) (bool, l.Target) {
+	v.pre++
+	switch t := x.(type) {
+	case *l.ByValType:
+		return true, l.ByValType{Val: "pre " + t.Val}
+	case *l.ContainerType:
+		return t.Container != t, nil
+	}
+	return true, nil
+}
+
+// VisitPost replaces by-reference types.
+func (v *upperVisitor) VisitPost(x l.Target) l.Target {
+	v.post++
+	if t, ok := x.(*l.ByRefType); ok {
+		return &l.ByRefType{Val: "post " + t.Val}
+	}
+	return nil
+}
+
+func TestAdapter(t *testing.T) {
+	a := assert.New(t)
+
+	x := &l.ContainerType{
+		ByRef:       l.ByRefType{Val: "ref"},
+		ByVal:       l.ByValType{Val: "val"},
+		TargetSlice: []l.Target{l.ByValType{Val: "slice"}},
+	}
+	v := &upperVisitor{}
+	y, changed, err := x.WalkTarget(l.TargetVisitorAdapter(v))
+	if !a.NoError(err) {
+		return
+	}
+	a.True(changed)
+	a.Equal("post ref", y.ByRef.Val)
+	a.Equal("pre val", y.ByVal.Val)
+	a.Equal(&l.ByValType{Val: "pre slice"}, y.TargetSlice[0])
+	a.Equal("ref", x.ByRef.Val)
+	a.Equal(v.pre, v.post)
+
+	// A false result from VisitPre skips the children and VisitPost.
+	x.Container = x
+	v = &upperVisitor{}
+	y, changed, err = x.WalkTarget(l.TargetVisitorAdapter(v))
+	if a.NoError(err) {
+		a.False(changed)
+		a.True(x == y)
+		a.Equal(1, v.pre)
+		a.Equal(0, v.post)
+	}
+}
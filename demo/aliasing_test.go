@@ -0,0 +1,90 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasCheck(t *testing.T) {
+	a := assert.New(t)
+	defer engine.SetAliasCheck(engine.SetAliasCheck(true))
+
+	newTree := func() *l.ContainerType {
+		return &l.ContainerType{
+			ByRefSlice: []l.ByRefType{{Val: "a"}, {Val: "b"}},
+			Container: &l.ContainerType{
+				ByRef:      l.ByRefType{Val: "inner"},
+				ByRefSlice: []l.ByRefType{{Val: "c"}},
+			},
+		}
+	}
+
+	// A rewrite which replaces values is allowed.
+	x := newTree()
+	out, changed, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if t, ok := x.(*l.ByRefType); ok && t.Val == "b" {
+			return ctx.Continue().Replace(&l.ByRefType{Val: "B"})
+		}
+		return ctx.Continue()
+	})
+	a.NoError(err)
+	a.True(changed)
+	a.Equal("B", out.ByRefSlice[1].Val)
+	a.Equal("b", x.ByRefSlice[1].Val)
+
+	// A shallow copy of a struct shares its slices with the original.
+	x = newTree()
+	_, _, err = x.WalkTarget(func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+		if y == x.Container {
+			cpy := *x.Container
+			cpy.ByRefSlice[0].Val = "oops"
+			return ctx.Skip().Replace(&cpy)
+		}
+		return ctx.Continue()
+	})
+	var aliasErr *engine.AliasError
+	if a.True(errors.As(err, &aliasErr), "%v", err) {
+		a.Equal("ContainerType/Container/ByRefSlice[0]/Val", aliasErr.Path)
+	}
+
+	// Values which are modified in place are also reported.
+	x = newTree()
+	_, _, err = x.WalkTarget(func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+		if t, ok := y.(*l.ContainerType); ok && t == x.Container {
+			t.ByRef.Val = "modified"
+		}
+		return ctx.Continue()
+	})
+	a.EqualError(err, "walk modified the original value at "+
+		"ContainerType/Container/ByRef/Val, which may be shared with the result")
+
+	// The check can be disabled.
+	a.True(engine.SetAliasCheck(false))
+	x = newTree()
+	_, _, err = x.WalkTarget(func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+		if t, ok := y.(*l.ByRefType); ok {
+			t.Val = "modified"
+		}
+		return ctx.Continue()
+	})
+	a.NoError(err)
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package ast is used to check the generation of code for types which
+// implement an interface declared in an imported package.
+package ast
+
+import "github.com/cockroachdb/walkabout/demo/ast/base"
+
+//go:generate -command walkabout go run ../..
+//go:generate walkabout base.Node
+
+// Ident is a leaf node.
+type Ident struct {
+	At   int
+	Name string
+}
+
+// Pos implements base.Node.
+func (i *Ident) Pos() int { return i.At }
+
+// Call refers to other nodes by their imported interface.
+type Call struct {
+	At   int
+	Fn   base.Node
+	Args []base.Node
+	Name *Ident
+}
+
+// Pos implements base.Node.
+func (c *Call) Pos() int { return c.At }
@@ -22,6 +22,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/engine"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,6 +47,54 @@ func TestNoMallocs(t *testing.T) {
 	}
 }
 
+// Deep or wide structures require more stack frames or slots than are
+// pre-allocated, which must be reused across visitations.
+func TestNoMallocsLarge(t *testing.T) {
+	t.Run("deep", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := demo.NewContainer(true)
+		for i := 0; i < 32; i++ {
+			next, _ := demo.NewContainer(true)
+			next.Container = x
+			x = next
+		}
+		testNoMallocs(a, x, false)
+	})
+
+	t.Run("wide", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := demo.NewContainer(true)
+		for i := 0; i < 100; i++ {
+			x.TargetSlice = append(x.TargetSlice, &demo.ByValType{})
+			x.ByRefSlice = append(x.ByRefSlice, demo.ByRefType{})
+		}
+		testNoMallocs(a, x, false)
+	})
+
+	t.Run("huge", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := demo.NewContainer(true)
+		for i := 0; i < 10000; i++ {
+			x.ByRefSlice = append(x.ByRefSlice, demo.ByRefType{})
+		}
+		testNoMallocs(a, x, false)
+	})
+
+	t.Run("deep and wide", func(t *testing.T) {
+		a := assert.New(t)
+		var x *demo.ContainerType
+		for i := 0; i < 16; i++ {
+			next, _ := demo.NewContainer(i%2 == 0)
+			for j := 0; j < 10*i; j++ {
+				next.TargetSlice = append(next.TargetSlice, &demo.ByValType{})
+			}
+			next.Container = x
+			x = next
+		}
+		testNoMallocs(a, x, false)
+	})
+}
+
 // BenchmarkNoop should demonstrate that visitations are allocation-free.
 func BenchmarkNoop(b *testing.B) {
 	tcs := []struct {
@@ -66,6 +115,32 @@ func BenchmarkNoop(b *testing.B) {
 	}
 }
 
+// BenchmarkCycleDetection compares linear and hash-based cycle
+// detection for a range of stack depths.
+func BenchmarkCycleDetection(b *testing.B) {
+	for _, depth := range []int{4, 32, 256} {
+		x, _ := demo.NewContainer(true)
+		for i := 1; i < depth; i++ {
+			next, _ := demo.NewContainer(true)
+			next.Container = x
+			x = next
+		}
+		for _, mode := range []struct {
+			name      string
+			threshold int
+		}{
+			{"linear", -1},
+			{"hash", 0},
+			{"hybrid", 32},
+		} {
+			b.Run(fmt.Sprintf("depth=%d/%s", depth, mode.name), func(b *testing.B) {
+				defer engine.SetCycleThreshold(engine.SetCycleThreshold(mode.threshold))
+				bench(b, x, false)
+			})
+		}
+	}
+}
+
 func bench(b *testing.B, x *demo.ContainerType, topLevel bool) {
 	b.Helper()
 	b.ReportAllocs()
@@ -86,6 +161,43 @@ func bench(b *testing.B, x *demo.ContainerType, topLevel bool) {
 	})
 }
 
+// A caller-supplied stack should be reused without allocations, and
+// its zero value should be usable.
+func TestStack(t *testing.T) {
+	a := assert.New(t)
+	x, _ := demo.NewContainer(true)
+	for i := 0; i < 32; i++ {
+		next, _ := demo.NewContainer(i%2 == 0)
+		next.Container = x
+		x = next
+	}
+	fn := func(ctx demo.TargetContext, x demo.Target) (ret demo.TargetDecision) { return }
+
+	var stack demo.TargetStack
+	stack.Grow(64)
+	x2, changed, err := stack.WalkTarget(x, fn)
+	if a.NoError(err) {
+		a.False(changed)
+		a.True(x == x2)
+	}
+	a.Zero(testing.AllocsPerRun(100, func() {
+		_, _, _ = stack.WalkTarget(x, fn)
+	}))
+
+	var zero demo.TargetStack
+	y, _ := demo.NewContainer(false)
+	y2, changed, err := zero.WalkTarget(y, func(ctx demo.TargetContext, x demo.Target) demo.TargetDecision {
+		if _, ok := x.(*demo.ByRefType); ok {
+			return ctx.Continue().Replace(&demo.ByRefType{Val: "Replaced"})
+		}
+		return ctx.Continue()
+	})
+	if a.NoError(err) {
+		a.True(changed)
+		a.Equal("Replaced", y2.(*demo.ContainerType).ByRef.Val)
+	}
+}
+
 // This runs in a loop until we have demonstrated that no mallocs
 // occur, or a timeout occurs. This allows us to account for any
 // other threads that may be running.
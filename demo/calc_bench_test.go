@@ -0,0 +1,286 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+// This file compares the generated walker for the calculator AST to a
+// hand-written recursive type switch, which is the ideal, and to a
+// generic walker which uses reflection. Each approach counts the nodes
+// of a large expression and rewrites it by incrementing every scalar.
+// Run with, e.g.:
+//	go test ./demo -run '^$' -bench BenchmarkCalc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// calcDepth produces an expression with about 2,000 nodes.
+const calcDepth = 8
+
+// newCalcTree returns a Calculation which alternates between levels of
+// binary operators and function calls.
+func newCalcTree(depth int) *Calculation {
+	next := 0
+	var build func(depth int) Expr
+	build = func(depth int) Expr {
+		switch {
+		case depth == 0:
+			next++
+			return &Scalar{next}
+		case depth%2 == 0:
+			return &BinaryOp{"+", build(depth - 1), build(depth - 1)}
+		default:
+			return &Func{"Sum", []Expr{build(depth - 1), build(depth - 1), build(depth - 1)}}
+		}
+	}
+	return &Calculation{build(depth)}
+}
+
+// calcWalker implements a workload using one of the approaches.
+type calcWalker struct {
+	count   func(c *Calculation) int
+	rewrite func(c *Calculation) *Calculation
+}
+
+var calcWalkers = []struct {
+	name   string
+	walker calcWalker
+}{
+	{"walkabout", calcWalker{
+		count: func(c *Calculation) int {
+			count := 0
+			_, _, _ = WalkCalc(c, func(ctx CalcContext, x Calc) (d CalcDecision) {
+				count++
+				return
+			})
+			return count
+		},
+		rewrite: func(c *Calculation) *Calculation {
+			out, _, _ := WalkCalc(c, func(ctx CalcContext, x Calc) (d CalcDecision) {
+				if s, ok := x.(*Scalar); ok {
+					d = d.Replace(&Scalar{s.val + 1})
+				}
+				return
+			})
+			return out.(*Calculation)
+		},
+	}},
+	{"hand", calcWalker{
+		count: func(c *Calculation) int {
+			return 1 + handCount(c.Expr)
+		},
+		rewrite: func(c *Calculation) *Calculation {
+			if expr, changed := handRewrite(c.Expr); changed {
+				return &Calculation{expr}
+			}
+			return c
+		},
+	}},
+	{"reflect", calcWalker{
+		count: func(c *Calculation) int {
+			count := 0
+			reflectWalk(reflect.ValueOf(c), func(x Calc) Calc {
+				count++
+				return x
+			})
+			return count
+		},
+		rewrite: func(c *Calculation) *Calculation {
+			out, _ := reflectWalk(reflect.ValueOf(c), func(x Calc) Calc {
+				if s, ok := x.(*Scalar); ok {
+					return &Scalar{s.val + 1}
+				}
+				return x
+			})
+			return out.Interface().(*Calculation)
+		},
+	}},
+}
+
+// handCount counts the nodes in the expression.
+func handCount(x Expr) int {
+	switch t := x.(type) {
+	case *BinaryOp:
+		return 1 + handCount(t.Left) + handCount(t.Right)
+	case *Func:
+		ret := 1
+		for _, arg := range t.Args {
+			ret += handCount(arg)
+		}
+		return ret
+	case *Scalar:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// handRewrite increments each scalar, copying only the nodes which
+// enclose a change.
+func handRewrite(x Expr) (Expr, bool) {
+	switch t := x.(type) {
+	case *BinaryOp:
+		left, leftChanged := handRewrite(t.Left)
+		right, rightChanged := handRewrite(t.Right)
+		if !leftChanged && !rightChanged {
+			return t, false
+		}
+		return &BinaryOp{t.Operator, left, right}, true
+	case *Func:
+		var args []Expr
+		for i, arg := range t.Args {
+			next, changed := handRewrite(arg)
+			if changed && args == nil {
+				args = make([]Expr, len(t.Args))
+				copy(args, t.Args)
+			}
+			if args != nil {
+				args[i] = next
+			}
+		}
+		if args == nil {
+			return t, false
+		}
+		return &Func{t.Fn, args}, true
+	case *Scalar:
+		return &Scalar{t.val + 1}, true
+	default:
+		return x, false
+	}
+}
+
+var calcType = reflect.TypeOf((*Calc)(nil)).Elem()
+
+// reflectWalk calls fn, in pre-order, with each value within v which
+// implements Calc. If fn returns a different value, it replaces the
+// original and its children are not visited. The returned value is a
+// copy of v which incorporates the replacements, or v if there were
+// none.
+func reflectWalk(v reflect.Value, fn func(Calc) Calc) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		elem, changed := reflectWalk(v.Elem(), fn)
+		if !changed {
+			return v, false
+		}
+		ret := reflect.New(v.Type()).Elem()
+		ret.Set(elem)
+		return ret, true
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false
+		}
+		if v.Type().Implements(calcType) {
+			x := v.Interface().(Calc)
+			if next := fn(x); next != x {
+				return reflect.ValueOf(next), true
+			}
+		}
+		elem, changed := reflectWalk(v.Elem(), fn)
+		if !changed {
+			return v, false
+		}
+		ret := reflect.New(elem.Type())
+		ret.Elem().Set(elem)
+		return ret, true
+
+	case reflect.Struct:
+		var ret reflect.Value
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			field, changed := reflectWalk(v.Field(i), fn)
+			if !changed {
+				continue
+			}
+			if !ret.IsValid() {
+				ret = reflect.New(v.Type()).Elem()
+				ret.Set(v)
+			}
+			ret.Field(i).Set(field)
+		}
+		if !ret.IsValid() {
+			return v, false
+		}
+		return ret, true
+
+	case reflect.Slice:
+		var ret reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			elt, changed := reflectWalk(v.Index(i), fn)
+			if !changed {
+				continue
+			}
+			if !ret.IsValid() {
+				ret = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+				reflect.Copy(ret, v)
+			}
+			ret.Index(i).Set(elt)
+		}
+		if !ret.IsValid() {
+			return v, false
+		}
+		return ret, true
+
+	default:
+		return v, false
+	}
+}
+
+// The approaches must produce the same results for the benchmarks to
+// be comparable.
+func TestCalcWalkers(t *testing.T) {
+	a := assert.New(t)
+	c := newCalcTree(4)
+	want := calcWalkers[0].walker
+	for _, w := range calcWalkers[1:] {
+		a.Equal(want.count(c), w.walker.count(c), w.name)
+		a.Equal(want.rewrite(c), w.walker.rewrite(c), w.name)
+	}
+	a.Equal(c, newCalcTree(4), "the original was modified")
+}
+
+// BenchmarkCalc should be used to catch regressions in the engine
+// relative to hand-written code.
+func BenchmarkCalc(b *testing.B) {
+	c := newCalcTree(calcDepth)
+	for _, w := range calcWalkers {
+		w := w
+		b.Run(fmt.Sprintf("count/%s", w.name), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = w.walker.count(c)
+			}
+		})
+	}
+	for _, w := range calcWalkers {
+		w := w
+		b.Run(fmt.Sprintf("rewrite/%s", w.name), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = w.walker.rewrite(c)
+			}
+		})
+	}
+}
@@ -25,7 +25,7 @@ import (
 // are reachable from the Calculation struct and create a
 // Calc interface to unify them.
 //go:generate -command walkabout go run ..
-//go:generate walkabout --union Calc --reachable Calculation
+//go:generate walkabout --union Calc --reachable --inline 4 Calculation Let
 
 // This example shows a toy calculator AST and how custom actions can be
 // introduced into the visitation flow. We've decided to use a visitor
@@ -98,10 +98,11 @@ type Expr interface {
 	isExpr()
 }
 
+// BinaryOp renames its fields in paths, queries, and serialized forms.
 type BinaryOp struct {
-	Operator string
-	Left     Expr
-	Right    Expr
+	Operator string `walkabout:"name=op"`
+	Left     Expr   `walkabout:"name=lhs"`
+	Right    Expr   `walkabout:"name=rhs"`
 }
 
 func (*BinaryOp) isExpr() {}
@@ -116,3 +117,14 @@ type Func struct {
 }
 
 func (*Func) isExpr() {}
+
+// Let binds a name to a value before evaluating its body. The binding
+// is declared after the body, but its tag ensures that it is visited
+// first.
+type Let struct {
+	Body  Expr
+	Name  string
+	Value Expr `walkabout:"order=-1"`
+}
+
+func (*Let) isExpr() {}
@@ -1,10 +1,15 @@
 // Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.
 // source:
+// walkabout version: dev
 
 package demo
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
 	"unsafe"
 
 	e "github.com/cockroachdb/walkabout/engine"
@@ -21,22 +26,54 @@ type CalcTypeID e.TypeID
 type CalcAbstract interface {
 	// CalcAt returns the nth field of a struct or nth element of a
 	// slice. If the child is a type which directly implements
-	// CalcAbstract, it will be returned. If the child is of a pointer or
-	// interface type, the value will be automatically dereferenced if it
-	// is non-nil. If the child is a slice type, a CalcAbstract wrapper
-	// around the slice will be returned.
+	// CalcAbstract, it will be returned, unless the child must record
+	// its path. If the child is of a pointer or interface type, the value
+	// will be automatically dereferenced if it is non-nil. Otherwise, a
+	// CalcAbstract wrapper around the child will be returned.
 	CalcAt(index int) CalcAbstract
+	// CalcEach calls yield with each index for which CalcAt
+	// would return a non-nil value, and that value, until yield returns
+	// false. This does not allocate for each child, so a CalcAbstract
+	// wrapper around a slice will be reused and must not be retained
+	// after yield returns.
+	CalcEach(yield func(index int, child CalcAbstract) bool)
+	// CalcField returns the name and the complete tag of the nth field
+	// of a struct, or empty values for a slice. The name is the one used
+	// in paths and serialized forms.
+	CalcField(index int) (name string, tag reflect.StructTag)
+	// CalcKind returns whether the value is a struct or a slice.
+	CalcKind() CalcKind
 	// CalcCount returns the number of visitable fields in a struct,
 	// or the length of a slice.
 	CalcCount() int
+	// CalcPath returns the location of the value, using the same
+	// syntax as CalcContext.Path, if it was obtained from
+	// AbstractCalcAt or is a descendant of such a value.
+	// Otherwise, it returns an empty string.
+	CalcPath() string
 	// CalcTypeID returns a type token.
 	CalcTypeID() CalcTypeID
+	// CalcTypeName returns a human-readable name for the type of the
+	// value, e.g. "Foo" or "[]Foo".
+	CalcTypeName() string
 }
 
+// CalcKind describes the shape of a visitable type.
+type CalcKind = e.Kind
+
+// The kinds of visitable types.
+const (
+	CalcKindInterface = e.KindInterface
+	CalcKindPointer   = e.KindPointer
+	CalcKindSlice     = e.KindSlice
+	CalcKindStruct    = e.KindStruct
+)
+
 var (
 	_ CalcAbstract = &BinaryOp{}
 	_ CalcAbstract = &Calculation{}
 	_ CalcAbstract = &Func{}
+	_ CalcAbstract = &Let{}
 	_ CalcAbstract = &Scalar{}
 )
 
@@ -81,6 +118,14 @@ func (c *CalcContext) Continue() CalcDecision {
 	return CalcDecision(c.impl.Continue())
 }
 
+// Defer registers a function to be called after the walk completes,
+// even if it is halted or fails. Functions are called in the reverse
+// order of their registration. If the walk succeeds, the first error
+// returned by a deferred function is returned from the walk.
+func (c *CalcContext) Defer(fn func() error) {
+	c.impl.Defer(fn)
+}
+
 // Error returns a CalcDecision which will cause the given error
 // to be returned from the Walk() function. Post-visit functions
 // will not be called.
@@ -94,6 +139,12 @@ func (c *CalcContext) Halt() CalcDecision {
 	return CalcDecision(c.impl.Halt())
 }
 
+// Path returns the location of the current object, e.g.
+// "Parent/Slice[1]/Field".
+func (c *CalcContext) Path() string {
+	return c.impl.Path()
+}
+
 // Skip will not traverse the fields of the current object.
 func (c *CalcContext) Skip() CalcDecision {
 	return CalcDecision(c.impl.Skip())
@@ -120,11 +171,17 @@ func (d CalcDecision) Post(fn CalcWalkerFn) CalcDecision {
 }
 
 // Replace allows the currently-visited value to be replaced. All
-// parent nodes will be cloned.
+// parent nodes will be cloned. If the replacement cannot be stored in
+// the value's location, the walk returns a *CalcReplacementError.
 func (d CalcDecision) Replace(x Calc) CalcDecision {
 	return CalcDecision((e.Decision)(d).Replace(calcIdentify(x)))
 }
 
+// CalcReplacementError is returned from a walk when a value is
+// replaced by a value whose type is not assignable to the value's
+// location. It identifies the path of the value and both types.
+type CalcReplacementError = e.ReplacementError
+
 // calcIdentify is a utility function to map a Calc into
 // its generated type id and a pointer to the data.
 func calcIdentify(x Calc) (typeId e.TypeID, data e.Ptr) {
@@ -138,6 +195,9 @@ func calcIdentify(x Calc) (typeId e.TypeID, data e.Ptr) {
 	case *Func:
 		typeId = e.TypeID(CalcTypeFunc)
 		data = e.Ptr(t)
+	case *Let:
+		typeId = e.TypeID(CalcTypeLet)
+		data = e.Ptr(t)
 	case *Scalar:
 		typeId = e.TypeID(CalcTypeScalar)
 		data = e.Ptr(t)
@@ -153,23 +213,17 @@ func calcIdentify(x Calc) (typeId e.TypeID, data e.Ptr) {
 // calcWrap is a utility function to reconstitute a Calc
 // from an internal type token and a pointer to the value.
 func calcWrap(typeId e.TypeID, x e.Ptr) Calc {
-	switch CalcTypeID(typeId) {
-	case CalcTypeBinaryOp:
+	switch typeId {
+	case e.TypeID(CalcTypeBinaryOp):
 		return (*BinaryOp)(x)
-	case CalcTypeBinaryOpPtr:
-		return *(**BinaryOp)(x)
-	case CalcTypeCalculation:
+	case e.TypeID(CalcTypeCalculation):
 		return (*Calculation)(x)
-	case CalcTypeCalculationPtr:
-		return *(**Calculation)(x)
-	case CalcTypeFunc:
+	case e.TypeID(CalcTypeFunc):
 		return (*Func)(x)
-	case CalcTypeFuncPtr:
-		return *(**Func)(x)
-	case CalcTypeScalar:
+	case e.TypeID(CalcTypeLet):
+		return (*Let)(x)
+	case e.TypeID(CalcTypeScalar):
 		return (*Scalar)(x)
-	case CalcTypeScalarPtr:
-		return *(**Scalar)(x)
 	default:
 		// This is likely a code-generation problem.
 		panic(fmt.Sprintf("unhandled TypeID %d", typeId))
@@ -200,32 +254,70 @@ type calcAbstract struct {
 var _ CalcAbstract = &calcAbstract{}
 
 // CalcAt implements CalcAbstract.
-func (a *calcAbstract) CalcAt(index int) (ret CalcAbstract) {
+func (a *calcAbstract) CalcAt(index int) CalcAbstract {
 	impl := a.delegate.ChildAt(index)
 	if impl == nil {
 		return nil
 	}
-	switch CalcTypeID(impl.TypeID()) {
-	case CalcTypeBinaryOp:
-		ret = (*BinaryOp)(impl.Ptr())
-	case CalcTypeBinaryOpPtr:
-		ret = *(**BinaryOp)(impl.Ptr())
-	case CalcTypeCalculation:
-		ret = (*Calculation)(impl.Ptr())
-	case CalcTypeCalculationPtr:
-		ret = *(**Calculation)(impl.Ptr())
-	case CalcTypeFunc:
-		ret = (*Func)(impl.Ptr())
-	case CalcTypeFuncPtr:
-		ret = *(**Func)(impl.Ptr())
-	case CalcTypeScalar:
-		ret = (*Scalar)(impl.Ptr())
-	case CalcTypeScalarPtr:
-		ret = *(**Scalar)(impl.Ptr())
-	default:
-		ret = &calcAbstract{impl}
+	return calcAbstractOf(impl, nil)
+}
+
+// CalcEach implements CalcAbstract.
+func (a *calcAbstract) CalcEach(yield func(index int, child CalcAbstract) bool) {
+	var reuse calcAbstract
+	a.delegate.Each(func(index int, impl *e.Abstract) bool {
+		return yield(index, calcAbstractOf(impl, &reuse))
+	})
+}
+
+// CalcField implements CalcAbstract.
+func (a *calcAbstract) CalcField(index int) (name string, tag reflect.StructTag) {
+	f, _ := a.delegate.Field(index)
+	return f.Name, f.Tag
+}
+
+// calcAbstractOf returns the struct that impl refers to, or a
+// facade around impl. If reuse is non-nil, it will be used as the
+// facade instead of allocating a new one.
+func calcAbstractOf(impl *e.Abstract, reuse *calcAbstract) CalcAbstract {
+	// Structs cannot record their path, so they are returned only if
+	// there is no path to record.
+	if impl.Path() == "" {
+		switch impl.TypeID() {
+		case e.TypeID(CalcTypeBinaryOp):
+			return (*BinaryOp)(impl.Ptr())
+		case e.TypeID(CalcTypeCalculation):
+			return (*Calculation)(impl.Ptr())
+		case e.TypeID(CalcTypeFunc):
+			return (*Func)(impl.Ptr())
+		case e.TypeID(CalcTypeLet):
+			return (*Let)(impl.Ptr())
+		case e.TypeID(CalcTypeScalar):
+			return (*Scalar)(impl.Ptr())
+		}
 	}
-	return
+	if reuse == nil {
+		return &calcAbstract{impl}
+	}
+	reuse.delegate = impl
+	return reuse
+}
+
+// AbstractCalcAt returns an accessor for the value being visited,
+// which records the value's path, as do its descendants. It returns nil
+// if the value is not being visited by the engine, as is the case for
+// some values of types which are walked inline.
+func AbstractCalcAt(ctx CalcContext) CalcAbstract {
+	impl := ctx.impl.Abstract()
+	if impl == nil {
+		return nil
+	}
+	return &calcAbstract{impl}
+}
+
+// CalcKind implements CalcAbstract.
+func (a *calcAbstract) CalcKind() CalcKind {
+	return a.delegate.Kind()
 }
 
 // CalcCount implements CalcAbstract.
@@ -238,98 +330,639 @@ func (a *calcAbstract) CalcTypeID() CalcTypeID {
 	return CalcTypeID(a.delegate.TypeID())
 }
 
+// CalcPath implements CalcAbstract.
+func (a *calcAbstract) CalcPath() string {
+	return a.delegate.Path()
+}
+
+// CalcTypeName implements CalcAbstract.
+func (a *calcAbstract) CalcTypeName() string {
+	return a.delegate.TypeName()
+}
+
 // CalcAt implements CalcAbstract.
 func (x *BinaryOp) CalcAt(index int) CalcAbstract {
-	self := calcAbstract{calcEngine.Abstract(e.TypeID(CalcTypeBinaryOp), e.Ptr(x))}
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeBinaryOp), e.Ptr(x))}
 	return self.CalcAt(index)
 }
 
+// CalcEach implements CalcAbstract.
+func (x *BinaryOp) CalcEach(yield func(index int, child CalcAbstract) bool) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeBinaryOp), e.Ptr(x))}
+	self.CalcEach(yield)
+}
+
+// CalcField implements CalcAbstract.
+func (x *BinaryOp) CalcField(index int) (name string, tag reflect.StructTag) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeBinaryOp), e.Ptr(x))}
+	return self.CalcField(index)
+}
+
+// CalcPath returns an empty string, because a struct does not record
+// its location.
+func (*BinaryOp) CalcPath() string { return "" }
+
+// CalcKind returns CalcKindStruct.
+func (*BinaryOp) CalcKind() CalcKind { return CalcKindStruct }
+
 // CalcCount returns 2.
 func (x *BinaryOp) CalcCount() int { return 2 }
 
 // CalcTypeID returns CalcTypeBinaryOp.
 func (*BinaryOp) CalcTypeID() CalcTypeID { return CalcTypeBinaryOp }
 
+// CalcTypeName returns "BinaryOp".
+func (*BinaryOp) CalcTypeName() string { return "BinaryOp" }
+
 // WalkCalc visits the receiver with the provided callback.
 func (x *BinaryOp) WalkCalc(fn CalcWalkerFn) (_ *BinaryOp, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
 	var y e.Ptr
-	_, y, changed, err = calcEngine.Execute(fn, e.TypeID(CalcTypeBinaryOp), e.Ptr(x), e.TypeID(CalcTypeBinaryOp))
+	_, y, changed, err = calcEngine().Execute(fn, e.TypeID(CalcTypeBinaryOp), e.Ptr(x), e.TypeID(CalcTypeBinaryOp))
 	if err != nil {
 		return nil, false, err
 	}
 	return (*BinaryOp)(y), changed, nil
 }
 
+// WalkCalcFrom is equivalent to WalkCalc, but the receiver may be
+// replaced by any Calc, rather than only by a *BinaryOp.
+func (x *BinaryOp) WalkCalcFrom(fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, y, changed, err := calcEngine().Execute(fn, e.TypeID(CalcTypeBinaryOp), e.Ptr(x), e.TypeID(CalcTypeCalc))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return calcWrap(id, y), true, nil
+	}
+	return x, false, nil
+}
+
 // CalcAt implements CalcAbstract.
 func (x *Calculation) CalcAt(index int) CalcAbstract {
-	self := calcAbstract{calcEngine.Abstract(e.TypeID(CalcTypeCalculation), e.Ptr(x))}
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeCalculation), e.Ptr(x))}
 	return self.CalcAt(index)
 }
 
+// CalcEach implements CalcAbstract.
+func (x *Calculation) CalcEach(yield func(index int, child CalcAbstract) bool) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeCalculation), e.Ptr(x))}
+	self.CalcEach(yield)
+}
+
+// CalcField implements CalcAbstract.
+func (x *Calculation) CalcField(index int) (name string, tag reflect.StructTag) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeCalculation), e.Ptr(x))}
+	return self.CalcField(index)
+}
+
+// CalcPath returns an empty string, because a struct does not record
+// its location.
+func (*Calculation) CalcPath() string { return "" }
+
+// CalcKind returns CalcKindStruct.
+func (*Calculation) CalcKind() CalcKind { return CalcKindStruct }
+
 // CalcCount returns 1.
 func (x *Calculation) CalcCount() int { return 1 }
 
 // CalcTypeID returns CalcTypeCalculation.
 func (*Calculation) CalcTypeID() CalcTypeID { return CalcTypeCalculation }
 
+// CalcTypeName returns "Calculation".
+func (*Calculation) CalcTypeName() string { return "Calculation" }
+
 // WalkCalc visits the receiver with the provided callback.
 func (x *Calculation) WalkCalc(fn CalcWalkerFn) (_ *Calculation, changed bool, err error) {
-	var y e.Ptr
-	_, y, changed, err = calcEngine.Execute(fn, e.TypeID(CalcTypeCalculation), e.Ptr(x), e.TypeID(CalcTypeCalculation))
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	// The scope runs deferred functions even if fn panics.
+	scope := e.AcquireScope()
+	defer scope.Release()
+	x, changed, _, err = calcInlineCalculation(scope, fn, x)
+	if err = scope.Close(err); err != nil {
+		return nil, false, err
+	}
+	return x, changed, nil
+}
+
+// WalkCalcFrom is equivalent to WalkCalc, but the receiver may be
+// replaced by any Calc, rather than only by a *Calculation.
+func (x *Calculation) WalkCalcFrom(fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, y, changed, err := calcEngine().Execute(fn, e.TypeID(CalcTypeCalculation), e.Ptr(x), e.TypeID(CalcTypeCalc))
 	if err != nil {
 		return nil, false, err
 	}
-	return (*Calculation)(y), changed, nil
+	if changed {
+		return calcWrap(id, y), true, nil
+	}
+	return x, false, nil
+}
+
+// calcInlineCalculation visits a Calculation without using the engine's
+// stack. Any decision other than continuing, skipping, or halting is
+// handed off to the engine, as are fields which are not structs.
+// Deferred functions are recorded in the scope.
+func calcInlineCalculation(scope *e.Scope, fn CalcWalkerFn, x *Calculation) (_ *Calculation, changed, halted bool, err error) {
+	d := e.Decision(fn(CalcContext{scope.Context()}, x))
+	skip, halt, ok := d.Inline()
+	if !ok {
+		var y e.Ptr
+		_, y, changed, halted, err = calcEngine().ResumeScope(scope, fn, &d, e.TypeID(CalcTypeCalculation), e.Ptr(x), e.TypeID(CalcTypeCalculation))
+		return (*Calculation)(y), changed, halted, err
+	}
+	if skip || halt {
+		return x, false, halt, nil
+	}
+	next := x
+	if x.Expr != nil {
+		_, y, dirty, stop, err := calcEngine().ResumeScope(scope, fn, nil, e.TypeID(CalcTypeExpr), e.Ptr(&x.Expr), e.TypeID(CalcTypeExpr))
+		if err != nil {
+			return nil, false, false, err
+		}
+		if dirty {
+			if !changed {
+				cp := *x
+				next, changed = &cp, true
+			}
+			next.Expr = *(*Expr)(y)
+		}
+		if stop {
+			return next, changed, true, nil
+		}
+	}
+	return next, changed, false, nil
 }
 
 // CalcAt implements CalcAbstract.
 func (x *Func) CalcAt(index int) CalcAbstract {
-	self := calcAbstract{calcEngine.Abstract(e.TypeID(CalcTypeFunc), e.Ptr(x))}
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeFunc), e.Ptr(x))}
 	return self.CalcAt(index)
 }
 
+// CalcEach implements CalcAbstract.
+func (x *Func) CalcEach(yield func(index int, child CalcAbstract) bool) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeFunc), e.Ptr(x))}
+	self.CalcEach(yield)
+}
+
+// CalcField implements CalcAbstract.
+func (x *Func) CalcField(index int) (name string, tag reflect.StructTag) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeFunc), e.Ptr(x))}
+	return self.CalcField(index)
+}
+
+// CalcPath returns an empty string, because a struct does not record
+// its location.
+func (*Func) CalcPath() string { return "" }
+
+// CalcKind returns CalcKindStruct.
+func (*Func) CalcKind() CalcKind { return CalcKindStruct }
+
 // CalcCount returns 1.
 func (x *Func) CalcCount() int { return 1 }
 
 // CalcTypeID returns CalcTypeFunc.
 func (*Func) CalcTypeID() CalcTypeID { return CalcTypeFunc }
 
+// CalcTypeName returns "Func".
+func (*Func) CalcTypeName() string { return "Func" }
+
 // WalkCalc visits the receiver with the provided callback.
 func (x *Func) WalkCalc(fn CalcWalkerFn) (_ *Func, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
 	var y e.Ptr
-	_, y, changed, err = calcEngine.Execute(fn, e.TypeID(CalcTypeFunc), e.Ptr(x), e.TypeID(CalcTypeFunc))
+	_, y, changed, err = calcEngine().Execute(fn, e.TypeID(CalcTypeFunc), e.Ptr(x), e.TypeID(CalcTypeFunc))
 	if err != nil {
 		return nil, false, err
 	}
 	return (*Func)(y), changed, nil
 }
 
+// WalkCalcFrom is equivalent to WalkCalc, but the receiver may be
+// replaced by any Calc, rather than only by a *Func.
+func (x *Func) WalkCalcFrom(fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, y, changed, err := calcEngine().Execute(fn, e.TypeID(CalcTypeFunc), e.Ptr(x), e.TypeID(CalcTypeCalc))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return calcWrap(id, y), true, nil
+	}
+	return x, false, nil
+}
+
+// CalcAt implements CalcAbstract.
+func (x *Let) CalcAt(index int) CalcAbstract {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeLet), e.Ptr(x))}
+	return self.CalcAt(index)
+}
+
+// CalcEach implements CalcAbstract.
+func (x *Let) CalcEach(yield func(index int, child CalcAbstract) bool) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeLet), e.Ptr(x))}
+	self.CalcEach(yield)
+}
+
+// CalcField implements CalcAbstract.
+func (x *Let) CalcField(index int) (name string, tag reflect.StructTag) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeLet), e.Ptr(x))}
+	return self.CalcField(index)
+}
+
+// CalcPath returns an empty string, because a struct does not record
+// its location.
+func (*Let) CalcPath() string { return "" }
+
+// CalcKind returns CalcKindStruct.
+func (*Let) CalcKind() CalcKind { return CalcKindStruct }
+
+// CalcCount returns 2.
+func (x *Let) CalcCount() int { return 2 }
+
+// CalcTypeID returns CalcTypeLet.
+func (*Let) CalcTypeID() CalcTypeID { return CalcTypeLet }
+
+// CalcTypeName returns "Let".
+func (*Let) CalcTypeName() string { return "Let" }
+
+// WalkCalc visits the receiver with the provided callback.
+func (x *Let) WalkCalc(fn CalcWalkerFn) (_ *Let, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	var y e.Ptr
+	_, y, changed, err = calcEngine().Execute(fn, e.TypeID(CalcTypeLet), e.Ptr(x), e.TypeID(CalcTypeLet))
+	if err != nil {
+		return nil, false, err
+	}
+	return (*Let)(y), changed, nil
+}
+
+// WalkCalcFrom is equivalent to WalkCalc, but the receiver may be
+// replaced by any Calc, rather than only by a *Let.
+func (x *Let) WalkCalcFrom(fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, y, changed, err := calcEngine().Execute(fn, e.TypeID(CalcTypeLet), e.Ptr(x), e.TypeID(CalcTypeCalc))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return calcWrap(id, y), true, nil
+	}
+	return x, false, nil
+}
+
 // CalcAt implements CalcAbstract.
 func (x *Scalar) CalcAt(index int) CalcAbstract {
-	self := calcAbstract{calcEngine.Abstract(e.TypeID(CalcTypeScalar), e.Ptr(x))}
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeScalar), e.Ptr(x))}
 	return self.CalcAt(index)
 }
 
+// CalcEach implements CalcAbstract.
+func (x *Scalar) CalcEach(yield func(index int, child CalcAbstract) bool) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeScalar), e.Ptr(x))}
+	self.CalcEach(yield)
+}
+
+// CalcField implements CalcAbstract.
+func (x *Scalar) CalcField(index int) (name string, tag reflect.StructTag) {
+	self := calcAbstract{calcEngine().Abstract(e.TypeID(CalcTypeScalar), e.Ptr(x))}
+	return self.CalcField(index)
+}
+
+// CalcPath returns an empty string, because a struct does not record
+// its location.
+func (*Scalar) CalcPath() string { return "" }
+
+// CalcKind returns CalcKindStruct.
+func (*Scalar) CalcKind() CalcKind { return CalcKindStruct }
+
 // CalcCount returns 0.
 func (x *Scalar) CalcCount() int { return 0 }
 
 // CalcTypeID returns CalcTypeScalar.
 func (*Scalar) CalcTypeID() CalcTypeID { return CalcTypeScalar }
 
+// CalcTypeName returns "Scalar".
+func (*Scalar) CalcTypeName() string { return "Scalar" }
+
 // WalkCalc visits the receiver with the provided callback.
 func (x *Scalar) WalkCalc(fn CalcWalkerFn) (_ *Scalar, changed bool, err error) {
-	var y e.Ptr
-	_, y, changed, err = calcEngine.Execute(fn, e.TypeID(CalcTypeScalar), e.Ptr(x), e.TypeID(CalcTypeScalar))
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	// The scope runs deferred functions even if fn panics.
+	scope := e.AcquireScope()
+	defer scope.Release()
+	x, changed, _, err = calcInlineScalar(scope, fn, x)
+	if err = scope.Close(err); err != nil {
+		return nil, false, err
+	}
+	return x, changed, nil
+}
+
+// WalkCalcFrom is equivalent to WalkCalc, but the receiver may be
+// replaced by any Calc, rather than only by a *Scalar.
+func (x *Scalar) WalkCalcFrom(fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, y, changed, err := calcEngine().Execute(fn, e.TypeID(CalcTypeScalar), e.Ptr(x), e.TypeID(CalcTypeCalc))
 	if err != nil {
 		return nil, false, err
 	}
-	return (*Scalar)(y), changed, nil
+	if changed {
+		return calcWrap(id, y), true, nil
+	}
+	return x, false, nil
+}
+
+// calcInlineScalar visits a Scalar without using the engine's
+// stack. Any decision other than continuing, skipping, or halting is
+// handed off to the engine, as are fields which are not structs.
+// Deferred functions are recorded in the scope.
+func calcInlineScalar(scope *e.Scope, fn CalcWalkerFn, x *Scalar) (_ *Scalar, changed, halted bool, err error) {
+	d := e.Decision(fn(CalcContext{scope.Context()}, x))
+	skip, halt, ok := d.Inline()
+	if !ok {
+		var y e.Ptr
+		_, y, changed, halted, err = calcEngine().ResumeScope(scope, fn, &d, e.TypeID(CalcTypeScalar), e.Ptr(x), e.TypeID(CalcTypeScalar))
+		return (*Scalar)(y), changed, halted, err
+	}
+	if skip || halt {
+		return x, false, halt, nil
+	}
+	return x, false, false, nil
 }
 
-// WalkCalc visits the receiver with the provided callback.
+// ErrNilCalc is returned when a nil Calc is walked.
+var ErrNilCalc = e.ErrNilRoot
+
+// ErrMaxDepthCalc is wrapped by the error returned from
+// CalcWalkOptions.WalkCalc when MaxDepth is exceeded.
+var ErrMaxDepthCalc = e.ErrMaxDepth
+
+// WalkCalc visits the receiver with the provided callback. It
+// returns ErrNilCalc if x is nil.
 func WalkCalc(x Calc, fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, ptr := calcIdentify(x)
+	id, ptr, changed, err = calcEngine().Execute(fn, id, ptr, e.TypeID(CalcTypeCalc))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return calcWrap(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// WalkCalcAbstract visits the struct or slice which a refers to,
+// such as a value returned from CalcAt, with the provided
+// callback. Paths are relative to that value, which may only be
+// replaced by one of the same type. If the value is changed, a
+// CalcAbstract for the new value is returned, and the caller may
+// place it into its own copy of the enclosing values. It returns
+// ErrNilCalc if a is nil.
+func WalkCalcAbstract(a CalcAbstract, fn CalcWalkerFn) (_ CalcAbstract, changed bool, err error) {
+	var impl *e.Abstract
+	switch t := a.(type) {
+	case *calcAbstract:
+		impl = t.delegate
+	case *BinaryOp:
+		if t != nil {
+			impl = calcEngine().Abstract(e.TypeID(CalcTypeBinaryOp), e.Ptr(t))
+		}
+	case *Calculation:
+		if t != nil {
+			impl = calcEngine().Abstract(e.TypeID(CalcTypeCalculation), e.Ptr(t))
+		}
+	case *Func:
+		if t != nil {
+			impl = calcEngine().Abstract(e.TypeID(CalcTypeFunc), e.Ptr(t))
+		}
+	case *Let:
+		if t != nil {
+			impl = calcEngine().Abstract(e.TypeID(CalcTypeLet), e.Ptr(t))
+		}
+	case *Scalar:
+		if t != nil {
+			impl = calcEngine().Abstract(e.TypeID(CalcTypeScalar), e.Ptr(t))
+		}
+	}
+	if impl == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, ptr, changed, err := calcEngine().Execute(fn, impl.TypeID(), impl.Ptr(), impl.TypeID())
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return calcAbstractOf(calcEngine().Abstract(id, ptr), nil), true, nil
+	}
+	return a, false, nil
+}
+
+// WalkCalcAll visits x once, calling each of the walkers for every
+// value, as though each walker had walked x by itself. A walker's
+// decisions affect only that walker: if it skips a value, it does not
+// see the value's fields, and if it halts, it sees no further values.
+// The walk stops at the first error. The walkers may not replace
+// values, or return actions or interceptors. It returns ErrNilCalc if
+// x is nil.
+func WalkCalcAll(x Calc, fns ...CalcWalkerFn) error {
+	if x == nil {
+		return ErrNilCalc
+	}
+	f := e.NewFusion(len(fns))
+	post := func(ctx CalcContext, x Calc) CalcDecision {
+		for idx, fn, ok := f.NextPost(); ok; idx, fn, ok = f.NextPost() {
+			if err := f.Decide(idx, e.Decision(fn.(CalcWalkerFn)(ctx, x))); err != nil {
+				return ctx.Error(err)
+			}
+		}
+		return CalcDecision(f.Exit())
+	}
+	id, ptr := calcIdentify(x)
+	_, _, _, err := calcEngine().Execute(CalcWalkerFn(func(ctx CalcContext, x Calc) CalcDecision {
+		f.Enter()
+		for idx, fn := range fns {
+			if !f.Active(idx) {
+				continue
+			}
+			if err := f.Decide(idx, e.Decision(fn(ctx, x))); err != nil {
+				return ctx.Error(err)
+			}
+		}
+		return CalcDecision(f.Next()).Post(post)
+	}), id, ptr, e.TypeID(CalcTypeCalc))
+	return err
+}
+
+// CalcArena allocates the values which are cloned by its WalkCalc
+// method in batches, which reduces the number of allocations made by
+// large rewrites. The zero value is ready to use. An CalcArena is
+// not safe for concurrent use.
+type CalcArena struct {
+	impl e.Arena
+}
+
+// Release allows the memory used by the values returned from
+// WalkCalc to be reused. Those values must not be used after calling
+// Release.
+func (a *CalcArena) Release() {
+	a.impl.Release()
+}
+
+// WalkCalc is equivalent to the top-level WalkCalc function, but
+// any values which are cloned are allocated from the arena.
+func (a *CalcArena) WalkCalc(x Calc, fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, ptr := calcIdentify(x)
+	id, ptr, changed, err = calcEngine().ExecuteArena(&a.impl, fn, id, ptr, e.TypeID(CalcTypeCalc))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return calcWrap(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// CalcStack holds the working state of its WalkCalc method, and
+// may be reused across walks, instead of using the shared pool of
+// stacks. The zero value is ready to use. A CalcStack is not safe
+// for concurrent use.
+type CalcStack struct {
+	impl e.Stack
+}
+
+// Grow preallocates space for walks of the given depth.
+func (s *CalcStack) Grow(depth int) {
+	s.impl.Grow(depth)
+}
+
+// WalkCalc is equivalent to the top-level WalkCalc function, but
+// uses the receiver to hold its working state.
+func (s *CalcStack) WalkCalc(x Calc, fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
+	id, ptr := calcIdentify(x)
+	id, ptr, changed, err = calcEngine().ExecuteWith(&s.impl, fn, id, ptr, e.TypeID(CalcTypeCalc))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return calcWrap(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// CalcWalkOptions controls a single call to its WalkCalc method.
+// The zero value is equivalent to the top-level WalkCalc function.
+type CalcWalkOptions struct {
+	// Recover converts a panic in a CalcWalkerFn, post-visit
+	// function, or action into a *CalcPanicError, which describes the
+	// location of the value being visited.
+	Recover bool
+	// MaxDepth is optional and limits the depth of the walk, which
+	// grows by one for each struct, pointer, slice, interface, or set of
+	// actions which is entered. A walk which would exceed the limit
+	// returns a *CalcPathError which wraps ErrMaxDepthCalc.
+	MaxDepth int
+	// CheckRaces panics if a walk which replaces a value overlaps
+	// another walk of the same root which also sets CheckRaces. If the
+	// race detector is enabled, the walk also reports reads of the
+	// values it visits, so that in-place mutations by other goroutines
+	// are flagged.
+	CheckRaces bool
+	// VisitTypedNils calls the CalcWalkerFn with the nil pointer held
+	// by a "typed nil" interface field, so that it may be rejected or
+	// replaced. The fields of a typed nil are never visited. Otherwise,
+	// typed nils are skipped.
+	VisitTypedNils bool
+	// NormalizeTypedNils sets an interface field which holds a nil
+	// pointer to nil, unless the CalcWalkerFn replaces the typed nil
+	// with another value.
+	NormalizeTypedNils bool
+	// Counts is optional and is incremented once for each struct which
+	// is visited, keyed by the struct's type. It can be reused across
+	// walks to profile which types dominate a traversal.
+	Counts map[CalcTypeID]int
+	// Only is optional and restricts the walk to structs of the given
+	// types. The CalcWalkerFn is called only for those structs, and
+	// values which cannot contain one of them, based on the types that
+	// were generated, are not entered.
+	Only []CalcTypeID
+	// Tracing is optional and opens spans around the walk.
+	Tracing *e.Tracing
+	// Context is optional and is passed to Tracing.Tracer when the span
+	// of the walk is opened. It does not cancel the walk.
+	Context context.Context
+}
+
+// CalcPathError records the location of the value which caused an
+// error. Errors returned from a CalcWalkerFn, post-visit function, or
+// action are wrapped in a *CalcPathError.
+type CalcPathError = e.PathError
+
+// CalcPanicError is returned from CalcWalkOptions.WalkCalc when
+// Recover is set and a callback panics.
+type CalcPanicError = e.PanicError
+
+// WalkCalc is equivalent to the top-level WalkCalc function, but
+// applies the options.
+func (o CalcWalkOptions) WalkCalc(x Calc, fn CalcWalkerFn) (_ Calc, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilCalc
+	}
 	id, ptr := calcIdentify(x)
-	id, ptr, changed, err = calcEngine.Execute(fn, id, ptr, e.TypeID(CalcTypeCalc))
+	var counts map[e.TypeID]int
+	if o.Counts != nil {
+		counts = make(map[e.TypeID]int)
+		defer func() {
+			for id, count := range counts {
+				o.Counts[CalcTypeID(id)] += count
+			}
+		}()
+	}
+	var only []e.TypeID
+	for _, id := range o.Only {
+		only = append(only, e.TypeID(id))
+	}
+	id, ptr, changed, err = calcEngine().ExecuteOptions(e.Options{
+		CheckRaces:         o.CheckRaces,
+		Context:            o.Context,
+		Counts:             counts,
+		MaxDepth:           o.MaxDepth,
+		NormalizeTypedNils: o.NormalizeTypedNils,
+		Only:               only,
+		Recover:            o.Recover,
+		Tracing:            o.Tracing,
+		VisitTypedNils:     o.VisitTypedNils,
+	}, fn, id, ptr, e.TypeID(CalcTypeCalc))
 	if err != nil {
 		return nil, false, err
 	}
@@ -339,6 +972,337 @@ func WalkCalc(x Calc, fn CalcWalkerFn) (_ Calc, changed bool, err error) {
 	return x, false, nil
 }
 
+// WalkCalcAs is equivalent to WalkCalc, but calls fn only for the
+// values of type T and continues past all others. T is usually a
+// pointer to a struct; a struct type also matches the pointers to it
+// which the walk provides. Unless T is an interface, the walk is
+// restricted as if by CalcWalkOptions.Only, so values which cannot
+// contain a T are not entered.
+func WalkCalcAs[T Calc](x Calc, fn func(ctx CalcContext, x T) CalcDecision) (_ Calc, changed bool, err error) {
+	var opts CalcWalkOptions
+	var zero T
+	if any(zero) != nil {
+		id, _ := calcIdentify(zero)
+		opts.Only = []CalcTypeID{CalcTypeID(id)}
+	}
+	return opts.WalkCalc(x, func(ctx CalcContext, x Calc) CalcDecision {
+		if t, ok := x.(T); ok {
+			return fn(ctx, t)
+		}
+		if t, ok := any(x).(*T); ok {
+			return fn(ctx, *t)
+		}
+		return ctx.Continue()
+	})
+}
+
+// CalcAccumulator holds the results of a walk by WalkCalcAccum, so
+// that a walker function may be reused without capturing its results.
+type CalcAccumulator[A any] struct {
+	// Value is updated by the walker.
+	Value A
+}
+
+// NewCalcAccumulator returns an accumulator which holds the
+// zero value of A.
+func NewCalcAccumulator[A any]() *CalcAccumulator[A] {
+	return &CalcAccumulator[A]{}
+}
+
+// CalcAccumulatorFn is a CalcWalkerFn which also receives the
+// accumulator passed to WalkCalcAccum.
+type CalcAccumulatorFn[A any] func(ctx CalcContext, x Calc, acc *CalcAccumulator[A]) CalcDecision
+
+// WalkCalcAccum is equivalent to WalkCalc, but passes acc to fn,
+// which may record the results of the walk in acc.Value.
+func WalkCalcAccum[A any](x Calc, acc *CalcAccumulator[A], fn CalcAccumulatorFn[A]) (_ Calc, changed bool, err error) {
+	return WalkCalc(x, func(ctx CalcContext, x Calc) CalcDecision {
+		return fn(ctx, x, acc)
+	})
+}
+
+// CompareCalc reports the number of structs reachable from after,
+// which is typically the result of calling WalkCalc on before, that
+// were cloned or replaced, and the number which are shared with before.
+func CompareCalc(before, after Calc) (cloned, shared int) {
+	var beforeID, afterID e.TypeID
+	var beforePtr, afterPtr e.Ptr
+	if before != nil {
+		beforeID, beforePtr = calcIdentify(before)
+	}
+	if after != nil {
+		afterID, afterPtr = calcIdentify(after)
+	}
+	s := calcEngine().Sharing(beforeID, beforePtr, afterID, afterPtr)
+	return s.Cloned, s.Shared
+}
+
+// CalcZipFn is called by ZipCalc with each pair of values which
+// occupy the same location in two trees. If only one of the trees has a
+// value at the location, the other value is nil. The function returns
+// true to visit the fields of the pair, which only happens if both
+// values are present and have the same type.
+type CalcZipFn func(path string, a, b Calc) (descend bool, err error)
+
+// ZipCalc visits a and b in lockstep, in the same order as WalkCalc,
+// and calls fn with each pair of corresponding values. Elements of
+// slices are paired by index. The path of each pair uses the same
+// syntax as CalcContext.Path. It returns the first error returned by
+// fn.
+func ZipCalc(a, b Calc, fn CalcZipFn) error {
+	var aID, bID e.TypeID
+	var aPtr, bPtr e.Ptr
+	if a != nil {
+		aID, aPtr = calcIdentify(a)
+	}
+	if b != nil {
+		bID, bPtr = calcIdentify(b)
+	}
+	return calcEngine().Zip(aID, aPtr, bID, bPtr, func(path string, aID e.TypeID, aPtr e.Ptr, bID e.TypeID, bPtr e.Ptr) (bool, error) {
+		var x, y Calc
+		if aPtr != nil {
+			x = calcWrap(aID, aPtr)
+		}
+		if bPtr != nil {
+			y = calcWrap(bID, bPtr)
+		}
+		return fn(path, x, y)
+	})
+}
+
+// DiffCalc returns the paths at which a and b differ: where only one
+// of them has a value, where the values have different types, or where
+// the values have different basic fields. The fields of a value which
+// differs are still compared.
+func DiffCalc(a, b Calc) []string {
+	var ret []string
+	_ = ZipCalc(a, b, func(path string, x, y Calc) (bool, error) {
+		if x == nil || y == nil {
+			ret = append(ret, path)
+			return false, nil
+		}
+		xID, xPtr := calcIdentify(x)
+		yID, yPtr := calcIdentify(y)
+		if xID != yID || !calcEngine().ShallowEqual(xID, xPtr, yPtr) {
+			ret = append(ret, path)
+		}
+		return true, nil
+	})
+	return ret
+}
+
+// EqualCalc reports whether DiffCalc would find no differences
+// between a and b.
+func EqualCalc(a, b Calc) bool {
+	return len(DiffCalc(a, b)) == 0
+}
+
+// CalcConflict describes a location which was changed in different
+// ways by both sides of a merge.
+type CalcConflict = e.Conflict
+
+// MergeCalc performs a three-way merge of left and right, which were
+// both derived from base. A value which is unchanged on one side takes
+// the changes made by the other side. Values which are changed on both
+// sides are merged field by field, as are slices which have the same
+// length in all three trees. The paths of any other values or basic
+// fields which were changed differently by both sides are reported as
+// conflicts, and those values are taken from left. The trees are not
+// modified, and unchanged values are shared with the result. An error
+// is returned if a cycle would need to be merged. As in EqualCalc,
+// only the visitable fields and the exported fields of basic types are
+// compared, so a change to any other field is kept only if its struct
+// is taken from the side which made it.
+func MergeCalc(base, left, right Calc) (_ Calc, conflicts []CalcConflict, err error) {
+	var out Calc
+	conflicts, err = calcEngine().Merge(e.TypeID(CalcTypeCalc), e.Ptr(&base), e.Ptr(&left), e.Ptr(&right), e.Ptr(&out))
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, conflicts, nil
+}
+
+// EncodeCalc appends a compact, binary encoding of x to buf, which
+// may be decoded by DecodeCalc. Only the visitable fields and the
+// exported fields of basic types are encoded.
+func EncodeCalc(buf []byte, x Calc) ([]byte, error) {
+	return calcEngine().Encode(buf, e.TypeID(CalcTypeCalc), e.Ptr(&x))
+}
+
+// DecodeCalc decodes a value which was encoded by EncodeCalc.
+func DecodeCalc(data []byte) (x Calc, err error) {
+	err = calcEngine().Decode(data, e.TypeID(CalcTypeCalc), e.Ptr(&x))
+	return x, err
+}
+
+// EncodeCalcMap converts x to a tree of maps, slices, and basic
+// values, which may be decoded by DecodeCalcMap. Each struct is
+// a map which contains the name of its type under the "$type" key, its
+// visitable fields, and its exported fields of basic types. A nil value
+// is converted to a nil map.
+func EncodeCalcMap(x Calc) (map[string]interface{}, error) {
+	m, err := calcEngine().ToMap(e.TypeID(CalcTypeCalc), e.Ptr(&x))
+	if m == nil {
+		return nil, err
+	}
+	return m.(map[string]interface{}), err
+}
+
+// DecodeCalcMap constructs a value from a tree of maps, such as
+// one produced by EncodeCalcMap or by decoding JSON into an
+// interface{}.
+func DecodeCalcMap(m map[string]interface{}) (x Calc, err error) {
+	if m == nil {
+		return nil, nil
+	}
+	err = calcEngine().FromMap(m, e.TypeID(CalcTypeCalc), e.Ptr(&x))
+	return x, err
+}
+
+// DumpCalc returns a human-readable, s-expression form of x, which
+// may be read by ParseCalc. The output is stable, which makes it
+// suitable for golden files.
+func DumpCalc(x Calc) (string, error) {
+	return calcEngine().Dump(e.TypeID(CalcTypeCalc), e.Ptr(&x))
+}
+
+// ParseCalc constructs a value from its s-expression form, such as
+// one produced by DumpCalc.
+func ParseCalc(text string) (x Calc, err error) {
+	err = calcEngine().Parse(text, e.TypeID(CalcTypeCalc), e.Ptr(&x))
+	return x, err
+}
+
+// CalcMatch is a value which was selected by QueryCalc.
+type CalcMatch struct {
+	// Path is the location of the value, e.g. "Parent/Slice[1]/Field".
+	Path  string
+	Value CalcAbstract
+}
+
+// QueryCalc returns the values within x which are selected by a
+// path expression, such as "Parent/Slice[*]/Field". Each step of the
+// expression is a field name, "*" to select any field or element, or
+// "**" to select a value and all of its descendants, and may be
+// followed by "[n]" or "[*]" to select elements of a slice. The first
+// step must be "*", "**", or the name of the type of x.
+func QueryCalc(x Calc, expr string) ([]CalcMatch, error) {
+	q, err := e.ParseQuery(expr)
+	if err != nil || x == nil {
+		return nil, err
+	}
+	id, ptr := calcIdentify(x)
+	matches := q.Select(calcEngine().Abstract(id, ptr))
+	ret := make([]CalcMatch, len(matches))
+	for i, m := range matches {
+		ret[i] = CalcMatch{Path: m.Path, Value: calcAbstractOf(m.Value, nil)}
+	}
+	return ret, nil
+}
+
+// CalcRandomConfig controls the shape of the trees built by
+// RandomCalc.
+type CalcRandomConfig = e.RandomConfig
+
+// RandomCalc builds a random tree for property-based testing of
+// walkers and rewrites. The tree is determined by the values from r,
+// so a failing case may be reproduced from its seed.
+func RandomCalc(r *rand.Rand, cfg CalcRandomConfig) (x Calc) {
+	calcEngine().Random(r, cfg, e.TypeID(CalcTypeCalc), e.Ptr(&x))
+	return x
+}
+
+// ShrinkCalc returns a minimal value for which fails returns true,
+// such as the smallest tree which reproduces the failure of a property
+// test that uses RandomCalc. Elements of slices are removed and
+// fields are reset to their zero values, while the failure is
+// preserved. An error is returned if x does not fail.
+func ShrinkCalc(x Calc, fails func(Calc) bool) (Calc, error) {
+	err := calcEngine().Shrink(e.TypeID(CalcTypeCalc), e.Ptr(&x), func(p e.Ptr) bool {
+		return fails(*(*Calc)(p))
+	})
+	return x, err
+}
+
+// CalcEventKind distinguishes the events recorded by a CalcRecorder.
+type CalcEventKind int
+
+// These are the kinds of events recorded by a CalcRecorder.
+const (
+	// CalcEventKindPre is recorded before the fields of a value are visited.
+	CalcEventKindPre CalcEventKind = 0
+	// CalcEventKindPost is recorded after the fields of a value are visited.
+	CalcEventKindPost CalcEventKind = 1
+)
+
+// String returns "pre" or "post".
+func (k CalcEventKind) String() string {
+	if k == CalcEventKindPost {
+		return "post"
+	}
+	return "pre"
+}
+
+// CalcEvent records the visitation of a value.
+type CalcEvent struct {
+	Kind CalcEventKind
+	// Path is the location of the value, e.g. "Parent/Slice[1]/Field".
+	Path   string
+	TypeID CalcTypeID
+}
+
+// String returns a description of the event, e.g.
+// "pre Parent/Slice[1]/Field Type".
+func (v CalcEvent) String() string {
+	return fmt.Sprintf("%s %s %s", v.Kind, v.Path, v.TypeID)
+}
+
+// CalcRecorder is a walker which records the order in which values
+// are visited, for use in assertions about traversal behavior. Its
+// Walk method should be passed as the CalcWalkerFn.
+type CalcRecorder struct {
+	Events []CalcEvent
+}
+
+// NewRecordingCalcWalker returns a CalcRecorder which has not recorded
+// any events.
+func NewRecordingCalcWalker() *CalcRecorder {
+	return &CalcRecorder{}
+}
+
+// Walk records a pre-visit event and registers a post-visit function
+// which records a post-visit event. It always continues visitation.
+func (r *CalcRecorder) Walk(ctx CalcContext, x Calc) CalcDecision {
+	r.record(ctx, CalcEventKindPre, x)
+	return ctx.Continue().Post(r.post)
+}
+
+// post is registered by Walk.
+func (r *CalcRecorder) post(ctx CalcContext, x Calc) CalcDecision {
+	r.record(ctx, CalcEventKindPost, x)
+	return ctx.Continue()
+}
+
+// record appends an event.
+func (r *CalcRecorder) record(ctx CalcContext, kind CalcEventKind, x Calc) {
+	id, _ := calcIdentify(x)
+	r.Events = append(r.Events, CalcEvent{Kind: kind, Path: ctx.Path(), TypeID: CalcTypeID(id)})
+}
+
+// Reset discards the recorded events.
+func (r *CalcRecorder) Reset() {
+	r.Events = r.Events[:0]
+}
+
+// Strings returns the String form of each recorded event.
+func (r *CalcRecorder) Strings() []string {
+	ret := make([]string, len(r.Events))
+	for i, v := range r.Events {
+		ret[i] = v.String()
+	}
+	return ret
+}
+
 // ------ Union Support -----
 type Calc interface {
 	CalcAbstract
@@ -349,234 +1313,276 @@ var (
 	_ Calc = &BinaryOp{}
 	_ Calc = &Calculation{}
 	_ Calc = &Func{}
+	_ Calc = &Let{}
 	_ Calc = &Scalar{}
 )
 
 func (*BinaryOp) isCalcType()    {}
 func (*Calculation) isCalcType() {}
 func (*Func) isCalcType()        {}
+func (*Let) isCalcType()         {}
 func (*Scalar) isCalcType()      {} // ------ Type Mapping ------
-var calcEngine = e.New(e.TypeMap{
-	// ------ Structs ------
-	CalcTypeBinaryOp: {
-		Copy: func(dest, from e.Ptr) { *(*BinaryOp)(dest) = *(*BinaryOp)(from) },
-		Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
-			return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*BinaryOp)(x)))
-		},
-		Fields: []e.FieldInfo{
-			{Name: "Left", Offset: unsafe.Offsetof(BinaryOp{}.Left), Target: e.TypeID(CalcTypeExpr)},
-			{Name: "Right", Offset: unsafe.Offsetof(BinaryOp{}.Right), Target: e.TypeID(CalcTypeExpr)},
-		},
-		Name:      "BinaryOp",
-		NewStruct: func() e.Ptr { return e.Ptr(&BinaryOp{}) },
-		SizeOf:    unsafe.Sizeof(BinaryOp{}),
-		Kind:      e.KindStruct,
-		TypeID:    e.TypeID(CalcTypeBinaryOp),
-	},
-	CalcTypeCalculation: {
-		Copy: func(dest, from e.Ptr) { *(*Calculation)(dest) = *(*Calculation)(from) },
-		Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
-			return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*Calculation)(x)))
-		},
-		Fields: []e.FieldInfo{
-			{Name: "Expr", Offset: unsafe.Offsetof(Calculation{}.Expr), Target: e.TypeID(CalcTypeExpr)},
-		},
-		Name:      "Calculation",
-		NewStruct: func() e.Ptr { return e.Ptr(&Calculation{}) },
-		SizeOf:    unsafe.Sizeof(Calculation{}),
-		Kind:      e.KindStruct,
-		TypeID:    e.TypeID(CalcTypeCalculation),
-	},
-	CalcTypeFunc: {
-		Copy: func(dest, from e.Ptr) { *(*Func)(dest) = *(*Func)(from) },
-		Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
-			return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*Func)(x)))
-		},
-		Fields: []e.FieldInfo{
-			{Name: "Args", Offset: unsafe.Offsetof(Func{}.Args), Target: e.TypeID(CalcTypeExprSlice)},
-		},
-		Name:      "Func",
-		NewStruct: func() e.Ptr { return e.Ptr(&Func{}) },
-		SizeOf:    unsafe.Sizeof(Func{}),
-		Kind:      e.KindStruct,
-		TypeID:    e.TypeID(CalcTypeFunc),
-	},
-	CalcTypeScalar: {
-		Copy: func(dest, from e.Ptr) { *(*Scalar)(dest) = *(*Scalar)(from) },
-		Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
-			return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*Scalar)(x)))
-		},
-		Fields:    []e.FieldInfo{},
-		Name:      "Scalar",
-		NewStruct: func() e.Ptr { return e.Ptr(&Scalar{}) },
-		SizeOf:    unsafe.Sizeof(Scalar{}),
-		Kind:      e.KindStruct,
-		TypeID:    e.TypeID(CalcTypeScalar),
-	},
-
-	// ------ Interfaces ------
-	CalcTypeCalc: {
-		Copy: func(dest, from e.Ptr) {
-			*(*Calc)(dest) = *(*Calc)(from)
-		},
-		IntfType: func(x e.Ptr) e.TypeID {
-			d := *(*Calc)(x)
-			switch d.(type) {
-			case *BinaryOp:
-				return e.TypeID(CalcTypeBinaryOp)
-			case *Calculation:
-				return e.TypeID(CalcTypeCalculation)
-			case *Func:
-				return e.TypeID(CalcTypeFunc)
-			case *Scalar:
-				return e.TypeID(CalcTypeScalar)
-			default:
-				return 0
-			}
-		},
-		IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
-			var d Calc
-			switch CalcTypeID(id) {
-			case CalcTypeBinaryOp:
-				d = (*BinaryOp)(x)
-			case CalcTypeBinaryOpPtr:
-				d = *(**BinaryOp)(x)
-			case CalcTypeCalculation:
-				d = (*Calculation)(x)
-			case CalcTypeCalculationPtr:
-				d = *(**Calculation)(x)
-			case CalcTypeFunc:
-				d = (*Func)(x)
-			case CalcTypeFuncPtr:
-				d = *(**Func)(x)
-			case CalcTypeScalar:
-				d = (*Scalar)(x)
-			case CalcTypeScalarPtr:
-				d = *(**Scalar)(x)
-			default:
-				return nil
-			}
-			return e.Ptr(&d)
-		},
-		Kind:   e.KindInterface,
-		Name:   "Calc",
-		SizeOf: unsafe.Sizeof(Calc(nil)),
-		TypeID: e.TypeID(CalcTypeCalc),
-	},
-	CalcTypeExpr: {
-		Copy: func(dest, from e.Ptr) {
-			*(*Expr)(dest) = *(*Expr)(from)
-		},
-		IntfType: func(x e.Ptr) e.TypeID {
-			d := *(*Expr)(x)
-			switch d.(type) {
-			case *BinaryOp:
-				return e.TypeID(CalcTypeBinaryOp)
-			case *Func:
-				return e.TypeID(CalcTypeFunc)
-			case *Scalar:
-				return e.TypeID(CalcTypeScalar)
-			default:
-				return 0
-			}
+var (
+	calcEngineImpl *e.Engine
+	calcEngineOnce sync.Once
+)
+
+// calcEngine returns the engine for the generated types, which is
+// constructed on first use.
+func calcEngine() *e.Engine {
+	calcEngineOnce.Do(func() { calcEngineImpl = e.New(calcTypeMap()) })
+	return calcEngineImpl
+}
+
+// calcTypeMap describes the generated types to the engine.
+func calcTypeMap() e.TypeMap {
+	return e.TypeMap{
+		// ------ Structs ------
+		e.TypeID(CalcTypeBinaryOp): {
+			Copy: func(dest, from e.Ptr) { *(*BinaryOp)(dest) = *(*BinaryOp)(from) },
+			Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+				return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*BinaryOp)(x)))
+			},
+			Fields: []e.FieldInfo{
+				{Name: "lhs", Field: "Left", Offset: unsafe.Offsetof(BinaryOp{}.Left), Tag: "walkabout:\"name=lhs\"", Target: e.TypeID(CalcTypeExpr)},
+				{Name: "rhs", Field: "Right", Offset: unsafe.Offsetof(BinaryOp{}.Right), Tag: "walkabout:\"name=rhs\"", Target: e.TypeID(CalcTypeExpr)},
+			},
+			Name:    "BinaryOp",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			Scalars: []e.ScalarInfo{
+				{Kind: e.ScalarString, Name: "op", Field: "Operator", Offset: unsafe.Offsetof(BinaryOp{}.Operator), Tag: "walkabout:\"name=op\""},
+			},
+			NewStruct: func() e.Ptr { return e.Ptr(&BinaryOp{}) },
+			NewStructs: func(count int) e.Ptr {
+				x := make([]BinaryOp, count)
+				return e.Ptr(&x[0])
+			},
+			Shape:  0x198304de86257811,
+			SizeOf: unsafe.Sizeof(BinaryOp{}),
+			Kind:   e.KindStruct,
+			Type:   reflect.TypeOf(BinaryOp{}),
+			TypeID: e.TypeID(CalcTypeBinaryOp),
 		},
-		IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
-			var d Expr
-			switch CalcTypeID(id) {
-			case CalcTypeBinaryOp:
-				d = (*BinaryOp)(x)
-			case CalcTypeBinaryOpPtr:
-				d = *(**BinaryOp)(x)
-			case CalcTypeFunc:
-				d = (*Func)(x)
-			case CalcTypeFuncPtr:
-				d = *(**Func)(x)
-			case CalcTypeScalar:
-				d = (*Scalar)(x)
-			case CalcTypeScalarPtr:
-				d = *(**Scalar)(x)
-			default:
-				return nil
-			}
-			return e.Ptr(&d)
+		e.TypeID(CalcTypeCalculation): {
+			Copy: func(dest, from e.Ptr) { *(*Calculation)(dest) = *(*Calculation)(from) },
+			Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+				return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*Calculation)(x)))
+			},
+			Fields: []e.FieldInfo{
+				{Name: "Expr", Offset: unsafe.Offsetof(Calculation{}.Expr), Target: e.TypeID(CalcTypeExpr)},
+			},
+			Name:      "Calculation",
+			PkgPath:   "github.com/cockroachdb/walkabout/demo",
+			NewStruct: func() e.Ptr { return e.Ptr(&Calculation{}) },
+			NewStructs: func(count int) e.Ptr {
+				x := make([]Calculation, count)
+				return e.Ptr(&x[0])
+			},
+			Shape:  0x7d8296d0910723e7,
+			SizeOf: unsafe.Sizeof(Calculation{}),
+			Kind:   e.KindStruct,
+			Type:   reflect.TypeOf(Calculation{}),
+			TypeID: e.TypeID(CalcTypeCalculation),
 		},
-		Kind:   e.KindInterface,
-		Name:   "Expr",
-		SizeOf: unsafe.Sizeof(Expr(nil)),
-		TypeID: e.TypeID(CalcTypeExpr),
-	},
-
-	// ------ Pointers ------
-	CalcTypeBinaryOpPtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**BinaryOp)(dest) = *(**BinaryOp)(from)
+		e.TypeID(CalcTypeFunc): {
+			Copy: func(dest, from e.Ptr) { *(*Func)(dest) = *(*Func)(from) },
+			Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+				return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*Func)(x)))
+			},
+			Fields: []e.FieldInfo{
+				{Name: "Args", Offset: unsafe.Offsetof(Func{}.Args), Target: e.TypeID(CalcTypeExprSlice)},
+			},
+			Name:    "Func",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			Scalars: []e.ScalarInfo{
+				{Kind: e.ScalarString, Name: "Fn", Offset: unsafe.Offsetof(Func{}.Fn)},
+			},
+			NewStruct: func() e.Ptr { return e.Ptr(&Func{}) },
+			NewStructs: func(count int) e.Ptr {
+				x := make([]Func, count)
+				return e.Ptr(&x[0])
+			},
+			Shape:  0xadf8a8d800fcde6a,
+			SizeOf: unsafe.Sizeof(Func{}),
+			Kind:   e.KindStruct,
+			Type:   reflect.TypeOf(Func{}),
+			TypeID: e.TypeID(CalcTypeFunc),
 		},
-		Elem:   e.TypeID(CalcTypeBinaryOp),
-		SizeOf: unsafe.Sizeof((*BinaryOp)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(CalcTypeBinaryOpPtr),
-	},
-	CalcTypeCalculationPtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**Calculation)(dest) = *(**Calculation)(from)
+		e.TypeID(CalcTypeLet): {
+			Copy: func(dest, from e.Ptr) { *(*Let)(dest) = *(*Let)(from) },
+			Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+				return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*Let)(x)))
+			},
+			Fields: []e.FieldInfo{
+				{Name: "Value", Offset: unsafe.Offsetof(Let{}.Value), Tag: "walkabout:\"order=-1\"", Target: e.TypeID(CalcTypeExpr)},
+				{Name: "Body", Offset: unsafe.Offsetof(Let{}.Body), Target: e.TypeID(CalcTypeExpr)},
+			},
+			Name:    "Let",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			Scalars: []e.ScalarInfo{
+				{Kind: e.ScalarString, Name: "Name", Offset: unsafe.Offsetof(Let{}.Name)},
+			},
+			NewStruct: func() e.Ptr { return e.Ptr(&Let{}) },
+			NewStructs: func(count int) e.Ptr {
+				x := make([]Let, count)
+				return e.Ptr(&x[0])
+			},
+			Shape:  0x32499adf5ec83968,
+			SizeOf: unsafe.Sizeof(Let{}),
+			Kind:   e.KindStruct,
+			Type:   reflect.TypeOf(Let{}),
+			TypeID: e.TypeID(CalcTypeLet),
 		},
-		Elem:   e.TypeID(CalcTypeCalculation),
-		SizeOf: unsafe.Sizeof((*Calculation)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(CalcTypeCalculationPtr),
-	},
-	CalcTypeFuncPtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**Func)(dest) = *(**Func)(from)
+		e.TypeID(CalcTypeScalar): {
+			Copy: func(dest, from e.Ptr) { *(*Scalar)(dest) = *(*Scalar)(from) },
+			Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+				return e.Decision(fn.(CalcWalkerFn)(CalcContext{impl}, (*Scalar)(x)))
+			},
+			Fields:    []e.FieldInfo{},
+			Name:      "Scalar",
+			PkgPath:   "github.com/cockroachdb/walkabout/demo",
+			NewStruct: func() e.Ptr { return e.Ptr(&Scalar{}) },
+			NewStructs: func(count int) e.Ptr {
+				x := make([]Scalar, count)
+				return e.Ptr(&x[0])
+			},
+			Shape:  0x6d76c1d267f15b97,
+			SizeOf: unsafe.Sizeof(Scalar{}),
+			Kind:   e.KindStruct,
+			Type:   reflect.TypeOf(Scalar{}),
+			TypeID: e.TypeID(CalcTypeScalar),
 		},
-		Elem:   e.TypeID(CalcTypeFunc),
-		SizeOf: unsafe.Sizeof((*Func)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(CalcTypeFuncPtr),
-	},
-	CalcTypeScalarPtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**Scalar)(dest) = *(**Scalar)(from)
+
+		// ------ Interfaces ------
+		e.TypeID(CalcTypeCalc): {
+			Copy: func(dest, from e.Ptr) {
+				*(*Calc)(dest) = *(*Calc)(from)
+			},
+			IntfType: func(x e.Ptr) e.TypeID {
+				d := *(*Calc)(x)
+				switch d.(type) {
+				case *BinaryOp:
+					return e.TypeID(CalcTypeBinaryOp)
+				case *Calculation:
+					return e.TypeID(CalcTypeCalculation)
+				case *Func:
+					return e.TypeID(CalcTypeFunc)
+				case *Let:
+					return e.TypeID(CalcTypeLet)
+				case *Scalar:
+					return e.TypeID(CalcTypeScalar)
+				default:
+					return 0
+				}
+			},
+			Impls: []e.TypeID{e.TypeID(CalcTypeBinaryOp), e.TypeID(CalcTypeCalculation), e.TypeID(CalcTypeFunc), e.TypeID(CalcTypeLet), e.TypeID(CalcTypeScalar)},
+			IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
+				var d Calc
+				switch id {
+				case e.TypeID(CalcTypeBinaryOp):
+					d = (*BinaryOp)(x)
+				case e.TypeID(CalcTypeCalculation):
+					d = (*Calculation)(x)
+				case e.TypeID(CalcTypeFunc):
+					d = (*Func)(x)
+				case e.TypeID(CalcTypeLet):
+					d = (*Let)(x)
+				case e.TypeID(CalcTypeScalar):
+					d = (*Scalar)(x)
+				default:
+					return nil
+				}
+				return e.Ptr(&d)
+			},
+			Kind:    e.KindInterface,
+			Name:    "Calc",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			SizeOf:  unsafe.Sizeof(Calc(nil)),
+			TypeID:  e.TypeID(CalcTypeCalc),
 		},
-		Elem:   e.TypeID(CalcTypeScalar),
-		SizeOf: unsafe.Sizeof((*Scalar)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(CalcTypeScalarPtr),
-	},
-
-	// ------ Slices ------
-	CalcTypeExprSlice: {
-		Copy: func(dest, from e.Ptr) {
-			*(*[]Expr)(dest) = *(*[]Expr)(from)
+		e.TypeID(CalcTypeExpr): {
+			Copy: func(dest, from e.Ptr) {
+				*(*Expr)(dest) = *(*Expr)(from)
+			},
+			IntfType: func(x e.Ptr) e.TypeID {
+				d := *(*Expr)(x)
+				switch d.(type) {
+				case *BinaryOp:
+					return e.TypeID(CalcTypeBinaryOp)
+				case *Func:
+					return e.TypeID(CalcTypeFunc)
+				case *Let:
+					return e.TypeID(CalcTypeLet)
+				case *Scalar:
+					return e.TypeID(CalcTypeScalar)
+				default:
+					return 0
+				}
+			},
+			Impls: []e.TypeID{e.TypeID(CalcTypeBinaryOp), e.TypeID(CalcTypeFunc), e.TypeID(CalcTypeLet), e.TypeID(CalcTypeScalar)},
+			IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
+				var d Expr
+				switch id {
+				case e.TypeID(CalcTypeBinaryOp):
+					d = (*BinaryOp)(x)
+				case e.TypeID(CalcTypeFunc):
+					d = (*Func)(x)
+				case e.TypeID(CalcTypeLet):
+					d = (*Let)(x)
+				case e.TypeID(CalcTypeScalar):
+					d = (*Scalar)(x)
+				default:
+					return nil
+				}
+				return e.Ptr(&d)
+			},
+			Kind:    e.KindInterface,
+			Name:    "Expr",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			SizeOf:  unsafe.Sizeof(Expr(nil)),
+			TypeID:  e.TypeID(CalcTypeExpr),
 		},
-		Elem: e.TypeID(CalcTypeExpr),
-		Kind: e.KindSlice,
-		NewSlice: func(size int) e.Ptr {
-			x := make([]Expr, size)
-			return e.Ptr(&x)
+
+		// ------ Pointers ------
+
+		// ------ Slices ------
+		e.TypeID(CalcTypeExprSlice): {
+			Copy: func(dest, from e.Ptr) {
+				*(*[]Expr)(dest) = *(*[]Expr)(from)
+			},
+			Elem: e.TypeID(CalcTypeExpr),
+			Kind: e.KindSlice,
+			NewSlice: func(size int) e.Ptr {
+				x := make([]Expr, size)
+				return e.Ptr(&x)
+			},
+			SizeOf: unsafe.Sizeof(([]Expr)(nil)),
+			TypeID: e.TypeID(CalcTypeExprSlice),
 		},
-		SizeOf: unsafe.Sizeof(([]Expr)(nil)),
-		TypeID: e.TypeID(CalcTypeExprSlice),
-	},
-})
+	}
+}
 
 // These are lightweight type tokens.
 const (
 	_ CalcTypeID = iota
 	CalcTypeBinaryOp
-	CalcTypeBinaryOpPtr
 	CalcTypeCalc
 	CalcTypeCalculation
-	CalcTypeCalculationPtr
 	CalcTypeExpr
 	CalcTypeExprSlice
 	CalcTypeFunc
-	CalcTypeFuncPtr
+	CalcTypeLet
 	CalcTypeScalar
-	CalcTypeScalarPtr
 )
 
 // String is for debugging use only.
 func (t CalcTypeID) String() string {
-	return calcEngine.Stringify(e.TypeID(t))
+	return calcEngine().Stringify(e.TypeID(t))
+}
+
+// QualifiedString returns the name of the type, including the import
+// path of the package which declares each named type. It is for
+// debugging use only.
+func (t CalcTypeID) QualifiedString() string {
+	return calcEngine().StringifyQualified(e.TypeID(t))
 }
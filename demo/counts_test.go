@@ -0,0 +1,66 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWalkCounts verifies that WalkOptions.Counts tallies each struct
+// that the walker sees, and accumulates across walks.
+func TestWalkCounts(t *testing.T) {
+	a := assert.New(t)
+	x, _ := l.NewContainer(true)
+
+	var r l.TargetRecorder
+	_, _, err := x.WalkTarget(r.Walk)
+	a.NoError(err)
+	expected := make(map[l.TargetTypeID]int)
+	for _, event := range r.Events {
+		if event.Kind == l.TargetEventKindPre {
+			expected[event.TypeID]++
+		}
+	}
+	a.Equal(1, expected[l.TargetTypeContainerType])
+
+	counts := make(map[l.TargetTypeID]int)
+	opts := l.TargetWalkOptions{Counts: counts}
+	_, _, err = opts.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+		return ctx.Continue()
+	})
+	a.NoError(err)
+	a.Equal(expected, counts)
+
+	// A second walk adds to the existing counts.
+	_, _, err = opts.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+		return ctx.Continue()
+	})
+	a.NoError(err)
+	for id, count := range expected {
+		a.Equal(2*count, counts[id], id.String())
+	}
+
+	// Skipped children are not counted.
+	counts = make(map[l.TargetTypeID]int)
+	_, _, err = l.TargetWalkOptions{Counts: counts}.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+		return ctx.Skip()
+	})
+	a.NoError(err)
+	a.Equal(map[l.TargetTypeID]int{l.TargetTypeContainerType: 1}, counts)
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/cockroachdb/walkabout/reflectwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestDefer verifies that functions passed to Context.Defer are called
+// in reverse order once the walk is complete, however it completes.
+func TestDefer(t *testing.T) {
+	errDeferred := errors.New("deferred")
+	errWalker := errors.New("walker")
+
+	// deferring returns a walker which defers a function for each
+	// ByRefType, which records its value in closed. The walker returns
+	// the decision made by last when it sees the final ByRefType.
+	deferring := func(
+		closed *[]string, last func(ctx TargetContext) TargetDecision,
+	) TargetWalkerFn {
+		return func(ctx TargetContext, x Target) TargetDecision {
+			if ref, ok := x.(*ByRefType); ok {
+				ctx.Defer(func() error {
+					*closed = append(*closed, ref.Val)
+					return nil
+				})
+				if ref.Val == "c" {
+					return last(ctx)
+				}
+			}
+			return ctx.Continue()
+		}
+	}
+	newTree := func() *ContainerType {
+		return &ContainerType{
+			ByRef:      ByRefType{"a"},
+			ByRefSlice: []ByRefType{{"b"}, {"c"}, {"d"}},
+		}
+	}
+
+	tcs := []struct {
+		name   string
+		last   func(ctx TargetContext) TargetDecision
+		closed []string
+		err    error
+	}{
+		{
+			name:   "continue",
+			last:   func(ctx TargetContext) TargetDecision { return ctx.Continue() },
+			closed: []string{"d", "c", "b", "a"},
+		},
+		{
+			name:   "halt",
+			last:   func(ctx TargetContext) TargetDecision { return ctx.Halt() },
+			closed: []string{"c", "b", "a"},
+		},
+		{
+			name:   "walker error",
+			last:   func(ctx TargetContext) TargetDecision { return ctx.Error(errWalker) },
+			closed: []string{"c", "b", "a"},
+			err:    errWalker,
+		},
+		{
+			name: "deferred error",
+			last: func(ctx TargetContext) TargetDecision {
+				ctx.Defer(func() error { return errDeferred })
+				return ctx.Continue().Replace(&ByRefType{"replaced"})
+			},
+			closed: []string{"d", "c", "b", "a"},
+			err:    errDeferred,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("engine", func(t *testing.T) {
+				a := assert.New(t)
+				var closed []string
+				out, _, err := WalkTarget(newTree(), deferring(&closed, tc.last))
+				a.Equal(tc.closed, closed)
+				if tc.err == nil {
+					a.NoError(err)
+				} else {
+					a.True(errors.Is(err, tc.err))
+					a.Nil(out)
+				}
+			})
+
+			t.Run("reflectwalk", func(t *testing.T) {
+				a := assert.New(t)
+				var closed []string
+				out, _, err := reflectwalk.Walk[Target](newTree(), func(ctx reflectwalk.Context[Target], x Target) reflectwalk.Decision[Target] {
+					if ref, ok := x.(*ByRefType); ok {
+						ctx.Defer(func() error {
+							closed = append(closed, ref.Val)
+							return nil
+						})
+						if ref.Val == "c" {
+							switch tc.name {
+							case "halt":
+								return ctx.Halt()
+							case "walker error":
+								return ctx.Error(errWalker)
+							case "deferred error":
+								ctx.Defer(func() error { return errDeferred })
+							}
+						}
+					}
+					return ctx.Continue()
+				})
+				a.Equal(tc.closed, closed)
+				if tc.err == nil {
+					a.NoError(err)
+				} else {
+					a.True(errors.Is(err, tc.err))
+					a.Nil(out)
+				}
+			})
+		})
+	}
+
+	// Deferred functions are called even if the walker panics.
+	t.Run("panic", func(t *testing.T) {
+		a := assert.New(t)
+		var closed []string
+		a.Panics(func() {
+			_, _, _ = WalkTarget(newTree(), deferring(&closed, func(TargetContext) TargetDecision {
+				panic("boom")
+			}))
+		})
+		a.Equal([]string{"c", "b", "a"}, closed)
+	})
+
+	// Walks of inlined types, which use several calls into the engine,
+	// call deferred functions once the entire walk is complete.
+	t.Run("inline", func(t *testing.T) {
+		a := assert.New(t)
+		c := &Calculation{Expr: &BinaryOp{"+", &Scalar{1}, &Func{"F", []Expr{&Scalar{2}}}}}
+		var events []string
+		_, _, err := c.WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			if s, ok := x.(*Scalar); ok {
+				ctx.Defer(func() error {
+					events = append(events, "close "+strconv.Itoa(s.val))
+					return nil
+				})
+			}
+			events = append(events, "visit "+x.CalcTypeName())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal([]string{
+			"visit Calculation",
+			"visit BinaryOp",
+			"visit Scalar",
+			"visit Func",
+			"visit Scalar",
+			"close 2",
+			"close 1",
+		}, events)
+
+		_, _, err = c.WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			ctx.Defer(func() error { return errDeferred })
+			return ctx.Continue()
+		})
+		a.True(errors.Is(err, errDeferred))
+	})
+
+	t.Run("outside walk", func(t *testing.T) {
+		a := assert.New(t)
+		var ctx TargetContext
+		a.Panics(func() { ctx.Defer(func() error { return nil }) })
+	})
+}
@@ -20,7 +20,7 @@ import "github.com/cockroachdb/walkabout/demo/other"
 
 //lint:file-ignore U1000 Ignore code for demos.
 //go:generate -command walkabout go run ..
-//go:generate walkabout Target
+//go:generate walkabout --adapter TargetVisitor --equal-methods --examples Target
 
 // Target is a base interface that we run the code-generator against.
 // There's nothing special about this interface.
@@ -204,3 +204,15 @@ func NewContainer(useValuePtrs bool) (*ContainerType, int) {
 	}
 	return x, count
 }
+
+// Equal allows the generated code to avoid cloning a structure when a
+// ByValType is replaced by an equivalent value, since the code is
+// generated with --equal-methods.
+func (x ByValType) Equal(o ByValType) bool { return x == o }
+
+// TargetVisitor demonstrates an existing visitor interface, which can
+// be used to walk a Target by way of a generated adapter.
+type TargetVisitor interface {
+	VisitPre(x Target) (recurse bool, replacement Target)
+	VisitPost(x Target) Target
+}
@@ -56,17 +56,26 @@ func Example_abstract() {
 	//15: []Target *demo.targetAbstract
 }
 
-// This example shows how an error can be returned from a visitor function.
+// This example shows how an error can be returned from a visitor
+// function. The error is wrapped with the path of the value which was
+// being visited.
 func Example_error() {
+	errVisit := errors.New("an error")
 	data, _ := demo.NewContainer(true)
 	ret, changed, err := data.WalkTarget(
 		func(ctx demo.TargetContext, x demo.Target) demo.TargetDecision {
-			return ctx.Error(errors.New("an error"))
+			return ctx.Error(errVisit)
 		})
 	fmt.Println(ret, changed, err)
 
+	var pathErr *demo.TargetPathError
+	if errors.As(err, &pathErr) {
+		fmt.Println(pathErr.Path, errors.Is(err, errVisit))
+	}
+
 	//Output:
-	//<nil> false an error
+	//<nil> false ContainerType: an error
+	//ContainerType true
 }
 
 // This example demonstrates how enhanced visitable types can be
@@ -0,0 +1,108 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	a := assert.New(t)
+	x, _ := l.NewContainer(true)
+	x.ByValSlice = []l.ByValType{}
+
+	data, err := l.EncodeTarget(nil, x)
+	if !a.NoError(err) {
+		return
+	}
+	y, err := l.DecodeTarget(data)
+	if !a.NoError(err) {
+		return
+	}
+	a.Equal(x, y)
+	a.NotNil(y.(*l.ContainerType).ByValSlice)
+	a.Nil(y.(*l.ContainerType).Container)
+
+	// Values stored in an interface by-value are decoded as pointers.
+	data, err = l.EncodeTarget(data[:0], l.ByValType{Val: "value"})
+	if a.NoError(err) {
+		y, err = l.DecodeTarget(data)
+		if a.NoError(err) {
+			a.Equal(&l.ByValType{Val: "value"}, y)
+		}
+	}
+
+	data, err = l.EncodeTarget(nil, nil)
+	if a.NoError(err) {
+		y, err = l.DecodeTarget(data)
+		if a.NoError(err) {
+			a.Nil(y)
+		}
+	}
+
+	_, err = l.DecodeTarget(data[:len(data)-1])
+	a.EqualError(err, "truncated encoding")
+
+	_, err = l.DecodeTarget(append(data, 0))
+	a.EqualError(err, "1 unexpected bytes after encoding")
+
+	data[0] = 99
+	_, err = l.DecodeTarget(data)
+	a.EqualError(err, "unsupported encoding version 99")
+}
+
+func TestDecodeLargeCounts(t *testing.T) {
+	a := assert.New(t)
+
+	// A table of structs which claims to have 2^31 entries.
+	_, err := l.DecodeTarget([]byte{1, 0xff, 0xff, 0xff, 0xff, 0x07})
+	a.EqualError(err, "truncated encoding")
+
+	// A table which omits the fields of ByRefType must not allow a
+	// large slice of them to be allocated.
+	data := []byte("\x01\x02\rContainerType\x01\nByRefSlice\v[]ByRefType" +
+		"\tByRefType\x00\x06Target\x01\xff\xff\xff\xff\x07")
+	_, err = l.DecodeTarget(data)
+	a.EqualError(err, "truncated encoding")
+}
+
+func TestEncodeCycle(t *testing.T) {
+	a := assert.New(t)
+	x := &l.ContainerType{}
+	x.Container = x
+
+	_, err := l.EncodeTarget(nil, x)
+	a.EqualError(err, "cannot encode a cycle through ContainerType")
+	a.True(errors.Is(err, engine.ErrCycle))
+
+	// Values which are shared, but not cyclic, are duplicated.
+	shared := &l.ByRefType{Val: "shared"}
+	x = &l.ContainerType{ByRefPtr: shared, ByRefPtrSlice: []*l.ByRefType{shared}}
+	data, err := l.EncodeTarget(nil, x)
+	if a.NoError(err) {
+		y, err := l.DecodeTarget(data)
+		if a.NoError(err) {
+			a.Equal(x, y)
+			c := y.(*l.ContainerType)
+			a.True(c.ByRefPtr != c.ByRefPtrSlice[0])
+		}
+	}
+}
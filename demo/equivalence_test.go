@@ -0,0 +1,102 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"math/rand"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	wt "github.com/cockroachdb/walkabout/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEquivalence(t *testing.T) {
+	// rename replaces each ByRefType with a renamed copy.
+	rename := func(x l.Target) l.Target {
+		if ref, ok := x.(*l.ByRefType); ok {
+			return &l.ByRefType{Val: ref.Val + "!"}
+		}
+		return x
+	}
+	// wrap replaces each ContainerType with a copy, whose children are
+	// visited and may themselves be replaced.
+	wrap := func(x l.Target) l.Target {
+		switch t := x.(type) {
+		case *l.ContainerType:
+			cpy := *t
+			return &cpy
+		case *l.ByRefType:
+			return &l.ByRefType{Val: t.Val + "!"}
+		}
+		return x
+	}
+
+	t.Run("fixture", func(t *testing.T) {
+		for _, useValuePtrs := range []bool{false, true} {
+			x, _ := l.NewContainer(useValuePtrs)
+			wt.CheckWalk(t, l.WalkTarget, l.Target(x), nil)
+			wt.CheckWalk(t, l.WalkTarget, l.Target(x), rename)
+			wt.CheckWalk(t, l.WalkTarget, l.Target(x), wrap)
+		}
+	})
+
+	t.Run("children of replacement", func(t *testing.T) {
+		a := assert.New(t)
+		x := &l.ContainerType{ByRef: l.ByRefType{Val: "a"}}
+		wt.CheckWalk(t, l.WalkTarget, l.Target(x), wrap)
+
+		y, changed, err := l.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			return ctx.Continue().Replace(wrap(x))
+		})
+		a.NoError(err)
+		a.True(changed)
+		a.Equal("a!", y.(*l.ContainerType).ByRef.Val)
+		a.Equal("a", x.ByRef.Val)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 200; i++ {
+			x := l.RandomTarget(r, l.TargetRandomConfig{NilChance: 0.2, MaxNodes: 50})
+			if x == nil {
+				continue
+			}
+			wt.CheckWalk(t, l.WalkTarget, x, nil)
+			wt.CheckWalk(t, l.WalkTarget, x, rename)
+			wt.CheckWalk(t, l.WalkTarget, x, wrap)
+		}
+	})
+
+	t.Run("reports differences", func(t *testing.T) {
+		a := assert.New(t)
+		// A walk which skips a subtree will not match the reference.
+		skipping := func(x l.Target, fn l.TargetWalkerFn) (l.Target, bool, error) {
+			return l.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				d := fn(ctx, x)
+				if _, ok := x.(*l.ContainerType); ok {
+					return ctx.Skip()
+				}
+				return d
+			})
+		}
+		x := &l.ContainerType{AnotherTarget: &l.ByRefType{Val: "a"}}
+		tb := &recordingTB{TB: t}
+		wt.CheckWalk(tb, skipping, l.Target(x), nil)
+		a.Len(tb.errors, 1)
+		a.Contains(tb.errors[0], "visits differ")
+	})
+}
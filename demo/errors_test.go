@@ -0,0 +1,88 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// errUser is a sentinel error returned by the walkers in this file.
+var errUser = errors.New("user error")
+
+// TestWalkErrors verifies that errors returned from a walk can be
+// inspected with errors.Is and errors.As.
+func TestWalkErrors(t *testing.T) {
+	x, _ := l.NewContainer(true)
+
+	check := func(t *testing.T, err error, path string) {
+		a := assert.New(t)
+		a.True(errors.Is(err, errUser))
+		var pathErr *l.TargetPathError
+		if a.True(errors.As(err, &pathErr)) {
+			a.Equal(path, pathErr.Path)
+		}
+	}
+
+	t.Run("walker", func(t *testing.T) {
+		_, _, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			if _, ok := x.(*l.ByRefType); ok {
+				return ctx.Error(errUser)
+			}
+			return ctx.Continue()
+		})
+		check(t, err, "ContainerType/ByRef")
+	})
+
+	t.Run("post", func(t *testing.T) {
+		_, _, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			return ctx.Continue().Post(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				return ctx.Error(errUser)
+			})
+		})
+		check(t, err, "ContainerType/ByRef")
+	})
+
+	t.Run("action", func(t *testing.T) {
+		_, _, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			if c, ok := x.(*l.ContainerType); ok {
+				return ctx.Actions(
+					ctx.ActionVisit(c.ByRefPtr),
+					ctx.ActionCall(func() error { return errUser }),
+				)
+			}
+			return ctx.Continue()
+		})
+		check(t, err, "ContainerType/action[1]")
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		a := assert.New(t)
+		fn := func(ctx l.TargetContext, x l.Target) (d l.TargetDecision) { return }
+
+		// ContainerType -> *ContainerType -> ContainerType -> ByRef
+		deep := &l.ContainerType{Container: &l.ContainerType{ByRef: l.ByRefType{Val: "deep"}}}
+		_, _, err := l.TargetWalkOptions{MaxDepth: 4}.WalkTarget(deep, fn)
+		a.NoError(err)
+
+		_, _, err = l.TargetWalkOptions{MaxDepth: 3}.WalkTarget(deep, fn)
+		a.True(errors.Is(err, l.ErrMaxDepthTarget))
+		a.EqualError(err, "ContainerType/Container/ByRef: maximum depth exceeded")
+	})
+}
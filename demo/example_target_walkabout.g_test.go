@@ -0,0 +1,69 @@
+// Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.
+// source: demo.go
+// walkabout version: dev
+
+package demo
+
+import "fmt"
+
+// ExampleWalkTarget prints the type of each value which is visited in
+// a ContainerType.
+func ExampleWalkTarget() {
+	var x Target = &ContainerType{}
+	_, _, err := WalkTarget(x, func(ctx TargetContext, x Target) TargetDecision {
+		fmt.Printf("%T\n", x)
+		return ctx.Continue()
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// *demo.ContainerType
+	// *demo.ByRefType
+	// *demo.ByValType
+}
+
+// ExampleWalkTarget_replace replaces a ContainerType with a new value. The
+// value which was walked is not modified.
+func ExampleWalkTarget_replace() {
+	var x Target = &ContainerType{}
+	y, _, err := WalkTarget(x, func(ctx TargetContext, x Target) TargetDecision {
+		if _, ok := x.(*ContainerType); ok {
+			return ctx.Continue().Replace(&ContainerType{})
+		}
+		return ctx.Continue()
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%T\n", y)
+	// Output: *demo.ContainerType
+}
+
+// ExampleWalkTarget_actions calls functions in place of visiting the
+// fields of a ContainerType, and then calls a post-visit function.
+func ExampleWalkTarget_actions() {
+	var x Target = &ContainerType{}
+	_, _, err := WalkTarget(x, func(ctx TargetContext, x Target) TargetDecision {
+		return ctx.Actions(
+			ctx.ActionCall(func() error {
+				fmt.Println("first")
+				return nil
+			}),
+			ctx.ActionCall(func() error {
+				fmt.Println("second")
+				return nil
+			}),
+		).Post(func(ctx TargetContext, x Target) TargetDecision {
+			fmt.Printf("post %T\n", x)
+			return ctx.Continue()
+		})
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// first
+	// second
+	// post *demo.ContainerType
+}
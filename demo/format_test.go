@@ -0,0 +1,124 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	e "github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingWriter returns an error once it has been written to.
+type failingWriter struct{ err error }
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestFormat(t *testing.T) {
+	x := &Calculation{
+		Expr: &Let{
+			Body: &Func{"Sum", []Expr{
+				&BinaryOp{"+", &Scalar{1}, nil},
+				&Func{"Random", []Expr{}},
+			}},
+			Name:  "x",
+			Value: &Scalar{2},
+		},
+	}
+	format := func(x interface{}, opts e.FormatOptions) string {
+		var sb strings.Builder
+		var err error
+		switch t := x.(type) {
+		case *Calculation:
+			err = calcEngine().Format(&sb, e.TypeID(CalcTypeCalculation), e.Ptr(t), opts)
+		case Calc:
+			err = calcEngine().Format(&sb, e.TypeID(CalcTypeCalc), e.Ptr(&t), opts)
+		}
+		assert.NoError(t, err)
+		return sb.String()
+	}
+
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, `Calculation{
+  Expr: Let{
+    Body: Func{
+      Fn: "Sum",
+      Args: []Expr{
+        BinaryOp{
+          op: "+",
+          lhs: Scalar{},
+          rhs: nil,
+        },
+        Func{
+          Fn: "Random",
+          Args: []Expr{},
+        },
+      },
+    },
+    Name: "x",
+    Value: Scalar{},
+  },
+}
+`, format(x, e.FormatOptions{}))
+	})
+
+	t.Run("options", func(t *testing.T) {
+		assert.Equal(t, `Calculation{
+	Expr: Let{
+		Body: Func{
+			Fn: "Sum",
+			Args: []Expr{...},
+		},
+		Name: "x",
+		Value: Scalar{},
+	},
+}
+`, format(x, e.FormatOptions{Indent: "\t", MaxDepth: 3}))
+
+		assert.Equal(t, `BinaryOp{
+  op: "+",
+  lhs: Scalar{},
+}
+`, format(Calc(&BinaryOp{"+", &Scalar{1}, nil}), e.FormatOptions{OmitZero: true}))
+
+		assert.Equal(t, "Func{}\n", format(Calc(&Func{}), e.FormatOptions{OmitZero: true}))
+
+		var sb strings.Builder
+		var nilCalc Calc
+		assert.NoError(t, calcEngine().Format(&sb, e.TypeID(CalcTypeCalc), e.Ptr(&nilCalc), e.FormatOptions{}))
+		assert.Equal(t, "nil\n", sb.String())
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		fn := &Func{Fn: "Loop"}
+		fn.Args = []Expr{fn}
+		assert.Equal(t, `Func{
+  Fn: "Loop",
+  Args: []Expr{
+    <cycle>,
+  },
+}
+`, format(Calc(fn), e.FormatOptions{}))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		err := calcEngine().Format(failingWriter{errBoom}, e.TypeID(CalcTypeCalculation), e.Ptr(x), e.FormatOptions{})
+		assert.Equal(t, errBoom, err)
+	})
+}
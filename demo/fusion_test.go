@@ -0,0 +1,115 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptedWalker returns a walker which records the values it visits
+// and which makes decisions from ops, in order.
+func scriptedWalker(ops []byte, trace *[]string) l.TargetWalkerFn {
+	idx := 0
+	var post l.TargetWalkerFn = func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		*trace = append(*trace, fmt.Sprintf("post %s %T", ctx.Path(), x))
+		return ctx.Continue()
+	}
+	return func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		*trace = append(*trace, fmt.Sprintf("%s %T", ctx.Path(), x))
+		op := ops[idx%len(ops)] % 4
+		idx++
+		switch op {
+		case 1:
+			return ctx.Skip()
+		case 2:
+			return ctx.Continue().Post(post)
+		case 3:
+			if idx > len(ops) {
+				return ctx.Halt().Post(post)
+			}
+		}
+		return ctx.Continue()
+	}
+}
+
+func TestWalkTargetAll(t *testing.T) {
+	t.Run("isolated", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 200; i++ {
+			x := l.RandomTarget(r, l.TargetRandomConfig{NilChance: 0.3, MaxNodes: 64})
+			if x == nil {
+				continue
+			}
+			scripts := make([][]byte, 1+r.Intn(4))
+			for j := range scripts {
+				scripts[j] = make([]byte, 1+r.Intn(8))
+				r.Read(scripts[j])
+			}
+
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				a := assert.New(t)
+				fused := make([][]string, len(scripts))
+				fns := make([]l.TargetWalkerFn, len(scripts))
+				for j, ops := range scripts {
+					fns[j] = scriptedWalker(ops, &fused[j])
+				}
+				a.NoError(l.WalkTargetAll(x, fns...))
+
+				for j, ops := range scripts {
+					var alone []string
+					_, _, err := l.WalkTarget(x, scriptedWalker(ops, &alone))
+					a.NoError(err)
+					a.Equal(alone, fused[j], "walker %d", j)
+				}
+			})
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		a.Equal(l.ErrNilTarget, l.WalkTargetAll(nil))
+
+		errBoom := errors.New("boom")
+		var visits int
+		err := l.WalkTargetAll(x,
+			func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				visits++
+				return ctx.Continue()
+			},
+			func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				if _, ok := x.(*l.ByRefType); ok {
+					return ctx.Error(errBoom)
+				}
+				return ctx.Continue()
+			})
+		a.True(errors.Is(err, errBoom))
+		a.EqualError(err, "ContainerType/ByRef: boom")
+		a.Equal(2, visits)
+
+		err = l.WalkTargetAll(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			return ctx.Continue().Replace(x)
+		})
+		a.True(errors.Is(err, engine.ErrFusedRewrite))
+	})
+}
@@ -0,0 +1,192 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+// This file contains native fuzz targets for the engine. FuzzWalk
+// drives a walk over a random tree with a sequence of decisions taken
+// from the fuzzer's input, and FuzzDecode decodes the fuzzer's input.
+// Run with, e.g.:
+//	go test ./demo -run '^$' -fuzz FuzzWalk
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+)
+
+// Operations which may be chosen by a byte of fuzzer input.
+const (
+	fuzzContinue = iota
+	fuzzSkip
+	fuzzHalt
+	fuzzError
+	fuzzReplace
+	fuzzPostReplace
+	fuzzActions
+	fuzzIntercept
+	fuzzOpCount
+)
+
+var errFuzz = errors.New("fuzz")
+
+// fuzzReplacement returns a new value with the same type as x.
+func fuzzReplacement(x l.Target) l.Target {
+	switch t := x.(type) {
+	case *l.ByRefType:
+		return &l.ByRefType{Val: t.Val + "!"}
+	case l.ByValType:
+		return l.ByValType{Val: t.Val + "!"}
+	case *l.ByValType:
+		return &l.ByValType{Val: t.Val + "!"}
+	case *l.ContainerType:
+		cpy := *t
+		return &cpy
+	default:
+		return x
+	}
+}
+
+func FuzzWalk(f *testing.F) {
+	f.Add(int64(0), []byte{})
+	f.Add(int64(1), []byte{fuzzContinue})
+	f.Add(int64(2), []byte{fuzzContinue, fuzzReplace, fuzzSkip})
+	f.Add(int64(3), []byte{fuzzActions, fuzzPostReplace, fuzzIntercept, fuzzHalt})
+	f.Add(int64(4), []byte{fuzzContinue, fuzzContinue, fuzzContinue, fuzzError})
+	f.Add(int64(5), []byte{fuzzIntercept, fuzzReplace, fuzzActions, fuzzReplace, fuzzPostReplace})
+
+	f.Fuzz(func(t *testing.T, seed int64, ops []byte) {
+		x := l.RandomTarget(rand.New(rand.NewSource(seed)),
+			l.TargetRandomConfig{NilChance: 0.3, MaxNodes: 64})
+		before, err := l.DumpTarget(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Take the next operation, repeating the input as needed.
+		idx := 0
+		next := func() byte {
+			if len(ops) == 0 {
+				return fuzzContinue
+			}
+			op := ops[idx%len(ops)] % fuzzOpCount
+			idx++
+			return op
+		}
+
+		var fn l.TargetWalkerFn
+		expectErr := false
+		// Values visited by an action have no location, so they cannot
+		// be replaced.
+		usedActions := false
+		fn = func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			switch next() {
+			case fuzzSkip:
+				return ctx.Skip()
+			case fuzzHalt:
+				return ctx.Halt()
+			case fuzzError:
+				expectErr = true
+				return ctx.Error(errFuzz)
+			case fuzzReplace:
+				return ctx.Continue().Replace(fuzzReplacement(x))
+			case fuzzPostReplace:
+				return ctx.Continue().Post(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+					return ctx.Continue().Replace(fuzzReplacement(x))
+				})
+			case fuzzActions:
+				usedActions = true
+				actions := []l.TargetAction{ctx.ActionCall(func() error { return nil })}
+				if c, ok := x.(*l.ContainerType); ok {
+					actions = append(actions, ctx.ActionVisit(&c.ByRef), ctx.ActionVisit(c.ByVal))
+					if c.AnotherTarget != nil {
+						actions = append(actions, ctx.ActionVisit(c.AnotherTarget))
+					}
+				}
+				return ctx.Actions(actions...)
+			case fuzzIntercept:
+				return ctx.Continue().Intercept(fn)
+			default:
+				return ctx.Continue()
+			}
+		}
+
+		out, changed, err := l.WalkTarget(x, fn)
+		if err != nil {
+			switch {
+			case expectErr && errors.Is(err, errFuzz):
+			case usedActions && errors.As(err, new(*l.TargetReplacementError)):
+			default:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return
+		}
+
+		// Replacements must never modify the original tree.
+		after, err := l.DumpTarget(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if before != after {
+			t.Fatalf("input was modified:\n%s\n%s", before, after)
+		}
+		if !changed && out != x {
+			t.Fatal("unchanged walk returned a different value")
+		}
+
+		// The result must still be a well-formed tree.
+		text, err := l.DumpTarget(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := l.ParseTarget(text); err != nil {
+			t.Fatalf("%v:\n%s", err, text)
+		}
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+	x, _ := l.NewContainer(true)
+	for _, seed := range []l.Target{nil, l.ByValType{Val: "value"}, x} {
+		data, err := l.EncodeTarget(nil, seed)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte{1, 0xff, 0xff, 0xff, 0xff, 0x07})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		x, err := l.DecodeTarget(data)
+		if err != nil {
+			return
+		}
+		// Anything which can be decoded must survive a round-trip.
+		again, err := l.EncodeTarget(nil, x)
+		if err != nil {
+			t.Fatal(err)
+		}
+		y, err := l.DecodeTarget(again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(x, y) {
+			t.Fatalf("round-trip changed the value:\n%#v\n%#v", x, y)
+		}
+	})
+}
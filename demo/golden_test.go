@@ -0,0 +1,93 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	wt "github.com/cockroachdb/walkabout/testing"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTB captures failures instead of reporting them.
+type recordingTB struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingTB) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+	r.fatal = true
+}
+
+func TestGolden(t *testing.T) {
+	a := assert.New(t)
+	x := &l.ContainerType{
+		ByRef:       l.ByRefType{Val: "hello"},
+		TargetSlice: []l.Target{&l.ByRefType{Val: "a"}, l.ByValType{Val: "b"}},
+	}
+	// Uppercase the values of ByRefTypes.
+	out, _, err := l.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if t, ok := x.(*l.ByRefType); ok {
+			return ctx.Continue().Replace(&l.ByRefType{Val: strings.ToUpper(t.Val)})
+		}
+		return ctx.Continue()
+	})
+	a.NoError(err)
+
+	a.Equal(filepath.Join("testdata", "TestGolden.golden"), wt.GoldenFile(t))
+	wt.GoldenTree(t, wt.GoldenFile(t), l.DumpTarget, out)
+
+	t.Run("mismatch", func(t *testing.T) {
+		if wt.Update() {
+			t.Skip("updating golden files")
+		}
+		a := assert.New(t)
+		a.Equal(filepath.Join("testdata", "TestGolden_mismatch.golden"), wt.GoldenFile(t))
+
+		rec := &recordingTB{TB: t}
+		wt.GoldenTree(rec, filepath.Join("testdata", "TestGolden.golden"), l.DumpTarget, l.Target(x))
+		if a.Len(rec.errors, 1) {
+			a.Contains(rec.errors[0], "  (ContainerType\n")
+			a.Contains(rec.errors[0], `-   :ByRef (ByRefType :Val "HELLO")`)
+			a.Contains(rec.errors[0], `+   :ByRef (ByRefType :Val "hello")`)
+		}
+
+		rec = &recordingTB{TB: t}
+		wt.Golden(rec, filepath.Join(t.TempDir(), "missing.golden"), "")
+		a.True(rec.fatal)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		a := assert.New(t)
+		t.Setenv(wt.UpdateEnv, "1")
+		path := filepath.Join(t.TempDir(), "nested", "out.golden")
+		wt.GoldenTree(t, path, l.DumpTarget, out)
+		data, err := os.ReadFile(path)
+		a.NoError(err)
+		a.Equal(wt.Dump(t, l.DumpTarget, out)+"\n", string(data))
+	})
+}
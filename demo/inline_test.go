@@ -0,0 +1,180 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// The Calculation and Scalar types have inlined walkers, which hand
+// off to the engine for interface fields and for complex decisions.
+func TestInlineWalk(t *testing.T) {
+	newCalc := func() *Calculation {
+		return &Calculation{
+			Expr: &BinaryOp{"+", &Scalar{1}, &Func{"Sum", []Expr{&Scalar{2}, &Scalar{3}}}},
+		}
+	}
+
+	t.Run("visits", func(t *testing.T) {
+		a := assert.New(t)
+		var inline, engine []string
+		record := func(into *[]string) CalcWalkerFn {
+			return func(ctx CalcContext, x Calc) CalcDecision {
+				id, _ := calcIdentify(x)
+				*into = append(*into, calcEngine().Stringify(id))
+				return ctx.Continue()
+			}
+		}
+		c := newCalc()
+		_, changed, err := c.WalkCalc(record(&inline))
+		a.NoError(err)
+		a.False(changed)
+		_, _, err = WalkCalc(c, record(&engine))
+		a.NoError(err)
+		a.Equal(engine, inline)
+		a.Len(inline, 6)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		a := assert.New(t)
+		var c *Calculation
+		_, changed, err := c.WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			return ctx.Continue()
+		})
+		a.Equal(ErrNilCalc, err)
+		a.False(changed)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		a := assert.New(t)
+		c := newCalc()
+		c2, changed, err := c.WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			if s, ok := x.(*Scalar); ok {
+				return ctx.Continue().Replace(&Scalar{s.val * 10})
+			}
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.True(changed)
+		a.False(c == c2)
+		a.Equal(1, c.Expr.(*BinaryOp).Left.(*Scalar).val)
+		a.Equal(10, c2.Expr.(*BinaryOp).Left.(*Scalar).val)
+
+		s2, changed, err := (&Scalar{1}).WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			return ctx.Continue().Replace(&Scalar{2})
+		})
+		a.NoError(err)
+		a.True(changed)
+		a.Equal(2, s2.val)
+	})
+
+	t.Run("halt", func(t *testing.T) {
+		a := assert.New(t)
+		count := 0
+		_, changed, err := newCalc().WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			count++
+			return ctx.Halt()
+		})
+		a.NoError(err)
+		a.False(changed)
+		a.Equal(1, count)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		a := assert.New(t)
+		count := 0
+		_, _, err := newCalc().WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			count++
+			return ctx.Skip()
+		})
+		a.NoError(err)
+		a.Equal(1, count)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		a := assert.New(t)
+		_, _, err := newCalc().WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			return ctx.Error(errors.New("boom"))
+		})
+		a.EqualError(err, "Calculation: boom")
+	})
+
+	t.Run("post", func(t *testing.T) {
+		a := assert.New(t)
+		var order []string
+		_, _, err := newCalc().WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			if _, ok := x.(*Calculation); ok {
+				return ctx.Continue().Post(func(ctx CalcContext, x Calc) CalcDecision {
+					order = append(order, "post")
+					return ctx.Continue()
+				})
+			}
+			order = append(order, "visit")
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal([]string{"visit", "visit", "visit", "visit", "visit", "post"}, order)
+	})
+
+	// Values which are visited inline are not seen by the engine, so
+	// they have no path or abstract accessor and are not counted.
+	t.Run("bypasses engine", func(t *testing.T) {
+		a := assert.New(t)
+		var visits, walks expvar.Int
+		defer engine.SetMetrics(engine.SetMetrics(&engine.Metrics{
+			Visits: &visits,
+			Walks:  &walks,
+		}))
+
+		var paths []string
+		var abstract []bool
+		fn := func(ctx CalcContext, x Calc) CalcDecision {
+			paths = append(paths, ctx.Path())
+			abstract = append(abstract, AbstractCalcAt(ctx) != nil)
+			return ctx.Continue()
+		}
+		_, _, err := newCalc().WalkCalc(fn)
+		a.NoError(err)
+		a.Equal([]string{"", "Expr", "Expr/lhs", "Expr/rhs",
+			"Expr/rhs/Args[0]", "Expr/rhs/Args[1]"}, paths)
+		a.Equal([]bool{false, true, true, true, true, true}, abstract)
+		a.Equal(int64(1), walks.Value())
+		a.Equal(int64(5), visits.Value())
+
+		// A walk which never leaves the inlined structs is not counted.
+		paths, abstract = nil, nil
+		_, _, err = (&Scalar{1}).WalkCalc(fn)
+		a.NoError(err)
+		a.Equal([]string{""}, paths)
+		a.Equal([]bool{false}, abstract)
+		a.Equal(int64(1), walks.Value())
+
+		// The engine sees every value when the function is used.
+		paths, abstract = nil, nil
+		_, _, err = WalkCalc(newCalc(), fn)
+		a.NoError(err)
+		a.Equal("Calculation", paths[0])
+		a.Equal("Calculation/Expr/rhs/Args[1]", paths[5])
+		a.NotContains(abstract, false)
+		a.Equal(int64(2), walks.Value())
+		a.Equal(int64(11), visits.Value())
+	})
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecodeMap(t *testing.T) {
+	a := assert.New(t)
+	x, _ := l.NewContainer(true)
+	x.ByValSlice = []l.ByValType{}
+	// The map form cannot distinguish a nil pointer from a pointer to a
+	// nil interface.
+	for i, ptr := range x.InterfacePtrSlice {
+		if ptr != nil && *ptr == nil {
+			x.InterfacePtrSlice[i] = nil
+		}
+	}
+
+	m, err := l.EncodeTargetMap(x)
+	if !a.NoError(err) {
+		return
+	}
+	a.Equal("ContainerType", m["$type"])
+	a.Equal("olleH", m["ByRef"].(map[string]interface{})["Val"])
+	a.Equal([]interface{}{}, m["ByValSlice"])
+
+	y, err := l.DecodeTargetMap(m)
+	if a.NoError(err) {
+		a.Equal(x, y)
+	}
+
+	// The tree of maps survives a round-trip through JSON.
+	data, err := json.Marshal(m)
+	if !a.NoError(err) {
+		return
+	}
+	var fromJSON map[string]interface{}
+	if !a.NoError(json.Unmarshal(data, &fromJSON)) {
+		return
+	}
+	y, err = l.DecodeTargetMap(fromJSON)
+	if a.NoError(err) {
+		a.Equal(x, y)
+	}
+
+	m, err = l.EncodeTargetMap(nil)
+	if a.NoError(err) {
+		a.Nil(m)
+	}
+	y, err = l.DecodeTargetMap(nil)
+	if a.NoError(err) {
+		a.Nil(y)
+	}
+
+	cyclic := &l.ContainerType{}
+	cyclic.Container = cyclic
+	_, err = l.EncodeTargetMap(cyclic)
+	a.EqualError(err, "cannot convert a cycle through ContainerType")
+	a.True(errors.Is(err, engine.ErrCycle))
+}
+
+func TestDecodeMapErrors(t *testing.T) {
+	tcs := []struct {
+		json string
+		err  string
+	}{
+		{
+			json: `{"Val": "x"}`,
+			err:  "Target: missing $type for Target",
+		},
+		{
+			json: `{"$type": "NeverType"}`,
+			err:  "Target: unknown type NeverType",
+		},
+		{
+			json: `{"$type": "ContainerType", "Nope": 1}`,
+			err:  "Target.Nope: ContainerType has no field Nope",
+		},
+		{
+			json: `{"$type": "ContainerType", "ByRefSlice": [{}, {"Val": 1}]}`,
+			err:  "Target.ByRefSlice[1].Val: expecting a string, got float64",
+		},
+		{
+			json: `{"$type": "ContainerType", "ByVal": {"$type": "ByRefType"}}`,
+			err:  "Target.ByVal: cannot use a ByRefType as a ByValType",
+		},
+		{
+			json: `{"$type": "ContainerType", "EmbedsTarget": {"$type": "ByRefType"}}`,
+			err:  "Target.EmbedsTarget: type ByRefType is not assignable to EmbedsTarget",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.json, func(t *testing.T) {
+			a := assert.New(t)
+			var m map[string]interface{}
+			if !a.NoError(json.Unmarshal([]byte(tc.json), &m)) {
+				return
+			}
+			_, err := l.DecodeTargetMap(m)
+			a.EqualError(err, tc.err)
+			var pathErr *engine.PathError
+			a.True(errors.As(err, &pathErr))
+		})
+	}
+}
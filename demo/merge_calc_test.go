@@ -0,0 +1,71 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMergeCalc verifies merges of values with unexported fields, and
+// of deep trees.
+func TestMergeCalc(t *testing.T) {
+	// Changes to unexported fields are not detected, so they are kept
+	// only if the enclosing struct is taken from the side which made
+	// them.
+	t.Run("unexported", func(t *testing.T) {
+		a := assert.New(t)
+		newOp := func(op string, val int) *BinaryOp {
+			return &BinaryOp{Operator: op, Left: &Scalar{val}, Right: &Scalar{0}}
+		}
+
+		out, conflicts, err := MergeCalc(newOp("+", 1), newOp("+", 1), newOp("+", 2))
+		a.NoError(err)
+		a.Empty(conflicts)
+		a.Equal(2, out.(*BinaryOp).Left.(*Scalar).val)
+
+		out, conflicts, err = MergeCalc(newOp("+", 1), newOp("-", 1), newOp("+", 2))
+		a.NoError(err)
+		a.Empty(conflicts)
+		a.Equal("-", out.(*BinaryOp).Operator)
+		a.Equal(1, out.(*BinaryOp).Left.(*Scalar).val)
+	})
+
+	// Each struct is related once, so merging changes at both ends of a
+	// deep tree does not compare its subtrees at every level.
+	t.Run("deep", func(t *testing.T) {
+		a := assert.New(t)
+		const depth = 10000
+		newChain := func(top, bottom string) *Calculation {
+			var expr Expr = &BinaryOp{Operator: bottom, Left: &Scalar{}, Right: &Scalar{}}
+			for i := 1; i < depth-1; i++ {
+				expr = &BinaryOp{Operator: "+", Left: expr, Right: &Scalar{}}
+			}
+			return &Calculation{&BinaryOp{Operator: top, Left: expr, Right: &Scalar{}}}
+		}
+
+		out, conflicts, err := MergeCalc(newChain("+", "+"), newChain("-", "+"), newChain("+", "*"))
+		a.NoError(err)
+		a.Empty(conflicts)
+		op := out.(*Calculation).Expr.(*BinaryOp)
+		a.Equal("-", op.Operator)
+		for i := 1; i < depth; i++ {
+			op = op.Left.(*BinaryOp)
+		}
+		a.Equal("*", op.Operator)
+	})
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	e "github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMerge verifies three-way merges of trees.
+func TestMerge(t *testing.T) {
+	newBase := func() *l.ContainerType {
+		return &l.ContainerType{
+			ByRef:         l.ByRefType{Val: "a"},
+			ByRefSlice:    []l.ByRefType{{Val: "b"}, {Val: "c"}},
+			ByRefPtrSlice: []*l.ByRefType{{Val: "d"}},
+			AnotherTarget: &l.ByRefType{Val: "e"},
+		}
+	}
+
+	t.Run("disjoint", func(t *testing.T) {
+		a := assert.New(t)
+		base := newBase()
+		left := newBase()
+		left.ByRefSlice[0].Val = "left"
+		left.ByValPtr = &l.ByValType{Val: "added"}
+		right := newBase()
+		right.ByRefSlice[1].Val = "right"
+		right.AnotherTarget = &l.ByValType{Val: "swapped"}
+
+		out, conflicts, err := l.MergeTarget(base, left, right)
+		a.NoError(err)
+		a.Empty(conflicts)
+		merged := out.(*l.ContainerType)
+		a.Equal([]l.ByRefType{{Val: "left"}, {Val: "right"}}, merged.ByRefSlice)
+		a.Equal("added", merged.ByValPtr.Val)
+		a.Equal(&l.ByValType{Val: "swapped"}, merged.AnotherTarget)
+
+		// Unchanged values are shared, and the inputs are not modified.
+		a.True(merged.ByRefPtrSlice[0] == right.ByRefPtrSlice[0])
+		a.True(merged.ByValPtr == left.ByValPtr)
+		a.True(l.EqualTarget(base, newBase()))
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		a := assert.New(t)
+		base := newBase()
+		left := newBase()
+		left.ByRef.Val = "left"
+		left.ByRefPtrSlice = append(left.ByRefPtrSlice, &l.ByRefType{Val: "left"})
+		left.AnotherTarget.(*l.ByRefType).Val = "same"
+		right := newBase()
+		right.ByRef.Val = "right"
+		right.ByRefPtrSlice = nil
+		right.AnotherTarget.(*l.ByRefType).Val = "same"
+
+		out, conflicts, err := l.MergeTarget(base, left, right)
+		a.NoError(err)
+		a.Equal([]l.TargetConflict{
+			{Path: "ContainerType/ByRef/Val"},
+			{Path: "ContainerType/ByRefPtrSlice"},
+		}, conflicts)
+		merged := out.(*l.ContainerType)
+		a.Equal("left", merged.ByRef.Val)
+		a.Len(merged.ByRefPtrSlice, 2)
+		a.Equal("same", merged.AnotherTarget.(*l.ByRefType).Val)
+	})
+
+	t.Run("roots", func(t *testing.T) {
+		a := assert.New(t)
+		out, conflicts, err := l.MergeTarget(nil, nil, &l.ByRefType{Val: "new"})
+		a.NoError(err)
+		a.Empty(conflicts)
+		a.Equal(&l.ByRefType{Val: "new"}, out)
+
+		out, conflicts, err = l.MergeTarget(&l.ByRefType{}, &l.ByRefType{Val: "left"}, &l.ByValType{})
+		a.NoError(err)
+		a.Equal([]l.TargetConflict{{Path: "ByRefType"}}, conflicts)
+		a.Equal(&l.ByRefType{Val: "left"}, out)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		a := assert.New(t)
+		cyclic := func(val string) *l.ContainerType {
+			x := &l.ContainerType{ByRef: l.ByRefType{Val: val}}
+			x.Container = x
+			return x
+		}
+		_, _, err := l.MergeTarget(cyclic("base"), cyclic("left"), cyclic("right"))
+		a.True(errors.Is(err, e.ErrCycle))
+
+		// Cycles which are unchanged on one side do not need to be merged.
+		out, _, err := l.MergeTarget(cyclic("base"), cyclic("base"), cyclic("right"))
+		a.NoError(err)
+		a.Equal("right", out.(*l.ContainerType).ByRef.Val)
+	})
+}
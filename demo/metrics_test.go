@@ -0,0 +1,92 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	a := assert.New(t)
+
+	// The counters are not published, to avoid a panic if the test is
+	// run more than once.
+	var errs, replacements, visits, walks expvar.Int
+	defer engine.SetMetrics(engine.SetMetrics(&engine.Metrics{
+		Errors:       &errs,
+		Replacements: &replacements,
+		Visits:       &visits,
+		Walks:        &walks,
+	}))
+
+	x := &l.ContainerType{
+		AnotherTarget: l.ByValType{Val: "ChangeMe"},
+		EmbedsTarget:  l.ByValType{Val: "Keep"},
+	}
+	count := 0
+	_, _, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		count++
+		return ctx.Continue()
+	})
+	a.NoError(err)
+	a.Equal(int64(1), walks.Value())
+	a.Equal(int64(count), visits.Value())
+	a.Equal(int64(0), replacements.Value())
+	a.Equal(int64(0), errs.Value())
+
+	_, changed, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) (d l.TargetDecision) {
+		if x.Value() == "ChangeMe" {
+			d = d.Replace(&l.ByRefType{Val: "Changed"})
+		}
+		return
+	})
+	a.NoError(err)
+	a.True(changed)
+	a.Equal(int64(2), walks.Value())
+	a.Equal(int64(2*count), visits.Value())
+	a.Equal(int64(1), replacements.Value())
+
+	_, _, err = x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		return ctx.Error(errors.New("boom"))
+	})
+	a.EqualError(err, "ContainerType: boom")
+	a.Equal(int64(3), walks.Value())
+	a.Equal(int64(1), errs.Value())
+
+	// Disabling metrics stops the counters.
+	prev := engine.SetMetrics(nil)
+	_, _, _ = x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		return ctx.Continue()
+	})
+	a.Equal(int64(3), walks.Value())
+	engine.SetMetrics(prev)
+
+	// Adapted counters receive the same updates.
+	var adapted int64
+	engine.SetMetrics(&engine.Metrics{
+		Walks: engine.CounterFunc(func(d int64) { adapted += d }),
+	})
+	_, _, _ = x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		return ctx.Continue()
+	})
+	a.Equal(int64(1), adapted)
+}
@@ -21,11 +21,16 @@ package demo_test
 // but must replace values of ByValType.
 
 import (
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
 	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/demo/ast"
+	"github.com/cockroachdb/walkabout/demo/ast/base"
 	"github.com/cockroachdb/walkabout/demo/other"
+	"github.com/cockroachdb/walkabout/engine"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,8 +41,32 @@ func TestBadMutations(t *testing.T) {
 	_, _, err := d.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
 		return ctx.Continue().Replace(&l.ByRefType{})
 	})
-	a.EqualError(err, "cannot change type of ByValType to ByRefType")
+	a.EqualError(err, "ByValType: type ByRefType is not assignable to ByValType")
+	var re *l.TargetReplacementError
+	if a.True(errors.As(err, &re)) {
+		a.Equal(l.TargetReplacementError{
+			Path: "ByValType", Slot: "ByValType", Type: "ByRefType",
+		}, *re)
+	}
 
+	// Changes within a value visited by an action can't be applied.
+	c := &l.ContainerType{AnotherTarget: &l.ContainerType{ByRefPtr: &l.ByRefType{}}}
+	_, _, err = c.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		switch t := x.(type) {
+		case *l.ContainerType:
+			if t == c {
+				return ctx.Actions(ctx.ActionVisit(t.AnotherTarget))
+			}
+		case *l.ByRefType:
+			return ctx.Continue().Replace(&l.ByRefType{Val: "replaced"})
+		}
+		return ctx.Continue()
+	})
+	a.EqualError(err,
+		"ContainerType/action[0]: a value visited by an action cannot be replaced by ContainerType")
+	if a.True(errors.As(err, &re)) {
+		a.Empty(re.Slot)
+	}
 }
 
 // Verify data extraction.
@@ -91,6 +120,53 @@ func TestChildAt(t *testing.T) {
 	})
 }
 
+// TestEach verifies that Each produces the same children as ChildAt,
+// and that it does not allocate for each child.
+func TestEach(t *testing.T) {
+	a := assert.New(t)
+	x, _ := l.NewContainer(true)
+	x.Container, _ = l.NewContainer(false)
+
+	var check func(x l.TargetAbstract)
+	check = func(x l.TargetAbstract) {
+		var expected []int
+		for i, j := 0, x.TargetCount(); i < j; i++ {
+			if x.TargetAt(i) != nil {
+				expected = append(expected, i)
+			}
+		}
+		var actual []int
+		x.TargetEach(func(index int, child l.TargetAbstract) bool {
+			actual = append(actual, index)
+			a.Equal(x.TargetAt(index).TargetTypeID(), child.TargetTypeID())
+			check(child)
+			return true
+		})
+		a.Equal(expected, actual)
+	}
+	check(x)
+
+	// Stop early.
+	count := 0
+	x.TargetEach(func(int, l.TargetAbstract) bool {
+		count++
+		return count < 3
+	})
+	a.Equal(3, count)
+
+	enumerate := func(x l.TargetAbstract) float64 {
+		return testing.AllocsPerRun(100, func() {
+			x.TargetEach(func(_ int, child l.TargetAbstract) bool {
+				child.TargetEach(func(int, l.TargetAbstract) bool { return true })
+				return true
+			})
+		})
+	}
+	small := &l.ContainerType{ByRefSlice: make([]l.ByRefType, 10)}
+	large := &l.ContainerType{ByRefSlice: make([]l.ByRefType, 1000)}
+	a.Equal(enumerate(small), enumerate(large))
+}
+
 // TestCycleBreak creates a cyclical datastructure.
 func TestCycleBreak(t *testing.T) {
 	d, _ := l.NewContainer(false)
@@ -100,6 +176,36 @@ func TestCycleBreak(t *testing.T) {
 	})
 }
 
+// TestCycleBreakDeep creates a cycle which is deeper than the
+// threshold for hash-based cycle detection.
+func TestCycleBreakDeep(t *testing.T) {
+	for _, threshold := range []int{-1, 0, 4} {
+		t.Run(fmt.Sprintf("threshold=%d", threshold), func(t *testing.T) {
+			a := assert.New(t)
+			defer engine.SetCycleThreshold(engine.SetCycleThreshold(threshold))
+
+			head, _ := l.NewContainer(false)
+			x := head
+			for i := 0; i < 16; i++ {
+				next, _ := l.NewContainer(true)
+				x.Container = next
+				x = next
+			}
+			x.Container = head
+
+			count := 0
+			_, _, err := head.WalkTarget(func(ctx l.TargetContext, x l.Target) (d l.TargetDecision) {
+				if _, ok := x.(*l.ContainerType); ok {
+					count++
+				}
+				return
+			})
+			a.NoError(err)
+			a.Equal(17, count)
+		})
+	}
+}
+
 // Regression check to ensure that Halt().Replace() works.
 func TestHaltReplaceInner(t *testing.T) {
 	a := assert.New(t)
@@ -149,6 +255,24 @@ func TestInterfaceChange(t *testing.T) {
 		a.True(changed, "should have changed")
 		a.IsType(&l.ByRefType{}, ret)
 	})
+	t.Run("test imported root", func(t *testing.T) {
+		a := assert.New(t)
+
+		// The root interface is declared in another package and is not a
+		// --union interface.
+		call := &ast.Call{At: 1, Name: &ast.Ident{Name: "f"}}
+		ret, changed, err := ast.WalkNode(call, func(ctx ast.NodeContext, x base.Node) ast.NodeDecision {
+			if c, ok := x.(*ast.Call); ok {
+				return ctx.Skip().Replace(c.Name)
+			}
+			return ctx.Continue()
+		})
+		if !a.NoError(err) {
+			return
+		}
+		a.True(changed)
+		a.True(ret == base.Node(call.Name))
+	})
 	t.Run("test interface field", func(t *testing.T) {
 		a := assert.New(t)
 
@@ -173,6 +297,63 @@ func TestInterfaceChange(t *testing.T) {
 		// Ensure that the underlying field wasn't touched.
 		a.IsType(l.ByValType{}, ref)
 	})
+	t.Run("entered", func(t *testing.T) {
+		// The fields of a replacement of another type are visited,
+		// whether it has more or fewer fields than the value which it
+		// replaces.
+		tcs := []struct{ from, to l.Target }{
+			{&l.ByRefType{}, &l.ContainerType{ByRefPtr: &l.ByRefType{}}},
+			{&l.ContainerType{ByRefPtr: &l.ByRefType{}}, &l.ByRefType{Val: "Changed"}},
+		}
+		for _, tc := range tcs {
+			a := assert.New(t)
+			c := l.ContainerType{AnotherTarget: tc.from}
+			var visited []string
+			d2, changed, err := c.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				visited = append(visited, ctx.Path())
+				if x == tc.from {
+					return ctx.Continue().Replace(tc.to)
+				}
+				return ctx.Continue()
+			})
+			if !a.NoError(err) {
+				continue
+			}
+			a.True(changed)
+			a.True(d2.AnotherTarget == tc.to)
+			if _, ok := tc.to.(*l.ContainerType); ok {
+				a.Contains(visited, "ContainerType/AnotherTarget/ByRefPtr")
+			} else {
+				a.NotContains(visited, "ContainerType/AnotherTarget/ByRefPtr")
+			}
+		}
+	})
+	t.Run("cross-type", func(t *testing.T) {
+		// Replace a value before it is visited with one of another type
+		// which has fewer fields.
+		a := assert.New(t)
+
+		from := &l.ContainerType{
+			ByRefPtr:    &l.ByRefType{Val: "Child"},
+			TargetSlice: []l.Target{&l.ByRefType{Val: "Child"}},
+		}
+		to := &l.ByRefType{Val: "Changed"}
+		c := l.ContainerType{AnotherTarget: from}
+		var visited []string
+		d2, changed, err := c.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			visited = append(visited, x.Value())
+			if x == from {
+				return ctx.Continue().Replace(to)
+			}
+			return ctx.Continue()
+		})
+		if !a.NoError(err) {
+			return
+		}
+		a.True(changed)
+		a.True(d2.AnotherTarget == to)
+		a.NotContains(visited, "Child")
+	})
 	t.Run("cross-assign", func(t *testing.T) {
 		a := assert.New(t)
 
@@ -185,7 +366,7 @@ func TestInterfaceChange(t *testing.T) {
 			}
 			return
 		})
-		a.EqualError(err, "type ByRefType is unknown or not assignable to EmbedsTarget")
+		a.EqualError(err, "ContainerType/EmbedsTarget: type ByRefType is not assignable to EmbedsTarget")
 	})
 	t.Run("unknown type", func(t *testing.T) {
 		a := assert.New(t)
@@ -212,6 +393,234 @@ func TestMutations(t *testing.T) {
 		x, count := l.NewContainer(false)
 		checkMutations(t, x, count)
 	})
+	// Nested slices which require more than the fixed number of slots
+	// per frame.
+	t.Run("nested wide", func(t *testing.T) {
+		var x *l.ContainerType
+		total := 0
+		for i := 0; i < 4; i++ {
+			next, count := l.NewContainer(i%2 == 0)
+			for j := 0; j < 20; j++ {
+				next.ByRefSlice = append(next.ByRefSlice, l.ByRefType{Val: "olleH"})
+				next.ByValSlice = append(next.ByValSlice, l.ByValType{Val: "olleH"})
+			}
+			next.Container = x
+			x = next
+			total += count + 40
+		}
+		checkMutations(t, x, total)
+	})
+}
+
+// ReplaceByValType avoids the allocations required to box a
+// by-value implementation of Target.
+func TestReplaceByValType(t *testing.T) {
+	a := assert.New(t)
+	replacement := &l.ByValType{Val: "Replaced"}
+
+	x, _ := l.NewContainer(false)
+	x2, changed, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if _, ok := x.(*l.ByValType); ok {
+			return ctx.Continue().ReplaceByValType(replacement)
+		}
+		return ctx.Continue()
+	})
+	if !a.NoError(err) {
+		return
+	}
+	a.True(changed)
+	a.Equal("Replaced", x2.ByVal.Val)
+	a.Equal("Replaced", x2.ByValSlice[1].Val)
+	a.Equal("Replaced", x2.AnotherTarget.Value())
+
+	var ctx l.TargetContext
+	var sink l.TargetDecision
+	a.Zero(testing.AllocsPerRun(100, func() {
+		sink = ctx.Continue().ReplaceByValType(replacement)
+	}))
+	a.NotZero(testing.AllocsPerRun(100, func() {
+		sink = ctx.Continue().Replace(*replacement)
+	}))
+	_ = sink
+}
+
+// Replacing a value with itself, or with an equal value, should not
+// cause any part of the structure to be cloned.
+func TestReplaceIdentical(t *testing.T) {
+	a := assert.New(t)
+	x, _ := l.NewContainer(true)
+
+	x2, changed, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		switch t := x.(type) {
+		case *l.ByRefType:
+			return ctx.Continue().Replace(t)
+		case *l.ByValType:
+			return ctx.Continue().ReplaceByValType(&l.ByValType{Val: t.Val})
+		case *l.ContainerType:
+			return ctx.Continue().Post(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				return ctx.Continue().Replace(x)
+			})
+		}
+		return ctx.Continue()
+	})
+	if a.NoError(err) {
+		a.False(changed)
+		a.True(x == x2)
+	}
+
+	x2, changed, err = x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if t, ok := x.(*l.ByValType); ok {
+			return ctx.Continue().ReplaceByValType(&l.ByValType{Val: t.Val + "!"})
+		}
+		return ctx.Continue()
+	})
+	if a.NoError(err) {
+		a.True(changed)
+		a.False(x == x2)
+		a.Equal(x.ByVal.Val+"!", x2.ByVal.Val)
+	}
+}
+
+// TestLargeSlice verifies that slices which are visited in chunks are
+// correctly rewritten.
+func TestLargeSlice(t *testing.T) {
+	const size = 2500
+	x := &l.ContainerType{}
+	for i := 0; i < size; i++ {
+		x.ByValSlice = append(x.ByValSlice, l.ByValType{Val: fmt.Sprint(i)})
+	}
+	replace := func(which map[string]bool, halt string) l.TargetWalkerFn {
+		return func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			d := ctx.Continue()
+			if t, ok := x.(*l.ByValType); ok {
+				if halt != "" && t.Val == halt {
+					d = ctx.Halt()
+				}
+				if which[t.Val] {
+					d = d.ReplaceByValType(&l.ByValType{Val: "Replaced"})
+				}
+			}
+			return d
+		}
+	}
+
+	tcs := []struct {
+		name     string
+		replace  []string
+		halt     string
+		expected []int
+	}{
+		{name: "unchanged"},
+		{name: "first chunk", replace: []string{"0", "1023"}, expected: []int{0, 1023}},
+		{name: "last chunk", replace: []string{"2499"}, expected: []int{2499}},
+		{name: "every chunk", replace: []string{"1", "1024", "2048"}, expected: []int{1, 1024, 2048}},
+		{name: "halt", replace: []string{"5", "1500", "2000"}, halt: "1500", expected: []int{5, 1500}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+			which := make(map[string]bool)
+			for _, val := range tc.replace {
+				which[val] = true
+			}
+			x2, changed, err := x.WalkTarget(replace(which, tc.halt))
+			if !a.NoError(err) {
+				return
+			}
+			a.Equal(len(tc.expected) > 0, changed)
+			if !changed {
+				a.True(x == x2)
+				return
+			}
+			a.Len(x2.ByValSlice, size)
+			expected := make(map[int]bool)
+			for _, idx := range tc.expected {
+				expected[idx] = true
+			}
+			for i, elt := range x2.ByValSlice {
+				if expected[i] {
+					a.Equal("Replaced", elt.Val)
+				} else {
+					a.Equal(fmt.Sprint(i), elt.Val)
+				}
+				a.Equal(fmt.Sprint(i), x.ByValSlice[i].Val)
+			}
+		})
+	}
+}
+
+// TestArena verifies that rewriting a value using an arena produces
+// the same result, with fewer allocations once the arena is reused.
+func TestArena(t *testing.T) {
+	a := assert.New(t)
+	x, _ := l.NewContainer(true)
+	for i := 0; i < 100; i++ {
+		next, _ := l.NewContainer(i%2 == 0)
+		next.Container = x
+		x = next
+	}
+	replacement := &l.ByValType{Val: "Replaced"}
+	fn := func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if _, ok := x.(*l.ByValType); ok {
+			return ctx.Continue().ReplaceByValType(replacement)
+		}
+		return ctx.Continue()
+	}
+
+	expected, changed, err := l.WalkTarget(x, fn)
+	if !a.NoError(err) || !a.True(changed) {
+		return
+	}
+
+	var arena l.TargetArena
+	for i := 0; i < 2; i++ {
+		actual, changed, err := arena.WalkTarget(x, fn)
+		if a.NoError(err) && a.True(changed) {
+			a.Equal(expected, actual)
+		}
+		arena.Release()
+	}
+
+	withArena := testing.AllocsPerRun(10, func() {
+		_, _, _ = arena.WalkTarget(x, fn)
+		arena.Release()
+	})
+	withoutArena := testing.AllocsPerRun(10, func() {
+		_, _, _ = l.WalkTarget(x, fn)
+	})
+	a.Truef(withArena < withoutArena/2, "%v >= %v/2", withArena, withoutArena)
+}
+
+// TestCompare verifies the structural sharing between a value and the
+// result of rewriting it.
+func TestCompare(t *testing.T) {
+	a := assert.New(t)
+	x, _ := l.NewContainer(true)
+	inner, _ := l.NewContainer(true)
+	x.Container = inner
+
+	cloned, total := l.CompareTarget(x, x)
+	a.Zero(cloned)
+	a.NotZero(total)
+
+	x2, changed, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if x == inner.ByRefPtr {
+			return ctx.Continue().Replace(&l.ByRefType{Val: "Replaced"})
+		}
+		return ctx.Continue()
+	})
+	if !a.NoError(err) || !a.True(changed) {
+		return
+	}
+	cloned, shared := l.CompareTarget(x, x2)
+	// The replacement, both containers, and their by-value fields.
+	a.Equal(7, cloned)
+	a.Equal(total-7, shared)
+
+	cloned, shared = l.CompareTarget(nil, x2)
+	a.Equal(total, cloned)
+	a.Zero(shared)
 }
 
 // Ensure that if Replace() is called from a Post() callback, we discard
@@ -236,6 +645,68 @@ func TestPostReplaceIgnoresOldValues(t *testing.T) {
 	a.Nil(d2.ByRefPtr)
 }
 
+// Verify that rewrites of the children of a replacement, which was
+// given before they were visited, are kept.
+func TestPreReplaceKeepsChildRewrites(t *testing.T) {
+	rewrite := func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		switch t := x.(type) {
+		case *l.ContainerType:
+			if t.ByRef.Val == "old" {
+				return ctx.Continue().Replace(&l.ContainerType{
+					ByRef:    l.ByRefType{Val: "new"},
+					ByRefPtr: &l.ByRefType{Val: "new"},
+				})
+			}
+		case *l.ByRefType:
+			if t.Val == "new" {
+				return ctx.Continue().Replace(&l.ByRefType{Val: "rewritten"})
+			}
+		}
+		return ctx.Continue()
+	}
+
+	t.Run("root", func(t *testing.T) {
+		a := assert.New(t)
+		x := &l.ContainerType{ByRef: l.ByRefType{Val: "old"}}
+		x2, changed, err := x.WalkTarget(rewrite)
+		a.NoError(err)
+		a.True(changed)
+		a.Equal("rewritten", x2.ByRef.Val)
+		a.Equal("rewritten", x2.ByRefPtr.Val)
+		a.Equal("old", x.ByRef.Val)
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		a := assert.New(t)
+		x := &l.ContainerType{Container: &l.ContainerType{ByRef: l.ByRefType{Val: "old"}}}
+		x2, changed, err := x.WalkTarget(rewrite)
+		a.NoError(err)
+		a.True(changed)
+		a.Equal("rewritten", x2.Container.ByRef.Val)
+		a.Equal("rewritten", x2.Container.ByRefPtr.Val)
+		a.Equal("old", x.Container.ByRef.Val)
+	})
+
+	// A post-visit replacement supersedes the rewritten children.
+	t.Run("post", func(t *testing.T) {
+		a := assert.New(t)
+		x := &l.ContainerType{ByRef: l.ByRefType{Val: "old"}}
+		x2, changed, err := x.WalkTarget(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			d := rewrite(ctx, x)
+			if t, ok := x.(*l.ContainerType); ok && t.ByRef.Val == "old" {
+				d = d.Post(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+					return ctx.Continue().Replace(&l.ContainerType{ByRef: l.ByRefType{Val: "post"}})
+				})
+			}
+			return d
+		})
+		a.NoError(err)
+		a.True(changed)
+		a.Equal("post", x2.ByRef.Val)
+		a.Nil(x2.ByRefPtr)
+	})
+}
+
 func abstractWalk(x l.TargetAbstract) {
 	if x == nil {
 		return
@@ -0,0 +1,104 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"testing"
+
+	rw "github.com/cockroachdb/walkabout/reflectwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+// The fields of BinaryOp are renamed by their tags.
+func TestFieldNames(t *testing.T) {
+	a := assert.New(t)
+	x := &Calculation{Expr: &BinaryOp{"+", &Scalar{1}, &Scalar{3}}}
+	expected := []string{
+		"Calculation",
+		"Calculation/Expr",
+		"Calculation/Expr/lhs",
+		"Calculation/Expr/rhs",
+	}
+
+	t.Run("paths", func(t *testing.T) {
+		var paths []string
+		_, _, err := WalkCalc(x, func(ctx CalcContext, x Calc) CalcDecision {
+			paths = append(paths, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal(expected, paths)
+
+		paths = nil
+		_, _, err = rw.Walk[Calc](x, func(ctx rw.Context[Calc], x Calc) rw.Decision[Calc] {
+			paths = append(paths, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal(expected, paths)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		found, err := QueryCalc(x, "Calculation/Expr/rhs")
+		if a.NoError(err) && a.Len(found, 1) {
+			a.Equal(&Scalar{3}, found[0].Value)
+		}
+		found, err = QueryCalc(x, "Calculation/Expr/Right")
+		a.NoError(err)
+		a.Empty(found)
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		dump, err := DumpCalc(x)
+		a.NoError(err)
+		a.Equal(`(Calculation :Expr (BinaryOp :op "+" :lhs (Scalar) :rhs (Scalar)))`, dump)
+
+		parsed, err := ParseCalc(dump)
+		if a.NoError(err) {
+			a.Equal("+", parsed.(*Calculation).Expr.(*BinaryOp).Operator)
+		}
+	})
+
+	t.Run("map", func(t *testing.T) {
+		m, err := EncodeCalcMap(x)
+		if !a.NoError(err) {
+			return
+		}
+		op := m["Expr"].(map[string]interface{})
+		a.Equal("+", op["op"])
+		a.Contains(op, "lhs")
+		a.Contains(op, "rhs")
+		a.NotContains(op, "Left")
+
+		decoded, err := DecodeCalcMap(m)
+		if a.NoError(err) {
+			a.Equal("+", decoded.(*Calculation).Expr.(*BinaryOp).Operator)
+			a.NotNil(decoded.(*Calculation).Expr.(*BinaryOp).Left)
+		}
+	})
+
+	t.Run("encode", func(t *testing.T) {
+		buf, err := EncodeCalc(nil, x)
+		if !a.NoError(err) {
+			return
+		}
+		decoded, err := DecodeCalc(buf)
+		if a.NoError(err) {
+			a.Equal("+", decoded.(*Calculation).Expr.(*BinaryOp).Operator)
+			a.NotNil(decoded.(*Calculation).Expr.(*BinaryOp).Right)
+		}
+	})
+}
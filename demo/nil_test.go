@@ -0,0 +1,65 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	rw "github.com/cockroachdb/walkabout/reflectwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNilRoot(t *testing.T) {
+	a := assert.New(t)
+	called := false
+	fn := func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		called = true
+		return ctx.Continue()
+	}
+	var arena l.TargetArena
+	var stack l.TargetStack
+	walks := map[string]func(x l.Target) (l.Target, bool, error){
+		"func": func(x l.Target) (l.Target, bool, error) { return l.WalkTarget(x, fn) },
+		"arena": func(x l.Target) (l.Target, bool, error) {
+			return arena.WalkTarget(x, fn)
+		},
+		"stack": func(x l.Target) (l.Target, bool, error) {
+			return stack.WalkTarget(x, fn)
+		},
+		"options": func(x l.Target) (l.Target, bool, error) {
+			return l.TargetWalkOptions{Recover: true}.WalkTarget(x, fn)
+		},
+	}
+	for name, walk := range walks {
+		for _, x := range []l.Target{nil, (*l.ByRefType)(nil), (*l.ContainerType)(nil)} {
+			out, changed, err := walk(x)
+			a.Equal(l.ErrNilTarget, err, "%s %T", name, x)
+			a.Nil(out)
+			a.False(changed)
+		}
+	}
+
+	out, changed, err := (*l.ContainerType)(nil).WalkTarget(fn)
+	a.Equal(l.ErrNilTarget, err)
+	a.Nil(out)
+	a.False(changed)
+	a.False(called)
+
+	_, _, err = rw.Walk[l.Target]((*l.ContainerType)(nil), nil)
+	a.Equal(rw.ErrNilRoot, err)
+	a.Equal(l.ErrNilTarget.Error(), err.Error())
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWalkOnly verifies that a walk restricted to certain types sees
+// the same values, in the same order, as an unrestricted walk which
+// ignores the other types.
+func TestWalkOnly(t *testing.T) {
+	for _, only := range [][]l.TargetTypeID{
+		{l.TargetTypeByRefType},
+		{l.TargetTypeByValType},
+		{l.TargetTypeByRefType, l.TargetTypeByValType},
+		{l.TargetTypeContainerType},
+	} {
+		t.Run(only[0].String(), func(t *testing.T) {
+			a := assert.New(t)
+			x, _ := l.NewContainer(true)
+			wanted := make(map[l.TargetTypeID]bool)
+			for _, id := range only {
+				wanted[id] = true
+			}
+
+			var all l.TargetRecorder
+			_, _, err := x.WalkTarget(all.Walk)
+			a.NoError(err)
+			var expected []l.TargetEvent
+			for _, event := range all.Events {
+				if wanted[event.TypeID] {
+					expected = append(expected, event)
+				}
+			}
+			a.NotEmpty(expected)
+
+			var restricted l.TargetRecorder
+			_, _, err = l.TargetWalkOptions{Only: only}.WalkTarget(x, restricted.Walk)
+			a.NoError(err)
+			a.Equal(expected, restricted.Events)
+		})
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		fn := func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+			if ref, ok := y.(*l.ByRefType); ok {
+				return ctx.Continue().Replace(&l.ByRefType{Val: ref.Val + "!"})
+			}
+			return ctx.Continue()
+		}
+
+		expected, changed, err := x.WalkTarget(fn)
+		a.NoError(err)
+		a.True(changed)
+
+		counts := make(map[l.TargetTypeID]int)
+		opts := l.TargetWalkOptions{
+			Counts: counts,
+			Only:   []l.TargetTypeID{l.TargetTypeByRefType},
+		}
+		actual, changed, err := opts.WalkTarget(x, fn)
+		a.NoError(err)
+		a.True(changed)
+		a.Equal(expected, actual)
+		// Only the wanted structs are passed to the walker.
+		a.Equal(map[l.TargetTypeID]int{l.TargetTypeByRefType: 6}, counts)
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		a := assert.New(t)
+		var r l.TargetRecorder
+		x := &l.ByRefType{Val: "hello"}
+		_, changed, err := l.TargetWalkOptions{
+			Only: []l.TargetTypeID{l.TargetTypeByValType},
+		}.WalkTarget(x, r.Walk)
+		a.NoError(err)
+		a.False(changed)
+		a.Empty(r.Events)
+	})
+}
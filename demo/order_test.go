@@ -0,0 +1,73 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"testing"
+
+	rw "github.com/cockroachdb/walkabout/reflectwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+// The Value field of Let is declared after Body, but is tagged to be
+// visited first.
+func TestFieldOrder(t *testing.T) {
+	a := assert.New(t)
+	let := &Let{Body: &Scalar{1}, Name: "x", Value: &Scalar{2}}
+	expected := []string{
+		"Calculation",
+		"Calculation/Expr",
+		"Calculation/Expr/Value",
+		"Calculation/Expr/Body",
+	}
+
+	// A Calculation hands its Expr field to the engine.
+	t.Run("engine", func(t *testing.T) {
+		var paths []string
+		_, _, err := WalkCalc(&Calculation{Expr: let}, func(ctx CalcContext, x Calc) CalcDecision {
+			paths = append(paths, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal(expected, paths)
+	})
+
+	t.Run("inline", func(t *testing.T) {
+		var paths []string
+		_, _, err := let.WalkCalc(func(ctx CalcContext, x Calc) CalcDecision {
+			paths = append(paths, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal([]string{"Let", "Let/Value", "Let/Body"}, paths)
+	})
+
+	t.Run("reflectwalk", func(t *testing.T) {
+		var paths []string
+		_, _, err := rw.Walk[Calc](&Calculation{Expr: let}, func(ctx rw.Context[Calc], x Calc) rw.Decision[Calc] {
+			paths = append(paths, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal(expected, paths)
+	})
+
+	t.Run("abstract", func(t *testing.T) {
+		a.Equal(2, let.CalcCount())
+		a.Equal(let.Value, let.CalcAt(0).(*Scalar))
+		a.Equal(let.Body, let.CalcAt(1).(*Scalar))
+	})
+}
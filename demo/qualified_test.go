@@ -0,0 +1,52 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"reflect"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/demo/ast"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQualifiedString(t *testing.T) {
+	a := assert.New(t)
+	pkg := reflect.TypeOf(l.ContainerType{}).PkgPath()
+
+	tcs := []struct {
+		id       interface{ QualifiedString() string }
+		expected string
+	}{
+		{l.TargetTypeContainerType, pkg + ".ContainerType"},
+		{l.TargetTypeContainerTypePtr, "*" + pkg + ".ContainerType"},
+		{l.TargetTypeByRefTypePtrSlice, "[]*" + pkg + ".ByRefType"},
+		{l.TargetTypeTarget, pkg + ".Target"},
+		{l.TargetTypeTargetSlice, "[]" + pkg + ".Target"},
+		{l.TargetTypeID(0), "<NIL>"},
+		// The root interface is declared in another package.
+		{ast.NodeTypeNode, "github.com/cockroachdb/walkabout/demo/ast/base.Node"},
+		{ast.NodeTypeNodeSlice, "[]github.com/cockroachdb/walkabout/demo/ast/base.Node"},
+		{ast.NodeTypeIdentPtr, "*github.com/cockroachdb/walkabout/demo/ast.Ident"},
+	}
+	for _, tc := range tcs {
+		a.Equal(tc.expected, tc.id.QualifiedString())
+	}
+
+	// The unqualified names are unchanged.
+	a.Equal("[]*ByRefType", l.TargetTypeByRefTypePtrSlice.String())
+}
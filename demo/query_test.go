@@ -0,0 +1,99 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	inner := &l.ContainerType{ByRef: l.ByRefType{Val: "inner"}}
+	x := &l.ContainerType{
+		ByRef:         l.ByRefType{Val: "outer"},
+		ByRefPtrSlice: []*l.ByRefType{{Val: "a"}, nil, {Val: "c"}},
+		TargetSlice:   []l.Target{inner, &l.ByValType{Val: "v"}},
+	}
+	// Cycles are not followed by **.
+	inner.Container = x
+
+	tcs := []struct {
+		expr  string
+		paths []string
+	}{
+		{"ContainerType", []string{"ContainerType"}},
+		{"ByRefType", nil},
+		{"ContainerType/ByRef", []string{"ContainerType/ByRef"}},
+		{"*/ByRefPtrSlice[*]", []string{
+			"ContainerType/ByRefPtrSlice[0]",
+			"ContainerType/ByRefPtrSlice[2]",
+		}},
+		{"*/ByRefPtrSlice[1]", nil},
+		{"*/ByRefPtrSlice[2]", []string{"ContainerType/ByRefPtrSlice[2]"}},
+		{"*/ByRefPtrSlice[9]", nil},
+		{"ContainerType/TargetSlice[*]/ByRef", []string{"ContainerType/TargetSlice[0]/ByRef"}},
+		{"*/TargetSlice/*", []string{
+			"ContainerType/TargetSlice[0]",
+			"ContainerType/TargetSlice[1]",
+		}},
+		{"**/ByRef", []string{
+			"ContainerType/ByRef",
+			"ContainerType/TargetSlice[0]/ByRef",
+		}},
+		{"*/**/Container", []string{"ContainerType/TargetSlice[0]/Container"}},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.expr, func(t *testing.T) {
+			a := assert.New(t)
+			matches, err := l.QueryTarget(x, tc.expr)
+			if !a.NoError(err) {
+				return
+			}
+			var paths []string
+			for _, m := range matches {
+				paths = append(paths, m.Path)
+			}
+			a.Equal(tc.paths, paths)
+		})
+	}
+
+	a := assert.New(t)
+	matches, err := l.QueryTarget(x, "*/TargetSlice[0]/ByRef")
+	if a.NoError(err) && a.Len(matches, 1) {
+		a.Equal(&inner.ByRef, matches[0].Value)
+	}
+
+	matches, err = l.QueryTarget(nil, "*")
+	a.NoError(err)
+	a.Empty(matches)
+
+	for expr, msg := range map[string]string{
+		"":           "empty query",
+		"*//ByRef":   `bad step "" in "*//ByRef": missing field name`,
+		"*/A[x]":     `bad step "A[x]" in "*/A[x]": bad index "x"`,
+		"*/A[1":      `bad step "A[1" in "*/A[1": bad selector`,
+		"*/**[*]":    `bad step "**[*]" in "*/**[*]": ** cannot have selectors`,
+		"*/A*":       `bad step "A*" in "*/A*": bad field name`,
+		"*/A[-1]":    `bad step "A[-1]" in "*/A[-1]": bad index "-1"`,
+		"*/A[0][*]x": `bad step "A[0][*]x" in "*/A[0][*]x": bad selector`,
+	} {
+		_, err := l.QueryTarget(x, expr)
+		a.EqualError(err, msg, expr)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// recovered returns the value passed to panic by fn, if any.
+func recovered(fn func()) (r interface{}) {
+	defer func() { r = recover() }()
+	fn()
+	return nil
+}
+
+// TestCheckRaces uses nested walks to create overlapping walks of the
+// same root in a deterministic order.
+func TestCheckRaces(t *testing.T) {
+	opts := l.TargetWalkOptions{CheckRaces: true}
+	rewrite := func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if ref, ok := x.(*l.ByRefType); ok {
+			return ctx.Continue().Replace(&l.ByRefType{Val: ref.Val + "!"})
+		}
+		return ctx.Continue()
+	}
+	read := func(ctx l.TargetContext, x l.Target) l.TargetDecision { return ctx.Continue() }
+
+	t.Run("concurrent reads", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+			if y == l.Target(x) {
+				_, _, err := opts.WalkTarget(x, read)
+				a.NoError(err)
+			}
+			return ctx.Continue()
+		})
+		a.NoError(err)
+	})
+
+	t.Run("rewrite during a read", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		r := recovered(func() {
+			_, _, _ = opts.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+				if y == l.Target(x) {
+					_, _, _ = opts.WalkTarget(x, rewrite)
+				}
+				return ctx.Continue()
+			})
+		})
+		a.EqualError(r.(error), "walkabout: concurrent rewrite of ContainerType at "+
+			"ContainerType/ByRef: 1 other walk(s) of the same root are in progress; "+
+			"walks which replace values must not overlap other walks of the same root")
+	})
+
+	t.Run("read during a rewrite", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		r := recovered(func() {
+			_, _, _ = opts.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+				if y != l.Target(x) {
+					return rewrite(ctx, y)
+				}
+				return ctx.Continue().Post(func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+					_, _, _ = opts.WalkTarget(x, read)
+					return ctx.Continue()
+				})
+			})
+		})
+		a.EqualError(r.(error), "walkabout: ContainerType is being walked while "+
+			"another walk is rewriting it at ContainerType/ByRef; walks which "+
+			"replace values must not overlap other walks of the same root")
+	})
+
+	t.Run("sequential rewrites", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		for i := 0; i < 2; i++ {
+			_, changed, err := opts.WalkTarget(x, rewrite)
+			a.NoError(err)
+			a.True(changed)
+		}
+	})
+
+	t.Run("unchecked", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+			if y == l.Target(x) {
+				_, _, err := l.WalkTarget(x, rewrite)
+				a.NoError(err)
+			}
+			return ctx.Continue()
+		})
+		a.NoError(err)
+	})
+
+	t.Run("recover", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+			if y == l.Target(x) {
+				_, _, err := l.TargetWalkOptions{CheckRaces: true, Recover: true}.WalkTarget(x, rewrite)
+				var panicErr *l.TargetPanicError
+				if a.True(errors.As(err, &panicErr)) {
+					a.Equal("ContainerType/ByRef", panicErr.Path)
+				}
+			}
+			return ctx.Continue()
+		})
+		a.NoError(err)
+	})
+
+	t.Run("goroutines", func(t *testing.T) {
+		// Walks which only read a shared tree never panic.
+		x, _ := l.NewContainer(true)
+		var wg sync.WaitGroup
+		errs := make(chan error, 8)
+		for i := 0; i < cap(errs); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, err := opts.WalkTarget(x, read)
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"math/rand"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandom(t *testing.T) {
+	a := assert.New(t)
+
+	t.Run("deterministic", func(t *testing.T) {
+		a := assert.New(t)
+		cfg := l.TargetRandomConfig{NilChance: 0.2}
+		x := l.RandomTarget(rand.New(rand.NewSource(1)), cfg)
+		y := l.RandomTarget(rand.New(rand.NewSource(1)), cfg)
+		xs, err := l.DumpTarget(x)
+		a.NoError(err)
+		ys, err := l.DumpTarget(y)
+		a.NoError(err)
+		a.Equal(xs, ys)
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		a := assert.New(t)
+		cfg := l.TargetRandomConfig{
+			Weights: map[string]float64{"ByRefType": 0, "ByValType": 0},
+		}
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 10; i++ {
+			_, ok := l.RandomTarget(r, cfg).(*l.ContainerType)
+			a.True(ok)
+		}
+
+		cfg.Weights["ContainerType"] = 0
+		a.Nil(l.RandomTarget(r, cfg))
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		a := assert.New(t)
+		r := rand.New(rand.NewSource(1))
+		cfg := l.TargetRandomConfig{
+			MaxDepth: 3,
+			Weights:  map[string]float64{"ByRefType": 0, "ByValType": 0},
+		}
+		x := l.RandomTarget(r, cfg).(*l.ContainerType)
+		if a.NotNil(x.Container) && a.NotNil(x.Container.Container) {
+			a.Nil(x.Container.Container.Container)
+			a.Nil(x.Container.Container.TargetSlice)
+		}
+
+		cfg.MaxNodes = 1
+		x = l.RandomTarget(r, cfg).(*l.ContainerType)
+		a.Nil(x.Container)
+		a.Nil(x.AnotherTarget)
+	})
+
+	// Check some properties of the generated code against random trees.
+	r := rand.New(rand.NewSource(42))
+	for i := 0; i < 100; i++ {
+		x := l.RandomTarget(r, l.TargetRandomConfig{NilChance: 0.3, MaxNodes: 50})
+		text, err := l.DumpTarget(x)
+		if !a.NoError(err) {
+			return
+		}
+
+		parsed, err := l.ParseTarget(text)
+		if a.NoError(err) {
+			again, err := l.DumpTarget(parsed)
+			a.NoError(err)
+			a.Equal(text, again)
+		}
+
+		data, err := l.EncodeTarget(nil, x)
+		if a.NoError(err) {
+			decoded, err := l.DecodeTarget(data)
+			a.NoError(err)
+			again, err := l.EncodeTarget(nil, decoded)
+			a.NoError(err)
+			a.Equal(data, again, text)
+		}
+
+		_, changed, err := l.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.False(changed)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordingWalker(t *testing.T) {
+	a := assert.New(t)
+	x := &l.ContainerType{
+		ByRefSlice:  []l.ByRefType{{}, {}},
+		Container:   &l.ContainerType{},
+		TargetSlice: []l.Target{nil, &l.ByRefType{}},
+	}
+
+	r := l.NewRecordingTargetWalker()
+	_, _, err := l.WalkTarget(x, r.Walk)
+	a.NoError(err)
+	a.Equal([]string{
+		"pre ContainerType ContainerType",
+		"pre ContainerType/ByRef ByRefType",
+		"post ContainerType/ByRef ByRefType",
+		"pre ContainerType/ByRefSlice[0] ByRefType",
+		"post ContainerType/ByRefSlice[0] ByRefType",
+		"pre ContainerType/ByRefSlice[1] ByRefType",
+		"post ContainerType/ByRefSlice[1] ByRefType",
+		"pre ContainerType/ByVal ByValType",
+		"post ContainerType/ByVal ByValType",
+		"pre ContainerType/Container ContainerType",
+		"pre ContainerType/Container/ByRef ByRefType",
+		"post ContainerType/Container/ByRef ByRefType",
+		"pre ContainerType/Container/ByVal ByValType",
+		"post ContainerType/Container/ByVal ByValType",
+		"post ContainerType/Container ContainerType",
+		"pre ContainerType/TargetSlice[1] ByRefType",
+		"post ContainerType/TargetSlice[1] ByRefType",
+		"post ContainerType ContainerType",
+	}, r.Strings())
+	a.Equal(l.TargetEvent{Kind: l.TargetEventKindPre, Path: "ContainerType", TypeID: l.TargetTypeContainerType}, r.Events[0])
+
+	// Values visited by actions are located by their index.
+	r.Reset()
+	_, _, err = l.WalkTarget(x, func(ctx l.TargetContext, y l.Target) l.TargetDecision {
+		if y == x {
+			r.Walk(ctx, y)
+			return ctx.Actions(
+				ctx.ActionCall(func() error { return nil }),
+				ctx.ActionVisit(x.TargetSlice[1]),
+			)
+		}
+		return r.Walk(ctx, y)
+	})
+	a.NoError(err)
+	a.Equal([]string{
+		"pre ContainerType ContainerType",
+		"pre ContainerType/action[1] ByRefType",
+		"post ContainerType/action[1] ByRefType",
+	}, r.Strings())
+}
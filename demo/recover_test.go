@@ -0,0 +1,95 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	x, _ := l.NewContainer(true)
+	opts := l.TargetWalkOptions{Recover: true}
+
+	t.Run("walker", func(t *testing.T) {
+		a := assert.New(t)
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			if ctx.Path() == "ContainerType/ByRefPtr" {
+				panic("boom")
+			}
+			return ctx.Continue()
+		})
+		var pe *l.TargetPanicError
+		if a.True(errors.As(err, &pe)) {
+			a.Equal("ContainerType/ByRefPtr", pe.Path)
+			a.Equal("ByRefType", pe.Type)
+			a.NotZero(pe.TypeID)
+			a.Equal("boom", pe.Value)
+			a.Contains(string(pe.Stack), "recover_test.go")
+		}
+		a.EqualError(err, "panic while visiting ByRefType at ContainerType/ByRefPtr: boom")
+	})
+
+	t.Run("post", func(t *testing.T) {
+		a := assert.New(t)
+		errBoom := errors.New("boom")
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			if ctx.Path() != "ContainerType/TargetSlice[1]" {
+				return ctx.Continue()
+			}
+			return ctx.Continue().Post(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				panic(errBoom)
+			})
+		})
+		a.True(errors.Is(err, errBoom))
+		a.EqualError(err, "panic while visiting ByValType at ContainerType/TargetSlice[1]: boom")
+	})
+
+	t.Run("action", func(t *testing.T) {
+		a := assert.New(t)
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			return ctx.Actions(ctx.ActionCall(func() error { panic("boom") }))
+		})
+		var pe *l.TargetPanicError
+		if a.True(errors.As(err, &pe)) {
+			a.Equal("ContainerType/action[0]", pe.Path)
+			a.Empty(pe.Type)
+			a.Zero(pe.TypeID)
+		}
+		a.EqualError(err, "panic at ContainerType/action[0]: boom")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		a := assert.New(t)
+		panicking := func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			panic("boom")
+		}
+		a.PanicsWithValue("boom", func() { _, _, _ = l.WalkTarget(x, panicking) })
+		a.PanicsWithValue("boom", func() { _, _, _ = l.TargetWalkOptions{}.WalkTarget(x, panicking) })
+
+		// The walker remains usable after a panic.
+		count := 0
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			count++
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.NotZero(count)
+	})
+}
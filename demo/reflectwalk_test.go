@@ -0,0 +1,211 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	rw "github.com/cockroachdb/walkabout/reflectwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptedWalk walks x with the generated code and with reflectwalk,
+// making the same sequence of decisions as FuzzWalk, and fails unless
+// the walks are indistinguishable.
+func scriptedWalk(t *testing.T, seed int64, ops []byte) {
+	t.Helper()
+	x := l.RandomTarget(rand.New(rand.NewSource(seed)),
+		l.TargetRandomConfig{NilChance: 0.3, MaxNodes: 64})
+	if x == nil {
+		return
+	}
+
+	// Each walk takes the same operations, in the same order.
+	nextOp := func() func() byte {
+		idx := 0
+		return func() byte {
+			if len(ops) == 0 {
+				return fuzzContinue
+			}
+			op := ops[idx%len(ops)] % fuzzOpCount
+			idx++
+			return op
+		}
+	}
+
+	var generated []string
+	next := nextOp()
+	var fn l.TargetWalkerFn
+	fn = func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		generated = append(generated, fmt.Sprintf("%s %T", ctx.Path(), x))
+		switch next() {
+		case fuzzSkip:
+			return ctx.Skip()
+		case fuzzHalt:
+			return ctx.Halt()
+		case fuzzError:
+			return ctx.Error(errFuzz)
+		case fuzzReplace:
+			return ctx.Continue().Replace(fuzzReplacement(x))
+		case fuzzPostReplace:
+			return ctx.Continue().Post(func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				generated = append(generated, fmt.Sprintf("post %s %T", ctx.Path(), x))
+				return ctx.Continue().Replace(fuzzReplacement(x))
+			})
+		case fuzzActions:
+			actions := []l.TargetAction{ctx.ActionCall(func() error {
+				generated = append(generated, "call")
+				return nil
+			})}
+			if c, ok := x.(*l.ContainerType); ok {
+				actions = append(actions, ctx.ActionVisit(&c.ByRef), ctx.ActionVisit(c.ByVal))
+				if c.AnotherTarget != nil {
+					actions = append(actions, ctx.ActionVisit(c.AnotherTarget))
+				}
+			}
+			return ctx.Actions(actions...)
+		case fuzzIntercept:
+			return ctx.Continue().Intercept(fn)
+		default:
+			return ctx.Continue()
+		}
+	}
+	got, gotChanged, gotErr := l.WalkTarget(x, fn)
+
+	var reference []string
+	next = nextOp()
+	var rfn rw.WalkerFn[l.Target]
+	rfn = func(ctx rw.Context[l.Target], x l.Target) rw.Decision[l.Target] {
+		reference = append(reference, fmt.Sprintf("%s %T", ctx.Path(), x))
+		switch next() {
+		case fuzzSkip:
+			return ctx.Skip()
+		case fuzzHalt:
+			return ctx.Halt()
+		case fuzzError:
+			return ctx.Error(errFuzz)
+		case fuzzReplace:
+			return ctx.Continue().Replace(fuzzReplacement(x))
+		case fuzzPostReplace:
+			return ctx.Continue().Post(func(ctx rw.Context[l.Target], x l.Target) rw.Decision[l.Target] {
+				reference = append(reference, fmt.Sprintf("post %s %T", ctx.Path(), x))
+				return ctx.Continue().Replace(fuzzReplacement(x))
+			})
+		case fuzzActions:
+			actions := []rw.Action[l.Target]{ctx.ActionCall(func() error {
+				reference = append(reference, "call")
+				return nil
+			})}
+			if c, ok := x.(*l.ContainerType); ok {
+				actions = append(actions, ctx.ActionVisit(&c.ByRef), ctx.ActionVisit(c.ByVal))
+				if c.AnotherTarget != nil {
+					actions = append(actions, ctx.ActionVisit(c.AnotherTarget))
+				}
+			}
+			return ctx.Actions(actions...)
+		case fuzzIntercept:
+			return ctx.Continue().Intercept(rfn)
+		default:
+			return ctx.Continue()
+		}
+	}
+	want, wantChanged, wantErr := rw.Walk(x, rfn)
+
+	a := assert.New(t)
+	a.Equal(reference, generated, "visits")
+	a.Equal(fmt.Sprint(wantErr), fmt.Sprint(gotErr), "errors")
+	a.Equal(wantChanged, gotChanged, "changed")
+	a.True(reflect.DeepEqual(want, got), "results")
+}
+
+// Walkable is a visitable interface whose implementation has a field
+// which is excluded by a walkabout tag.
+type Walkable interface{ isWalkable() }
+
+// WalkableNode implements Walkable.
+type WalkableNode struct {
+	Kept     *WalkableNode
+	Excluded *WalkableNode `walkabout:"-"`
+}
+
+func (*WalkableNode) isWalkable() {}
+
+func TestReflectWalk(t *testing.T) {
+	t.Run("api", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+
+		var paths []string
+		out, changed, err := rw.Walk[l.Target](x, func(ctx rw.Context[l.Target], x l.Target) rw.Decision[l.Target] {
+			paths = append(paths, ctx.Path())
+			if ref, ok := x.(*l.ByRefType); ok {
+				return ctx.Continue().Replace(&l.ByRefType{Val: ref.Val + "!"})
+			}
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.True(changed)
+		a.Contains(paths, "ContainerType/ByRefSlice[1]")
+		a.Equal(x.ByRef.Val+"!", out.(*l.ContainerType).ByRef.Val)
+		a.NotEqual(x.ByRef.Val, out.(*l.ContainerType).ByRef.Val)
+
+		_, _, err = rw.Walk[l.Target](x, func(ctx rw.Context[l.Target], x l.Target) rw.Decision[l.Target] {
+			if _, ok := x.(*l.ByRefType); ok {
+				return ctx.Continue().Replace(&l.ByValType{})
+			}
+			return ctx.Continue()
+		})
+		a.EqualError(err, "ContainerType/ByRef: type ByValType is not assignable to ByRefType")
+
+		_, _, err = rw.Walk[l.Target](nil, nil)
+		a.Equal(rw.ErrNilRoot, err)
+	})
+
+	t.Run("excluded", func(t *testing.T) {
+		a := assert.New(t)
+		x := &WalkableNode{Kept: &WalkableNode{}, Excluded: &WalkableNode{}}
+
+		var paths []string
+		_, _, err := rw.Walk[Walkable](x, func(ctx rw.Context[Walkable], x Walkable) rw.Decision[Walkable] {
+			paths = append(paths, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal([]string{"WalkableNode", "WalkableNode/Kept"}, paths)
+	})
+
+	t.Run("scripted", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 500; i++ {
+			ops := make([]byte, r.Intn(8))
+			r.Read(ops)
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				scriptedWalk(t, int64(i), ops)
+			})
+		}
+	})
+}
+
+func FuzzReflectWalk(f *testing.F) {
+	f.Add(int64(0), []byte{})
+	f.Add(int64(3), []byte{fuzzActions, fuzzPostReplace, fuzzIntercept, fuzzHalt})
+	f.Add(int64(5), []byte{fuzzIntercept, fuzzReplace, fuzzActions, fuzzReplace, fuzzPostReplace})
+	f.Fuzz(scriptedWalk)
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpParse(t *testing.T) {
+	a := assert.New(t)
+	x := &l.ContainerType{
+		ByRef:         l.ByRefType{Val: "ref"},
+		ByValSlice:    []l.ByValType{},
+		ByRefPtrSlice: []*l.ByRefType{{Val: "a"}, nil},
+		AnotherTarget: &l.ByValType{Val: "needs \"quotes\""},
+		TargetSlice: []l.Target{
+			&l.ByRefType{Val: "a long value which will not fit on one line"},
+			&l.ByRefType{Val: "another long value"},
+		},
+	}
+
+	const expected = `(ContainerType
+  :ByRef (ByRefType :Val "ref")
+  :ByRefPtrSlice [(ByRefType :Val "a") nil]
+  :ByVal (ByValType)
+  :ByValSlice []
+  :AnotherTarget (ByValType :Val "needs \"quotes\"")
+  :TargetSlice [(ByRefType :Val "a long value which will not fit on one line")
+                (ByRefType :Val "another long value")])`
+
+	text, err := l.DumpTarget(x)
+	if !a.NoError(err) {
+		return
+	}
+	a.Equal(expected, text)
+
+	y, err := l.ParseTarget(text)
+	if a.NoError(err) {
+		a.Equal(x, y)
+	}
+
+	// Comments and arbitrary whitespace are allowed.
+	y, err = l.ParseTarget(`
+; A fixture.
+(ContainerType :ByVal (ByValType :Val "v")   ; trailing comment
+	:Container (ContainerType))`)
+	if a.NoError(err) {
+		a.Equal(&l.ContainerType{
+			ByVal:     l.ByValType{Val: "v"},
+			Container: &l.ContainerType{},
+		}, y)
+	}
+
+	text, err = l.DumpTarget(nil)
+	if a.NoError(err) {
+		a.Equal("nil", text)
+	}
+	y, err = l.ParseTarget(text)
+	if a.NoError(err) {
+		a.Nil(y)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tcs := []struct {
+		text string
+		err  string
+	}{
+		{"", "1:1: unexpected end of input"},
+		{"(ContainerType", "1:15: unexpected end of input"},
+		{"(ContainerType ByRef)", "1:16: expecting a :Field in ContainerType"},
+		{"(ContainerType :ByRef nope)", "1:27: unexpected \"nope\""},
+		{"(ContainerType :ByRef (ByRefType :Val \"x))", "1:39: unterminated string"},
+		{"(ByRefType :Val \"a\" :Val \"b\")", "1:25: duplicate field Val in ByRefType"},
+		{"(ByRefType) (ByRefType)", "1:13: unexpected \"(\" after value"},
+		{"(ByRefType\n  :Val 1.5x)", "2:12: bad number \"1.5x\""},
+		{"(ByRefType :Val 1)", "Target.Val: expecting a string, got json.Number"},
+		{"(NeverType)", "Target: unknown type NeverType"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.text, func(t *testing.T) {
+			a := assert.New(t)
+			_, err := l.ParseTarget(tc.text)
+			a.EqualError(err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"reflect"
+	"testing"
+
+	e "github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// byRef returns the entry for ByRefType. TargetTypeByRefType is not
+// used, since it is not an integer if the code is generated with
+// string TypeIDs.
+func byRef(m e.TypeMap) *e.TypeData {
+	for i := range m {
+		if m[i].Name == "ByRefType" {
+			return &m[i]
+		}
+	}
+	panic("ByRefType not found")
+}
+
+// TestStaleTypeMap verifies that the engine refuses a TypeMap which
+// no longer describes the structs that it was generated from.
+func TestStaleTypeMap(t *testing.T) {
+	stale := func(t *testing.T, fn func(m e.TypeMap)) {
+		a := assert.New(t)
+		m := targetTypeMap()
+		fn(m)
+		defer func() {
+			err, _ := recover().(error)
+			if a.Error(err) {
+				a.Contains(err.Error(), "ByRefType has changed")
+				a.Contains(err.Error(), "regenerate walkabout output")
+			}
+		}()
+		e.New(m)
+	}
+
+	t.Run("current", func(t *testing.T) {
+		assert.NotPanics(t, func() { e.New(targetTypeMap()) })
+	})
+	t.Run("field added", func(t *testing.T) {
+		stale(t, func(m e.TypeMap) {
+			byRef(m).Type = reflect.TypeOf(struct {
+				Val   string
+				Extra int
+			}{})
+		})
+	})
+	t.Run("field type changed", func(t *testing.T) {
+		stale(t, func(m e.TypeMap) {
+			byRef(m).Type = reflect.TypeOf(struct{ Val []byte }{})
+		})
+	})
+	t.Run("offset changed", func(t *testing.T) {
+		stale(t, func(m e.TypeMap) {
+			byRef(m).Scalars[0].Offset++
+		})
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"math/rand"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// countVisits returns the number of structs in x which match the
+// predicate.
+func countVisits(x l.Target, pred func(l.Target) bool) int {
+	count := 0
+	_, _, _ = l.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		if pred(x) {
+			count++
+		}
+		return ctx.Continue()
+	})
+	return count
+}
+
+func TestShrink(t *testing.T) {
+	a := assert.New(t)
+
+	// The property fails if the tree contains a ByRefType with a bad
+	// value.
+	hasVal := func(val string) func(l.Target) bool {
+		return func(x l.Target) bool {
+			return countVisits(x, func(x l.Target) bool {
+				t, ok := x.(*l.ByRefType)
+				return ok && t.Val == val
+			}) > 0
+		}
+	}
+
+	x := &l.ContainerType{
+		ByRef:      l.ByRefType{Val: "a"},
+		ByRefSlice: []l.ByRefType{{Val: "b"}, {Val: "bad"}, {Val: "c"}},
+		Container:  &l.ContainerType{ByValPtr: &l.ByValType{Val: "d"}},
+		TargetSlice: []l.Target{
+			&l.ContainerType{ByRefPtr: &l.ByRefType{Val: "e"}},
+			nil,
+		},
+	}
+	shrunk, err := l.ShrinkTarget(x, hasVal("bad"))
+	a.NoError(err)
+	text, err := l.DumpTarget(shrunk)
+	a.NoError(err)
+	a.Equal(`(ContainerType
+  :ByRef (ByRefType)
+  :ByRefSlice [(ByRefType :Val "bad")]
+  :ByVal (ByValType))`, text)
+	// The original is unchanged.
+	a.Equal("bad", x.ByRefSlice[1].Val)
+	a.Len(x.TargetSlice, 2)
+
+	_, err = l.ShrinkTarget(x, hasVal("missing"))
+	a.EqualError(err, "the value does not fail")
+
+	// Shrink random trees which contain at least three ContainerTypes.
+	isContainer := func(x l.Target) bool {
+		_, ok := x.(*l.ContainerType)
+		return ok
+	}
+	fails := func(x l.Target) bool {
+		return countVisits(x, isContainer) >= 3
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		x := l.RandomTarget(r, l.TargetRandomConfig{MaxDepth: 5})
+		if !fails(x) {
+			continue
+		}
+		shrunk, err := l.ShrinkTarget(x, fails)
+		if !a.NoError(err) {
+			return
+		}
+		// Only the three structs, and the fields which they contain by
+		// value, remain.
+		a.Equal(3, countVisits(shrunk, isContainer))
+		a.Equal(9, countVisits(shrunk, func(l.Target) bool { return true }))
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package ast is used to check the generation of code for types which
+package sqlast_test
+
+// This file contains benchmarks which run the generated walker, the
+// pretty-printer, and the rewrite rules over the corpus. Run with,
+// e.g.:
+//	go test ./demo/sqlast -run '^$' -bench .
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/cockroachdb/walkabout/demo/sqlast"
+)
+
+// benchCorpus returns the statements in the corpus, and a number of
+// larger statements made by mutating them.
+func benchCorpus(b *testing.B) []Node {
+	var ret []Node
+	for i, tc := range corpus {
+		ret = append(ret, tc.stmt)
+		for j := 0; j < 4; j++ {
+			ret = append(ret, mutate(b, tc.stmt, int64(i*4+j)))
+		}
+	}
+	return ret
+}
+
+// BenchmarkNoop should demonstrate that walking the corpus is
+// allocation-free.
+func BenchmarkNoop(b *testing.B) {
+	nodes := benchCorpus(b)
+	fn := func(ctx NodeContext, x Node) NodeDecision { return ctx.Continue() }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, x := range nodes {
+			if _, _, err := WalkNode(x, fn); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	nodes := benchCorpus(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, x := range nodes {
+			if _, err := Format(x); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSimplify(b *testing.B) {
+	nodes := benchCorpus(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, x := range nodes {
+			if _, err := Simplify(x); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// BenchmarkRandom walks random trees, to measure the cost of deeper
+// nesting than is found in the corpus.
+func BenchmarkRandom(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	var nodes []Node
+	for i := 0; i < 16; i++ {
+		nodes = append(nodes, RandomNode(r, NodeRandomConfig{MaxDepth: 12, MaxNodes: 1024}))
+	}
+	fn := func(ctx NodeContext, x Node) NodeDecision { return ctx.Continue() }
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, x := range nodes {
+			if _, _, err := WalkNode(x, fn); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
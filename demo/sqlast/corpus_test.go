@@ -0,0 +1,271 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package ast is used to check the generation of code for types which
+package sqlast_test
+
+// This file contains the corpus of statements which is used by the
+// tests, benchmarks, and fuzz targets in this package.
+
+import (
+	"testing"
+
+	. "github.com/cockroachdb/walkabout/demo/sqlast"
+	"github.com/stretchr/testify/assert"
+)
+
+func id(name string) *Ident { return &Ident{Name: name} }
+
+func col(table, column string) *ColumnRef {
+	return &ColumnRef{Table: id(table), Column: id(column)}
+}
+
+func table(name, as string) *TableName {
+	ret := &TableName{Name: id(name)}
+	if as != "" {
+		ret.As = id(as)
+	}
+	return ret
+}
+
+func cmp(op string, left, right Expr) *ComparisonExpr {
+	return &ComparisonExpr{Op: op, Left: left, Right: right}
+}
+
+func num(val int64) *IntLit { return &IntLit{Val: val} }
+
+// corpus contains statements and their formatted text.
+var corpus = []struct {
+	stmt Statement
+	sql  string
+}{
+	{
+		stmt: &SelectStmt{
+			Exprs: []SelectExpr{&Star{}},
+			From:  []TableExpr{table("users", "")},
+		},
+		sql: `SELECT * FROM users`,
+	},
+	{
+		stmt: &SelectStmt{
+			Distinct: true,
+			Exprs: []SelectExpr{
+				&AliasedExpr{Expr: col("u", "name")},
+				&AliasedExpr{
+					Expr: &FuncCall{Name: id("count"), Args: []Expr{col("o", "id")}},
+					As:   id("orders"),
+				},
+			},
+			From: []TableExpr{&Join{
+				Kind:  "LEFT",
+				Left:  table("users", "u"),
+				Right: table("orders", "o"),
+				On:    cmp("=", col("u", "id"), col("o", "user_id")),
+			}},
+			Where: &AndExpr{
+				Left: &NotExpr{Expr: &IsNullExpr{Expr: col("u", "email")}},
+				Right: &OrExpr{
+					Left:  cmp(">", col("o", "total"), &BinaryExpr{Op: "*", Left: num(10), Right: num(100)}),
+					Right: &BoolLit{Val: false},
+				},
+			},
+			GroupBy: []Expr{col("u", "name")},
+			Having:  cmp(">=", &FuncCall{Name: id("count"), Args: []Expr{num(1)}}, num(1)),
+			OrderBy: []*OrderBy{{Expr: id("orders"), Desc: true}},
+			Limit:   &Limit{Count: num(10), Offset: &Placeholder{Index: 1}},
+		},
+		sql: `SELECT DISTINCT u.name, count(o.id) AS orders ` +
+			`FROM users AS u LEFT JOIN orders AS o ON u.id = o.user_id ` +
+			`WHERE (NOT (u.email IS NULL)) AND ((o.total > (10 * 100)) OR FALSE) ` +
+			`GROUP BY u.name HAVING count(1) >= 1 ORDER BY orders DESC LIMIT 10 OFFSET $1`,
+	},
+	{
+		stmt: &SelectStmt{
+			With: &With{
+				Recursive: true,
+				CTEs: []*CTE{{
+					Name: id("tree"),
+					Query: &UnionStmt{
+						All: true,
+						Left: &SelectStmt{
+							Exprs: []SelectExpr{&AliasedExpr{Expr: id("id")}},
+							From:  []TableExpr{table("nodes", "")},
+							Where: &IsNullExpr{Expr: id("parent")},
+						},
+						Right: &SelectStmt{
+							Exprs: []SelectExpr{&AliasedExpr{Expr: col("n", "id")}},
+							From: []TableExpr{&Join{
+								Left:  table("nodes", "n"),
+								Right: table("tree", "t"),
+								On:    cmp("=", col("n", "parent"), col("t", "id")),
+							}},
+						},
+					},
+				}},
+			},
+			Exprs: []SelectExpr{&AliasedExpr{Expr: &FuncCall{
+				Name: id("count"), Distinct: true, Args: []Expr{id("id")},
+			}}},
+			From: []TableExpr{table("tree", "")},
+		},
+		sql: `WITH RECURSIVE tree AS (` +
+			`SELECT id FROM nodes WHERE parent IS NULL UNION ALL ` +
+			`SELECT n.id FROM nodes AS n JOIN tree AS t ON n.parent = t.id) ` +
+			`SELECT count(DISTINCT id) FROM tree`,
+	},
+	{
+		stmt: &SelectStmt{
+			Exprs: []SelectExpr{
+				&Star{Table: id("p")},
+				&AliasedExpr{
+					Expr: &CaseExpr{
+						Whens: []*When{
+							{Cond: cmp("<", id("price"), num(10)), Result: &StringLit{Val: "cheap"}},
+							{Cond: &BetweenExpr{Expr: id("price"), Lo: num(10), Hi: &BinaryExpr{Op: "-", Left: num(101), Right: num(1)}}, Result: &StringLit{Val: "fair"}},
+						},
+						Else: &StringLit{Val: "don't ask"},
+					},
+					As: id("Band"),
+				},
+				&AliasedExpr{Expr: &CastExpr{Expr: &FloatLit{Val: 1.5}, Type: &TypeName{Name: "DECIMAL"}}},
+			},
+			From: []TableExpr{
+				table("products", "p"),
+				&AliasedSubquery{
+					Select: &SelectStmt{
+						Exprs: []SelectExpr{&AliasedExpr{Expr: &UnaryExpr{Op: "-", Expr: num(1)}}},
+					},
+					As: id("s"),
+				},
+			},
+			Where: &AndExpr{
+				Left: &InExpr{Expr: id("category"), Not: true, List: []Expr{&StringLit{Val: "a"}, &NullLit{}}},
+				Right: &AndExpr{
+					Left: &LikeExpr{Expr: id("name"), Pattern: &StringLit{Val: "%x%"}},
+					Right: &ExistsExpr{Subquery: &Subquery{Select: &SelectStmt{
+						Exprs: []SelectExpr{&AliasedExpr{Expr: num(1)}},
+						From:  []TableExpr{table("stock", "")},
+						Where: &AndExpr{
+							Left:  cmp("=", col("stock", "product"), col("p", "id")),
+							Right: &NotExpr{Expr: &NotExpr{Expr: &BoolLit{Val: true}}},
+						},
+					}}},
+				},
+			},
+		},
+		sql: `SELECT p.*, CASE WHEN price < 10 THEN 'cheap' ` +
+			`WHEN price BETWEEN 10 AND (101 - 1) THEN 'fair' ELSE 'don''t ask' END AS "Band", ` +
+			`CAST(1.5 AS DECIMAL) ` +
+			`FROM products AS p, (SELECT -1) AS s ` +
+			`WHERE (category NOT IN ('a', NULL)) AND ((name LIKE '%x%') AND ` +
+			`EXISTS (SELECT 1 FROM stock WHERE (stock.product = p.id) AND (NOT (NOT TRUE))))`,
+	},
+	{
+		stmt: &InsertStmt{
+			Table:   table("users", ""),
+			Columns: []*Ident{id("id"), id("name")},
+			Rows: []*Tuple{
+				{Exprs: []Expr{num(1), &StringLit{Val: "alice"}}},
+				{Exprs: []Expr{&BinaryExpr{Op: "+", Left: num(1), Right: num(1)}, &Placeholder{Index: 2}}},
+			},
+		},
+		sql: `INSERT INTO users (id, name) VALUES (1, 'alice'), (1 + 1, $2)`,
+	},
+	{
+		stmt: &InsertStmt{
+			Table: table("archive", ""),
+			Select: &SelectStmt{
+				Exprs: []SelectExpr{&Star{}},
+				From:  []TableExpr{table("orders", "")},
+				Where: &NotExpr{Expr: cmp("<", id("placed"), &FuncCall{Name: id("now")})},
+			},
+		},
+		sql: `INSERT INTO archive SELECT * FROM orders WHERE NOT (placed < now())`,
+	},
+	{
+		stmt: &UpdateStmt{
+			Table: table("accounts", ""),
+			Set: []*Assignment{
+				{Column: id("balance"), Value: &BinaryExpr{Op: "-", Left: id("balance"), Right: &Placeholder{Index: 1}}},
+				{Column: id("updated"), Value: &FuncCall{Name: id("now")}},
+			},
+			Where: &AndExpr{
+				Left:  cmp("=", id("id"), &Placeholder{Index: 2}),
+				Right: &BoolLit{Val: true},
+			},
+		},
+		sql: `UPDATE accounts SET balance = balance - $1, updated = now() WHERE (id = $2) AND TRUE`,
+	},
+	{
+		stmt: &DeleteStmt{
+			Table: table("sessions", ""),
+			Where: &OrExpr{
+				Left:  cmp("<", id("expires"), &FuncCall{Name: id("now")}),
+				Right: &NotExpr{Expr: &IsNullExpr{Expr: id("revoked"), Not: true}},
+			},
+		},
+		sql: `DELETE FROM sessions WHERE (expires < now()) OR (NOT (revoked IS NOT NULL))`,
+	},
+}
+
+func TestFormat(t *testing.T) {
+	for _, tc := range corpus {
+		s, err := Format(tc.stmt)
+		if assert.NoError(t, err) {
+			assert.Equal(t, tc.sql, s)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	expected := []string{
+		`SELECT * FROM users`,
+		`SELECT DISTINCT u.name, count(o.id) AS orders ` +
+			`FROM users AS u LEFT JOIN orders AS o ON u.id = o.user_id ` +
+			`WHERE (u.email IS NOT NULL) AND (o.total > 1000) ` +
+			`GROUP BY u.name HAVING count(1) >= 1 ORDER BY orders DESC LIMIT 10 OFFSET $1`,
+		corpus[2].sql,
+		`SELECT p.*, CASE WHEN price < 10 THEN 'cheap' ` +
+			`WHEN price BETWEEN 10 AND 100 THEN 'fair' ELSE 'don''t ask' END AS "Band", ` +
+			`CAST(1.5 AS DECIMAL) ` +
+			`FROM products AS p, (SELECT -1) AS s ` +
+			`WHERE (category NOT IN ('a', NULL)) AND ((name LIKE '%x%') AND ` +
+			`EXISTS (SELECT 1 FROM stock WHERE stock.product = p.id))`,
+		`INSERT INTO users (id, name) VALUES (1, 'alice'), (2, $2)`,
+		`INSERT INTO archive SELECT * FROM orders WHERE placed >= now()`,
+		`UPDATE accounts SET balance = balance - $1, updated = now() WHERE id = $2`,
+		`DELETE FROM sessions WHERE (expires < now()) OR (revoked IS NULL)`,
+	}
+	for i, tc := range corpus {
+		a := assert.New(t)
+		x, err := Simplify(tc.stmt)
+		if !a.NoError(err) {
+			continue
+		}
+		s, err := Format(x)
+		if a.NoError(err) {
+			a.Equal(expected[i], s)
+		}
+
+		// The input is not modified, and unchanged statements are
+		// returned as-is.
+		s, err = Format(tc.stmt)
+		a.NoError(err)
+		a.Equal(tc.sql, s)
+		if tc.sql == expected[i] {
+			a.True(x == Node(tc.stmt))
+		}
+	}
+}
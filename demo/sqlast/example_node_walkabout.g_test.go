@@ -0,0 +1,67 @@
+// Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.
+// source: sqlast.go
+// walkabout version: dev
+
+package sqlast
+
+import "fmt"
+
+// ExampleWalkNode prints the type of each value which is visited in
+// a SelectStmt.
+func ExampleWalkNode() {
+	var x Node = &SelectStmt{}
+	_, _, err := WalkNode(x, func(ctx NodeContext, x Node) NodeDecision {
+		fmt.Printf("%T\n", x)
+		return ctx.Continue()
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// *sqlast.SelectStmt
+}
+
+// ExampleWalkNode_replace replaces a SelectStmt with a new value. The
+// value which was walked is not modified.
+func ExampleWalkNode_replace() {
+	var x Node = &SelectStmt{}
+	y, _, err := WalkNode(x, func(ctx NodeContext, x Node) NodeDecision {
+		if _, ok := x.(*SelectStmt); ok {
+			return ctx.Continue().Replace(&SelectStmt{})
+		}
+		return ctx.Continue()
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%T\n", y)
+	// Output: *sqlast.SelectStmt
+}
+
+// ExampleWalkNode_actions calls functions in place of visiting the
+// fields of a SelectStmt, and then calls a post-visit function.
+func ExampleWalkNode_actions() {
+	var x Node = &SelectStmt{}
+	_, _, err := WalkNode(x, func(ctx NodeContext, x Node) NodeDecision {
+		return ctx.Actions(
+			ctx.ActionCall(func() error {
+				fmt.Println("first")
+				return nil
+			}),
+			ctx.ActionCall(func() error {
+				fmt.Println("second")
+				return nil
+			}),
+		).Post(func(ctx NodeContext, x Node) NodeDecision {
+			fmt.Printf("post %T\n", x)
+			return ctx.Continue()
+		})
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// first
+	// second
+	// post *sqlast.SelectStmt
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package ast is used to check the generation of code for types which
+package sqlast_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/cockroachdb/walkabout/demo/sqlast"
+)
+
+// This example prints a statement using Format, which interleaves
+// keywords with visits to the children of each node.
+func Example_format() {
+	stmt := &SelectStmt{
+		Exprs: []SelectExpr{
+			&AliasedExpr{Expr: &ColumnRef{Table: &Ident{Name: "u"}, Column: &Ident{Name: "name"}}},
+		},
+		From: []TableExpr{&TableName{Name: &Ident{Name: "users"}, As: &Ident{Name: "u"}}},
+		Where: &AndExpr{
+			Left:  &ComparisonExpr{Op: ">", Left: &Ident{Name: "age"}, Right: &Placeholder{Index: 1}},
+			Right: &LikeExpr{Expr: &Ident{Name: "name"}, Not: true, Pattern: &StringLit{Val: "A%"}},
+		},
+	}
+	s, err := Format(stmt)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(s)
+	// Output: SELECT u.name FROM users AS u WHERE (age > $1) AND (name NOT LIKE 'A%')
+}
+
+// This example simplifies a filter. The rules are applied repeatedly,
+// so folding the comparison of constants allows the conjunction to be
+// simplified as well.
+func Example_simplify() {
+	stmt := &DeleteStmt{
+		Table: &TableName{Name: &Ident{Name: "t"}},
+		Where: &AndExpr{
+			Left: &NotExpr{Expr: &IsNullExpr{Expr: &Ident{Name: "x"}}},
+			Right: &ComparisonExpr{
+				Op:    "<",
+				Left:  &BinaryExpr{Op: "*", Left: &IntLit{Val: 6}, Right: &IntLit{Val: 7}},
+				Right: &IntLit{Val: 100},
+			},
+		},
+	}
+	x, err := Simplify(stmt)
+	if err != nil {
+		panic(err)
+	}
+	before, _ := Format(stmt)
+	after, _ := Format(x)
+	fmt.Println(before)
+	fmt.Println(after)
+	// Output:
+	// DELETE FROM t WHERE (NOT (x IS NULL)) AND ((6 * 7) < 100)
+	// DELETE FROM t WHERE x IS NOT NULL
+}
+
+// This example writes a custom rule, which lowercases the names of
+// functions, and applies it along with the default rules. The rule
+// returns a new node, rather than modifying the one which it is given.
+func Example_rule() {
+	lower := func(x Expr) (Expr, bool) {
+		call, ok := x.(*FuncCall)
+		if !ok || call.Name == nil || call.Name.Name == strings.ToLower(call.Name.Name) {
+			return nil, false
+		}
+		cpy := *call
+		cpy.Name = &Ident{Name: strings.ToLower(call.Name.Name)}
+		return &cpy, true
+	}
+
+	stmt := &SelectStmt{
+		Exprs: []SelectExpr{&AliasedExpr{Expr: &FuncCall{
+			Name: &Ident{Name: "COALESCE"},
+			Args: []Expr{
+				&FuncCall{Name: &Ident{Name: "MAX"}, Args: []Expr{&Ident{Name: "x"}}},
+				&BinaryExpr{Op: "-", Left: &IntLit{Val: 0}, Right: &IntLit{Val: 1}},
+			},
+		}}},
+		From: []TableExpr{&TableName{Name: &Ident{Name: "t"}}},
+	}
+	x, err := Rewrite(stmt, append([]Rule{lower}, SimplifyRules...)...)
+	if err != nil {
+		panic(err)
+	}
+	s, _ := Format(x)
+	fmt.Println(s)
+	// Output: SELECT coalesce(max(x), -1) FROM t
+}
@@ -0,0 +1,403 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package ast is used to check the generation of code for types which
+package sqlast
+
+// This file contains a pretty-printer, which is a visitor that replaces
+// the visitation of each node's fields with a sequence of actions. The
+// actions interleave keywords and punctuation with visits to the
+// node's children, so that no node needs to know how to print any
+// other.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format returns the SQL text of a tree. Children which are nil are
+// omitted, so that any tree, including a random one, can be printed.
+func Format(n Node) (string, error) {
+	var w strings.Builder
+	if _, _, err := WalkNode(n, func(ctx NodeContext, x Node) NodeDecision {
+		return format(ctx, &w, x)
+	}); err != nil {
+		return "", err
+	}
+	return w.String(), nil
+}
+
+// script accumulates the actions which print a node.
+type script struct {
+	ctx  *NodeContext
+	w    *strings.Builder
+	acts []NodeAction
+}
+
+// text appends an action which writes the string.
+func (s *script) text(str string) {
+	s.acts = append(s.acts, s.ctx.ActionCall(func() error {
+		s.w.WriteString(str)
+		return nil
+	}))
+}
+
+// textIf appends an action which writes the string if cond is true.
+func (s *script) textIf(cond bool, str string) {
+	if cond {
+		s.text(str)
+	}
+}
+
+// present returns false if x is a nil interface or pointer.
+func present[T comparable](x T) bool {
+	var zero T
+	return x != zero
+}
+
+// child appends a visit to x, if it is present.
+func child[T interface {
+	Node
+	comparable
+}](s *script, x T) {
+	if present(x) {
+		s.acts = append(s.acts, s.ctx.ActionVisit(x))
+	}
+}
+
+// clause appends the keyword and a visit to x, if it is present.
+func clause[T interface {
+	Node
+	comparable
+}](s *script, keyword string, x T) {
+	if present(x) {
+		s.text(keyword)
+		child(s, x)
+	}
+}
+
+// list appends visits to the elements of xs which are present,
+// separated by sep.
+func list[T interface {
+	Node
+	comparable
+}](s *script, xs []T, sep string) {
+	first := true
+	for _, x := range xs {
+		if !present(x) {
+			continue
+		}
+		s.textIf(!first, sep)
+		child(s, x)
+		first = false
+	}
+}
+
+// operand appends a visit to an expression which is an operand of
+// another, adding parentheses if the expression contains an operator.
+func operand(s *script, x Expr) {
+	switch x.(type) {
+	case *AndExpr, *BetweenExpr, *BinaryExpr, *ComparisonExpr, *InExpr,
+		*IsNullExpr, *LikeExpr, *NotExpr, *OrExpr, *UnaryExpr:
+		s.text("(")
+		child(s, x)
+		s.text(")")
+	default:
+		child(s, x)
+	}
+}
+
+// not returns the NOT keyword which precedes an operator, if it is
+// negated.
+func not(negated bool) string {
+	if negated {
+		return " NOT"
+	}
+	return ""
+}
+
+// format prints leaves directly and returns the actions which print
+// any other node.
+func format(ctx NodeContext, w *strings.Builder, x Node) NodeDecision {
+	s := &script{ctx: &ctx, w: w}
+	switch t := x.(type) {
+	case *SelectStmt:
+		if present(t.With) {
+			child(s, t.With)
+			s.text(" ")
+		}
+		s.text("SELECT ")
+		s.textIf(t.Distinct, "DISTINCT ")
+		list(s, t.Exprs, ", ")
+		if len(t.From) > 0 {
+			s.text(" FROM ")
+			list(s, t.From, ", ")
+		}
+		clause(s, " WHERE ", t.Where)
+		if len(t.GroupBy) > 0 {
+			s.text(" GROUP BY ")
+			list(s, t.GroupBy, ", ")
+		}
+		clause(s, " HAVING ", t.Having)
+		if len(t.OrderBy) > 0 {
+			s.text(" ORDER BY ")
+			list(s, t.OrderBy, ", ")
+		}
+		clause(s, " ", t.Limit)
+
+	case *UnionStmt:
+		child(s, t.Left)
+		s.text(" UNION ")
+		s.textIf(t.All, "ALL ")
+		child(s, t.Right)
+
+	case *InsertStmt:
+		s.text("INSERT INTO ")
+		child(s, t.Table)
+		if len(t.Columns) > 0 {
+			s.text(" (")
+			list(s, t.Columns, ", ")
+			s.text(")")
+		}
+		if len(t.Rows) > 0 {
+			s.text(" VALUES ")
+			list(s, t.Rows, ", ")
+		}
+		clause(s, " ", t.Select)
+
+	case *UpdateStmt:
+		s.text("UPDATE ")
+		child(s, t.Table)
+		s.text(" SET ")
+		list(s, t.Set, ", ")
+		clause(s, " WHERE ", t.Where)
+
+	case *DeleteStmt:
+		s.text("DELETE FROM ")
+		child(s, t.Table)
+		clause(s, " WHERE ", t.Where)
+
+	case *With:
+		s.text("WITH ")
+		s.textIf(t.Recursive, "RECURSIVE ")
+		list(s, t.CTEs, ", ")
+
+	case *CTE:
+		child(s, t.Name)
+		s.text(" AS (")
+		child(s, t.Query)
+		s.text(")")
+
+	case *AliasedExpr:
+		child(s, t.Expr)
+		clause(s, " AS ", t.As)
+
+	case *Star:
+		if present(t.Table) {
+			child(s, t.Table)
+			s.text(".")
+		}
+		s.text("*")
+
+	case *TableName:
+		child(s, t.Name)
+		clause(s, " AS ", t.As)
+
+	case *Join:
+		child(s, t.Left)
+		s.text(" ")
+		s.textIf(t.Kind != "", t.Kind+" ")
+		s.text("JOIN ")
+		child(s, t.Right)
+		clause(s, " ON ", t.On)
+
+	case *AliasedSubquery:
+		s.text("(")
+		child(s, t.Select)
+		s.text(")")
+		clause(s, " AS ", t.As)
+
+	case *OrderBy:
+		child(s, t.Expr)
+		s.textIf(t.Desc, " DESC")
+
+	case *Limit:
+		s.text("LIMIT ")
+		if present(t.Count) {
+			child(s, t.Count)
+		} else {
+			s.text("ALL")
+		}
+		clause(s, " OFFSET ", t.Offset)
+
+	case *Assignment:
+		child(s, t.Column)
+		s.text(" = ")
+		child(s, t.Value)
+
+	case *ColumnRef:
+		if present(t.Table) {
+			child(s, t.Table)
+			s.text(".")
+		}
+		child(s, t.Column)
+
+	case *BinaryExpr:
+		operand(s, t.Left)
+		s.text(" " + t.Op + " ")
+		operand(s, t.Right)
+
+	case *UnaryExpr:
+		s.text(t.Op)
+		operand(s, t.Expr)
+
+	case *ComparisonExpr:
+		operand(s, t.Left)
+		s.text(" " + t.Op + " ")
+		operand(s, t.Right)
+
+	case *AndExpr:
+		operand(s, t.Left)
+		s.text(" AND ")
+		operand(s, t.Right)
+
+	case *OrExpr:
+		operand(s, t.Left)
+		s.text(" OR ")
+		operand(s, t.Right)
+
+	case *NotExpr:
+		s.text("NOT ")
+		operand(s, t.Expr)
+
+	case *IsNullExpr:
+		operand(s, t.Expr)
+		s.text(" IS" + not(t.Not) + " NULL")
+
+	case *InExpr:
+		operand(s, t.Expr)
+		s.text(not(t.Not) + " IN (")
+		list(s, t.List, ", ")
+		s.text(")")
+
+	case *BetweenExpr:
+		operand(s, t.Expr)
+		s.text(not(t.Not) + " BETWEEN ")
+		operand(s, t.Lo)
+		s.text(" AND ")
+		operand(s, t.Hi)
+
+	case *LikeExpr:
+		operand(s, t.Expr)
+		s.text(not(t.Not) + " LIKE ")
+		operand(s, t.Pattern)
+
+	case *FuncCall:
+		child(s, t.Name)
+		s.text("(")
+		s.textIf(t.Distinct, "DISTINCT ")
+		list(s, t.Args, ", ")
+		s.text(")")
+
+	case *CaseExpr:
+		s.text("CASE")
+		clause(s, " ", t.Operand)
+		for _, when := range t.Whens {
+			clause(s, " ", when)
+		}
+		clause(s, " ELSE ", t.Else)
+		s.text(" END")
+
+	case *When:
+		s.text("WHEN ")
+		child(s, t.Cond)
+		s.text(" THEN ")
+		child(s, t.Result)
+
+	case *CastExpr:
+		s.text("CAST(")
+		child(s, t.Expr)
+		s.text(" AS ")
+		child(s, t.Type)
+		s.text(")")
+
+	case *Tuple:
+		s.text("(")
+		list(s, t.Exprs, ", ")
+		s.text(")")
+
+	case *Subquery:
+		s.text("(")
+		child(s, t.Select)
+		s.text(")")
+
+	case *ExistsExpr:
+		s.text("EXISTS ")
+		child(s, t.Subquery)
+
+	// Leaves are printed immediately.
+	case *Ident:
+		w.WriteString(quoteIdent(t.Name))
+		return ctx.Continue()
+	case *IntLit:
+		w.WriteString(strconv.FormatInt(t.Val, 10))
+		return ctx.Continue()
+	case *FloatLit:
+		w.WriteString(strconv.FormatFloat(t.Val, 'g', -1, 64))
+		return ctx.Continue()
+	case *StringLit:
+		w.WriteString("'" + strings.ReplaceAll(t.Val, "'", "''") + "'")
+		return ctx.Continue()
+	case *BoolLit:
+		if t.Val {
+			w.WriteString("TRUE")
+		} else {
+			w.WriteString("FALSE")
+		}
+		return ctx.Continue()
+	case *NullLit:
+		w.WriteString("NULL")
+		return ctx.Continue()
+	case *Placeholder:
+		w.WriteString("$" + strconv.Itoa(t.Index))
+		return ctx.Continue()
+	case *TypeName:
+		w.WriteString(t.Name)
+		return ctx.Continue()
+
+	default:
+		return ctx.Error(fmt.Errorf("cannot format a %T", x))
+	}
+	return ctx.Actions(s.acts...)
+}
+
+// quoteIdent returns the name, which is quoted unless it is a simple
+// lowercase identifier.
+func quoteIdent(name string) string {
+	simple := name != ""
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			simple = false
+		}
+	}
+	if simple {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
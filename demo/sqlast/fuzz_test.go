@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package ast is used to check the generation of code for types which
+package sqlast_test
+
+// This file contains a native fuzz target for the pretty-printer and
+// the rewrite rules. Run with, e.g.:
+//	go test ./demo/sqlast -run '^$' -fuzz FuzzSimplify
+
+import (
+	"math/rand"
+	"testing"
+
+	. "github.com/cockroachdb/walkabout/demo/sqlast"
+)
+
+// randomExpr returns a random expression.
+func randomExpr(r *rand.Rand) Expr {
+	for {
+		if x, ok := RandomNode(r, NodeRandomConfig{NilChance: 0.3, MaxNodes: 16}).(Expr); ok {
+			return x
+		}
+	}
+}
+
+// mutate replaces expressions in a statement from the corpus with
+// random ones, which are chosen by the seed.
+func mutate(tb testing.TB, stmt Statement, seed int64) Node {
+	r := rand.New(rand.NewSource(seed))
+	x, _, err := WalkNode(stmt, func(ctx NodeContext, x Node) NodeDecision {
+		switch x.(type) {
+		// These types are held in fields which cannot hold any other
+		// expression.
+		case *Ident, *Subquery, *Tuple:
+		case Expr:
+			if r.Intn(4) == 0 {
+				return ctx.Skip().Replace(randomExpr(r))
+			}
+		}
+		return ctx.Continue()
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return x
+}
+
+func FuzzSimplify(f *testing.F) {
+	for i := range corpus {
+		f.Add(uint8(i), int64(i))
+	}
+
+	f.Fuzz(func(t *testing.T, which uint8, seed int64) {
+		x := mutate(t, corpus[int(which)%len(corpus)].stmt, seed)
+		before, err := Format(x)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		y, err := Simplify(x)
+		if err != nil {
+			t.Fatalf("%s: %v", before, err)
+		}
+		if _, err := Format(y); err != nil {
+			t.Fatal(err)
+		}
+
+		// The input is not modified.
+		if after, err := Format(x); err != nil || after != before {
+			t.Fatalf("input was modified: %s", after)
+		}
+		// The rules have been applied until none apply.
+		if z, err := Simplify(y); err != nil || z != y {
+			s, _ := Format(z)
+			t.Fatalf("not idempotent: %s", s)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package ast is used to check the generation of code for types which
+package sqlast
+
+// This file contains a small rewrite-rule engine. Rules are applied to
+// each expression before its children are visited, and then to the
+// children of the replacement. A rule may apply to an expression once
+// its children have been rewritten, so the walk is repeated until no
+// rule applies.
+
+import "math"
+
+// A Rule rewrites an expression. It returns the replacement and true
+// if it applies to the expression; the expression must not be
+// modified.
+type Rule func(x Expr) (Expr, bool)
+
+// SimplifyRules are the rules which are applied by Simplify.
+var SimplifyRules = []Rule{FoldConstants, SimplifyNot, SimplifyBoolean}
+
+// Simplify applies SimplifyRules to the tree.
+func Simplify(n Node) (Node, error) {
+	return Rewrite(n, SimplifyRules...)
+}
+
+// Rewrite applies the rules to each expression in the tree until none
+// of them apply. The rules must not undo each other's changes. The tree
+// which is passed in is not modified; only the nodes which enclose a
+// rewritten expression are copied.
+func Rewrite(n Node, rules ...Rule) (Node, error) {
+	fn := func(ctx NodeContext, x Node) NodeDecision {
+		expr, ok := x.(Expr)
+		// Names are not rewritten, since they may appear in fields
+		// which cannot hold any other expression.
+		if _, isIdent := x.(*Ident); !ok || isIdent {
+			return ctx.Continue()
+		}
+		changed := false
+		for applied := true; applied; {
+			applied = false
+			for _, rule := range rules {
+				if next, ok := rule(expr); ok {
+					expr, applied, changed = next, true, true
+				}
+			}
+		}
+		if changed {
+			return ctx.Continue().Replace(expr)
+		}
+		return ctx.Continue()
+	}
+	for {
+		next, changed, err := WalkNode(n, fn)
+		if err != nil || !changed {
+			return next, err
+		}
+		n = next
+	}
+}
+
+// FoldConstants evaluates integer arithmetic and comparisons of
+// integer literals. Arithmetic which would overflow is not folded.
+func FoldConstants(x Expr) (Expr, bool) {
+	switch t := x.(type) {
+	case *BinaryExpr:
+		l, r, ok := intOperands(t.Left, t.Right)
+		if !ok {
+			return nil, false
+		}
+		var ret int64
+		switch t.Op {
+		case "+":
+			if (r > 0 && l > math.MaxInt64-r) || (r < 0 && l < math.MinInt64-r) {
+				return nil, false
+			}
+			ret = l + r
+		case "-":
+			if (r < 0 && l > math.MaxInt64+r) || (r > 0 && l < math.MinInt64+r) {
+				return nil, false
+			}
+			ret = l - r
+		case "*":
+			ret = l * r
+			if l != 0 && (ret/l != r || (l == -1 && r == math.MinInt64)) {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+		return &IntLit{Val: ret}, true
+
+	case *ComparisonExpr:
+		l, r, ok := intOperands(t.Left, t.Right)
+		if !ok {
+			return nil, false
+		}
+		var ret bool
+		switch t.Op {
+		case "=":
+			ret = l == r
+		case "<>", "!=":
+			ret = l != r
+		case "<":
+			ret = l < r
+		case "<=":
+			ret = l <= r
+		case ">":
+			ret = l > r
+		case ">=":
+			ret = l >= r
+		default:
+			return nil, false
+		}
+		return &BoolLit{Val: ret}, true
+	}
+	return nil, false
+}
+
+// intOperands returns the values of two integer literals.
+func intOperands(left, right Expr) (l, r int64, ok bool) {
+	a, ok := left.(*IntLit)
+	if !ok || a == nil {
+		return 0, 0, false
+	}
+	b, ok := right.(*IntLit)
+	if !ok || b == nil {
+		return 0, 0, false
+	}
+	return a.Val, b.Val, true
+}
+
+// negatedComparisons maps comparison operators to their negations.
+var negatedComparisons = map[string]string{
+	"=": "<>", "<>": "=", "!=": "=",
+	"<": ">=", ">=": "<",
+	">": "<=", "<=": ">",
+}
+
+// SimplifyNot removes double negations and pushes a negation into the
+// operator which it encloses, where the operator has a negated form.
+func SimplifyNot(x Expr) (Expr, bool) {
+	not, ok := x.(*NotExpr)
+	if !ok || not == nil {
+		return nil, false
+	}
+	switch t := not.Expr.(type) {
+	case *NotExpr:
+		if t != nil && t.Expr != nil {
+			return t.Expr, true
+		}
+	case *BoolLit:
+		if t != nil {
+			return &BoolLit{Val: !t.Val}, true
+		}
+	case *ComparisonExpr:
+		if t != nil {
+			if op, ok := negatedComparisons[t.Op]; ok {
+				return &ComparisonExpr{Op: op, Left: t.Left, Right: t.Right}, true
+			}
+		}
+	case *IsNullExpr:
+		if t != nil {
+			return &IsNullExpr{Expr: t.Expr, Not: !t.Not}, true
+		}
+	case *InExpr:
+		if t != nil {
+			return &InExpr{Expr: t.Expr, Not: !t.Not, List: t.List}, true
+		}
+	case *BetweenExpr:
+		if t != nil {
+			return &BetweenExpr{Expr: t.Expr, Not: !t.Not, Lo: t.Lo, Hi: t.Hi}, true
+		}
+	case *LikeExpr:
+		if t != nil {
+			return &LikeExpr{Expr: t.Expr, Not: !t.Not, Pattern: t.Pattern}, true
+		}
+	}
+	return nil, false
+}
+
+// SimplifyBoolean removes boolean literals from conjunctions and
+// disjunctions. A conjunction with FALSE is FALSE and a disjunction
+// with TRUE is TRUE, even if the other operand is NULL.
+func SimplifyBoolean(x Expr) (Expr, bool) {
+	var left, right Expr
+	var identity bool
+	switch t := x.(type) {
+	case *AndExpr:
+		if t == nil {
+			return nil, false
+		}
+		left, right, identity = t.Left, t.Right, true
+	case *OrExpr:
+		if t == nil {
+			return nil, false
+		}
+		left, right, identity = t.Left, t.Right, false
+	default:
+		return nil, false
+	}
+	for _, pair := range [2][2]Expr{{left, right}, {right, left}} {
+		lit, ok := pair[0].(*BoolLit)
+		if !ok || lit == nil || pair[1] == nil {
+			continue
+		}
+		if lit.Val == identity {
+			return pair[1], true
+		}
+		return &BoolLit{Val: !identity}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,388 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package ast is used to check the generation of code for types which
+// Package sqlast is a larger demo which models the syntax tree of a
+// small SQL dialect. It exercises deep nesting, hierarchies of
+// interfaces, and slices of interfaces, and its statements are used as
+// the corpus for benchmarks and fuzzing. The pretty-printer in
+// format.go and the rewrite rules in rewrite.go show how the generated
+// walker is used in a realistic setting.
+package sqlast
+
+//go:generate -command walkabout go run ../..
+//go:generate walkabout --examples Node
+
+// Node is implemented by every type in the syntax tree.
+type Node interface {
+	node()
+}
+
+// Statement is a complete query or modification.
+type Statement interface {
+	Node
+	statement()
+}
+
+// Expr is a scalar expression.
+type Expr interface {
+	Node
+	expr()
+}
+
+// TableExpr is a source of rows in a FROM clause.
+type TableExpr interface {
+	Node
+	tableExpr()
+}
+
+// SelectExpr is an entry in the projection of a SELECT.
+type SelectExpr interface {
+	Node
+	selectExpr()
+}
+
+// Statements.
+
+// SelectStmt is a SELECT query.
+type SelectStmt struct {
+	With     *With
+	Distinct bool
+	Exprs    []SelectExpr
+	From     []TableExpr
+	Where    Expr
+	GroupBy  []Expr
+	Having   Expr
+	OrderBy  []*OrderBy
+	Limit    *Limit
+}
+
+// UnionStmt combines the rows of two queries.
+type UnionStmt struct {
+	All         bool
+	Left, Right Statement
+}
+
+// InsertStmt adds rows to a table, from either a list of tuples or a
+// query.
+type InsertStmt struct {
+	Table   *TableName
+	Columns []*Ident
+	Rows    []*Tuple
+	Select  Statement
+}
+
+// UpdateStmt modifies the rows of a table.
+type UpdateStmt struct {
+	Table *TableName
+	Set   []*Assignment
+	Where Expr
+}
+
+// DeleteStmt removes rows from a table.
+type DeleteStmt struct {
+	Table *TableName
+	Where Expr
+}
+
+// Clauses.
+
+// With declares common table expressions.
+type With struct {
+	Recursive bool
+	CTEs      []*CTE
+}
+
+// CTE is a named query in a WITH clause.
+type CTE struct {
+	Name  *Ident
+	Query Statement
+}
+
+// AliasedExpr is a projected expression with an optional alias.
+type AliasedExpr struct {
+	Expr Expr
+	As   *Ident
+}
+
+// Star projects every column, optionally of a single table.
+type Star struct {
+	Table *Ident
+}
+
+// TableName refers to a table, with an optional alias.
+type TableName struct {
+	Name *Ident
+	As   *Ident
+}
+
+// Join combines two sources of rows.
+type Join struct {
+	Kind        string
+	Left, Right TableExpr
+	On          Expr
+}
+
+// AliasedSubquery uses the rows of a query as a table.
+type AliasedSubquery struct {
+	Select Statement
+	As     *Ident
+}
+
+// OrderBy is an entry in an ORDER BY clause.
+type OrderBy struct {
+	Expr Expr
+	Desc bool
+}
+
+// Limit restricts the number of rows which are returned.
+type Limit struct {
+	Count, Offset Expr
+}
+
+// Assignment is an entry in the SET clause of an UPDATE.
+type Assignment struct {
+	Column *Ident
+	Value  Expr
+}
+
+// Expressions.
+
+// Ident is a name.
+type Ident struct {
+	Name string
+}
+
+// ColumnRef refers to a column, optionally qualified by a table.
+type ColumnRef struct {
+	Table  *Ident
+	Column *Ident
+}
+
+// IntLit is an integer literal.
+type IntLit struct {
+	Val int64
+}
+
+// FloatLit is a floating-point literal.
+type FloatLit struct {
+	Val float64
+}
+
+// StringLit is a string literal.
+type StringLit struct {
+	Val string
+}
+
+// BoolLit is TRUE or FALSE.
+type BoolLit struct {
+	Val bool
+}
+
+// NullLit is NULL.
+type NullLit struct{}
+
+// Placeholder is a positional parameter, such as $1.
+type Placeholder struct {
+	Index int
+}
+
+// BinaryExpr is an arithmetic or string operation, such as a + b or
+// a || b.
+type BinaryExpr struct {
+	Op          string
+	Left, Right Expr
+}
+
+// UnaryExpr is a prefix operation, such as -a.
+type UnaryExpr struct {
+	Op   string
+	Expr Expr
+}
+
+// ComparisonExpr compares two values, such as a = b.
+type ComparisonExpr struct {
+	Op          string
+	Left, Right Expr
+}
+
+// AndExpr is a conjunction.
+type AndExpr struct {
+	Left, Right Expr
+}
+
+// OrExpr is a disjunction.
+type OrExpr struct {
+	Left, Right Expr
+}
+
+// NotExpr is a negation.
+type NotExpr struct {
+	Expr Expr
+}
+
+// IsNullExpr is a IS [NOT] NULL.
+type IsNullExpr struct {
+	Expr Expr
+	Not  bool
+}
+
+// InExpr is a [NOT] IN (b, c, ...).
+type InExpr struct {
+	Expr Expr
+	Not  bool
+	List []Expr
+}
+
+// BetweenExpr is a [NOT] BETWEEN lo AND hi.
+type BetweenExpr struct {
+	Expr   Expr
+	Not    bool
+	Lo, Hi Expr
+}
+
+// LikeExpr is a [NOT] LIKE pattern.
+type LikeExpr struct {
+	Expr    Expr
+	Not     bool
+	Pattern Expr
+}
+
+// FuncCall calls a function or aggregate.
+type FuncCall struct {
+	Name     *Ident
+	Distinct bool
+	Args     []Expr
+}
+
+// CaseExpr is a CASE expression, with an optional operand.
+type CaseExpr struct {
+	Operand Expr
+	Whens   []*When
+	Else    Expr
+}
+
+// When is a branch of a CASE expression.
+type When struct {
+	Cond, Result Expr
+}
+
+// CastExpr converts a value to a type.
+type CastExpr struct {
+	Expr Expr
+	Type *TypeName
+}
+
+// TypeName names a column type.
+type TypeName struct {
+	Name string
+}
+
+// Tuple is a parenthesized list of expressions.
+type Tuple struct {
+	Exprs []Expr
+}
+
+// Subquery is a query which is used as a value.
+type Subquery struct {
+	Select Statement
+}
+
+// ExistsExpr tests whether a query returns any rows.
+type ExistsExpr struct {
+	Subquery *Subquery
+}
+
+// The marker methods below place each type in the hierarchy.
+
+func (*SelectStmt) node()      {}
+func (*SelectStmt) statement() {}
+func (*UnionStmt) node()       {}
+func (*UnionStmt) statement()  {}
+func (*InsertStmt) node()      {}
+func (*InsertStmt) statement() {}
+func (*UpdateStmt) node()      {}
+func (*UpdateStmt) statement() {}
+func (*DeleteStmt) node()      {}
+func (*DeleteStmt) statement() {}
+
+func (*With) node()       {}
+func (*CTE) node()        {}
+func (*OrderBy) node()    {}
+func (*Limit) node()      {}
+func (*Assignment) node() {}
+func (*When) node()       {}
+func (*TypeName) node()   {}
+
+func (*AliasedExpr) node()       {}
+func (*AliasedExpr) selectExpr() {}
+func (*Star) node()              {}
+func (*Star) selectExpr()        {}
+
+func (*TableName) node()            {}
+func (*TableName) tableExpr()       {}
+func (*Join) node()                 {}
+func (*Join) tableExpr()            {}
+func (*AliasedSubquery) node()      {}
+func (*AliasedSubquery) tableExpr() {}
+
+func (*Ident) node()          {}
+func (*Ident) expr()          {}
+func (*ColumnRef) node()      {}
+func (*ColumnRef) expr()      {}
+func (*IntLit) node()         {}
+func (*IntLit) expr()         {}
+func (*FloatLit) node()       {}
+func (*FloatLit) expr()       {}
+func (*StringLit) node()      {}
+func (*StringLit) expr()      {}
+func (*BoolLit) node()        {}
+func (*BoolLit) expr()        {}
+func (*NullLit) node()        {}
+func (*NullLit) expr()        {}
+func (*Placeholder) node()    {}
+func (*Placeholder) expr()    {}
+func (*BinaryExpr) node()     {}
+func (*BinaryExpr) expr()     {}
+func (*UnaryExpr) node()      {}
+func (*UnaryExpr) expr()      {}
+func (*ComparisonExpr) node() {}
+func (*ComparisonExpr) expr() {}
+func (*AndExpr) node()        {}
+func (*AndExpr) expr()        {}
+func (*OrExpr) node()         {}
+func (*OrExpr) expr()         {}
+func (*NotExpr) node()        {}
+func (*NotExpr) expr()        {}
+func (*IsNullExpr) node()     {}
+func (*IsNullExpr) expr()     {}
+func (*InExpr) node()         {}
+func (*InExpr) expr()         {}
+func (*BetweenExpr) node()    {}
+func (*BetweenExpr) expr()    {}
+func (*LikeExpr) node()       {}
+func (*LikeExpr) expr()       {}
+func (*FuncCall) node()       {}
+func (*FuncCall) expr()       {}
+func (*CaseExpr) node()       {}
+func (*CaseExpr) expr()       {}
+func (*CastExpr) node()       {}
+func (*CastExpr) expr()       {}
+func (*Tuple) node()          {}
+func (*Tuple) expr()          {}
+func (*Subquery) node()       {}
+func (*Subquery) expr()       {}
+func (*ExistsExpr) node()     {}
+func (*ExistsExpr) expr()     {}
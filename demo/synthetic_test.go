@@ -0,0 +1,121 @@
+// Copyright 2018 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"fmt"
+	"testing"
+
+	e "github.com/cockroachdb/walkabout/engine"
+	"github.com/cockroachdb/walkabout/engine/bench"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSyntheticTree verifies that every node of a synthetic tree is
+// visited.
+func TestSyntheticTree(t *testing.T) {
+	for _, cfg := range []bench.Config{
+		{Depth: 1},
+		{Depth: 3, SliceLen: 2},
+		{Depth: 3, Fanout: 4, SliceLen: 20, Seed: 1},
+	} {
+		t.Run(fmt.Sprintf("%+v", cfg), func(t *testing.T) {
+			a := assert.New(t)
+			tree, err := bench.Build(targetEngine(), containerTypeID(), cfg)
+			if !a.NoError(err) {
+				return
+			}
+			count := 0
+			fn := TargetWalkerFn(func(ctx TargetContext, x Target) (d TargetDecision) {
+				count++
+				return
+			})
+			res, err := bench.Run(targetEngine(), tree, fn, 2)
+			if a.NoError(err) {
+				a.Equal(2*tree.Nodes, count)
+				a.Equal(tree.Nodes, res.Nodes)
+			}
+		})
+	}
+
+	_, err := bench.Build(targetEngine(), 9999, bench.Config{Depth: 1})
+	assert.EqualError(t, err, "unknown TypeID 9999")
+}
+
+// BenchmarkSyntheticTree reports the rate at which nodes are visited
+// in trees of various shapes.
+func BenchmarkSyntheticTree(b *testing.B) {
+	for _, cfg := range []bench.Config{
+		{Depth: 4, SliceLen: 2},
+		{Depth: 8, Fanout: 9, SliceLen: 1},
+		{Depth: 3, SliceLen: 32},
+	} {
+		b.Run(fmt.Sprintf("depth=%d/fanout=%d/slices=%d", cfg.Depth, cfg.Fanout, cfg.SliceLen), func(b *testing.B) {
+			tree, err := bench.Build(targetEngine(), containerTypeID(), cfg)
+			if err != nil {
+				b.Fatal(err)
+			}
+			fn := TargetWalkerFn(func(ctx TargetContext, x Target) (d TargetDecision) { return })
+			b.ReportAllocs()
+			b.ResetTimer()
+			res, err := bench.Run(targetEngine(), tree, fn, b.N)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(res.NodesPerSecond(), "nodes/sec")
+		})
+	}
+}
+
+// containerTypeID looks up the engine's TypeID for ContainerType, which
+// is independent of the representation of the generated TypeIDs.
+func containerTypeID() e.TypeID {
+	for _, td := range targetEngine().TypeMap() {
+		if td.Kind == e.KindStruct && td.Name == "ContainerType" {
+			return td.TypeID
+		}
+	}
+	return 0
+}
+
+// BenchmarkDecisions reports the per-node cost of returning the zero
+// decision, which the engine does not need to apply, compared to a
+// no-op Post decision, which it does.
+func BenchmarkDecisions(b *testing.B) {
+	tree, err := bench.Build(targetEngine(), containerTypeID(), bench.Config{Depth: 4, SliceLen: 2})
+	if err != nil {
+		b.Fatal(err)
+	}
+	post := TargetWalkerFn(func(ctx TargetContext, x Target) (d TargetDecision) { return })
+
+	for _, tc := range []struct {
+		name string
+		fn   TargetWalkerFn
+	}{
+		{"zero", func(ctx TargetContext, x Target) (d TargetDecision) { return }},
+		{"post", func(ctx TargetContext, x Target) TargetDecision { return ctx.Continue().Post(post) }},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.ResetTimer()
+			res, err := bench.Run(targetEngine(), tree, tc.fn, b.N)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(res.Elapsed.Nanoseconds())/float64(res.Nodes*res.Walks), "ns/node")
+		})
+	}
+}
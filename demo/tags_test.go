@@ -0,0 +1,73 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Verify that the names and tags of fields are available through the
+// abstract API.
+func TestFieldTags(t *testing.T) {
+	a := assert.New(t)
+	op := &BinaryOp{"+", &Scalar{1}, &Scalar{3}}
+	let := &Let{Body: op, Name: "x", Value: &Scalar{2}}
+	fn := &Func{"Avg", []Expr{let}}
+
+	name, tag := op.CalcField(0)
+	a.Equal("lhs", name)
+	a.Equal("name=lhs", tag.Get("walkabout"))
+	name, tag = op.CalcField(1)
+	a.Equal("rhs", name)
+	a.Equal(reflect.StructTag(`walkabout:"name=rhs"`), tag)
+
+	// Fields are described in the order in which they are visited.
+	name, tag = let.CalcField(0)
+	a.Equal("Value", name)
+	a.Equal("order=-1", tag.Get("walkabout"))
+	name, tag = let.CalcField(1)
+	a.Equal("Body", name)
+	a.Empty(tag)
+
+	// Slices have no fields.
+	name, tag = fn.CalcField(0)
+	a.Equal("Args", name)
+	a.Empty(tag)
+	args := fn.CalcAt(0)
+	if a.NotNil(args) {
+		name, tag = args.CalcField(0)
+		a.Empty(name)
+		a.Empty(tag)
+	}
+
+	// Visit every field through the abstract API.
+	var names []string
+	var visit func(x CalcAbstract)
+	visit = func(x CalcAbstract) {
+		x.CalcEach(func(index int, child CalcAbstract) bool {
+			if name, _ := x.CalcField(index); name != "" {
+				names = append(names, name)
+			}
+			visit(child)
+			return true
+		})
+	}
+	visit(fn)
+	a.Equal([]string{"Args", "Value", "Body", "lhs", "rhs"}, names)
+}
@@ -1,10 +1,15 @@
 // Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.
 // source: demo.go
+// walkabout version: dev
 
 package demo
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
 	"unsafe"
 
 	e "github.com/cockroachdb/walkabout/engine"
@@ -21,18 +26,49 @@ type TargetTypeID e.TypeID
 type TargetAbstract interface {
 	// TargetAt returns the nth field of a struct or nth element of a
 	// slice. If the child is a type which directly implements
-	// TargetAbstract, it will be returned. If the child is of a pointer or
-	// interface type, the value will be automatically dereferenced if it
-	// is non-nil. If the child is a slice type, a TargetAbstract wrapper
-	// around the slice will be returned.
+	// TargetAbstract, it will be returned, unless the child must record
+	// its path. If the child is of a pointer or interface type, the value
+	// will be automatically dereferenced if it is non-nil. Otherwise, a
+	// TargetAbstract wrapper around the child will be returned.
 	TargetAt(index int) TargetAbstract
+	// TargetEach calls yield with each index for which TargetAt
+	// would return a non-nil value, and that value, until yield returns
+	// false. This does not allocate for each child, so a TargetAbstract
+	// wrapper around a slice will be reused and must not be retained
+	// after yield returns.
+	TargetEach(yield func(index int, child TargetAbstract) bool)
+	// TargetField returns the name and the complete tag of the nth field
+	// of a struct, or empty values for a slice. The name is the one used
+	// in paths and serialized forms.
+	TargetField(index int) (name string, tag reflect.StructTag)
+	// TargetKind returns whether the value is a struct or a slice.
+	TargetKind() TargetKind
 	// TargetCount returns the number of visitable fields in a struct,
 	// or the length of a slice.
 	TargetCount() int
+	// TargetPath returns the location of the value, using the same
+	// syntax as TargetContext.Path, if it was obtained from
+	// AbstractTargetAt or is a descendant of such a value.
+	// Otherwise, it returns an empty string.
+	TargetPath() string
 	// TargetTypeID returns a type token.
 	TargetTypeID() TargetTypeID
+	// TargetTypeName returns a human-readable name for the type of the
+	// value, e.g. "Foo" or "[]Foo".
+	TargetTypeName() string
 }
 
+// TargetKind describes the shape of a visitable type.
+type TargetKind = e.Kind
+
+// The kinds of visitable types.
+const (
+	TargetKindInterface = e.KindInterface
+	TargetKindPointer   = e.KindPointer
+	TargetKindSlice     = e.KindSlice
+	TargetKindStruct    = e.KindStruct
+)
+
 var (
 	_ TargetAbstract = &ByRefType{}
 	_ TargetAbstract = &ByValType{}
@@ -80,6 +116,14 @@ func (c *TargetContext) Continue() TargetDecision {
 	return TargetDecision(c.impl.Continue())
 }
 
+// Defer registers a function to be called after the walk completes,
+// even if it is halted or fails. Functions are called in the reverse
+// order of their registration. If the walk succeeds, the first error
+// returned by a deferred function is returned from the walk.
+func (c *TargetContext) Defer(fn func() error) {
+	c.impl.Defer(fn)
+}
+
 // Error returns a TargetDecision which will cause the given error
 // to be returned from the Walk() function. Post-visit functions
 // will not be called.
@@ -93,6 +137,12 @@ func (c *TargetContext) Halt() TargetDecision {
 	return TargetDecision(c.impl.Halt())
 }
 
+// Path returns the location of the current object, e.g.
+// "Parent/Slice[1]/Field".
+func (c *TargetContext) Path() string {
+	return c.impl.Path()
+}
+
 // Skip will not traverse the fields of the current object.
 func (c *TargetContext) Skip() TargetDecision {
 	return TargetDecision(c.impl.Skip())
@@ -119,11 +169,24 @@ func (d TargetDecision) Post(fn TargetWalkerFn) TargetDecision {
 }
 
 // Replace allows the currently-visited value to be replaced. All
-// parent nodes will be cloned.
+// parent nodes will be cloned. If the replacement cannot be stored in
+// the value's location, the walk returns a *TargetReplacementError.
 func (d TargetDecision) Replace(x Target) TargetDecision {
 	return TargetDecision((e.Decision)(d).Replace(targetIdentify(x)))
 }
 
+// ReplaceByValType is equivalent to Replace, but avoids the
+// allocations required to pass a ByValType as a Target.
+// The value must not be modified until the visitation has completed.
+func (d TargetDecision) ReplaceByValType(x *ByValType) TargetDecision {
+	return TargetDecision((e.Decision)(d).Replace(e.TypeID(TargetTypeByValType), e.Ptr(x)))
+}
+
+// TargetReplacementError is returned from a walk when a value is
+// replaced by a value whose type is not assignable to the value's
+// location. It identifies the path of the value and both types.
+type TargetReplacementError = e.ReplacementError
+
 // targetIdentify is a utility function to map a Target into
 // its generated type id and a pointer to the data.
 func targetIdentify(x Target) (typeId e.TypeID, data e.Ptr) {
@@ -152,18 +215,18 @@ func targetIdentify(x Target) (typeId e.TypeID, data e.Ptr) {
 // targetWrap is a utility function to reconstitute a Target
 // from an internal type token and a pointer to the value.
 func targetWrap(typeId e.TypeID, x e.Ptr) Target {
-	switch TargetTypeID(typeId) {
-	case TargetTypeByRefType:
+	switch typeId {
+	case e.TypeID(TargetTypeByRefType):
 		return (*ByRefType)(x)
-	case TargetTypeByRefTypePtr:
+	case e.TypeID(TargetTypeByRefTypePtr):
 		return *(**ByRefType)(x)
-	case TargetTypeByValType:
+	case e.TypeID(TargetTypeByValType):
 		return (*ByValType)(x)
-	case TargetTypeByValTypePtr:
+	case e.TypeID(TargetTypeByValTypePtr):
 		return *(**ByValType)(x)
-	case TargetTypeContainerType:
+	case e.TypeID(TargetTypeContainerType):
 		return (*ContainerType)(x)
-	case TargetTypeContainerTypePtr:
+	case e.TypeID(TargetTypeContainerTypePtr):
 		return *(**ContainerType)(x)
 	default:
 		// This is likely a code-generation problem.
@@ -180,6 +243,12 @@ func (c *TargetContext) ActionVisit(x Target) TargetAction {
 	return TargetAction(c.impl.ActionVisitTypeID(targetIdentify(x)))
 }
 
+// ActionVisitByValType is equivalent to ActionVisit, but avoids
+// the allocations required to pass a ByValType as a Target.
+func (c *TargetContext) ActionVisitByValType(x *ByValType) TargetAction {
+	return TargetAction(c.impl.ActionVisitTypeID(e.TypeID(TargetTypeByValType), e.Ptr(x)))
+}
+
 // ActionCall constructs a TargetAction that will invoke the given callback.
 func (c *TargetContext) ActionCall(fn func() error) TargetAction {
 	return TargetAction(c.impl.ActionCall(fn))
@@ -195,28 +264,72 @@ type targetAbstract struct {
 var _ TargetAbstract = &targetAbstract{}
 
 // TargetAt implements TargetAbstract.
-func (a *targetAbstract) TargetAt(index int) (ret TargetAbstract) {
+func (a *targetAbstract) TargetAt(index int) TargetAbstract {
 	impl := a.delegate.ChildAt(index)
 	if impl == nil {
 		return nil
 	}
-	switch TargetTypeID(impl.TypeID()) {
-	case TargetTypeByRefType:
-		ret = (*ByRefType)(impl.Ptr())
-	case TargetTypeByRefTypePtr:
-		ret = *(**ByRefType)(impl.Ptr())
-	case TargetTypeByValType:
-		ret = (*ByValType)(impl.Ptr())
-	case TargetTypeByValTypePtr:
-		ret = *(**ByValType)(impl.Ptr())
-	case TargetTypeContainerType:
-		ret = (*ContainerType)(impl.Ptr())
-	case TargetTypeContainerTypePtr:
-		ret = *(**ContainerType)(impl.Ptr())
-	default:
-		ret = &targetAbstract{impl}
+	return targetAbstractOf(impl, nil)
+}
+
+// TargetEach implements TargetAbstract.
+func (a *targetAbstract) TargetEach(yield func(index int, child TargetAbstract) bool) {
+	var reuse targetAbstract
+	a.delegate.Each(func(index int, impl *e.Abstract) bool {
+		return yield(index, targetAbstractOf(impl, &reuse))
+	})
+}
+
+// TargetField implements TargetAbstract.
+func (a *targetAbstract) TargetField(index int) (name string, tag reflect.StructTag) {
+	f, _ := a.delegate.Field(index)
+	return f.Name, f.Tag
+}
+
+// targetAbstractOf returns the struct that impl refers to, or a
+// facade around impl. If reuse is non-nil, it will be used as the
+// facade instead of allocating a new one.
+func targetAbstractOf(impl *e.Abstract, reuse *targetAbstract) TargetAbstract {
+	// Structs cannot record their path, so they are returned only if
+	// there is no path to record.
+	if impl.Path() == "" {
+		switch impl.TypeID() {
+		case e.TypeID(TargetTypeByRefType):
+			return (*ByRefType)(impl.Ptr())
+		case e.TypeID(TargetTypeByRefTypePtr):
+			return *(**ByRefType)(impl.Ptr())
+		case e.TypeID(TargetTypeByValType):
+			return (*ByValType)(impl.Ptr())
+		case e.TypeID(TargetTypeByValTypePtr):
+			return *(**ByValType)(impl.Ptr())
+		case e.TypeID(TargetTypeContainerType):
+			return (*ContainerType)(impl.Ptr())
+		case e.TypeID(TargetTypeContainerTypePtr):
+			return *(**ContainerType)(impl.Ptr())
+		}
 	}
-	return
+	if reuse == nil {
+		return &targetAbstract{impl}
+	}
+	reuse.delegate = impl
+	return reuse
+}
+
+// AbstractTargetAt returns an accessor for the value being visited,
+// which records the value's path, as do its descendants. It returns nil
+// if the value is not being visited by the engine, as is the case for
+// some values of types which are walked inline.
+func AbstractTargetAt(ctx TargetContext) TargetAbstract {
+	impl := ctx.impl.Abstract()
+	if impl == nil {
+		return nil
+	}
+	return &targetAbstract{impl}
+}
+
+// TargetKind implements TargetAbstract.
+func (a *targetAbstract) TargetKind() TargetKind {
+	return a.delegate.Kind()
 }
 
 // TargetCount implements TargetAbstract.
@@ -229,76 +342,220 @@ func (a *targetAbstract) TargetTypeID() TargetTypeID {
 	return TargetTypeID(a.delegate.TypeID())
 }
 
+// TargetPath implements TargetAbstract.
+func (a *targetAbstract) TargetPath() string {
+	return a.delegate.Path()
+}
+
+// TargetTypeName implements TargetAbstract.
+func (a *targetAbstract) TargetTypeName() string {
+	return a.delegate.TypeName()
+}
+
 // TargetAt implements TargetAbstract.
 func (x *ByRefType) TargetAt(index int) TargetAbstract {
-	self := targetAbstract{targetEngine.Abstract(e.TypeID(TargetTypeByRefType), e.Ptr(x))}
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeByRefType), e.Ptr(x))}
 	return self.TargetAt(index)
 }
 
+// TargetEach implements TargetAbstract.
+func (x *ByRefType) TargetEach(yield func(index int, child TargetAbstract) bool) {
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeByRefType), e.Ptr(x))}
+	self.TargetEach(yield)
+}
+
+// TargetField implements TargetAbstract.
+func (x *ByRefType) TargetField(index int) (name string, tag reflect.StructTag) {
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeByRefType), e.Ptr(x))}
+	return self.TargetField(index)
+}
+
+// TargetPath returns an empty string, because a struct does not record
+// its location.
+func (*ByRefType) TargetPath() string { return "" }
+
+// TargetKind returns TargetKindStruct.
+func (*ByRefType) TargetKind() TargetKind { return TargetKindStruct }
+
 // TargetCount returns 0.
 func (x *ByRefType) TargetCount() int { return 0 }
 
 // TargetTypeID returns TargetTypeByRefType.
 func (*ByRefType) TargetTypeID() TargetTypeID { return TargetTypeByRefType }
 
+// TargetTypeName returns "ByRefType".
+func (*ByRefType) TargetTypeName() string { return "ByRefType" }
+
 // WalkTarget visits the receiver with the provided callback.
 func (x *ByRefType) WalkTarget(fn TargetWalkerFn) (_ *ByRefType, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
 	var y e.Ptr
-	_, y, changed, err = targetEngine.Execute(fn, e.TypeID(TargetTypeByRefType), e.Ptr(x), e.TypeID(TargetTypeByRefType))
+	_, y, changed, err = targetEngine().Execute(fn, e.TypeID(TargetTypeByRefType), e.Ptr(x), e.TypeID(TargetTypeByRefType))
 	if err != nil {
 		return nil, false, err
 	}
 	return (*ByRefType)(y), changed, nil
 }
 
+// WalkTargetFrom is equivalent to WalkTarget, but the receiver may be
+// replaced by any Target, rather than only by a *ByRefType.
+func (x *ByRefType) WalkTargetFrom(fn TargetWalkerFn) (_ Target, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
+	id, y, changed, err := targetEngine().Execute(fn, e.TypeID(TargetTypeByRefType), e.Ptr(x), e.TypeID(TargetTypeTarget))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return targetWrap(id, y), true, nil
+	}
+	return x, false, nil
+}
+
 // TargetAt implements TargetAbstract.
 func (x *ByValType) TargetAt(index int) TargetAbstract {
-	self := targetAbstract{targetEngine.Abstract(e.TypeID(TargetTypeByValType), e.Ptr(x))}
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeByValType), e.Ptr(x))}
 	return self.TargetAt(index)
 }
 
+// TargetEach implements TargetAbstract.
+func (x *ByValType) TargetEach(yield func(index int, child TargetAbstract) bool) {
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeByValType), e.Ptr(x))}
+	self.TargetEach(yield)
+}
+
+// TargetField implements TargetAbstract.
+func (x *ByValType) TargetField(index int) (name string, tag reflect.StructTag) {
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeByValType), e.Ptr(x))}
+	return self.TargetField(index)
+}
+
+// TargetPath returns an empty string, because a struct does not record
+// its location.
+func (*ByValType) TargetPath() string { return "" }
+
+// TargetKind returns TargetKindStruct.
+func (*ByValType) TargetKind() TargetKind { return TargetKindStruct }
+
 // TargetCount returns 0.
 func (x *ByValType) TargetCount() int { return 0 }
 
 // TargetTypeID returns TargetTypeByValType.
 func (*ByValType) TargetTypeID() TargetTypeID { return TargetTypeByValType }
 
+// TargetTypeName returns "ByValType".
+func (*ByValType) TargetTypeName() string { return "ByValType" }
+
 // WalkTarget visits the receiver with the provided callback.
 func (x *ByValType) WalkTarget(fn TargetWalkerFn) (_ *ByValType, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
 	var y e.Ptr
-	_, y, changed, err = targetEngine.Execute(fn, e.TypeID(TargetTypeByValType), e.Ptr(x), e.TypeID(TargetTypeByValType))
+	_, y, changed, err = targetEngine().Execute(fn, e.TypeID(TargetTypeByValType), e.Ptr(x), e.TypeID(TargetTypeByValType))
 	if err != nil {
 		return nil, false, err
 	}
 	return (*ByValType)(y), changed, nil
 }
 
+// WalkTargetFrom is equivalent to WalkTarget, but the receiver may be
+// replaced by any Target, rather than only by a *ByValType.
+func (x *ByValType) WalkTargetFrom(fn TargetWalkerFn) (_ Target, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
+	id, y, changed, err := targetEngine().Execute(fn, e.TypeID(TargetTypeByValType), e.Ptr(x), e.TypeID(TargetTypeTarget))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return targetWrap(id, y), true, nil
+	}
+	return x, false, nil
+}
+
 // TargetAt implements TargetAbstract.
 func (x *ContainerType) TargetAt(index int) TargetAbstract {
-	self := targetAbstract{targetEngine.Abstract(e.TypeID(TargetTypeContainerType), e.Ptr(x))}
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeContainerType), e.Ptr(x))}
 	return self.TargetAt(index)
 }
 
+// TargetEach implements TargetAbstract.
+func (x *ContainerType) TargetEach(yield func(index int, child TargetAbstract) bool) {
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeContainerType), e.Ptr(x))}
+	self.TargetEach(yield)
+}
+
+// TargetField implements TargetAbstract.
+func (x *ContainerType) TargetField(index int) (name string, tag reflect.StructTag) {
+	self := targetAbstract{targetEngine().Abstract(e.TypeID(TargetTypeContainerType), e.Ptr(x))}
+	return self.TargetField(index)
+}
+
+// TargetPath returns an empty string, because a struct does not record
+// its location.
+func (*ContainerType) TargetPath() string { return "" }
+
+// TargetKind returns TargetKindStruct.
+func (*ContainerType) TargetKind() TargetKind { return TargetKindStruct }
+
 // TargetCount returns 16.
 func (x *ContainerType) TargetCount() int { return 16 }
 
 // TargetTypeID returns TargetTypeContainerType.
 func (*ContainerType) TargetTypeID() TargetTypeID { return TargetTypeContainerType }
 
+// TargetTypeName returns "ContainerType".
+func (*ContainerType) TargetTypeName() string { return "ContainerType" }
+
 // WalkTarget visits the receiver with the provided callback.
 func (x *ContainerType) WalkTarget(fn TargetWalkerFn) (_ *ContainerType, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
 	var y e.Ptr
-	_, y, changed, err = targetEngine.Execute(fn, e.TypeID(TargetTypeContainerType), e.Ptr(x), e.TypeID(TargetTypeContainerType))
+	_, y, changed, err = targetEngine().Execute(fn, e.TypeID(TargetTypeContainerType), e.Ptr(x), e.TypeID(TargetTypeContainerType))
 	if err != nil {
 		return nil, false, err
 	}
 	return (*ContainerType)(y), changed, nil
 }
 
-// WalkTarget visits the receiver with the provided callback.
+// WalkTargetFrom is equivalent to WalkTarget, but the receiver may be
+// replaced by any Target, rather than only by a *ContainerType.
+func (x *ContainerType) WalkTargetFrom(fn TargetWalkerFn) (_ Target, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
+	id, y, changed, err := targetEngine().Execute(fn, e.TypeID(TargetTypeContainerType), e.Ptr(x), e.TypeID(TargetTypeTarget))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return targetWrap(id, y), true, nil
+	}
+	return x, false, nil
+}
+
+// ErrNilTarget is returned when a nil Target is walked.
+var ErrNilTarget = e.ErrNilRoot
+
+// ErrMaxDepthTarget is wrapped by the error returned from
+// TargetWalkOptions.WalkTarget when MaxDepth is exceeded.
+var ErrMaxDepthTarget = e.ErrMaxDepth
+
+// WalkTarget visits the receiver with the provided callback. It
+// returns ErrNilTarget if x is nil.
 func WalkTarget(x Target, fn TargetWalkerFn) (_ Target, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
 	id, ptr := targetIdentify(x)
-	id, ptr, changed, err = targetEngine.Execute(fn, id, ptr, e.TypeID(TargetTypeTarget))
+	id, ptr, changed, err = targetEngine().Execute(fn, id, ptr, e.TypeID(TargetTypeTarget))
 	if err != nil {
 		return nil, false, err
 	}
@@ -308,268 +565,903 @@ func WalkTarget(x Target, fn TargetWalkerFn) (_ Target, changed bool, err error)
 	return x, false, nil
 }
 
-// ------ Type Mapping ------
-var targetEngine = e.New(e.TypeMap{
-	// ------ Structs ------
-	TargetTypeByRefType: {
-		Copy: func(dest, from e.Ptr) { *(*ByRefType)(dest) = *(*ByRefType)(from) },
-		Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
-			return e.Decision(fn.(TargetWalkerFn)(TargetContext{impl}, (*ByRefType)(x)))
-		},
-		Fields:    []e.FieldInfo{},
-		Name:      "ByRefType",
-		NewStruct: func() e.Ptr { return e.Ptr(&ByRefType{}) },
-		SizeOf:    unsafe.Sizeof(ByRefType{}),
-		Kind:      e.KindStruct,
-		TypeID:    e.TypeID(TargetTypeByRefType),
-	},
-	TargetTypeByValType: {
-		Copy: func(dest, from e.Ptr) { *(*ByValType)(dest) = *(*ByValType)(from) },
-		Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
-			return e.Decision(fn.(TargetWalkerFn)(TargetContext{impl}, (*ByValType)(x)))
-		},
-		Fields:    []e.FieldInfo{},
-		Name:      "ByValType",
-		NewStruct: func() e.Ptr { return e.Ptr(&ByValType{}) },
-		SizeOf:    unsafe.Sizeof(ByValType{}),
-		Kind:      e.KindStruct,
-		TypeID:    e.TypeID(TargetTypeByValType),
-	},
-	TargetTypeContainerType: {
-		Copy: func(dest, from e.Ptr) { *(*ContainerType)(dest) = *(*ContainerType)(from) },
-		Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
-			return e.Decision(fn.(TargetWalkerFn)(TargetContext{impl}, (*ContainerType)(x)))
-		},
-		Fields: []e.FieldInfo{
-			{Name: "ByRef", Offset: unsafe.Offsetof(ContainerType{}.ByRef), Target: e.TypeID(TargetTypeByRefType)},
-			{Name: "ByRefPtr", Offset: unsafe.Offsetof(ContainerType{}.ByRefPtr), Target: e.TypeID(TargetTypeByRefTypePtr)},
-			{Name: "ByRefSlice", Offset: unsafe.Offsetof(ContainerType{}.ByRefSlice), Target: e.TypeID(TargetTypeByRefTypeSlice)},
-			{Name: "ByRefPtrSlice", Offset: unsafe.Offsetof(ContainerType{}.ByRefPtrSlice), Target: e.TypeID(TargetTypeByRefTypePtrSlice)},
-			{Name: "ByVal", Offset: unsafe.Offsetof(ContainerType{}.ByVal), Target: e.TypeID(TargetTypeByValType)},
-			{Name: "ByValPtr", Offset: unsafe.Offsetof(ContainerType{}.ByValPtr), Target: e.TypeID(TargetTypeByValTypePtr)},
-			{Name: "ByValSlice", Offset: unsafe.Offsetof(ContainerType{}.ByValSlice), Target: e.TypeID(TargetTypeByValTypeSlice)},
-			{Name: "ByValPtrSlice", Offset: unsafe.Offsetof(ContainerType{}.ByValPtrSlice), Target: e.TypeID(TargetTypeByValTypePtrSlice)},
-			{Name: "Container", Offset: unsafe.Offsetof(ContainerType{}.Container), Target: e.TypeID(TargetTypeContainerTypePtr)},
-			{Name: "AnotherTarget", Offset: unsafe.Offsetof(ContainerType{}.AnotherTarget), Target: e.TypeID(TargetTypeTarget)},
-			{Name: "AnotherTargetPtr", Offset: unsafe.Offsetof(ContainerType{}.AnotherTargetPtr), Target: e.TypeID(TargetTypeTargetPtr)},
-			{Name: "EmbedsTarget", Offset: unsafe.Offsetof(ContainerType{}.EmbedsTarget), Target: e.TypeID(TargetTypeEmbedsTarget)},
-			{Name: "EmbedsTargetPtr", Offset: unsafe.Offsetof(ContainerType{}.EmbedsTargetPtr), Target: e.TypeID(TargetTypeEmbedsTargetPtr)},
-			{Name: "TargetSlice", Offset: unsafe.Offsetof(ContainerType{}.TargetSlice), Target: e.TypeID(TargetTypeTargetSlice)},
-			{Name: "InterfacePtrSlice", Offset: unsafe.Offsetof(ContainerType{}.InterfacePtrSlice), Target: e.TypeID(TargetTypeTargetPtrSlice)},
-			{Name: "NamedTargets", Offset: unsafe.Offsetof(ContainerType{}.NamedTargets), Target: e.TypeID(TargetTypeTargetSlice)},
-		},
-		Name:      "ContainerType",
-		NewStruct: func() e.Ptr { return e.Ptr(&ContainerType{}) },
-		SizeOf:    unsafe.Sizeof(ContainerType{}),
-		Kind:      e.KindStruct,
-		TypeID:    e.TypeID(TargetTypeContainerType),
-	},
-
-	// ------ Interfaces ------
-	TargetTypeEmbedsTarget: {
-		Copy: func(dest, from e.Ptr) {
-			*(*EmbedsTarget)(dest) = *(*EmbedsTarget)(from)
-		},
-		IntfType: func(x e.Ptr) e.TypeID {
-			d := *(*EmbedsTarget)(x)
-			switch d.(type) {
-			case ByValType:
-				return e.TypeID(TargetTypeByValType)
-			case *ByValType:
-				return e.TypeID(TargetTypeByValType)
-			default:
-				return 0
+// WalkTargetAbstract visits the struct or slice which a refers to,
+// such as a value returned from TargetAt, with the provided
+// callback. Paths are relative to that value, which may only be
+// replaced by one of the same type. If the value is changed, a
+// TargetAbstract for the new value is returned, and the caller may
+// place it into its own copy of the enclosing values. It returns
+// ErrNilTarget if a is nil.
+func WalkTargetAbstract(a TargetAbstract, fn TargetWalkerFn) (_ TargetAbstract, changed bool, err error) {
+	var impl *e.Abstract
+	switch t := a.(type) {
+	case *targetAbstract:
+		impl = t.delegate
+	case *ByRefType:
+		if t != nil {
+			impl = targetEngine().Abstract(e.TypeID(TargetTypeByRefType), e.Ptr(t))
+		}
+	case *ByValType:
+		if t != nil {
+			impl = targetEngine().Abstract(e.TypeID(TargetTypeByValType), e.Ptr(t))
+		}
+	case *ContainerType:
+		if t != nil {
+			impl = targetEngine().Abstract(e.TypeID(TargetTypeContainerType), e.Ptr(t))
+		}
+	}
+	if impl == nil {
+		return nil, false, ErrNilTarget
+	}
+	id, ptr, changed, err := targetEngine().Execute(fn, impl.TypeID(), impl.Ptr(), impl.TypeID())
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return targetAbstractOf(targetEngine().Abstract(id, ptr), nil), true, nil
+	}
+	return a, false, nil
+}
+
+// WalkTargetAll visits x once, calling each of the walkers for every
+// value, as though each walker had walked x by itself. A walker's
+// decisions affect only that walker: if it skips a value, it does not
+// see the value's fields, and if it halts, it sees no further values.
+// The walk stops at the first error. The walkers may not replace
+// values, or return actions or interceptors. It returns ErrNilTarget if
+// x is nil.
+func WalkTargetAll(x Target, fns ...TargetWalkerFn) error {
+	if x == nil {
+		return ErrNilTarget
+	}
+	f := e.NewFusion(len(fns))
+	post := func(ctx TargetContext, x Target) TargetDecision {
+		for idx, fn, ok := f.NextPost(); ok; idx, fn, ok = f.NextPost() {
+			if err := f.Decide(idx, e.Decision(fn.(TargetWalkerFn)(ctx, x))); err != nil {
+				return ctx.Error(err)
 			}
-		},
-		IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
-			var d EmbedsTarget
-			switch TargetTypeID(id) {
-			case TargetTypeByValType:
-				d = (*ByValType)(x)
-			case TargetTypeByValTypePtr:
-				d = *(**ByValType)(x)
-			default:
-				return nil
+		}
+		return TargetDecision(f.Exit())
+	}
+	id, ptr := targetIdentify(x)
+	_, _, _, err := targetEngine().Execute(TargetWalkerFn(func(ctx TargetContext, x Target) TargetDecision {
+		f.Enter()
+		for idx, fn := range fns {
+			if !f.Active(idx) {
+				continue
 			}
-			return e.Ptr(&d)
-		},
-		Kind:   e.KindInterface,
-		Name:   "EmbedsTarget",
-		SizeOf: unsafe.Sizeof(EmbedsTarget(nil)),
-		TypeID: e.TypeID(TargetTypeEmbedsTarget),
-	},
-	TargetTypeTarget: {
-		Copy: func(dest, from e.Ptr) {
-			*(*Target)(dest) = *(*Target)(from)
-		},
-		IntfType: func(x e.Ptr) e.TypeID {
-			d := *(*Target)(x)
-			switch d.(type) {
-			case *ByRefType:
-				return e.TypeID(TargetTypeByRefType)
-			case ByValType:
-				return e.TypeID(TargetTypeByValType)
-			case *ByValType:
-				return e.TypeID(TargetTypeByValType)
-			case *ContainerType:
-				return e.TypeID(TargetTypeContainerType)
-			default:
-				return 0
+			if err := f.Decide(idx, e.Decision(fn(ctx, x))); err != nil {
+				return ctx.Error(err)
 			}
-		},
-		IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
-			var d Target
-			switch TargetTypeID(id) {
-			case TargetTypeByRefType:
-				d = (*ByRefType)(x)
-			case TargetTypeByRefTypePtr:
-				d = *(**ByRefType)(x)
-			case TargetTypeByValType:
-				d = (*ByValType)(x)
-			case TargetTypeByValTypePtr:
-				d = *(**ByValType)(x)
-			case TargetTypeContainerType:
-				d = (*ContainerType)(x)
-			case TargetTypeContainerTypePtr:
-				d = *(**ContainerType)(x)
-			default:
-				return nil
+		}
+		return TargetDecision(f.Next()).Post(post)
+	}), id, ptr, e.TypeID(TargetTypeTarget))
+	return err
+}
+
+// TargetArena allocates the values which are cloned by its WalkTarget
+// method in batches, which reduces the number of allocations made by
+// large rewrites. The zero value is ready to use. An TargetArena is
+// not safe for concurrent use.
+type TargetArena struct {
+	impl e.Arena
+}
+
+// Release allows the memory used by the values returned from
+// WalkTarget to be reused. Those values must not be used after calling
+// Release.
+func (a *TargetArena) Release() {
+	a.impl.Release()
+}
+
+// WalkTarget is equivalent to the top-level WalkTarget function, but
+// any values which are cloned are allocated from the arena.
+func (a *TargetArena) WalkTarget(x Target, fn TargetWalkerFn) (_ Target, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
+	id, ptr := targetIdentify(x)
+	id, ptr, changed, err = targetEngine().ExecuteArena(&a.impl, fn, id, ptr, e.TypeID(TargetTypeTarget))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return targetWrap(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// TargetStack holds the working state of its WalkTarget method, and
+// may be reused across walks, instead of using the shared pool of
+// stacks. The zero value is ready to use. A TargetStack is not safe
+// for concurrent use.
+type TargetStack struct {
+	impl e.Stack
+}
+
+// Grow preallocates space for walks of the given depth.
+func (s *TargetStack) Grow(depth int) {
+	s.impl.Grow(depth)
+}
+
+// WalkTarget is equivalent to the top-level WalkTarget function, but
+// uses the receiver to hold its working state.
+func (s *TargetStack) WalkTarget(x Target, fn TargetWalkerFn) (_ Target, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
+	id, ptr := targetIdentify(x)
+	id, ptr, changed, err = targetEngine().ExecuteWith(&s.impl, fn, id, ptr, e.TypeID(TargetTypeTarget))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return targetWrap(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// TargetWalkOptions controls a single call to its WalkTarget method.
+// The zero value is equivalent to the top-level WalkTarget function.
+type TargetWalkOptions struct {
+	// Recover converts a panic in a TargetWalkerFn, post-visit
+	// function, or action into a *TargetPanicError, which describes the
+	// location of the value being visited.
+	Recover bool
+	// MaxDepth is optional and limits the depth of the walk, which
+	// grows by one for each struct, pointer, slice, interface, or set of
+	// actions which is entered. A walk which would exceed the limit
+	// returns a *TargetPathError which wraps ErrMaxDepthTarget.
+	MaxDepth int
+	// CheckRaces panics if a walk which replaces a value overlaps
+	// another walk of the same root which also sets CheckRaces. If the
+	// race detector is enabled, the walk also reports reads of the
+	// values it visits, so that in-place mutations by other goroutines
+	// are flagged.
+	CheckRaces bool
+	// VisitTypedNils calls the TargetWalkerFn with the nil pointer held
+	// by a "typed nil" interface field, so that it may be rejected or
+	// replaced. The fields of a typed nil are never visited. Otherwise,
+	// typed nils are skipped.
+	VisitTypedNils bool
+	// NormalizeTypedNils sets an interface field which holds a nil
+	// pointer to nil, unless the TargetWalkerFn replaces the typed nil
+	// with another value.
+	NormalizeTypedNils bool
+	// Counts is optional and is incremented once for each struct which
+	// is visited, keyed by the struct's type. It can be reused across
+	// walks to profile which types dominate a traversal.
+	Counts map[TargetTypeID]int
+	// Only is optional and restricts the walk to structs of the given
+	// types. The TargetWalkerFn is called only for those structs, and
+	// values which cannot contain one of them, based on the types that
+	// were generated, are not entered.
+	Only []TargetTypeID
+	// Tracing is optional and opens spans around the walk.
+	Tracing *e.Tracing
+	// Context is optional and is passed to Tracing.Tracer when the span
+	// of the walk is opened. It does not cancel the walk.
+	Context context.Context
+}
+
+// TargetPathError records the location of the value which caused an
+// error. Errors returned from a TargetWalkerFn, post-visit function, or
+// action are wrapped in a *TargetPathError.
+type TargetPathError = e.PathError
+
+// TargetPanicError is returned from TargetWalkOptions.WalkTarget when
+// Recover is set and a callback panics.
+type TargetPanicError = e.PanicError
+
+// WalkTarget is equivalent to the top-level WalkTarget function, but
+// applies the options.
+func (o TargetWalkOptions) WalkTarget(x Target, fn TargetWalkerFn) (_ Target, changed bool, err error) {
+	if x == nil {
+		return nil, false, ErrNilTarget
+	}
+	id, ptr := targetIdentify(x)
+	var counts map[e.TypeID]int
+	if o.Counts != nil {
+		counts = make(map[e.TypeID]int)
+		defer func() {
+			for id, count := range counts {
+				o.Counts[TargetTypeID(id)] += count
 			}
-			return e.Ptr(&d)
-		},
-		Kind:   e.KindInterface,
-		Name:   "Target",
-		SizeOf: unsafe.Sizeof(Target(nil)),
-		TypeID: e.TypeID(TargetTypeTarget),
-	},
-
-	// ------ Pointers ------
-	TargetTypeByRefTypePtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**ByRefType)(dest) = *(**ByRefType)(from)
-		},
-		Elem:   e.TypeID(TargetTypeByRefType),
-		SizeOf: unsafe.Sizeof((*ByRefType)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(TargetTypeByRefTypePtr),
-	},
-	TargetTypeByValTypePtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**ByValType)(dest) = *(**ByValType)(from)
+		}()
+	}
+	var only []e.TypeID
+	for _, id := range o.Only {
+		only = append(only, e.TypeID(id))
+	}
+	id, ptr, changed, err = targetEngine().ExecuteOptions(e.Options{
+		CheckRaces:         o.CheckRaces,
+		Context:            o.Context,
+		Counts:             counts,
+		MaxDepth:           o.MaxDepth,
+		NormalizeTypedNils: o.NormalizeTypedNils,
+		Only:               only,
+		Recover:            o.Recover,
+		Tracing:            o.Tracing,
+		VisitTypedNils:     o.VisitTypedNils,
+	}, fn, id, ptr, e.TypeID(TargetTypeTarget))
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return targetWrap(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// WalkTargetAs is equivalent to WalkTarget, but calls fn only for the
+// values of type T and continues past all others. T is usually a
+// pointer to a struct; a struct type also matches the pointers to it
+// which the walk provides. Unless T is an interface, the walk is
+// restricted as if by TargetWalkOptions.Only, so values which cannot
+// contain a T are not entered.
+func WalkTargetAs[T Target](x Target, fn func(ctx TargetContext, x T) TargetDecision) (_ Target, changed bool, err error) {
+	var opts TargetWalkOptions
+	var zero T
+	if any(zero) != nil {
+		id, _ := targetIdentify(zero)
+		opts.Only = []TargetTypeID{TargetTypeID(id)}
+	}
+	return opts.WalkTarget(x, func(ctx TargetContext, x Target) TargetDecision {
+		if t, ok := x.(T); ok {
+			return fn(ctx, t)
+		}
+		if t, ok := any(x).(*T); ok {
+			return fn(ctx, *t)
+		}
+		return ctx.Continue()
+	})
+}
+
+// TargetAccumulator holds the results of a walk by WalkTargetAccum, so
+// that a walker function may be reused without capturing its results.
+type TargetAccumulator[A any] struct {
+	// Value is updated by the walker.
+	Value A
+}
+
+// NewTargetAccumulator returns an accumulator which holds the
+// zero value of A.
+func NewTargetAccumulator[A any]() *TargetAccumulator[A] {
+	return &TargetAccumulator[A]{}
+}
+
+// TargetAccumulatorFn is a TargetWalkerFn which also receives the
+// accumulator passed to WalkTargetAccum.
+type TargetAccumulatorFn[A any] func(ctx TargetContext, x Target, acc *TargetAccumulator[A]) TargetDecision
+
+// WalkTargetAccum is equivalent to WalkTarget, but passes acc to fn,
+// which may record the results of the walk in acc.Value.
+func WalkTargetAccum[A any](x Target, acc *TargetAccumulator[A], fn TargetAccumulatorFn[A]) (_ Target, changed bool, err error) {
+	return WalkTarget(x, func(ctx TargetContext, x Target) TargetDecision {
+		return fn(ctx, x, acc)
+	})
+}
+
+// CompareTarget reports the number of structs reachable from after,
+// which is typically the result of calling WalkTarget on before, that
+// were cloned or replaced, and the number which are shared with before.
+func CompareTarget(before, after Target) (cloned, shared int) {
+	var beforeID, afterID e.TypeID
+	var beforePtr, afterPtr e.Ptr
+	if before != nil {
+		beforeID, beforePtr = targetIdentify(before)
+	}
+	if after != nil {
+		afterID, afterPtr = targetIdentify(after)
+	}
+	s := targetEngine().Sharing(beforeID, beforePtr, afterID, afterPtr)
+	return s.Cloned, s.Shared
+}
+
+// TargetZipFn is called by ZipTarget with each pair of values which
+// occupy the same location in two trees. If only one of the trees has a
+// value at the location, the other value is nil. The function returns
+// true to visit the fields of the pair, which only happens if both
+// values are present and have the same type.
+type TargetZipFn func(path string, a, b Target) (descend bool, err error)
+
+// ZipTarget visits a and b in lockstep, in the same order as WalkTarget,
+// and calls fn with each pair of corresponding values. Elements of
+// slices are paired by index. The path of each pair uses the same
+// syntax as TargetContext.Path. It returns the first error returned by
+// fn.
+func ZipTarget(a, b Target, fn TargetZipFn) error {
+	var aID, bID e.TypeID
+	var aPtr, bPtr e.Ptr
+	if a != nil {
+		aID, aPtr = targetIdentify(a)
+	}
+	if b != nil {
+		bID, bPtr = targetIdentify(b)
+	}
+	return targetEngine().Zip(aID, aPtr, bID, bPtr, func(path string, aID e.TypeID, aPtr e.Ptr, bID e.TypeID, bPtr e.Ptr) (bool, error) {
+		var x, y Target
+		if aPtr != nil {
+			x = targetWrap(aID, aPtr)
+		}
+		if bPtr != nil {
+			y = targetWrap(bID, bPtr)
+		}
+		return fn(path, x, y)
+	})
+}
+
+// DiffTarget returns the paths at which a and b differ: where only one
+// of them has a value, where the values have different types, or where
+// the values have different basic fields. The fields of a value which
+// differs are still compared.
+func DiffTarget(a, b Target) []string {
+	var ret []string
+	_ = ZipTarget(a, b, func(path string, x, y Target) (bool, error) {
+		if x == nil || y == nil {
+			ret = append(ret, path)
+			return false, nil
+		}
+		xID, xPtr := targetIdentify(x)
+		yID, yPtr := targetIdentify(y)
+		if xID != yID || !targetEngine().ShallowEqual(xID, xPtr, yPtr) {
+			ret = append(ret, path)
+		}
+		return true, nil
+	})
+	return ret
+}
+
+// EqualTarget reports whether DiffTarget would find no differences
+// between a and b.
+func EqualTarget(a, b Target) bool {
+	return len(DiffTarget(a, b)) == 0
+}
+
+// TargetConflict describes a location which was changed in different
+// ways by both sides of a merge.
+type TargetConflict = e.Conflict
+
+// MergeTarget performs a three-way merge of left and right, which were
+// both derived from base. A value which is unchanged on one side takes
+// the changes made by the other side. Values which are changed on both
+// sides are merged field by field, as are slices which have the same
+// length in all three trees. The paths of any other values or basic
+// fields which were changed differently by both sides are reported as
+// conflicts, and those values are taken from left. The trees are not
+// modified, and unchanged values are shared with the result. An error
+// is returned if a cycle would need to be merged. As in EqualTarget,
+// only the visitable fields and the exported fields of basic types are
+// compared, so a change to any other field is kept only if its struct
+// is taken from the side which made it.
+func MergeTarget(base, left, right Target) (_ Target, conflicts []TargetConflict, err error) {
+	var out Target
+	conflicts, err = targetEngine().Merge(e.TypeID(TargetTypeTarget), e.Ptr(&base), e.Ptr(&left), e.Ptr(&right), e.Ptr(&out))
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, conflicts, nil
+}
+
+// EncodeTarget appends a compact, binary encoding of x to buf, which
+// may be decoded by DecodeTarget. Only the visitable fields and the
+// exported fields of basic types are encoded.
+func EncodeTarget(buf []byte, x Target) ([]byte, error) {
+	return targetEngine().Encode(buf, e.TypeID(TargetTypeTarget), e.Ptr(&x))
+}
+
+// DecodeTarget decodes a value which was encoded by EncodeTarget.
+func DecodeTarget(data []byte) (x Target, err error) {
+	err = targetEngine().Decode(data, e.TypeID(TargetTypeTarget), e.Ptr(&x))
+	return x, err
+}
+
+// EncodeTargetMap converts x to a tree of maps, slices, and basic
+// values, which may be decoded by DecodeTargetMap. Each struct is
+// a map which contains the name of its type under the "$type" key, its
+// visitable fields, and its exported fields of basic types. A nil value
+// is converted to a nil map.
+func EncodeTargetMap(x Target) (map[string]interface{}, error) {
+	m, err := targetEngine().ToMap(e.TypeID(TargetTypeTarget), e.Ptr(&x))
+	if m == nil {
+		return nil, err
+	}
+	return m.(map[string]interface{}), err
+}
+
+// DecodeTargetMap constructs a value from a tree of maps, such as
+// one produced by EncodeTargetMap or by decoding JSON into an
+// interface{}.
+func DecodeTargetMap(m map[string]interface{}) (x Target, err error) {
+	if m == nil {
+		return nil, nil
+	}
+	err = targetEngine().FromMap(m, e.TypeID(TargetTypeTarget), e.Ptr(&x))
+	return x, err
+}
+
+// DumpTarget returns a human-readable, s-expression form of x, which
+// may be read by ParseTarget. The output is stable, which makes it
+// suitable for golden files.
+func DumpTarget(x Target) (string, error) {
+	return targetEngine().Dump(e.TypeID(TargetTypeTarget), e.Ptr(&x))
+}
+
+// ParseTarget constructs a value from its s-expression form, such as
+// one produced by DumpTarget.
+func ParseTarget(text string) (x Target, err error) {
+	err = targetEngine().Parse(text, e.TypeID(TargetTypeTarget), e.Ptr(&x))
+	return x, err
+}
+
+// TargetMatch is a value which was selected by QueryTarget.
+type TargetMatch struct {
+	// Path is the location of the value, e.g. "Parent/Slice[1]/Field".
+	Path  string
+	Value TargetAbstract
+}
+
+// QueryTarget returns the values within x which are selected by a
+// path expression, such as "Parent/Slice[*]/Field". Each step of the
+// expression is a field name, "*" to select any field or element, or
+// "**" to select a value and all of its descendants, and may be
+// followed by "[n]" or "[*]" to select elements of a slice. The first
+// step must be "*", "**", or the name of the type of x.
+func QueryTarget(x Target, expr string) ([]TargetMatch, error) {
+	q, err := e.ParseQuery(expr)
+	if err != nil || x == nil {
+		return nil, err
+	}
+	id, ptr := targetIdentify(x)
+	matches := q.Select(targetEngine().Abstract(id, ptr))
+	ret := make([]TargetMatch, len(matches))
+	for i, m := range matches {
+		ret[i] = TargetMatch{Path: m.Path, Value: targetAbstractOf(m.Value, nil)}
+	}
+	return ret, nil
+}
+
+// TargetRandomConfig controls the shape of the trees built by
+// RandomTarget.
+type TargetRandomConfig = e.RandomConfig
+
+// RandomTarget builds a random tree for property-based testing of
+// walkers and rewrites. The tree is determined by the values from r,
+// so a failing case may be reproduced from its seed.
+func RandomTarget(r *rand.Rand, cfg TargetRandomConfig) (x Target) {
+	targetEngine().Random(r, cfg, e.TypeID(TargetTypeTarget), e.Ptr(&x))
+	return x
+}
+
+// ShrinkTarget returns a minimal value for which fails returns true,
+// such as the smallest tree which reproduces the failure of a property
+// test that uses RandomTarget. Elements of slices are removed and
+// fields are reset to their zero values, while the failure is
+// preserved. An error is returned if x does not fail.
+func ShrinkTarget(x Target, fails func(Target) bool) (Target, error) {
+	err := targetEngine().Shrink(e.TypeID(TargetTypeTarget), e.Ptr(&x), func(p e.Ptr) bool {
+		return fails(*(*Target)(p))
+	})
+	return x, err
+}
+
+// TargetEventKind distinguishes the events recorded by a TargetRecorder.
+type TargetEventKind int
+
+// These are the kinds of events recorded by a TargetRecorder.
+const (
+	// TargetEventKindPre is recorded before the fields of a value are visited.
+	TargetEventKindPre TargetEventKind = 0
+	// TargetEventKindPost is recorded after the fields of a value are visited.
+	TargetEventKindPost TargetEventKind = 1
+)
+
+// String returns "pre" or "post".
+func (k TargetEventKind) String() string {
+	if k == TargetEventKindPost {
+		return "post"
+	}
+	return "pre"
+}
+
+// TargetEvent records the visitation of a value.
+type TargetEvent struct {
+	Kind TargetEventKind
+	// Path is the location of the value, e.g. "Parent/Slice[1]/Field".
+	Path   string
+	TypeID TargetTypeID
+}
+
+// String returns a description of the event, e.g.
+// "pre Parent/Slice[1]/Field Type".
+func (v TargetEvent) String() string {
+	return fmt.Sprintf("%s %s %s", v.Kind, v.Path, v.TypeID)
+}
+
+// TargetRecorder is a walker which records the order in which values
+// are visited, for use in assertions about traversal behavior. Its
+// Walk method should be passed as the TargetWalkerFn.
+type TargetRecorder struct {
+	Events []TargetEvent
+}
+
+// NewRecordingTargetWalker returns a TargetRecorder which has not recorded
+// any events.
+func NewRecordingTargetWalker() *TargetRecorder {
+	return &TargetRecorder{}
+}
+
+// Walk records a pre-visit event and registers a post-visit function
+// which records a post-visit event. It always continues visitation.
+func (r *TargetRecorder) Walk(ctx TargetContext, x Target) TargetDecision {
+	r.record(ctx, TargetEventKindPre, x)
+	return ctx.Continue().Post(r.post)
+}
+
+// post is registered by Walk.
+func (r *TargetRecorder) post(ctx TargetContext, x Target) TargetDecision {
+	r.record(ctx, TargetEventKindPost, x)
+	return ctx.Continue()
+}
+
+// record appends an event.
+func (r *TargetRecorder) record(ctx TargetContext, kind TargetEventKind, x Target) {
+	id, _ := targetIdentify(x)
+	r.Events = append(r.Events, TargetEvent{Kind: kind, Path: ctx.Path(), TypeID: TargetTypeID(id)})
+}
+
+// Reset discards the recorded events.
+func (r *TargetRecorder) Reset() {
+	r.Events = r.Events[:0]
+}
+
+// Strings returns the String form of each recorded event.
+func (r *TargetRecorder) Strings() []string {
+	ret := make([]string, len(r.Events))
+	for i, v := range r.Events {
+		ret[i] = v.String()
+	}
+	return ret
+}
+
+// ------ Visitor Adapters ------
+
+// TargetVisitorAdapter returns a TargetWalkerFn which delegates to an
+// implementation of TargetVisitor.
+// Children will not be visited if VisitPre returns false.
+func TargetVisitorAdapter(visitor TargetVisitor) TargetWalkerFn {
+	post := func(ctx TargetContext, x Target) TargetDecision {
+		if y := visitor.VisitPost(x); y != nil {
+			return ctx.Continue().Replace(y)
+		}
+		return ctx.Continue()
+	}
+	return func(ctx TargetContext, x Target) TargetDecision {
+		recurse, y := visitor.VisitPre(x)
+		if !recurse {
+			if y != nil {
+				return ctx.Skip().Replace(y)
+			}
+			return ctx.Skip()
+		}
+		d := ctx.Continue()
+		if y != nil {
+			d = d.Replace(y)
+		}
+		d = d.Post(post)
+		return d
+	}
+}
+
+// ------ Type Mapping ------
+var (
+	targetEngineImpl *e.Engine
+	targetEngineOnce sync.Once
+)
+
+// targetEngine returns the engine for the generated types, which is
+// constructed on first use.
+func targetEngine() *e.Engine {
+	targetEngineOnce.Do(func() { targetEngineImpl = e.New(targetTypeMap()) })
+	return targetEngineImpl
+}
+
+// targetTypeMap describes the generated types to the engine.
+func targetTypeMap() e.TypeMap {
+	return e.TypeMap{
+		// ------ Structs ------
+		e.TypeID(TargetTypeByRefType): {
+			Copy: func(dest, from e.Ptr) { *(*ByRefType)(dest) = *(*ByRefType)(from) },
+			Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+				return e.Decision(fn.(TargetWalkerFn)(TargetContext{impl}, (*ByRefType)(x)))
+			},
+			Fields:  []e.FieldInfo{},
+			Name:    "ByRefType",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			Scalars: []e.ScalarInfo{
+				{Kind: e.ScalarString, Name: "Val", Offset: unsafe.Offsetof(ByRefType{}.Val)},
+			},
+			NewStruct: func() e.Ptr { return e.Ptr(&ByRefType{}) },
+			NewStructs: func(count int) e.Ptr {
+				x := make([]ByRefType, count)
+				return e.Ptr(&x[0])
+			},
+			Shape:  0x580f55c500d29aef,
+			SizeOf: unsafe.Sizeof(ByRefType{}),
+			Kind:   e.KindStruct,
+			Type:   reflect.TypeOf(ByRefType{}),
+			TypeID: e.TypeID(TargetTypeByRefType),
 		},
-		Elem:   e.TypeID(TargetTypeByValType),
-		SizeOf: unsafe.Sizeof((*ByValType)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(TargetTypeByValTypePtr),
-	},
-	TargetTypeContainerTypePtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**ContainerType)(dest) = *(**ContainerType)(from)
+		e.TypeID(TargetTypeByValType): {
+			Copy:  func(dest, from e.Ptr) { *(*ByValType)(dest) = *(*ByValType)(from) },
+			Equal: func(a, b e.Ptr) bool { return (*ByValType)(a).Equal(*(*ByValType)(b)) },
+			Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+				return e.Decision(fn.(TargetWalkerFn)(TargetContext{impl}, (*ByValType)(x)))
+			},
+			Fields:  []e.FieldInfo{},
+			Name:    "ByValType",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			Scalars: []e.ScalarInfo{
+				{Kind: e.ScalarString, Name: "Val", Offset: unsafe.Offsetof(ByValType{}.Val)},
+			},
+			NewStruct: func() e.Ptr { return e.Ptr(&ByValType{}) },
+			NewStructs: func(count int) e.Ptr {
+				x := make([]ByValType, count)
+				return e.Ptr(&x[0])
+			},
+			Shape:  0x580f55c500d29aef,
+			SizeOf: unsafe.Sizeof(ByValType{}),
+			Kind:   e.KindStruct,
+			Type:   reflect.TypeOf(ByValType{}),
+			TypeID: e.TypeID(TargetTypeByValType),
 		},
-		Elem:   e.TypeID(TargetTypeContainerType),
-		SizeOf: unsafe.Sizeof((*ContainerType)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(TargetTypeContainerTypePtr),
-	},
-	TargetTypeEmbedsTargetPtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**EmbedsTarget)(dest) = *(**EmbedsTarget)(from)
+		e.TypeID(TargetTypeContainerType): {
+			Copy: func(dest, from e.Ptr) { *(*ContainerType)(dest) = *(*ContainerType)(from) },
+			Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+				return e.Decision(fn.(TargetWalkerFn)(TargetContext{impl}, (*ContainerType)(x)))
+			},
+			Fields: []e.FieldInfo{
+				{Name: "ByRef", Offset: unsafe.Offsetof(ContainerType{}.ByRef), Target: e.TypeID(TargetTypeByRefType)},
+				{Name: "ByRefPtr", Offset: unsafe.Offsetof(ContainerType{}.ByRefPtr), Target: e.TypeID(TargetTypeByRefTypePtr)},
+				{Name: "ByRefSlice", Offset: unsafe.Offsetof(ContainerType{}.ByRefSlice), Target: e.TypeID(TargetTypeByRefTypeSlice)},
+				{Name: "ByRefPtrSlice", Offset: unsafe.Offsetof(ContainerType{}.ByRefPtrSlice), Target: e.TypeID(TargetTypeByRefTypePtrSlice)},
+				{Name: "ByVal", Offset: unsafe.Offsetof(ContainerType{}.ByVal), Target: e.TypeID(TargetTypeByValType)},
+				{Name: "ByValPtr", Offset: unsafe.Offsetof(ContainerType{}.ByValPtr), Target: e.TypeID(TargetTypeByValTypePtr)},
+				{Name: "ByValSlice", Offset: unsafe.Offsetof(ContainerType{}.ByValSlice), Target: e.TypeID(TargetTypeByValTypeSlice)},
+				{Name: "ByValPtrSlice", Offset: unsafe.Offsetof(ContainerType{}.ByValPtrSlice), Target: e.TypeID(TargetTypeByValTypePtrSlice)},
+				{Name: "Container", Offset: unsafe.Offsetof(ContainerType{}.Container), Target: e.TypeID(TargetTypeContainerTypePtr)},
+				{Name: "AnotherTarget", Offset: unsafe.Offsetof(ContainerType{}.AnotherTarget), Target: e.TypeID(TargetTypeTarget)},
+				{Name: "AnotherTargetPtr", Offset: unsafe.Offsetof(ContainerType{}.AnotherTargetPtr), Target: e.TypeID(TargetTypeTargetPtr)},
+				{Name: "EmbedsTarget", Offset: unsafe.Offsetof(ContainerType{}.EmbedsTarget), Target: e.TypeID(TargetTypeEmbedsTarget)},
+				{Name: "EmbedsTargetPtr", Offset: unsafe.Offsetof(ContainerType{}.EmbedsTargetPtr), Target: e.TypeID(TargetTypeEmbedsTargetPtr)},
+				{Name: "TargetSlice", Offset: unsafe.Offsetof(ContainerType{}.TargetSlice), Target: e.TypeID(TargetTypeTargetSlice)},
+				{Name: "InterfacePtrSlice", Offset: unsafe.Offsetof(ContainerType{}.InterfacePtrSlice), Target: e.TypeID(TargetTypeTargetPtrSlice)},
+				{Name: "NamedTargets", Offset: unsafe.Offsetof(ContainerType{}.NamedTargets), Target: e.TypeID(TargetTypeTargetSlice)},
+			},
+			Name:      "ContainerType",
+			PkgPath:   "github.com/cockroachdb/walkabout/demo",
+			NewStruct: func() e.Ptr { return e.Ptr(&ContainerType{}) },
+			NewStructs: func(count int) e.Ptr {
+				x := make([]ContainerType, count)
+				return e.Ptr(&x[0])
+			},
+			Shape:  0x9327c459b61c17f0,
+			SizeOf: unsafe.Sizeof(ContainerType{}),
+			Kind:   e.KindStruct,
+			Type:   reflect.TypeOf(ContainerType{}),
+			TypeID: e.TypeID(TargetTypeContainerType),
 		},
-		Elem:   e.TypeID(TargetTypeEmbedsTarget),
-		SizeOf: unsafe.Sizeof((*EmbedsTarget)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(TargetTypeEmbedsTargetPtr),
-	},
-	TargetTypeTargetPtr: {
-		Copy: func(dest, from e.Ptr) {
-			*(**Target)(dest) = *(**Target)(from)
+
+		// ------ Interfaces ------
+		e.TypeID(TargetTypeEmbedsTarget): {
+			Copy: func(dest, from e.Ptr) {
+				*(*EmbedsTarget)(dest) = *(*EmbedsTarget)(from)
+			},
+			IntfType: func(x e.Ptr) e.TypeID {
+				d := *(*EmbedsTarget)(x)
+				switch d.(type) {
+				case ByValType:
+					return e.TypeID(TargetTypeByValType)
+				case *ByValType:
+					return e.TypeID(TargetTypeByValType)
+				default:
+					return 0
+				}
+			},
+			Impls: []e.TypeID{e.TypeID(TargetTypeByValType)},
+			IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
+				var d EmbedsTarget
+				switch id {
+				case e.TypeID(TargetTypeByValType):
+					d = (*ByValType)(x)
+				case e.TypeID(TargetTypeByValTypePtr):
+					d = *(**ByValType)(x)
+				default:
+					return nil
+				}
+				return e.Ptr(&d)
+			},
+			Kind:    e.KindInterface,
+			Name:    "EmbedsTarget",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			SizeOf:  unsafe.Sizeof(EmbedsTarget(nil)),
+			TypeID:  e.TypeID(TargetTypeEmbedsTarget),
 		},
-		Elem:   e.TypeID(TargetTypeTarget),
-		SizeOf: unsafe.Sizeof((*Target)(nil)),
-		Kind:   e.KindPointer,
-		TypeID: e.TypeID(TargetTypeTargetPtr),
-	},
-
-	// ------ Slices ------
-	TargetTypeByRefTypePtrSlice: {
-		Copy: func(dest, from e.Ptr) {
-			*(*[]*ByRefType)(dest) = *(*[]*ByRefType)(from)
+		e.TypeID(TargetTypeTarget): {
+			Copy: func(dest, from e.Ptr) {
+				*(*Target)(dest) = *(*Target)(from)
+			},
+			IntfType: func(x e.Ptr) e.TypeID {
+				d := *(*Target)(x)
+				switch d.(type) {
+				case *ByRefType:
+					return e.TypeID(TargetTypeByRefType)
+				case ByValType:
+					return e.TypeID(TargetTypeByValType)
+				case *ByValType:
+					return e.TypeID(TargetTypeByValType)
+				case *ContainerType:
+					return e.TypeID(TargetTypeContainerType)
+				default:
+					return 0
+				}
+			},
+			Impls: []e.TypeID{e.TypeID(TargetTypeByRefType), e.TypeID(TargetTypeByValType), e.TypeID(TargetTypeContainerType)},
+			IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
+				var d Target
+				switch id {
+				case e.TypeID(TargetTypeByRefType):
+					d = (*ByRefType)(x)
+				case e.TypeID(TargetTypeByRefTypePtr):
+					d = *(**ByRefType)(x)
+				case e.TypeID(TargetTypeByValType):
+					d = (*ByValType)(x)
+				case e.TypeID(TargetTypeByValTypePtr):
+					d = *(**ByValType)(x)
+				case e.TypeID(TargetTypeContainerType):
+					d = (*ContainerType)(x)
+				case e.TypeID(TargetTypeContainerTypePtr):
+					d = *(**ContainerType)(x)
+				default:
+					return nil
+				}
+				return e.Ptr(&d)
+			},
+			Kind:    e.KindInterface,
+			Name:    "Target",
+			PkgPath: "github.com/cockroachdb/walkabout/demo",
+			SizeOf:  unsafe.Sizeof(Target(nil)),
+			TypeID:  e.TypeID(TargetTypeTarget),
 		},
-		Elem: e.TypeID(TargetTypeByRefTypePtr),
-		Kind: e.KindSlice,
-		NewSlice: func(size int) e.Ptr {
-			x := make([]*ByRefType, size)
-			return e.Ptr(&x)
+
+		// ------ Pointers ------
+		e.TypeID(TargetTypeByRefTypePtr): {
+			Copy: func(dest, from e.Ptr) {
+				*(**ByRefType)(dest) = *(**ByRefType)(from)
+			},
+			Elem:   e.TypeID(TargetTypeByRefType),
+			SizeOf: unsafe.Sizeof((*ByRefType)(nil)),
+			Kind:   e.KindPointer,
+			TypeID: e.TypeID(TargetTypeByRefTypePtr),
 		},
-		SizeOf: unsafe.Sizeof(([]*ByRefType)(nil)),
-		TypeID: e.TypeID(TargetTypeByRefTypePtrSlice),
-	},
-	TargetTypeByValTypePtrSlice: {
-		Copy: func(dest, from e.Ptr) {
-			*(*[]*ByValType)(dest) = *(*[]*ByValType)(from)
+		e.TypeID(TargetTypeByValTypePtr): {
+			Copy: func(dest, from e.Ptr) {
+				*(**ByValType)(dest) = *(**ByValType)(from)
+			},
+			Elem:   e.TypeID(TargetTypeByValType),
+			SizeOf: unsafe.Sizeof((*ByValType)(nil)),
+			Kind:   e.KindPointer,
+			TypeID: e.TypeID(TargetTypeByValTypePtr),
 		},
-		Elem: e.TypeID(TargetTypeByValTypePtr),
-		Kind: e.KindSlice,
-		NewSlice: func(size int) e.Ptr {
-			x := make([]*ByValType, size)
-			return e.Ptr(&x)
+		e.TypeID(TargetTypeContainerTypePtr): {
+			Copy: func(dest, from e.Ptr) {
+				*(**ContainerType)(dest) = *(**ContainerType)(from)
+			},
+			Elem:   e.TypeID(TargetTypeContainerType),
+			SizeOf: unsafe.Sizeof((*ContainerType)(nil)),
+			Kind:   e.KindPointer,
+			TypeID: e.TypeID(TargetTypeContainerTypePtr),
 		},
-		SizeOf: unsafe.Sizeof(([]*ByValType)(nil)),
-		TypeID: e.TypeID(TargetTypeByValTypePtrSlice),
-	},
-	TargetTypeTargetPtrSlice: {
-		Copy: func(dest, from e.Ptr) {
-			*(*[]*Target)(dest) = *(*[]*Target)(from)
+		e.TypeID(TargetTypeEmbedsTargetPtr): {
+			Copy: func(dest, from e.Ptr) {
+				*(**EmbedsTarget)(dest) = *(**EmbedsTarget)(from)
+			},
+			Elem:   e.TypeID(TargetTypeEmbedsTarget),
+			SizeOf: unsafe.Sizeof((*EmbedsTarget)(nil)),
+			Kind:   e.KindPointer,
+			TypeID: e.TypeID(TargetTypeEmbedsTargetPtr),
 		},
-		Elem: e.TypeID(TargetTypeTargetPtr),
-		Kind: e.KindSlice,
-		NewSlice: func(size int) e.Ptr {
-			x := make([]*Target, size)
-			return e.Ptr(&x)
+		e.TypeID(TargetTypeTargetPtr): {
+			Copy: func(dest, from e.Ptr) {
+				*(**Target)(dest) = *(**Target)(from)
+			},
+			Elem:   e.TypeID(TargetTypeTarget),
+			SizeOf: unsafe.Sizeof((*Target)(nil)),
+			Kind:   e.KindPointer,
+			TypeID: e.TypeID(TargetTypeTargetPtr),
 		},
-		SizeOf: unsafe.Sizeof(([]*Target)(nil)),
-		TypeID: e.TypeID(TargetTypeTargetPtrSlice),
-	},
-	TargetTypeByRefTypeSlice: {
-		Copy: func(dest, from e.Ptr) {
-			*(*[]ByRefType)(dest) = *(*[]ByRefType)(from)
+
+		// ------ Slices ------
+		e.TypeID(TargetTypeByRefTypePtrSlice): {
+			Copy: func(dest, from e.Ptr) {
+				*(*[]*ByRefType)(dest) = *(*[]*ByRefType)(from)
+			},
+			Elem: e.TypeID(TargetTypeByRefTypePtr),
+			Kind: e.KindSlice,
+			NewSlice: func(size int) e.Ptr {
+				x := make([]*ByRefType, size)
+				return e.Ptr(&x)
+			},
+			SizeOf: unsafe.Sizeof(([]*ByRefType)(nil)),
+			TypeID: e.TypeID(TargetTypeByRefTypePtrSlice),
 		},
-		Elem: e.TypeID(TargetTypeByRefType),
-		Kind: e.KindSlice,
-		NewSlice: func(size int) e.Ptr {
-			x := make([]ByRefType, size)
-			return e.Ptr(&x)
+		e.TypeID(TargetTypeByValTypePtrSlice): {
+			Copy: func(dest, from e.Ptr) {
+				*(*[]*ByValType)(dest) = *(*[]*ByValType)(from)
+			},
+			Elem: e.TypeID(TargetTypeByValTypePtr),
+			Kind: e.KindSlice,
+			NewSlice: func(size int) e.Ptr {
+				x := make([]*ByValType, size)
+				return e.Ptr(&x)
+			},
+			SizeOf: unsafe.Sizeof(([]*ByValType)(nil)),
+			TypeID: e.TypeID(TargetTypeByValTypePtrSlice),
 		},
-		SizeOf: unsafe.Sizeof(([]ByRefType)(nil)),
-		TypeID: e.TypeID(TargetTypeByRefTypeSlice),
-	},
-	TargetTypeByValTypeSlice: {
-		Copy: func(dest, from e.Ptr) {
-			*(*[]ByValType)(dest) = *(*[]ByValType)(from)
+		e.TypeID(TargetTypeTargetPtrSlice): {
+			Copy: func(dest, from e.Ptr) {
+				*(*[]*Target)(dest) = *(*[]*Target)(from)
+			},
+			Elem: e.TypeID(TargetTypeTargetPtr),
+			Kind: e.KindSlice,
+			NewSlice: func(size int) e.Ptr {
+				x := make([]*Target, size)
+				return e.Ptr(&x)
+			},
+			SizeOf: unsafe.Sizeof(([]*Target)(nil)),
+			TypeID: e.TypeID(TargetTypeTargetPtrSlice),
 		},
-		Elem: e.TypeID(TargetTypeByValType),
-		Kind: e.KindSlice,
-		NewSlice: func(size int) e.Ptr {
-			x := make([]ByValType, size)
-			return e.Ptr(&x)
+		e.TypeID(TargetTypeByRefTypeSlice): {
+			Copy: func(dest, from e.Ptr) {
+				*(*[]ByRefType)(dest) = *(*[]ByRefType)(from)
+			},
+			Elem: e.TypeID(TargetTypeByRefType),
+			Kind: e.KindSlice,
+			NewSlice: func(size int) e.Ptr {
+				x := make([]ByRefType, size)
+				return e.Ptr(&x)
+			},
+			SizeOf: unsafe.Sizeof(([]ByRefType)(nil)),
+			TypeID: e.TypeID(TargetTypeByRefTypeSlice),
 		},
-		SizeOf: unsafe.Sizeof(([]ByValType)(nil)),
-		TypeID: e.TypeID(TargetTypeByValTypeSlice),
-	},
-	TargetTypeTargetSlice: {
-		Copy: func(dest, from e.Ptr) {
-			*(*[]Target)(dest) = *(*[]Target)(from)
+		e.TypeID(TargetTypeByValTypeSlice): {
+			Copy: func(dest, from e.Ptr) {
+				*(*[]ByValType)(dest) = *(*[]ByValType)(from)
+			},
+			Elem: e.TypeID(TargetTypeByValType),
+			Kind: e.KindSlice,
+			NewSlice: func(size int) e.Ptr {
+				x := make([]ByValType, size)
+				return e.Ptr(&x)
+			},
+			SizeOf: unsafe.Sizeof(([]ByValType)(nil)),
+			TypeID: e.TypeID(TargetTypeByValTypeSlice),
 		},
-		Elem: e.TypeID(TargetTypeTarget),
-		Kind: e.KindSlice,
-		NewSlice: func(size int) e.Ptr {
-			x := make([]Target, size)
-			return e.Ptr(&x)
+		e.TypeID(TargetTypeTargetSlice): {
+			Copy: func(dest, from e.Ptr) {
+				*(*[]Target)(dest) = *(*[]Target)(from)
+			},
+			Elem: e.TypeID(TargetTypeTarget),
+			Kind: e.KindSlice,
+			NewSlice: func(size int) e.Ptr {
+				x := make([]Target, size)
+				return e.Ptr(&x)
+			},
+			SizeOf: unsafe.Sizeof(([]Target)(nil)),
+			TypeID: e.TypeID(TargetTypeTargetSlice),
 		},
-		SizeOf: unsafe.Sizeof(([]Target)(nil)),
-		TypeID: e.TypeID(TargetTypeTargetSlice),
-	},
-})
+	}
+}
 
 // These are lightweight type tokens.
 const (
@@ -594,5 +1486,12 @@ const (
 
 // String is for debugging use only.
 func (t TargetTypeID) String() string {
-	return targetEngine.Stringify(e.TypeID(t))
+	return targetEngine().Stringify(e.TypeID(t))
+}
+
+// QualifiedString returns the name of the type, including the import
+// path of the package which declares each named type. It is for
+// debugging use only.
+func (t TargetTypeID) QualifiedString() string {
+	return targetEngine().StringifyQualified(e.TypeID(t))
 }
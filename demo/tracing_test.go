@@ -0,0 +1,139 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingTracer records the spans that the engine opens.
+type recordingTracer struct {
+	ended []string
+}
+
+type recordingSpan struct {
+	t    *recordingTracer
+	name string
+}
+
+type spanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, engine.Span) {
+	if parent, ok := ctx.Value(spanKey{}).(*recordingSpan); ok {
+		name = parent.name + "/" + name
+	}
+	span := &recordingSpan{t, name}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordingSpan) End(visits int64, err error) {
+	s.t.ended = append(s.t.ended, fmt.Sprintf("%s visits=%d err=%v", s.name, visits, err))
+}
+
+func TestTracing(t *testing.T) {
+	x := &l.ContainerType{
+		AnotherTarget:    l.ByValType{Val: "A"},
+		AnotherTargetPtr: nil,
+	}
+	visit := func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		return ctx.Continue()
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		a := assert.New(t)
+		tr := &recordingTracer{}
+		opts := l.TargetWalkOptions{Tracing: &engine.Tracing{Tracer: tr}}
+
+		count := 0
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			count++
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal([]string{fmt.Sprintf("ContainerType visits=%d err=<nil>", count)}, tr.ended)
+	})
+
+	// The span of the walk is a child of the span in the context.
+	t.Run("context", func(t *testing.T) {
+		a := assert.New(t)
+		tr := &recordingTracer{}
+		ctx, _ := tr.Start(context.Background(), "request")
+		opts := l.TargetWalkOptions{Context: ctx, Tracing: &engine.Tracing{Tracer: tr}}
+
+		_, _, err := opts.WalkTarget(x, visit)
+		a.NoError(err)
+		a.Equal([]string{"request/ContainerType visits=4 err=<nil>"}, tr.ended)
+
+		// Walks which are not traced do not open spans.
+		tr.ended = nil
+		_, _, err = x.WalkTarget(visit)
+		a.NoError(err)
+		a.Empty(tr.ended)
+	})
+
+	// The ByRef and ByVal fields have a depth of one, while the value
+	// in AnotherTarget has a depth of two.
+	t.Run("subtrees", func(t *testing.T) {
+		a := assert.New(t)
+		tr := &recordingTracer{}
+		opts := l.TargetWalkOptions{Tracing: &engine.Tracing{Tracer: tr, SubtreeDepth: 1}}
+
+		_, _, err := opts.WalkTarget(x, visit)
+		a.NoError(err)
+		a.Equal([]string{
+			"ContainerType/ByRefType visits=1 err=<nil>",
+			"ContainerType/ByValType visits=1 err=<nil>",
+			"ContainerType visits=4 err=<nil>",
+		}, tr.ended)
+
+		tr.ended = nil
+		opts.Tracing.SubtreeDepth = 2
+		_, _, err = opts.WalkTarget(x, visit)
+		a.NoError(err)
+		a.Equal([]string{
+			"ContainerType/ByRefType visits=1 err=<nil>",
+			"ContainerType/ByValType visits=1 err=<nil>",
+			"ContainerType/ByValType visits=1 err=<nil>",
+			"ContainerType visits=4 err=<nil>",
+		}, tr.ended)
+	})
+
+	// Spans which are open when an error occurs are ended with it.
+	t.Run("error", func(t *testing.T) {
+		a := assert.New(t)
+		tr := &recordingTracer{}
+		opts := l.TargetWalkOptions{Tracing: &engine.Tracing{Tracer: tr, SubtreeDepth: 1}}
+
+		_, _, err := opts.WalkTarget(x, func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			if _, ok := x.(*l.ByRefType); ok {
+				return ctx.Error(errors.New("boom"))
+			}
+			return ctx.Continue()
+		})
+		a.EqualError(err, "ContainerType/ByRef: boom")
+		a.Equal([]string{
+			"ContainerType/ByRefType visits=1 err=ContainerType/ByRef: boom",
+			"ContainerType visits=2 err=ContainerType/ByRef: boom",
+		}, tr.ended)
+	})
+}
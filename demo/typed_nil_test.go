@@ -0,0 +1,131 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// typedNils returns a value whose interface fields hold nil pointers.
+func typedNils() *l.ContainerType {
+	return &l.ContainerType{
+		AnotherTarget: (*l.ContainerType)(nil),
+		EmbedsTarget:  (*l.ByValType)(nil),
+		TargetSlice:   []l.Target{&l.ByRefType{Val: "ok"}, (*l.ByRefType)(nil)},
+	}
+}
+
+func TestTypedNils(t *testing.T) {
+	// record returns a walker which records the path and the type of
+	// each value which it visits.
+	record := func(paths *[]string) l.TargetWalkerFn {
+		return func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+			*paths = append(*paths, fmt.Sprintf("%s %T", ctx.Path(), x))
+			return ctx.Continue()
+		}
+	}
+
+	t.Run("skipped", func(t *testing.T) {
+		a := assert.New(t)
+		var paths []string
+		_, changed, err := l.WalkTarget(typedNils(), record(&paths))
+		a.NoError(err)
+		a.False(changed)
+		a.NotContains(paths, "ContainerType/AnotherTarget *demo.ContainerType")
+	})
+
+	t.Run("visit", func(t *testing.T) {
+		a := assert.New(t)
+		var paths []string
+		x := typedNils()
+		_, changed, err := l.TargetWalkOptions{VisitTypedNils: true}.WalkTarget(x, record(&paths))
+		a.NoError(err)
+		a.False(changed)
+		a.Contains(paths, "ContainerType/AnotherTarget *demo.ContainerType")
+		a.Contains(paths, "ContainerType/EmbedsTarget *demo.ByValType")
+		a.Contains(paths, "ContainerType/TargetSlice[1] *demo.ByRefType")
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		a := assert.New(t)
+		errTypedNil := errors.New("typed nil")
+		_, _, err := l.TargetWalkOptions{VisitTypedNils: true}.WalkTarget(typedNils(),
+			func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				if x, ok := x.(*l.ContainerType); ok && x == nil {
+					return ctx.Error(errTypedNil)
+				}
+				return ctx.Continue()
+			})
+		a.True(errors.Is(err, errTypedNil))
+		a.EqualError(err, "ContainerType/AnotherTarget: typed nil")
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		a := assert.New(t)
+		x := typedNils()
+		out, changed, err := l.TargetWalkOptions{VisitTypedNils: true}.WalkTarget(x,
+			func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				if x, ok := x.(*l.ByRefType); ok && x == nil {
+					return ctx.Continue().Replace(&l.ByRefType{Val: "replaced"})
+				}
+				return ctx.Continue()
+			})
+		a.NoError(err)
+		a.True(changed)
+		a.Equal(&l.ByRefType{Val: "replaced"}, out.(*l.ContainerType).TargetSlice[1])
+		a.Equal(typedNils(), x)
+	})
+
+	t.Run("normalize", func(t *testing.T) {
+		a := assert.New(t)
+		x := typedNils()
+		out, changed, err := l.TargetWalkOptions{NormalizeTypedNils: true}.WalkTarget(x, record(new([]string)))
+		a.NoError(err)
+		a.True(changed)
+		c := out.(*l.ContainerType)
+		a.Nil(c.AnotherTarget)
+		a.Nil(c.EmbedsTarget)
+		a.Equal([]l.Target{&l.ByRefType{Val: "ok"}, nil}, c.TargetSlice)
+		a.Equal(typedNils(), x)
+
+		// A value without typed nils is unchanged.
+		_, changed, err = l.TargetWalkOptions{NormalizeTypedNils: true}.WalkTarget(c, record(new([]string)))
+		a.NoError(err)
+		a.False(changed)
+	})
+
+	t.Run("visit and normalize", func(t *testing.T) {
+		a := assert.New(t)
+		out, changed, err := l.TargetWalkOptions{NormalizeTypedNils: true, VisitTypedNils: true}.WalkTarget(typedNils(),
+			func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+				if x, ok := x.(*l.ByValType); ok && x == nil {
+					return ctx.Continue().Replace(&l.ByValType{Val: "replaced"})
+				}
+				return ctx.Continue()
+			})
+		a.NoError(err)
+		a.True(changed)
+		c := out.(*l.ContainerType)
+		a.Nil(c.AnotherTarget)
+		a.Equal(&l.ByValType{Val: "replaced"}, c.EmbedsTarget)
+		a.Nil(c.TargetSlice[1])
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWalkAbstract verifies that a walk may begin at a node which was
+// located through the abstract API.
+func TestWalkAbstract(t *testing.T) {
+	a := assert.New(t)
+	x := &l.ContainerType{
+		ByRef:      l.ByRefType{Val: "a"},
+		ByRefSlice: []l.ByRefType{{Val: "b"}, {Val: "c"}},
+		ByValPtr:   &l.ByValType{Val: "d"},
+	}
+
+	// find returns the child of x with the given field name.
+	find := func(name string) l.TargetAbstract {
+		for i := 0; i < x.TargetCount(); i++ {
+			if n, _ := x.TargetField(i); n == name {
+				return x.TargetAt(i)
+			}
+		}
+		return nil
+	}
+
+	var visited []string
+	upper := func(ctx l.TargetContext, x l.Target) l.TargetDecision {
+		visited = append(visited, ctx.Path())
+		if ref, ok := x.(*l.ByRefType); ok {
+			return ctx.Continue().Replace(&l.ByRefType{Val: ref.Val + "!"})
+		}
+		return ctx.Continue()
+	}
+
+	// A slice is wrapped in a facade.
+	slice := find("ByRefSlice")
+	a.Equal(l.TargetKindSlice, slice.TargetKind())
+	out, changed, err := l.WalkTargetAbstract(slice, upper)
+	a.NoError(err)
+	a.True(changed)
+	// Paths are relative to the starting node.
+	a.Equal([]string{"[0]", "[1]"}, visited)
+	a.Equal(2, out.TargetCount())
+	a.Equal(&l.ByRefType{Val: "b!"}, out.TargetAt(0))
+	a.Equal(&l.ByRefType{Val: "c!"}, out.TargetAt(1))
+	// The original tree is not modified.
+	a.Equal("b", x.ByRefSlice[0].Val)
+
+	// A struct is returned directly.
+	visited = nil
+	out, changed, err = l.WalkTargetAbstract(find("ByRef"), upper)
+	a.NoError(err)
+	a.True(changed)
+	a.Equal(&l.ByRefType{Val: "a!"}, out)
+	a.Equal("a", x.ByRef.Val)
+
+	// Subtrees which are not changed are returned as-is.
+	ptr := find("ByValPtr")
+	out, changed, err = l.WalkTargetAbstract(ptr, upper)
+	a.NoError(err)
+	a.False(changed)
+	a.True(out == ptr)
+
+	_, _, err = l.WalkTargetAbstract(nil, upper)
+	a.Equal(l.ErrNilTarget, err)
+	_, _, err = l.WalkTargetAbstract((*l.ByRefType)(nil), upper)
+	a.Equal(l.ErrNilTarget, err)
+}
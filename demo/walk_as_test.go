@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWalkAs verifies that WalkTargetAs calls its callback only for
+// values of the requested type.
+func TestWalkAs(t *testing.T) {
+	// paths returns the paths at which the recorder saw the type.
+	paths := func(x l.Target, id l.TargetTypeID) []string {
+		var r l.TargetRecorder
+		_, _, _ = l.WalkTarget(x, r.Walk)
+		var ret []string
+		for _, event := range r.Events {
+			if event.Kind == l.TargetEventKindPre && event.TypeID == id {
+				ret = append(ret, event.Path)
+			}
+		}
+		return ret
+	}
+
+	t.Run("pointer", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		var seen []string
+		_, changed, err := l.WalkTargetAs(x, func(ctx l.TargetContext, x *l.ByRefType) l.TargetDecision {
+			a.Equal("olleH", x.Val)
+			seen = append(seen, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.False(changed)
+		a.Equal(paths(x, l.TargetTypeByRefType), seen)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(false)
+		var seen []string
+		_, _, err := l.WalkTargetAs(x, func(ctx l.TargetContext, x l.ByValType) l.TargetDecision {
+			seen = append(seen, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		a.Equal(paths(x, l.TargetTypeByValType), seen)
+	})
+
+	t.Run("interface", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		var seen []string
+		_, _, err := l.WalkTargetAs(x, func(ctx l.TargetContext, x l.EmbedsTarget) l.TargetDecision {
+			seen = append(seen, ctx.Path())
+			return ctx.Continue()
+		})
+		a.NoError(err)
+		// Only ByValType implements EmbedsTarget.
+		a.Equal(paths(x, l.TargetTypeByValType), seen)
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		out, changed, err := l.WalkTargetAs(x, func(ctx l.TargetContext, x *l.ByRefType) l.TargetDecision {
+			return ctx.Continue().Replace(&l.ByRefType{Val: "replaced"})
+		})
+		a.NoError(err)
+		a.True(changed)
+		a.Equal("replaced", out.(*l.ContainerType).ByRef.Val)
+		a.Equal("olleH", x.ByRef.Val)
+	})
+}
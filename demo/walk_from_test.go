@@ -0,0 +1,70 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWalkFrom verifies that the struct which a walk begins with may be
+// replaced by a value of another type.
+func TestWalkFrom(t *testing.T) {
+	a := assert.New(t)
+	// The replacement contains the scalar, so it is not entered.
+	toFunc := func(ctx CalcContext, x Calc) CalcDecision {
+		if s, ok := x.(*Scalar); ok {
+			return ctx.Skip().Replace(&Func{Fn: "Const", Args: []Expr{s}})
+		}
+		return ctx.Continue()
+	}
+
+	s := &Scalar{42}
+	_, _, err := s.WalkCalc(toFunc)
+	a.EqualError(err, "Scalar: type Func is not assignable to Scalar")
+
+	out, changed, err := s.WalkCalcFrom(toFunc)
+	a.NoError(err)
+	a.True(changed)
+	if fn, ok := out.(*Func); a.True(ok) {
+		a.Equal("Const", fn.Fn)
+		a.Equal([]Expr{s}, fn.Args)
+	}
+
+	// An unchanged receiver is returned as-is.
+	out, changed, err = s.WalkCalcFrom(func(ctx CalcContext, x Calc) CalcDecision {
+		return ctx.Continue()
+	})
+	a.NoError(err)
+	a.False(changed)
+	a.True(out == Calc(s))
+
+	// The fields of the receiver may also be replaced.
+	op := &BinaryOp{"+", &Scalar{1}, &Scalar{2}}
+	out, changed, err = op.WalkCalcFrom(toFunc)
+	a.NoError(err)
+	a.True(changed)
+	if next, ok := out.(*BinaryOp); a.True(ok) {
+		a.True(op != next)
+		a.IsType(&Func{}, next.Left)
+		a.IsType(&Func{}, next.Right)
+	}
+
+	var nilScalar *Scalar
+	_, _, err = nilScalar.WalkCalcFrom(toFunc)
+	a.Equal(ErrNilCalc, err)
+}
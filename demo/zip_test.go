@@ -0,0 +1,141 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package demo_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	l "github.com/cockroachdb/walkabout/demo"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestZip verifies that two trees are visited in lockstep.
+func TestZip(t *testing.T) {
+	name := func(x l.Target) string {
+		if x == nil {
+			return "nil"
+		}
+		return fmt.Sprintf("%T", x)
+	}
+
+	t.Run("pairs", func(t *testing.T) {
+		a := assert.New(t)
+		x := &l.ContainerType{
+			ByRef:         l.ByRefType{Val: "a"},
+			ByRefPtrSlice: []*l.ByRefType{{Val: "b"}, nil},
+			AnotherTarget: &l.ByRefType{Val: "c"},
+		}
+		y := &l.ContainerType{
+			ByRef:         l.ByRefType{Val: "a"},
+			ByRefPtrSlice: []*l.ByRefType{{Val: "b"}, {Val: "d"}, {Val: "e"}},
+			AnotherTarget: &l.ByValType{Val: "c"},
+			ByValPtr:      &l.ByValType{Val: "f"},
+		}
+
+		var pairs []string
+		a.NoError(l.ZipTarget(x, y, func(path string, a, b l.Target) (bool, error) {
+			pairs = append(pairs, fmt.Sprintf("%s %s %s", path, name(a), name(b)))
+			return true, nil
+		}))
+		a.Equal([]string{
+			"ContainerType *demo.ContainerType *demo.ContainerType",
+			"ContainerType/ByRef *demo.ByRefType *demo.ByRefType",
+			"ContainerType/ByRefPtrSlice[0] *demo.ByRefType *demo.ByRefType",
+			"ContainerType/ByRefPtrSlice[1] nil *demo.ByRefType",
+			"ContainerType/ByRefPtrSlice[2] nil *demo.ByRefType",
+			"ContainerType/ByVal *demo.ByValType *demo.ByValType",
+			"ContainerType/ByValPtr nil *demo.ByValType",
+			"ContainerType/AnotherTarget *demo.ByRefType *demo.ByValType",
+		}, pairs)
+
+		a.Equal([]string{
+			"ContainerType/ByRefPtrSlice[1]",
+			"ContainerType/ByRefPtrSlice[2]",
+			"ContainerType/ByValPtr",
+			"ContainerType/AnotherTarget",
+		}, l.DiffTarget(x, y))
+		a.False(l.EqualTarget(x, y))
+	})
+
+	t.Run("same order as walk", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		y, _ := l.NewContainer(true)
+
+		var r l.TargetRecorder
+		_, _, err := l.WalkTarget(x, r.Walk)
+		a.NoError(err)
+		var walked []string
+		for _, event := range r.Events {
+			if event.Kind == l.TargetEventKindPre {
+				walked = append(walked, event.Path)
+			}
+		}
+
+		var zipped []string
+		a.NoError(l.ZipTarget(x, y, func(path string, a, b l.Target) (bool, error) {
+			zipped = append(zipped, path)
+			return true, nil
+		}))
+		a.Equal(walked, zipped)
+		a.True(l.EqualTarget(x, y))
+		a.Empty(l.DiffTarget(x, y))
+
+		y.ByValSlice[1].Val = "changed"
+		a.Equal([]string{"ContainerType/ByValSlice[1]"}, l.DiffTarget(x, y))
+	})
+
+	t.Run("skip and stop", func(t *testing.T) {
+		a := assert.New(t)
+		x, _ := l.NewContainer(true)
+		var count int
+		a.NoError(l.ZipTarget(x, x, func(path string, a, b l.Target) (bool, error) {
+			count++
+			return false, nil
+		}))
+		a.Equal(1, count)
+
+		errStop := errors.New("stop")
+		count = 0
+		err := l.ZipTarget(x, x, func(path string, a, b l.Target) (bool, error) {
+			count++
+			if count == 3 {
+				return false, errStop
+			}
+			return true, nil
+		})
+		a.Equal(errStop, err)
+		a.Equal(3, count)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		a := assert.New(t)
+		a.True(l.EqualTarget(nil, nil))
+		a.Equal([]string{"ByRefType"}, l.DiffTarget(nil, &l.ByRefType{}))
+		a.Equal([]string{"ByRefType"}, l.DiffTarget(&l.ByRefType{}, (*l.ByRefType)(nil)))
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		a := assert.New(t)
+		x := &l.ContainerType{}
+		x.Container = x
+		y := &l.ContainerType{}
+		y.Container = y
+		a.True(l.EqualTarget(x, y))
+	})
+}
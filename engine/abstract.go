@@ -20,7 +20,7 @@ package engine
 
 import (
 	"fmt"
-	"reflect"
+	"strconv"
 )
 
 // Abstract allows a visitable object to be manipulated as an abstract
@@ -32,12 +32,36 @@ type Abstract struct {
 	engine   *Engine
 	typeData *TypeData
 	value    Ptr
+	// path is recorded only if the Abstract was obtained from a Context.
+	path string
 }
 
 // ChildAt returns the nth field or slice element. If that value is
 // a pointer or an interface, it is dereferenced before returning.
 // Nil pointers, interfaces, and empty slices will return nil here.
 func (a *Abstract) ChildAt(index int) *Abstract {
+	ret := &Abstract{}
+	if !a.childAt(index, ret) {
+		return nil
+	}
+	return ret
+}
+
+// Each calls yield with each child for which ChildAt would return a
+// non-nil value, until yield returns false. The Abstract passed to
+// yield is reused and must not be retained.
+func (a *Abstract) Each(yield func(index int, child *Abstract) bool) {
+	var child Abstract
+	for i, j := 0, a.NumChildren(); i < j; i++ {
+		if a.childAt(i, &child) && !yield(i, &child) {
+			return
+		}
+	}
+}
+
+// childAt implements ChildAt, populating the given Abstract and
+// returning true if the child is non-nil.
+func (a *Abstract) childAt(index int, into *Abstract) bool {
 	var chaseType *TypeData
 	var chaseValue Ptr
 
@@ -48,12 +72,12 @@ func (a *Abstract) ChildAt(index int) *Abstract {
 		chaseType = f.targetData
 		chaseValue = Ptr(uintptr(a.value) + f.Offset)
 	case KindSlice:
-		header := (*reflect.SliceHeader)(a.value)
+		header := (*sliceHeader)(a.value)
 		if index < 0 || index >= header.Len {
 			panic(fmt.Errorf("index out of range: %d", index))
 		}
 		chaseType = a.typeData.elemData
-		chaseValue = Ptr(header.Data + uintptr(index)*chaseType.SizeOf)
+		chaseValue = Ptr(uintptr(header.Data) + uintptr(index)*chaseType.SizeOf)
 	default:
 		// We should never have returned an Abstract wrapping anything other
 		// than a struct or a slice. Getting here indicates a problem
@@ -61,36 +85,48 @@ func (a *Abstract) ChildAt(index int) *Abstract {
 		panic(fmt.Errorf("unimplemented: %d", a.typeData.Kind))
 	}
 
+	// Children of an Abstract which records its path also record theirs.
+	var path string
+	if a.path != "" {
+		if a.typeData.Kind == KindStruct {
+			path = a.path + "/" + a.typeData.Fields[index].Name
+		} else {
+			path = a.path + "[" + strconv.Itoa(index) + "]"
+		}
+	}
+
 	// Now, we traverse pointers and interfaces until we arrive at
 	// a struct or a slice.
 	for {
 		if chaseValue == nil {
-			return nil
+			return false
 		}
 		switch chaseType.Kind {
 		case KindSlice:
 			// Special-case: If the slice is empty, return nil
-			header := (*reflect.SliceHeader)(chaseValue)
+			header := (*sliceHeader)(chaseValue)
 			if header.Len == 0 {
-				return nil
+				return false
 			}
 			fallthrough
 		case KindStruct:
-			// We wrap structs and slices in a new Abstract.
-			return &Abstract{
+			// We wrap structs and slices in an Abstract.
+			*into = Abstract{
 				engine:   a.engine,
 				typeData: chaseType,
 				value:    chaseValue,
+				path:     path,
 			}
+			return true
 		case KindPointer:
 			// We try to dereference pointers and loop around.
 			chaseValue = *(*Ptr)(chaseValue)
 			chaseType = chaseType.elemData
 		case KindInterface:
 			// Interfaces return a more specialized type.
-			elemType := chaseType.IntfType(chaseValue)
+			elemType := chaseType.intfType(chaseValue)
 			if elemType == 0 {
-				return nil
+				return false
 			}
 			chaseType = a.engine.typeData(elemType)
 			chaseValue = ((*[2]Ptr)(chaseValue))[1]
@@ -100,6 +136,21 @@ func (a *Abstract) ChildAt(index int) *Abstract {
 	}
 }
 
+// Field returns a description of the nth field of a struct, or false
+// if the Abstract represents a slice.
+func (a *Abstract) Field(index int) (FieldInfo, bool) {
+	if a.typeData.Kind != KindStruct {
+		return FieldInfo{}, false
+	}
+	return a.typeData.Fields[index], true
+}
+
+// Kind returns the kind of the embedded value, which is usually a
+// struct or a slice.
+func (a *Abstract) Kind() Kind {
+	return a.typeData.Kind
+}
+
 // NumChildren returns the number of fields or slice elements.
 func (a *Abstract) NumChildren() int {
 	if a.value == nil {
@@ -109,7 +160,7 @@ func (a *Abstract) NumChildren() int {
 	case KindStruct:
 		return len(a.typeData.Fields)
 	case KindSlice:
-		return (*reflect.SliceHeader)(a.value).Len
+		return (*sliceHeader)(a.value).Len
 	default:
 		// Interfaces should be replaced by a more specific type and
 		// pointers should be dereferenced.
@@ -117,6 +168,14 @@ func (a *Abstract) NumChildren() int {
 	}
 }
 
+// Path returns the location of the value, using the same syntax as
+// Context.Path, if the Abstract was obtained from Context.Abstract or
+// is a descendant of such an Abstract. Otherwise, it returns an empty
+// string.
+func (a *Abstract) Path() string {
+	return a.path
+}
+
 // Ptr returns the embedded pointer. This should not be exposed to
 // user code, but should instead be provided via a type-safe facade.
 func (a *Abstract) Ptr() Ptr {
@@ -127,3 +186,9 @@ func (a *Abstract) Ptr() Ptr {
 func (a *Abstract) TypeID() TypeID {
 	return a.typeData.TypeID
 }
+
+// TypeName returns a human-readable name for the type of the embedded
+// value, e.g. "ContainerType" or "[]Target".
+func (a *Abstract) TypeName() string {
+	return a.engine.Stringify(a.typeData.TypeID)
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// aliasCheck is accessed atomically.
+var aliasCheck atomic.Bool
+
+// SetAliasCheck enables or disables a debugging mode which verifies
+// that a walk does not modify the value that it was given, and returns
+// the previous setting. A rewrite returns a copy of the values along
+// the paths to each replacement and shares the remainder of the tree
+// with the original. If a walker changes a value which is still shared,
+// such as by modifying the elements of a slice in a shallow copy of a
+// struct, the original will be modified as well. When enabled, the
+// original is captured in the form produced by ToMap before each walk
+// and compared afterwards, and the walk returns an *AliasError which
+// names the first modified location. Walkers which deliberately modify
+// values in place will also be reported. Values which contain cycles
+// are not checked.
+//
+// This mode is expensive, and is intended for use in tests. The
+// setting applies to walks which start after the call.
+func SetAliasCheck(enabled bool) bool {
+	return aliasCheck.Swap(enabled)
+}
+
+// AliasError is returned from a walk when SetAliasCheck is enabled and
+// the walk modified the value that it was given.
+type AliasError struct {
+	// Path is the location of the modified value within the original,
+	// using the same syntax as Context.Path.
+	Path string
+}
+
+// Error implements error.
+func (e *AliasError) Error() string {
+	return fmt.Sprintf("walk modified the original value at %s, which may be shared with the result", e.Path)
+}
+
+// snapshot returns the generic form of the value, or false if it could
+// not be captured.
+func (e *Engine) snapshot(id TypeID, x Ptr) (interface{}, bool) {
+	data, err := e.ToMap(id, x)
+	return data, err == nil
+}
+
+// checkAliasing compares a snapshot to the current value.
+func (e *Engine) checkAliasing(before interface{}, id TypeID, x Ptr) error {
+	after, ok := e.snapshot(id, x)
+	if !ok {
+		return nil
+	}
+	if path, found := diffMaps(before, after, e.Stringify(id)); found {
+		return &AliasError{Path: path}
+	}
+	return nil
+}
+
+// diffMaps returns the path to the first difference between two
+// values in the generic form.
+func diffMaps(before, after interface{}, path string) (string, bool) {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok || a[TypeKey] != b[TypeKey] {
+			return path, true
+		}
+		// Visit the keys in a stable order, so the same difference is
+		// always reported.
+		keys := make([]string, 0, len(b))
+		for key := range b {
+			if key != TypeKey {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if found, ok := diffMaps(b[key], a[key], path+"/"+key); ok {
+				return found, true
+			}
+		}
+		return "", false
+
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok || len(a) != len(b) {
+			return path, true
+		}
+		for i := range b {
+			if found, ok := diffMaps(b[i], a[i], fmt.Sprintf("%s[%d]", path, i)); ok {
+				return found, true
+			}
+		}
+		return "", false
+
+	case []byte:
+		a, ok := after.([]byte)
+		return path, !ok || !bytes.Equal(a, b) || (a == nil) != (b == nil)
+
+	case float32:
+		a, ok := after.(float32)
+		return path, !ok || (a != b && !(math.IsNaN(float64(a)) && math.IsNaN(float64(b))))
+
+	case float64:
+		a, ok := after.(float64)
+		return path, !ok || (a != b && !(math.IsNaN(a) && math.IsNaN(b)))
+
+	default:
+		return path, before != after
+	}
+}
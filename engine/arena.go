@@ -0,0 +1,161 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import "unsafe"
+
+// The sizes of the values allocated for pointers and slice headers.
+const (
+	headerSize = unsafe.Sizeof(sliceHeader{})
+	ptrSize    = unsafe.Sizeof(Ptr(nil))
+)
+
+// The number of values allocated at once by an Arena.
+const defaultArenaChunk = 64
+
+// An Arena allocates the values which are cloned during a visitation in
+// batches, rather than individually, which reduces the number of
+// allocations made by large rewrites. The zero value is ready to use.
+// An Arena is not safe for concurrent use.
+type Arena struct {
+	// ChunkSize is the number of values to allocate at once. If zero,
+	// a default value is used. It must not be changed once the Arena
+	// has been used.
+	ChunkSize int
+
+	// The chunks of struct values and slice elements, keyed by the
+	// struct or slice type.
+	chunks map[TypeID]*arenaChunks
+	// Slice headers and pointers are allocated from these.
+	headers arenaChunks
+	ptrs    arenaChunks
+}
+
+// arenaChunk is a contiguous block of values.
+type arenaChunk struct {
+	base Ptr
+	// The number of values which have been allocated.
+	used int
+}
+
+// arenaChunks is a list of chunks of the same type. Chunks are
+// retained when the Arena is released, so that they can be reused.
+type arenaChunks struct {
+	data []arenaChunk
+	// The index of the chunk being allocated from.
+	idx int
+}
+
+// Release makes the memory used by the values which were returned from
+// visitations using the Arena available for reuse. Those values must
+// not be used after calling Release.
+func (a *Arena) Release() {
+	for _, c := range a.chunks {
+		c.reset()
+	}
+	a.headers.reset()
+	a.ptrs.reset()
+}
+
+// chunkSize returns the configured or default chunk size.
+func (a *Arena) chunkSize() int {
+	if a.ChunkSize > 0 {
+		return a.ChunkSize
+	}
+	return defaultArenaChunk
+}
+
+// alloc returns a pointer to the next unused value in the list of
+// chunks, calling newChunk if a chunk must be allocated.
+func (a *Arena) alloc(c *arenaChunks, size uintptr, count int, newChunk func() Ptr) Ptr {
+	chunkSize := a.chunkSize()
+	for {
+		if c.idx == len(c.data) {
+			c.data = append(c.data, arenaChunk{base: newChunk()})
+		}
+		chunk := &c.data[c.idx]
+		if chunk.used+count <= chunkSize {
+			ret := Ptr(uintptr(chunk.base) + uintptr(chunk.used)*size)
+			chunk.used += count
+			return ret
+		}
+		c.idx++
+	}
+}
+
+// chunksFor returns the chunks of values of the given type.
+func (a *Arena) chunksFor(id TypeID) *arenaChunks {
+	if a.chunks == nil {
+		a.chunks = make(map[TypeID]*arenaChunks)
+	}
+	c := a.chunks[id]
+	if c == nil {
+		c = &arenaChunks{}
+		a.chunks[id] = c
+	}
+	return c
+}
+
+// newPtr returns a pointer to a location which holds the given pointer.
+func (a *Arena) newPtr(x Ptr) Ptr {
+	if a == nil {
+		// Allocating explicitly prevents x from escaping.
+		ret := new(Ptr)
+		*ret = x
+		return Ptr(ret)
+	}
+	ret := a.alloc(&a.ptrs, ptrSize, 1, func() Ptr {
+		chunk := make([]Ptr, a.chunkSize())
+		return Ptr(&chunk[0])
+	})
+	*(*Ptr)(ret) = x
+	return ret
+}
+
+// newSlice returns a pointer to the header of a slice of the given type
+// and length, whose elements are allocated from the arena.
+func (a *Arena) newSlice(td *TypeData, size int) Ptr {
+	if a == nil || size > a.chunkSize() {
+		return td.NewSlice(size)
+	}
+	data := a.alloc(a.chunksFor(td.TypeID), td.elemData.SizeOf, size, func() Ptr {
+		return (*sliceHeader)(td.NewSlice(a.chunkSize())).Data
+	})
+	header := a.alloc(&a.headers, headerSize, 1, func() Ptr {
+		chunk := make([]sliceHeader, a.chunkSize())
+		return Ptr(&chunk[0])
+	})
+	*(*sliceHeader)(header) = sliceHeader{Data: data, Len: size, Cap: size}
+	return header
+}
+
+// newStruct returns a pointer to a struct of the given type.
+func (a *Arena) newStruct(td *TypeData) Ptr {
+	if a == nil || td.NewStructs == nil {
+		return td.NewStruct()
+	}
+	return a.alloc(a.chunksFor(td.TypeID), td.SizeOf, 1, func() Ptr {
+		return td.NewStructs(a.chunkSize())
+	})
+}
+
+// reset allows the chunks to be reused.
+func (c *arenaChunks) reset() {
+	for i := range c.data {
+		c.data[i].used = 0
+	}
+	c.idx = 0
+}
@@ -0,0 +1,225 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package bench constructs synthetic trees from an engine's TypeMap
+// and measures the cost of visiting them. This allows changes to the
+// engine to be evaluated without writing fixtures by hand.
+package bench
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"time"
+
+	e "github.com/cockroachdb/walkabout/engine"
+)
+
+// Config describes the shape of a synthetic tree.
+type Config struct {
+	// Depth is the number of levels of structs that will be created
+	// by following pointers, slices, or interfaces. Structs which are
+	// embedded by value do not count towards the depth.
+	Depth int
+	// Fanout limits the number of fields which are populated in each
+	// struct. If zero, all fields are populated.
+	Fanout int
+	// Seed is used to choose between the implementations of interfaces.
+	Seed int64
+	// SliceLen is the number of elements in each slice.
+	SliceLen int
+}
+
+// Tree is a synthetic tree.
+type Tree struct {
+	// Nodes is the number of structs in the tree.
+	Nodes int
+	// Root points to the root value.
+	Root e.Ptr
+	// TypeID is the type of the root value.
+	TypeID e.TypeID
+}
+
+// Build constructs a synthetic tree of the given root type. The root
+// type may be any type known to the engine.
+func Build(eng *e.Engine, root e.TypeID, cfg Config) (*Tree, error) {
+	types := eng.TypeMap()
+	if root <= 0 || int(root) >= len(types) || types[root].TypeID != root {
+		return nil, fmt.Errorf("unknown TypeID %d", root)
+	}
+	b := &builder{
+		Config:  cfg,
+		impls:   make(map[e.TypeID][]e.TypeID),
+		rnd:     rand.New(rand.NewSource(cfg.Seed)),
+		typeMap: types,
+	}
+	ptr := b.newValue(&types[root], 0)
+	if ptr == nil {
+		return nil, fmt.Errorf("cannot construct %s with depth %d", eng.Stringify(root), cfg.Depth)
+	}
+	return &Tree{Nodes: b.nodes, Root: ptr, TypeID: root}, nil
+}
+
+// Result describes the cost of repeatedly visiting a tree.
+type Result struct {
+	Allocs  uint64
+	Bytes   uint64
+	Elapsed time.Duration
+	Nodes   int
+	Walks   int
+}
+
+// NodesPerSecond returns the rate at which nodes were visited.
+func (r Result) NodesPerSecond() float64 {
+	if r.Elapsed == 0 {
+		return 0
+	}
+	return float64(r.Nodes) * float64(r.Walks) / r.Elapsed.Seconds()
+}
+
+// String is for human consumption.
+func (r Result) String() string {
+	walks := uint64(r.Walks)
+	if walks == 0 {
+		walks = 1
+	}
+	return fmt.Sprintf("%d nodes x %d walks in %s: %.0f nodes/sec, %d allocs/walk, %d bytes/walk",
+		r.Nodes, r.Walks, r.Elapsed, r.NodesPerSecond(), r.Allocs/walks, r.Bytes/walks)
+}
+
+// Run visits the tree the requested number of times with a generated
+// walker function, such as a FooWalkerFn, and reports the cost.
+func Run(eng *e.Engine, tree *Tree, fn e.FacadeFn, walks int) (Result, error) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < walks; i++ {
+		if _, _, _, err := eng.Execute(fn, tree.TypeID, tree.Root, tree.TypeID); err != nil {
+			return Result{}, err
+		}
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	return Result{
+		Allocs:  after.Mallocs - before.Mallocs,
+		Bytes:   after.TotalAlloc - before.TotalAlloc,
+		Elapsed: elapsed,
+		Nodes:   tree.Nodes,
+		Walks:   walks,
+	}, nil
+}
+
+// builder holds the state of constructing a tree.
+type builder struct {
+	Config
+	// impls caches the struct types which implement an interface.
+	impls   map[e.TypeID][]e.TypeID
+	nodes   int
+	rnd     *rand.Rand
+	typeMap e.TypeMap
+}
+
+// fill populates the memory at dest, which holds a value of the
+// given type.
+func (b *builder) fill(dest e.Ptr, td *e.TypeData, depth int) {
+	switch td.Kind {
+	case e.KindStruct:
+		// Structs embedded by value will always be visited.
+		b.nodes++
+		for i, f := range td.Fields {
+			if b.Fanout > 0 && i >= b.Fanout {
+				b.countZero(&b.typeMap[f.Target])
+				continue
+			}
+			b.fill(e.Ptr(uintptr(dest)+f.Offset), &b.typeMap[f.Target], depth+1)
+		}
+	case e.KindPointer:
+		if elem := b.newValue(&b.typeMap[td.Elem], depth); elem != nil {
+			*(*e.Ptr)(dest) = elem
+		}
+	case e.KindInterface, e.KindSlice:
+		if value := b.newValue(td, depth); value != nil {
+			td.Copy(dest, value)
+		}
+	default:
+		panic(fmt.Errorf("unexpected kind: %d", td.Kind))
+	}
+}
+
+// countZero accounts for the structs in a zero value of the given type,
+// which will be visited even if they are not populated.
+func (b *builder) countZero(td *e.TypeData) {
+	if td.Kind != e.KindStruct {
+		return
+	}
+	b.nodes++
+	for _, f := range td.Fields {
+		b.countZero(&b.typeMap[f.Target])
+	}
+}
+
+// newValue returns a pointer to a new, populated value of the given
+// type, or nil if the tree is already deep enough.
+func (b *builder) newValue(td *e.TypeData, depth int) e.Ptr {
+	if depth >= b.Depth {
+		return nil
+	}
+	switch td.Kind {
+	case e.KindStruct:
+		ret := td.NewStruct()
+		b.fill(ret, td, depth)
+		return ret
+	case e.KindPointer:
+		ret := new(e.Ptr)
+		b.fill(e.Ptr(ret), td, depth)
+		return e.Ptr(ret)
+	case e.KindSlice:
+		ret := td.NewSlice(b.SliceLen)
+		elem := &b.typeMap[td.Elem]
+		// The first word of a slice header is the data pointer.
+		data := *(*e.Ptr)(ret)
+		for i := 0; i < b.SliceLen; i++ {
+			b.fill(e.Ptr(uintptr(data)+uintptr(i)*elem.SizeOf), elem, depth)
+		}
+		return ret
+	case e.KindInterface:
+		impls := b.implementors(td)
+		if len(impls) == 0 {
+			return nil
+		}
+		impl := &b.typeMap[impls[b.rnd.Intn(len(impls))]]
+		return td.IntfWrap(impl.TypeID, b.newValue(impl, depth))
+	default:
+		panic(fmt.Errorf("unexpected kind: %d", td.Kind))
+	}
+}
+
+// implementors returns the struct types which may be stored in an
+// interface, by asking the interface to wrap each struct type.
+func (b *builder) implementors(intf *e.TypeData) []e.TypeID {
+	if ret, ok := b.impls[intf.TypeID]; ok {
+		return ret
+	}
+	var ret []e.TypeID
+	for i := range b.typeMap {
+		td := &b.typeMap[i]
+		if td.Kind == e.KindStruct && intf.IntfWrap(td.TypeID, td.NewStruct()) != nil {
+			ret = append(ret, td.TypeID)
+		}
+	}
+	b.impls[intf.TypeID] = ret
+	return ret
+}
@@ -0,0 +1,195 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+//go:build walkabout_debug
+// +build walkabout_debug
+
+package engine
+
+// This file contains checks which are compiled in when the
+// walkabout_debug build tag is set. They validate the metadata and the
+// pointers which the engine is about to cast, so that a bug in the
+// generated code or in the engine panics, rather than silently
+// corrupting memory.
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// debugEnabled allows the checks to be compiled out.
+const debugEnabled = true
+
+// poisoned is the type of the slots in frames which have been popped
+// from the stack and which must no longer be read.
+var poisoned = TypeData{Name: "<freed>", TypeID: -1}
+
+// debugf panics with a message that identifies the location in the
+// walk.
+func debugf(s *stack, format string, args ...interface{}) {
+	panic(fmt.Errorf("walkabout_debug: %s: %s", s.Path(), fmt.Sprintf(format, args...)))
+}
+
+// debugCheckTypeMap validates the sizes and offsets which the
+// generated code has recorded.
+func (e *Engine) debugCheckTypeMap() {
+	for i := range e.typeMap {
+		td := &e.typeMap[i]
+		if td.TypeID == 0 {
+			continue
+		}
+		if td.TypeID != TypeID(i) {
+			panic(fmt.Errorf("walkabout_debug: %s has TypeID %d at index %d",
+				td.Name, td.TypeID, i))
+		}
+		var want uintptr
+		switch td.Kind {
+		case KindInterface:
+			want = unsafe.Sizeof([2]Ptr{})
+		case KindPointer:
+			want = unsafe.Sizeof(Ptr(nil))
+		case KindSlice:
+			want = unsafe.Sizeof(sliceHeader{})
+		case KindStruct:
+			want = td.SizeOf
+			for _, f := range td.Fields {
+				if f.Offset+f.targetData.SizeOf > td.SizeOf {
+					panic(fmt.Errorf("walkabout_debug: %s.%s at offset %d with size %d exceeds size %d",
+						td.Name, fieldName(f.Name, f.Field), f.Offset, f.targetData.SizeOf, td.SizeOf))
+				}
+			}
+		default:
+			panic(fmt.Errorf("walkabout_debug: %s has unknown kind %d", td.Name, td.Kind))
+		}
+		if td.SizeOf != want {
+			panic(fmt.Errorf("walkabout_debug: %s has size %d, expected %d",
+				td.Name, td.SizeOf, want))
+		}
+		if (td.Kind == KindPointer || td.Kind == KindSlice) && td.elemData == nil {
+			panic(fmt.Errorf("walkabout_debug: %s has no element type", td.Name))
+		}
+	}
+}
+
+// debugCheckType panics if the TypeData does not belong to the engine.
+func (e *Engine) debugCheckType(s *stack, td *TypeData) {
+	switch {
+	case td == &poisoned:
+		debugf(s, "use of a slot in a popped frame")
+	case td == nil:
+		debugf(s, "slot has no type")
+	case td.TypeID <= 0 || int(td.TypeID) >= len(e.typeMap) || &e.typeMap[td.TypeID] != td:
+		debugf(s, "type %s (%d) does not belong to the engine", td.Name, td.TypeID)
+	}
+}
+
+// debugCheckSlot validates a slot before its value is visited.
+func (e *Engine) debugCheckSlot(s *stack, a *Action) {
+	td := a.typeData
+	e.debugCheckType(s, td)
+	// A struct's value is nil only if it is a typed nil which the
+	// options require to be visited.
+	if a.value == nil && td.Kind != KindStruct {
+		debugf(s, "nil pointer to %s", td.Name)
+	}
+	if a.assignableTo != nil {
+		e.debugCheckType(s, a.assignableTo)
+		e.debugCheckAssignable(s, a.assignableTo, td)
+	}
+	switch td.Kind {
+	case KindInterface:
+		id := td.intfType(a.value)
+		ptr := (*[2]Ptr)(a.value)[1]
+		if id == 0 || ptr == nil {
+			break
+		}
+		elem := e.typeData(id)
+		e.debugCheckType(s, elem)
+		e.debugCheckAssignable(s, td, elem)
+	case KindSlice:
+		header := (*sliceHeader)(a.value)
+		if header.Len < 0 || header.Len > header.Cap || (header.Cap > 0 && header.Data == nil) {
+			debugf(s, "corrupt slice header for %s: %+v", td.Name, *header)
+		}
+	}
+}
+
+// debugCheckAssignable panics if a value of the given type cannot be
+// stored in a location of type slot. A struct is only checked for
+// assignability to an interface by apply, which must allocate to do so.
+func (e *Engine) debugCheckAssignable(s *stack, slot, td *TypeData) {
+	switch {
+	case slot == td:
+	case slot.Kind != KindInterface:
+		debugf(s, "%s stored in a location of type %s", td.Name, slot.Name)
+	case td.Kind != KindStruct:
+		debugf(s, "%s is not assignable to %s", td.Name, slot.Name)
+	}
+}
+
+// debugCheckReplacement validates the type of a non-nil replacement.
+func (e *Engine) debugCheckReplacement(s *stack, d *Decision) {
+	id := d.replacementType
+	if id <= 0 || int(id) >= len(e.typeMap) || e.typeMap[id].TypeID != id {
+		debugf(s, "replacement has unknown type %d", id)
+	}
+	if e.typeMap[id].Kind != KindStruct {
+		debugf(s, "replacement has non-struct type %s", e.typeMap[id].Name)
+	}
+}
+
+// debugCheckFold validates the slots of a frame whose values are about
+// to be copied into a replacement for the slot.
+func (e *Engine) debugCheckFold(s *stack, a *Action, children *frame) {
+	td := a.typeData
+	var count int
+	switch td.Kind {
+	case KindStruct:
+		count = len(td.Fields)
+	case KindPointer, KindInterface:
+		count = 1
+	case KindSlice:
+		if children.sliceClone != nil {
+			return
+		}
+		count = children.Count
+	}
+	if children.Count != count {
+		debugf(s, "folding %d slots into %s, expected %d", children.Count, td.Name, count)
+	}
+	for i := 0; i < count; i++ {
+		child := children.Slot(i)
+		e.debugCheckType(s, child.typeData)
+		switch td.Kind {
+		case KindStruct:
+			e.debugCheckAssignable(s, td.Fields[i].targetData, child.typeData)
+		case KindPointer, KindSlice:
+			e.debugCheckAssignable(s, td.elemData, child.typeData)
+		case KindInterface:
+			e.debugCheckAssignable(s, td, child.typeData)
+		}
+	}
+}
+
+// debugPoison overwrites the slots of a frame which has been popped, so
+// that any later use of them will be detected.
+func debugPoison(f *frame) {
+	for i := range f.Slots {
+		f.Slots[i] = Action{typeData: &poisoned}
+	}
+	for i := range f.Overflow {
+		f.Overflow[i] = Action{typeData: &poisoned}
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+//go:build !walkabout_debug
+// +build !walkabout_debug
+
+package engine
+
+// debugEnabled allows the checks in debug.go to be compiled out. The
+// functions in this file are never called.
+const debugEnabled = false
+
+func (e *Engine) debugCheckTypeMap()                                  {}
+func (e *Engine) debugCheckSlot(s *stack, a *Action)                  {}
+func (e *Engine) debugCheckReplacement(s *stack, d *Decision)         {}
+func (e *Engine) debugCheckFold(s *stack, a *Action, children *frame) {}
+func debugPoison(f *frame)                                            {}
@@ -0,0 +1,81 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains support for Context.Defer.
+
+import "sync"
+
+// scopePool allows Scopes to be reused across walks.
+var scopePool = sync.Pool{
+	New: func() interface{} { return &Scope{} },
+}
+
+// A Scope collects the functions passed to Context.Defer during a walk
+// which is split across several calls to ResumeScope. It is for use by
+// generated walkers only.
+type Scope struct {
+	defers []ActionFn
+}
+
+// AcquireScope returns a Scope from a pool. The caller must call
+// Release once the walk is complete.
+func AcquireScope() *Scope {
+	return scopePool.Get().(*Scope)
+}
+
+// Close calls the deferred functions in the reverse order of their
+// registration. It returns err if it is non-nil, otherwise it returns
+// the first error returned by a deferred function.
+func (s *Scope) Close(err error) error {
+	// The functions are removed first, so that they will not be called
+	// again by Release if one of them panics.
+	defers := s.defers
+	s.defers = defers[:0]
+	if deferErr := runDeferred(defers); err == nil {
+		err = deferErr
+	}
+	for i := range defers {
+		defers[i] = nil
+	}
+	return err
+}
+
+// Context returns a Context, for a value which is not being visited by
+// the engine, which records deferred functions in the Scope.
+func (s *Scope) Context() Context {
+	return Context{scope: s}
+}
+
+// Release calls any deferred functions which have not been called by
+// Close, such as when a walker panics, and returns the Scope to the
+// pool.
+func (s *Scope) Release() {
+	_ = s.Close(nil)
+	scopePool.Put(s)
+}
+
+// runDeferred calls the functions in reverse order and returns the
+// first error. Every function is called, even if one fails.
+func runDeferred(fns []ActionFn) error {
+	var ret error
+	for i := len(fns) - 1; i >= 0; i-- {
+		if err := fns[i](); err != nil && ret == nil {
+			ret = err
+		}
+	}
+	return ret
+}
@@ -0,0 +1,631 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains a compact, binary encoding of visitable values.
+//
+// An encoding consists of:
+//	version  a byte, which is encodingVersion
+//	structs  a count, followed by each struct type that was encoded:
+//	           its name
+//	           a count, followed by the name and type of each field
+//	root     the name of the type of the encoded value
+//	value
+//
+// Counts and lengths are uvarints and strings are prefixed by their
+// length. Values are encoded according to their static type:
+//	struct     its fields, in the order given in the table of structs
+//	pointer    0 if nil, otherwise 1 followed by the element
+//	slice      0 if nil, otherwise the length plus one, followed by
+//	           the elements
+//	interface  0 if nil, otherwise the index of the struct type in the
+//	           table plus one, followed by the struct
+//
+// Types and fields are referred to by name, rather than by TypeID, so
+// that encoded values remain valid when TypeIDs are reassigned or when
+// fields are added to or reordered within a struct.
+//
+// Since the encoding may come from an untrusted source, no count may
+// exceed the number of bytes which remain to be decoded. This bounds
+// the memory which Decode allocates by the size of the encoding, but
+// means that a slice of structs which have no encoded fields cannot be
+// decoded if it has more elements than there are bytes after its
+// length, unless the structs occupy no memory.
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// encodingVersion is the first byte of every encoding. It must be
+// changed if the format changes incompatibly.
+const encodingVersion = 1
+
+var errTruncated = errors.New("truncated encoding")
+
+// Encode appends a compact, binary encoding of the value of the given
+// type at x to buf, which may be decoded by Decode. The visitable
+// fields of structs and the fields described by TypeData.Scalars are
+// encoded, while other fields are not. Values which are reachable more
+// than once will be encoded more than once, and an error is returned
+// if the value contains a cycle.
+func (e *Engine) Encode(buf []byte, id TypeID, x Ptr) ([]byte, error) {
+	enc := encoder{
+		engine:  e,
+		active:  make(map[cycleKey]struct{}),
+		structs: make(map[TypeID]int),
+	}
+	if err := enc.value(e.typeData(id), x); err != nil {
+		return buf, err
+	}
+
+	buf = append(buf, encodingVersion)
+	buf = binary.AppendUvarint(buf, uint64(len(enc.table)))
+	for _, td := range enc.table {
+		buf = appendString(buf, td.Name)
+		buf = binary.AppendUvarint(buf, uint64(len(td.Fields)+len(td.Scalars)))
+		for _, f := range td.Fields {
+			buf = appendString(buf, f.Name)
+			buf = appendString(buf, e.Stringify(f.Target))
+		}
+		for _, s := range td.Scalars {
+			buf = appendString(buf, s.Name)
+			buf = appendString(buf, s.Kind.String())
+		}
+	}
+	buf = appendString(buf, e.Stringify(id))
+	return append(buf, enc.body...), nil
+}
+
+// Decode replaces the value of the given type at x with a value that
+// was encoded by Encode. Pointers to structs which implement an
+// interface are decoded as pointers, even if a struct was stored in
+// the interface by value.
+func (e *Engine) Decode(data []byte, id TypeID, x Ptr) error {
+	dec := decoder{engine: e, data: data}
+	version, err := dec.byte()
+	if err != nil {
+		return err
+	}
+	if version != encodingVersion {
+		return fmt.Errorf("unsupported encoding version %d", version)
+	}
+	if err := dec.readTable(); err != nil {
+		return err
+	}
+	root, err := dec.string()
+	if err != nil {
+		return err
+	}
+	if want := e.Stringify(id); root != want {
+		return fmt.Errorf("cannot decode a %s as a %s", root, want)
+	}
+	if err := dec.value(e.typeData(id), x); err != nil {
+		return err
+	}
+	if len(dec.data) > 0 {
+		return fmt.Errorf("%d unexpected bytes after encoding", len(dec.data))
+	}
+	return nil
+}
+
+// encoder holds the state of a call to Encode.
+type encoder struct {
+	engine *Engine
+	// The structs which are being encoded, to detect cycles.
+	active map[cycleKey]struct{}
+	// The encoded value, which follows the table of structs.
+	body []byte
+	// The index of each struct type within table.
+	structs map[TypeID]int
+	table   []*TypeData
+}
+
+// enter encodes a value which is reachable through a pointer or an
+// interface, which may lead to a cycle.
+func (enc *encoder) enter(td *TypeData, x Ptr) error {
+	if td.Kind != KindStruct {
+		return enc.value(td, x)
+	}
+	key := cycleKey{td.TypeID, x}
+	if _, found := enc.active[key]; found {
+		return fmt.Errorf("cannot encode a %w through %s", ErrCycle, td.Name)
+	}
+	enc.active[key] = struct{}{}
+	err := enc.value(td, x)
+	delete(enc.active, key)
+	return err
+}
+
+// structIndex returns the position of the struct type in the table.
+func (enc *encoder) structIndex(td *TypeData) int {
+	idx, found := enc.structs[td.TypeID]
+	if !found {
+		idx = len(enc.table)
+		enc.structs[td.TypeID] = idx
+		enc.table = append(enc.table, td)
+	}
+	return idx
+}
+
+// value appends the encoding of x to the body.
+func (enc *encoder) value(td *TypeData, x Ptr) error {
+	switch td.Kind {
+	case KindStruct:
+		enc.structIndex(td)
+		for _, f := range td.Fields {
+			if err := enc.value(f.targetData, Ptr(uintptr(x)+f.Offset)); err != nil {
+				return err
+			}
+		}
+		for _, s := range td.Scalars {
+			enc.body = appendScalar(enc.body, s.Kind, Ptr(uintptr(x)+s.Offset))
+		}
+
+	case KindPointer:
+		ptr := *(*Ptr)(x)
+		if ptr == nil {
+			enc.body = append(enc.body, 0)
+			return nil
+		}
+		enc.body = append(enc.body, 1)
+		return enc.enter(td.elemData, ptr)
+
+	case KindSlice:
+		header := (*sliceHeader)(x)
+		if header.Data == nil {
+			enc.body = append(enc.body, 0)
+			return nil
+		}
+		enc.body = binary.AppendUvarint(enc.body, uint64(header.Len)+1)
+		eltTd := td.elemData
+		for i, off := 0, uintptr(0); i < header.Len; i, off = i+1, off+eltTd.SizeOf {
+			if err := enc.value(eltTd, Ptr(uintptr(header.Data)+off)); err != nil {
+				return err
+			}
+		}
+
+	case KindInterface:
+		ptr := (*[2]Ptr)(x)[1]
+		elem := td.intfType(x)
+		if elem == 0 || ptr == nil {
+			enc.body = append(enc.body, 0)
+			return nil
+		}
+		elemTd := enc.engine.typeData(elem)
+		enc.body = binary.AppendUvarint(enc.body, uint64(enc.structIndex(elemTd))+1)
+		return enc.enter(elemTd, ptr)
+
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+	return nil
+}
+
+// decodedStruct maps an entry in the encoded table of structs to a
+// local struct type.
+type decodedStruct struct {
+	name   string
+	fields []decodedField
+	// typeData will be nil if there is no local type with the name.
+	typeData *TypeData
+}
+
+// decodedField maps an encoded field to a field of a local struct.
+type decodedField struct {
+	offset uintptr
+	scalar ScalarKind
+	// targetData will be nil for scalar fields.
+	targetData *TypeData
+}
+
+// decoder holds the state of a call to Decode.
+type decoder struct {
+	engine *Engine
+	// The data which has not yet been consumed.
+	data []byte
+	// The encoded table of structs.
+	structs []decodedStruct
+	// Entries in structs, by local TypeID.
+	byID map[TypeID]*decodedStruct
+}
+
+// readTable reads the table of structs and maps the encoded fields to
+// the fields of the local types.
+func (dec *decoder) readTable() error {
+	byName := make(map[string]*TypeData)
+	for idx := range dec.engine.typeMap {
+		if td := &dec.engine.typeMap[idx]; td.Kind == KindStruct {
+			byName[td.Name] = td
+		}
+	}
+
+	count, err := dec.count()
+	if err != nil {
+		return err
+	}
+	if count > len(dec.data) {
+		return errTruncated
+	}
+	dec.structs = make([]decodedStruct, count)
+	dec.byID = make(map[TypeID]*decodedStruct, count)
+	for i := range dec.structs {
+		ds := &dec.structs[i]
+		if ds.name, err = dec.string(); err != nil {
+			return err
+		}
+		fieldCount, err := dec.count()
+		if err != nil {
+			return err
+		}
+		if fieldCount > len(dec.data) {
+			return errTruncated
+		}
+		ds.typeData = byName[ds.name]
+		if ds.typeData != nil {
+			dec.byID[ds.typeData.TypeID] = ds
+		}
+		ds.fields = make([]decodedField, fieldCount)
+		for j := range ds.fields {
+			name, err := dec.string()
+			if err != nil {
+				return err
+			}
+			typ, err := dec.string()
+			if err != nil {
+				return err
+			}
+			if ds.typeData == nil {
+				continue
+			}
+			if ds.fields[j], err = dec.field(ds.typeData, name, typ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// field finds the local field which corresponds to an encoded field.
+func (dec *decoder) field(td *TypeData, name, typ string) (decodedField, error) {
+	for _, f := range td.Fields {
+		if f.Name != name {
+			continue
+		}
+		if local := dec.engine.Stringify(f.Target); local != typ {
+			return decodedField{}, fmt.Errorf("field %s.%s was encoded as %s, but is %s",
+				td.Name, name, typ, local)
+		}
+		return decodedField{offset: f.Offset, targetData: f.targetData}, nil
+	}
+	for _, s := range td.Scalars {
+		if s.Name != name {
+			continue
+		}
+		if local := s.Kind.String(); local != typ {
+			return decodedField{}, fmt.Errorf("field %s.%s was encoded as %s, but is %s",
+				td.Name, name, typ, local)
+		}
+		return decodedField{offset: s.Offset, scalar: s.Kind}, nil
+	}
+	return decodedField{}, fmt.Errorf("unknown field %s.%s", td.Name, name)
+}
+
+// value decodes a value into x.
+func (dec *decoder) value(td *TypeData, x Ptr) error {
+	switch td.Kind {
+	case KindStruct:
+		ds := dec.byID[td.TypeID]
+		if ds == nil {
+			return fmt.Errorf("missing %s in table of structs", td.Name)
+		}
+		return dec.structFields(ds, x)
+
+	case KindPointer:
+		present, err := dec.byte()
+		if err != nil {
+			return err
+		}
+		switch present {
+		case 0:
+			*(*Ptr)(x) = nil
+		case 1:
+			elem := newValue(td.elemData)
+			if err := dec.value(td.elemData, elem); err != nil {
+				return err
+			}
+			*(*Ptr)(x) = elem
+		default:
+			return fmt.Errorf("bad pointer marker %d", present)
+		}
+
+	case KindSlice:
+		count, err := dec.count()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			*(*sliceHeader)(x) = sliceHeader{}
+			return nil
+		}
+		count--
+		eltTd := td.elemData
+		// The table of structs is not trusted to say how many bytes an
+		// element occupies.
+		if count > len(dec.data) && eltTd.SizeOf > 0 {
+			return errTruncated
+		}
+		next := td.NewSlice(count)
+		header := (*sliceHeader)(next)
+		for i, off := 0, uintptr(0); i < count; i, off = i+1, off+eltTd.SizeOf {
+			if err := dec.value(eltTd, Ptr(uintptr(header.Data)+off)); err != nil {
+				return err
+			}
+			// Values which occupy no memory are identical and are
+			// encoded in no bytes, so only one must be decoded.
+			if eltTd.SizeOf == 0 {
+				break
+			}
+		}
+		td.Copy(x, next)
+
+	case KindInterface:
+		idx, err := dec.count()
+		if err != nil {
+			return err
+		}
+		if idx == 0 {
+			*(*[2]Ptr)(x) = [2]Ptr{}
+			return nil
+		}
+		if idx > len(dec.structs) {
+			return fmt.Errorf("bad struct index %d", idx)
+		}
+		ds := &dec.structs[idx-1]
+		if ds.typeData == nil {
+			return fmt.Errorf("unknown type %s", ds.name)
+		}
+		elem := ds.typeData.NewStruct()
+		if err := dec.structFields(ds, elem); err != nil {
+			return err
+		}
+		wrapped := td.IntfWrap(ds.typeData.TypeID, elem)
+		if wrapped == nil {
+			return fmt.Errorf("type %s is not assignable to %s", ds.name, td.Name)
+		}
+		td.Copy(x, wrapped)
+
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+	return nil
+}
+
+// structFields decodes the fields of a struct into x.
+func (dec *decoder) structFields(ds *decodedStruct, x Ptr) error {
+	for _, f := range ds.fields {
+		fPtr := Ptr(uintptr(x) + f.offset)
+		var err error
+		if f.targetData == nil {
+			err = dec.scalar(f.scalar, fPtr)
+		} else {
+			err = dec.value(f.targetData, fPtr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scalar decodes a basic value into x.
+func (dec *decoder) scalar(kind ScalarKind, x Ptr) error {
+	switch kind {
+	case ScalarBool:
+		b, err := dec.byte()
+		if err != nil {
+			return err
+		}
+		*(*bool)(x) = b != 0
+
+	case ScalarInt, ScalarInt8, ScalarInt16, ScalarInt32, ScalarInt64:
+		v, n := binary.Varint(dec.data)
+		if n <= 0 {
+			return errTruncated
+		}
+		dec.data = dec.data[n:]
+		switch kind {
+		case ScalarInt:
+			*(*int)(x) = int(v)
+		case ScalarInt8:
+			*(*int8)(x) = int8(v)
+		case ScalarInt16:
+			*(*int16)(x) = int16(v)
+		case ScalarInt32:
+			*(*int32)(x) = int32(v)
+		case ScalarInt64:
+			*(*int64)(x) = v
+		}
+
+	case ScalarUint, ScalarUint8, ScalarUint16, ScalarUint32, ScalarUint64, ScalarUintptr:
+		v, n := binary.Uvarint(dec.data)
+		if n <= 0 {
+			return errTruncated
+		}
+		dec.data = dec.data[n:]
+		switch kind {
+		case ScalarUint:
+			*(*uint)(x) = uint(v)
+		case ScalarUint8:
+			*(*uint8)(x) = uint8(v)
+		case ScalarUint16:
+			*(*uint16)(x) = uint16(v)
+		case ScalarUint32:
+			*(*uint32)(x) = uint32(v)
+		case ScalarUint64:
+			*(*uint64)(x) = v
+		case ScalarUintptr:
+			*(*uintptr)(x) = uintptr(v)
+		}
+
+	case ScalarFloat32:
+		if len(dec.data) < 4 {
+			return errTruncated
+		}
+		*(*float32)(x) = math.Float32frombits(binary.LittleEndian.Uint32(dec.data))
+		dec.data = dec.data[4:]
+
+	case ScalarFloat64:
+		if len(dec.data) < 8 {
+			return errTruncated
+		}
+		*(*float64)(x) = math.Float64frombits(binary.LittleEndian.Uint64(dec.data))
+		dec.data = dec.data[8:]
+
+	case ScalarString:
+		s, err := dec.string()
+		if err != nil {
+			return err
+		}
+		*(*string)(x) = s
+
+	case ScalarBytes:
+		count, err := dec.count()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			*(*[]byte)(x) = nil
+			return nil
+		}
+		count--
+		if count > len(dec.data) {
+			return errTruncated
+		}
+		*(*[]byte)(x) = append([]byte{}, dec.data[:count]...)
+		dec.data = dec.data[count:]
+
+	default:
+		return fmt.Errorf("unsupported scalar kind %d", kind)
+	}
+	return nil
+}
+
+// byte consumes a single byte.
+func (dec *decoder) byte() (byte, error) {
+	if len(dec.data) == 0 {
+		return 0, errTruncated
+	}
+	ret := dec.data[0]
+	dec.data = dec.data[1:]
+	return ret, nil
+}
+
+// count consumes a uvarint which must fit within an int.
+func (dec *decoder) count() (int, error) {
+	v, n := binary.Uvarint(dec.data)
+	if n <= 0 || v > math.MaxInt32 {
+		return 0, errTruncated
+	}
+	dec.data = dec.data[n:]
+	return int(v), nil
+}
+
+// string consumes a length-prefixed string.
+func (dec *decoder) string() (string, error) {
+	count, err := dec.count()
+	if err != nil {
+		return "", err
+	}
+	if count > len(dec.data) {
+		return "", errTruncated
+	}
+	ret := string(dec.data[:count])
+	dec.data = dec.data[count:]
+	return ret, nil
+}
+
+// appendScalar appends the encoding of the basic value at x.
+func appendScalar(buf []byte, kind ScalarKind, x Ptr) []byte {
+	switch kind {
+	case ScalarBool:
+		if *(*bool)(x) {
+			return append(buf, 1)
+		}
+		return append(buf, 0)
+	case ScalarInt:
+		return binary.AppendVarint(buf, int64(*(*int)(x)))
+	case ScalarInt8:
+		return binary.AppendVarint(buf, int64(*(*int8)(x)))
+	case ScalarInt16:
+		return binary.AppendVarint(buf, int64(*(*int16)(x)))
+	case ScalarInt32:
+		return binary.AppendVarint(buf, int64(*(*int32)(x)))
+	case ScalarInt64:
+		return binary.AppendVarint(buf, *(*int64)(x))
+	case ScalarUint:
+		return binary.AppendUvarint(buf, uint64(*(*uint)(x)))
+	case ScalarUint8:
+		return binary.AppendUvarint(buf, uint64(*(*uint8)(x)))
+	case ScalarUint16:
+		return binary.AppendUvarint(buf, uint64(*(*uint16)(x)))
+	case ScalarUint32:
+		return binary.AppendUvarint(buf, uint64(*(*uint32)(x)))
+	case ScalarUint64:
+		return binary.AppendUvarint(buf, *(*uint64)(x))
+	case ScalarUintptr:
+		return binary.AppendUvarint(buf, uint64(*(*uintptr)(x)))
+	case ScalarFloat32:
+		return binary.LittleEndian.AppendUint32(buf, math.Float32bits(*(*float32)(x)))
+	case ScalarFloat64:
+		return binary.LittleEndian.AppendUint64(buf, math.Float64bits(*(*float64)(x)))
+	case ScalarString:
+		return appendString(buf, *(*string)(x))
+	case ScalarBytes:
+		b := *(*[]byte)(x)
+		if b == nil {
+			return append(buf, 0)
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(b))+1)
+		return append(buf, b...)
+	default:
+		panic(fmt.Errorf("unimplemented: %d", kind))
+	}
+}
+
+// appendString appends a length-prefixed string.
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// newValue allocates a zero value of the given type.
+func newValue(td *TypeData) Ptr {
+	switch td.Kind {
+	case KindStruct:
+		return td.NewStruct()
+	case KindSlice:
+		return td.NewSlice(0)
+	case KindInterface:
+		return Ptr(new([2]Ptr))
+	case KindPointer:
+		return Ptr(new(Ptr))
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+}
@@ -20,16 +20,38 @@ package engine
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Allows us to pre-allocate working space on the call stack.
 const defaultStackDepth = 8
 
+// See SetCycleThreshold.
+const defaultCycleThreshold = 32
+
+// cycleThreshold is accessed atomically.
+var cycleThreshold = int64(defaultCycleThreshold)
+
+// SetCycleThreshold configures the stack depth beyond which cycles
+// are detected using a hash lookup instead of a linear scan of the
+// stack, and returns the previous value. A linear scan is faster for
+// the shallow stacks that most visitations produce. A threshold of zero
+// will always use hash lookups, while a negative threshold disables
+// them. The new value applies to visitations which start after the
+// call.
+func SetCycleThreshold(depth int) int {
+	return int(atomic.SwapInt64(&cycleThreshold, int64(depth)))
+}
+
 // See discussion on frame.Slots.
 const fixedSlotCount = 16
 
+// Slices which are longer than this are visited in chunks, to bound
+// the number of slots which are needed.
+const sliceChunkSize = 1024
+
 // A frame represents the visitation of a single struct,
 // interface, or slice.
 type frame struct {
@@ -38,13 +60,26 @@ type frame struct {
 	// Idx is the current slot being visited.
 	Idx       int
 	Intercept FacadeFn
+	// actions is set if the slots hold the actions of a Decision,
+	// rather than the fields of a struct.
+	actions bool
 	// We keep a fixed-size array of slots per frame so that most
 	// visitable objects won't need a heap allocation to store
 	// the intermediate state.
 	Slots [fixedSlotCount]Action
-	// Large targets (such as slices) will use additional, heap-allocated
-	// memory to store the intermediate state.
+	// Large targets (such as slices) will use additional slots, which
+	// are allocated from an arena owned by the stack.
 	Overflow []Action
+
+	// These fields are set when visiting a slice which is longer than
+	// sliceChunkSize. The slots hold a chunk of the slice's elements,
+	// starting at sliceOffset.
+	sliceData   *TypeData
+	slice       sliceHeader
+	sliceOffset int
+	// sliceClone is a replacement for the slice, which is allocated once
+	// any element of the slice has changed.
+	sliceClone Ptr
 }
 
 // Active retrieves the active slot.
@@ -52,6 +87,12 @@ func (f *frame) Active() *Action {
 	return f.Slot(f.Idx)
 }
 
+// activeKey identifies the value in the active slot.
+func (f *frame) activeKey() cycleKey {
+	active := f.Active()
+	return cycleKey{active.typeData.TypeID, active.value}
+}
+
 // Slot is used to access a storage slot within the frame.
 func (f *frame) Slot(idx int) *Action {
 	if idx < fixedSlotCount {
@@ -60,6 +101,49 @@ func (f *frame) Slot(idx int) *Action {
 	return &f.Overflow[idx-fixedSlotCount]
 }
 
+// fillChunk configures the slots to visit the chunk of a large slice
+// which starts at sliceOffset.
+func (f *frame) fillChunk(e *Engine) {
+	eltTd := f.sliceData.elemData
+	f.Count = f.slice.Len - f.sliceOffset
+	if f.Count > sliceChunkSize {
+		f.Count = sliceChunkSize
+	}
+	f.Idx = 0
+	for i, off := 0, uintptr(f.sliceOffset)*eltTd.SizeOf; i < f.Count; i, off = i+1, off+eltTd.SizeOf {
+		f.SetSlot(e, i, Context{}.ActionVisitReplace(eltTd, Ptr(uintptr(f.slice.Data)+off), eltTd))
+	}
+}
+
+// flushChunk copies the elements in the current chunk of a large
+// slice into the replacement slice, which will be allocated if the
+// slice is dirty. If last is true, any elements beyond the current
+// chunk will also be copied, since they will not be visited.
+func (f *frame) flushChunk(arena *Arena, dirty, last bool) {
+	eltTd := f.sliceData.elemData
+	if f.sliceClone == nil {
+		if !dirty {
+			return
+		}
+		f.sliceClone = arena.newSlice(f.sliceData, f.slice.Len)
+		// Copy the elements from any previous chunks.
+		for i := 0; i < f.sliceOffset; i++ {
+			off := uintptr(i) * eltTd.SizeOf
+			eltTd.Copy(Ptr(uintptr((*sliceHeader)(f.sliceClone).Data)+off), Ptr(uintptr(f.slice.Data)+off))
+		}
+	}
+	for i := 0; i < f.Count; i++ {
+		off := uintptr(f.sliceOffset+i) * eltTd.SizeOf
+		eltTd.Copy(Ptr(uintptr((*sliceHeader)(f.sliceClone).Data)+off), f.Slot(i).value)
+	}
+	if last {
+		for i := f.sliceOffset + f.Count; i < f.slice.Len; i++ {
+			off := uintptr(i) * eltTd.SizeOf
+			eltTd.Copy(Ptr(uintptr((*sliceHeader)(f.sliceClone).Data)+off), Ptr(uintptr(f.slice.Data)+off))
+		}
+	}
+}
+
 // SetSlot is a helper function to configure a slot.
 func (f *frame) SetSlot(e *Engine, idx int, action Action) *Action {
 	ret := f.Slot(idx)
@@ -78,14 +162,21 @@ func (f *frame) Zero() *Action {
 // An Engine holds the necessary information to pass a visitor over
 // a field.
 type Engine struct {
+	// filters caches the *typeFilter for each set of types passed to
+	// Options.Only.
+	filters sync.Map
 	typeMap TypeMap
 }
 
-// New constructs an Engine.
+// New constructs an Engine. It panics if the TypeMap is inconsistent,
+// or if a struct has changed since the TypeMap was generated.
 func New(m TypeMap) *Engine {
 	// Make a copy of the TypeMap and link all of the TypeDatas together.
 	e := &Engine{typeMap: append(m[:0:0], m...)}
 	for idx, td := range e.typeMap {
+		if td.Type != nil {
+			td.checkShape()
+		}
 		if td.Elem != 0 {
 			found := e.typeData(td.Elem)
 			if found.TypeID == 0 {
@@ -103,6 +194,17 @@ func New(m TypeMap) *Engine {
 			}
 			e.typeMap[idx].Fields[fIdx].targetData = found
 		}
+
+		if len(td.IntfImpls) > 0 {
+			m := make(map[Ptr]TypeID, len(td.IntfImpls))
+			for _, impl := range td.IntfImpls {
+				m[(*[2]Ptr)(impl.Sample)[0]] = impl.TypeID
+			}
+			e.typeMap[idx].intfMap = m
+		}
+	}
+	if debugEnabled {
+		e.debugCheckTypeMap()
 	}
 	return e
 }
@@ -127,8 +229,147 @@ func (e *Engine) Abstract(typeID TypeID, x Ptr) *Abstract {
 func (e *Engine) Execute(
 	fn FacadeFn, t TypeID, x Ptr, assignableTo TypeID,
 ) (retType TypeID, ret Ptr, changed bool, err error) {
+	retType, ret, changed, _, err = e.execute(Options{}, nil, nil, nil, fn, nil, t, x, assignableTo)
+	return
+}
+
+// ExecuteWith is equivalent to Execute, but uses the caller's stack
+// instead of a pooled stack.
+func (e *Engine) ExecuteWith(
+	stack *Stack, fn FacadeFn, t TypeID, x Ptr, assignableTo TypeID,
+) (retType TypeID, ret Ptr, changed bool, err error) {
+	retType, ret, changed, _, err = e.execute(Options{}, nil, &stack.impl, nil, fn, nil, t, x, assignableTo)
+	return
+}
+
+// ExecuteArena is equivalent to Execute, but any values which are
+// cloned are allocated from the arena.
+func (e *Engine) ExecuteArena(
+	arena *Arena, fn FacadeFn, t TypeID, x Ptr, assignableTo TypeID,
+) (retType TypeID, ret Ptr, changed bool, err error) {
+	retType, ret, changed, _, err = e.execute(Options{}, arena, nil, nil, fn, nil, t, x, assignableTo)
+	return
+}
+
+// ExecuteOptions is equivalent to Execute, but accepts options which
+// control the walk.
+func (e *Engine) ExecuteOptions(
+	opts Options, fn FacadeFn, t TypeID, x Ptr, assignableTo TypeID,
+) (retType TypeID, ret Ptr, changed bool, err error) {
+	retType, ret, changed, _, err = e.execute(opts, nil, nil, nil, fn, nil, t, x, assignableTo)
+	return
+}
+
+// Resume is equivalent to Execute, but is used by generated walkers
+// which visit some values without using the engine. If decided is
+// non-nil, it is used in place of calling fn for the initial value,
+// which must be a struct. It also reports whether the visitation was
+// halted.
+func (e *Engine) Resume(
+	fn FacadeFn, decided *Decision, t TypeID, x Ptr, assignableTo TypeID,
+) (retType TypeID, ret Ptr, changed, halted bool, err error) {
+	return e.execute(Options{}, nil, nil, nil, fn, decided, t, x, assignableTo)
+}
+
+// ResumeScope is equivalent to Resume, but functions passed to
+// Context.Defer are recorded in the Scope, instead of being called
+// when the visitation completes.
+func (e *Engine) ResumeScope(
+	scope *Scope, fn FacadeFn, decided *Decision, t TypeID, x Ptr, assignableTo TypeID,
+) (retType TypeID, ret Ptr, changed, halted bool, err error) {
+	return e.execute(Options{}, nil, nil, scope, fn, decided, t, x, assignableTo)
+}
+
+// execute implements Execute, ExecuteArena, ExecuteOptions,
+// ExecuteWith, Resume, and ResumeScope. If stack is nil, a pooled
+// stack will be used. If scope is nil, deferred functions are called
+// before execute returns.
+func (e *Engine) execute(
+	opts Options,
+	arena *Arena,
+	stack *stack,
+	scope *Scope,
+	fn FacadeFn,
+	decided *Decision,
+	t TypeID,
+	x Ptr,
+	assignableTo TypeID,
+) (retType TypeID, ret Ptr, changed, halted bool, err error) {
+	if x == nil {
+		return 0, nil, false, false, ErrNilRoot
+	}
 	ctx := Context{}
-	stack := newStack()
+	// Activity is tallied locally and only reported if metrics are
+	// enabled.
+	var visits, replacements int64
+	if m := metrics.Load(); m != nil {
+		defer func() { m.record(visits, replacements, err) }()
+	}
+	// The original is captured only if aliases are being checked.
+	if aliasCheck.Load() {
+		if before, ok := e.snapshot(t, x); ok {
+			defer func() {
+				if err == nil {
+					err = e.checkAliasing(before, t, x)
+				}
+			}()
+		}
+	}
+	// Spans are opened only if tracing is enabled.
+	var rootSpan openSpan
+	var spans []openSpan
+	tr := opts.Tracing
+	if tr != nil {
+		rootSpan = tr.start(opts.Context, e, t)
+		defer func() { finish(spans, rootSpan, visits, err) }()
+	}
+	if stack == nil {
+		stack = acquireStack()
+		defer stack.release()
+	} else {
+		stack.prepare()
+		defer stack.Reset()
+	}
+
+	ctx.engine = e
+	ctx.stack = stack
+	ctx.scope = scope
+
+	// Deferred functions are called before the stack is released, even
+	// if the walk fails or panics.
+	if scope == nil {
+		defer func() {
+			if len(stack.defers) == 0 {
+				return
+			}
+			if deferErr := runDeferred(stack.defers); deferErr != nil && err == nil {
+				retType, ret, changed, halted, err = 0, nil, false, false, deferErr
+			}
+		}()
+	}
+
+	var filter *typeFilter
+	if len(opts.Only) > 0 {
+		filter = e.filter(opts.Only)
+	}
+
+	// The stack is inspected to describe a panic, so this must run
+	// before the stack is released.
+	if opts.Recover {
+		defer func() {
+			if r := recover(); r != nil {
+				retType, ret, changed, halted = 0, nil, false, false
+				err = e.recovered(stack, r)
+			}
+		}()
+	}
+
+	// Overlapping walks are tracked only if requested.
+	var race *raceWalk
+	if opts.CheckRaces {
+		race = e.raceEnter(t, x)
+		defer race.exit()
+	}
 
 	// Bootstrap the stack.
 	curFrame := stack.Enter(nil, 1)
@@ -147,24 +388,29 @@ func (e *Engine) Execute(
 enter:
 	if curSlot.call != nil {
 		if err := curSlot.call(); err != nil {
-			return 0, nil, false, err
+			return 0, nil, false, false, &PathError{Path: stack.Path(), Err: err}
 		}
 		goto unwind
 	}
 
-	// Linear search for cycle-breaking. Note that this does not guarantee
-	// exactly-once behavior if there are multiple pointers to an object
-	// within a visitable graph. pprof says this is much faster than using
-	// a map structure, especially since we expect the stack to be fairly
-	// shallow. We use both the type and pointer as a unique key in order
-	// to distinguish a struct from the first field of the struct. go
+	if debugEnabled {
+		e.debugCheckSlot(stack, curSlot)
+	}
+
+	// Cycle-breaking. Note that this does not guarantee exactly-once
+	// behavior if there are multiple pointers to an object within a
+	// visitable graph. We use both the type and pointer as a unique key in
+	// order to distinguish a struct from the first field of the struct. go
 	// disallows recursive type definitions, so it's impossible for the
 	// first field of a struct to be exactly the struct type.
-	for l := 0; l < stack.Depth()-1; l++ {
-		onStack := stack.Peek(l).Active()
-		if onStack.value == curSlot.value && onStack.typeData.TypeID == curSlot.typeData.TypeID {
-			goto nextSlot
-		}
+	if stack.Contains(curSlot) {
+		goto nextSlot
+	}
+
+	// A walk restricted to certain types doesn't enter values which
+	// cannot contain one of them.
+	if filter != nil && !filter.reaches[curSlot.typeData.TypeID] {
+		goto nextSlot
 	}
 
 	// In this switch statement, we're going to set up the next frame. If
@@ -182,38 +428,93 @@ enter:
 		entering.SetSlot(e, 0, ctx.ActionVisitReplace(curSlot.typeData.elemData, ptr, curSlot.typeData.elemData))
 
 	case KindStruct:
-		// Allow parent frames to intercept child values.
-		if curFrame.Intercept != nil {
-			d := curSlot.typeData.Facade(ctx, curFrame.Intercept, curSlot.value)
-			if err := curSlot.apply(e, d); err != nil {
-				return 0, nil, false, err
+		if raceEnabled && race != nil && curSlot.value != nil {
+			raceRead(curSlot.typeData, curSlot.value)
+		}
+		// Values which are only being passed through by a restricted
+		// walk are neither intercepted nor visited.
+		if filter != nil && !filter.wanted[curSlot.typeData.TypeID] {
+			fieldCount := len(curSlot.typeData.Fields)
+			if halting || fieldCount == 0 || curSlot.value == nil {
+				goto unwind
 			}
-			if d.halt {
-				halting = true
+			entering = stack.Enter(nil, fieldCount)
+			for i, f := range curSlot.typeData.Fields {
+				fPtr := Ptr(uintptr(curSlot.value) + f.Offset)
+				entering.SetSlot(e, i, ctx.ActionVisitReplace(f.targetData, fPtr, f.targetData))
 			}
-			// Allow interceptors to replace themselves.
-			if d.intercept != nil {
-				curFrame.Intercept = d.intercept
+			break
+		}
+		// Allow parent frames to intercept child values.
+		if curFrame.Intercept != nil {
+			if d := curSlot.typeData.Facade(ctx, curFrame.Intercept, curSlot.value); !d.isZero() {
+				replaced, err := curSlot.apply(e, stack, d)
+				if err != nil {
+					return 0, nil, false, false, err
+				}
+				if replaced {
+					replacements++
+				}
+				if d.halt {
+					halting = true
+				}
+				// Allow interceptors to replace themselves.
+				if d.intercept != nil {
+					curFrame.Intercept = d.intercept
+				}
 			}
 		}
 
 		// Structs are where we call out to user logic via a generated,
 		// type-safe facade. The user code can trigger various flow-control
 		// to happen.
-		d := curSlot.typeData.Facade(ctx, fn, curSlot.value)
+		var d Decision
+		if decided != nil {
+			d = *decided
+			decided = nil
+		} else {
+			d = curSlot.typeData.Facade(ctx, fn, curSlot.value)
+		}
+		visits++
+		if opts.Counts != nil {
+			opts.Counts[curSlot.typeData.TypeID]++
+		}
+		if tr != nil {
+			spans = tr.enter(e, spans, rootSpan, curSlot.typeData.TypeID,
+				stack.Depth()-1, curFrame.Idx, visits-1)
+		}
+		// Slices and structs have very similar approaches, we create a new
+		// frame, add slots for each field or slice element, and then jump
+		// back to the top.
+		if d.isZero() {
+			// The overwhelmingly common case is that the user simply
+			// wants to continue, so there's nothing to apply.
+			fieldCount := curSlot.fieldCount()
+			if halting || fieldCount == 0 {
+				goto unwind
+			}
+			entering = stack.Enter(nil, fieldCount)
+			for i, f := range curSlot.typeData.Fields {
+				fPtr := Ptr(uintptr(curSlot.value) + f.Offset)
+				entering.SetSlot(e, i, ctx.ActionVisitReplace(f.targetData, fPtr, f.targetData))
+			}
+			break
+		}
 		// Incorporate replacements, bail on error, etc.
-		if err := curSlot.apply(e, d); err != nil {
-			return 0, nil, false, err
+		replaced, err := curSlot.apply(e, stack, d)
+		if err != nil {
+			return 0, nil, false, false, err
+		}
+		if replaced {
+			replacements++
 		}
+		// A replacement may have a different number of fields.
+		fieldCount := curSlot.fieldCount()
 		// If the user wants to stop, we'll set the flag and just let the
 		// unwind loop run to completion.
 		if d.halt {
 			halting = true
 		}
-		// Slices and structs have very similar approaches, we create a new
-		// frame, add slots for each field or slice element, and then jump
-		// back to the top.
-		fieldCount := len(curSlot.typeData.Fields)
 		switch {
 		case halting, d.skip:
 			goto unwind
@@ -223,6 +524,7 @@ enter:
 				goto unwind
 			}
 			entering = stack.Enter(d.intercept, len(d.actions))
+			entering.actions = true
 			for i, a := range d.actions {
 				entering.SetSlot(e, i, a)
 			}
@@ -241,26 +543,44 @@ enter:
 	case KindSlice:
 		// Slices have the same general flow as a struct; they're just
 		// a sequence of visitable values.
-		header := (*reflect.SliceHeader)(curSlot.value)
+		header := (*sliceHeader)(curSlot.value)
 		if header.Len == 0 {
 			goto unwind
 		}
+		if header.Len > sliceChunkSize {
+			entering = stack.Enter(curFrame.Intercept, sliceChunkSize)
+			entering.sliceData = curSlot.typeData
+			entering.slice = *header
+			entering.fillChunk(e)
+			break
+		}
 		entering = stack.Enter(curFrame.Intercept, header.Len)
 		eltTd := curSlot.typeData.elemData
 		for i, off := 0, uintptr(0); i < header.Len; i, off = i+1, off+eltTd.SizeOf {
-			entering.SetSlot(e, i, ctx.ActionVisitReplace(eltTd, Ptr(header.Data+off), eltTd))
+			entering.SetSlot(e, i, ctx.ActionVisitReplace(eltTd, Ptr(uintptr(header.Data)+off), eltTd))
 		}
 
 	case KindInterface:
 		// An interface is a type-tag and a pointer.
 		ptr := (*[2]Ptr)(curSlot.value)[1]
-		// We do need to map the type-tag to our TypeID.
-		// Perhaps this could be accomplished with a map?
-		elem := curSlot.typeData.IntfType(curSlot.value)
-		// Need to check elem==0 in the case of a "typed nil" value.
-		if elem == 0 || ptr == nil {
+		// We do need to map the type-tag to our TypeID, which uses a
+		// map for large interfaces.
+		elem := curSlot.typeData.intfType(curSlot.value)
+		if elem == 0 {
 			goto unwind
 		}
+		// A "typed nil" value holds a nil pointer to a struct. It is
+		// skipped unless the options say otherwise.
+		if ptr == nil {
+			if opts.NormalizeTypedNils && curSlot.assignableTo != nil {
+				curSlot.value = Ptr(new([2]Ptr))
+				curSlot.dirty = true
+				replacements++
+			}
+			if !opts.VisitTypedNils {
+				goto unwind
+			}
+		}
 		entering = stack.Enter(curFrame.Intercept, 1)
 		entering.SetSlot(e, 0, ctx.ActionVisitReplace(e.typeData(elem), ptr, curSlot.typeData))
 
@@ -268,6 +588,9 @@ enter:
 		panic(fmt.Errorf("unexpected kind: %d", curSlot.typeData.Kind))
 	}
 
+	if opts.MaxDepth > 0 && stack.Depth() > opts.MaxDepth {
+		return 0, nil, false, false, &PathError{Path: stack.Path(), Err: ErrMaxDepth}
+	}
 	curFrame = entering
 	curSlot = curFrame.Zero()
 
@@ -279,8 +602,15 @@ unwind:
 	// the same as above, although we don't respect all decision options.
 	if curSlot.post != nil {
 		d := curSlot.typeData.Facade(ctx, curSlot.post, curSlot.value)
-		if err := curSlot.apply(e, d); err != nil {
-			return 0, nil, false, err
+		replaced, err := curSlot.apply(e, stack, d)
+		if err != nil {
+			return 0, nil, false, false, err
+		}
+		if replaced {
+			replacements++
+			// The post-visit replacement supersedes any changes to the
+			// children of the previous value.
+			curSlot.childDirty = false
 		}
 		if d.halt {
 			halting = true
@@ -290,19 +620,33 @@ unwind:
 	// If the slot reports that it's dirty, we want to propagate
 	// the changes upwards in the stack.
 	if curSlot.dirty {
+		if race != nil {
+			race.rewrite(stack)
+		}
+		// A value visited by an action has no location in its parent,
+		// so changes to its children cannot be applied.
+		if curSlot.assignableTo == nil {
+			return 0, nil, false, false, e.replacementError(stack, nil, curSlot.typeData.TypeID)
+		}
 		if stack.Depth() > 1 {
-			stack.Top(1).Active().dirty = true
+			parent := stack.Top(1).Active()
+			parent.dirty = true
+			parent.childDirty = true
 		}
 
-		// If we were given a replacement value, there's no need to
-		// copy out any data.
-		if !curSlot.replaced {
+		// If only the value itself was replaced, there's no need to
+		// copy out any data. The children of a replacement which was
+		// given before they were visited must still be copied.
+		if curSlot.childDirty {
+			if debugEnabled {
+				e.debugCheckFold(stack, curSlot, returning)
+			}
 			// This switch statement is the inverse of the above. We'll fold the
 			// returning frame into a replacement value for the current slot.
 			switch curSlot.typeData.Kind {
 			case KindStruct:
 				// Allocate a replacement instance of the struct.
-				next := curSlot.typeData.NewStruct()
+				next := arena.newStruct(curSlot.typeData)
 				// Perform a shallow copy to catch non-visitable fields.
 				curSlot.typeData.Copy(next, curSlot.value)
 
@@ -315,18 +659,22 @@ unwind:
 
 			case KindPointer:
 				// Copy out the pointer to a local var so we don't stomp on it.
-				next := returning.Zero().value
-				curSlot.value = Ptr(&next)
+				curSlot.value = arena.newPtr(returning.Zero().value)
 
 			case KindSlice:
+				// Large slices have already been copied.
+				if returning.sliceClone != nil {
+					curSlot.value = returning.sliceClone
+					break
+				}
 				// Create a new slice instance and populate the elements.
-				next := curSlot.typeData.NewSlice(returning.Count)
-				toHeader := (*reflect.SliceHeader)(next)
+				next := arena.newSlice(curSlot.typeData, returning.Count)
+				toHeader := (*sliceHeader)(next)
 				elemTd := curSlot.typeData.elemData
 
 				// Copy the elements across.
 				for i := 0; i < returning.Count; i++ {
-					toElem := Ptr(toHeader.Data + uintptr(i)*elemTd.SizeOf)
+					toElem := Ptr(uintptr(toHeader.Data) + uintptr(i)*elemTd.SizeOf)
 					elemTd.Copy(toElem, returning.Slot(i).value)
 				}
 				curSlot.value = next
@@ -342,6 +690,10 @@ unwind:
 		}
 	}
 
+	if tr != nil {
+		spans = exit(spans, stack.Depth()-1, curFrame.Idx, visits)
+	}
+
 nextSlot:
 	// We'll advance the current slot or unwind one level if we've
 	// processed the last slot in the frame.
@@ -349,12 +701,24 @@ nextSlot:
 	// If the user wants to stop early, we'll just keep running the
 	// unwind loop until we hit the top frame.
 	if curFrame.Idx == curFrame.Count || halting {
+		// Visit the next chunk of a large slice.
+		if curFrame.sliceData != nil {
+			next := curFrame.sliceOffset + curFrame.Count
+			last := halting || next == curFrame.slice.Len
+			curFrame.flushChunk(arena, stack.Top(1).Active().dirty, last)
+			if !last {
+				curFrame.sliceOffset = next
+				curFrame.fillChunk(e)
+				curSlot = curFrame.Active()
+				goto enter
+			}
+		}
 		// If we've finished the bootstrap frame, we're done.
 		if stack.Depth() == 1 {
 			// pprof says that this is measurably faster than repeatedly
 			// dereferencing the pointer.
 			z := *curFrame.Zero()
-			return z.typeData.TypeID, z.value, z.dirty, nil
+			return z.typeData.TypeID, z.value, z.dirty, halting, nil
 		}
 		// Save off the current frame so we can copy the data out.
 		returning = stack.Pop()
@@ -374,6 +738,18 @@ nextSlot:
 // Stringify returns a string representation of the given type that
 // is suitable for debugging purposes.
 func (e *Engine) Stringify(id TypeID) string {
+	return e.stringify(id, false)
+}
+
+// StringifyQualified is like Stringify, but includes the import path
+// of the package which declares each named type, e.g.
+// "[]*example.com/pkg.Foo". This distinguishes the types of several
+// engines in the same program.
+func (e *Engine) StringifyQualified(id TypeID) string {
+	return e.stringify(id, true)
+}
+
+func (e *Engine) stringify(id TypeID, qualified bool) string {
 	if id == 0 {
 		return "<NIL>"
 	}
@@ -382,7 +758,10 @@ func (e *Engine) Stringify(id TypeID) string {
 	for {
 		switch td.Kind {
 		case KindInterface, KindStruct:
-			if ret.Len() == 0 {
+			if qualified && td.PkgPath != "" {
+				ret.WriteString(td.PkgPath)
+				ret.WriteString(".")
+			} else if ret.Len() == 0 {
 				return td.Name
 			}
 			ret.WriteString(td.Name)
@@ -399,6 +778,13 @@ func (e *Engine) Stringify(id TypeID) string {
 	}
 }
 
+// TypeMap returns the engine's linked copy of its TypeMap, which is
+// indexed by TypeID. It is intended for tools, such as package bench,
+// which operate on arbitrary types and must not be modified.
+func (e *Engine) TypeMap() TypeMap {
+	return e.typeMap
+}
+
 // typeData returns a pointer to the TypeData for the given type.
 func (e *Engine) typeData(id TypeID) *TypeData {
 	return &e.typeMap[id]
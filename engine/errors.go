@@ -0,0 +1,59 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCycle is wrapped by the errors which are returned when a value
+	// which contains a cycle is encoded or converted to a map.
+	ErrCycle = errors.New("cycle")
+	// ErrMaxDepth is wrapped by the *PathError which is returned when a
+	// walk exceeds Options.MaxDepth.
+	ErrMaxDepth = errors.New("maximum depth exceeded")
+	// ErrNilRoot is returned when a walk is given a nil value.
+	ErrNilRoot = errors.New("cannot walk a nil value")
+)
+
+// PathError records the location of the value which caused an error.
+// Errors returned by a user's walker, post-visit, or action function
+// are wrapped in a PathError, so that they can be found with errors.Is
+// or errors.As.
+type PathError struct {
+	// Path is the location of the value. Errors from a walk use the
+	// same syntax as Context.Path.
+	Path string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements error.
+func (e *PathError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// pathErrorf returns a PathError with a formatted message.
+func pathErrorf(path, format string, args ...interface{}) *PathError {
+	return &PathError{Path: path, Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains support for walks which are restricted to a set
+// of struct types, using Options.Only.
+
+import (
+	"fmt"
+	"sort"
+)
+
+// typeFilter records which types are of interest to a walk restricted
+// by Options.Only, and which types may contain one of them.
+type typeFilter struct {
+	// reaches is indexed by TypeID and is true if a value of the type
+	// is, or may contain, a struct of one of the wanted types.
+	reaches []bool
+	// wanted is indexed by TypeID and is true for the types which
+	// were requested.
+	wanted []bool
+}
+
+// filter returns the typeFilter for the given set of types. Filters
+// are cached by the engine, since they depend only on the TypeMap.
+func (e *Engine) filter(ids []TypeID) *typeFilter {
+	sorted := append(ids[:0:0], ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	key := fmt.Sprint(sorted)
+	if found, ok := e.filters.Load(key); ok {
+		return found.(*typeFilter)
+	}
+
+	f := &typeFilter{
+		reaches: make([]bool, len(e.typeMap)),
+		wanted:  make([]bool, len(e.typeMap)),
+	}
+	for _, id := range sorted {
+		if id > 0 && int(id) < len(e.typeMap) && e.typeMap[id].TypeID != 0 {
+			f.reaches[id] = true
+			f.wanted[id] = true
+		}
+	}
+
+	// A type reaches a wanted type if any of the types which it may
+	// contain do. The type graph may be cyclic, so we iterate until
+	// nothing changes.
+	for changed := true; changed; {
+		changed = false
+		for i := range e.typeMap {
+			td := &e.typeMap[i]
+			if td.TypeID == 0 || f.reaches[td.TypeID] {
+				continue
+			}
+			if f.successorReaches(td) {
+				f.reaches[td.TypeID] = true
+				changed = true
+			}
+		}
+	}
+
+	found, _ := e.filters.LoadOrStore(key, f)
+	return found.(*typeFilter)
+}
+
+// successorReaches returns true if any type which may be found within
+// a value of the given type reaches a wanted type. An interface whose
+// implementations are unknown is assumed to reach one.
+func (f *typeFilter) successorReaches(td *TypeData) bool {
+	switch td.Kind {
+	case KindPointer, KindSlice:
+		return f.reaches[td.Elem]
+	case KindStruct:
+		for _, field := range td.Fields {
+			if f.reaches[field.Target] {
+				return true
+			}
+		}
+		return false
+	case KindInterface:
+		if td.Impls == nil {
+			return true
+		}
+		for _, impl := range td.Impls {
+			if f.reaches[impl] {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
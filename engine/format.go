@@ -0,0 +1,234 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains a human-readable formatter for visitable values,
+// which resembles a Go composite literal:
+//	struct     Name{ Field: value, ... }, listing the fields in the
+//	           order in which they are declared
+//	pointer    nil, or & followed by the element
+//	slice      nil, or []Elem{ value, ... }
+//	interface  nil, or the struct that it contains
+//	scalar     a Go literal, with strings and bytes quoted
+//
+// Unlike Dump, the output is not intended to be parsed, and cycles are
+// printed as <cycle> instead of returning an error.
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FormatOptions controls the output of Format. The zero value prints
+// every field of every value, indented by two spaces.
+type FormatOptions struct {
+	// Indent is written once for each level of nesting. It defaults to
+	// two spaces.
+	Indent string
+	// MaxDepth limits the number of nested structs and slices which are
+	// printed. The contents of deeper values are printed as "...". Zero
+	// means no limit.
+	MaxDepth int
+	// OmitZero omits fields which are nil, which are empty slices, or
+	// which contain the zero value of a basic type.
+	OmitZero bool
+}
+
+// Format writes a human-readable form of the value of the given type
+// at x to w, using the names and kinds recorded in the TypeMap. It
+// allows tools to print values of any type that the engine knows
+// about, without importing the generated code for those types. An
+// error is returned only if w returns one.
+func (e *Engine) Format(w io.Writer, id TypeID, x Ptr, opts FormatOptions) error {
+	if opts.Indent == "" {
+		opts.Indent = "  "
+	}
+	f := formatter{
+		active: make(map[cycleKey]struct{}),
+		engine: e,
+		opts:   opts,
+		w:      w,
+	}
+	f.value(e.typeData(id), x, 0)
+	f.write("\n")
+	return f.err
+}
+
+// formatter holds the state of a call to Format.
+type formatter struct {
+	// The structs which are being printed, to detect cycles.
+	active map[cycleKey]struct{}
+	engine *Engine
+	// The first error returned by w.
+	err  error
+	opts FormatOptions
+	w    io.Writer
+}
+
+// formatEntry is a field of a struct, which is either visitable or
+// a scalar.
+type formatEntry struct {
+	name   string
+	offset uintptr
+	// Exactly one of these is set.
+	field  *FieldInfo
+	scalar *ScalarInfo
+}
+
+// value writes the value at x, which is nested within depth structs
+// and slices.
+func (f *formatter) value(td *TypeData, x Ptr, depth int) {
+	switch td.Kind {
+	case KindStruct:
+		key := cycleKey{td.TypeID, x}
+		if _, found := f.active[key]; found {
+			f.write("<cycle>")
+			return
+		}
+
+		entries := make([]formatEntry, 0, len(td.Fields)+len(td.Scalars))
+		for i := range td.Fields {
+			fi := &td.Fields[i]
+			if !f.opts.OmitZero || !formatZero(fi.targetData, Ptr(uintptr(x)+fi.Offset)) {
+				entries = append(entries, formatEntry{name: fi.Name, offset: fi.Offset, field: fi})
+			}
+		}
+		for i := range td.Scalars {
+			si := &td.Scalars[i]
+			if !f.opts.OmitZero || !reflect.ValueOf(scalarValue(si.Kind, Ptr(uintptr(x)+si.Offset))).IsZero() {
+				entries = append(entries, formatEntry{name: si.Name, offset: si.Offset, scalar: si})
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].offset < entries[j].offset })
+
+		f.write(td.Name)
+		if len(entries) == 0 {
+			f.write("{}")
+			return
+		}
+		if f.opts.MaxDepth > 0 && depth >= f.opts.MaxDepth {
+			f.write("{...}")
+			return
+		}
+
+		f.active[key] = struct{}{}
+		defer delete(f.active, key)
+
+		f.write("{\n")
+		for _, entry := range entries {
+			f.indent(depth + 1)
+			f.write(entry.name)
+			f.write(": ")
+			ptr := Ptr(uintptr(x) + entry.offset)
+			if entry.field != nil {
+				f.value(entry.field.targetData, ptr, depth+1)
+			} else {
+				f.scalar(entry.scalar.Kind, ptr)
+			}
+			f.write(",\n")
+		}
+		f.indent(depth)
+		f.write("}")
+
+	case KindPointer:
+		ptr := *(*Ptr)(x)
+		if ptr == nil {
+			f.write("nil")
+			return
+		}
+		f.write("&")
+		f.value(td.elemData, ptr, depth)
+
+	case KindSlice:
+		header := (*sliceHeader)(x)
+		if header.Data == nil {
+			f.write("nil")
+			return
+		}
+		f.write(f.engine.Stringify(td.TypeID))
+		if header.Len == 0 {
+			f.write("{}")
+			return
+		}
+		if f.opts.MaxDepth > 0 && depth >= f.opts.MaxDepth {
+			f.write("{...}")
+			return
+		}
+		f.write("{\n")
+		eltTd := td.elemData
+		for i, off := 0, uintptr(0); i < header.Len; i, off = i+1, off+eltTd.SizeOf {
+			f.indent(depth + 1)
+			f.value(eltTd, Ptr(uintptr(header.Data)+off), depth+1)
+			f.write(",\n")
+		}
+		f.indent(depth)
+		f.write("}")
+
+	case KindInterface:
+		ptr := (*[2]Ptr)(x)[1]
+		elem := td.intfType(x)
+		if elem == 0 || ptr == nil {
+			f.write("nil")
+			return
+		}
+		f.value(f.engine.typeData(elem), ptr, depth)
+
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+}
+
+// scalar writes the basic value at x as a Go literal.
+func (f *formatter) scalar(kind ScalarKind, x Ptr) {
+	switch t := scalarValue(kind, x).(type) {
+	case string:
+		f.write(fmt.Sprintf("%q", t))
+	case []byte:
+		f.write(fmt.Sprintf("%q", t))
+	default:
+		f.write(fmt.Sprint(t))
+	}
+}
+
+// indent writes the indentation for the given depth.
+func (f *formatter) indent(depth int) {
+	f.write(strings.Repeat(f.opts.Indent, depth))
+}
+
+// write writes s, unless w has already returned an error.
+func (f *formatter) write(s string) {
+	if f.err == nil {
+		_, f.err = io.WriteString(f.w, s)
+	}
+}
+
+// formatZero returns true if the visitable value at x is nil or an
+// empty slice. Structs are never zero.
+func formatZero(td *TypeData, x Ptr) bool {
+	switch td.Kind {
+	case KindPointer:
+		return *(*Ptr)(x) == nil
+	case KindSlice:
+		return (*sliceHeader)(x).Len == 0
+	case KindInterface:
+		return (*[2]Ptr)(x)[1] == nil
+	default:
+		return false
+	}
+}
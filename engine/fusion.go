@@ -0,0 +1,152 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import "errors"
+
+// ErrFusedRewrite is returned when a walker which has been fused with
+// other walkers attempts to replace a value, or returns actions or an
+// interceptor. The walkers would otherwise observe each other's
+// changes.
+var ErrFusedRewrite = errors.New(
+	"walkers which are fused may not return replacements, actions, or interceptors")
+
+// Fusion holds the state of several walkers which share a single walk.
+// It is driven by a generated walker, which calls Enter and Decide for
+// each value before its fields are visited, and NextPost, Decide, and
+// Exit after. Each walker's decisions affect only that walker: a walker
+// which skips a value does not see its fields, and a walker which halts
+// sees no further values, but the other walkers are unaffected.
+type Fusion struct {
+	depth   int
+	halted  int
+	pending []fusedPost
+	walkers []fusedWalker
+}
+
+// fusedWalker is the state of a single walker.
+type fusedWalker struct {
+	halted bool
+	// skipDepth is the depth of the value which the walker skipped, or
+	// zero if the walker is not skipping.
+	skipDepth int
+}
+
+// fusedPost is a post-visit function which is waiting for a walk to
+// return to the value at depth.
+type fusedPost struct {
+	depth int
+	fn    FacadeFn
+	idx   int
+}
+
+// NewFusion returns a Fusion for the given number of walkers.
+func NewFusion(count int) *Fusion {
+	return &Fusion{walkers: make([]fusedWalker, count)}
+}
+
+// Active reports whether the walker at idx should be called for the
+// value which was just entered.
+func (f *Fusion) Active(idx int) bool {
+	w := &f.walkers[idx]
+	return !w.halted && w.skipDepth == 0
+}
+
+// Decide records the decision made by the walker at idx for the
+// current value, either before or after its fields were visited. An
+// error returned by the walker is returned, and should stop the walk.
+func (f *Fusion) Decide(idx int, d Decision) error {
+	if d.error != nil {
+		return d.error
+	}
+	if d.actions != nil || d.intercept != nil || d.replacement != nil {
+		return ErrFusedRewrite
+	}
+	w := &f.walkers[idx]
+	if d.post != nil {
+		f.pending = append(f.pending, fusedPost{depth: f.depth, fn: d.post, idx: idx})
+	}
+	if d.skip && w.skipDepth == 0 {
+		w.skipDepth = f.depth
+	}
+	if d.halt && !w.halted {
+		w.halted = true
+		f.halted++
+	}
+	return nil
+}
+
+// Enter records that a value is being visited.
+func (f *Fusion) Enter() {
+	f.depth++
+}
+
+// Exit records that the fields of the current value have been visited
+// and that its post-visit functions have been called. It returns a
+// decision for the shared walk, which halts once every walker has
+// halted.
+func (f *Fusion) Exit() Decision {
+	for i := range f.walkers {
+		if w := &f.walkers[i]; w.skipDepth == f.depth {
+			w.skipDepth = 0
+		}
+	}
+	f.depth--
+	return Decision{halt: f.halted == len(f.walkers)}
+}
+
+// Next returns a decision for the shared walk which halts once every
+// walker has halted, and which skips the fields of the current value if
+// no walker will visit them.
+func (f *Fusion) Next() Decision {
+	switch {
+	case f.halted == len(f.walkers):
+		return Decision{halt: true}
+	case f.allInactive():
+		return Decision{skip: true}
+	default:
+		return Decision{}
+	}
+}
+
+// NextPost removes and returns the next post-visit function which is
+// waiting for the current value, in the order in which the walkers
+// were given.
+func (f *Fusion) NextPost() (idx int, fn FacadeFn, ok bool) {
+	n := len(f.pending)
+	if n == 0 || f.pending[n-1].depth != f.depth {
+		return 0, nil, false
+	}
+	// Find the first function registered at this depth, so that the
+	// walkers are called in order.
+	first := n - 1
+	for first > 0 && f.pending[first-1].depth == f.depth {
+		first--
+	}
+	p := f.pending[first]
+	f.pending = append(f.pending[:first], f.pending[first+1:]...)
+	return p.idx, p.fn, true
+}
+
+// allInactive reports whether no walker is active.
+func (f *Fusion) allInactive() bool {
+	for i := range f.walkers {
+		if f.Active(i) {
+			return false
+		}
+	}
+	return true
+}
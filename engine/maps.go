@@ -0,0 +1,501 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file converts visitable values to and from a generic form,
+// which is built from maps, slices, and basic values:
+//	struct     a map[string]interface{} which contains the name of the
+//	           struct under TypeKey, the visitable fields, and the
+//	           fields described by TypeData.Scalars
+//	pointer    nil, or the form of the element; a pointer to a nil
+//	           interface or slice is therefore converted to nil
+//	slice      nil, or an []interface{} of the elements
+//	interface  nil, or the form of the struct that it contains
+//
+// This is the form produced by encoding/json when decoding into an
+// interface{}, which allows fixtures to be written as JSON or YAML.
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// TypeKey is the key, in the generic form of a struct, which holds the
+// name of the struct's type. It is required for a struct which is
+// stored in an interface.
+const TypeKey = "$type"
+
+// ToMap converts the value of the given type at x to the generic form
+// described above. An error will be returned if the value contains a
+// cycle.
+func (e *Engine) ToMap(id TypeID, x Ptr) (interface{}, error) {
+	m := mapper{engine: e, active: make(map[cycleKey]struct{})}
+	return m.toMap(e.typeData(id), x)
+}
+
+// FromMap replaces the value of the given type at x with one that is
+// constructed from the generic form described above. Numbers may be
+// of any numeric type, provided that they can be converted exactly,
+// and byte slices may be given as base64-encoded strings, as produced
+// by encoding/json.
+func (e *Engine) FromMap(data interface{}, id TypeID, x Ptr) error {
+	m := mapper{engine: e}
+	td := e.typeData(id)
+	return m.fromMap(td, data, x, e.Stringify(id))
+}
+
+// mapper holds the state of a call to ToMap or FromMap.
+type mapper struct {
+	engine *Engine
+	// The structs which are being converted, to detect cycles.
+	active map[cycleKey]struct{}
+	// Structs by name, populated lazily.
+	byName map[string]*TypeData
+}
+
+// toMap returns the generic form of x.
+func (m *mapper) toMap(td *TypeData, x Ptr) (interface{}, error) {
+	switch td.Kind {
+	case KindStruct:
+		key := cycleKey{td.TypeID, x}
+		if _, found := m.active[key]; found {
+			return nil, fmt.Errorf("cannot convert a %w through %s", ErrCycle, td.Name)
+		}
+		m.active[key] = struct{}{}
+		defer delete(m.active, key)
+
+		ret := make(map[string]interface{}, len(td.Fields)+len(td.Scalars)+1)
+		ret[TypeKey] = td.Name
+		for _, f := range td.Fields {
+			v, err := m.toMap(f.targetData, Ptr(uintptr(x)+f.Offset))
+			if err != nil {
+				return nil, err
+			}
+			ret[f.Name] = v
+		}
+		for _, s := range td.Scalars {
+			ret[s.Name] = scalarValue(s.Kind, Ptr(uintptr(x)+s.Offset))
+		}
+		return ret, nil
+
+	case KindPointer:
+		ptr := *(*Ptr)(x)
+		if ptr == nil {
+			return nil, nil
+		}
+		return m.toMap(td.elemData, ptr)
+
+	case KindSlice:
+		header := (*sliceHeader)(x)
+		if header.Data == nil {
+			return nil, nil
+		}
+		ret := make([]interface{}, header.Len)
+		eltTd := td.elemData
+		for i, off := 0, uintptr(0); i < header.Len; i, off = i+1, off+eltTd.SizeOf {
+			v, err := m.toMap(eltTd, Ptr(uintptr(header.Data)+off))
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = v
+		}
+		return ret, nil
+
+	case KindInterface:
+		ptr := (*[2]Ptr)(x)[1]
+		elem := td.intfType(x)
+		if elem == 0 || ptr == nil {
+			return nil, nil
+		}
+		return m.toMap(m.engine.typeData(elem), ptr)
+
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+}
+
+// fromMap replaces x with the value described by data. The path
+// describes the location of the value, for use in error messages.
+func (m *mapper) fromMap(td *TypeData, data interface{}, x Ptr, path string) error {
+	switch td.Kind {
+	case KindStruct:
+		fields, ok := data.(map[string]interface{})
+		if !ok {
+			return pathErrorf(path, "expecting a map for %s, got %T", td.Name, data)
+		}
+		if name, ok := fields[TypeKey]; ok {
+			if s, _ := name.(string); strings.TrimPrefix(s, "*") != td.Name {
+				return pathErrorf(path, "cannot use a %v as a %s", name, td.Name)
+			}
+		}
+		next := td.NewStruct()
+		if err := m.fields(td, fields, next, path); err != nil {
+			return err
+		}
+		td.Copy(x, next)
+
+	case KindPointer:
+		if data == nil {
+			*(*Ptr)(x) = nil
+			return nil
+		}
+		elem := newValue(td.elemData)
+		if err := m.fromMap(td.elemData, data, elem, path); err != nil {
+			return err
+		}
+		*(*Ptr)(x) = elem
+
+	case KindSlice:
+		if data == nil {
+			*(*sliceHeader)(x) = sliceHeader{}
+			return nil
+		}
+		elts, ok := data.([]interface{})
+		if !ok {
+			return pathErrorf(path, "expecting a slice for %s, got %T",
+				m.engine.Stringify(td.TypeID), data)
+		}
+		eltTd := td.elemData
+		next := td.NewSlice(len(elts))
+		header := (*sliceHeader)(next)
+		for i, off := 0, uintptr(0); i < len(elts); i, off = i+1, off+eltTd.SizeOf {
+			eltPath := fmt.Sprintf("%s[%d]", path, i)
+			if err := m.fromMap(eltTd, elts[i], Ptr(uintptr(header.Data)+off), eltPath); err != nil {
+				return err
+			}
+		}
+		td.Copy(x, next)
+
+	case KindInterface:
+		if data == nil {
+			*(*[2]Ptr)(x) = [2]Ptr{}
+			return nil
+		}
+		fields, ok := data.(map[string]interface{})
+		if !ok {
+			return pathErrorf(path, "expecting a map for %s, got %T", td.Name, data)
+		}
+		name, ok := fields[TypeKey].(string)
+		if !ok {
+			return pathErrorf(path, "missing %s for %s", TypeKey, td.Name)
+		}
+		elemTd := m.lookup(name)
+		if elemTd == nil {
+			return pathErrorf(path, "unknown type %s", name)
+		}
+		elem := elemTd.NewStruct()
+		if err := m.fields(elemTd, fields, elem, path); err != nil {
+			return err
+		}
+		wrapped := td.IntfWrap(elemTd.TypeID, elem)
+		if wrapped == nil {
+			return pathErrorf(path, "type %s is not assignable to %s", name, td.Name)
+		}
+		td.Copy(x, wrapped)
+
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+	return nil
+}
+
+// fields populates the struct at x, which has been zeroed.
+func (m *mapper) fields(td *TypeData, fields map[string]interface{}, x Ptr, path string) error {
+	// Sort the keys so that errors are reported consistently.
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		if key != TypeKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+outer:
+	for _, key := range keys {
+		fPath := path + "." + key
+		for _, f := range td.Fields {
+			if f.Name == key {
+				if err := m.fromMap(f.targetData, fields[key], Ptr(uintptr(x)+f.Offset), fPath); err != nil {
+					return err
+				}
+				continue outer
+			}
+		}
+		for _, s := range td.Scalars {
+			if s.Name == key {
+				if err := setScalar(s.Kind, fields[key], Ptr(uintptr(x)+s.Offset)); err != nil {
+					return &PathError{Path: fPath, Err: err}
+				}
+				continue outer
+			}
+		}
+		return pathErrorf(fPath, "%s has no field %s", td.Name, key)
+	}
+	return nil
+}
+
+// lookup finds a struct type by name.
+func (m *mapper) lookup(name string) *TypeData {
+	if m.byName == nil {
+		m.byName = make(map[string]*TypeData)
+		for idx := range m.engine.typeMap {
+			if td := &m.engine.typeMap[idx]; td.Kind == KindStruct {
+				m.byName[td.Name] = td
+			}
+		}
+	}
+	// Allow the type of a pointer to be named.
+	return m.byName[strings.TrimPrefix(name, "*")]
+}
+
+// scalarValue returns the basic value at x.
+func scalarValue(kind ScalarKind, x Ptr) interface{} {
+	switch kind {
+	case ScalarBool:
+		return *(*bool)(x)
+	case ScalarInt:
+		return *(*int)(x)
+	case ScalarInt8:
+		return *(*int8)(x)
+	case ScalarInt16:
+		return *(*int16)(x)
+	case ScalarInt32:
+		return *(*int32)(x)
+	case ScalarInt64:
+		return *(*int64)(x)
+	case ScalarUint:
+		return *(*uint)(x)
+	case ScalarUint8:
+		return *(*uint8)(x)
+	case ScalarUint16:
+		return *(*uint16)(x)
+	case ScalarUint32:
+		return *(*uint32)(x)
+	case ScalarUint64:
+		return *(*uint64)(x)
+	case ScalarUintptr:
+		return *(*uintptr)(x)
+	case ScalarFloat32:
+		return *(*float32)(x)
+	case ScalarFloat64:
+		return *(*float64)(x)
+	case ScalarString:
+		return *(*string)(x)
+	case ScalarBytes:
+		return *(*[]byte)(x)
+	default:
+		panic(fmt.Errorf("unimplemented: %d", kind))
+	}
+}
+
+// setScalar stores a basic value into x, converting it if necessary.
+func setScalar(kind ScalarKind, v interface{}, x Ptr) error {
+	switch kind {
+	case ScalarBool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("expecting a bool, got %T", v)
+		}
+		*(*bool)(x) = b
+
+	case ScalarInt, ScalarInt8, ScalarInt16, ScalarInt32, ScalarInt64:
+		bits := kind.bits()
+		i, ok := toInt64(v)
+		if !ok || (bits < 64 && (i < -1<<(bits-1) || i >= 1<<(bits-1))) {
+			return fmt.Errorf("cannot convert %v (%T) to %s", v, v, kind)
+		}
+		switch kind {
+		case ScalarInt:
+			*(*int)(x) = int(i)
+		case ScalarInt8:
+			*(*int8)(x) = int8(i)
+		case ScalarInt16:
+			*(*int16)(x) = int16(i)
+		case ScalarInt32:
+			*(*int32)(x) = int32(i)
+		case ScalarInt64:
+			*(*int64)(x) = i
+		}
+
+	case ScalarUint, ScalarUint8, ScalarUint16, ScalarUint32, ScalarUint64, ScalarUintptr:
+		bits := kind.bits()
+		u, ok := toUint64(v)
+		if !ok || (bits < 64 && u >= 1<<bits) {
+			return fmt.Errorf("cannot convert %v (%T) to %s", v, v, kind)
+		}
+		switch kind {
+		case ScalarUint:
+			*(*uint)(x) = uint(u)
+		case ScalarUint8:
+			*(*uint8)(x) = uint8(u)
+		case ScalarUint16:
+			*(*uint16)(x) = uint16(u)
+		case ScalarUint32:
+			*(*uint32)(x) = uint32(u)
+		case ScalarUint64:
+			*(*uint64)(x) = u
+		case ScalarUintptr:
+			*(*uintptr)(x) = uintptr(u)
+		}
+
+	case ScalarFloat32, ScalarFloat64:
+		f, ok := toFloat64(v)
+		if !ok {
+			return fmt.Errorf("cannot convert %v (%T) to %s", v, v, kind)
+		}
+		if kind == ScalarFloat32 {
+			*(*float32)(x) = float32(f)
+		} else {
+			*(*float64)(x) = f
+		}
+
+	case ScalarString:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expecting a string, got %T", v)
+		}
+		*(*string)(x) = s
+
+	case ScalarBytes:
+		switch t := v.(type) {
+		case nil:
+			*(*[]byte)(x) = nil
+		case []byte:
+			*(*[]byte)(x) = append([]byte{}, t...)
+		case string:
+			b, err := base64.StdEncoding.DecodeString(t)
+			if err != nil {
+				return err
+			}
+			*(*[]byte)(x) = b
+		default:
+			return fmt.Errorf("expecting bytes or a base64 string, got %T", v)
+		}
+
+	default:
+		return fmt.Errorf("unsupported scalar kind %d", kind)
+	}
+	return nil
+}
+
+// bits returns the size of an integer kind.
+func (k ScalarKind) bits() uint {
+	switch k {
+	case ScalarInt8, ScalarUint8:
+		return 8
+	case ScalarInt16, ScalarUint16:
+		return 16
+	case ScalarInt32, ScalarUint32:
+		return 32
+	case ScalarInt, ScalarUint:
+		return strconv.IntSize
+	case ScalarUintptr:
+		return 8 * uint(unsafe.Sizeof(uintptr(0)))
+	default:
+		return 64
+	}
+}
+
+// toInt64 converts a numeric value to an int64, if it can be done
+// exactly.
+func toInt64(v interface{}) (int64, bool) {
+	switch t := v.(type) {
+	case int:
+		return int64(t), true
+	case int8:
+		return int64(t), true
+	case int16:
+		return int64(t), true
+	case int32:
+		return int64(t), true
+	case int64:
+		return t, true
+	case uint, uint8, uint16, uint32, uint64, uintptr:
+		u, _ := toUint64(t)
+		return int64(u), u <= math.MaxInt64
+	case float32:
+		return toInt64(float64(t))
+	case float64:
+		if t != math.Trunc(t) || t < math.MinInt64 || t >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(t), true
+	case json.Number:
+		i, err := t.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// toUint64 converts a numeric value to a uint64, if it can be done
+// exactly.
+func toUint64(v interface{}) (uint64, bool) {
+	switch t := v.(type) {
+	case uint:
+		return uint64(t), true
+	case uint8:
+		return uint64(t), true
+	case uint16:
+		return uint64(t), true
+	case uint32:
+		return uint64(t), true
+	case uint64:
+		return t, true
+	case uintptr:
+		return uint64(t), true
+	case float32:
+		return toUint64(float64(t))
+	case float64:
+		if t != math.Trunc(t) || t < 0 || t >= math.MaxUint64 {
+			return 0, false
+		}
+		return uint64(t), true
+	case json.Number:
+		u, err := strconv.ParseUint(t.String(), 10, 64)
+		return u, err == nil
+	default:
+		i, ok := toInt64(v)
+		return uint64(i), ok && i >= 0
+	}
+}
+
+// toFloat64 converts a numeric value to a float64.
+func toFloat64(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float32:
+		return float64(t), true
+	case float64:
+		return t, true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	default:
+		if i, ok := toInt64(v); ok {
+			return float64(i), true
+		}
+		if u, ok := toUint64(v); ok {
+			return float64(u), true
+		}
+		return 0, false
+	}
+}
@@ -0,0 +1,361 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains support for three-way merges.
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Conflict describes a location which was changed in different ways
+// by both sides of a merge.
+type Conflict struct {
+	// Path uses the same syntax as Context.Path. If a basic field is in
+	// conflict, the path ends with the name of the field.
+	Path string
+}
+
+// relation records which of the three values at a location are equal.
+type relation uint8
+
+const (
+	baseLeft relation = 1 << iota
+	baseRight
+	leftRight
+
+	allEqual = baseLeft | baseRight | leftRight
+)
+
+// mergeKey identifies the three structs at a location.
+type mergeKey struct {
+	typeData          *TypeData
+	base, left, right Ptr
+}
+
+// merger holds the state of a call to Merge.
+type merger struct {
+	engine    *Engine
+	conflicts []Conflict
+	// The structs which are being merged, to detect cycles.
+	active map[cycleKey]struct{}
+	// The structs which are being related, and their depth, to break
+	// cycles.
+	relating map[mergeKey]int
+	// The shallowest depth in relating whose relation has been assumed
+	// by the struct being related.
+	assumed int
+	// The relations of structs which have been computed. Each struct is
+	// related once, rather than once per enclosing value.
+	relations map[mergeKey]relation
+}
+
+// Merge performs a three-way merge of the values of type id at left
+// and right, which were both derived from the value at base, and
+// stores the result into dest. A location which is unchanged on one
+// side takes its value from the other side. Structs which are changed
+// on both sides are merged field by field, as are slices which have
+// the same length on all sides. Any other location which is changed
+// differently on both sides is reported as a Conflict and takes its
+// value from left. Unchanged values are shared with the inputs, rather
+// than copied.
+//
+// Values are compared in the same way as Zip: only the visitable fields
+// and the basic fields described by the TypeMap's Scalars are
+// considered. A change to any other field, such as an unexported one,
+// is not detected, so it is kept only if the struct is taken from the
+// side which made it.
+//
+// Merge returns an error wrapping ErrCycle if it would need to merge
+// a cycle.
+func (e *Engine) Merge(id TypeID, base, left, right, dest Ptr) ([]Conflict, error) {
+	td := e.typeData(id)
+	path := td.Name
+	if td.Kind == KindInterface {
+		// Paths start with the name of the root struct, as in Zip.
+		path = ""
+		for _, x := range []Ptr{left, right, base} {
+			if elem := td.intfType(x); elem != 0 {
+				path = e.typeData(elem).Name
+				break
+			}
+		}
+	}
+	m := &merger{
+		engine:    e,
+		active:    make(map[cycleKey]struct{}),
+		relating:  make(map[mergeKey]int),
+		relations: make(map[mergeKey]relation),
+	}
+	if err := m.merge(path, td, base, left, right, dest); err != nil {
+		return nil, err
+	}
+	return m.conflicts, nil
+}
+
+// merge stores the merged value of three locations into dest, which
+// must not overlap any of them.
+func (m *merger) merge(path string, td *TypeData, base, left, right, dest Ptr) error {
+	rel := m.relate(td, base, left, right)
+	switch {
+	case rel&baseLeft != 0:
+		td.Copy(dest, right)
+		return nil
+	case rel&(baseRight|leftRight) != 0:
+		td.Copy(dest, left)
+		return nil
+	}
+
+	switch td.Kind {
+	case KindStruct:
+		return m.fields(path, td, base, left, right, dest)
+
+	case KindPointer:
+		b, l, r := *(*Ptr)(base), *(*Ptr)(left), *(*Ptr)(right)
+		if b == nil || l == nil || r == nil || td.elemData.Kind != KindStruct {
+			break
+		}
+		next := td.elemData.NewStruct()
+		if err := m.enter(path, td.elemData, b, l, r, next); err != nil {
+			return err
+		}
+		*(*Ptr)(dest) = next
+		return nil
+
+	case KindSlice:
+		b, l, r := (*sliceHeader)(base), (*sliceHeader)(left), (*sliceHeader)(right)
+		if b.Len != l.Len || b.Len != r.Len {
+			break
+		}
+		eltTd := td.elemData
+		next := td.NewSlice(l.Len)
+		header := (*sliceHeader)(next)
+		for i, off := 0, uintptr(0); i < l.Len; i, off = i+1, off+eltTd.SizeOf {
+			if err := m.merge(path+"["+strconv.Itoa(i)+"]", eltTd,
+				Ptr(uintptr(b.Data)+off), Ptr(uintptr(l.Data)+off), Ptr(uintptr(r.Data)+off),
+				Ptr(uintptr(header.Data)+off),
+			); err != nil {
+				return err
+			}
+		}
+		td.Copy(dest, next)
+		return nil
+
+	case KindInterface:
+		id := td.intfType(left)
+		if id == 0 || td.intfType(base) != id || td.intfType(right) != id {
+			break
+		}
+		b, l, r := (*[2]Ptr)(base)[1], (*[2]Ptr)(left)[1], (*[2]Ptr)(right)[1]
+		if b == nil || l == nil || r == nil {
+			break
+		}
+		elemTd := m.engine.typeData(id)
+		next := elemTd.NewStruct()
+		if err := m.enter(path, elemTd, b, l, r, next); err != nil {
+			return err
+		}
+		td.Copy(dest, td.IntfWrap(id, next))
+		return nil
+
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+
+	m.conflicts = append(m.conflicts, Conflict{Path: path})
+	td.Copy(dest, left)
+	return nil
+}
+
+// enter merges structs which are reachable through a pointer or an
+// interface, which may lead to a cycle.
+func (m *merger) enter(path string, td *TypeData, base, left, right, dest Ptr) error {
+	key := cycleKey{td.TypeID, left}
+	if _, found := m.active[key]; found {
+		return fmt.Errorf("cannot merge a %w through %s", ErrCycle, td.Name)
+	}
+	m.active[key] = struct{}{}
+	err := m.fields(path, td, base, left, right, dest)
+	delete(m.active, key)
+	return err
+}
+
+// fields merges three structs of the same type into dest. The basic
+// fields are merged individually.
+func (m *merger) fields(path string, td *TypeData, base, left, right, dest Ptr) error {
+	td.Copy(dest, left)
+	for _, si := range td.Scalars {
+		b := Ptr(uintptr(base) + si.Offset)
+		l := Ptr(uintptr(left) + si.Offset)
+		r := Ptr(uintptr(right) + si.Offset)
+		switch {
+		case scalarEqual(si.Kind, b, r), scalarEqual(si.Kind, l, r):
+			// Keep the value from left.
+		case scalarEqual(si.Kind, b, l):
+			if err := setScalar(si.Kind, scalarValue(si.Kind, r), Ptr(uintptr(dest)+si.Offset)); err != nil {
+				return err
+			}
+		default:
+			m.conflicts = append(m.conflicts, Conflict{Path: path + "/" + si.Name})
+		}
+	}
+	for i := range td.Fields {
+		f := &td.Fields[i]
+		if err := m.merge(path+"/"+f.Name, f.targetData,
+			Ptr(uintptr(base)+f.Offset), Ptr(uintptr(left)+f.Offset), Ptr(uintptr(right)+f.Offset),
+			Ptr(uintptr(dest)+f.Offset),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relate determines which of three locations of the given type hold
+// values which Zip would find to have the same shape and basic fields.
+// The relations of structs are memoized, so that merging a tree
+// relates each of its structs once.
+func (m *merger) relate(td *TypeData, base, left, right Ptr) relation {
+	switch td.Kind {
+	case KindStruct:
+		return m.relateStruct(td, base, left, right)
+
+	case KindPointer:
+		b, l, r := *(*Ptr)(base), *(*Ptr)(left), *(*Ptr)(right)
+		switch {
+		case b == nil && l == nil && r == nil:
+			return allEqual
+		case b != nil && l != nil && r != nil:
+			return m.relate(td.elemData, b, l, r)
+		}
+		return m.pairwise(td, base, left, right, func(x, y Ptr) bool {
+			return (*(*Ptr)(x) == nil) == (*(*Ptr)(y) == nil)
+		})
+
+	case KindSlice:
+		b, l, r := (*sliceHeader)(base), (*sliceHeader)(left), (*sliceHeader)(right)
+		if b.Len != l.Len || b.Len != r.Len {
+			return m.pairwise(td, base, left, right, func(x, y Ptr) bool {
+				return (*sliceHeader)(x).Len == (*sliceHeader)(y).Len
+			})
+		}
+		eltTd := td.elemData
+		rel := allEqual
+		for i, off := 0, uintptr(0); i < l.Len && rel != 0; i, off = i+1, off+eltTd.SizeOf {
+			rel &= m.relate(eltTd,
+				Ptr(uintptr(b.Data)+off), Ptr(uintptr(l.Data)+off), Ptr(uintptr(r.Data)+off))
+		}
+		return rel
+
+	case KindInterface:
+		// Typed nils are treated as missing, as in Zip.
+		elem := func(x Ptr) (TypeID, Ptr) {
+			id := td.intfType(x)
+			data := (*[2]Ptr)(x)[1]
+			if id == 0 || data == nil {
+				return 0, nil
+			}
+			return id, data
+		}
+		bID, b := elem(base)
+		lID, l := elem(left)
+		rID, r := elem(right)
+		if bID == lID && bID == rID {
+			if bID == 0 {
+				return allEqual
+			}
+			return m.relate(m.engine.typeData(bID), b, l, r)
+		}
+		return m.pairwise(td, base, left, right, func(x, y Ptr) bool {
+			xID, _ := elem(x)
+			yID, _ := elem(y)
+			return xID == yID
+		})
+
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+}
+
+// pairwise relates three locations which do not all have the same
+// shape, such as slices of different lengths, by relating each pair
+// which does. Since the locations in each call to relate have the same
+// shape, pairwise is not called again for them.
+func (m *merger) pairwise(td *TypeData, base, left, right Ptr, same func(x, y Ptr) bool) relation {
+	var rel relation
+	if same(base, left) && m.relate(td, base, base, left)&leftRight != 0 {
+		rel |= baseLeft
+	}
+	if same(base, right) && m.relate(td, base, base, right)&leftRight != 0 {
+		rel |= baseRight
+	}
+	if same(left, right) && m.relate(td, left, left, right)&leftRight != 0 {
+		rel |= leftRight
+	}
+	return rel
+}
+
+// relateStruct relates three structs of the same type. A struct which
+// is already being related is assumed to be equal on all sides, as Zip
+// visits each pair of structs once. The relation of a struct which
+// depends on that assumption about an enclosing struct is not
+// memoized, since it may not hold.
+func (m *merger) relateStruct(td *TypeData, base, left, right Ptr) relation {
+	if base == left && base == right {
+		return allEqual
+	}
+	key := mergeKey{td, base, left, right}
+	if rel, ok := m.relations[key]; ok {
+		return rel
+	}
+	if depth, ok := m.relating[key]; ok {
+		m.assumed = min(m.assumed, depth)
+		return allEqual
+	}
+	depth := len(m.relating)
+	m.relating[key] = depth
+	outer := m.assumed
+	m.assumed = depth
+
+	rel := allEqual
+	for _, si := range td.Scalars {
+		b := Ptr(uintptr(base) + si.Offset)
+		l := Ptr(uintptr(left) + si.Offset)
+		r := Ptr(uintptr(right) + si.Offset)
+		if !scalarEqual(si.Kind, b, l) {
+			rel &^= baseLeft
+		}
+		if !scalarEqual(si.Kind, b, r) {
+			rel &^= baseRight
+		}
+		if !scalarEqual(si.Kind, l, r) {
+			rel &^= leftRight
+		}
+	}
+	for i := 0; i < len(td.Fields) && rel != 0; i++ {
+		f := &td.Fields[i]
+		rel &= m.relate(f.targetData,
+			Ptr(uintptr(base)+f.Offset), Ptr(uintptr(left)+f.Offset), Ptr(uintptr(right)+f.Offset))
+	}
+
+	delete(m.relating, key)
+	if m.assumed >= depth {
+		m.relations[key] = rel
+	}
+	m.assumed = min(outer, m.assumed)
+	return rel
+}
@@ -0,0 +1,83 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import "sync/atomic"
+
+// Counter is a monotonic counter which records walk activity. It is
+// implemented by *expvar.Int. Other metrics libraries can be adapted
+// using CounterFunc.
+type Counter interface {
+	Add(delta int64)
+}
+
+// CounterFunc adapts a function to the Counter interface. For
+// example, a Prometheus counter can be adapted with:
+//
+//	engine.CounterFunc(func(d int64) { c.Add(float64(d)) })
+type CounterFunc func(delta int64)
+
+// Add implements Counter.
+func (fn CounterFunc) Add(delta int64) { fn(delta) }
+
+// Metrics holds the counters which are updated by the engine. Any of
+// the counters may be nil. The counters must be safe for concurrent
+// use.
+//
+// Each call into the engine is counted as a walk. Walkers generated
+// with --inline visit small structs without calling into the engine,
+// so those visits are not counted and a single walk may be counted
+// more than once.
+type Metrics struct {
+	// Errors counts the walks which returned an error.
+	Errors Counter
+	// Replacements counts the values which were replaced by a
+	// different value.
+	Replacements Counter
+	// Visits counts the structs which were passed to a user's function.
+	Visits Counter
+	// Walks counts the number of walks.
+	Walks Counter
+}
+
+// metrics is accessed atomically.
+var metrics atomic.Pointer[Metrics]
+
+// SetMetrics installs counters which will be updated by all engines,
+// and returns the previously-installed counters. A nil value disables
+// the collection of metrics, which is the default. The counters are
+// updated once at the end of each walk, and apply to walks which start
+// after the call.
+func SetMetrics(m *Metrics) *Metrics {
+	return metrics.Swap(m)
+}
+
+// record updates the counters at the end of a walk.
+func (m *Metrics) record(visits, replacements int64, err error) {
+	add(m.Walks, 1)
+	add(m.Visits, visits)
+	add(m.Replacements, replacements)
+	if err != nil {
+		add(m.Errors, 1)
+	}
+}
+
+// add updates the counter, if it is non-nil and there is a change.
+func add(c Counter, delta int64) {
+	if c != nil && delta != 0 {
+		c.Add(delta)
+	}
+}
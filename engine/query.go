@@ -0,0 +1,234 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains a small path-expression language for selecting
+// values from an Abstract tree. An expression is a sequence of steps
+// separated by slashes:
+//	Name    a field of a struct with the given name
+//	*       any field of a struct or element of a slice
+//	**      the value itself and all of its descendants
+// A Name or * step may be followed by any number of selectors, which
+// choose elements from a slice:
+//	[n]     the nth element
+//	[*]     every element
+// The first step is matched against the root value, so a Name in the
+// first step must be the name of the root's type. For example,
+// "ContainerType/TargetSlice[*]/ByRef" selects the ByRef field of each
+// element of the root's TargetSlice field, while "*/**/ByRef" selects
+// every ByRef field.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Query is a parsed path expression. It is safe for concurrent use.
+type Query struct {
+	expr  string
+	steps []queryStep
+}
+
+// queryStep is a single, slash-separated step of a Query.
+type queryStep struct {
+	// The field name, or "*" or "**".
+	name string
+	// Slice indexes, where -1 selects every element.
+	selectors []int
+}
+
+// Match is a value which was selected by a Query.
+type Match struct {
+	// Path is the location of the value within the root, using the
+	// same syntax as a Query, e.g. "ContainerType/TargetSlice[1]/ByRef".
+	Path  string
+	Value *Abstract
+}
+
+// ParseQuery parses a path expression.
+func ParseQuery(expr string) (*Query, error) {
+	if expr == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+	q := &Query{expr: expr}
+	for _, part := range strings.Split(expr, "/") {
+		step, err := parseStep(part)
+		if err != nil {
+			return nil, fmt.Errorf("bad step %q in %q: %w", part, expr, err)
+		}
+		q.steps = append(q.steps, step)
+	}
+	return q, nil
+}
+
+// parseStep parses a single step of a Query.
+func parseStep(part string) (queryStep, error) {
+	name := part
+	var rest string
+	if idx := strings.IndexByte(part, '['); idx >= 0 {
+		name, rest = part[:idx], part[idx:]
+	}
+	if name == "" {
+		return queryStep{}, fmt.Errorf("missing field name")
+	}
+	if name == "**" && rest != "" {
+		return queryStep{}, fmt.Errorf("** cannot have selectors")
+	}
+	if strings.ContainsAny(name, "*]") && name != "*" && name != "**" {
+		return queryStep{}, fmt.Errorf("bad field name")
+	}
+
+	step := queryStep{name: name}
+	for rest != "" {
+		end := strings.IndexByte(rest, ']')
+		if rest[0] != '[' || end < 0 {
+			return queryStep{}, fmt.Errorf("bad selector")
+		}
+		sel := rest[1:end]
+		rest = rest[end+1:]
+		if sel == "*" {
+			step.selectors = append(step.selectors, -1)
+			continue
+		}
+		idx, err := strconv.Atoi(sel)
+		if err != nil || idx < 0 {
+			return queryStep{}, fmt.Errorf("bad index %q", sel)
+		}
+		step.selectors = append(step.selectors, idx)
+	}
+	return step, nil
+}
+
+// String returns the expression that the Query was parsed from.
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Select returns the values within the root which are matched by the
+// Query, in depth-first order. Nil values are never matched. Cycles
+// are not followed by a ** step.
+func (q *Query) Select(root *Abstract) []Match {
+	if root == nil {
+		return nil
+	}
+
+	// The first step is matched against the root, rather than its
+	// children.
+	current := []Match{{Path: root.engine.Stringify(root.TypeID()), Value: root}}
+	first := q.steps[0]
+	switch first.name {
+	case "*":
+	case "**":
+		current = descendants(current)
+	default:
+		if first.name != current[0].Path {
+			return nil
+		}
+	}
+	current = selectElements(current, first.selectors)
+
+	for _, step := range q.steps[1:] {
+		var next []Match
+		switch step.name {
+		case "**":
+			next = descendants(current)
+		case "*":
+			for _, m := range current {
+				next = appendChildren(next, m)
+			}
+		default:
+			for _, m := range current {
+				if m.Value.typeData.Kind != KindStruct {
+					continue
+				}
+				for idx, f := range m.Value.typeData.Fields {
+					if f.Name != step.name {
+						continue
+					}
+					if child := m.Value.ChildAt(idx); child != nil {
+						next = append(next, Match{Path: m.Path + "/" + f.Name, Value: child})
+					}
+				}
+			}
+		}
+		current = selectElements(next, step.selectors)
+		if len(current) == 0 {
+			return nil
+		}
+	}
+	return current
+}
+
+// appendChildren appends each non-nil field or element of the match.
+func appendChildren(buf []Match, m Match) []Match {
+	for i, j := 0, m.Value.NumChildren(); i < j; i++ {
+		child := m.Value.ChildAt(i)
+		if child == nil {
+			continue
+		}
+		if m.Value.typeData.Kind == KindStruct {
+			buf = append(buf, Match{Path: m.Path + "/" + m.Value.typeData.Fields[i].Name, Value: child})
+		} else {
+			buf = append(buf, Match{Path: fmt.Sprintf("%s[%d]", m.Path, i), Value: child})
+		}
+	}
+	return buf
+}
+
+// descendants returns the matches and all of their descendants.
+func descendants(matches []Match) []Match {
+	var ret []Match
+	active := make(map[cycleKey]struct{})
+	var visit func(m Match)
+	visit = func(m Match) {
+		key := cycleKey{m.Value.TypeID(), m.Value.Ptr()}
+		if _, found := active[key]; found {
+			return
+		}
+		active[key] = struct{}{}
+		ret = append(ret, m)
+		for _, child := range appendChildren(nil, m) {
+			visit(child)
+		}
+		delete(active, key)
+	}
+	for _, m := range matches {
+		visit(m)
+	}
+	return ret
+}
+
+// selectElements applies slice selectors to the matches.
+func selectElements(matches []Match, selectors []int) []Match {
+	for _, sel := range selectors {
+		var next []Match
+		for _, m := range matches {
+			if m.Value.typeData.Kind != KindSlice {
+				continue
+			}
+			if sel < 0 {
+				next = appendChildren(next, m)
+			} else if sel < m.Value.NumChildren() {
+				if child := m.Value.ChildAt(sel); child != nil {
+					next = append(next, Match{Path: fmt.Sprintf("%s[%d]", m.Path, sel), Value: child})
+				}
+			}
+		}
+		matches = next
+	}
+	return matches
+}
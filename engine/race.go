@@ -0,0 +1,38 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+//go:build race
+// +build race
+
+package engine
+
+// This file is compiled in when the race detector is enabled. A walk
+// which checks for races reports a read of each struct that it visits,
+// so that the race detector will flag any in-place mutation of that
+// memory by another goroutine, including of fields which the walk
+// would otherwise never load.
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// raceEnabled allows the race detector hooks to be compiled out.
+const raceEnabled = true
+
+// raceRead reports a read of the value to the race detector.
+func raceRead(td *TypeData, x Ptr) {
+	runtime.RaceReadRange(unsafe.Pointer(x), int(td.SizeOf))
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains the bookkeeping for Options.CheckRaces. Walks are
+// copy-on-write, so walking a shared value is safe. However, two walks
+// which both rewrite the same root usually mean that one of the results
+// will be lost, and a walk which rewrites a root while another walk
+// reads it suggests that the tree is being shared without the
+// coordination that its owner expects.
+
+import (
+	"fmt"
+	"sync"
+)
+
+// races tracks the roots of the walks which are checking for races.
+var races = struct {
+	sync.Mutex
+	roots map[Ptr]*raceRoot
+}{roots: make(map[Ptr]*raceRoot)}
+
+// raceRoot describes the walks of a single root value.
+type raceRoot struct {
+	// rewriting is the path of the first replacement made by a walk of
+	// the root, or empty if no walk has replaced a value.
+	rewriting string
+	// walks is the number of walks of the root which are in progress.
+	walks int
+}
+
+// raceWalk is the state of a single walk which is checking for races.
+type raceWalk struct {
+	name      string
+	root      Ptr
+	rewriting bool
+}
+
+// raceEnter registers a walk of the root value. It panics if another
+// walk is rewriting the same value.
+func (e *Engine) raceEnter(t TypeID, x Ptr) *raceWalk {
+	w := &raceWalk{name: e.Stringify(t), root: x}
+	races.Lock()
+	defer races.Unlock()
+	r := races.roots[x]
+	if r == nil {
+		r = &raceRoot{}
+		races.roots[x] = r
+	}
+	if r.rewriting != "" {
+		panic(fmt.Errorf("walkabout: %s is being walked while another walk is "+
+			"rewriting it at %s; walks which replace values must not overlap "+
+			"other walks of the same root", w.name, r.rewriting))
+	}
+	r.walks++
+	return w
+}
+
+// rewrite records that the walk has replaced a value. It panics if
+// another walk of the same root is in progress.
+func (w *raceWalk) rewrite(s *stack) {
+	if w.rewriting {
+		return
+	}
+	w.rewriting = true
+	races.Lock()
+	defer races.Unlock()
+	r := races.roots[w.root]
+	if others := r.walks - 1; others > 0 {
+		panic(fmt.Errorf("walkabout: concurrent rewrite of %s at %s: %d other "+
+			"walk(s) of the same root are in progress; walks which replace "+
+			"values must not overlap other walks of the same root",
+			w.name, s.Path(), others))
+	}
+	r.rewriting = s.Path()
+}
+
+// exit unregisters the walk.
+func (w *raceWalk) exit() {
+	races.Lock()
+	defer races.Unlock()
+	r := races.roots[w.root]
+	if w.rewriting {
+		r.rewriting = ""
+	}
+	if r.walks--; r.walks == 0 {
+		delete(races.roots, w.root)
+	}
+}
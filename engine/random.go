@@ -0,0 +1,269 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Defaults for RandomConfig.
+const (
+	defaultRandomDepth    = 4
+	defaultRandomSliceLen = 3
+)
+
+// RandomConfig controls the shape of the trees built by Random.
+type RandomConfig struct {
+	// MaxDepth limits the number of levels of structs that will be
+	// created by following pointers, slices, or interfaces. Structs which
+	// are embedded by value do not count towards the depth. If zero, a
+	// depth of 4 is used.
+	MaxDepth int
+	// MaxNodes limits the number of structs in the tree. Once reached,
+	// pointers, slices, and interfaces will be left nil. Structs which
+	// are embedded by value are always created, so the limit may be
+	// exceeded. If zero, there is no limit.
+	MaxNodes int
+	// MaxSliceLen is the largest number of elements in a slice. If zero,
+	// a maximum of 3 is used.
+	MaxSliceLen int
+	// NilChance is the probability, between 0 and 1, that a pointer,
+	// slice, or interface will be left nil.
+	NilChance float64
+	// Weights contains the relative likelihood of choosing a struct
+	// type, by name, when populating an interface. Types which are not
+	// present have a weight of one, and types with a weight of zero will
+	// not be chosen.
+	Weights map[string]float64
+}
+
+// Random replaces the value of the given type at x with a randomly
+// generated value. Fields described by TypeData.Scalars are also given
+// random values. The same sequence of values from r will produce the
+// same tree.
+func (e *Engine) Random(r *rand.Rand, cfg RandomConfig, id TypeID, x Ptr) {
+	if cfg.MaxDepth == 0 {
+		cfg.MaxDepth = defaultRandomDepth
+	}
+	if cfg.MaxSliceLen == 0 {
+		cfg.MaxSliceLen = defaultRandomSliceLen
+	}
+	g := randomizer{
+		RandomConfig: cfg,
+		engine:       e,
+		impls:        make(map[TypeID][]TypeID),
+		rnd:          r,
+	}
+	td := e.typeData(id)
+	if td.Kind == KindStruct {
+		g.fill(x, td, 0)
+		return
+	}
+	// Only the contents of the value are optional.
+	g.NilChance, cfg.NilChance = 0, g.NilChance
+	ret := g.newValue(td, 0)
+	g.NilChance = cfg.NilChance
+	if ret == nil {
+		td.Copy(x, newValue(td))
+		return
+	}
+	td.Copy(x, ret)
+}
+
+// randomizer holds the state of a call to Random.
+type randomizer struct {
+	RandomConfig
+	engine *Engine
+	// impls caches the struct types which implement an interface.
+	impls map[TypeID][]TypeID
+	nodes int
+	rnd   *rand.Rand
+}
+
+// fill populates the struct at dest.
+func (g *randomizer) fill(dest Ptr, td *TypeData, depth int) {
+	g.nodes++
+	for _, f := range td.Fields {
+		fPtr := Ptr(uintptr(dest) + f.Offset)
+		if f.targetData.Kind == KindStruct {
+			g.fill(fPtr, f.targetData, depth)
+			continue
+		}
+		if value := g.newValue(f.targetData, depth); value != nil {
+			f.targetData.Copy(fPtr, value)
+		}
+	}
+	for _, s := range td.Scalars {
+		g.scalar(s.Kind, Ptr(uintptr(dest)+s.Offset))
+	}
+}
+
+// newValue returns a pointer to a new value of the given type, or nil
+// if the value should be left as its zero value.
+func (g *randomizer) newValue(td *TypeData, depth int) Ptr {
+	if td.Kind != KindStruct {
+		if depth >= g.MaxDepth || (g.MaxNodes > 0 && g.nodes >= g.MaxNodes) ||
+			(g.NilChance > 0 && g.rnd.Float64() < g.NilChance) {
+			return nil
+		}
+	}
+	switch td.Kind {
+	case KindStruct:
+		ret := td.NewStruct()
+		g.fill(ret, td, depth)
+		return ret
+
+	case KindPointer:
+		elem := td.elemData
+		var ptr Ptr
+		if elem.Kind == KindStruct {
+			ptr = elem.NewStruct()
+			g.fill(ptr, elem, depth+1)
+		} else if ptr = g.newValue(elem, depth); ptr == nil {
+			return nil
+		}
+		ret := new(Ptr)
+		*ret = ptr
+		return Ptr(ret)
+
+	case KindSlice:
+		count := g.rnd.Intn(g.MaxSliceLen + 1)
+		ret := td.NewSlice(count)
+		eltTd := td.elemData
+		data := (*sliceHeader)(ret).Data
+		for i := 0; i < count; i++ {
+			eltPtr := Ptr(uintptr(data) + uintptr(i)*eltTd.SizeOf)
+			if eltTd.Kind == KindStruct {
+				g.fill(eltPtr, eltTd, depth+1)
+			} else if value := g.newValue(eltTd, depth+1); value != nil {
+				eltTd.Copy(eltPtr, value)
+			}
+		}
+		return ret
+
+	case KindInterface:
+		impl := g.choose(td)
+		if impl == nil {
+			return nil
+		}
+		elem := impl.NewStruct()
+		g.fill(elem, impl, depth+1)
+		return td.IntfWrap(impl.TypeID, elem)
+
+	default:
+		panic(fmt.Errorf("unimplemented: %d", td.Kind))
+	}
+}
+
+// choose selects a struct type to store in an interface, according to
+// the configured weights.
+func (g *randomizer) choose(intf *TypeData) *TypeData {
+	impls, ok := g.impls[intf.TypeID]
+	if !ok {
+		for idx := range g.engine.typeMap {
+			td := &g.engine.typeMap[idx]
+			if td.Kind == KindStruct && intf.IntfWrap(td.TypeID, td.NewStruct()) != nil {
+				impls = append(impls, td.TypeID)
+			}
+		}
+		g.impls[intf.TypeID] = impls
+	}
+
+	total := 0.0
+	for _, id := range impls {
+		total += g.weight(id)
+	}
+	if total <= 0 {
+		return nil
+	}
+	pick := g.rnd.Float64() * total
+	for _, id := range impls {
+		w := g.weight(id)
+		if w <= 0 {
+			continue
+		}
+		if pick < w {
+			return g.engine.typeData(id)
+		}
+		pick -= w
+	}
+	// Guard against rounding; choose the last viable type.
+	for i := len(impls) - 1; i >= 0; i-- {
+		if g.weight(impls[i]) > 0 {
+			return g.engine.typeData(impls[i])
+		}
+	}
+	return nil
+}
+
+// weight returns the configured weight of a struct type.
+func (g *randomizer) weight(id TypeID) float64 {
+	if w, ok := g.Weights[g.engine.typeData(id).Name]; ok {
+		return w
+	}
+	return 1
+}
+
+// randomLetters are used to build random strings.
+const randomLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// scalar stores a random basic value into x.
+func (g *randomizer) scalar(kind ScalarKind, x Ptr) {
+	bits := g.rnd.Uint64()
+	switch kind {
+	case ScalarBool:
+		*(*bool)(x) = bits&1 == 1
+	case ScalarInt:
+		*(*int)(x) = int(bits)
+	case ScalarInt8:
+		*(*int8)(x) = int8(bits)
+	case ScalarInt16:
+		*(*int16)(x) = int16(bits)
+	case ScalarInt32:
+		*(*int32)(x) = int32(bits)
+	case ScalarInt64:
+		*(*int64)(x) = int64(bits)
+	case ScalarUint:
+		*(*uint)(x) = uint(bits)
+	case ScalarUint8:
+		*(*uint8)(x) = uint8(bits)
+	case ScalarUint16:
+		*(*uint16)(x) = uint16(bits)
+	case ScalarUint32:
+		*(*uint32)(x) = uint32(bits)
+	case ScalarUint64:
+		*(*uint64)(x) = bits
+	case ScalarUintptr:
+		*(*uintptr)(x) = uintptr(bits)
+	case ScalarFloat32:
+		*(*float32)(x) = float32(g.rnd.NormFloat64())
+	case ScalarFloat64:
+		*(*float64)(x) = g.rnd.NormFloat64()
+	case ScalarString:
+		b := make([]byte, g.rnd.Intn(9))
+		for i := range b {
+			b[i] = randomLetters[g.rnd.Intn(len(randomLetters))]
+		}
+		*(*string)(x) = string(b)
+	case ScalarBytes:
+		b := make([]byte, g.rnd.Intn(9))
+		g.rnd.Read(b)
+		*(*[]byte)(x) = b
+	default:
+		panic(fmt.Errorf("unimplemented: %d", kind))
+	}
+}
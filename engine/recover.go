@@ -0,0 +1,115 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// Options control a single walk. The zero value is equivalent to
+// calling Execute.
+type Options struct {
+	// Recover converts a panic during the walk, such as in a user's
+	// walker, post-visit, or action function, into a *PanicError which
+	// is returned from the walk. Otherwise, the panic will unwind
+	// through the caller of the walk.
+	Recover bool
+	// MaxDepth is optional and limits the depth of the walk's stack,
+	// which grows by one for each struct, pointer, slice, interface,
+	// or set of actions which is entered. A walk which would exceed the
+	// limit returns a *PathError which wraps ErrMaxDepth.
+	MaxDepth int
+	// CheckRaces panics if a walk which replaces a value overlaps
+	// another walk of the same root value which also sets CheckRaces.
+	// If the race detector is enabled, each struct which is visited is
+	// also reported as read, so that an in-place mutation of the tree
+	// by another goroutine will be flagged.
+	CheckRaces bool
+	// VisitTypedNils calls the walker for an interface which holds a
+	// nil pointer to a struct, with the nil pointer as its value, so
+	// that it may be rejected or replaced. The fields of a typed nil
+	// are never visited. Otherwise, typed nils are skipped.
+	VisitTypedNils bool
+	// NormalizeTypedNils replaces an interface which holds a nil
+	// pointer to a struct with a nil interface, unless the walker
+	// replaces it with another value.
+	NormalizeTypedNils bool
+	// Counts is optional and is incremented once for each struct which
+	// is visited, keyed by the struct's type. It allows the types which
+	// dominate a walk to be identified without the overhead of tracing.
+	Counts map[TypeID]int
+	// Only is optional and restricts the walk to structs of the given
+	// types. The walker is called only for those structs, and values
+	// which cannot contain one of them are not entered. The decision
+	// is made using the types of the values, so an interface field is
+	// entered only if its implementations may contain a wanted type.
+	Only []TypeID
+	// Tracing is optional and opens spans around the walk.
+	Tracing *Tracing
+	// Context is optional and is passed to Tracing.Tracer when the span
+	// of the walk is opened. It does not cancel the walk.
+	Context context.Context
+}
+
+// PanicError is returned from a walk which recovered from a panic,
+// when Options.Recover is set.
+type PanicError struct {
+	// Path is the location of the value which was being visited, using
+	// the same syntax as Context.Path.
+	Path string
+	// Stack is a trace of the goroutine which panicked.
+	Stack []byte
+	// Type is the name of the value's type. It is empty if the panic
+	// occurred in a function passed to Context.ActionCall.
+	Type string
+	// TypeID is the type of the value. It is zero if the panic occurred
+	// in a function passed to Context.ActionCall.
+	TypeID TypeID
+	// Value is the value which was passed to panic.
+	Value interface{}
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("panic at %s: %v", e.Path, e.Value)
+	}
+	return fmt.Sprintf("panic while visiting %s at %s: %v", e.Type, e.Path, e.Value)
+}
+
+// Unwrap returns the value which was passed to panic, if it is an
+// error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recovered returns a PanicError which describes the value being
+// visited by the top frame of the stack.
+func (e *Engine) recovered(s *stack, value interface{}) *PanicError {
+	ret := &PanicError{Stack: debug.Stack(), Value: value}
+	if s.depth == 0 {
+		return ret
+	}
+	ret.Path = s.Path()
+	if td := s.Top(0).Active().typeData; td != nil && td.TypeID != 0 {
+		ret.Type = e.Stringify(td.TypeID)
+		ret.TypeID = td.TypeID
+	}
+	return ret
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import "fmt"
+
+// ReplacementError is returned from a walk when a value is replaced by
+// a value which cannot be stored in the value's location.
+type ReplacementError struct {
+	// Path is the location of the value which was replaced, using the
+	// same syntax as Context.Path.
+	Path string
+	// Slot is the name of the type of the value's location. It is empty
+	// if the value was visited by an action, and has no location.
+	Slot string
+	// Type is the name of the replacement's type. If the children of a
+	// value visited by an action were replaced, it is the name of the
+	// value's type.
+	Type string
+}
+
+// Error implements error.
+func (e *ReplacementError) Error() string {
+	if e.Slot == "" {
+		return fmt.Sprintf("%s: a value visited by an action cannot be replaced by %s",
+			e.Path, e.Type)
+	}
+	return fmt.Sprintf("%s: type %s is not assignable to %s", e.Path, e.Type, e.Slot)
+}
+
+// replacementError returns a ReplacementError which describes the
+// active slot of the top frame of the stack.
+func (e *Engine) replacementError(s *stack, assignableTo *TypeData, id TypeID) *ReplacementError {
+	ret := &ReplacementError{Path: s.Path(), Type: e.Stringify(id)}
+	if assignableTo != nil {
+		ret.Slot = e.Stringify(assignableTo.TypeID)
+	}
+	return ret
+}
@@ -0,0 +1,342 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains a textual, s-expression format for visitable
+// values, which is intended for golden files and test fixtures:
+//	struct     (TypeName :Field value ...), listing the fields in the
+//	           order in which they are declared
+//	slice      [value ...]
+//	nil        nil
+//	string     a quoted Go string
+//	bytes      a quoted, base64-encoded string
+//	number     a decimal number
+//	bool       true or false
+//
+// Fields which are nil or which contain the zero value of a basic type
+// are omitted when dumping. A semicolon starts a comment which runs to
+// the end of the line. The format is a rendering of the generic form
+// used by ToMap and FromMap.
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dumpWidth is the line length beyond which a struct or slice will be
+// split across multiple lines.
+const dumpWidth = 80
+
+// Dump returns the s-expression form of the value of the given type at
+// x, which may be read by Parse. The output is stable, so that it may
+// be compared to a golden file.
+func (e *Engine) Dump(id TypeID, x Ptr) (string, error) {
+	m := mapper{engine: e, active: make(map[cycleKey]struct{})}
+	data, err := m.toMap(e.typeData(id), x)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	m.dump(&sb, data, 0)
+	return sb.String(), nil
+}
+
+// Parse replaces the value of the given type at x with one which is
+// read from its s-expression form.
+func (e *Engine) Parse(text string, id TypeID, x Ptr) error {
+	p := parser{text: text, line: 1, col: 1}
+	data, err := p.value()
+	if err != nil {
+		return err
+	}
+	p.skip()
+	if p.pos < len(p.text) {
+		return p.errorf("unexpected %q after value", p.text[p.pos:p.pos+1])
+	}
+	return e.FromMap(data, id, x)
+}
+
+// dump writes data, which has already been indented by indent spaces.
+func (m *mapper) dump(sb *strings.Builder, data interface{}, indent int) {
+	compact := m.compact(data)
+	if indent+len(compact) <= dumpWidth {
+		sb.WriteString(compact)
+		return
+	}
+
+	switch t := data.(type) {
+	case map[string]interface{}:
+		pad := "\n" + strings.Repeat(" ", indent+2)
+		sb.WriteString("(")
+		sb.WriteString(t[TypeKey].(string))
+		for _, key := range m.dumpKeys(t) {
+			sb.WriteString(pad)
+			sb.WriteString(":")
+			sb.WriteString(key)
+			sb.WriteString(" ")
+			m.dump(sb, t[key], indent+len(key)+4)
+		}
+		sb.WriteString(")")
+	case []interface{}:
+		pad := "\n" + strings.Repeat(" ", indent+1)
+		sb.WriteString("[")
+		for i, elt := range t {
+			if i > 0 {
+				sb.WriteString(pad)
+			}
+			m.dump(sb, elt, indent+1)
+		}
+		sb.WriteString("]")
+	default:
+		sb.WriteString(compact)
+	}
+}
+
+// compact returns the single-line form of data.
+func (m *mapper) compact(data interface{}) string {
+	switch t := data.(type) {
+	case nil:
+		return "nil"
+	case map[string]interface{}:
+		var sb strings.Builder
+		sb.WriteString("(")
+		sb.WriteString(t[TypeKey].(string))
+		for _, key := range m.dumpKeys(t) {
+			fmt.Fprintf(&sb, " :%s %s", key, m.compact(t[key]))
+		}
+		sb.WriteString(")")
+		return sb.String()
+	case []interface{}:
+		parts := make([]string, len(t))
+		for i, elt := range t {
+			parts[i] = m.compact(elt)
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	case string:
+		return strconv.Quote(t)
+	case []byte:
+		return strconv.Quote(base64.StdEncoding.EncodeToString(t))
+	case float32:
+		return strconv.FormatFloat(float64(t), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// dumpKeys returns the keys of the struct's fields which have
+// non-zero values, in the order in which they are declared.
+func (m *mapper) dumpKeys(fields map[string]interface{}) []string {
+	td := m.lookup(fields[TypeKey].(string))
+	offsets := make(map[string]uintptr, len(td.Fields)+len(td.Scalars))
+	for _, f := range td.Fields {
+		offsets[f.Name] = f.Offset
+	}
+	for _, s := range td.Scalars {
+		offsets[s.Name] = s.Offset
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key, value := range fields {
+		if key != TypeKey && !isZero(value) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return offsets[keys[i]] < offsets[keys[j]] })
+	return keys
+}
+
+// isZero returns true if the value in the generic form need not be
+// dumped.
+func isZero(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !t
+	case string:
+		return t == ""
+	case []byte:
+		return t == nil
+	case map[string]interface{}, []interface{}:
+		return false
+	default:
+		f, ok := toFloat64(t)
+		return ok && f == 0
+	}
+}
+
+// parser reads the s-expression form into the generic form.
+type parser struct {
+	text      string
+	pos       int
+	line, col int
+}
+
+// errorf returns an error which includes the current position.
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%d:%d: %s", p.line, p.col, fmt.Sprintf(format, args...))
+}
+
+// advance consumes n bytes.
+func (p *parser) advance(n int) {
+	for _, r := range p.text[p.pos : p.pos+n] {
+		if r == '\n' {
+			p.line++
+			p.col = 1
+		} else {
+			p.col++
+		}
+	}
+	p.pos += n
+}
+
+// skip consumes whitespace and comments.
+func (p *parser) skip() {
+	for p.pos < len(p.text) {
+		switch p.text[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.advance(1)
+		case ';':
+			end := strings.IndexByte(p.text[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.text) - p.pos
+			}
+			p.advance(end)
+		default:
+			return
+		}
+	}
+}
+
+// word consumes a run of characters which are not delimiters.
+func (p *parser) word() string {
+	start := p.pos
+	end := p.pos
+	for end < len(p.text) && !strings.ContainsRune(" \t\r\n()[]:;\"", rune(p.text[end])) {
+		end++
+	}
+	p.advance(end - start)
+	return p.text[start:end]
+}
+
+// value reads a single value.
+func (p *parser) value() (interface{}, error) {
+	p.skip()
+	if p.pos >= len(p.text) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch c := p.text[p.pos]; {
+	case c == '(':
+		return p.structValue()
+
+	case c == '[':
+		p.advance(1)
+		ret := []interface{}{}
+		for {
+			p.skip()
+			if p.pos < len(p.text) && p.text[p.pos] == ']' {
+				p.advance(1)
+				return ret, nil
+			}
+			elt, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, elt)
+		}
+
+	case c == '"':
+		end := p.pos + 1
+		for ; end < len(p.text) && p.text[end] != '"'; end++ {
+			if p.text[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(p.text) {
+			return nil, p.errorf("unterminated string")
+		}
+		s, err := strconv.Unquote(p.text[p.pos : end+1])
+		if err != nil {
+			return nil, p.errorf("bad string: %v", err)
+		}
+		p.advance(end + 1 - p.pos)
+		return s, nil
+
+	case c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9'):
+		word := p.word()
+		if _, err := strconv.ParseFloat(word, 64); err != nil {
+			return nil, p.errorf("bad number %q", word)
+		}
+		return json.Number(word), nil
+
+	default:
+		switch word := p.word(); word {
+		case "nil":
+			return nil, nil
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "":
+			return nil, p.errorf("unexpected %q", p.text[p.pos:p.pos+1])
+		default:
+			return nil, p.errorf("unexpected %q", word)
+		}
+	}
+}
+
+// structValue reads a struct, starting at its opening parenthesis.
+func (p *parser) structValue() (interface{}, error) {
+	p.advance(1)
+	p.skip()
+	name := p.word()
+	if name == "" {
+		return nil, p.errorf("expecting a type name")
+	}
+	ret := map[string]interface{}{TypeKey: name}
+	for {
+		p.skip()
+		if p.pos >= len(p.text) {
+			return nil, p.errorf("unexpected end of input")
+		}
+		if p.text[p.pos] == ')' {
+			p.advance(1)
+			return ret, nil
+		}
+		if p.text[p.pos] != ':' {
+			return nil, p.errorf("expecting a :Field in %s", name)
+		}
+		p.advance(1)
+		key := p.word()
+		if key == "" {
+			return nil, p.errorf("expecting a field name in %s", name)
+		}
+		if _, dup := ret[key]; dup {
+			return nil, p.errorf("duplicate field %s in %s", key, name)
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		ret[key] = v
+	}
+}
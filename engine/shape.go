@@ -0,0 +1,132 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file detects generated code which is out of date with respect
+// to the structs that it describes. Since the generated code refers to
+// fields by name, most changes will cause a compilation error. A field
+// which is added, or whose type is changed, may not.
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strconv"
+)
+
+// A Shape is a hash of the names and types of the fields of a struct.
+// The code generator records the Shape of each struct in the TypeMap.
+type Shape uint64
+
+// NewShape returns the Shape of a struct whose fields, in order, are
+// described by ShapeField.
+func NewShape(fields ...string) Shape {
+	h := fnv.New64a()
+	for _, f := range fields {
+		_, _ = h.Write([]byte(f))
+		_, _ = h.Write([]byte{0})
+	}
+	return Shape(h.Sum64())
+}
+
+// ShapeField describes a field of a struct, given the field's name and
+// a description of its type. Named types are described by their
+// unqualified name, pointers, slices, and arrays by their element type,
+// and all other types by their reflect.Kind.
+func ShapeField(name, typ string) string {
+	return name + " " + typ
+}
+
+// ShapeOf returns the Shape of a struct type.
+func ShapeOf(typ reflect.Type) Shape {
+	fields := make([]string, typ.NumField())
+	for i := range fields {
+		f := typ.Field(i)
+		fields[i] = ShapeField(f.Name, shapeType(f.Type))
+	}
+	return NewShape(fields...)
+}
+
+// shapeType describes a type for ShapeField.
+func shapeType(typ reflect.Type) string {
+	// Instantiated generic types are described by their kind, since
+	// their names include the qualified names of their type arguments.
+	if name := typ.Name(); name != "" && !isInstantiated(name) {
+		return name
+	}
+	switch typ.Kind() {
+	case reflect.Array:
+		return "[" + strconv.Itoa(typ.Len()) + "]" + shapeType(typ.Elem())
+	case reflect.Ptr:
+		return "*" + shapeType(typ.Elem())
+	case reflect.Slice:
+		return "[]" + shapeType(typ.Elem())
+	default:
+		return typ.Kind().String()
+	}
+}
+
+// isInstantiated returns true if the name of a type includes type
+// arguments.
+func isInstantiated(name string) bool {
+	return name[len(name)-1] == ']'
+}
+
+// fieldName returns the name of a struct field, given the Name and
+// Field of a FieldInfo or ScalarInfo.
+func fieldName(name, field string) string {
+	if field != "" {
+		return field
+	}
+	return name
+}
+
+// checkShape panics if a struct type has changed since the code was
+// generated.
+func (td *TypeData) checkShape() {
+	typ := td.Type
+	stale := func(format string, args ...interface{}) {
+		panic(fmt.Errorf("%s has changed since its walkabout code was generated (%s); "+
+			"regenerate walkabout output", td.Name, fmt.Sprintf(format, args...)))
+	}
+	if typ.Kind() != reflect.Struct {
+		stale("not a struct")
+	}
+	if got := ShapeOf(typ); got != td.Shape {
+		stale("shape %#x, expected %#x", uint64(got), uint64(td.Shape))
+	}
+	if typ.Size() != td.SizeOf {
+		stale("size %d, expected %d", typ.Size(), td.SizeOf)
+	}
+	check := func(name string, offset uintptr, tag reflect.StructTag) {
+		f, ok := typ.FieldByName(name)
+		if !ok {
+			stale("missing field %s", name)
+		}
+		if f.Offset != offset {
+			stale("field %s at offset %d, expected %d", name, f.Offset, offset)
+		}
+		if f.Tag != tag {
+			stale("field %s has tag %q, expected %q", name, f.Tag, tag)
+		}
+	}
+	for _, f := range td.Fields {
+		check(fieldName(f.Name, f.Field), f.Offset, f.Tag)
+	}
+	for _, s := range td.Scalars {
+		check(fieldName(s.Name, s.Field), s.Offset, s.Tag)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// Sharing describes the extent to which a value produced by Execute
+// shares structs with the original value.
+type Sharing struct {
+	// Cloned counts the structs which were cloned or replaced.
+	Cloned int
+	// Shared counts the structs which are part of both values.
+	Shared int
+}
+
+// Sharing compares the structs reachable from after to those reachable
+// from before. A struct is shared only if the same memory is reachable
+// from both values. A TypeID of zero represents a nil value.
+func (e *Engine) Sharing(beforeType TypeID, before Ptr, afterType TypeID, after Ptr) Sharing {
+	original := make(map[cycleKey]struct{})
+	e.forEachStruct(beforeType, before, func(key cycleKey) {
+		original[key] = struct{}{}
+	})
+
+	var ret Sharing
+	e.forEachStruct(afterType, after, func(key cycleKey) {
+		if _, found := original[key]; found {
+			ret.Shared++
+		} else {
+			ret.Cloned++
+		}
+	})
+	return ret
+}
+
+// forEachStruct invokes the callback once for each struct which is
+// reachable from the given value.
+func (e *Engine) forEachStruct(t TypeID, x Ptr, fn func(cycleKey)) {
+	if t == 0 || x == nil {
+		return
+	}
+	type entry struct {
+		typeData *TypeData
+		value    Ptr
+	}
+	seen := make(map[cycleKey]struct{})
+	work := []entry{{e.typeData(t), x}}
+
+	for len(work) > 0 {
+		top := work[len(work)-1]
+		work = work[:len(work)-1]
+
+		switch top.typeData.Kind {
+		case KindStruct:
+			key := cycleKey{top.typeData.TypeID, top.value}
+			if _, found := seen[key]; found {
+				continue
+			}
+			seen[key] = struct{}{}
+			fn(key)
+			for _, f := range top.typeData.Fields {
+				work = append(work, entry{f.targetData, Ptr(uintptr(top.value) + f.Offset)})
+			}
+
+		case KindPointer:
+			if ptr := *(*Ptr)(top.value); ptr != nil {
+				work = append(work, entry{top.typeData.elemData, ptr})
+			}
+
+		case KindSlice:
+			header := (*sliceHeader)(top.value)
+			eltTd := top.typeData.elemData
+			for i, off := 0, uintptr(0); i < header.Len; i, off = i+1, off+eltTd.SizeOf {
+				work = append(work, entry{eltTd, Ptr(uintptr(header.Data) + off)})
+			}
+
+		case KindInterface:
+			ptr := (*[2]Ptr)(top.value)[1]
+			if elem := top.typeData.intfType(top.value); elem != 0 && ptr != nil {
+				work = append(work, entry{e.typeData(elem), ptr})
+			}
+		}
+	}
+}
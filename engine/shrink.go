@@ -0,0 +1,125 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import (
+	"errors"
+	"sort"
+)
+
+// Shrink replaces the value of the given type at x with a smaller value
+// for which fails still returns true, such as a minimal tree which
+// reproduces the failure of a property-based test. The value is
+// shrunk, using the form produced by ToMap, by repeatedly removing
+// elements of slices and by resetting fields to their zero values,
+// which nils out subtrees, until no single change preserves the
+// failure. Larger subtrees are tried first. The value itself is never
+// replaced with nil.
+//
+// The function is given a pointer to a newly-constructed value of the
+// given type, and must not retain it. An error is returned if the
+// original value does not fail or cannot be converted.
+func (e *Engine) Shrink(id TypeID, x Ptr, fails func(x Ptr) bool) error {
+	data, err := e.ToMap(id, x)
+	if err != nil {
+		return err
+	}
+	td := e.typeData(id)
+	try := func() bool {
+		candidate := newValue(td)
+		if err := e.FromMap(data, id, candidate); err != nil {
+			return false
+		}
+		return fails(candidate)
+	}
+	if !try() {
+		return errors.New("the value does not fail")
+	}
+
+	for progress := true; progress; {
+		progress = false
+		var edits []shrinkEdit
+		edits = appendEdits(edits, data, func(v interface{}) { data = v }, false)
+		for _, edit := range edits {
+			edit.apply()
+			if try() {
+				// The remaining edits may refer to values which have
+				// been removed, so they are collected again.
+				progress = true
+				break
+			}
+			edit.undo()
+		}
+	}
+	return e.FromMap(data, id, x)
+}
+
+// shrinkEdit is a reversible change to a value in the generic form.
+type shrinkEdit struct {
+	apply, undo func()
+}
+
+// appendEdits appends the edits which would shrink data, in pre-order.
+// The set function replaces data within its parent. The root is not
+// removable, so that the function is never given a nil value.
+func appendEdits(
+	buf []shrinkEdit, data interface{}, set func(interface{}), removable bool,
+) []shrinkEdit {
+	switch t := data.(type) {
+	case nil:
+		return buf
+
+	case map[string]interface{}:
+		if removable {
+			buf = append(buf, shrinkEdit{func() { set(nil) }, func() { set(t) }})
+		}
+		keys := make([]string, 0, len(t))
+		for key := range t {
+			if key != TypeKey {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			key, v := key, t[key]
+			buf = append(buf, shrinkEdit{func() { delete(t, key) }, func() { t[key] = v }})
+		}
+		for _, key := range keys {
+			key := key
+			buf = appendEdits(buf, t[key], func(v interface{}) { t[key] = v }, true)
+		}
+
+	case []interface{}:
+		if removable {
+			buf = append(buf, shrinkEdit{func() { set(nil) }, func() { set(t) }})
+		}
+		for i := range t {
+			i := i
+			buf = append(buf, shrinkEdit{
+				func() { set(append(append([]interface{}{}, t[:i]...), t[i+1:]...)) },
+				func() { set(t) },
+			})
+		}
+		for i := range t {
+			i := i
+			buf = appendEdits(buf, t[i], func(v interface{}) { t[i] = v }, true)
+		}
+
+	default:
+		// Scalars are reset by removing them from their struct.
+	}
+	return buf
+}
@@ -15,15 +15,96 @@
 
 package engine
 
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledSlots bounds the number of overflow slots that a pooled
+// stack may retain, so that a single visitation of a very large slice
+// does not pin that memory indefinitely.
+const maxPooledSlots = 1 << 14
+
+// stackPool allows stacks, and their frames, to be reused across
+// calls to Execute.
+var stackPool = sync.Pool{
+	New: func() interface{} { return newStack() },
+}
+
+// cycleKey identifies a value which is being visited.
+type cycleKey struct {
+	typeID TypeID
+	value  Ptr
+}
+
 type stack struct {
 	data  []frame
 	depth int
+	// Frames at or beyond this depth record their active slot in
+	// visiting, rather than being scanned by Contains.
+	threshold int
+	visiting  map[cycleKey]struct{}
+	// slots is an arena which provides the overflow slots for frames.
+	// Since frames are strictly nested, the arena is used as a stack;
+	// slotTop is the index of the first unused slot.
+	slots   []Action
+	slotTop int
+	// slotsUsed is the high-water mark of slotTop since the last Reset.
+	slotsUsed int
+	// used is the high-water mark of depth since the last Reset.
+	used int
+	// defers holds the functions passed to Context.Defer.
+	defers []ActionFn
 }
 
 func newStack() *stack {
 	return &stack{data: make([]frame, defaultStackDepth)}
 }
 
+// A Stack holds the working state of a visitation. Callers which
+// provide a Stack to ExecuteWith own it, and may reuse it across
+// visitations instead of using a pooled stack. The zero value is ready
+// to use. A Stack is not safe for concurrent use.
+type Stack struct {
+	impl stack
+}
+
+// Grow ensures that the Stack can hold visitations of the given depth
+// without allocating additional frames.
+func (s *Stack) Grow(depth int) {
+	if depth > len(s.impl.data) {
+		temp := make([]frame, depth)
+		copy(temp, s.impl.data)
+		s.impl.data = temp
+	}
+}
+
+// acquireStack returns an empty stack from the pool.
+func acquireStack() *stack {
+	s := stackPool.Get().(*stack)
+	s.prepare()
+	return s
+}
+
+// prepare configures an empty stack for a visitation.
+func (s *stack) prepare() {
+	s.threshold = int(atomic.LoadInt64(&cycleThreshold))
+	if s.threshold < 0 {
+		s.threshold = math.MaxInt32
+	}
+}
+
+// release resets the stack and returns it to the pool, unless it has
+// grown too large to retain.
+func (s *stack) release() {
+	if s.Reset() <= maxPooledSlots {
+		stackPool.Put(s)
+	}
+}
+
 // Depth returns the current stack depth.
 func (s *stack) Depth() int {
 	return s.depth
@@ -36,30 +117,149 @@ func (s *stack) Enter(intercept FacadeFn, slotCount int) *frame {
 		copy(temp, s.data)
 		s.data = temp
 	}
+	// The frame on top of the stack is about to become an ancestor.
+	if s.depth > 0 && s.depth-1 >= s.threshold {
+		if s.visiting == nil {
+			s.visiting = make(map[cycleKey]struct{})
+		}
+		s.visiting[s.data[s.depth-1].activeKey()] = struct{}{}
+	}
 	entering := &s.data[s.depth]
 	s.depth++
+	if s.depth > s.used {
+		s.used = s.depth
+	}
 
 	entering.Count = slotCount
 	entering.Intercept = intercept
 	entering.Idx = 0
-	if slotCount > fixedSlotCount {
-		entering.Overflow = make([]Action, slotCount-fixedSlotCount)
+	entering.actions = false
+	entering.Overflow = s.allocSlots(slotCount - fixedSlotCount)
+	entering.sliceData = nil
+	entering.sliceClone = nil
+	// Every slot must be set by the caller before it is visited.
+	if debugEnabled {
+		debugPoison(entering)
 	}
 	return entering
 }
 
-// Peek retrieves the frame at the given depth.
-func (s *stack) Peek(depth int) *frame {
-	return &s.data[depth]
+// allocSlots returns overflow slots from the arena, which will be
+// released when the frame is popped. Every slot will be overwritten
+// by the caller, so they are not cleared here.
+func (s *stack) allocSlots(n int) []Action {
+	if n <= 0 {
+		return nil
+	}
+	end := s.slotTop + n
+	if end > len(s.slots) {
+		// Frames which are already on the stack retain the previous
+		// arena, so we only need to allocate a replacement.
+		size := len(s.slots) * 2
+		if size < end {
+			size = end
+		}
+		s.slots = make([]Action, size)
+	}
+	ret := s.slots[s.slotTop:end:end]
+	s.slotTop = end
+	if end > s.slotsUsed {
+		s.slotsUsed = end
+	}
+	return ret
+}
+
+// Contains returns true if the frames beneath the top of the stack
+// are already visiting the value in the slot. Shallow frames are
+// scanned linearly, which pprof says is much faster than using a map
+// for the stack depths that we usually expect. Deeper frames are found
+// using a hash lookup.
+func (s *stack) Contains(slot *Action) bool {
+	n := s.depth - 1
+	if n > s.threshold {
+		n = s.threshold
+	}
+	for l := 0; l < n; l++ {
+		onStack := s.data[l].Active()
+		if onStack.value == slot.value && onStack.typeData.TypeID == slot.typeData.TypeID {
+			return true
+		}
+	}
+	if s.depth-1 > s.threshold {
+		_, found := s.visiting[cycleKey{slot.typeData.TypeID, slot.value}]
+		return found
+	}
+	return false
 }
 
 // Pop removes and returns the top frame.
 func (s *stack) Pop() *frame {
 	s.depth--
-	return &s.data[s.depth]
+	ret := &s.data[s.depth]
+	// The frame beneath is no longer an ancestor.
+	if s.depth > 0 && s.depth-1 >= s.threshold {
+		delete(s.visiting, s.data[s.depth-1].activeKey())
+	}
+	// The slots remain readable until the next call to Enter. The
+	// frame which was previously popped is no longer readable.
+	s.slotTop -= len(ret.Overflow)
+	if debugEnabled && s.depth+1 < s.used {
+		debugPoison(&s.data[s.depth+1])
+	}
+	return ret
+}
+
+// Path describes the location of the active slot of the top frame.
+// See Context.Path.
+func (s *stack) Path() string {
+	if s.depth == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(s.data[0].Active().typeData.Name)
+	for l := 1; l < s.depth; l++ {
+		f := &s.data[l]
+		parent := s.data[l-1].Active().typeData
+		switch {
+		case f.actions:
+			fmt.Fprintf(&sb, "/action[%d]", f.Idx)
+		case parent.Kind == KindStruct:
+			sb.WriteString("/")
+			sb.WriteString(parent.Fields[f.Idx].Name)
+		case parent.Kind == KindSlice:
+			fmt.Fprintf(&sb, "[%d]", f.sliceOffset+f.Idx)
+		}
+	}
+	return sb.String()
 }
 
 // Top access the Nth frame from the top of the stack.
 func (s *stack) Top(offset int) *frame {
 	return &s.data[s.depth-1-offset]
 }
+
+// Reset empties the stack and clears all frames and slots which have
+// been used, so that a pooled stack does not retain references to
+// visited values. It returns the number of overflow slots that are
+// retained for reuse.
+func (s *stack) Reset() int {
+	for i := 0; i < s.used; i++ {
+		s.data[i] = frame{}
+	}
+	used := s.slots[:s.slotsUsed]
+	for i := range used {
+		used[i] = Action{}
+	}
+	for k := range s.visiting {
+		delete(s.visiting, k)
+	}
+	for i := range s.defers {
+		s.defers[i] = nil
+	}
+	s.defers = s.defers[:0]
+	s.depth = 0
+	s.slotTop = 0
+	s.slotsUsed = 0
+	s.used = 0
+	return len(s.slots)
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+import "context"
+
+// Tracer opens spans around walks. It allows tracing libraries, such
+// as OpenTelemetry, to be used without the engine depending upon
+// them. For example:
+//
+//	func (t *otelTracer) Start(ctx context.Context, name string) (context.Context, engine.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		return ctx, &otelSpan{span}
+//	}
+//
+//	func (s *otelSpan) End(visits int64, err error) {
+//		s.span.SetAttributes(attribute.Int64("walkabout.visits", visits))
+//		if err != nil {
+//			s.span.RecordError(err)
+//		}
+//		s.span.End()
+//	}
+type Tracer interface {
+	// Start is called when a walk, or a subtree of a walk, begins. The
+	// context is Options.Context for the span of a walk, or the context
+	// returned by the Start call for the enclosing span. The name is
+	// that of the type being visited. Start may return a nil Span to
+	// skip the span, in which case the returned context is ignored.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is returned from Tracer.Start.
+type Span interface {
+	// End is called once the walk or subtree has been visited, with
+	// the number of structs which were passed to the user's function.
+	// The error is that returned from the walk, which may have
+	// occurred outside of the subtree.
+	End(visits int64, err error)
+}
+
+// Tracing configures the spans opened by the engine for a walk, when
+// it is set in Options.
+type Tracing struct {
+	Tracer Tracer
+	// If non-zero, a span will also be opened for each struct whose
+	// depth in the engine's stack is at most this value. The top-level
+	// value has a depth of zero and is covered by the span of the walk.
+	// Fields, pointers, slices, and interfaces each add a level of
+	// depth.
+	SubtreeDepth int
+}
+
+// openSpan records a subtree span which has not yet ended.
+type openSpan struct {
+	ctx  context.Context
+	span Span
+	// The position of the struct's slot in the stack.
+	depth, idx int
+	// The number of visits before the subtree was entered.
+	visits int64
+}
+
+// start opens a span for the walk.
+func (t *Tracing) start(ctx context.Context, e *Engine, id TypeID) openSpan {
+	if t.Tracer == nil {
+		return openSpan{}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	next, span := t.Tracer.Start(ctx, e.Stringify(id))
+	if span == nil {
+		return openSpan{ctx: ctx}
+	}
+	return openSpan{ctx: next, span: span}
+}
+
+// enter opens a span for a subtree, if the struct is shallow enough.
+func (t *Tracing) enter(
+	e *Engine, spans []openSpan, root openSpan, id TypeID, depth, idx int, visits int64,
+) []openSpan {
+	if t.Tracer == nil || depth == 0 || depth > t.SubtreeDepth {
+		return spans
+	}
+	parent := root
+	if len(spans) > 0 {
+		parent = spans[len(spans)-1]
+	}
+	if parent.ctx == nil {
+		parent.ctx = context.Background()
+	}
+	ctx, span := t.Tracer.Start(parent.ctx, e.Stringify(id))
+	if span == nil {
+		return spans
+	}
+	return append(spans, openSpan{ctx: ctx, span: span, depth: depth, idx: idx, visits: visits})
+}
+
+// exit ends the span for a subtree, if the slot opened one.
+func exit(spans []openSpan, depth, idx int, visits int64) []openSpan {
+	if n := len(spans); n > 0 && spans[n-1].depth == depth && spans[n-1].idx == idx {
+		spans[n-1].span.End(visits-spans[n-1].visits, nil)
+		return spans[:n-1]
+	}
+	return spans
+}
+
+// finish ends any spans which remain open at the end of a walk.
+func finish(spans []openSpan, root openSpan, visits int64, err error) {
+	for i := len(spans) - 1; i >= 0; i-- {
+		spans[i].span.End(visits-spans[i].visits, err)
+	}
+	if root.span != nil {
+		root.span.End(visits, err)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains generic facades which may be used by generated
+// code in place of generating equivalent types for each visitable
+// interface. The type parameter R is the visitable interface and I is
+// a generated, zero-sized type which identifies values of R.
+
+// Identifier is implemented by generated code to map a value of the
+// visitable interface R into its type id and a pointer to the data.
+type Identifier[R any] interface {
+	Identify(x R) (TypeID, Ptr)
+}
+
+// TypedFacade is used by generated TypeData.Facade functions to call a
+// TypedWalkerFn.
+func TypedFacade[R any, I Identifier[R]](impl Context, fn FacadeFn, x R) Decision {
+	return Decision(fn.(TypedWalkerFn[R, I])(TypedContext[R, I]{impl}, x))
+}
+
+// NewTypedContext is used by generated walkers to call a TypedWalkerFn
+// for a value which is not being visited by the engine.
+func NewTypedContext[R any, I Identifier[R]](impl Context) TypedContext[R, I] {
+	return TypedContext[R, I]{impl}
+}
+
+// TypedWalkerFn is used to implement a visitor pattern over types
+// which implement R.
+//
+// Implementations of this function return a TypedDecision, which
+// allows the function to control traversal. The zero value of
+// TypedDecision means "continue". Other values can be obtained from
+// the provided TypedContext to stop or to return an error.
+//
+// A TypedDecision can also specify a post-visit function to execute
+// or can be used to replace the value being visited.
+type TypedWalkerFn[R any, I Identifier[R]] func(ctx TypedContext[R, I], x R) TypedDecision[R, I]
+
+// TypedContext is provided to TypedWalkerFn and acts as a factory for
+// constructing TypedDecision instances.
+type TypedContext[R any, I Identifier[R]] struct {
+	impl Context
+}
+
+// Abstract returns an accessor for the value being visited, which
+// records the value's Path, as do its children. It returns nil if the
+// value is not being visited by the engine.
+func (c *TypedContext[R, I]) Abstract() *Abstract {
+	return c.impl.Abstract()
+}
+
+// Actions will perform the given actions in place of visiting values
+// that would normally be visited. This allows callers to control
+// specific field visitation order or to insert additional callbacks
+// between visiting certain values.
+func (c *TypedContext[R, I]) Actions(actions ...TypedAction[R, I]) TypedDecision[R, I] {
+	if len(actions) == 0 {
+		return c.Skip()
+	}
+
+	ret := make([]Action, len(actions))
+	for i, a := range actions {
+		ret[i] = Action(a)
+	}
+
+	return TypedDecision[R, I](c.impl.Actions(ret))
+}
+
+// Continue returns the zero-value of TypedDecision. It exists only for
+// cases where it improves the readability of code.
+func (c *TypedContext[R, I]) Continue() TypedDecision[R, I] {
+	return TypedDecision[R, I](c.impl.Continue())
+}
+
+// Defer registers a function to be called after the walk completes,
+// even if it is halted or fails. Functions are called in the reverse
+// order of their registration. If the walk succeeds, the first error
+// returned by a deferred function is returned from the walk.
+func (c *TypedContext[R, I]) Defer(fn func() error) {
+	c.impl.Defer(fn)
+}
+
+// Error returns a TypedDecision which will cause the given error to be
+// returned from the Walk() function. Post-visit functions will not be
+// called.
+func (c *TypedContext[R, I]) Error(err error) TypedDecision[R, I] {
+	return TypedDecision[R, I](c.impl.Error(err))
+}
+
+// Halt will end a visitation early and return from the Walk() function.
+// Any registered post-visit functions will be called.
+func (c *TypedContext[R, I]) Halt() TypedDecision[R, I] {
+	return TypedDecision[R, I](c.impl.Halt())
+}
+
+// Path returns the location of the current object, e.g.
+// "Parent/Slice[1]/Field".
+func (c *TypedContext[R, I]) Path() string {
+	return c.impl.Path()
+}
+
+// Skip will not traverse the fields of the current object.
+func (c *TypedContext[R, I]) Skip() TypedDecision[R, I] {
+	return TypedDecision[R, I](c.impl.Skip())
+}
+
+// ActionVisit constructs a TypedAction that will visit the given value.
+func (c *TypedContext[R, I]) ActionVisit(x R) TypedAction[R, I] {
+	var id I
+	return TypedAction[R, I](c.impl.ActionVisitTypeID(id.Identify(x)))
+}
+
+// ActionCall constructs a TypedAction that will invoke the given
+// callback.
+func (c *TypedContext[R, I]) ActionCall(fn func() error) TypedAction[R, I] {
+	return TypedAction[R, I](c.impl.ActionCall(fn))
+}
+
+// TypedDecision is used by TypedWalkerFn to control visitation. The
+// TypedContext provided to a TypedWalkerFn acts as a factory for
+// TypedDecision instances. In general, the factory methods choose a
+// traversal strategy and additional methods on the TypedDecision can
+// achieve a variety of side-effects.
+type TypedDecision[R any, I Identifier[R]] Decision
+
+// Intercept registers a function to be called immediately before
+// visiting each field or element of the current value.
+func (d TypedDecision[R, I]) Intercept(fn TypedWalkerFn[R, I]) TypedDecision[R, I] {
+	return TypedDecision[R, I](Decision(d).Intercept(fn))
+}
+
+// Post registers a post-visit function, which will be called after the
+// fields of the current object. The function can make another decision
+// about the current value.
+func (d TypedDecision[R, I]) Post(fn TypedWalkerFn[R, I]) TypedDecision[R, I] {
+	return TypedDecision[R, I](Decision(d).Post(fn))
+}
+
+// Replace allows the currently-visited value to be replaced. All
+// parent nodes will be cloned.
+func (d TypedDecision[R, I]) Replace(x R) TypedDecision[R, I] {
+	var id I
+	return TypedDecision[R, I](Decision(d).Replace(id.Identify(x)))
+}
+
+// TypedAction is used by TypedContext.Actions() and allows users to
+// have fine-grained control over traversal.
+type TypedAction[R any, I Identifier[R]] Action
@@ -18,8 +18,8 @@ package engine
 // This file contains various type definitions.
 
 import (
-	"errors"
 	"fmt"
+	"reflect"
 	"unsafe"
 )
 
@@ -44,6 +44,21 @@ const (
 	KindStruct
 )
 
+var kindNames = [...]string{
+	KindInterface: "interface",
+	KindPointer:   "pointer",
+	KindSlice:     "slice",
+	KindStruct:    "struct",
+}
+
+// String returns a human-readable name for the kind.
+func (k Kind) String() string {
+	if k <= 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
 // ActionFn describes a simple callback function.
 type ActionFn func() error
 
@@ -54,11 +69,24 @@ type FacadeFn interface{}
 // Ptr is an alias for unsafe.Pointer.
 type Ptr unsafe.Pointer
 
+// sliceHeader mirrors the runtime representation of a slice. Unlike
+// reflect.SliceHeader, the data pointer is kept as a Ptr so that the
+// garbage collector can see it.
+type sliceHeader struct {
+	Data Ptr
+	Len  int
+	Cap  int
+}
+
 // TypeData contains metadata and accessors that are produced by the
 // code generator.
 type TypeData struct {
 	// Copy will effect a type aware copy of the data at from to dest.
 	Copy func(dest, from Ptr)
+	// Equal is optional and reports whether two values of a struct
+	// type are equivalent. A replacement which is equal to the
+	// original value will not cause the enclosing values to be cloned.
+	Equal func(a, b Ptr) bool
 	// Elem is the element type of a slice or of a pointer.
 	Elem TypeID
 	// Facade will call a user-provided facade function in a
@@ -74,42 +102,213 @@ type TypeData struct {
 	// to handle ourselves. Instead, we generate functions which
 	// will perform the necessary type mapping.
 	IntfType func(Ptr) TypeID
+	// IntfImpls is optional and lists the implementations of an
+	// interface type. If present, the engine will find the TypeID of
+	// a value in the interface with a map lookup, keyed by the
+	// interface's type-tag, before falling back to IntfType. This is
+	// much faster than a type-switch for large interfaces.
+	IntfImpls []IntfImpl
 	// IntfWrap provides the opposite function of IntfType. It accepts
 	// a TypeID and a pointer to the interface's value and returns a
 	// pointer to the resulting interface array.
 	IntfWrap func(TypeID, Ptr) Ptr
+	// Impls is optional and lists the TypeIDs of the structs which
+	// implement an interface type. It allows walks restricted by
+	// Options.Only to skip interfaces which cannot contain a wanted
+	// type.
+	Impls []TypeID
 	// Kind selects various strategies for handling the given type.
 	Kind Kind
 	// Name is the source name of the type.
 	Name string
+	// PkgPath is the import path of the package which declares a
+	// struct or interface type. It is used by StringifyQualified.
+	PkgPath string
+	// Scalars holds information about the fields of a struct which are
+	// not visitable, but which have a basic type. These are used by
+	// Encode and Decode.
+	Scalars []ScalarInfo
 	// NewSlice constructs a slice of the given length and returns a
 	// pointer to the slice's header.
 	NewSlice func(size int) Ptr
 	// NewStruct returns a pointer to a newly-allocated struct.
 	NewStruct func() Ptr
+	// NewStructs returns a pointer to the first of a newly-allocated
+	// array of structs.
+	NewStructs func(count int) Ptr
+	// Shape is the Shape of a struct type when the code was generated.
+	// It is checked by New if Type is set.
+	Shape Shape
 	// SizeOf is the size of the data type. This is used for traversing
 	// slices. It could be expanded in the future to generalizing the
 	// Copy() function.
 	SizeOf uintptr
+	// Type is optional and is the type of a struct. If set, New will
+	// verify that the struct's Shape, size, and field offsets match
+	// those which were generated.
+	Type reflect.Type
 	// TypeID is a generated id.
 	TypeID TypeID
 
-	// This field is populated when an Engine is constructed.
+	// These fields are populated when an Engine is constructed.
 	elemData *TypeData
+	intfMap  map[Ptr]TypeID
+}
+
+// IntfImpl describes an implementation of an interface type.
+type IntfImpl struct {
+	// Sample points to a value of the interface type which holds a
+	// value of the implementing type.
+	Sample Ptr
+	// TypeID is the engine's type token for the implementing type.
+	TypeID TypeID
+}
+
+// intfType returns the TypeID of the value in the interface pointed to
+// by x.
+func (td *TypeData) intfType(x Ptr) TypeID {
+	if td.intfMap != nil {
+		if id, ok := td.intfMap[(*[2]Ptr)(x)[0]]; ok {
+			return id
+		}
+	}
+	return td.IntfType(x)
 }
 
 // FieldInfo describes a field within a struct.
 type FieldInfo struct {
-	Name   string
+	// Name is used in paths, queries, and the serialized forms of the
+	// struct. It is the name of the field, unless the field has a
+	// `walkabout:"name=N"` tag.
+	Name string
+	// Field is the name of the field in the struct, if it differs from
+	// Name.
+	Field  string
 	Offset uintptr
+	// Tag is the complete tag of the field, which allows code built on
+	// the engine to honor its own tag conventions.
+	Tag    reflect.StructTag
 	Target TypeID
 
 	// This field is populated when an Engine is constructed.
 	targetData *TypeData
 }
 
+// ScalarKind identifies the basic type of a struct field which is not
+// visitable.
+type ScalarKind int
+
+// The basic types which may be encoded.
+const (
+	_ ScalarKind = iota
+	ScalarBool
+	ScalarInt
+	ScalarInt8
+	ScalarInt16
+	ScalarInt32
+	ScalarInt64
+	ScalarUint
+	ScalarUint8
+	ScalarUint16
+	ScalarUint32
+	ScalarUint64
+	ScalarUintptr
+	ScalarFloat32
+	ScalarFloat64
+	ScalarString
+	ScalarBytes
+)
+
+var scalarNames = [...]string{
+	ScalarBool:    "bool",
+	ScalarInt:     "int",
+	ScalarInt8:    "int8",
+	ScalarInt16:   "int16",
+	ScalarInt32:   "int32",
+	ScalarInt64:   "int64",
+	ScalarUint:    "uint",
+	ScalarUint8:   "uint8",
+	ScalarUint16:  "uint16",
+	ScalarUint32:  "uint32",
+	ScalarUint64:  "uint64",
+	ScalarUintptr: "uintptr",
+	ScalarFloat32: "float32",
+	ScalarFloat64: "float64",
+	ScalarString:  "string",
+	ScalarBytes:   "[]byte",
+}
+
+// String returns the name of the basic type.
+func (k ScalarKind) String() string {
+	if k <= 0 || int(k) >= len(scalarNames) {
+		return fmt.Sprintf("ScalarKind(%d)", int(k))
+	}
+	return scalarNames[k]
+}
+
+// ScalarInfo describes a field within a struct which has a basic type.
+type ScalarInfo struct {
+	Kind ScalarKind
+	// Name is used in the serialized forms of the struct, like
+	// FieldInfo.Name.
+	Name string
+	// Field is the name of the field in the struct, if it differs from
+	// Name.
+	Field  string
+	Offset uintptr
+	// Tag is the complete tag of the field, like FieldInfo.Tag.
+	Tag reflect.StructTag
+}
+
 // Context is provided to generated, type-safe facades.
-type Context struct{}
+type Context struct {
+	engine *Engine
+	scope  *Scope
+	stack  *stack
+}
+
+// Path returns the location of the value being visited, relative to
+// the value passed to Execute, using the same syntax as a Query, e.g.
+// "ContainerType/TargetSlice[1]/ByRef". An element of a Decision's
+// actions is shown as "action[n]". The path is empty if the value is
+// not being visited by the engine.
+func (c Context) Path() string {
+	if c.stack == nil {
+		return ""
+	}
+	return c.stack.Path()
+}
+
+// Abstract returns an accessor for the value being visited, which
+// records the value's Path, as do its children. It returns nil if the
+// value is not being visited by the engine.
+func (c Context) Abstract() *Abstract {
+	if c.stack == nil || c.stack.depth == 0 {
+		return nil
+	}
+	slot := c.stack.Top(0).Active()
+	return &Abstract{
+		engine:   c.engine,
+		typeData: slot.typeData,
+		value:    slot.value,
+		path:     c.stack.Path(),
+	}
+}
+
+// Defer registers a function to be called after the walk completes,
+// even if it is halted or fails. Functions are called in the reverse
+// order of their registration. If the walk succeeds, the first error
+// returned by a deferred function is returned from the walk.
+func (c Context) Defer(fn ActionFn) {
+	switch {
+	case c.scope != nil:
+		c.scope.defers = append(c.scope.defers, fn)
+	case c.stack != nil:
+		c.stack.defers = append(c.stack.defers, fn)
+	default:
+		panic("Defer called outside of a walk")
+	}
+}
 
 // ActionCall constructs an action which will invoke the function.
 func (Context) ActionCall(fn ActionFn) Action {
@@ -169,6 +368,24 @@ type Decision struct {
 	skip            bool
 }
 
+// isZero returns true if the decision is equivalent to Continue(),
+// which allows the engine to skip applying it.
+func (d *Decision) isZero() bool {
+	return d.actions == nil && d.error == nil && !d.halt && d.intercept == nil &&
+		d.post == nil && d.replacement == nil && !d.skip
+}
+
+// Inline is for use by generated code only. It returns false if the
+// decision must be handled by the engine, otherwise it reports
+// whether the decision was to skip or to halt.
+func (d Decision) Inline() (skip, halt, ok bool) {
+	if d.actions != nil || d.error != nil || d.intercept != nil ||
+		d.post != nil || d.replacement != nil {
+		return false, false, false
+	}
+	return d.skip, d.halt, true
+}
+
 // Intercept is for use by generated code only.
 func (d Decision) Intercept(fn FacadeFn) Decision {
 	d.intercept = fn
@@ -193,46 +410,76 @@ func (d Decision) Replace(id TypeID, x Ptr) Decision {
 type Action struct {
 	assignableTo *TypeData
 	call         ActionFn
-	dirty        bool
-	post         FacadeFn
-	replaced     bool
-	typeData     *TypeData
-	value        Ptr
-	valueType    TypeID
+	// childDirty is set if the value's children have changed and must
+	// be copied into a replacement for the value.
+	childDirty bool
+	dirty      bool
+	post       FacadeFn
+	replaced   bool
+	typeData   *TypeData
+	value      Ptr
+	valueType  TypeID
 }
 
-// apply updates the action with information from a decision.
-func (a *Action) apply(e *Engine, d Decision) error {
+// apply updates the action with information from a decision and
+// reports whether the value was replaced. A replacement which cannot be
+// stored in the action's location is reported as a *ReplacementError.
+func (a *Action) apply(e *Engine, s *stack, d Decision) (replaced bool, err error) {
 	if d.error != nil {
-		return d.error
+		return false, &PathError{Path: s.Path(), Err: d.error}
 	}
 	if d.post != nil {
 		a.post = d.post
 	}
 	if d.replacement != nil {
+		if debugEnabled {
+			e.debugCheckReplacement(s, &d)
+		}
+		if a.identical(d.replacementType, d.replacement) {
+			return false, nil
+		}
 		if a.assignableTo == nil {
-			return errors.New("this value cannot be replaced")
+			return false, e.replacementError(s, nil, d.replacementType)
 		}
 		if a.typeData.TypeID != d.replacementType {
 			// The user can only change the type of the object if it's being
 			// assigned to an interface slot. Even then, we'll want to
 			// check the assignability.
-			if a.assignableTo.Kind == KindInterface {
-				if a.assignableTo.IntfWrap(d.replacementType, d.replacement) == nil {
-					return fmt.Errorf(
-						"type %s is unknown or not assignable to %s",
-						e.Stringify(d.replacementType), e.Stringify(a.assignableTo.TypeID))
-				}
-				a.typeData = e.typeData(d.replacementType)
-			} else {
-				return fmt.Errorf(
-					"cannot change type of %s to %s",
-					e.Stringify(a.assignableTo.TypeID), e.Stringify(d.replacementType))
+			if a.assignableTo.Kind != KindInterface ||
+				a.assignableTo.IntfWrap(d.replacementType, d.replacement) == nil {
+				return false, e.replacementError(s, a.assignableTo, d.replacementType)
 			}
+			a.typeData = e.typeData(d.replacementType)
 		}
 		a.dirty = true
 		a.replaced = true
 		a.value = d.replacement
+		return true, nil
+	}
+	return false, nil
+}
+
+// fieldCount returns the number of fields of the struct in the slot.
+// The fields of a typed nil cannot be visited.
+func (a *Action) fieldCount() int {
+	if a.value == nil {
+		return 0
+	}
+	return len(a.typeData.Fields)
+}
+
+// identical returns true if the replacement is the same as, or is
+// equal to, the value in an otherwise-unmodified slot.
+func (a *Action) identical(id TypeID, x Ptr) bool {
+	if a.dirty || a.typeData.TypeID != id {
+		return false
+	}
+	if a.value == x {
+		return true
+	}
+	// A typed nil is not equal to any replacement.
+	if a.value == nil {
+		return false
 	}
-	return nil
+	return a.typeData.Equal != nil && a.typeData.Equal(a.value, x)
 }
@@ -0,0 +1,209 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package engine
+
+// This file contains support for traversing two values in lockstep.
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// ZipFn is called by Zip with each pair of structs which occupy the
+// same location in two values. If only one of the values has a struct
+// at the location, the other pointer is nil and its TypeID is zero. The
+// path uses the same syntax as Context.Path. The function returns true
+// to visit the fields of the pair, which only happens if both structs
+// are present and have the same type.
+type ZipFn func(path string, aType TypeID, a Ptr, bType TypeID, b Ptr) (descend bool, err error)
+
+// zipEntry is a location which exists in at least one of the values
+// being zipped. The locations have the same type.
+type zipEntry struct {
+	path     string
+	typeData *TypeData
+	a, b     Ptr
+}
+
+// zipPair identifies a pair of structs which has been visited, to
+// break cycles.
+type zipPair struct {
+	a, b cycleKey
+}
+
+// zipper holds the state of a call to Zip.
+type zipper struct {
+	engine *Engine
+	fn     ZipFn
+	// The pairs of structs which have been visited, to break cycles.
+	seen map[zipPair]struct{}
+	// The locations which remain to be visited.
+	work []zipEntry
+}
+
+// Zip traverses two values in lockstep, in the same order as Execute,
+// and calls fn with each pair of corresponding structs. Elements of
+// slices are paired by index. Each pair is visited at most once. Zip
+// stops and returns the first error returned by fn.
+func (e *Engine) Zip(aType TypeID, a Ptr, bType TypeID, b Ptr, fn ZipFn) error {
+	var aData, bData *TypeData
+	if a != nil {
+		aData = e.typeData(aType)
+	}
+	if b != nil {
+		bData = e.typeData(bType)
+	}
+	path := ""
+	if aData != nil {
+		path = aData.Name
+	} else if bData != nil {
+		path = bData.Name
+	}
+
+	z := &zipper{engine: e, fn: fn, seen: make(map[zipPair]struct{})}
+	if err := z.visit(path, aData, a, bData, b); err != nil {
+		return err
+	}
+	return z.run()
+}
+
+// visit calls fn with a pair of structs, either of which may be nil,
+// and queues the fields of the pair.
+func (z *zipper) visit(path string, aData *TypeData, a Ptr, bData *TypeData, b Ptr) error {
+	if a == nil && b == nil {
+		return nil
+	}
+	var aID, bID TypeID
+	if a != nil {
+		aID = aData.TypeID
+	}
+	if b != nil {
+		bID = bData.TypeID
+	}
+	descend, err := z.fn(path, aID, a, bID, b)
+	if err != nil || !descend || aID == 0 || aID != bID {
+		return err
+	}
+	key := zipPair{cycleKey{aID, a}, cycleKey{bID, b}}
+	if _, found := z.seen[key]; found {
+		return nil
+	}
+	z.seen[key] = struct{}{}
+	// Fields are pushed in reverse, so that they are visited in order.
+	for i := len(aData.Fields) - 1; i >= 0; i-- {
+		f := &aData.Fields[i]
+		z.work = append(z.work, zipEntry{
+			path:     path + "/" + f.Name,
+			typeData: f.targetData,
+			a:        Ptr(uintptr(a) + f.Offset),
+			b:        Ptr(uintptr(b) + f.Offset),
+		})
+	}
+	return nil
+}
+
+// run visits the queued locations until none remain.
+func (z *zipper) run() error {
+	for len(z.work) > 0 {
+		top := z.work[len(z.work)-1]
+		z.work = z.work[:len(z.work)-1]
+
+		switch top.typeData.Kind {
+		case KindStruct:
+			if err := z.visit(top.path, top.typeData, top.a, top.typeData, top.b); err != nil {
+				return err
+			}
+
+		case KindPointer:
+			var a, b Ptr
+			if top.a != nil {
+				a = *(*Ptr)(top.a)
+			}
+			if top.b != nil {
+				b = *(*Ptr)(top.b)
+			}
+			if a != nil || b != nil {
+				z.work = append(z.work, zipEntry{top.path, top.typeData.elemData, a, b})
+			}
+
+		case KindSlice:
+			var a, b sliceHeader
+			if top.a != nil {
+				a = *(*sliceHeader)(top.a)
+			}
+			if top.b != nil {
+				b = *(*sliceHeader)(top.b)
+			}
+			eltTd := top.typeData.elemData
+			for i := max(a.Len, b.Len) - 1; i >= 0; i-- {
+				entry := zipEntry{
+					path:     top.path + "[" + strconv.Itoa(i) + "]",
+					typeData: eltTd,
+				}
+				if i < a.Len {
+					entry.a = Ptr(uintptr(a.Data) + uintptr(i)*eltTd.SizeOf)
+				}
+				if i < b.Len {
+					entry.b = Ptr(uintptr(b.Data) + uintptr(i)*eltTd.SizeOf)
+				}
+				z.work = append(z.work, entry)
+			}
+
+		case KindInterface:
+			// The values may hold structs of different types. Typed nils
+			// are treated as missing.
+			var aData, bData *TypeData
+			var a, b Ptr
+			if top.a != nil {
+				if id := top.typeData.intfType(top.a); id != 0 {
+					aData, a = z.engine.typeData(id), (*[2]Ptr)(top.a)[1]
+				}
+			}
+			if top.b != nil {
+				if id := top.typeData.intfType(top.b); id != 0 {
+					bData, b = z.engine.typeData(id), (*[2]Ptr)(top.b)[1]
+				}
+			}
+			if err := z.visit(top.path, aData, a, bData, b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ShallowEqual reports whether two structs of the given type have
+// equal basic fields, as described by the TypeMap's Scalars. Visitable
+// fields and fields of other types are not compared.
+func (e *Engine) ShallowEqual(id TypeID, a, b Ptr) bool {
+	for _, si := range e.typeData(id).Scalars {
+		if !scalarEqual(si.Kind, Ptr(uintptr(a)+si.Offset), Ptr(uintptr(b)+si.Offset)) {
+			return false
+		}
+	}
+	return true
+}
+
+// scalarEqual reports whether two basic values of the given kind are
+// equal.
+func scalarEqual(kind ScalarKind, a, b Ptr) bool {
+	x := scalarValue(kind, a)
+	y := scalarValue(kind, b)
+	if kind == ScalarBytes {
+		return bytes.Equal(x.([]byte), y.([]byte))
+	}
+	return x == y
+}
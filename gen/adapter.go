@@ -0,0 +1,146 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"go/types"
+
+	"github.com/pkg/errors"
+)
+
+// adapter describes an existing visitor interface in the package, to
+// which a generated WalkerFn will delegate. The interface must have a
+// VisitPre method, a VisitPost method, or both, with one of these
+// signatures:
+//
+//	VisitPre(x Root) (recurse bool)
+//	VisitPre(x Root) (recurse bool, replacement Root)
+//	VisitPost(x Root)
+//	VisitPost(x Root) (replacement Root)
+type adapter struct {
+	Name string
+	// Pre is true if the interface has a VisitPre method.
+	Pre bool
+	// PreReplaces is true if VisitPre returns a replacement value.
+	PreReplaces bool
+	// Post is true if the interface has a VisitPost method.
+	Post bool
+	// PostReplaces is true if VisitPost returns a replacement value.
+	PostReplaces bool
+}
+
+// String is codegen-safe.
+func (a adapter) String() string {
+	return a.Name
+}
+
+// findAdapters resolves the visitor interfaces named by the
+// configuration.
+func (v *visitation) findAdapters() error {
+	for _, name := range v.gen.Adapters {
+		var obj *types.TypeName
+		for _, scope := range v.scopes {
+			if found, ok := scope.Lookup(name).(*types.TypeName); ok {
+				obj = found
+				break
+			}
+		}
+		if obj == nil {
+			return unknownType(name, v.scopes)
+		}
+		intf, ok := obj.Type().Underlying().(*types.Interface)
+		if !ok {
+			return errors.Errorf("adapter %s is not an interface", name)
+		}
+
+		// When generating several roots, each adapter is generated for
+		// the roots which it accepts.
+		if v.gen.otherRoots && !v.acceptsRoot(intf) {
+			continue
+		}
+
+		a := adapter{Name: name}
+		for i, j := 0, intf.NumMethods(); i < j; i++ {
+			m := intf.Method(i)
+			sig := m.Type().(*types.Signature)
+			switch m.Name() {
+			case "VisitPre":
+				if !v.isRootParam(sig) {
+					return errors.Errorf("%s.VisitPre must accept a single %s", name, v.Root)
+				}
+				res := sig.Results()
+				if res.Len() < 1 || res.Len() > 2 || !isBool(res.At(0).Type()) ||
+					(res.Len() == 2 && !v.isRoot(res.At(1).Type())) {
+					return errors.Errorf("%s.VisitPre must return (bool) or (bool, %s)", name, v.Root)
+				}
+				a.Pre = true
+				a.PreReplaces = res.Len() == 2
+			case "VisitPost":
+				if !v.isRootParam(sig) {
+					return errors.Errorf("%s.VisitPost must accept a single %s", name, v.Root)
+				}
+				res := sig.Results()
+				if res.Len() > 1 || (res.Len() == 1 && !v.isRoot(res.At(0).Type())) {
+					return errors.Errorf("%s.VisitPost must return nothing or a %s", name, v.Root)
+				}
+				a.Post = true
+				a.PostReplaces = res.Len() == 1
+			}
+		}
+		if !a.Pre && !a.Post {
+			return errors.Errorf("adapter %s has neither a VisitPre nor a VisitPost method", name)
+		}
+		v.gen.logf(Verbose, "generating an adapter for %s", name)
+		v.adapters = append(v.adapters, a)
+	}
+	return nil
+}
+
+// isRoot returns true if the type is the root visitable interface.
+func (v *visitation) isRoot(typ types.Type) bool {
+	found, ok := v.visitableType(typ, false)
+	return ok && v.typeID(found) == v.typeID(v.Root)
+}
+
+// isRootParam returns true if the signature accepts only the root
+// visitable interface.
+func (v *visitation) isRootParam(sig *types.Signature) bool {
+	return sig.Params().Len() == 1 && !sig.Variadic() && v.isRoot(sig.Params().At(0).Type())
+}
+
+// acceptsRoot returns true if the VisitPre or VisitPost method of a
+// visitor interface accepts the root visitable interface.
+func (v *visitation) acceptsRoot(intf *types.Interface) bool {
+	for i, j := 0, intf.NumMethods(); i < j; i++ {
+		m := intf.Method(i)
+		if (m.Name() == "VisitPre" || m.Name() == "VisitPost") &&
+			v.isRootParam(m.Type().(*types.Signature)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBool returns true if the type is a bool.
+func isBool(typ types.Type) bool {
+	return types.Identical(typ, types.Typ[types.Bool])
+}
+
+// Adapters returns the visitor interfaces for which adapters will be
+// generated.
+func (v *visitation) Adapters() []adapter {
+	return v.adapters
+}
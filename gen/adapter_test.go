@@ -0,0 +1,78 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdapters(t *testing.T) {
+	extra, err := filepath.Abs("../demo/adapter_extra.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	const src = `package demo
+type PreOnly interface { VisitPre(Target) bool }
+type PostOnly interface { VisitPost(Target) }
+type Neither interface { Visit(Target) }
+type BadPre interface { VisitPre(Target) (bool, error) }
+type BadPost interface { VisitPost(*ByRefType) Target }
+type NotIntf struct{}
+`
+
+	tcs := []struct {
+		adapter  string
+		contains string
+		err      string
+	}{
+		{adapter: "PreOnly", contains: "\t\tif !visitor.VisitPre(x) {\n"},
+		{adapter: "PostOnly", contains: "\t\tvisitor.VisitPost(x)\n"},
+		{adapter: "Neither", err: "adapter Neither has neither a VisitPre nor a VisitPost method"},
+		{adapter: "BadPre", err: "BadPre.VisitPre must return (bool) or (bool, Target)"},
+		{adapter: "BadPost", err: "BadPost.VisitPost must accept a single Target"},
+		{adapter: "NotIntf", err: "adapter NotIntf is not an interface"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.adapter, func(t *testing.T) {
+			a := assert.New(t)
+			cfg := configs["single"]
+			cfg.Adapters = []string{tc.adapter}
+
+			outputs := make(map[string][]byte)
+			g, err := newGenerationForTesting(cfg, outputs)
+			if !a.NoError(err) {
+				return
+			}
+			g.extraTestSource = map[string][]byte{extra: []byte(src)}
+			err = g.Execute()
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+				return
+			}
+			if !a.NoError(err) {
+				return
+			}
+			for _, out := range outputs {
+				a.Contains(string(out), "func "+tc.adapter+"Adapter(visitor "+tc.adapter+") TargetWalkerFn {")
+				a.Contains(string(out), tc.contains)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// atomicWriter writes to a temporary file in the same directory as
+// its target, and renames the temporary file over the target when it
+// is closed. A failed or interrupted generation will never leave a
+// truncated file behind, which would otherwise break the build.
+type atomicWriter struct {
+	err  error
+	mode os.FileMode // The mode of the file being replaced, if any.
+	name string
+	tmp  *os.File
+}
+
+var _ io.WriteCloser = &atomicWriter{}
+
+// newAtomicWriter creates a temporary file alongside the named file.
+// The temporary file is created with the same permissions that
+// os.Create would use, so that a new file respects the umask, and is
+// given the mode of the named file, if it exists, when it is closed.
+func newAtomicWriter(name string) (*atomicWriter, error) {
+	var mode os.FileMode
+	if info, err := os.Stat(name); err == nil {
+		mode = info.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	prefix := filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".")
+	for {
+		tmpName := prefix + strconv.FormatUint(uint64(rand.Uint32()), 10) + ".tmp"
+		tmp, err := os.OpenFile(tmpName, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &atomicWriter{mode: mode, name: name, tmp: tmp}, nil
+	}
+}
+
+// Write implements io.Writer.
+func (w *atomicWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.tmp.Write(p)
+	w.err = err
+	return n, err
+}
+
+// Close implements io.Closer. If any previous call to Write failed,
+// the temporary file is removed and the target is left untouched.
+func (w *atomicWriter) Close() error {
+	err := w.err
+	if x := w.tmp.Close(); err == nil {
+		err = x
+	}
+	if err == nil && w.mode != 0 {
+		err = os.Chmod(w.tmp.Name(), w.mode)
+	}
+	if err == nil {
+		err = os.Rename(w.tmp.Name(), w.name)
+	}
+	if err != nil {
+		_ = os.Remove(w.tmp.Name())
+	}
+	return err
+}
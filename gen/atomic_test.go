@@ -0,0 +1,62 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomicWriter(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+	name := filepath.Join(dir, "out.go")
+	a.NoError(os.WriteFile(name, []byte("original"), 0644))
+
+	// A failed write leaves the original file in place.
+	w, err := newAtomicWriter(name)
+	if !a.NoError(err) {
+		return
+	}
+	_, err = w.Write([]byte("partial"))
+	a.NoError(err)
+	w.err = errors.New("injected")
+	a.EqualError(w.Close(), "injected")
+	data, err := os.ReadFile(name)
+	a.NoError(err)
+	a.Equal("original", string(data))
+
+	// A successful write replaces the file.
+	w, err = newAtomicWriter(name)
+	if !a.NoError(err) {
+		return
+	}
+	_, err = w.Write([]byte("replaced"))
+	a.NoError(err)
+	a.NoError(w.Close())
+	data, err = os.ReadFile(name)
+	a.NoError(err)
+	a.Equal("replaced", string(data))
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(dir)
+	a.NoError(err)
+	a.Len(entries, 1)
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+//go:build unix
+// +build unix
+
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomicWriterMode(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+	write := func(name string) {
+		w, err := newAtomicWriter(name)
+		if !a.NoError(err) {
+			return
+		}
+		_, err = w.Write([]byte("data"))
+		a.NoError(err)
+		a.NoError(w.Close())
+	}
+	mode := func(name string) os.FileMode {
+		info, err := os.Stat(name)
+		if !a.NoError(err) {
+			return 0
+		}
+		return info.Mode().Perm()
+	}
+
+	// A new file is created as os.Create would, filtered by the umask.
+	umask := syscall.Umask(0027)
+	defer syscall.Umask(umask)
+	created := filepath.Join(dir, "created.go")
+	write(created)
+	a.Equal(os.FileMode(0640), mode(created))
+
+	// An existing file keeps its mode.
+	existing := filepath.Join(dir, "existing.go")
+	a.NoError(os.WriteFile(existing, []byte("original"), 0600))
+	a.NoError(os.Chmod(existing, 0755))
+	write(existing)
+	a.Equal(os.FileMode(0755), mode(existing))
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// load loads the target package. When cgo is disabled in the current
+// environment, the go command will silently ignore any files which
+// import "C", along with the types declared in them. In that case, the
+// package is re-loaded with cgo enabled. If no C compiler is available,
+// the type information will be recovered from export data or by
+// processing the cgo files directly.
+func (g *generation) load() ([]*packages.Package, error) {
+	cfg := g.packageConfig()
+	pkgs, err := packages.Load(cfg, g.pattern())
+	if err != nil || !ignoresCgo(pkgs) {
+		return pkgs, err
+	}
+	g.logf(Verbose, "cgo files were ignored, reloading with CGO_ENABLED=1")
+	g.forceCgo = true
+	cfg.Env = g.env()
+	return packages.Load(cfg, g.pattern())
+}
+
+// env returns the environment to use when invoking the go command.
+func (g *generation) env() []string {
+	if g.forceCgo {
+		return append(os.Environ(), "CGO_ENABLED=1")
+	}
+	return nil
+}
+
+// ignoresCgo returns true if any of the packages have ignored files
+// which import "C".
+func ignoresCgo(pkgs []*packages.Package) bool {
+	var fset token.FileSet
+	for _, pkg := range pkgs {
+		for _, name := range pkg.IgnoredFiles {
+			file, err := parser.ParseFile(&fset, name, nil, parser.ImportsOnly)
+			if err != nil {
+				continue
+			}
+			for _, imp := range file.Imports {
+				if path, _ := strconv.Unquote(imp.Path.Value); path == "C" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
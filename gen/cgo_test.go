@@ -0,0 +1,57 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCgo verifies that seed types declared in a file which imports
+// "C" are found, even when cgo is disabled in the environment.
+func TestCgo(t *testing.T) {
+	a := assert.New(t)
+	t.Setenv("CGO_ENABLED", "0")
+
+	dir := t.TempDir()
+	a.NoError(os.WriteFile(filepath.Join(dir, "go.mod"),
+		[]byte("module example.com/cgo\n\ngo 1.21\n"), 0644))
+	a.NoError(os.WriteFile(filepath.Join(dir, "cgo.go"), []byte(`package cgo
+
+// int add(int a, int b) { return a + b; }
+import "C"
+
+type Node interface{ isNode() }
+
+type Leaf struct{}
+
+func (*Leaf) isNode() {}
+
+type Pair struct{ Left, Right Node }
+
+func (*Pair) isNode() {}
+
+func Add(a, b int) int { return int(C.add(C.int(a), C.int(b))) }
+`), 0644))
+
+	outputs, err := Generate(Config{Dir: dir, TypeNames: []string{"Node"}})
+	if a.NoError(err) {
+		a.Contains(outputs, filepath.Join(dir, "node_walkabout.g.go"))
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// check type-checks the target package as though the generated source
+// had already been written to the output file. Any previously-generated
+// code which was ignored when loading the package is also ignored here.
+func (v *visitation) check(outName string, src []byte) error {
+	defer v.gen.timed(Verbose, "compile check")()
+
+	name, err := filepath.Abs(outName)
+	if err != nil {
+		return err
+	}
+	overlay := make(map[string][]byte,
+		len(v.gen.cleanSource)+len(v.gen.extraTestSource)+1)
+	for k, v := range v.gen.cleanSource {
+		overlay[k] = v
+	}
+	for k, v := range v.gen.extraTestSource {
+		overlay[k] = v
+	}
+	overlay[name] = src
+
+	pkgs, err := packages.Load(&packages.Config{
+		Dir:     v.dir,
+		Env:     v.gen.env(),
+		Mode:    packages.LoadSyntax,
+		Overlay: overlay,
+		Tests:   true,
+	}, ".")
+	if err != nil {
+		return err
+	}
+
+	// Test variants of a package will report the same errors.
+	var msgs []string
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			msg := e.Error()
+			if !seen[msg] {
+				seen[msg] = true
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.Errorf("generated code for %s does not compile:\n  %s",
+			outName, strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := configs["single"]
+	cfg.Check = true
+	outputs, err := Generate(cfg)
+	a.NoError(err)
+	a.Len(outputs, 1)
+
+	// The demo tests use the exported API, so this must fail and
+	// nothing should be emitted.
+	cfg.Unexported = true
+	outputs, err = Generate(cfg)
+	if a.Error(err) {
+		a.Contains(err.Error(), "target_walkabout.g.go does not compile")
+		a.Contains(err.Error(), "undefined: demo.TargetContext")
+	}
+	a.Nil(outputs)
+}
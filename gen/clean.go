@@ -0,0 +1,101 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+// This file supports ignoring previously-generated code when loading
+// the target package. That code may no longer compile once the
+// visitable types have changed, and a new struct cannot implement a
+// seed interface which embeds a generated interface until its methods
+// have been generated.
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// isGenerated returns true if the file name is one which is written by
+// walkabout, either by default or because it is a configured output
+// file.
+func (g *generation) isGenerated(name string) bool {
+	if strings.Contains(filepath.Base(name), "_walkabout.g") {
+		return true
+	}
+	return g.outFiles[filepath.Clean(name)]
+}
+
+// loadClean reloads the packages without any previously-generated
+// files, if requested or if those files contain errors.
+func (g *generation) loadClean(pkgs []*packages.Package) ([]*packages.Package, error) {
+	if !g.Clean && !g.generatedErrors(pkgs) {
+		return pkgs, nil
+	}
+	overlay := g.cleanOverlay(pkgs)
+	if len(overlay) == 0 {
+		return pkgs, nil
+	}
+	if !g.Clean {
+		g.logf(Verbose, "previously-generated code has errors, reloading without it")
+	}
+	g.cleanSource = overlay
+	return g.load()
+}
+
+// generatedErrors returns true if any of the packages have errors
+// which are located in generated files.
+func (g *generation) generatedErrors(pkgs []*packages.Package) bool {
+	for _, pkg := range pkgs {
+		for _, err := range pkg.Errors {
+			if g.isGenerated(errorFile(err)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errorFile returns the name of the file in which the error is located,
+// from a position of the form file:line:col or file:line.
+func errorFile(err packages.Error) string {
+	pos := err.Pos
+	for i := 0; i < 2; i++ {
+		idx := strings.LastIndexByte(pos, ':')
+		if idx == -1 {
+			break
+		}
+		if _, err := strconv.Atoi(pos[idx+1:]); err != nil {
+			break
+		}
+		pos = pos[:idx]
+	}
+	return pos
+}
+
+// cleanOverlay returns an overlay which replaces each generated file in
+// the packages with only a package clause.
+func (g *generation) cleanOverlay(pkgs []*packages.Package) map[string][]byte {
+	ret := make(map[string][]byte)
+	for _, pkg := range pkgs {
+		for _, name := range pkg.GoFiles {
+			if g.isGenerated(name) {
+				ret[name] = []byte("package " + pkg.Name + "\n")
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,202 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestClean verifies that previously-generated code is ignored, whether
+// it was written to the default output file or to a custom one.
+func TestClean(t *testing.T) {
+	for _, custom := range []bool{false, true} {
+		t.Run(fmt.Sprintf("custom=%t", custom), func(t *testing.T) {
+			testClean(t, custom)
+		})
+	}
+}
+
+func testClean(t *testing.T, custom bool) {
+	a := assert.New(t)
+
+	// The generated code must be able to import the engine.
+	root, err := filepath.Abs("..")
+	a.NoError(err)
+	dir := t.TempDir()
+	a.NoError(os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example.com/clean
+
+go 1.21
+
+require github.com/cockroachdb/walkabout v0.0.0
+
+replace github.com/cockroachdb/walkabout => `+root+"\n"), 0644))
+	src := filepath.Join(dir, "clean.go")
+	outName := filepath.Join(dir, "node_walkabout.g.go")
+	var outFile string
+	if custom {
+		outName = filepath.Join(dir, "nodes.go")
+		outFile = outName
+	}
+
+	// The seed interface embeds a generated interface, so a struct only
+	// implements it once its methods have been generated.
+	const model = `package clean
+
+type Node interface {
+	NodeAbstract
+	isNode()
+}
+
+type Leaf struct{}
+
+func (*Leaf) isNode() {}
+
+type Pair struct{ Left, Right Node }
+
+func (*Pair) isNode() {}
+`
+	a.NoError(os.WriteFile(src, []byte(model), 0644))
+	generate := func(clean bool) string {
+		var log bytes.Buffer
+		outputs, err := Generate(Config{
+			Clean:     clean,
+			Dir:       dir,
+			Log:       &log,
+			OutFile:   outFile,
+			TypeNames: []string{"Node"},
+			Verbosity: Verbose,
+		})
+		if !a.NoError(err) {
+			return ""
+		}
+		a.NoError(os.WriteFile(outName, outputs[outName], 0644))
+		return log.String() + string(outputs[outName])
+	}
+	a.Contains(generate(false), "func (*Pair) NodeTypeName() string")
+
+	// A new struct is not found unless the generated code is ignored.
+	a.NoError(os.WriteFile(src, []byte(model+`
+type Triple struct{ A, B, C Node }
+
+func (*Triple) isNode() {}
+`), 0644))
+	a.NotContains(generate(false), "Triple")
+	a.Contains(generate(true), "func (*Triple) NodeTypeName() string")
+
+	// Generated code which no longer compiles is ignored automatically.
+	a.NoError(os.WriteFile(src, []byte(`package clean
+
+type Node interface {
+	NodeAbstract
+	isNode()
+}
+
+type Leaf struct{}
+
+func (*Leaf) isNode() {}
+
+type Quad struct{ A, B, C, D Node }
+
+func (*Quad) isNode() {}
+`), 0644))
+	out := generate(false)
+	a.Contains(out, "previously-generated code has errors, reloading without it")
+	a.Contains(out, "func (*Quad) NodeTypeName() string")
+	a.NotContains(out, "Triple")
+}
+
+// TestCleanAll verifies that GenerateAll and --check ignore
+// previously-generated code which no longer compiles, including the
+// code generated for other targets in the same package.
+func TestCleanAll(t *testing.T) {
+	a := assert.New(t)
+
+	root, err := filepath.Abs("..")
+	a.NoError(err)
+	dir := t.TempDir()
+	a.NoError(os.WriteFile(filepath.Join(dir, "go.mod"), []byte(`module example.com/clean
+
+go 1.21
+
+require github.com/cockroachdb/walkabout v0.0.0
+
+replace github.com/cockroachdb/walkabout => `+root+"\n"), 0644))
+	src := filepath.Join(dir, "clean.go")
+
+	const model = `package clean
+
+type Node interface{ isNode() }
+
+type Other interface{ isOther() }
+
+type Leaf struct{}
+
+func (*Leaf) isNode()  {}
+func (*Leaf) isOther() {}
+`
+	generate := func(extra string) (string, map[string][]byte) {
+		a.NoError(os.WriteFile(src, []byte(model+extra), 0644))
+		var log bytes.Buffer
+		var cfgs []Config
+		for _, name := range []string{"Node", "Other"} {
+			cfgs = append(cfgs, Config{
+				Check:     true,
+				Dir:       dir,
+				Log:       &log,
+				TypeNames: []string{name},
+				Verbosity: Verbose,
+			})
+		}
+		outputs, err := GenerateAll(cfgs)
+		if !a.NoError(err) {
+			return log.String(), nil
+		}
+		for name, out := range outputs {
+			a.NoError(os.WriteFile(name, out, 0644))
+		}
+		return log.String(), outputs
+	}
+
+	_, outputs := generate(`
+type Triple struct{ A, B, C Node }
+
+func (*Triple) isNode()  {}
+func (*Triple) isOther() {}
+`)
+	a.Len(outputs, 2)
+	for _, out := range outputs {
+		a.Contains(string(out), "Triple")
+	}
+
+	log, outputs := generate(`
+type Quad struct{ A, B, C, D Node }
+
+func (*Quad) isNode()  {}
+func (*Quad) isOther() {}
+`)
+	a.Contains(log, "previously-generated code has errors, reloading without it")
+	a.Len(outputs, 2)
+	for _, out := range outputs {
+		a.Contains(string(out), "Quad")
+		a.NotContains(string(out), "Triple")
+	}
+}
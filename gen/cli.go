@@ -17,19 +17,23 @@
 package gen
 
 import (
-	"fmt"
-	"runtime"
+	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
-// buildID is set by a linker flag.
-var buildID = "dev"
-
 // Main is the entry point for the walkabout tool.  It is invoked from
 // a main() method in the top-level walkabout package.
 func Main() error {
-	var config config
+	return rootCommand().Execute()
+}
+
+// rootCommand constructs the walkabout command and its sub-commands.
+func rootCommand() *cobra.Command {
+	// The root command retains the flags of the generate command so
+	// that existing go:generate lines continue to work.
+	var config Config
 	rootCmd := &cobra.Command{
 		Use: "walkabout",
 		Short: `walkabout is a code-generation tool to enhance struct types.
@@ -39,6 +43,10 @@ walkabout InterfaceName
   Generates support code to make all struct types that implement
   the given interface walkable.
 
+walkabout InterfaceName InterfaceName ...
+  Generates independent support code for each of the interfaces into a
+  single file, as though walkabout had been run once for each.
+
 walkabout --union UnionInterface ( InterfaceName | StructName ) ...
   Generates an interface called "UnionInterface" which will be
   implemented by the named struct types, or those structs that implement
@@ -49,40 +57,249 @@ walkabout --union UnionInterface --reachable ( InterfaceName | StructName ) ...
   transitively reachable from the named types.  This is useful for
   refitting an entire package where the existing types may not all
   share a common interface.
+
+walkabout graph [ --format dot | mermaid ] ( InterfaceName | StructName ) ...
+  Prints a diagram of the visitable types and the fields which
+  connect them.
+
+walkabout verify [ generate flags ] ( InterfaceName | StructName ) ...
+  Fails if the generated files on disk are not up to date.
 `,
-		Args: cobra.MinimumNArgs(1),
+		// Setting Args allows unknown positional arguments to be
+		// treated as type names, rather than as sub-command names.
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			config.typeNames = args
-			g, err := newGeneration(config)
-			if err != nil {
-				return err
+			if len(args) == 0 {
+				return cmd.Help()
 			}
-			return g.Execute()
+			config.TypeNames = args
+			return run(config)
 		},
 	}
+	addGenerateFlags(rootCmd.Flags(), &config)
 
-	rootCmd.Flags().StringVarP(&config.dir, "dir", "d", ".",
-		"the directory to operate in")
+	var generateConfig Config
+	generateCmd := &cobra.Command{
+		Use:   "generate ( InterfaceName | StructName ) ...",
+		Short: "generate visitation code; this is the default command",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			generateConfig.TypeNames = args
+			return run(generateConfig)
+		},
+	}
+	addGenerateFlags(generateCmd.Flags(), &generateConfig)
 
-	rootCmd.Flags().StringVarP(&config.outFile, "out", "o", "",
-		"overrides the output file name")
+	var verifyConfig Config
+	verifyCmd := &cobra.Command{
+		Use:   "verify ( InterfaceName | StructName ) ...",
+		Short: "check that the generated files are up to date, without writing them",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			verifyConfig.TypeNames = args
+			verifyConfig.Progress = isTerminal(os.Stderr)
+			return Verify(verifyConfig)
+		},
+	}
+	addGenerateFlags(verifyCmd.Flags(), &verifyConfig)
 
-	rootCmd.Flags().BoolVarP(&config.reachable, "reachable", "r", false,
-		`make all transitively reachable types in the same package also
-implement the --union interface. Only valid when using --union.`)
+	var graphConfig Config
+	var graphFormat string
+	graphCmd := &cobra.Command{
+		Use:   "graph ( InterfaceName | StructName ) ...",
+		Short: "print a DOT or Mermaid diagram of the visitable types",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			graphConfig.TypeNames = args
+			return Graph(graphConfig, graphFormat, os.Stdout)
+		},
+	}
+	addLoadFlags(graphCmd.Flags(), &graphConfig)
+	graphCmd.Flags().StringVar(&graphFormat, "format", GraphDOT,
+		"the format of the diagram: dot or mermaid")
 
-	rootCmd.Flags().StringVarP(&config.union, "union", "u", "",
-		`generate a new interface with the given name to be used as the
-visitable interface.`)
+	var listConfig Config
+	listCmd := &cobra.Command{
+		Use:   "list ( InterfaceName | StructName ) ...",
+		Short: "print the types that would be made visitable, without generating code",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listConfig.TypeNames = args
+			return List(listConfig, os.Stdout)
+		},
+	}
+	addLoadFlags(listCmd.Flags(), &listConfig)
+
+	var explainConfig Config
+	explainCmd := &cobra.Command{
+		Use:   "explain TypeName ( InterfaceName | StructName ) ...",
+		Short: "explain why a type is, or is not, visitable from the given types",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			explainConfig.TypeNames = args[1:]
+			return Explain(explainConfig, args[0], os.Stdout)
+		},
+	}
+	addLoadFlags(explainCmd.Flags(), &explainConfig)
 
 	rootCmd.AddCommand(
+		explainCmd,
+		generateCmd,
+		graphCmd,
+		listCmd,
+		verifyCmd,
 		&cobra.Command{
 			Use:   "version",
 			Short: "print version information",
-			Run: func(cmd *cobra.Command, args []string) {
-				fmt.Printf("walkabout version %s; %s", buildID, runtime.Version())
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return printVersion(os.Stdout)
 			},
 		})
+	rootCmd.AddCommand(completionCommands(rootCmd)...)
+
+	return rootCmd
+}
+
+// run executes a complete generation.
+func run(config Config) error {
+	config.Progress = isTerminal(os.Stderr)
+	g, err := newGeneration(config)
+	if err != nil {
+		return err
+	}
+	return g.Execute()
+}
+
+// addGenerateFlags adds the flags which control code generation,
+// including those added by addLoadFlags.
+func addGenerateFlags(flags *pflag.FlagSet, config *Config) {
+	addLoadFlags(flags, config)
+
+	flags.StringSliceVar(&config.Adapters, "adapter", nil,
+		`generate a WalkerFn which delegates to an existing visitor interface
+with VisitPre and/or VisitPost methods. May be repeated.`)
+
+	flags.BoolVar(&config.Check, "check", false,
+		`type-check the package with the generated code before writing it,
+and fail instead of writing code which does not compile.`)
+
+	flags.BoolVar(&config.EqualMethods, "equal-methods", false,
+		`compare replacements of structs which have an Equal method with the
+values they replace, and do not clone the enclosing values if they are
+equal.`)
+
+	flags.BoolVar(&config.Examples, "examples", false,
+		`write a _test.go file of runnable examples, which walk one of the
+visitable structs, next to the generated code.`)
+
+	flags.BoolVar(&config.ExplicitEngine, "explicit-engine", false,
+		`generate an Engine type, which callers construct and use to walk
+values, instead of any package-level state. The structs will not
+implement the Abstract interface or have Walk methods.`)
+
+	flags.BoolVar(&config.Generics, "generics", false,
+		`generate Context, Decision, and Action types which are aliases of
+generic types in the engine package, which reduces the size of the
+generated code.`)
+
+	flags.IntVar(&config.InlineFields, "inline", 0,
+		`generate specialized walkers, which do not use the engine's stack,
+for structs with at most this many visitable fields. Values visited
+inline have no path or abstract accessor, and are not counted by
+metrics, traced, or checked for aliasing or races.`)
+
+	flags.StringVar(&config.JSONSchema, "json-schema", "",
+		`write a JSON Schema which describes the map form of the visitable
+types to this file, to validate trees which are supplied externally.`)
+
+	flags.BoolVar(&config.LineDirectives, "line-directives", false,
+		`attribute the generated methods of each struct to its declaration
+using //line directives.`)
+
+	flags.StringVar(&config.LocalPrefix, "local", "",
+		`imports beginning with this prefix will be grouped separately when
+using --format goimports or gofumpt.`)
+
+	flags.StringVar(&config.Manifest, "manifest", "",
+		`a file which records the values assigned to TypeID constants so
+that they remain stable across regenerations. This file should be
+checked in.`)
+
+	flags.StringVar(&config.Format, "format", FormatGofmt,
+		`the formatter to apply to the generated code: gofmt, goimports, or
+gofumpt. The gofumpt formatter must be installed separately.`)
+
+	flags.StringVar(&config.GoVersion, "go", "",
+		`the oldest version of Go that the generated code must support,
+e.g. 1.22. Defaults to the version in the package's go.mod file. The
+oldest version which is supported is go1.21.`)
+
+	flags.StringVarP(&config.OutFile, "out", "o", "",
+		"overrides the output file name")
+
+	flags.StringVar(&config.Proto, "proto", "",
+		`write a proto3 schema which describes the visitable types to this
+file. The field numbers in an existing file are kept. No code is
+generated to convert trees to or from its messages.`)
+
+	flags.Var(renameFlag{&config.Renames}, "rename",
+		`rename a generated identifier, e.g. --rename WalkTarget=Traverse, if
+it would collide with a field or method of a visitable struct. May be
+repeated.`)
+
+	flags.BoolVar(&config.ReportSize, "report-size", false,
+		`report the number of lines and bytes generated for each type, to
+identify the types which contribute the most to compile times.`)
+
+	flags.BoolVar(&config.StringTypeIDs, "string-ids", false,
+		`generate TypeID constants whose values are the names of the types.
+These are stable across regenerations and are safe to persist.`)
+
+	flags.StringVar(&config.TemplateDir, "template-dir", "",
+		`a directory of *.tmpl files which override built-in templates of
+the same name, or which are appended to the generated code.`)
+
+	flags.VarPF(testModeFlag{TestAlways, &config.Test}, "test", "",
+		"always generate a _test.go file.").NoOptDefVal = "true"
+
+	flags.VarPF(testModeFlag{TestNever, &config.Test}, "no-test", "",
+		`never generate a _test.go file, even if the seed types are declared
+in test files.`).NoOptDefVal = "true"
+
+	flags.BoolVar(&config.Unexported, "unexported", false,
+		"generate an API consisting only of un-exported identifiers.")
+}
+
+// addLoadFlags adds the flags which determine the visitable types.
+func addLoadFlags(flags *pflag.FlagSet, config *Config) {
+	flags.BoolVar(&config.Clean, "clean", false,
+		`ignore previously-generated files when loading the package, so that
+new structs are found if the seed interface embeds a generated one.`)
+
+	flags.VarPF(verbosityFlag{Debug, &config.Verbosity}, "debug", "",
+		"log every decision made about a type, and template timings.").NoOptDefVal = "true"
+
+	flags.BoolVar(&config.Diagnostics, "diagnostics", false,
+		`report the positions of fields and types which refer to visitable
+types, but which will not be visited, and how to change that.`)
+
+	flags.StringVarP(&config.Dir, "dir", "d", ".",
+		"the directory to operate in")
+
+	flags.StringVarP(&config.Package, "package", "p", "",
+		`the import path of the package to operate on, if not the package
+in --dir. This may name any package in the module or go.work
+workspace which contains --dir.`)
+
+	flags.BoolVarP(&config.Reachable, "reachable", "r", false,
+		`make all transitively reachable types in the same package also
+implement the --union interface. Only valid when using --union.`)
+
+	flags.StringVarP(&config.Union, "union", "u", "",
+		`generate a new interface with the given name to be used as the
+visitable interface.`)
 
-	return rootCmd.Execute()
+	flags.VarPF(verbosityFlag{Verbose, &config.Verbosity}, "verbose", "v",
+		"log the time spent in each phase of the generator.").NoOptDefVal = "true"
 }
@@ -0,0 +1,144 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+// This file detects generated methods whose names are already used by
+// a field or method of their receiver, which would otherwise result in
+// code that does not compile.
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// checkCollisions returns an error which describes each method in the
+// generated source whose name is used by a field or method that is
+// declared on its receiver. Declarations in the file that we are about
+// to overwrite, or in any other generated file, are ignored.
+func (v *visitation) checkCollisions(outName string, src []byte) error {
+	file, err := parser.ParseFile(token.NewFileSet(), outName, src, parser.SkipObjectResolution)
+	if err != nil {
+		return err
+	}
+	outName, err = filepath.Abs(outName)
+	if err != nil {
+		return err
+	}
+
+	structs := make(map[string]namedStruct)
+	for _, t := range v.Types {
+		if s, ok := t.Implementation().(namedStruct); ok && s.PkgPath() == v.packagePath {
+			structs[s.String()] = s
+		}
+	}
+
+	var msgs []string
+	seen := make(map[string]bool)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		ident, ok := recv.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		s, ok := structs[ident.Name]
+		if !ok {
+			continue
+		}
+		name := fn.Name.Name
+		obj, index, _ := types.LookupFieldOrMethod(s.Named, true, s.Obj().Pkg(), name)
+		// A generated method may shadow a promoted field or method.
+		if obj == nil || len(index) != 1 {
+			continue
+		}
+		what := "method"
+		if _, ok := obj.(*types.Var); ok {
+			what = "field"
+		}
+		where := ""
+		if obj.Pos().IsValid() {
+			pos := v.gen.fileSet.Position(obj.Pos())
+			if filepath.Clean(pos.Filename) == outName || v.gen.isGenerated(pos.Filename) {
+				continue
+			}
+			where = fmt.Sprintf(" (%s:%d)", filepath.Base(pos.Filename), pos.Line)
+		}
+		msg := fmt.Sprintf("%s %s.%s%s; rename it or use --rename %s=<name>",
+			what, s, name, where, name)
+		if !seen[msg] {
+			seen[msg] = true
+			msgs = append(msgs, msg)
+		}
+	}
+	if len(msgs) > 0 {
+		sort.Strings(msgs)
+		return errors.Errorf("generated methods collide with existing declarations:\n  %s",
+			strings.Join(msgs, "\n  "))
+	}
+	return nil
+}
+
+// renameFlag adds entries to Config.Renames.
+type renameFlag struct {
+	target *map[string]string
+}
+
+// String implements pflag.Value.
+func (f renameFlag) String() string {
+	if f.target == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*f.target))
+	for from, to := range *f.target {
+		pairs = append(pairs, from+"="+to)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements pflag.Value.
+func (f renameFlag) Set(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		idx := strings.IndexByte(pair, '=')
+		if idx == -1 {
+			return errors.Errorf("%q must be of the form old=new", pair)
+		}
+		if *f.target == nil {
+			*f.target = make(map[string]string)
+		}
+		(*f.target)[pair[:idx]] = pair[idx+1:]
+	}
+	return nil
+}
+
+// Type implements pflag.Value.
+func (f renameFlag) Type() string {
+	return "old=new"
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollisions(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	a.NoError(os.WriteFile(filepath.Join(dir, "go.mod"),
+		[]byte("module example.com/collide\n\ngo 1.21\n"), 0644))
+	a.NoError(os.WriteFile(filepath.Join(dir, "collide.go"), []byte(`package collide
+
+type Node interface{ isNode() }
+
+type Embedded struct{}
+
+func (Embedded) NodeKind() string { return "" }
+
+type Leaf struct {
+	Embedded
+	NodeCount int
+}
+
+func (*Leaf) isNode() {}
+
+func (*Leaf) WalkNode() {}
+
+type Pair struct{ Left, Right Node }
+
+func (*Pair) isNode() {}
+`), 0644))
+	outName := filepath.Join(dir, "node_walkabout.g.go")
+	cfg := Config{Dir: dir, TypeNames: []string{"Node"}}
+
+	_, err := Generate(cfg)
+	a.EqualError(err, `generated methods collide with existing declarations:
+  field Leaf.NodeCount (collide.go:11); rename it or use --rename NodeCount=<name>
+  method Leaf.WalkNode (collide.go:16); rename it or use --rename WalkNode=<name>`)
+
+	cfg.Renames = map[string]string{"NodeCount": "NodeFieldCount", "WalkNode": "TraverseNode"}
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	src := string(outputs[outName])
+	a.Contains(src, "func (x *Leaf) NodeFieldCount() int {")
+	a.Contains(src, "func (x *Leaf) TraverseNode(fn NodeWalkerFn) (_ *Leaf, changed bool, err error) {")
+	a.Contains(src, "func TraverseNode(x Node, fn NodeWalkerFn) (_ Node, changed bool, err error) {")
+	// The promoted method is shadowed, which is allowed.
+	a.Contains(src, "func (*Leaf) NodeKind() NodeKind {")
+
+	// Declarations in a previously-generated file are ignored.
+	a.NoError(os.WriteFile(outName, outputs[outName], 0644))
+	_, err = Generate(cfg)
+	a.NoError(err)
+
+	// The flag may be repeated.
+	cfg.Renames = nil
+	flag := renameFlag{&cfg.Renames}
+	a.NoError(flag.Set("NodeCount=NodeFieldCount"))
+	a.NoError(flag.Set("WalkNode=TraverseNode"))
+	a.Equal("NodeCount=NodeFieldCount,WalkNode=TraverseNode", flag.String())
+	a.EqualError(flag.Set("WalkNode"), `"WalkNode" must be of the form old=new`)
+
+	cfg.Renames = map[string]string{"WalkNode": "not valid"}
+	_, err = Generate(cfg)
+	a.EqualError(err, "--rename WalkNode=not valid must map an identifier to an identifier")
+}
@@ -0,0 +1,247 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+// This file contains support for shell completion. The scripts which
+// are installed into the shell call a hidden command, which prints the
+// candidates for the word being completed. This allows the names of
+// types to be completed by loading the package.
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completeCommand is the name of the hidden command which is called
+// by the completion scripts.
+const completeCommand = "__complete"
+
+// completionScripts contains the script for each supported shell. The
+// scripts call the hidden completion command with the words on the
+// command line, excluding the name of the program, and ending with the
+// word being completed. If there are no candidates, the shell falls
+// back to completing file names.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for walkabout
+_walkabout() {
+	local IFS=$'\n'
+	COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _walkabout walkabout
+`,
+	"fish": `# fish completion for walkabout
+function __walkabout_complete
+	set -l args (commandline -opc)
+	set -e args[1]
+	walkabout __complete $args (commandline -ct) 2>/dev/null
+end
+complete -c walkabout -a '(__walkabout_complete)'
+`,
+	"zsh": `#compdef walkabout
+# zsh completion for walkabout
+_walkabout() {
+	local -a candidates
+	candidates=("${(@f)$("${words[1]}" __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if [[ -n "${candidates[1]}" ]]; then
+		compadd -a candidates
+	else
+		_files
+	fi
+}
+if [ "$funcstack[1]" = "_walkabout" ]; then
+	_walkabout "$@"
+else
+	compdef _walkabout walkabout
+fi
+`,
+}
+
+// completionCommands returns the command which prints a completion
+// script, and the hidden command which the script calls.
+func completionCommands(root *cobra.Command) []*cobra.Command {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+
+	return []*cobra.Command{
+		{
+			Use:       "completion " + strings.Join(shells, "|"),
+			Short:     "print a script which completes commands, flags, and type names",
+			Args:      cobra.ExactArgs(1),
+			ValidArgs: shells,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return WriteCompletion(args[0], cmd.OutOrStdout())
+			},
+		},
+		{
+			Use:                completeCommand,
+			Hidden:             true,
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return complete(root, args, cmd.OutOrStdout())
+			},
+		},
+	}
+}
+
+// WriteCompletion writes the completion script for the named shell,
+// which is one of bash, fish, or zsh.
+func WriteCompletion(shell string, w io.Writer) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return errors.Errorf("unsupported shell %q", shell)
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// complete writes the candidates for the last of the words, one per
+// line. Nothing is written for the value of a flag, so that the shell
+// will complete a file name.
+func complete(root *cobra.Command, words []string, w io.Writer) error {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cmd := root
+	if len(words) > 1 {
+		for _, sub := range root.Commands() {
+			if sub.Name() == words[0] && !sub.Hidden {
+				cmd, words = sub, words[1:]
+				break
+			}
+		}
+	}
+	prefix := words[len(words)-1]
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(prefix, "-"):
+		cmd.Flags().VisitAll(func(f *pflag.Flag) {
+			if !f.Hidden {
+				candidates = append(candidates, "--"+f.Name)
+			}
+		})
+
+	case len(words) > 1 && takesValue(cmd.Flags(), words[len(words)-2]):
+		return nil
+
+	case len(cmd.ValidArgs) > 0:
+		candidates = cmd.ValidArgs
+
+	default:
+		if cmd == root && len(words) == 1 {
+			for _, sub := range root.Commands() {
+				if !sub.Hidden {
+					candidates = append(candidates, sub.Name())
+				}
+			}
+		}
+		// Errors are ignored, since the package may not yet compile.
+		names, _ := completeTypes(flagValue(cmd.Flags(), words, "dir"))
+		candidates = append(candidates, names...)
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			if _, err := fmt.Fprintln(w, candidate); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// takesValue reports whether the word is a flag which consumes the
+// following word as its value.
+func takesValue(flags *pflag.FlagSet, word string) bool {
+	var f *pflag.Flag
+	switch {
+	case strings.HasPrefix(word, "--") && !strings.Contains(word, "="):
+		f = flags.Lookup(word[2:])
+	case len(word) == 2 && word[0] == '-':
+		f = flags.ShorthandLookup(word[1:])
+	}
+	return f != nil && f.NoOptDefVal == "" && f.Value.Type() != "bool"
+}
+
+// flagValue returns the value of the named flag from the words, or its
+// default value.
+func flagValue(flags *pflag.FlagSet, words []string, name string) string {
+	f := flags.Lookup(name)
+	if f == nil {
+		return ""
+	}
+	ret := f.DefValue
+	for i, word := range words[:len(words)-1] {
+		switch {
+		case word == "--"+name || (f.Shorthand != "" && word == "-"+f.Shorthand):
+			ret = words[i+1]
+		case strings.HasPrefix(word, "--"+name+"="):
+			ret = word[len(name)+3:]
+		}
+	}
+	return ret
+}
+
+// completeTypes returns the names of the interface and struct types
+// which are declared in the package in the directory, excluding those
+// in generated files.
+func completeTypes(dir string) ([]string, error) {
+	g := &generation{Config: Config{Dir: dir}, fileSet: token.NewFileSet()}
+	pkgs, err := g.load()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{})
+	for _, pkg := range pkgs {
+		// External test packages cannot be operated on.
+		if pkg.Types == nil || strings.HasSuffix(pkg.Name, "_test") {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			switch obj.Type().Underlying().(type) {
+			case *types.Interface, *types.Struct:
+			default:
+				continue
+			}
+			if g.isGenerated(g.fileSet.Position(obj.Pos()).Filename) {
+				continue
+			}
+			seen[name] = struct{}{}
+		}
+	}
+	ret := make([]string, 0, len(seen))
+	for name := range seen {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletion(t *testing.T) {
+	root := rootCommand()
+
+	// candidates returns the completions for the words.
+	candidates := func(t *testing.T, words ...string) []string {
+		var buf bytes.Buffer
+		if !assert.NoError(t, complete(root, words, &buf)) {
+			return nil
+		}
+		return strings.Fields(buf.String())
+	}
+
+	t.Run("commands and types", func(t *testing.T) {
+		a := assert.New(t)
+		a.Equal([]string{"completion"}, candidates(t, "comp"))
+		a.Equal([]string{"ByRefType", "ByValType"}, candidates(t, "-d", "../demo", "By"))
+		a.Equal([]string{"Target", "TargetVisitor"}, candidates(t, "--dir=../demo", "Targ"))
+		a.Equal([]string{"ContainerType"}, candidates(t, "list", "--dir", "../demo", "Cont"))
+		// Generated types are not offered.
+		a.NotContains(candidates(t, "-d", "../demo", "Target"), "TargetAbstract")
+	})
+
+	t.Run("flags", func(t *testing.T) {
+		a := assert.New(t)
+		a.Equal([]string{"--union"}, candidates(t, "list", "--un"))
+		a.Equal([]string{"--examples", "--explicit-engine"}, candidates(t, "--ex"))
+		a.Empty(candidates(t, "graph", "--examples"))
+		// The values of flags are completed by the shell.
+		a.Empty(candidates(t, "--union", ""))
+		a.Empty(candidates(t, "-d", ""))
+	})
+
+	t.Run("shells", func(t *testing.T) {
+		a := assert.New(t)
+		a.Equal([]string{"bash", "fish", "zsh"}, candidates(t, "completion", ""))
+
+		for _, shell := range []string{"bash", "fish", "zsh"} {
+			var buf bytes.Buffer
+			root.SetArgs([]string{"completion", shell})
+			root.SetOutput(&buf)
+			a.NoError(root.Execute())
+			a.Contains(buf.String(), " __complete ", shell)
+		}
+		a.EqualError(WriteCompletion("tcsh", &bytes.Buffer{}), `unsupported shell "tcsh"`)
+	})
+}
@@ -0,0 +1,288 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// excludeHint is suggested for fields which are intentionally not
+// visited.
+const excludeHint = "tag the field `walkabout:\"-\"` to silence this diagnostic"
+
+// diagnostic describes a field or type which the generator skipped,
+// but which the user may have expected to be visitable.
+type diagnostic struct {
+	pos token.Position
+	msg string
+	// hint describes a change to the source or to the flags which
+	// would alter the generator's behavior.
+	hint string
+}
+
+// String returns the diagnostic in the usual file:line: format,
+// followed by an indented hint.
+func (d diagnostic) String() string {
+	name := d.pos.Filename
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, name); err == nil {
+			name = rel
+		}
+	}
+	ret := fmt.Sprintf("%s:%d: %s", name, d.pos.Line, d.msg)
+	if d.hint != "" {
+		ret += "\n\thint: " + d.hint
+	}
+	return ret
+}
+
+// diagnostics looks for fields of visitable structs and types in the
+// package which will not be visited, even though they refer to
+// visitable types. Each diagnostic explains why the field or type is
+// skipped, and hints at how to change that.
+func (v *visitation) diagnostics() []diagnostic {
+	var ret []diagnostic
+	// The same objects may be present in multiple scopes when test
+	// files are loaded.
+	seen := make(map[diagnostic]bool)
+	add := func(pos token.Pos, hint, format string, args ...interface{}) {
+		d := diagnostic{v.gen.fileSet.Position(pos), fmt.Sprintf(format, args...), hint}
+		if !seen[d] {
+			seen[d] = true
+			ret = append(ret, d)
+		}
+	}
+	qualifier := func(pkg *types.Package) string {
+		if pkg.Path() == v.packagePath {
+			return ""
+		}
+		return pkg.Name()
+	}
+
+	for _, scope := range v.scopes {
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || obj.Exported() {
+				continue
+			}
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			for _, filter := range v.filters {
+				intf, ok := filter.(namedInterfaceType)
+				if !ok {
+					continue
+				}
+				if types.Implements(named, intf.Interface) ||
+					types.Implements(types.NewPointer(named), intf.Interface) {
+					add(obj.Pos(), "export the type to make it visitable",
+						"type %s implements %s, but is not exported", name, intf)
+					break
+				}
+			}
+		}
+	}
+
+	for _, t := range v.SourceTypes {
+		s, ok := t.(namedStruct)
+		if !ok {
+			continue
+		}
+		for i, j := 0, s.NumFields(); i < j; i++ {
+			f := s.Field(i)
+			typ := types.TypeString(f.Type(), qualifier)
+			switch {
+			case s.Excluded(i):
+				// The user has opted out.
+			case !f.Exported():
+				if v.mentionsVisitable(f.Type(), nil) {
+					add(f.Pos(), "export the field to visit it, or "+excludeHint,
+						"field %s.%s has visitable type %s, but is not exported", s, f.Name(), typ)
+				}
+			case v.isVisitable(f.Type()):
+				// The common case.
+			case v.mentionsVisitable(f.Type(), nil):
+				reason, hint := v.unsupported(f.Type(), qualifier)
+				add(f.Pos(), hint, "field %s.%s has unsupported type %s: %s", s, f.Name(), typ, reason)
+			default:
+				named := namedElem(f.Type())
+				if named == nil || named.Obj().Pkg() == nil {
+					break
+				}
+				switch named.Underlying().(type) {
+				case *types.Interface, *types.Struct:
+				default:
+					continue
+				}
+				name := types.TypeString(named, qualifier)
+				if named.Obj().Pkg().Path() != v.packagePath {
+					if _, isStruct := named.Underlying().(*types.Struct); isStruct {
+						add(f.Pos(), "only types declared in the package of the visitable interface are visited; "+excludeHint,
+							"field %s.%s has type %s, which is declared in another package", s, f.Name(), typ)
+					}
+				} else if named.Obj().Exported() {
+					add(f.Pos(), v.reachableHint(),
+						"field %s.%s has type %s, but %s does not implement %s", s, f.Name(), typ, name, v.Root)
+				}
+			}
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		a, b := ret[i].pos, ret[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	return ret
+}
+
+// reachableHint describes the flags which would cause types that do
+// not implement the visitable interface to be visited.
+func (v *visitation) reachableHint() string {
+	switch {
+	case v.Root.Union == "":
+		return "pass --union and --reachable to visit it, or " + excludeHint
+	case !v.includeReachable:
+		return "pass --reachable to visit it, or " + excludeHint
+	default:
+		return excludeHint
+	}
+}
+
+// unsupported explains why a type which mentions a visitable type is
+// not traversed, and suggests an alternative.
+func (v *visitation) unsupported(typ types.Type, qualifier types.Qualifier) (reason, hint string) {
+	var elem types.Type
+	for elem == nil {
+		switch t := typ.Underlying().(type) {
+		case *types.Array:
+			reason, elem = "arrays are not traversed", t.Elem()
+		case *types.Chan:
+			reason, elem = "channels are not traversed", t.Elem()
+		case *types.Map:
+			reason, elem = "maps are not traversed", t.Elem()
+		case *types.Pointer:
+			typ = t.Elem()
+		case *types.Signature:
+			return "funcs are not traversed", excludeHint
+		case *types.Slice:
+			typ = t.Elem()
+		default:
+			return "it is not traversed", excludeHint
+		}
+	}
+	if v.isVisitable(elem) {
+		hint = fmt.Sprintf("use a slice such as %s to visit its elements, or %s",
+			types.TypeString(types.NewSlice(elem), qualifier), excludeHint)
+	} else {
+		hint = excludeHint
+	}
+	return reason, hint
+}
+
+// namedElem returns the named type which typ refers to, through any
+// pointers and slices, or nil.
+func namedElem(typ types.Type) *types.Named {
+	for {
+		switch t := types.Unalias(typ).(type) {
+		case *types.Named:
+			return t
+		case *types.Pointer:
+			typ = t.Elem()
+		case *types.Slice:
+			typ = t.Elem()
+		default:
+			return nil
+		}
+	}
+}
+
+// isVisitable returns true if the type is one that the generated code
+// will traverse. Unlike visitableType(), this has no side effects.
+func (v *visitation) isVisitable(typ types.Type) bool {
+	for {
+		switch t := typ.(type) {
+		case *types.Named:
+			_, ok := v.SourceTypes[SourceName(t.Obj().Name())]
+			return ok && t.Obj().Pkg() != nil && t.Obj().Pkg().Path() == v.packagePath
+		case *types.Pointer:
+			typ = t.Elem()
+		case *types.Slice:
+			typ = t.Elem()
+		default:
+			return false
+		}
+	}
+}
+
+// mentionsVisitable returns true if a visitable type appears anywhere
+// within typ, including through maps, arrays, channels, and other
+// kinds of types that are not traversed.
+func (v *visitation) mentionsVisitable(typ types.Type, seen map[types.Type]bool) bool {
+	if seen == nil {
+		seen = make(map[types.Type]bool)
+	}
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	switch t := typ.(type) {
+	case *types.Named:
+		if v.isVisitable(t) {
+			return true
+		}
+		if _, isStruct := t.Underlying().(*types.Struct); isStruct {
+			// Don't look inside of other, non-visitable, structs.
+			return false
+		}
+		return v.mentionsVisitable(t.Underlying(), seen)
+	case *types.Array:
+		return v.mentionsVisitable(t.Elem(), seen)
+	case *types.Chan:
+		return v.mentionsVisitable(t.Elem(), seen)
+	case *types.Map:
+		return v.mentionsVisitable(t.Key(), seen) || v.mentionsVisitable(t.Elem(), seen)
+	case *types.Pointer:
+		return v.mentionsVisitable(t.Elem(), seen)
+	case *types.Signature:
+		return v.mentionsVisitableTuple(t.Params(), seen) ||
+			v.mentionsVisitableTuple(t.Results(), seen)
+	case *types.Slice:
+		return v.mentionsVisitable(t.Elem(), seen)
+	default:
+		return false
+	}
+}
+
+// mentionsVisitableTuple applies mentionsVisitable to the parameters or
+// results of a func.
+func (v *visitation) mentionsVisitableTuple(tuple *types.Tuple, seen map[types.Type]bool) bool {
+	for i := 0; i < tuple.Len(); i++ {
+		if v.mentionsVisitable(tuple.At(i).Type(), seen) {
+			return true
+		}
+	}
+	return false
+}
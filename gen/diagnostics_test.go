@@ -0,0 +1,84 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnostics(t *testing.T) {
+	a := assert.New(t)
+
+	extra, err := filepath.Abs("../demo/diagnostics_extra.go")
+	if !a.NoError(err) {
+		return
+	}
+
+	var sb strings.Builder
+	cfg := configs["single"]
+	cfg.Diagnostics = true
+	cfg.Log = &sb
+	g, err := newGeneration(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	g.extraTestSource = map[string][]byte{
+		extra: []byte(`package demo
+
+type DiagnosticType struct {
+	ByMap    map[string]*ByRefType
+	ByArray  [2]ByValType
+	ByFunc   func() Target
+	ByChan   chan Target
+	Excluded map[string]Target ` + "`walkabout:\"-\"`" + `
+	Hidden   *ByRefType        ` + "`walkabout:\"-\"`" + `
+	Visible  *ByRefType
+}
+
+func (*DiagnosticType) Value() string { return "" }
+`),
+	}
+	v, err := g.analyze()
+	if !a.NoError(err) {
+		return
+	}
+	var fields []string
+	for _, f := range v.SourceTypes["DiagnosticType"].(namedStruct).Fields() {
+		fields = append(fields, f.Name)
+	}
+	a.Equal([]string{"Visible"}, fields)
+
+	out := sb.String()
+	a.Contains(out, "demo.go:72: type ignoredType implements Target, but is not exported\n"+
+		"\thint: export the type to make it visitable\n")
+	a.Contains(out, "demo.go:129: field ContainerType.ignored has visitable type ByRefType, but is not exported\n"+
+		"\thint: export the field to visit it, or tag the field `walkabout:\"-\"` to silence this diagnostic\n")
+	a.Contains(out, "field ContainerType.ReachableType has type ReachableType, but ReachableType does not implement Target\n"+
+		"\thint: pass --union and --reachable to visit it")
+	a.Contains(out, "field ContainerType.OtherReachable has type other.Reachable, which is declared in another package\n")
+	a.Contains(out, "diagnostics_extra.go:4: field DiagnosticType.ByMap has unsupported type map[string]*ByRefType: maps are not traversed\n"+
+		"\thint: use a slice such as []*ByRefType to visit its elements")
+	a.Contains(out, "diagnostics_extra.go:5: field DiagnosticType.ByArray has unsupported type [2]ByValType: arrays are not traversed\n")
+	a.Contains(out, "diagnostics_extra.go:6: field DiagnosticType.ByFunc has unsupported type func() Target: funcs are not traversed\n")
+	a.Contains(out, "diagnostics_extra.go:7: field DiagnosticType.ByChan has unsupported type chan Target: channels are not traversed\n")
+	a.NotContains(out, "Excluded")
+	a.NotContains(out, "Hidden")
+	a.Equal(1, strings.Count(out, "ignoredType"))
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/cockroachdb/walkabout/gen/templates"
+	"github.com/pkg/errors"
+)
+
+var exampleTemplate = template.Must(
+	template.New("examples").Funcs(funcMap).Parse(templates.ExampleSource))
+
+// exampleData is passed to exampleTemplate.
+type exampleData struct {
+	V *visitation
+	// The struct which is walked by the examples.
+	Struct namedStruct
+	// The types of the values which are visited in a zero-valued
+	// Struct, as printed by %T.
+	Visits []string
+}
+
+// exampleName returns the name of the file of examples, which is
+// written next to the generated code.
+func (v *visitation) exampleName() string {
+	return filepath.Join(filepath.Dir(v.outName()),
+		"example_"+strings.ToLower(v.Root.String())+"_walkabout.g_test.go")
+}
+
+// exampleStruct returns the struct which the examples walk: the
+// implementation of the visitable interface with the most visitable
+// fields, or the first by name if there is a tie.
+func (v *visitation) exampleStruct() (namedStruct, bool) {
+	var candidates []namedStruct
+	for _, imp := range v.Root.Implementors() {
+		candidates = append(candidates, imp.Underlying)
+	}
+	if len(candidates) == 0 {
+		return namedStruct{}, false
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := len(candidates[i].Fields()), len(candidates[j].Fields())
+		if a != b {
+			return a > b
+		}
+		return candidates[i].String() < candidates[j].String()
+	})
+	return candidates[0], true
+}
+
+// zeroVisits appends the types of the values which are visited when
+// walking a zero value of the struct, in order. Only fields which hold
+// structs, rather than pointers, slices, or interfaces, are visited.
+func zeroVisits(buf []string, s namedStruct) []string {
+	buf = append(buf, fmt.Sprintf("*%s.%s", s.Obj().Pkg().Name(), s))
+	for _, f := range s.Fields() {
+		if child, ok := f.Target.(namedStruct); ok {
+			buf = zeroVisits(buf, child)
+		}
+	}
+	return buf
+}
+
+// writeExamples writes a file of runnable examples which walk one of
+// the structs which implement the visitable interface.
+func (v *visitation) writeExamples() error {
+	s, ok := v.exampleStruct()
+	if !ok {
+		return errors.Errorf("--examples requires a struct which implements %s", v.Root)
+	}
+	var buf bytes.Buffer
+	if err := exampleTemplate.Execute(&buf, exampleData{
+		V:      v,
+		Struct: s,
+		Visits: zeroVisits(nil, s),
+	}); err != nil {
+		return err
+	}
+
+	outName := v.exampleName()
+	formatted, err := v.gen.format(outName, buf.Bytes())
+	if err != nil {
+		return err
+	}
+	out, err := v.gen.writeCloser(outName)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(formatted)
+	if x := out.Close(); x != nil && err == nil {
+		err = x
+	}
+	return err
+}
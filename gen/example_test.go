@@ -0,0 +1,79 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExamples(t *testing.T) {
+	t.Run("single", func(t *testing.T) {
+		a := assert.New(t)
+		cfg := configs["single"]
+		cfg.Examples = true
+		outputs, err := Generate(cfg)
+		if !a.NoError(err) {
+			return
+		}
+
+		name := filepath.Join("../demo", "example_target_walkabout.g_test.go")
+		src := string(outputs[name])
+		a.Contains(src, "package demo\n")
+		a.Contains(src, "func ExampleWalkTarget() {\n\tvar x Target = &ContainerType{}\n")
+		a.Contains(src, `
+	// Output:
+	// *demo.ContainerType
+	// *demo.ByRefType
+	// *demo.ByValType
+}
+`)
+		a.Contains(src, "func ExampleWalkTarget_replace() {")
+		a.Contains(src, "func ExampleWalkTarget_actions() {")
+
+		// The Go code should not be affected.
+		delete(outputs, name)
+		expected, err := Generate(configs["single"])
+		if a.NoError(err) {
+			a.Equal(expected, outputs)
+		}
+	})
+
+	t.Run("union", func(t *testing.T) {
+		a := assert.New(t)
+		cfg := configs["union"]
+		cfg.Examples = true
+		cfg.Generics = true
+		outputs, err := Generate(cfg)
+		if !a.NoError(err) {
+			return
+		}
+		src := string(outputs[filepath.Join("../demo", "example_union_walkabout.g_test.go")])
+		a.Contains(src, "func ExampleWalkUnion() {\n\tvar x Union = &ContainerType{}\n")
+		a.Contains(src, "_, _, err := WalkUnion(x, func(ctx UnionContext, x Union) UnionDecision {")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		a := assert.New(t)
+		cfg := configs["single"]
+		cfg.Examples = true
+		cfg.ExplicitEngine = true
+		_, err := Generate(cfg)
+		a.EqualError(err, "--examples cannot be used with --explicit-engine or --unexported")
+	})
+}
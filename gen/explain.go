@@ -0,0 +1,219 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Explain writes a description of why the named type is, or is not,
+// visitable under the given configuration. This describes the seed
+// interfaces that a type implements, the fields of other visitable
+// types which refer to it, and the rules which may have excluded it.
+func Explain(cfg Config, typeName string, w io.Writer) error {
+	g, err := newGeneration(cfg)
+	if err != nil {
+		return err
+	}
+	v, err := g.analyze()
+	if err != nil {
+		return err
+	}
+
+	// The type may be qualified in the same way as the seed types.
+	_, typeName = splitQualified(typeName)
+
+	var obj types.Object
+	for _, scope := range v.scopes {
+		if obj = scope.Lookup(typeName); obj != nil {
+			break
+		}
+	}
+	if obj == nil {
+		return unknownType(typeName, v.scopes)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok || obj.Pkg() == nil {
+		return errors.Errorf("%q is not a named type", typeName)
+	}
+
+	if found, ok := v.SourceTypes[SourceName(typeName)]; ok {
+		fmt.Fprintf(w, "%s is visitable:\n", typeName)
+		for _, reason := range v.inclusionReasons(named, found) {
+			fmt.Fprintf(w, "  %s\n", reason)
+		}
+	} else {
+		fmt.Fprintf(w, "%s is not visitable:\n", typeName)
+		for _, reason := range v.exclusionReasons(named) {
+			fmt.Fprintf(w, "  %s\n", reason)
+		}
+	}
+
+	if refs := v.references(named); len(refs) > 0 {
+		fmt.Fprintf(w, "referenced by:\n")
+		for _, ref := range refs {
+			fmt.Fprintf(w, "  %s\n", ref)
+		}
+	}
+
+	var forms []string
+	for _, t := range v.view().Types {
+		if t.Name != typeName && strings.TrimLeft(t.Name, "*[]") == typeName {
+			forms = append(forms, t.Name)
+		}
+	}
+	if len(forms) > 0 {
+		fmt.Fprintf(w, "traversable as: %s\n", strings.Join(forms, ", "))
+	}
+	return nil
+}
+
+// inclusionReasons describes why a type was included in the visitation.
+func (v *visitation) inclusionReasons(named *types.Named, found visitableType) []string {
+	var ret []string
+	name := named.Obj().Name()
+	for _, seed := range v.gen.TypeNames {
+		if seed == name {
+			ret = append(ret, "it is a seed type")
+		}
+	}
+
+	if nvt, ok := found.(namedVisitableType); ok {
+		ret = append(ret, fmt.Sprintf("it is defined as %s, which is visitable",
+			nvt.Underlying.Implementation()))
+	}
+
+	for _, filter := range v.filters {
+		intf, ok := filter.(namedInterfaceType)
+		if !ok || intf.Obj().Name() == name {
+			continue
+		}
+		switch u := named.Underlying().(type) {
+		case *types.Interface:
+			if types.Implements(u, intf.Interface) {
+				ret = append(ret, fmt.Sprintf("it extends %s", intf))
+			}
+		case *types.Struct:
+			if types.Implements(named, intf.Interface) {
+				ret = append(ret, fmt.Sprintf("it implements %s", intf))
+			} else if types.Implements(types.NewPointer(named), intf.Interface) {
+				ret = append(ret, fmt.Sprintf("*%s implements %s", name, intf))
+			}
+		}
+	}
+
+	if len(ret) == 0 && v.includeReachable {
+		ret = append(ret, "it is reachable from another visitable type and --reachable is set")
+	}
+	return ret
+}
+
+// exclusionReasons describes why a type was not included in the
+// visitation.
+func (v *visitation) exclusionReasons(named *types.Named) []string {
+	obj := named.Obj()
+	if obj.Pkg().Path() != v.packagePath {
+		return []string{fmt.Sprintf("it is declared in another package (%s)", obj.Pkg().Path())}
+	}
+	if !obj.Exported() {
+		return []string{"it is not exported"}
+	}
+
+	var ret []string
+	switch named.Underlying().(type) {
+	case *types.Struct, *types.Interface:
+		var intfs []string
+		for _, filter := range v.filters {
+			if intf, ok := filter.(namedInterfaceType); ok {
+				intfs = append(intfs, intf.String())
+			}
+		}
+		if len(intfs) > 0 {
+			ret = append(ret, fmt.Sprintf("it does not implement %s", strings.Join(intfs, " or ")))
+		}
+		if v.Root.Union == "" {
+			break
+		} else if !v.includeReachable {
+			ret = append(ret, "it is not a seed type and --reachable is not set")
+		} else {
+			ret = append(ret, "it is not referenced by an exported field of any visitable type")
+		}
+	default:
+		ret = append(ret, fmt.Sprintf("its underlying type %s does not refer to a visitable type",
+			named.Underlying()))
+	}
+	return ret
+}
+
+// references returns a description of every field in a visitable
+// struct that refers to the named type, either directly or through
+// pointers, slices, and other named types.
+func (v *visitation) references(named *types.Named) []string {
+	var ret []string
+	for _, t := range v.SourceTypes {
+		s, ok := t.(namedStruct)
+		if !ok {
+			continue
+		}
+		for i, j := 0, s.NumFields(); i < j; i++ {
+			f := s.Field(i)
+			if !refersTo(f.Type(), named) {
+				continue
+			}
+			desc := fmt.Sprintf("%s.%s (%s)", s, f.Name(),
+				types.TypeString(f.Type(), types.RelativeTo(named.Obj().Pkg())))
+			if !f.Exported() {
+				desc += ", which is not exported"
+			} else if s.Excluded(i) {
+				desc += ", which is excluded by its walkabout tag"
+			}
+			ret = append(ret, desc)
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// refersTo returns true if typ is named, or is a pointer or slice
+// which ultimately refers to named.
+func refersTo(typ types.Type, named *types.Named) bool {
+	for {
+		switch t := typ.(type) {
+		case *types.Named:
+			if t.Obj() == named.Obj() {
+				return true
+			}
+			switch u := t.Underlying().(type) {
+			case *types.Pointer, *types.Slice:
+				typ = u
+			default:
+				return false
+			}
+		case *types.Pointer:
+			typ = t.Elem()
+		case *types.Slice:
+			typ = t.Elem()
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	tcs := []struct {
+		name     string
+		cfg      string
+		typeName string
+		expect   []string
+		err      string
+	}{
+		{
+			name:     "visitable",
+			cfg:      "single",
+			typeName: "ByRefType",
+			expect: []string{
+				"ByRefType is visitable:\n  *ByRefType implements Target\n",
+				"  ContainerType.ByRefPtr (*ByRefType)\n",
+				"  ContainerType.ignored (ByRefType), which is not exported\n",
+				"traversable as: *ByRefType, []*ByRefType, []ByRefType\n",
+			},
+		},
+		{
+			name:     "unexported",
+			cfg:      "single",
+			typeName: "ignoredType",
+			expect:   []string{"ignoredType is not visitable:\n  it is not exported\n"},
+		},
+		{
+			name:     "not a seed",
+			cfg:      "union",
+			typeName: "ReachableType",
+			expect:   []string{"it is not a seed type and --reachable is not set\n"},
+		},
+		{
+			name:     "reachable",
+			cfg:      "unionReachable",
+			typeName: "ReachableType",
+			expect:   []string{"ReachableType is visitable:\n"},
+		},
+		{
+			name:     "unknown",
+			cfg:      "single",
+			typeName: "NotAType",
+			err:      `unknown type "NotAType"`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+			var sb strings.Builder
+			err := Explain(configs[tc.cfg], tc.typeName, &sb)
+			if tc.err != "" {
+				if a.Error(err) {
+					a.Contains(err.Error(), tc.err)
+				}
+				return
+			}
+			if !a.NoError(err) {
+				return
+			}
+			for _, expect := range tc.expect {
+				a.Contains(sb.String(), expect)
+			}
+		})
+	}
+}
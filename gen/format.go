@@ -0,0 +1,91 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bytes"
+	"go/format"
+	"os/exec"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/imports"
+)
+
+// The supported values for Config.Format.
+const (
+	FormatGofmt     = "gofmt"
+	FormatGoimports = "goimports"
+	FormatGofumpt   = "gofumpt"
+)
+
+var importsMu sync.Mutex
+
+// validateFormat ensures that the requested formatter is known.
+func validateFormat(name string) error {
+	switch name {
+	case "", FormatGofmt, FormatGoimports, FormatGofumpt:
+		return nil
+	default:
+		return errors.Errorf("unknown formatter %q, expecting one of %s, %s, or %s",
+			name, FormatGofmt, FormatGoimports, FormatGofumpt)
+	}
+}
+
+// format formats the generated source using the configured formatter.
+// The goimports formatter will group and sort the imports, with any
+// imports that match LocalPrefix in a separate group. The gofumpt
+// formatter is applied after goimports and requires a gofumpt binary
+// to be available.
+func (g *generation) format(outName string, src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, err
+	}
+
+	switch g.Format {
+	case "", FormatGofmt:
+		return formatted, nil
+	}
+
+	// The local prefix is a global variable in the imports package.
+	importsMu.Lock()
+	imports.LocalPrefix = g.LocalPrefix
+	formatted, err = imports.Process(outName, formatted, &imports.Options{
+		Comments:   true,
+		FormatOnly: true,
+		TabIndent:  true,
+		TabWidth:   8,
+	})
+	importsMu.Unlock()
+	if err != nil || g.Format == FormatGoimports {
+		return formatted, err
+	}
+
+	path, err := exec.LookPath("gofumpt")
+	if err != nil {
+		return nil, errors.Wrap(err, "--format=gofumpt requires gofumpt to be installed")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(formatted)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "gofumpt: %s", stderr.String())
+	}
+	return stdout.Bytes(), nil
+}
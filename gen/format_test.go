@@ -0,0 +1,63 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	a := assert.New(t)
+	outName := filepath.Join("../demo", "target_walkabout.g.go")
+
+	cfg := configs["single"]
+	expected, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+
+	// The built-in templates already group their imports.
+	cfg.Format = FormatGoimports
+	cfg.LocalPrefix = "github.com/cockroachdb"
+	outputs, err := Generate(cfg)
+	if a.NoError(err) {
+		a.Equal(string(expected[outName]), string(outputs[outName]))
+	}
+
+	// Use a stand-in for gofumpt.
+	if runtime.GOOS != "windows" {
+		bin := t.TempDir()
+		a.NoError(os.WriteFile(filepath.Join(bin, "gofumpt"),
+			[]byte("#!/bin/sh\ncat\necho '// gofumpt'\n"), 0755))
+		t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+		cfg.Format = FormatGofumpt
+		outputs, err = Generate(cfg)
+		if a.NoError(err) {
+			a.True(strings.HasSuffix(string(outputs[outName]), "\n// gofumpt\n"))
+		}
+	}
+
+	cfg.Format = "nope"
+	_, err = Generate(cfg)
+	a.EqualError(err, `unknown formatter "nope", expecting one of gofmt, goimports, or gofumpt`)
+}
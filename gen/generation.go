@@ -16,38 +16,179 @@
 package gen
 
 import (
+	"bytes"
+	"fmt"
 	"go/token"
 	"go/types"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"golang.org/x/tools/go/packages"
 )
 
-type config struct {
-	dir string
+// Config describes a single run of the code generator.
+type Config struct {
+	// The names of visitor interfaces in the package, with VisitPre or
+	// VisitPost methods, for which adapters will be generated.
+	Adapters []string
+	// If true, the target package will be type-checked with the
+	// generated code before any files are written.
+	Check bool
+	// If true, previously-generated files will be ignored when loading
+	// the package, as though they had been deleted. This allows a new
+	// struct to be found when the seed interface embeds a generated
+	// interface. Generated files which contain errors are always
+	// ignored.
+	Clean bool
+	// If true, fields and types which will not be visited, but which
+	// refer to visitable types, will be reported to Log along with the
+	// position of their declaration.
+	Diagnostics bool
+	// Dir is the directory containing the package to operate on.
+	Dir string
+	// The formatter to apply to the generated code, one of the Format
+	// constants. The default is FormatGofmt.
+	Format string
+	// If present, the generated code will not use any language
+	// features or library functions newer than this version of Go,
+	// e.g. "1.22". The default is the version declared in the target
+	// package's go.mod file.
+	GoVersion string
+	// If true, a struct which has an Equal method that accepts the
+	// struct or a pointer to it will be compared with its replacement
+	// using that method. A replacement which is equal to the value it
+	// replaces is not a change, so the enclosing values are not cloned.
+	EqualMethods bool
+	// If true, the generated code will have no package-level state.
+	// Instead, an Engine type is generated, which callers construct and
+	// use to walk values.
+	ExplicitEngine bool
+	// If true, a _test.go file of runnable examples, which walk one of
+	// the visitable structs, will be written next to the generated code.
+	Examples bool
+	// If true, the generated Context, Decision, Action, and WalkerFn
+	// types will be aliases of generic types in the engine package. This
+	// omits the methods which accept pointers to implementations of the
+	// visitable interface.
+	Generics bool
+	// If positive, structs with at most this many visitable fields will
+	// have specialized walkers which do not use the engine's stack.
+	// Values which are visited inline have no path or abstract
+	// accessor, and are not seen by the engine's metrics or alias and
+	// race checks.
+	InlineFields int
+	// If present, the name of a file to which a JSON Schema that
+	// describes the map form of the visitable types will be written.
+	JSONSchema string
+	// If present, imports beginning with this prefix will be grouped
+	// separately when using FormatGoimports or FormatGofumpt.
+	LocalPrefix string
+	// If true, the generated methods for each struct will be attributed
+	// to the struct's declaration using //line directives.
+	LineDirectives bool
+	// Log receives diagnostic messages. If nil, messages will be
+	// written to os.Stderr.
+	Log io.Writer
+	// If present, the name of a file which records the values assigned
+	// to TypeID constants. The file will be updated to include any new
+	// types and existing values will never be re-assigned.
+	Manifest string
 	// If present, overrides the output file name.
-	outFile string
+	OutFile string
+	// If present, the import path of the package to operate on. It
+	// is resolved relative to Dir, which allows Dir to be any
+	// directory within a module or go.work workspace.
+	Package string
+	// If present, the name of a file to which a proto3 schema that
+	// describes the visitable types will be written. No code is
+	// generated to convert values to or from its messages.
+	Proto string
+	// Renames maps generated identifiers, as they would otherwise be
+	// named, to replacement names. This allows a generated method to be
+	// renamed if it would collide with a field or method of a visitable
+	// struct.
+	Renames map[string]string
+	// Plugins will be invoked after the built-in templates. This
+	// option is only available when using Generate().
+	Plugins []Plugin
+	// If true, a breakdown of the size of the generated code, by type
+	// and by section, will be written to Log.
+	ReportSize bool
 	// Include all types reachable from visitable types that implement
 	// the root visitable interface.
-	reachable bool
+	Reachable bool
+	// If present, *.tmpl files in this directory will override the
+	// built-in template with the same base name, or will be appended
+	// to the generated output if there is no such built-in template.
+	TemplateDir string
+	// If true, a status line will be written to Log if generation takes
+	// more than a few seconds.
+	Progress bool
+	// Controls whether the generated code is written to a _test.go file.
+	Test TestMode
 	// The requested type names.
-	typeNames []string
+	TypeNames []string
+	// If true, the generated TypeID constants will contain the names of
+	// the types, instead of arbitrary integer values.
+	StringTypeIDs bool
+	// If true, all generated identifiers will be un-exported.
+	Unexported bool
 	// If present, unifies all specified interfaces under a single
 	// visitable interface with this name.
-	union string
+	Union string
+	// Controls the amount of diagnostic output written to Log.
+	Verbosity Verbosity
+}
+
+// Generate runs the code generator in-process and returns the
+// generated sources, keyed by the file name that the walkabout tool
+// would have written to. Nothing is written to disk, which allows
+// build tools to embed walkabout without shelling out.
+func Generate(cfg Config) (map[string][]byte, error) {
+	g, err := newGeneration(cfg)
+	if err != nil {
+		return nil, err
+	}
+	outputs := make(map[string][]byte)
+	var mu sync.Mutex
+	g.writeCloser = func(name string) (io.WriteCloser, error) {
+		return newMapWriter(name, &mu, outputs), nil
+	}
+	if err := g.Execute(); err != nil {
+		return nil, err
+	}
+	return outputs, nil
 }
 
 // generation represents an entire run of the code generator. The
 // overall flow is broken up into various stages, which can be seen in
 // Execute().
 type generation struct {
-	config
+	Config
 
+	// Replaces previously-generated files with empty ones when loading.
+	cleanSource map[string][]byte
 	// Allows additional files to be added to the parse phase for testing.
 	extraTestSource map[string][]byte
-	fileSet         token.FileSet
+	// May be shared between generations which load the same package.
+	fileSet *token.FileSet
+	// Reports progress, if enabled.
+	progress *progress
+	// Set if cgo must be enabled to load the package.
+	forceCgo bool
+	// The absolute names of configured output files, which are treated
+	// as previously-generated code.
+	outFiles map[string]bool
+	// Set if the generation is one of several roots which share an
+	// output file, so that adapters for the other roots are ignored.
+	otherRoots bool
+	// The import path of a package, imported by the package being
+	// operated on, which declares the qualified seed types.
+	seedPackage string
 	// Stores the executed visitation for testing.
 	visitation  *visitation
 	writeCloser func(name string) (io.WriteCloser, error)
@@ -55,72 +196,296 @@ type generation struct {
 
 // newGeneration constructs a generation which will look for the
 // named interface types in the given directory.
-func newGeneration(cfg config) (*generation, error) {
-	if len(cfg.typeNames) > 1 && cfg.union == "" {
-		return nil, errors.New("multiple input types can only be used with --union")
+func newGeneration(cfg Config) (*generation, error) {
+	if len(cfg.TypeNames) == 0 {
+		return nil, errors.New("at least one input type is required")
+	}
+	if len(cfg.TypeNames) > 1 && cfg.Union == "" {
+		switch {
+		case cfg.Manifest != "":
+			return nil, errors.New("--manifest requires a single input type or --union")
+		case cfg.Proto != "":
+			return nil, errors.New("--proto requires a single input type or --union")
+		case cfg.JSONSchema != "":
+			return nil, errors.New("--json-schema requires a single input type or --union")
+		}
 	}
-	if cfg.reachable && cfg.union == "" {
+	if cfg.Reachable && cfg.Union == "" {
 		return nil, errors.New("--reachable can only be used with --union")
 	}
+	if cfg.Manifest != "" && cfg.StringTypeIDs {
+		return nil, errors.New("--manifest cannot be used with --string-ids")
+	}
+	if cfg.ExplicitEngine && cfg.InlineFields > 0 {
+		return nil, errors.New("--inline cannot be used with --explicit-engine")
+	}
+	if cfg.Examples && (cfg.ExplicitEngine || cfg.Unexported) {
+		return nil, errors.New("--examples cannot be used with --explicit-engine or --unexported")
+	}
+	for from, to := range cfg.Renames {
+		if !token.IsIdentifier(from) || !token.IsIdentifier(to) {
+			return nil, errors.Errorf("--rename %s=%s must map an identifier to an identifier", from, to)
+		}
+	}
+	if err := validateFormat(cfg.Format); err != nil {
+		return nil, err
+	}
+	if cfg.GoVersion != "" {
+		if _, err := parseGoVersion(cfg.GoVersion); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "."
+	}
+	outFiles := make(map[string]bool)
+	if cfg.OutFile != "" && cfg.OutFile != "-" {
+		outFile, err := filepath.Abs(cfg.OutFile)
+		if err != nil {
+			return nil, err
+		}
+		outFiles[outFile] = true
+	}
 	return &generation{
-		config: cfg,
+		Config:   cfg,
+		fileSet:  token.NewFileSet(),
+		outFiles: outFiles,
 		writeCloser: func(name string) (io.WriteCloser, error) {
 			if name == "-" {
 				return os.Stdout, nil
 			}
-			return os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+			return newAtomicWriter(name)
 		},
 	}, nil
 }
 
 // Execute runs the complete code-generation cycle.
 func (g *generation) Execute() error {
+	defer g.startProgress()()
+	pkgs, err := g.loadPackages()
+	if err != nil {
+		return err
+	}
+	return g.executePackages(pkgs)
+}
+
+// executePackages runs the code-generation cycle using the
+// previously-loaded packages. Each of several input types without a
+// --union is generated as its own root.
+func (g *generation) executePackages(pkgs []*packages.Package) error {
+	if g.multipleRoots() {
+		return g.executeRoots(pkgs)
+	}
+	v, err := g.analyzePackages(pkgs)
+	if err != nil {
+		return err
+	}
+	return v.generateAPI()
+}
+
+// analyze loads the package and determines which types will be
+// visitable, without generating any code.
+func (g *generation) analyze() (*visitation, error) {
+	if g.multipleRoots() {
+		return nil, errors.New("multiple input types can only be used with --union")
+	}
+	pkgs, err := g.loadPackages()
+	if err != nil {
+		return nil, err
+	}
+	return g.analyzePackages(pkgs)
+}
+
+// loadPackages resolves any qualified seed types and loads the
+// packages to operate on.
+func (g *generation) loadPackages() ([]*packages.Package, error) {
 	// This will return multiple packages.Package if we're also loading
 	// test files. Note that the error here is whether or not the Load()
 	// was able to perform its work. The underlying source may still have
 	// syntax/type errors, but we ignore that in case of a "make clean"
 	// situation, where we're likely to see code that depends on generated
 	// code.
-	pkgs, err := packages.Load(g.packageConfig(), ".")
+	if err := g.resolveQualifiedSeeds(); err != nil {
+		return nil, err
+	}
+
+	done := g.timed(Verbose, "package loading")
+	g.progress.setPhase("loading packages")
+	pkgs, err := g.load()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	pkgs, err = g.loadClean(pkgs)
+	if err != nil {
+		return nil, err
+	}
+	done()
+	return pkgs, nil
+}
+
+// analyzePackages determines which types will be visitable from the
+// previously-loaded packages.
+func (g *generation) analyzePackages(pkgs []*packages.Package) (*visitation, error) {
+	var err error
+
+	// Ignore test variants and the synthesized test binary.
+	var primary []string
+	for _, pkg := range pkgs {
+		if pkg.ID == pkg.PkgPath && !strings.HasSuffix(pkg.PkgPath, ".test") {
+			primary = append(primary, pkg.PkgPath)
+		}
+	}
+	if len(primary) > 1 {
+		return nil, errors.Errorf("%s matches multiple packages: %s",
+			g.pattern(), strings.Join(primary, ", "))
 	}
 
 	v := &visitation{
+		dir:              g.Dir,
 		gen:              g,
-		includeReachable: g.config.reachable,
+		includeReachable: g.Reachable,
 		packagePath:      pkgs[0].PkgPath,
 		Types:            make(map[TypeID]visitableType),
 		SourceTypes:      make(map[SourceName]visitableType),
 	}
 	g.visitation = v
 
+	// Generate code alongside the package's sources, which may not be
+	// in Dir if a package was named explicitly.
+	if g.Package != "" && len(pkgs[0].GoFiles) > 0 {
+		v.dir = filepath.Dir(pkgs[0].GoFiles[0])
+	}
+	g.logf(Verbose, "loaded %s from %s", v.packagePath, v.dir)
+
+	// Determine the language version to target.
+	goVersion := g.GoVersion
+	if goVersion == "" && pkgs[0].Module != nil {
+		goVersion = pkgs[0].Module.GoVersion
+	}
+	v.goMinor = minGoVersion
+	if goVersion != "" {
+		if v.goMinor, err = parseGoVersion(goVersion); err != nil {
+			return nil, err
+		}
+	}
+	g.logf(Verbose, "generating code for go1.%d", v.goMinor)
+
+	if g.Manifest != "" {
+		if v.Manifest, err = readManifest(g.Manifest); err != nil {
+			return nil, err
+		}
+	}
+
 	// Synthesize a union interface, if configured.
-	if g.config.union != "" {
+	if g.Union != "" {
 		v.Root = namedInterfaceType{
-			Union: g.union,
+			Union: g.Union,
 			v:     v,
 		}
 	}
 
-	scopes := make([]*types.Scope, len(pkgs))
+	v.scopes = make([]*types.Scope, len(pkgs))
 	for idx, pkg := range pkgs {
-		scopes[idx] = pkg.Types.Scope()
+		v.scopes[idx] = pkg.Types.Scope()
 	}
 
-	if err := v.findSeedTypes(scopes); err != nil {
-		return err
+	if g.seedPackage != "" {
+		if v.seedScope = importedScope(pkgs, g.seedPackage); v.seedScope == nil {
+			return nil, errors.Errorf("could not load %s", g.seedPackage)
+		}
 	}
-	v.populateGeneratedTypes(scopes)
-	return v.generateAPI()
+
+	done := g.timed(Verbose, "type analysis")
+	g.progress.setPhase("analyzing types")
+	if err := v.findSeedTypes(v.scopes); err != nil {
+		return nil, err
+	}
+	if err := v.findRootDeclaration(); err != nil {
+		return nil, err
+	}
+	v.applyTestMode()
+	if g.Union != "" {
+		if err := v.findUnionDeclaration(); err != nil {
+			return nil, err
+		}
+	}
+	v.populateGeneratedTypes(v.scopes)
+	if err := v.checkTags(); err != nil {
+		return nil, err
+	}
+	if err := v.findAdapters(); err != nil {
+		return nil, err
+	}
+	done()
+	g.logf(Verbose, "found %d seed types, %d visitable source types, %d traversable types",
+		len(v.filters), len(v.SourceTypes), len(v.Types))
+
+	if g.Diagnostics {
+		for _, d := range v.diagnostics() {
+			fmt.Fprintln(g.logWriter(), d)
+		}
+	}
+	return v, nil
+}
+
+// pattern returns the package pattern to load.
+func (g *generation) pattern() string {
+	if g.Package == "" {
+		return "."
+	}
+	return g.Package
 }
 
 func (g *generation) packageConfig() *packages.Config {
+	overlay := g.extraTestSource
+	if len(g.cleanSource) > 0 {
+		overlay = make(map[string][]byte, len(g.cleanSource)+len(g.extraTestSource))
+		for k, v := range g.cleanSource {
+			overlay[k] = v
+		}
+		for k, v := range g.extraTestSource {
+			overlay[k] = v
+		}
+	}
 	return &packages.Config{
-		Dir:     g.dir,
-		Fset:    &g.fileSet,
-		Mode:    packages.LoadTypes,
-		Overlay: g.extraTestSource,
+		Dir:     g.Dir,
+		Fset:    g.fileSet,
+		Mode:    packages.LoadTypes | packages.NeedModule,
+		Overlay: overlay,
 		Tests:   true,
 	}
 }
+
+// mapWriter is a trivial implementation of io.WriteCloser that captures
+// its output in a map. Access to the map is synchronized via a
+// shared mutex.
+type mapWriter struct {
+	buf  bytes.Buffer
+	name string
+	mu   struct {
+		*sync.Mutex
+		dest map[string][]byte
+	}
+}
+
+func newMapWriter(name string, mu *sync.Mutex, outputs map[string][]byte) io.WriteCloser {
+	ret := &mapWriter{name: name}
+	ret.mu.Mutex = mu
+	ret.mu.dest = outputs
+	return ret
+}
+
+// Write implements io.Writer.
+func (w *mapWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+// Close implements io.Closer.
+func (w *mapWriter) Close() error {
+	w.mu.Lock()
+	if w.mu.dest != nil {
+		w.mu.dest[w.name] = w.buf.Bytes()
+	}
+	w.mu.Unlock()
+	return nil
+}
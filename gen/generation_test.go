@@ -16,9 +16,12 @@
 package gen
 
 import (
-	"bytes"
+	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"sync"
 	"testing"
 
@@ -26,30 +29,32 @@ import (
 	"golang.org/x/tools/go/packages"
 )
 
-var configs = map[string]config{
+var configs = map[string]Config{
 	"single": {
-		dir:       "../demo",
-		typeNames: []string{"Target"},
+		Adapters:     []string{"TargetVisitor"},
+		Dir:          "../demo",
+		EqualMethods: true,
+		TypeNames:    []string{"Target"},
 	},
 	"union": {
-		dir:       "../demo",
-		typeNames: []string{"Target", "Unionable"},
-		union:     "Union",
+		Dir:       "../demo",
+		TypeNames: []string{"Target", "Unionable"},
+		Union:     "Union",
 	},
 	"unionReachable": {
-		dir:       "../demo",
-		typeNames: []string{"Target", "Unionable"},
-		union:     "Union",
-		reachable: true},
+		Dir:       "../demo",
+		TypeNames: []string{"Target", "Unionable"},
+		Union:     "Union",
+		Reachable: true},
 	"structUnion": {
-		dir:       "../demo",
-		typeNames: []string{"ContainerType", "ByValType"},
-		union:     "Union"},
+		Dir:       "../demo",
+		TypeNames: []string{"ContainerType", "ByValType"},
+		Union:     "Union"},
 	"structUnionReachable": {
-		dir:       "../demo",
-		typeNames: []string{"ContainerType"},
-		union:     "Union",
-		reachable: true},
+		Dir:       "../demo",
+		TypeNames: []string{"ContainerType"},
+		Union:     "Union",
+		Reachable: true},
 }
 
 // Verify that our example data in the demo package is correct and
@@ -69,10 +74,10 @@ func TestExampleData(t *testing.T) {
 				if err != nil {
 					return nil, "", err
 				}
-				if cfg.union == "" {
-					return g, cfg.typeNames[0], nil
+				if cfg.Union == "" {
+					return g, cfg.TypeNames[0], nil
 				}
-				return g, cfg.union, nil
+				return g, cfg.Union, nil
 			}
 
 			g, prefix, err := newGeneration()
@@ -100,13 +105,15 @@ func TestExampleData(t *testing.T) {
 					"InterfacePtrSlice", "NamedTargets")
 
 			case "unionReachable":
-				a.Len(v.Types, 22)
+				a.Len(v.Types, 21)
 				v.checkStructInfo(a, "ContainerType", "ByRef", "ByRefPtr", "ByRefSlice", "ByRefPtrSlice",
 					"ByVal", "ByValPtr", "ByValSlice", "ByValPtrSlice", "Container", "AnotherTarget",
 					"AnotherTargetPtr", "EmbedsTarget", "EmbedsTargetPtr", "TargetSlice",
 					"InterfacePtrSlice", "NamedTargets", "UnionableType", "ReachableType")
 				v.checkStructInfo(a, "ReachableType")
-				a.Equal(cfg.union, v.Root.Union)
+				// No field refers to a *ReachableType.
+				a.NotContains(v.Types, TypeID("UnionTypeReachableTypePtr"))
+				a.Equal(cfg.Union, v.Root.Union)
 
 			case "union":
 				a.Len(v.Types, 20)
@@ -115,23 +122,23 @@ func TestExampleData(t *testing.T) {
 					"AnotherTargetPtr", "EmbedsTarget", "EmbedsTargetPtr", "TargetSlice", "InterfacePtrSlice",
 					"NamedTargets", "UnionableType")
 				v.checkStructInfo(a, "UnionableType")
-				a.Equal(cfg.union, v.Root.Union)
+				a.Equal(cfg.Union, v.Root.Union)
 
 			case "structUnion":
 				a.Len(v.Types, 11)
 				v.checkStructInfo(a, "ContainerType", "ByRef", "ByRefPtr", "ByRefSlice", "ByRefPtrSlice",
 					"ByVal", "ByValPtr", "ByValSlice", "ByValPtrSlice", "Container")
-				a.Equal(cfg.union, v.Root.Union)
+				a.Equal(cfg.Union, v.Root.Union)
 				expectTarget = false
 
 			case "structUnionReachable":
-				a.Len(v.Types, 21)
+				a.Len(v.Types, 20)
 				v.checkStructInfo(a, "ContainerType", "ByRef", "ByRefPtr", "ByRefSlice", "ByRefPtrSlice",
 					"ByVal", "ByValPtr", "ByValSlice", "ByValPtrSlice", "Container", "AnotherTarget",
 					"AnotherTargetPtr", "EmbedsTarget", "EmbedsTargetPtr", "TargetSlice",
 					"InterfacePtrSlice", "NamedTargets", "UnionableType", "ReachableType")
 				v.checkStructInfo(a, "ReachableType")
-				a.Equal(cfg.union, v.Root.Union)
+				a.Equal(cfg.Union, v.Root.Union)
 				expectTarget = false
 
 			default:
@@ -186,6 +193,197 @@ func TestOutputIsStable(t *testing.T) {
 	}
 }
 
+// Ensure that the library entry point produces the same output as
+// the checked-in demo code.
+func TestGenerate(t *testing.T) {
+	a := assert.New(t)
+
+	outputs, err := Generate(configs["single"])
+	if !a.NoError(err) {
+		return
+	}
+	name := filepath.Join("../demo", "target_walkabout.g.go")
+	if a.Contains(outputs, name) {
+		expected, err := os.ReadFile(name)
+		if a.NoError(err) {
+			a.Equal(string(expected), string(outputs[name]))
+		}
+	}
+
+	_, err = Generate(Config{Dir: "../demo"})
+	a.EqualError(err, "at least one input type is required")
+}
+
+// Verify that user-provided templates can extend and override the
+// built-in templates.
+func TestTemplateDir(t *testing.T) {
+	a := assert.New(t)
+	dir := t.TempDir()
+
+	a.NoError(os.WriteFile(filepath.Join(dir, "00header.tmpl"), []byte(`
+// Custom header
+package {{ Package . }}
+
+import (
+	"fmt"
+	"unsafe"
+
+	e "github.com/cockroachdb/walkabout/engine"
+)
+`), 0644))
+	a.NoError(os.WriteFile(filepath.Join(dir, "99extra.tmpl"), []byte(`
+const {{ T . "Extra" }} = "extra"
+`), 0644))
+
+	cfg := configs["single"]
+	cfg.TemplateDir = dir
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	for _, out := range outputs {
+		a.Contains(string(out), "// Custom header")
+		a.NotContains(string(out), "DO NOT EDIT")
+		a.Contains(string(out), `const TargetExtra = "extra"`)
+	}
+
+	cfg.TemplateDir = t.TempDir()
+	_, err = Generate(cfg)
+	a.Error(err)
+}
+
+// Verify that the generic facade types can be used.
+func TestGenerics(t *testing.T) {
+	for _, name := range []string{"single", "union"} {
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			cfg := configs[name]
+			expected, err := Generate(cfg)
+			if !a.NoError(err) {
+				return
+			}
+
+			cfg.Generics = true
+			outputs, err := Generate(cfg)
+			if !a.NoError(err) {
+				return
+			}
+			for file, out := range outputs {
+				a.Contains(string(out), "Context = e.TypedContext[")
+				a.Contains(string(out), "ReplacementError = e.ReplacementError")
+				a.NotContains(string(out), "ReplaceByValType")
+				a.True(len(out) < len(expected[file]), file)
+			}
+			// The test files in the demo package call methods which are
+			// not generated when using generics.
+			checkOutputs(a, cfg, outputs, false)
+		})
+	}
+}
+
+// Verify that large interfaces are dispatched using a map.
+func TestMapDispatch(t *testing.T) {
+	a := assert.New(t)
+
+	extra, err := filepath.Abs("../demo/wide_extra.go")
+	if !a.NoError(err) {
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString("package demo\n")
+	for i := 0; i < mapDispatchThreshold; i++ {
+		fmt.Fprintf(&sb, "type Wide%[1]d struct{}\nfunc (*Wide%[1]d) Value() string { return \"\" }\n", i)
+	}
+
+	outputs := make(map[string][]byte)
+	g, err := newGenerationForTesting(configs["single"], outputs)
+	if !a.NoError(err) {
+		return
+	}
+	g.extraTestSource = map[string][]byte{extra: []byte(sb.String())}
+	if !a.NoError(g.Execute()) {
+		return
+	}
+
+	for _, out := range outputs {
+		src := string(out)
+		// Target has enough implementations, but EmbedsTarget does not.
+		a.Equal(1, strings.Count(src, "IntfImpls: []e.IntfImpl{"))
+		a.Contains(src, "{Sample: e.Ptr(&[]Target{*new(*Wide0)}[0]), TypeID: e.TypeID(TargetTypeWide0)},")
+		a.Contains(src, "{Sample: e.Ptr(&[]Target{*new(ByValType)}[0]), TypeID: e.TypeID(TargetTypeByValType)},")
+	}
+
+	pcfg := g.packageConfig()
+	pcfg.Mode = packages.LoadAllSyntax
+	pcfg.Overlay = outputs
+	pcfg.Overlay[extra] = g.extraTestSource[extra]
+	pkgs, err := packages.Load(pcfg, ".")
+	if a.NoError(err) {
+		for _, pkg := range pkgs {
+			a.Nil(pkg.Errors)
+		}
+	}
+}
+
+// Verify that string-valued TypeIDs can be generated.
+func TestStringTypeIDs(t *testing.T) {
+	for _, name := range []string{"single", "union"} {
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			cfg := configs[name]
+			cfg.StringTypeIDs = true
+
+			outputs, err := Generate(cfg)
+			if !a.NoError(err) {
+				return
+			}
+			for _, out := range outputs {
+				a.Regexp(`TypeContainerTypePtr\s+\w+TypeID = "\*ContainerType"\n`, string(out))
+				a.NotContains(string(out), "Stringify")
+				// Engine ids must be looked up, since converting them to
+				// a string type would yield a one-rune string.
+				a.Regexp(`TypeID: \w+TypeIDs\[id\]\}`, string(out))
+				a.NotRegexp(`\w+TypeID\(id\)`, string(out))
+			}
+			checkOutputs(a, cfg, outputs, true)
+
+			cfg.Manifest = "manifest.txt"
+			_, err = Generate(cfg)
+			a.EqualError(err, "--manifest cannot be used with --string-ids")
+		})
+	}
+}
+
+// Verify that no exported identifiers are generated when requested.
+func TestUnexported(t *testing.T) {
+	for _, name := range []string{"single", "union"} {
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			cfg := configs[name]
+			cfg.Unexported = true
+
+			outputs, err := Generate(cfg)
+			if !a.NoError(err) {
+				return
+			}
+			exported := regexp.MustCompile(
+				`(?m)^(func [A-Z]|func \(x? ?\*[A-Z]\w*\) [A-Z]|type [A-Z])`)
+			for _, out := range outputs {
+				src := string(out)
+				if cfg.Union != "" {
+					src = strings.Replace(src, "type "+cfg.Union+" ", "", 1)
+				}
+				a.Empty(exported.FindAllString(src, -1))
+				a.Contains(src, "func walk")
+			}
+
+			// The test files in the demo package use the exported API, so
+			// we'll only type-check the production code.
+			checkOutputs(a, cfg, outputs, false)
+		})
+	}
+}
+
 func (v *visitation) checkVisitableInterface(a *assert.Assertions, name SourceName) {
 	found := v.SourceTypes[name]
 	if a.NotNilf(found, "%s", name) {
@@ -212,9 +410,33 @@ func (v *visitation) checkStructInfo(a *assert.Assertions, name SourceName, hasF
 	}
 }
 
+// checkOutputs verifies that the package will type-check when the
+// generated outputs are added to it.
+func checkOutputs(a *assert.Assertions, cfg Config, outputs map[string][]byte, tests bool) {
+	overlay := make(map[string][]byte, len(outputs))
+	for name, src := range outputs {
+		name, err := filepath.Abs(name)
+		if !a.NoError(err) {
+			return
+		}
+		overlay[name] = src
+	}
+	pkgs, err := packages.Load(&packages.Config{
+		Dir:     cfg.Dir,
+		Mode:    packages.LoadAllSyntax,
+		Overlay: overlay,
+		Tests:   tests,
+	}, ".")
+	if a.NoError(err) {
+		for _, pkg := range pkgs {
+			a.Nil(pkg.Errors)
+		}
+	}
+}
+
 // newGenerationForTesting creates a generator that captures
 // its output in the provided map.
-func newGenerationForTesting(cfg config, outputs map[string][]byte) (*generation, error) {
+func newGenerationForTesting(cfg Config, outputs map[string][]byte) (*generation, error) {
 	g, err := newGeneration(cfg)
 	if err != nil {
 		return nil, err
@@ -231,36 +453,101 @@ func newGenerationForTesting(cfg config, outputs map[string][]byte) (*generation
 	return g, nil
 }
 
-// mapWriter is a trivial implementation of io.WriteCloser that captures
-// its output in a map. Access to the map is synchronized via a
-// shared mutex.
-type mapWriter struct {
-	buf  bytes.Buffer
-	name string
-	mu   struct {
-		*sync.Mutex
-		dest map[string][]byte
+// Verify that an explicit engine can be generated without any
+// package-level state.
+func TestExplicitEngine(t *testing.T) {
+	for _, name := range []string{"single", "union"} {
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			cfg := configs[name]
+			cfg.ExplicitEngine = true
+
+			outputs, err := Generate(cfg)
+			if !a.NoError(err) {
+				return
+			}
+			root := cfg.TypeNames[0]
+			if cfg.Union != "" {
+				root = cfg.Union
+			}
+			for _, out := range outputs {
+				src := string(out)
+				a.Contains(src, fmt.Sprintf("func New%[1]sEngine() *%[1]sEngine {", root))
+				a.Contains(src, fmt.Sprintf("func (eng *%[1]sEngine) Walk%[1]s(", root))
+				a.NotContains(src, "sync.Once")
+				a.NotContains(src, "func init()")
+				a.NotRegexp(`(?m)^var [^_(]`, src)
+				// Qualified names are generated, rather than computed
+				// by the engine.
+				a.Contains(src, `return "[]*github.com/cockroachdb/walkabout/demo.ByRefType"`)
+				a.Contains(src, fmt.Sprintf(`return "github.com/cockroachdb/walkabout/demo.%s"`, root))
+			}
+			// The test files in the demo package use the package-level
+			// API, so we'll only type-check the production code.
+			checkOutputs(a, cfg, outputs, false)
+
+			cfg.InlineFields = 2
+			_, err = Generate(cfg)
+			a.EqualError(err, "--inline cannot be used with --explicit-engine")
+		})
 	}
 }
 
-func newMapWriter(name string, mu *sync.Mutex, outputs map[string][]byte) io.WriteCloser {
-	ret := &mapWriter{name: name}
-	ret.mu.Mutex = mu
-	ret.mu.dest = outputs
-	return ret
-}
+// Verify that Equal methods are called only when requested.
+func TestEqualMethods(t *testing.T) {
+	a := assert.New(t)
+	cfg := configs["single"]
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	for _, out := range outputs {
+		a.Contains(string(out), "Equal: func(a, b e.Ptr) bool { return (*ByValType)(a).Equal(*(*ByValType)(b)) },")
+	}
 
-// Write implements io.Writer.
-func (w *mapWriter) Write(p []byte) (int, error) {
-	return w.buf.Write(p)
+	cfg.EqualMethods = false
+	outputs, err = Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	for _, out := range outputs {
+		a.NotContains(string(out), "Equal: func(a, b e.Ptr)")
+	}
 }
 
-// Close implements io.Closer.
-func (w *mapWriter) Close() error {
-	w.mu.Lock()
-	if w.mu.dest != nil {
-		w.mu.dest[w.name] = w.buf.Bytes()
+// Verify that the function which wraps a replacement of the root has a
+// case for every struct, so that a top-level Replace works whether or
+// not the root is a --union interface.
+func TestWrapCases(t *testing.T) {
+	cfgs := map[string]Config{
+		"imported": {Dir: "../demo/ast", TypeNames: []string{"base.Node"}},
+	}
+	for name, cfg := range configs {
+		cfgs[name] = cfg
+	}
+	abstractRe := regexp.MustCompile(`(?m)^\t_ \w+Abstract = &(\w+)\{\}$`)
+	wrapRe := regexp.MustCompile(`(?s)\nfunc [a-z]\w*Wrap\(typeId e\.TypeID, x e\.Ptr\) [\w.]+ \{\n.*?\n\}\n`)
+
+	for name, cfg := range cfgs {
+		cfg := cfg
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			outputs, err := Generate(cfg)
+			if !a.NoError(err) {
+				return
+			}
+			for _, out := range outputs {
+				src := string(out)
+				wrap := wrapRe.FindString(src)
+				if !a.NotEmpty(wrap) {
+					continue
+				}
+				structs := abstractRe.FindAllStringSubmatch(src, -1)
+				a.NotEmpty(structs)
+				for _, s := range structs {
+					a.Contains(wrap, fmt.Sprintf("return (*%s)(x)", s[1]))
+				}
+			}
+		})
 	}
-	w.mu.Unlock()
-	return nil
 }
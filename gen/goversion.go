@@ -0,0 +1,60 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// minGoVersion is the oldest language version that the engine package
+// supports, since it uses generics and the min builtin. The generated
+// code imports the engine, so it cannot support an older version. This
+// is also assumed when the target package's module does not declare a
+// version.
+const minGoVersion = 21
+
+var goVersionPattern = regexp.MustCompile(`^(?:go)?1\.(\d+)(?:\.\d+)?$`)
+
+// parseGoVersion parses a version such as "1.22", "go1.22", or "1.22.3"
+// and returns its minor version number.
+func parseGoVersion(version string) (int, error) {
+	match := goVersionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return 0, errors.Errorf("could not parse Go version %q", version)
+	}
+	minor, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, errors.Wrap(err, version)
+	}
+	if minor < minGoVersion {
+		return 0, errors.Errorf("%q is older than the minimum supported Go version 1.%d",
+			version, minGoVersion)
+	}
+	return minor, nil
+}
+
+// GoAtLeast returns true if the generated code may use language
+// features and library functions introduced in the given version.
+func (v *visitation) GoAtLeast(version string) (bool, error) {
+	minor, err := parseGoVersion(version)
+	if err != nil {
+		return false, err
+	}
+	return v.goMinor >= minor, nil
+}
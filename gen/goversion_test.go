@@ -0,0 +1,98 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGoVersion(t *testing.T) {
+	tcs := []struct {
+		version string
+		minor   int
+		err     string
+	}{
+		{version: "1.21", minor: 21},
+		{version: "go1.22", minor: 22},
+		{version: "1.23.3", minor: 23},
+		{version: "1.17", err: `"1.17" is older than the minimum supported Go version 1.21`},
+		{version: "2.0", err: `could not parse Go version "2.0"`},
+		{version: "latest", err: `could not parse Go version "latest"`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.version, func(t *testing.T) {
+			a := assert.New(t)
+			minor, err := parseGoVersion(tc.version)
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+			} else if a.NoError(err) {
+				a.Equal(tc.minor, minor)
+			}
+		})
+	}
+}
+
+func TestGoVersion(t *testing.T) {
+	a := assert.New(t)
+
+	// The default is taken from the module's go.mod file.
+	g, err := newGeneration(configs["single"])
+	if !a.NoError(err) {
+		return
+	}
+	v, err := g.analyze()
+	if !a.NoError(err) {
+		return
+	}
+	ok, err := v.GoAtLeast("1.22")
+	a.NoError(err)
+	a.True(ok)
+
+	cfg := configs["single"]
+	cfg.GoVersion = "1.21"
+	g, err = newGeneration(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	v, err = g.analyze()
+	if !a.NoError(err) {
+		return
+	}
+	ok, err = v.GoAtLeast("1.22")
+	a.NoError(err)
+	a.False(ok)
+
+	// Generic functions are generated for the oldest supported version.
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	for file, out := range outputs {
+		a.Contains(string(out), "func WalkTargetAs[T Target](", file)
+	}
+
+	// The engine cannot be built by older versions.
+	cfg.GoVersion = "1.17"
+	_, err = Generate(cfg)
+	a.EqualError(err, `"1.17" is older than the minimum supported Go version 1.21`)
+
+	cfg.GoVersion = "bogus"
+	_, err = newGeneration(cfg)
+	a.EqualError(err, `could not parse Go version "bogus"`)
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cockroachdb/walkabout/gen/view"
+	"github.com/pkg/errors"
+)
+
+// The diagram formats supported by Graph.
+const (
+	GraphDOT     = "dot"
+	GraphMermaid = "mermaid"
+)
+
+// graphEdge connects two struct or interface types.
+type graphEdge struct {
+	from, to *view.Type
+	// The field which refers to the target, if the edge is not from an
+	// interface to one of its implementations.
+	label string
+}
+
+// Graph writes a diagram of the types that the configuration would
+// make visitable, in either DOT or Mermaid format. Each struct and
+// interface type is a node. A struct has an edge to each struct or
+// interface that one of its fields refers to, either directly or
+// through pointers and slices, which is labeled with the field. An
+// interface has a dashed edge to each of its implementations.
+func Graph(cfg Config, format string, w io.Writer) error {
+	switch format {
+	case GraphDOT, GraphMermaid:
+	default:
+		return errors.Errorf("unknown graph format %q, expecting %s or %s",
+			format, GraphDOT, GraphMermaid)
+	}
+
+	g, err := newGeneration(cfg)
+	if err != nil {
+		return err
+	}
+	v, err := g.analyze()
+	if err != nil {
+		return err
+	}
+	vw := v.view()
+
+	var nodes []*view.Type
+	var edges []graphEdge
+	for _, t := range vw.Types {
+		switch t.Kind {
+		case view.KindStruct:
+			nodes = append(nodes, t)
+			for _, f := range t.Fields {
+				label := f.Name
+				to := graphTarget(f.Target)
+				if to.Name != f.Target.Name {
+					label += " " + f.Target.Name
+				}
+				edges = append(edges, graphEdge{from: t, to: to, label: label})
+			}
+		case view.KindInterface:
+			nodes = append(nodes, t)
+			// A struct may implement the interface by value and by
+			// reference.
+			seen := make(map[*view.Type]bool)
+			for _, impl := range t.Implementors {
+				to := graphTarget(impl)
+				if !seen[to] {
+					seen[to] = true
+					edges = append(edges, graphEdge{from: t, to: to})
+				}
+			}
+		}
+	}
+
+	if format == GraphMermaid {
+		fmt.Fprintf(w, "graph LR\n")
+		for _, n := range nodes {
+			if n.Kind == view.KindInterface {
+				fmt.Fprintf(w, "  %s([%s])\n", n.Name, n.Name)
+			} else {
+				fmt.Fprintf(w, "  %s[%s]\n", n.Name, n.Name)
+			}
+		}
+		for _, e := range edges {
+			if e.label == "" {
+				fmt.Fprintf(w, "  %s -.-> %s\n", e.from.Name, e.to.Name)
+			} else {
+				fmt.Fprintf(w, "  %s -->|%q| %s\n", e.from.Name, e.label, e.to.Name)
+			}
+		}
+		return nil
+	}
+
+	fmt.Fprintf(w, "digraph %s {\n", vw.Root)
+	fmt.Fprintf(w, "  node [shape=box];\n")
+	for _, n := range nodes {
+		if n.Kind == view.KindInterface {
+			fmt.Fprintf(w, "  %q [shape=ellipse];\n", n.Name)
+		} else {
+			fmt.Fprintf(w, "  %q;\n", n.Name)
+		}
+	}
+	for _, e := range edges {
+		if e.label == "" {
+			fmt.Fprintf(w, "  %q -> %q [style=dashed];\n", e.from.Name, e.to.Name)
+		} else {
+			fmt.Fprintf(w, "  %q -> %q [label=%q];\n", e.from.Name, e.to.Name, e.label)
+		}
+	}
+	fmt.Fprintf(w, "}\n")
+	return nil
+}
+
+// graphTarget follows pointers and slices to a struct or interface.
+func graphTarget(t *view.Type) *view.Type {
+	for t.Elem != nil {
+		t = t.Elem
+	}
+	return t
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph(t *testing.T) {
+	t.Run(GraphDOT, func(t *testing.T) {
+		a := assert.New(t)
+
+		var sb strings.Builder
+		if !a.NoError(Graph(configs["union"], GraphDOT, &sb)) {
+			return
+		}
+		out := sb.String()
+		a.True(strings.HasPrefix(out, "digraph Union {\n"))
+		a.Contains(out, "  \"ContainerType\";\n")
+		a.Contains(out, "  \"Union\" [shape=ellipse];\n")
+		a.Contains(out, `  "ContainerType" -> "ByRefType" [label="ByRef"];`)
+		a.Contains(out, `  "ContainerType" -> "ByRefType" [label="ByRefSlice []ByRefType"];`)
+		a.Contains(out, `  "ContainerType" -> "Target" [label="AnotherTarget"];`)
+		a.Contains(out, `  "Union" -> "UnionableType" [style=dashed];`)
+		a.Equal(1, strings.Count(out, `"Target" -> "ByValType"`))
+		a.NotContains(out, "ReachableType")
+	})
+
+	t.Run(GraphMermaid, func(t *testing.T) {
+		a := assert.New(t)
+
+		var sb strings.Builder
+		if !a.NoError(Graph(configs["union"], GraphMermaid, &sb)) {
+			return
+		}
+		out := sb.String()
+		a.True(strings.HasPrefix(out, "graph LR\n"))
+		a.Contains(out, "  ContainerType[ContainerType]\n")
+		a.Contains(out, "  Union([Union])\n")
+		a.Contains(out, `  ContainerType -->|"ByRefPtr *ByRefType"| ByRefType`)
+		a.Contains(out, "  Union -.-> UnionableType\n")
+	})
+
+	a := assert.New(t)
+	a.EqualError(Graph(configs["union"], "svg", &strings.Builder{}),
+		`unknown graph format "svg", expecting dot or mermaid`)
+}
@@ -0,0 +1,92 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"go/types"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// importedScope returns the scope of the package with the given import
+// path, as imported by one of the loaded packages.
+func importedScope(pkgs []*packages.Package, path string) *types.Scope {
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+		for _, imp := range pkg.Types.Imports() {
+			if imp.Path() == path {
+				return imp.Scope()
+			}
+		}
+	}
+	return nil
+}
+
+// findRootDeclaration handles a root interface which is declared in an
+// imported package. The generated code refers to the root interface
+// by its unqualified name, so an alias will be generated unless one is
+// already declared in the package's source. A declaration in the file
+// that we are about to overwrite is ignored.
+func (v *visitation) findRootDeclaration() error {
+	if v.rootImport == nil {
+		return nil
+	}
+	v.ensureTypeID(v.Root)
+
+	name := v.Root.Obj().Name()
+	outName, err := filepath.Abs(v.outName())
+	if err != nil {
+		return err
+	}
+	for _, scope := range v.scopes {
+		obj := scope.Lookup(name)
+		if obj == nil {
+			continue
+		}
+		if obj.Pos().IsValid() {
+			if filepath.Clean(v.gen.fileSet.Position(obj.Pos()).Filename) == outName {
+				continue
+			}
+		}
+		if tn, ok := obj.(*types.TypeName); !ok || !types.Identical(tn.Type(), v.Root.Named) {
+			return errors.Errorf("%s is already declared in %s and is not an alias of %s.%s",
+				name, v.packagePath, v.rootImport.Name(), name)
+		}
+		v.gen.logf(Verbose, "using existing alias of %s.%s", v.rootImport.Name(), name)
+		v.rootImport = nil
+		return nil
+	}
+	return nil
+}
+
+// isImportedRoot returns true if the named type is a root interface
+// which is declared in another package.
+func (v *visitation) isImportedRoot(t *types.Named) bool {
+	return v.Root.Named != nil &&
+		t.Obj() == v.Root.Obj() &&
+		t.Obj().Pkg() != nil &&
+		t.Obj().Pkg().Path() != v.packagePath
+}
+
+// RootImport returns the package which declares the root interface if
+// the generated code must import it and declare an alias.
+func (v *visitation) RootImport() *types.Package {
+	return v.rootImport
+}
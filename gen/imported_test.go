@@ -0,0 +1,98 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestImportedSeed verifies that code can be generated for structs
+// which implement an interface declared in an imported package.
+func TestImportedSeed(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := Config{
+		Check:     true,
+		Dir:       "../demo/ast",
+		TypeNames: []string{"base.Node"},
+	}
+	outputs := make(map[string][]byte)
+	g, err := newGenerationForTesting(cfg, outputs)
+	if !a.NoError(err) {
+		return
+	}
+	if !a.NoError(g.Execute()) {
+		return
+	}
+	v := g.visitation
+	a.Equal("github.com/cockroachdb/walkabout/demo/ast/base", g.seedPackage)
+	a.Equal("Node", v.Root.String())
+	a.Contains(v.SourceTypes, SourceName("Call"))
+	a.Contains(v.SourceTypes, SourceName("Ident"))
+	a.Contains(v.Types, TypeID("NodeTypeNode"))
+	a.Contains(v.Types, TypeID("NodeTypeNodeSlice"))
+
+	name, err := filepath.Abs("../demo/ast/node_walkabout.g.go")
+	if !a.NoError(err) {
+		return
+	}
+	expected, err := os.ReadFile(name)
+	if a.NoError(err) {
+		a.Equal(string(expected), string(outputs[name]))
+	}
+	a.Contains(string(outputs[name]), "type Node = base.Node\n")
+
+	// An alias declared in source should be used instead.
+	cfg.Check = false
+	g, err = newGeneration(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	alias, err := filepath.Abs("../demo/ast/alias.go")
+	if !a.NoError(err) {
+		return
+	}
+	g.extraTestSource = map[string][]byte{
+		alias: []byte("package ast\n\nimport \"github.com/cockroachdb/walkabout/demo/ast/base\"\n\ntype Node = base.Node\n"),
+	}
+	if v, err := g.analyze(); a.NoError(err) {
+		a.Nil(v.RootImport())
+	}
+
+	// Any other declaration conflicts with the alias.
+	g, err = newGeneration(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	g.extraTestSource = map[string][]byte{
+		alias: []byte("package ast\n\ntype Node struct{}\n"),
+	}
+	_, err = g.analyze()
+	a.EqualError(err, "Node is already declared in github.com/cockroachdb/walkabout/demo/ast "+
+		"and is not an alias of base.Node")
+
+	// Only interfaces may be imported.
+	_, err = Generate(Config{
+		Dir:       "../demo",
+		TypeNames: []string{"Target", "other.Reachable"},
+		Union:     "Union",
+	})
+	a.EqualError(err, "other.Reachable is a struct; only interfaces may be used as seeds from another package")
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"sort"
+)
+
+// markInline determines which structs will have specialized walkers
+// which do not use the engine's stack. A struct is eligible if it has
+// no more than the configured number of visitable fields, and if it
+// cannot contain itself. The latter ensures that the specialized
+// walkers, which do not perform cycle detection, will terminate.
+func (v *visitation) markInline() {
+	v.inline = make(map[TypeID]bool)
+	if v.gen.InlineFields <= 0 {
+		return
+	}
+
+	names := make([]string, 0, len(v.SourceTypes))
+	for name := range v.SourceTypes {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		s, ok := v.SourceTypes[SourceName(name)].(namedStruct)
+		if !ok || len(s.Fields()) > v.gen.InlineFields {
+			continue
+		}
+		seen := make(map[TypeID]bool)
+		for _, f := range s.Fields() {
+			v.reachableStructs(f.Target, seen)
+		}
+		if seen[v.typeID(s)] {
+			continue
+		}
+		v.inline[v.typeID(s)] = true
+		v.gen.logf(Debug, "struct %s will be walked inline", s)
+	}
+}
+
+// reachableStructs adds the TypeIDs of all structs which could be
+// visited from a value of the given type.
+func (v *visitation) reachableStructs(t visitableType, seen map[TypeID]bool) {
+	switch t := t.(type) {
+	case namedStruct:
+		id := v.typeID(t)
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		for _, f := range t.Fields() {
+			v.reachableStructs(f.Target, seen)
+		}
+	case namedInterfaceType:
+		impls := t.Implementors()
+		keys := make([]string, 0, len(impls))
+		for key := range impls {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v.reachableStructs(impls[key].Underlying, seen)
+		}
+	case namedSliceType:
+		v.reachableStructs(t.Elem, seen)
+	case namedVisitableType:
+		v.reachableStructs(t.Underlying, seen)
+	case pointerType:
+		v.reachableStructs(t.Elem, seen)
+	}
+}
+
+// isInline returns true if the type is a struct, or a pointer to a
+// struct, which has a specialized walker.
+func (v *visitation) isInline(t visitableType) bool {
+	if ptr, ok := t.(pointerType); ok {
+		t = ptr.Elem
+	}
+	s, ok := t.(namedStruct)
+	return ok && v.inline[v.typeID(s)]
+}
+
+// InlineTarget returns the name of the struct, or pointed-to struct,
+// in an inlined field.
+func (f fieldInfo) InlineTarget() string {
+	if ptr, ok := f.Target.(pointerType); ok {
+		return ptr.Elem.String()
+	}
+	return f.Target.String()
+}
+
+// Present returns an expression which is true if the field, in a
+// struct named x, holds a value which the engine would visit. Struct
+// values are always present, so an empty string is returned.
+func (f fieldInfo) Present() string {
+	t := f.Target
+	for {
+		switch tt := t.(type) {
+		case namedVisitableType:
+			t = tt.Underlying
+		case namedSliceType:
+			return fmt.Sprintf("len(x.%s) != 0", f.Name)
+		case namedStruct:
+			return ""
+		default:
+			return fmt.Sprintf("x.%s != nil", f.Name)
+		}
+	}
+}
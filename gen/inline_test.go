@@ -0,0 +1,98 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+)
+
+func TestInline(t *testing.T) {
+	a := assert.New(t)
+
+	extra, err := filepath.Abs("../demo/inline_extra.go")
+	if !a.NoError(err) {
+		return
+	}
+
+	cfg := configs["single"]
+	cfg.InlineFields = 3
+	outputs := make(map[string][]byte)
+	g, err := newGenerationForTesting(cfg, outputs)
+	if !a.NoError(err) {
+		return
+	}
+	g.extraTestSource = map[string][]byte{
+		extra: []byte(`package demo
+
+type InlineLeaf struct {
+	ByRef    ByRefType
+	ByValPtr *ByValType
+}
+
+func (*InlineLeaf) Value() string { return "" }
+
+type InlineParent struct {
+	Leaf     InlineLeaf
+	LeafPtr  *InlineLeaf
+	Embedded EmbedsTarget
+}
+
+func (*InlineParent) Value() string { return "" }
+
+type InlineTooWide struct {
+	Leaf, Leaf2, Leaf3, Leaf4 InlineLeaf
+}
+
+func (*InlineTooWide) Value() string { return "" }
+`),
+	}
+	if !a.NoError(g.Execute()) {
+		return
+	}
+
+	v := g.visitation
+	for _, name := range []string{"ByRefType", "ByValType", "InlineLeaf", "InlineParent"} {
+		a.True(v.inline[v.typeID(v.SourceTypes[SourceName(name)])], name)
+	}
+	// Too many fields.
+	a.False(v.inline[v.typeID(v.SourceTypes["InlineTooWide"])])
+	// May contain itself via the Container field.
+	a.False(v.inline[v.typeID(v.SourceTypes["ContainerType"])])
+
+	var src string
+	for _, out := range outputs {
+		src = string(out)
+	}
+	a.Contains(src, "y, dirty, stop, err := targetInlineInlineLeaf(scope, fn, &x.Leaf)")
+	a.Contains(src, "if x.LeafPtr != nil {\n\t\ty, dirty, stop, err := targetInlineInlineLeaf(scope, fn, x.LeafPtr)")
+	a.Contains(src, "if x.Embedded != nil {\n\t\t_, y, dirty, stop, err := targetEngine().ResumeScope(scope, ")
+
+	// Ensure that the generated code compiles.
+	pcfg := g.packageConfig()
+	pcfg.Mode = packages.LoadAllSyntax
+	pcfg.Overlay = outputs
+	pcfg.Overlay[extra] = g.extraTestSource[extra]
+	pkgs, err := packages.Load(pcfg, ".")
+	if a.NoError(err) {
+		for _, pkg := range pkgs {
+			a.Nil(pkg.Errors)
+		}
+	}
+}
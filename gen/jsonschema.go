@@ -0,0 +1,149 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// jsonSchemaScalars maps the names of the engine's ScalarKind
+// constants to JSON Schema types.
+var jsonSchemaScalars = map[string]string{
+	"ScalarBool":    "boolean",
+	"ScalarInt":     "integer",
+	"ScalarInt8":    "integer",
+	"ScalarInt16":   "integer",
+	"ScalarInt32":   "integer",
+	"ScalarInt64":   "integer",
+	"ScalarUint":    "integer",
+	"ScalarUint8":   "integer",
+	"ScalarUint16":  "integer",
+	"ScalarUint32":  "integer",
+	"ScalarUint64":  "integer",
+	"ScalarUintptr": "integer",
+	"ScalarFloat32": "number",
+	"ScalarFloat64": "number",
+	"ScalarString":  "string",
+}
+
+// writeJSONSchema writes a JSON Schema which describes the map form
+// of the visitable types that is produced by the generated EncodeMap
+// function. Each struct becomes an object definition whose "$type"
+// property is its name. Each interface becomes a definition which
+// requires "$type" and is one of its implementations.
+func (v *visitation) writeJSONSchema(w io.Writer) error {
+	defs := make(map[string]interface{})
+	for _, t := range v.Types {
+		switch t := t.(type) {
+		case namedStruct:
+			props := map[string]interface{}{
+				"$type": map[string]interface{}{"const": t.String()},
+			}
+			for _, f := range t.Fields() {
+				props[f.Serialized] = jsonSchemaType(f.Target)
+			}
+			for _, s := range t.Scalars() {
+				if s.Kind == "ScalarBytes" {
+					props[s.Serialized] = map[string]interface{}{
+						"type":            []string{"string", "null"},
+						"contentEncoding": "base64",
+					}
+				} else {
+					props[s.Serialized] = map[string]interface{}{"type": jsonSchemaScalars[s.Kind]}
+				}
+			}
+			defs[t.String()] = map[string]interface{}{
+				"type":                 "object",
+				"properties":           props,
+				"additionalProperties": false,
+			}
+
+		case namedInterfaceType:
+			var names []string
+			seen := make(map[string]bool)
+			for _, impl := range t.Implementors() {
+				name := impl.Underlying.String()
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			oneOf := make([]interface{}, len(names))
+			for i, name := range names {
+				oneOf[i] = jsonSchemaRef(name)
+			}
+			defs[t.String()] = map[string]interface{}{
+				"type":     "object",
+				"required": []string{"$type"},
+				"oneOf":    oneOf,
+			}
+		}
+	}
+
+	schema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"$comment":    "Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.",
+		"title":       v.Root.String(),
+		"$defs":       defs,
+		"anyOf":       []interface{}{jsonSchemaRef(v.Root.String()), jsonSchemaNull},
+		"description": "The map form of a " + v.Root.String() + " value.",
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// jsonSchemaNull matches a nil value.
+var jsonSchemaNull = map[string]interface{}{"type": "null"}
+
+// jsonSchemaRef refers to a definition.
+func jsonSchemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/$defs/" + name}
+}
+
+// jsonSchemaType returns the schema for a visitable field. Values of
+// pointer, slice, and interface types may be null.
+func jsonSchemaType(t visitableType) map[string]interface{} {
+	switch t := t.(type) {
+	case namedVisitableType:
+		return jsonSchemaType(t.Underlying)
+	case namedStruct:
+		return jsonSchemaRef(t.String())
+	case namedInterfaceType:
+		return map[string]interface{}{
+			"anyOf": []interface{}{jsonSchemaRef(t.String()), jsonSchemaNull},
+		}
+	case pointerType:
+		elem := jsonSchemaType(t.Elem)
+		if _, nullable := elem["anyOf"]; nullable {
+			return elem
+		}
+		return map[string]interface{}{"anyOf": []interface{}{elem, jsonSchemaNull}}
+	case namedSliceType:
+		return map[string]interface{}{
+			"type":  []string{"array", "null"},
+			"items": jsonSchemaType(t.Elem),
+		}
+	default:
+		return map[string]interface{}{}
+	}
+}
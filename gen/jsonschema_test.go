@@ -0,0 +1,84 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	a := assert.New(t)
+	schemaName := filepath.Join(t.TempDir(), "target.schema.json")
+
+	cfg := configs["single"]
+	cfg.JSONSchema = schemaName
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+
+	var schema struct {
+		Schema string `json:"$schema"`
+		Defs   map[string]struct {
+			Properties map[string]json.RawMessage
+			Required   []string
+			OneOf      []map[string]string
+		} `json:"$defs"`
+		AnyOf []json.RawMessage
+	}
+	if !a.NoError(json.Unmarshal(outputs[schemaName], &schema)) {
+		return
+	}
+	a.Equal("https://json-schema.org/draft/2020-12/schema", schema.Schema)
+	a.JSONEq(`{"$ref": "#/$defs/Target"}`, string(schema.AnyOf[0]))
+
+	a.Len(schema.Defs, 5)
+	byRef := schema.Defs["ByRefType"].Properties
+	a.JSONEq(`{"const": "ByRefType"}`, string(byRef["$type"]))
+	a.JSONEq(`{"type": "string"}`, string(byRef["Val"]))
+
+	container := schema.Defs["ContainerType"].Properties
+	a.JSONEq(`{"$ref": "#/$defs/ByRefType"}`, string(container["ByRef"]))
+	a.JSONEq(`{"anyOf": [{"$ref": "#/$defs/ByRefType"}, {"type": "null"}]}`,
+		string(container["ByRefPtr"]))
+	a.JSONEq(`{"type": ["array", "null"], "items": {"$ref": "#/$defs/ByRefType"}}`,
+		string(container["ByRefSlice"]))
+	a.JSONEq(`{"anyOf": [{"$ref": "#/$defs/Target"}, {"type": "null"}]}`,
+		string(container["AnotherTargetPtr"]))
+	a.JSONEq(`{"type": ["array", "null"],
+		"items": {"anyOf": [{"$ref": "#/$defs/Target"}, {"type": "null"}]}}`,
+		string(container["NamedTargets"]))
+	a.NotContains(container, "ignored")
+
+	target := schema.Defs["Target"]
+	a.Equal([]string{"$type"}, target.Required)
+	a.Equal([]map[string]string{
+		{"$ref": "#/$defs/ByRefType"},
+		{"$ref": "#/$defs/ByValType"},
+		{"$ref": "#/$defs/ContainerType"},
+	}, target.OneOf)
+
+	// The Go code should not be affected.
+	delete(outputs, schemaName)
+	expected, err := Generate(configs["single"])
+	if a.NoError(err) {
+		a.Equal(expected, outputs)
+	}
+}
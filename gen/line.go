@@ -0,0 +1,61 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+)
+
+// lineEndMarker is emitted by the templates at the end of a section
+// of code which has been attributed to a declaration. Since we can't
+// know the final line numbers until the code has been formatted, the
+// marker is replaced by resetLineDirectives().
+const lineEndMarker = "//walkabout:endline"
+
+// lineDirective returns a //line directive which attributes the
+// following code to the declaration of the struct.
+func (v *visitation) lineDirective(s namedStruct) string {
+	if !v.gen.LineDirectives || !s.Obj().Pos().IsValid() {
+		return ""
+	}
+	pos := v.gen.fileSet.Position(s.Obj().Pos())
+	return fmt.Sprintf("//line %s:%d\n", filepath.Base(pos.Filename), pos.Line)
+}
+
+// lineEnd returns a marker which will be replaced by a //line
+// directive that restores the positions of the generated file.
+func (v *visitation) lineEnd() string {
+	if !v.gen.LineDirectives {
+		return ""
+	}
+	return lineEndMarker + "\n"
+}
+
+// resetLineDirectives replaces each lineEndMarker in the formatted
+// source with a //line directive that refers back to the generated
+// file itself.
+func resetLineDirectives(src []byte, outName string) []byte {
+	lines := bytes.Split(src, []byte("\n"))
+	for i, line := range lines {
+		if string(bytes.TrimSpace(line)) == lineEndMarker {
+			// A //line directive applies to the line that follows it.
+			lines[i] = []byte(fmt.Sprintf("//line %s:%d", filepath.Base(outName), i+2))
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
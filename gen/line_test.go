@@ -0,0 +1,56 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineDirectives(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := configs["single"]
+	cfg.Check = true
+	cfg.LineDirectives = true
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	src := string(outputs[filepath.Join("../demo", "target_walkabout.g.go")])
+	a.Contains(src, "//line demo.go:98\nfunc (x *ContainerType) TargetAt(")
+	a.NotContains(src, lineEndMarker)
+
+	// Each reset directive must refer to the line that follows it.
+	lines := strings.Split(src, "\n")
+	resets := regexp.MustCompile(`^//line target_walkabout\.g\.go:(\d+)$`)
+	count := 0
+	for i, line := range lines {
+		if m := resets.FindStringSubmatch(line); m != nil {
+			count++
+			n, err := strconv.Atoi(m[1])
+			a.NoError(err)
+			a.Equal(i+2, n, fmt.Sprintf("line %d", i+1))
+		}
+	}
+	a.Equal(3, count)
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/cockroachdb/walkabout/gen/view"
+)
+
+// List writes a description of the types that the configuration would
+// make visitable, without generating any code. Struct types are
+// "visitable" and will be presented to a walker function, while
+// pointer, slice, and interface types are "traversable" and are
+// followed in order to find visitable types.
+func List(cfg Config, w io.Writer) error {
+	g, err := newGeneration(cfg)
+	if err != nil {
+		return err
+	}
+	v, err := g.analyze()
+	if err != nil {
+		return err
+	}
+	vw := v.view()
+
+	var visitable, traversable, intfs []*view.Type
+	for _, t := range vw.Types {
+		switch t.Kind {
+		case view.KindStruct:
+			visitable = append(visitable, t)
+		case view.KindInterface:
+			intfs = append(intfs, t)
+			traversable = append(traversable, t)
+		default:
+			traversable = append(traversable, t)
+		}
+	}
+
+	fmt.Fprintf(w, "root: %s\n", vw.Root)
+	fmt.Fprintf(w, "seeds: %s\n", strings.Join(g.TypeNames, ", "))
+
+	fmt.Fprintf(w, "visitable:\n")
+	for _, t := range visitable {
+		fmt.Fprintf(w, "  %s fields=%d %s\n", t.Name, len(t.Fields), g.position(t))
+	}
+
+	fmt.Fprintf(w, "traversable:\n")
+	for _, t := range traversable {
+		fmt.Fprintf(w, "  %s\n", t.Name)
+	}
+
+	fmt.Fprintf(w, "implementations:\n")
+	for _, t := range intfs {
+		names := make([]string, len(t.Implementors))
+		for i, impl := range t.Implementors {
+			names[i] = impl.Name
+		}
+		fmt.Fprintf(w, "  %s: %s\n", t.Name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// position returns a short description of where a type is declared.
+func (g *generation) position(t *view.Type) string {
+	if t.Obj == nil || !t.Obj.Pos().IsValid() {
+		return ""
+	}
+	pos := g.fileSet.Position(t.Obj.Pos())
+	return fmt.Sprintf("%s:%d", filepath.Base(pos.Filename), pos.Line)
+}
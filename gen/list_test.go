@@ -0,0 +1,42 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	a := assert.New(t)
+
+	var sb strings.Builder
+	if !a.NoError(List(configs["union"], &sb)) {
+		return
+	}
+	out := sb.String()
+	a.Contains(out, "root: Union\n")
+	a.Contains(out, "seeds: Target, Unionable\n")
+	a.Contains(out, "  ContainerType fields=17 demo.go:")
+	a.Contains(out, "  UnionableType fields=0 demo.go:")
+	a.Contains(out, "traversable:\n  *ByRefType\n")
+	a.Contains(out, "  Union: *ByRefType, *ByValType, *ContainerType, *UnionableType\n")
+	a.NotContains(out, "ReachableType")
+
+	a.Error(List(Config{Dir: "../demo", TypeNames: []string{"NotAType"}}, &sb))
+}
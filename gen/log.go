@@ -0,0 +1,112 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Verbosity controls the amount of diagnostic output produced by the
+// code generator.
+type Verbosity int
+
+// The available levels of diagnostic output.
+const (
+	// Quiet produces no diagnostic output.
+	Quiet Verbosity = iota
+	// Verbose reports the time spent in each phase of the generator
+	// and summary statistics about the visitable types.
+	Verbose
+	// Debug additionally reports every decision made about whether or
+	// not a type is visitable, and the time spent in each template.
+	Debug
+)
+
+// String is for debugging use only.
+func (v Verbosity) String() string {
+	switch v {
+	case Quiet:
+		return "quiet"
+	case Verbose:
+		return "verbose"
+	case Debug:
+		return "debug"
+	default:
+		return "unknown"
+	}
+}
+
+// logf writes a diagnostic message if the configured verbosity is at
+// least the given level.
+func (g *generation) logf(level Verbosity, format string, args ...interface{}) {
+	if g.Verbosity < level {
+		return
+	}
+	fmt.Fprintf(g.logWriter(), "walkabout: "+format+"\n", args...)
+}
+
+// logWriter returns the configured Log, or os.Stderr.
+func (g *generation) logWriter() io.Writer {
+	if g.Log == nil {
+		return os.Stderr
+	}
+	return g.Log
+}
+
+// timed logs the elapsed time of some phase of the generator when the
+// returned function is called.
+func (g *generation) timed(level Verbosity, phase string) func() {
+	if g.Verbosity < level {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		g.logf(level, "%s took %s", phase, time.Since(start))
+	}
+}
+
+// verbosityFlag is a boolean-style pflag.Value which raises a
+// Verbosity to a given level when it is set.
+type verbosityFlag struct {
+	level  Verbosity
+	target *Verbosity
+}
+
+// String implements pflag.Value.
+func (f verbosityFlag) String() string {
+	return strconv.FormatBool(f.target != nil && *f.target >= f.level)
+}
+
+// Set implements pflag.Value.
+func (f verbosityFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	if b && *f.target < f.level {
+		*f.target = f.level
+	}
+	return nil
+}
+
+// Type implements pflag.Value.
+func (f verbosityFlag) Type() string {
+	return "bool"
+}
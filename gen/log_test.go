@@ -0,0 +1,73 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogging(t *testing.T) {
+	tcs := []struct {
+		verbosity Verbosity
+		expect    []string
+		unexpect  []string
+	}{
+		{
+			verbosity: Quiet,
+			unexpect:  []string{"walkabout:"},
+		},
+		{
+			verbosity: Verbose,
+			expect: []string{
+				"walkabout: package loading took ",
+				"walkabout: found 1 seed types, 6 visitable source types",
+				"walkabout: template execution took ",
+			},
+			unexpect: []string{"is visitable", "template 10api"},
+		},
+		{
+			verbosity: Debug,
+			expect: []string{
+				"walkabout: package loading took ",
+				"walkabout: struct ContainerType is visitable (reachable=false)\n",
+				"walkabout: template 10api took ",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.verbosity.String(), func(t *testing.T) {
+			a := assert.New(t)
+			var sb strings.Builder
+			cfg := configs["single"]
+			cfg.Log = &sb
+			cfg.Verbosity = tc.verbosity
+			_, err := Generate(cfg)
+			if !a.NoError(err) {
+				return
+			}
+			for _, expect := range tc.expect {
+				a.Contains(sb.String(), expect)
+			}
+			for _, unexpect := range tc.unexpect {
+				a.NotContains(sb.String(), unexpect)
+			}
+		})
+	}
+}
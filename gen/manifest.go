@@ -0,0 +1,106 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// manifest records the values that have been assigned to the generated
+// TypeID constants, so that they remain stable when types are added
+// or removed. Values are only ever appended to a manifest; the values
+// assigned to types which are no longer generated are not reused.
+//
+// The file format is a sequence of "<value> <TypeID>" lines. Blank
+// lines and lines beginning with # are ignored.
+type manifest struct {
+	ids  map[TypeID]int
+	next int
+}
+
+// readManifest loads the manifest from the named file. An empty
+// manifest will be returned if the file does not exist.
+func readManifest(name string) (*manifest, error) {
+	ret := &manifest{ids: make(map[TypeID]int), next: 1}
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return ret, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.Fields(text)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("%s:%d: expecting <value> <TypeID>", name, line)
+		}
+		value, err := strconv.Atoi(parts[0])
+		if err != nil || value <= 0 {
+			return nil, errors.Errorf("%s:%d: bad value %q", name, line, parts[0])
+		}
+		if _, dup := ret.ids[TypeID(parts[1])]; dup {
+			return nil, errors.Errorf("%s:%d: duplicate TypeID %s", name, line, parts[1])
+		}
+		ret.ids[TypeID(parts[1])] = value
+		if value >= ret.next {
+			ret.next = value + 1
+		}
+	}
+	return ret, scanner.Err()
+}
+
+// ordinal returns the value assigned to the TypeID, allocating a new
+// value if necessary.
+func (m *manifest) ordinal(id TypeID) int {
+	if ret, ok := m.ids[id]; ok {
+		return ret
+	}
+	ret := m.next
+	m.ids[id] = ret
+	m.next++
+	return ret
+}
+
+// WriteTo implements io.WriterTo.
+func (m *manifest) WriteTo(w io.Writer) (int64, error) {
+	ids := make([]TypeID, 0, len(m.ids))
+	for id := range m.ids {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return m.ids[ids[i]] < m.ids[ids[j]] })
+
+	var buf bytes.Buffer
+	buf.WriteString("# Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.\n")
+	buf.WriteString("# This file records stable TypeID values and should be checked in.\n")
+	for _, id := range ids {
+		fmt.Fprintf(&buf, "%d %s\n", m.ids[id], id)
+	}
+	return buf.WriteTo(w)
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManifest(t *testing.T) {
+	a := assert.New(t)
+	manifestName := filepath.Join(t.TempDir(), "walkabout.manifest")
+	a.NoError(os.WriteFile(manifestName, []byte(`
+# A comment
+7 TargetTypeContainerType
+3 TargetTypeRemoved
+`), 0644))
+
+	cfg := configs["single"]
+	cfg.Manifest = manifestName
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+
+	src := string(outputs[filepath.Join(cfg.Dir, "target_walkabout.g.go")])
+	a.Regexp(`TargetTypeContainerType\s+TargetTypeID = 7\n`, src)
+	a.Regexp(`TargetTypeByRefType\s+TargetTypeID = 8\n`, src)
+	a.NotContains(src, "iota")
+	checkOutputs(a, cfg, outputs, false)
+
+	// The removed type should be retained, and new types appended.
+	lines := strings.Split(string(outputs[manifestName]), "\n")
+	a.Equal("3 TargetTypeRemoved", lines[2])
+	a.Equal("7 TargetTypeContainerType", lines[3])
+	a.Equal("8 TargetTypeByRefType", lines[4])
+
+	// Regenerating from the updated manifest should be stable.
+	a.NoError(os.WriteFile(manifestName, outputs[manifestName], 0644))
+	outputs2, err := Generate(cfg)
+	if a.NoError(err) {
+		a.Equal(outputs, outputs2)
+	}
+
+	a.NoError(os.WriteFile(manifestName, []byte("1 TargetTypeFoo\n2 TargetTypeFoo\n"), 0644))
+	_, err = Generate(cfg)
+	a.EqualError(err, manifestName+":2: duplicate TypeID TargetTypeFoo")
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// GenerateAll runs the code generator in-process for several targets,
+// such as multiple unions in the same package. Targets which operate
+// on the same package share a single load of that package, and the
+// analysis and template execution for each target run concurrently.
+// It is an error for two targets to generate the same file. The
+// walkabout command does not call GenerateAll, since it accepts only
+// one target.
+func GenerateAll(cfgs []Config) (map[string][]byte, error) {
+	outputs := make(map[string][]byte)
+	var mu sync.Mutex
+	// The index of the target which generated each file.
+	owners := make(map[string]int)
+	var targets []string
+
+	// Group the generations by the package that they will load.
+	var keys []string
+	groups := make(map[string][]*generation)
+	for idx, cfg := range cfgs {
+		g, err := newGeneration(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := g.resolveQualifiedSeeds(); err != nil {
+			return nil, err
+		}
+
+		targets = append(targets, g.target())
+		g.writeCloser = func(name string) (io.WriteCloser, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if owner, ok := owners[name]; ok && owner != idx {
+				return nil, errors.Errorf("%s is generated by both %s and %s",
+					name, targets[owner], targets[idx])
+			}
+			owners[name] = idx
+			return newMapWriter(name, &mu, outputs), nil
+		}
+
+		dir, err := filepath.Abs(g.Dir)
+		if err != nil {
+			return nil, err
+		}
+		key := dir + "\x00" + g.Package
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], g)
+	}
+
+	// Load each package once, using the first generation's
+	// configuration, and then analyze each target concurrently. The
+	// next package is loaded while the previous targets are running.
+	var wg sync.WaitGroup
+	errs := make([]error, len(cfgs))
+	next := 0
+	for _, key := range keys {
+		gens := groups[key]
+		// Every target's output file is previously-generated code as
+		// far as the shared load is concerned, and it is ignored if any
+		// target requests it.
+		for _, g := range gens[1:] {
+			for name := range g.outFiles {
+				gens[0].outFiles[name] = true
+			}
+			g.outFiles = gens[0].outFiles
+			gens[0].Clean = gens[0].Clean || g.Clean
+		}
+		done := gens[0].timed(Verbose, "package loading")
+		pkgs, err := gens[0].load()
+		if err == nil {
+			pkgs, err = gens[0].loadClean(pkgs)
+		}
+		if err != nil {
+			wg.Wait()
+			return nil, err
+		}
+		done()
+
+		for _, g := range gens {
+			g.cleanSource = gens[0].cleanSource
+			g.fileSet = gens[0].fileSet
+			g.forceCgo = gens[0].forceCgo
+
+			idx := next
+			next++
+			wg.Add(1)
+			go func(g *generation, pkgs []*packages.Package) {
+				defer wg.Done()
+				if err := g.executePackages(pkgs); err != nil {
+					errs[idx] = errors.Wrap(err, g.target())
+				}
+			}(g, pkgs)
+		}
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+// target returns a description of the generation for use in errors.
+func (g *generation) target() string {
+	if g.Union != "" {
+		return g.Union
+	}
+	return strings.Join(g.TypeNames, ", ")
+}
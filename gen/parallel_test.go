@@ -0,0 +1,54 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateAll(t *testing.T) {
+	a := assert.New(t)
+
+	names := []string{"single", "union", "structUnion"}
+	var cfgs []Config
+	for _, name := range names {
+		cfg := configs[name]
+		cfg.Union = map[string]string{"union": "Union", "structUnion": "StructUnion"}[name]
+		cfgs = append(cfgs, cfg)
+	}
+
+	outputs, err := GenerateAll(cfgs)
+	if !a.NoError(err) {
+		return
+	}
+	a.Len(outputs, len(cfgs))
+
+	// The outputs must be the same as running each target by itself.
+	for _, cfg := range cfgs {
+		expected, err := Generate(cfg)
+		if !a.NoError(err) {
+			continue
+		}
+		for name, src := range expected {
+			a.Equal(string(src), string(outputs[name]), name)
+		}
+	}
+
+	_, err = GenerateAll([]Config{configs["union"], configs["unionReachable"]})
+	a.EqualError(err, "Union: ../demo/union_walkabout.g.go is generated by both Union and Union")
+}
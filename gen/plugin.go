@@ -0,0 +1,104 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"io"
+	"path"
+	"reflect"
+	"sort"
+
+	"github.com/cockroachdb/walkabout/gen/view"
+)
+
+// A Plugin is invoked after the built-in templates have been executed
+// and may append additional code to the generated file. The generated
+// file will be formatted after all plugins have run.
+type Plugin interface {
+	Emit(view *view.View, w io.Writer) error
+}
+
+// view constructs a read-only description of the visitation.
+func (v *visitation) view() *view.View {
+	ret := &view.View{
+		Package:     path.Base(v.packagePath),
+		PackagePath: v.packagePath,
+		Root:        v.Root.String(),
+		Union:       v.Root.Union != "",
+	}
+
+	byID := make(map[TypeID]*view.Type, len(v.Types))
+	var lookup func(t visitableType) *view.Type
+	lookup = func(t visitableType) *view.Type {
+		id := v.ensureTypeID(t)
+		if found, ok := byID[id]; ok {
+			return found
+		}
+		impl := t.Implementation()
+		vt := &view.Type{Name: impl.String(), TypeID: string(id)}
+		byID[id] = vt
+
+		switch t := impl.(type) {
+		case namedInterfaceType:
+			vt.Kind = view.KindInterface
+			if t.Named != nil {
+				vt.Obj = t.Obj()
+			}
+			impls := t.Implementors()
+			keys := make([]string, 0, len(impls))
+			for key := range impls {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				vt.Implementors = append(vt.Implementors, lookup(impls[key].Actual))
+			}
+		case namedSliceType:
+			vt.Kind = view.KindSlice
+			vt.Elem = lookup(t.Elem)
+		case namedStruct:
+			vt.Kind = view.KindStruct
+			vt.Obj = t.Obj()
+			for _, f := range t.Fields() {
+				vt.Fields = append(vt.Fields, view.Field{Name: f.Name, Tag: reflect.StructTag(f.Tag), Target: lookup(f.Target)})
+			}
+		case pointerType:
+			vt.Kind = view.KindPointer
+			vt.Elem = lookup(t.Elem)
+		}
+		return vt
+	}
+
+	// Looking up a type may add additional types to the visitation.
+	lookup(v.Root)
+	for done := 0; done < len(v.Types); {
+		done = len(v.Types)
+		for _, t := range v.Types {
+			lookup(t)
+		}
+	}
+
+	// Sort the type ids so that the output is stable.
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		ret.Types = append(ret.Types, byID[TypeID(id)])
+	}
+	return ret
+}
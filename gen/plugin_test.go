@@ -0,0 +1,77 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/cockroachdb/walkabout/gen/view"
+	"github.com/stretchr/testify/assert"
+)
+
+type pluginFn func(v *view.View, w io.Writer) error
+
+func (fn pluginFn) Emit(v *view.View, w io.Writer) error { return fn(v, w) }
+
+func TestPlugins(t *testing.T) {
+	a := assert.New(t)
+
+	var seen *view.View
+	cfg := configs["single"]
+	cfg.Plugins = []Plugin{pluginFn(func(v *view.View, w io.Writer) error {
+		seen = v
+		_, err := fmt.Fprintf(w, "\nconst %sPluginTypes = %d\n", v.Root, len(v.Types))
+		return err
+	})}
+
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) || !a.NotNil(seen) {
+		return
+	}
+	for _, out := range outputs {
+		a.Contains(string(out), fmt.Sprintf("const TargetPluginTypes = %d", len(seen.Types)))
+	}
+
+	a.Equal("demo", seen.Package)
+	a.Equal("Target", seen.Root)
+	a.False(seen.Union)
+
+	if root := seen.Type("Target"); a.NotNil(root) {
+		a.Equal(view.KindInterface, root.Kind)
+		var names []string
+		for _, impl := range root.Implementors {
+			names = append(names, impl.Name)
+		}
+		a.Equal([]string{"*ByRefType", "ByValType", "*ByValType", "*ContainerType"}, names)
+	}
+
+	if container := seen.Type("ContainerType"); a.NotNil(container) {
+		a.Equal(view.KindStruct, container.Kind)
+		a.Equal("ContainerType", container.Obj.Name())
+		a.Len(container.Fields, 16)
+		a.Equal("ByRefPtr", container.Fields[1].Name)
+		a.Equal(view.KindPointer, container.Fields[1].Target.Kind)
+		a.Equal("ByRefType", container.Fields[1].Target.Elem.Name)
+	}
+
+	cfg.Plugins = []Plugin{pluginFn(func(*view.View, io.Writer) error {
+		return fmt.Errorf("boom")
+	})}
+	_, err = Generate(cfg)
+	a.EqualError(err, "plugin gen.pluginFn: boom")
+}
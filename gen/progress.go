@@ -0,0 +1,135 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// progressDelay is the amount of time to wait before reporting any
+// progress, so that fast generations remain silent.
+var progressDelay = 2 * time.Second
+
+// progressInterval is the rate at which progress is redrawn.
+var progressInterval = 250 * time.Millisecond
+
+// progress periodically redraws a single status line which describes
+// how far along the generator is. All methods are safe to call on a
+// nil receiver, which is used when progress reporting is disabled.
+type progress struct {
+	phase          atomic.Value // string
+	templates      int64
+	totalTemplates int64
+	types          int64
+	stop           chan struct{}
+	stopped        chan struct{}
+	w              io.Writer
+}
+
+// startProgress begins reporting progress if enabled. The returned
+// function must be called to stop reporting and clear the status line.
+func (g *generation) startProgress() func() {
+	if !g.Progress {
+		return func() {}
+	}
+	p := &progress{
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		w:       g.logWriter(),
+	}
+	p.phase.Store("starting")
+	g.progress = p
+	go p.run()
+	return func() {
+		close(p.stop)
+		<-p.stopped
+	}
+}
+
+// run redraws the status line until stopped.
+func (p *progress) run() {
+	defer close(p.stopped)
+
+	select {
+	case <-time.After(progressDelay):
+	case <-p.stop:
+		return
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		p.draw()
+		select {
+		case <-ticker.C:
+		case <-p.stop:
+			// Clear the status line.
+			fmt.Fprint(p.w, "\r\033[K")
+			return
+		}
+	}
+}
+
+// draw writes the current status.
+func (p *progress) draw() {
+	msg := p.phase.Load().(string)
+	if n := atomic.LoadInt64(&p.types); n > 0 {
+		msg += fmt.Sprintf(", %d types analyzed", n)
+	}
+	if total := atomic.LoadInt64(&p.totalTemplates); total > 0 {
+		msg += fmt.Sprintf(", %d/%d templates executed", atomic.LoadInt64(&p.templates), total)
+	}
+	fmt.Fprintf(p.w, "\r\033[Kwalkabout: %s...", msg)
+}
+
+// setPhase records the current phase of the generator.
+func (p *progress) setPhase(phase string) {
+	if p != nil {
+		p.phase.Store(phase)
+	}
+}
+
+// addType records that a type has been analyzed.
+func (p *progress) addType() {
+	if p != nil {
+		atomic.AddInt64(&p.types, 1)
+	}
+}
+
+// setTemplates records the number of templates that will be executed.
+func (p *progress) setTemplates(total int) {
+	if p != nil {
+		atomic.StoreInt64(&p.totalTemplates, int64(total))
+	}
+}
+
+// addTemplate records that a template has been executed.
+func (p *progress) addTemplate() {
+	if p != nil {
+		atomic.AddInt64(&p.templates, 1)
+	}
+}
+
+// isTerminal returns true if the file is a character device, such as
+// a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,78 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuilder is a strings.Builder which is safe for concurrent use.
+type syncBuilder struct {
+	mu sync.Mutex
+	sb strings.Builder
+}
+
+func (b *syncBuilder) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.Write(p)
+}
+
+func (b *syncBuilder) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sb.String()
+}
+
+func TestProgress(t *testing.T) {
+	a := assert.New(t)
+
+	defer func(delay, interval time.Duration) {
+		progressDelay, progressInterval = delay, interval
+	}(progressDelay, progressInterval)
+	progressDelay, progressInterval = 0, time.Millisecond
+
+	var out syncBuilder
+	cfg := configs["single"]
+	cfg.Log = &out
+	cfg.Progress = true
+	_, err := Generate(cfg)
+	a.NoError(err)
+	a.Contains(out.String(), "walkabout: loading packages...")
+	// The status line is always cleared.
+	a.True(strings.HasSuffix(out.String(), "\r\033[K"))
+
+	// Without a delay, nothing should be written.
+	progressDelay = time.Hour
+	var quiet syncBuilder
+	cfg.Log = &quiet
+	_, err = Generate(cfg)
+	a.NoError(err)
+	a.Empty(quiet.String())
+
+	// Check the formatting of the status line directly.
+	var line syncBuilder
+	p := &progress{w: &line, totalTemplates: 5, templates: 2, types: 10}
+	p.setPhase("executing templates")
+	p.draw()
+	a.Equal("\r\033[Kwalkabout: executing templates, 10 types analyzed, 2/5 templates executed...",
+		line.String())
+}
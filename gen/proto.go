@@ -0,0 +1,318 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// protoScalars maps basic Go types to proto3 scalar types.
+var protoScalars = map[types.BasicKind]string{
+	types.Bool:    "bool",
+	types.Int:     "int64",
+	types.Int8:    "int32",
+	types.Int16:   "int32",
+	types.Int32:   "int32",
+	types.Int64:   "int64",
+	types.Uint:    "uint64",
+	types.Uint8:   "uint32",
+	types.Uint16:  "uint32",
+	types.Uint32:  "uint32",
+	types.Uint64:  "uint64",
+	types.Uintptr: "uint64",
+	types.Float32: "float",
+	types.Float64: "double",
+	types.String:  "string",
+}
+
+// protoNumbers records the field numbers in a previously-written
+// schema, so that they remain stable when fields or implementations
+// are added, removed, or reordered. As with the manifest, the numbers
+// of fields which are no longer present are reserved, rather than
+// reused. Fields are identified by message and field name.
+type protoNumbers struct {
+	// fields maps a message name to the numbers of its fields.
+	fields map[string]map[string]int
+	// reserved maps a message name to numbers which must not be used.
+	reserved map[string][]int
+}
+
+// readProtoNumbers loads the field numbers from a schema which was
+// written by writeProto. No numbers will be returned if the file does
+// not exist.
+func readProtoNumbers(name string) (*protoNumbers, error) {
+	ret := &protoNumbers{
+		fields:   make(map[string]map[string]int),
+		reserved: make(map[string][]int),
+	}
+	data, err := os.ReadFile(name)
+	if os.IsNotExist(err) {
+		return ret, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var message string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ";")
+		switch {
+		case strings.HasPrefix(text, "message "):
+			message = strings.TrimSuffix(strings.TrimPrefix(text, "message "), " {")
+			ret.fields[message] = make(map[string]int)
+
+		case text == "}" || text == "" || strings.HasPrefix(text, "//"),
+			strings.HasPrefix(text, "oneof "):
+
+		case message == "":
+
+		case strings.HasPrefix(text, "reserved "):
+			for _, part := range strings.Split(strings.TrimPrefix(text, "reserved "), ",") {
+				number, err := strconv.Atoi(strings.TrimSpace(part))
+				if err != nil || number <= 0 {
+					return nil, errors.Errorf("%s:%d: bad reserved number %q", name, line, part)
+				}
+				ret.reserved[message] = append(ret.reserved[message], number)
+			}
+
+		default:
+			parts := strings.Fields(text)
+			if len(parts) < 4 || parts[len(parts)-2] != "=" {
+				return nil, errors.Errorf("%s:%d: expecting <type> <name> = <number>", name, line)
+			}
+			number, err := strconv.Atoi(parts[len(parts)-1])
+			if err != nil || number <= 0 {
+				return nil, errors.Errorf("%s:%d: bad field number %q", name, line, parts[len(parts)-1])
+			}
+			ret.fields[message][parts[len(parts)-3]] = number
+		}
+	}
+	return ret, scanner.Err()
+}
+
+// assign returns the numbers of the named fields of a message, in the
+// order of the names, and the numbers which must be reserved. Numbers
+// are allocated to new fields after all previously-used numbers.
+func (p *protoNumbers) assign(message string, names []string) (numbers []int, reserved []int) {
+	old := p.fields[message]
+	used := make(map[int]bool)
+	next := 1
+	for _, number := range old {
+		used[number] = true
+		if number >= next {
+			next = number + 1
+		}
+	}
+	for _, number := range p.reserved[message] {
+		used[number] = true
+		if number >= next {
+			next = number + 1
+		}
+	}
+
+	for _, name := range names {
+		number, ok := old[name]
+		if !ok {
+			number = next
+			next++
+		}
+		numbers = append(numbers, number)
+		delete(used, number)
+	}
+	for number := range used {
+		reserved = append(reserved, number)
+	}
+	sort.Ints(reserved)
+	return numbers, reserved
+}
+
+// writeProto writes a proto3 schema which describes the visitable
+// types. Each struct becomes a message and each interface becomes a
+// message containing a oneof of its implementations. Field numbers are
+// taken from the schema which was previously written, if any, so that
+// the schema should be checked in. Only the schema is written;
+// converting values to and from its messages is left to the user, so
+// that the generated code need not depend on a protobuf runtime.
+func (v *visitation) writeProto(w io.Writer, numbers *protoNumbers) error {
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.\n\n")
+	buf.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&buf, "package %s;\n", strings.ReplaceAll(path.Base(v.packagePath), "-", "_"))
+
+	ids := make([]string, 0, len(v.Types))
+	for id := range v.Types {
+		ids = append(ids, string(id))
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		switch t := v.Types[TypeID(id)].(type) {
+		case namedStruct:
+			v.writeProtoStruct(&buf, t, numbers)
+		case namedInterfaceType:
+			v.writeProtoInterface(&buf, t, numbers)
+		}
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeProtoStruct emits a message for a struct. Fields whose types
+// cannot be represented are recorded as comments.
+func (v *visitation) writeProtoStruct(buf *bytes.Buffer, t namedStruct, numbers *protoNumbers) {
+	// Each line is either a comment or a field, which is given a number.
+	var lines, names []string
+	for i, j := 0, t.NumFields(); i < j; i++ {
+		f := t.Field(i)
+		if !f.Exported() || t.Excluded(i) {
+			continue
+		}
+		typ, repeated, ok := v.protoType(f.Type())
+		if !ok {
+			lines = append(lines, fmt.Sprintf("  // %s has no proto representation.\n", f.Name()))
+			continue
+		}
+		if repeated {
+			typ = "repeated " + typ
+		}
+		name := snakeCase(f.Name())
+		lines = append(lines, fmt.Sprintf("  %s %s = ", typ, name))
+		names = append(names, name)
+	}
+	assigned, reserved := numbers.assign(t.String(), names)
+
+	fmt.Fprintf(buf, "\nmessage %s {\n", t)
+	writeProtoReserved(buf, reserved)
+	for _, line := range lines {
+		if strings.HasSuffix(line, "\n") {
+			buf.WriteString(line)
+			continue
+		}
+		fmt.Fprintf(buf, "%s%d;\n", line, assigned[0])
+		assigned = assigned[1:]
+	}
+	buf.WriteString("}\n")
+}
+
+// writeProtoInterface emits a message which contains exactly one of
+// the interface's implementations.
+func (v *visitation) writeProtoInterface(
+	buf *bytes.Buffer, t namedInterfaceType, numbers *protoNumbers,
+) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, impl := range t.Implementors() {
+		name := impl.Underlying.String()
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	fields := make([]string, len(names))
+	for i, name := range names {
+		fields[i] = snakeCase(name)
+	}
+	assigned, reserved := numbers.assign(t.String(), fields)
+
+	fmt.Fprintf(buf, "\nmessage %s {\n", t)
+	writeProtoReserved(buf, reserved)
+	if len(names) > 0 {
+		buf.WriteString("  oneof impl {\n")
+		for i, name := range names {
+			fmt.Fprintf(buf, "    %s %s = %d;\n", name, fields[i], assigned[i])
+		}
+		buf.WriteString("  }\n")
+	}
+	buf.WriteString("}\n")
+}
+
+// writeProtoReserved emits a statement which reserves field numbers.
+func writeProtoReserved(buf *bytes.Buffer, reserved []int) {
+	if len(reserved) == 0 {
+		return
+	}
+	buf.WriteString("  reserved ")
+	for i, number := range reserved {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(strconv.Itoa(number))
+	}
+	buf.WriteString(";\n")
+}
+
+// protoType returns the proto3 type which represents typ. Pointers
+// are elided, since messages are always nullable, and slices become
+// repeated fields. Nested slices cannot be represented.
+func (v *visitation) protoType(typ types.Type) (name string, repeated bool, ok bool) {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Pointer:
+		return v.protoType(t.Elem())
+
+	case *types.Slice:
+		if b, isBasic := t.Elem().Underlying().(*types.Basic); isBasic && b.Kind() == types.Uint8 {
+			return "bytes", false, true
+		}
+		name, nested, ok := v.protoType(t.Elem())
+		return name, true, ok && !nested
+
+	case *types.Named:
+		if found, isVisitable := v.visitableType(t, true); isVisitable {
+			switch impl := found.Implementation().(type) {
+			case namedStruct, namedInterfaceType:
+				return impl.String(), false, true
+			}
+		}
+		// Named scalars and named visitable slices.
+		return v.protoType(t.Underlying())
+
+	case *types.Basic:
+		name, ok = protoScalars[t.Kind()]
+		return name, false, ok
+	}
+	return "", false, false
+}
+
+// snakeCase converts a Go identifier to the naming convention used for
+// proto fields; e.g. ByRefPtr -> by_ref_ptr and URLPath -> url_path.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				sb.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
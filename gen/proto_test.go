@@ -0,0 +1,129 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProto(t *testing.T) {
+	a := assert.New(t)
+	protoName := filepath.Join(t.TempDir(), "target.proto")
+
+	cfg := configs["single"]
+	cfg.Proto = protoName
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+
+	src := string(outputs[protoName])
+	a.Contains(src, "syntax = \"proto3\";\n\npackage demo;\n")
+	a.Contains(src, `
+message ByRefType {
+  string val = 1;
+}
+`)
+	a.Contains(src, `
+message Target {
+  oneof impl {
+    ByRefType by_ref_type = 1;
+    ByValType by_val_type = 2;
+    ContainerType container_type = 3;
+  }
+}
+`)
+	a.Contains(src, "  ByRefType by_ref_ptr = 2;\n")
+	a.Contains(src, "  repeated ByRefType by_ref_ptr_slice = 4;\n")
+	a.Contains(src, "  Target another_target_ptr = 11;\n")
+	a.Contains(src, "  repeated Target named_targets = 16;\n")
+	a.Contains(src, "  // OtherReachable has no proto representation.\n")
+	a.NotContains(src, "ignored")
+
+	// The Go code should not be affected.
+	delete(outputs, protoName)
+	expected, err := Generate(configs["single"])
+	if a.NoError(err) {
+		a.Equal(expected, outputs)
+	}
+}
+
+func TestProtoNumbers(t *testing.T) {
+	a := assert.New(t)
+	protoName := filepath.Join(t.TempDir(), "target.proto")
+
+	// The numbers in an existing schema are kept, and those which are
+	// no longer used are reserved.
+	existing := `syntax = "proto3";
+
+message ByRefType {
+  reserved 3;
+  string val = 7;
+}
+
+message Target {
+  oneof impl {
+    ContainerType container_type = 1;
+    RemovedType removed_type = 2;
+  }
+}
+`
+	if !a.NoError(os.WriteFile(protoName, []byte(existing), 0644)) {
+		return
+	}
+
+	cfg := configs["single"]
+	cfg.Proto = protoName
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+
+	src := string(outputs[protoName])
+	a.Contains(src, `
+message ByRefType {
+  reserved 3;
+  string val = 7;
+}
+`)
+	a.Contains(src, `
+message Target {
+  reserved 2;
+  oneof impl {
+    ByRefType by_ref_type = 3;
+    ByValType by_val_type = 4;
+    ContainerType container_type = 1;
+  }
+}
+`)
+
+	if a.NoError(os.WriteFile(protoName, []byte("message Target {\n  oops\n}\n"), 0644)) {
+		_, err = Generate(cfg)
+		a.EqualError(err, protoName+":2: expecting <type> <name> = <number>")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("val", snakeCase("Val"))
+	a.Equal("by_ref_ptr", snakeCase("ByRefPtr"))
+	a.Equal("url_path", snakeCase("URLPath"))
+	a.Equal("type_id", snakeCase("TypeID"))
+}
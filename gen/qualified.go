@@ -0,0 +1,172 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// splitQualified splits a seed name such as "somepkg.Expr" or
+// "example.com/somepkg.Expr" into its qualifier and type name.
+func splitQualified(name string) (qualifier, typeName string) {
+	idx := strings.LastIndex(name, ".")
+	if idx == -1 {
+		return "", name
+	}
+	return name[:idx], name[idx+1:]
+}
+
+// resolveQualifiedSeeds removes any package qualifiers from the seed
+// type names and, if no package was explicitly configured, selects the
+// package to operate on. A qualifier may be a complete import path, or
+// the name of a package which is either under Dir or is imported by
+// the package in Dir.
+//
+// If the package to operate on imports the qualified package, the
+// qualified seeds are retained and will be resolved from the imported
+// package instead. This supports a layout where the visitable interface
+// is declared in a base package that is shared by the implementing
+// packages.
+func (g *generation) resolveQualifiedSeeds() error {
+	var qualifier string
+	names := make([]string, len(g.TypeNames))
+	for i, name := range g.TypeNames {
+		q, typeName := splitQualified(name)
+		if q != "" {
+			if qualifier != "" && q != qualifier {
+				return errors.Errorf("seed types must be in a single package, found %s and %s",
+					qualifier, q)
+			}
+			qualifier = q
+		}
+		names[i] = typeName
+	}
+	if qualifier == "" {
+		return nil
+	}
+
+	path, err := g.importedSeedPackage(qualifier)
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		g.seedPackage = path
+		g.logf(Verbose, "resolving %s seeds from imported package %s", qualifier, path)
+		return nil
+	}
+	g.TypeNames = names
+
+	if g.Package != "" {
+		if g.Package != qualifier && !strings.HasSuffix(g.Package, "/"+qualifier) {
+			return errors.Errorf("seed qualifier %s does not match --package %s", qualifier, g.Package)
+		}
+		return nil
+	}
+	if strings.Contains(qualifier, "/") {
+		g.Package = qualifier
+		return nil
+	}
+
+	// Look for packages under Dir, then for the imports of the
+	// package in Dir, with a matching name.
+	cfg := &packages.Config{
+		Dir:  g.Dir,
+		Env:  g.env(),
+		Mode: packages.NeedName | packages.NeedImports,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return err
+	}
+	found := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if pkg.Name == qualifier {
+			found[pkg.PkgPath] = true
+		}
+	}
+	if len(found) == 0 {
+		for _, pkg := range pkgs {
+			for _, imp := range pkg.Imports {
+				if imp.Name == qualifier {
+					found[imp.PkgPath] = true
+				}
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(found))
+	for path := range found {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	switch len(paths) {
+	case 0:
+		return errors.Errorf("could not find a package named %s", qualifier)
+	case 1:
+		g.Package = paths[0]
+		g.logf(Verbose, "resolved %s to %s", qualifier, g.Package)
+		return nil
+	default:
+		return errors.Errorf("%s is ambiguous, use an import path instead: %s",
+			qualifier, strings.Join(paths, ", "))
+	}
+}
+
+// importedSeedPackage returns the import path of the package that the
+// qualifier refers to, if it is imported by the package to operate on.
+func (g *generation) importedSeedPackage(qualifier string) (string, error) {
+	cfg := &packages.Config{
+		Dir:  g.Dir,
+		Env:  g.env(),
+		Mode: packages.NeedName | packages.NeedImports,
+	}
+	pkgs, err := packages.Load(cfg, g.pattern())
+	if err != nil {
+		return "", err
+	}
+	for _, pkg := range pkgs {
+		if pkg.PkgPath == qualifier || pkg.Name == qualifier {
+			return "", nil
+		}
+	}
+
+	found := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, imp := range pkg.Imports {
+			if imp.PkgPath == qualifier || imp.Name == qualifier {
+				found[imp.PkgPath] = true
+			}
+		}
+	}
+	paths := make([]string, 0, len(found))
+	for path := range found {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	switch len(paths) {
+	case 0:
+		return "", nil
+	case 1:
+		return paths[0], nil
+	default:
+		return "", errors.Errorf("%s is ambiguous, use an import path instead: %s",
+			qualifier, strings.Join(paths, ", "))
+	}
+}
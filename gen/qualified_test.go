@@ -0,0 +1,73 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQualifiedSeeds(t *testing.T) {
+	tcs := []struct {
+		name  string
+		dir   string
+		pkg   string
+		seeds []string
+		union string
+		err   string
+	}{
+		{name: "package name", dir: "..", seeds: []string{"demo.Target"}},
+		{name: "import path", dir: ".", seeds: []string{"github.com/cockroachdb/walkabout/demo.Target"}},
+		{name: "matching package", dir: "..", pkg: "github.com/cockroachdb/walkabout/demo",
+			seeds: []string{"demo.Target"}},
+		{name: "mixed", dir: "..", seeds: []string{"demo.Target", "Unionable"}, union: "Union"},
+		{name: "mismatched package", dir: "..", pkg: "github.com/cockroachdb/walkabout/demo",
+			seeds: []string{"nope.Target"},
+			err:   "seed qualifier nope does not match --package github.com/cockroachdb/walkabout/demo"},
+		{name: "multiple packages", dir: "..", seeds: []string{"demo.Target", "other.Reachable"},
+			union: "Union", err: "seed types must be in a single package, found demo and other"},
+		{name: "unknown package", dir: "..", seeds: []string{"nope.Target"},
+			err: "could not find a package named nope"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+			outputs, err := Generate(Config{
+				Dir:       tc.dir,
+				Package:   tc.pkg,
+				TypeNames: tc.seeds,
+				Union:     tc.union,
+			})
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+				return
+			}
+			if !a.NoError(err) {
+				return
+			}
+			name := "target_walkabout.g.go"
+			if tc.union != "" {
+				name = "union_walkabout.g.go"
+			}
+			abs, err := filepath.Abs(filepath.Join("../demo", name))
+			a.NoError(err)
+			a.Contains(outputs, abs)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+// This file supports generating code for several independent root
+// types, without a --union, into a single file.
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/tools/go/packages"
+)
+
+// multipleRoots returns true if each of the input types should be
+// generated as its own root.
+func (g *generation) multipleRoots() bool {
+	return len(g.TypeNames) > 1 && g.Union == ""
+}
+
+// executeRoots generates the code for each of the input types as
+// though walkabout had been run once for each, and writes the results
+// into a single file.
+func (g *generation) executeRoots(pkgs []*packages.Package) error {
+	var first *visitation
+	var inTest bool
+	claimed := make(map[string]bool)
+	names := make([]string, len(g.TypeNames))
+	srcs := make([][]byte, len(g.TypeNames))
+	for idx, typeName := range g.TypeNames {
+		child := *g
+		child.TypeNames = []string{typeName}
+		child.OutFile = ""
+		child.otherRoots = true
+		v, err := child.analyzePackages(pkgs)
+		if err != nil {
+			return errors.Wrap(err, typeName)
+		}
+		for _, a := range v.Adapters() {
+			claimed[a.Name] = true
+		}
+		names[idx] = v.Root.String()
+		if idx == 0 {
+			first, inTest = v, v.inTest
+		} else if v.inTest != inTest {
+			return errors.Errorf("%s and %s cannot be generated into one file, since only "+
+				"one is declared in a test file; use --test or --no-test", names[0], names[idx])
+		}
+		if _, srcs[idx], err = v.render(); err != nil {
+			return errors.Wrap(err, typeName)
+		}
+	}
+
+	for _, name := range g.Adapters {
+		if !claimed[name] {
+			return errors.Errorf("adapter %s does not accept any of %s",
+				name, strings.Join(names, ", "))
+		}
+	}
+
+	merged, err := mergeSources(srcs)
+	if err != nil {
+		return err
+	}
+	outName := g.OutFile
+	if outName == "" {
+		outName = strings.ToLower(strings.Join(names, "_")) + "_walkabout.g"
+		if inTest {
+			outName += "_test"
+		}
+		outName = filepath.Join(first.dir, outName+".go")
+	}
+	formatted, err := g.format(outName, merged)
+	if err != nil {
+		return err
+	}
+	return first.writeAPI(outName, formatted)
+}
+
+// mergeSources combines several generated files from the same package
+// into one. The header of the first file is kept, the imports are
+// merged, and the declarations of each file are appended in order.
+func mergeSources(srcs [][]byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	var header, body bytes.Buffer
+	var imports []string
+	seenImport := make(map[string]bool)
+	var sources []string
+	seenSource := make(map[string]bool)
+
+	for idx, src := range srcs {
+		file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+
+		// Collect the names of the source files from each header.
+		for _, line := range strings.Split(string(src[:offset(file.Package)]), "\n") {
+			if source := strings.TrimPrefix(line, "// source: "); source != line && !seenSource[source] {
+				seenSource[source] = true
+				sources = append(sources, source)
+			}
+		}
+		if idx == 0 {
+			header.Write(src[:offset(file.Name.End())])
+		}
+
+		bodyStart := offset(file.Name.End())
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.IMPORT {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				imp := spec.(*ast.ImportSpec)
+				text := imp.Path.Value
+				if imp.Name != nil {
+					text = imp.Name.Name + " " + text
+				}
+				if !seenImport[text] {
+					seenImport[text] = true
+					imports = append(imports, text)
+				}
+			}
+			bodyStart = offset(gen.End())
+		}
+		body.Write(src[bodyStart:])
+		body.WriteString("\n")
+	}
+
+	var buf bytes.Buffer
+	// Replace the source line of the first header with all of the
+	// sources.
+	for _, line := range strings.SplitAfter(header.String(), "\n") {
+		if strings.HasPrefix(line, "// source: ") {
+			line = "// source: " + strings.Join(sources, ", ") + "\n"
+		}
+		buf.WriteString(line)
+	}
+	// Standard library imports are grouped before the others.
+	buf.WriteString("\n\nimport (\n")
+	for _, std := range []bool{true, false} {
+		if !std {
+			buf.WriteString("\n")
+		}
+		for _, imp := range imports {
+			if isStdImport(imp) == std {
+				buf.WriteString("\t" + imp + "\n")
+			}
+		}
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// isStdImport returns true if the import, as written in an import
+// declaration, is of a standard library package.
+func isStdImport(imp string) bool {
+	path, _ := strconv.Unquote(imp[strings.Index(imp, `"`):])
+	return !strings.Contains(strings.SplitN(path, "/", 2)[0], ".")
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/packages"
+)
+
+// Verify that several roots, without a union, are generated into a
+// single file which compiles.
+func TestMultipleRoots(t *testing.T) {
+	a := assert.New(t)
+
+	cfg := Config{
+		Adapters:  []string{"TargetVisitor"},
+		Dir:       "../demo",
+		TypeNames: []string{"Target", "EmbedsTarget"},
+	}
+	outputs := make(map[string][]byte)
+	g, err := newGenerationForTesting(cfg, outputs)
+	if !a.NoError(err) {
+		return
+	}
+	if !a.NoError(g.Execute()) {
+		return
+	}
+	outName, err := filepath.Abs("../demo/target_embedstarget_walkabout.g.go")
+	if !a.NoError(err) {
+		return
+	}
+	a.Len(outputs, 1)
+	src := string(outputs[outName])
+	a.Equal(1, strings.Count(src, "\nimport ("))
+	a.Contains(src, "// source: demo.go\n")
+	a.Contains(src, "func WalkTarget(")
+	a.Contains(src, "func WalkEmbedsTarget(")
+
+	// Replace the existing generated code for Target with the merged
+	// file.
+	existing, err := filepath.Abs("../demo/target_walkabout.g.go")
+	if !a.NoError(err) {
+		return
+	}
+	outputs[existing] = []byte("package demo\n")
+
+	pkgCfg := g.packageConfig()
+	pkgCfg.Mode = packages.LoadAllSyntax
+	pkgCfg.Overlay = outputs
+	pkgs, err := packages.Load(pkgCfg, ".")
+	if a.NoError(err) {
+		for _, pkg := range pkgs {
+			a.Nil(pkg.Errors)
+		}
+	}
+
+	// Files which describe a single root cannot be shared.
+	cfg.Manifest = "manifest.txt"
+	_, err = Generate(cfg)
+	a.EqualError(err, "--manifest requires a single input type or --union")
+
+	// An adapter must belong to one of the roots.
+	cfg.Manifest = ""
+	cfg.TypeNames = []string{"EmbedsTarget", "Unionable"}
+	_, err = Generate(cfg)
+	a.EqualError(err, "adapter TargetVisitor does not accept any of EmbedsTarget, Unionable")
+
+	// Other commands still require a union.
+	err = List(cfg, io.Discard)
+	a.EqualError(err, "multiple input types can only be used with --union")
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+// The declarations in shapeSource must match these.
+type shapeGeneric[T any] struct{ X T }
+type shapeNamed int
+type shapeFixture struct {
+	A int
+	B byte
+	C rune
+	D unsafe.Pointer
+	E error
+	F any
+	G interface{ M() }
+	H [4]*string
+	I map[string]int
+	J chan int
+	K func()
+	L struct{ X int }
+	M shapeGeneric[int]
+	N *shapeGeneric[string]
+	O []shapeNamed
+	shapeNamed
+	unexported bool
+}
+
+const shapeSource = `package fixture
+
+import "unsafe"
+
+type shapeGeneric[T any] struct{ X T }
+type shapeNamed int
+type shapeFixture struct {
+	A int
+	B byte
+	C rune
+	D unsafe.Pointer
+	E error
+	F any
+	G interface{ M() }
+	H [4]*string
+	I map[string]int
+	J chan int
+	K func()
+	L struct{ X int }
+	M shapeGeneric[int]
+	N *shapeGeneric[string]
+	O []shapeNamed
+	shapeNamed
+	unexported bool
+}
+`
+
+// TestShape verifies that the Shape computed from the source of a
+// struct matches the one which the engine computes at runtime.
+func TestShape(t *testing.T) {
+	a := assert.New(t)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "fixture.go", shapeSource, 0)
+	if !a.NoError(err) {
+		return
+	}
+	cfg := types.Config{Importer: importer.Default()}
+	pkg, err := cfg.Check("fixture", fset, []*ast.File{file}, nil)
+	if !a.NoError(err) {
+		return
+	}
+	named := pkg.Scope().Lookup("shapeFixture").Type().(*types.Named)
+	s := namedStruct{Named: named, Struct: named.Underlying().(*types.Struct)}
+
+	var fields []string
+	for i := 0; i < s.NumFields(); i++ {
+		fields = append(fields, engine.ShapeField(s.Field(i).Name(), shapeType(s.Field(i).Type())))
+	}
+	a.Equal(fmt.Sprintf("%#x", uint64(engine.ShapeOf(reflect.TypeOf(shapeFixture{})))),
+		s.Shape(), "fields: %q", fields)
+}
@@ -0,0 +1,229 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// sizeSections maps the section markers emitted by the built-in
+// templates to the columns of the size report.
+var sizeSections = map[string]string{
+	"API and public types": "api",
+	"Type Enhancements":    "enhancements",
+	"Type Mapping":         "typemap",
+	"Union Support":        "union",
+	"Visitor Adapters":     "api",
+}
+
+// sizeColumns is the order in which sections are reported. The header
+// contains the package clause and imports.
+var sizeColumns = []string{"header", "api", "enhancements", "union", "typemap"}
+
+var sizeMarker = regexp.MustCompile(`^------ (.+?) -+$`)
+
+// sharedOwner collects code which isn't specific to any one type.
+const sharedOwner = "(shared)"
+
+// typeSize accumulates the size of the code generated for one type.
+type typeSize struct {
+	bytes int
+	lines map[string]int
+	owner string
+}
+
+// reportSize writes a breakdown of the generated code, by visitable
+// type and by section. Pointer and slice types are reported with the
+// type that they refer to.
+func (v *visitation) reportSize(name string, src []byte, w io.Writer) error {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	// Find the offsets at which each section starts.
+	type section struct {
+		name   string
+		offset int
+	}
+	var sections []section
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+			if m := sizeMarker.FindStringSubmatch(text); m != nil {
+				if col, ok := sizeSections[m[1]]; ok {
+					sections = append(sections, section{col, fset.Position(c.Pos()).Offset})
+				}
+			}
+		}
+	}
+	sectionAt := func(offset int) string {
+		ret := "header"
+		for _, s := range sections {
+			if s.offset <= offset {
+				ret = s.name
+			}
+		}
+		return ret
+	}
+
+	// Map generated identifiers back to the types they describe.
+	owners := make(map[string]string)
+	for id, t := range v.Types {
+		owner := strings.TrimLeft(t.String(), "*[]")
+		owners[string(id)] = owner
+		owners[v.engineID(t)] = owner
+	}
+	for name := range v.SourceTypes {
+		owners[string(name)] = string(name)
+	}
+	owners[v.Root.String()] = v.Root.String()
+
+	sizes := make(map[string]*typeSize)
+	add := func(owner string, from, to token.Pos) {
+		if owner == "" {
+			owner = sharedOwner
+		}
+		s := sizes[owner]
+		if s == nil {
+			s = &typeSize{lines: make(map[string]int), owner: owner}
+			sizes[owner] = s
+		}
+		start, end := fset.Position(from), fset.Position(to)
+		s.bytes += end.Offset - start.Offset
+		s.lines[sectionAt(start.Offset)] += end.Line - start.Line + 1
+	}
+
+	add(sharedOwner, file.Package, file.Name.End())
+	for _, decl := range file.Decls {
+		// Include any doc comments with the declaration.
+		from := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				from = d.Doc.Pos()
+			}
+			owner := ""
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				owner = owners[receiverName(d.Recv.List[0].Type)]
+			}
+			add(owner, from, d.End())
+
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				from = d.Doc.Pos()
+			}
+			// Attribute the entries of the type map and the TypeID
+			// constants to their types.
+			var parts []ast.Node
+			for _, spec := range d.Specs {
+				vs, ok := spec.(*ast.ValueSpec)
+				if !ok {
+					continue
+				}
+				if len(vs.Names) == 1 && owners[vs.Names[0].Name] != "" {
+					parts = append(parts, vs)
+					add(owners[vs.Names[0].Name], vs.Pos(), vs.End())
+					continue
+				}
+				for _, value := range vs.Values {
+					ast.Inspect(value, func(n ast.Node) bool {
+						kv, ok := n.(*ast.KeyValueExpr)
+						if !ok {
+							return true
+						}
+						if owner := owners[keyName(kv.Key)]; owner != "" {
+							parts = append(parts, kv)
+							add(owner, kv.Pos(), kv.End())
+							return false
+						}
+						return true
+					})
+				}
+			}
+			// The remainder of the declaration is shared.
+			add(sharedOwner, from, d.End())
+			shared := sizes[sharedOwner]
+			section := sectionAt(fset.Position(from).Offset)
+			for _, part := range parts {
+				start, end := fset.Position(part.Pos()), fset.Position(part.End())
+				shared.bytes -= end.Offset - start.Offset
+				shared.lines[section] -= end.Line - start.Line + 1
+			}
+		}
+	}
+
+	sorted := make([]*typeSize, 0, len(sizes))
+	for _, s := range sizes {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].bytes != sorted[j].bytes {
+			return sorted[i].bytes > sorted[j].bytes
+		}
+		return sorted[i].owner < sorted[j].owner
+	})
+
+	fmt.Fprintf(w, "%s: %d lines, %d bytes\n", name, strings.Count(string(src), "\n"), len(src))
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "TYPE\t")
+	for _, col := range sizeColumns {
+		fmt.Fprintf(tw, "%s\t", strings.ToUpper(col))
+	}
+	fmt.Fprint(tw, "LINES\tBYTES\t\n")
+	for _, s := range sorted {
+		fmt.Fprintf(tw, "%s\t", s.owner)
+		total := 0
+		for _, col := range sizeColumns {
+			fmt.Fprintf(tw, "%d\t", s.lines[col])
+			total += s.lines[col]
+		}
+		fmt.Fprintf(tw, "%d\t%d\t\n", total, s.bytes)
+	}
+	return tw.Flush()
+}
+
+// receiverName returns the name of a method receiver's base type.
+func receiverName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// keyName returns the name of the TypeID in a type map key, which is
+// either e.TypeID(SomeTypeID) or an engine constant.
+func keyName(expr ast.Expr) string {
+	if call, ok := expr.(*ast.CallExpr); ok && len(call.Args) == 1 {
+		expr = call.Args[0]
+	}
+	if id, ok := expr.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportSize(t *testing.T) {
+	a := assert.New(t)
+
+	var sb strings.Builder
+	cfg := configs["single"]
+	cfg.Log = &sb
+	cfg.ReportSize = true
+	outputs, err := Generate(cfg)
+	if !a.NoError(err) {
+		return
+	}
+	src := outputs[filepath.Join("../demo", "target_walkabout.g.go")]
+
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if !a.True(len(lines) > 2) {
+		return
+	}
+	a.Contains(lines[0], strconv.Itoa(len(src))+" bytes")
+	a.Equal([]string{"TYPE", "HEADER", "API", "ENHANCEMENTS", "UNION", "TYPEMAP", "LINES", "BYTES"},
+		strings.Fields(lines[1]))
+
+	// Every byte of the generated code is attributed to exactly one row.
+	rows := make(map[string][]string)
+	total := 0
+	for _, line := range lines[2:] {
+		fields := strings.Fields(line)
+		rows[fields[0]] = fields
+		n, err := strconv.Atoi(fields[len(fields)-1])
+		a.NoError(err)
+		total += n
+	}
+	a.InDelta(len(src), total, float64(len(src))/20)
+
+	for _, name := range []string{sharedOwner, "ContainerType", "ByRefType", "ByValType", "Target"} {
+		a.Contains(rows, name)
+	}
+	// Pointer and slice types are reported with their elements.
+	a.NotContains(rows, "*ByRefType")
+	a.NotContains(rows, "[]ByValType")
+	// Enhancement methods are attributed to their structs.
+	a.NotEqual("0", rows["ContainerType"][3])
+}
@@ -15,7 +15,18 @@
 
 package gen
 
-import "go/types"
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/walkabout/engine"
+	"github.com/pkg/errors"
+)
 
 // visitableType represents a type that we can generate visitation logic
 // around:
@@ -93,11 +104,39 @@ func (t namedInterfaceType) Visitation() *visitation {
 	return t.v
 }
 
+// Implementors returns a sortable map of the visitable types which
+// implement the interface.
+func (t namedInterfaceType) Implementors() map[string]implementor {
+	ret := make(map[string]implementor)
+	isUnion := t.Union != "" && t.Union == t.Visitation().Root.Union
+	for _, typ := range t.Visitation().Types {
+		if s, ok := typ.(namedStruct); ok {
+			if !isUnion && types.Implements(s.Named, t.Interface) {
+				ret[s.String()] = implementor{t, s, s}
+			}
+			if isUnion || types.Implements(types.NewPointer(s.Named), t.Interface) {
+				p := pointerType{s}
+				ret[s.String()+"*"] = implementor{t, p, s}
+			}
+		}
+	}
+	return ret
+}
+
 // pointerType is a pointer to a visitableType.
 type pointerType struct {
 	Elem visitableType
 }
 
+// PkgPath returns the import path of the package which declares the
+// interface.
+func (t namedInterfaceType) PkgPath() string {
+	if t.Union != "" {
+		return t.v.packagePath
+	}
+	return t.Obj().Pkg().Path()
+}
+
 // Implementation returns the receiver.
 func (t pointerType) Implementation() visitableType {
 	return t
@@ -150,27 +189,260 @@ func (t namedStruct) String() string {
 	return t.Obj().Name()
 }
 
-// Fields returns the visitable fields of the struct.
+// PkgPath returns the import path of the package which declares the
+// struct.
+func (t namedStruct) PkgPath() string {
+	return t.Obj().Pkg().Path()
+}
+
+// qualifiedName returns the name of a type, including the import path
+// of each named type, as it would be returned from
+// engine.StringifyQualified.
+func qualifiedName(t visitableType) string {
+	switch t := t.Implementation().(type) {
+	case pointerType:
+		return "*" + qualifiedName(t.Elem)
+	case namedSliceType:
+		return "[]" + qualifiedName(t.Elem)
+	case namedInterfaceType:
+		return t.PkgPath() + "." + t.String()
+	case namedStruct:
+		return t.PkgPath() + "." + t.String()
+	default:
+		return t.String()
+	}
+}
+
+// Equal returns an expression which compares the structs pointed to
+// by a and b using the struct's Equal method, if it has one which
+// accepts either the struct or a pointer to it.
+func (t namedStruct) Equal(a, b string) string {
+	ptr := types.NewPointer(t.Named)
+	obj, _, _ := types.LookupFieldOrMethod(ptr, false, t.Obj().Pkg(), "Equal")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return ""
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 1 || sig.Results().Len() != 1 ||
+		!types.Identical(sig.Results().At(0).Type(), types.Typ[types.Bool]) {
+		return ""
+	}
+	switch param := sig.Params().At(0).Type(); {
+	case types.Identical(param, t.Named):
+		return fmt.Sprintf("(*%s)(%s).Equal(*(*%[1]s)(%[3]s))", t, a, b)
+	case types.Identical(param, ptr):
+		return fmt.Sprintf("(*%s)(%s).Equal((*%[1]s)(%[3]s))", t, a, b)
+	default:
+		return ""
+	}
+}
+
+// Shape returns the engine.Shape of the struct, which is checked at
+// runtime to detect generated code which is out of date.
+func (t namedStruct) Shape() string {
+	fields := make([]string, t.NumFields())
+	for i := range fields {
+		f := t.Field(i)
+		fields[i] = engine.ShapeField(f.Name(), shapeType(f.Type()))
+	}
+	return fmt.Sprintf("%#x", uint64(engine.NewShape(fields...)))
+}
+
+// shapeType describes a type in the same way as the engine does when
+// given a reflect.Type.
+func shapeType(typ types.Type) string {
+	typ = types.Unalias(typ)
+	switch t := typ.(type) {
+	case *types.Basic:
+		// Use the canonical name, e.g. uint8 instead of byte.
+		return types.Typ[t.Kind()].Name()
+	case *types.Named:
+		if t.TypeArgs().Len() == 0 {
+			return t.Obj().Name()
+		}
+	}
+	switch u := typ.Underlying().(type) {
+	case *types.Array:
+		return fmt.Sprintf("[%d]%s", u.Len(), shapeType(u.Elem()))
+	case *types.Pointer:
+		return "*" + shapeType(u.Elem())
+	case *types.Slice:
+		return "[]" + shapeType(u.Elem())
+	case *types.Chan:
+		return reflect.Chan.String()
+	case *types.Interface:
+		return reflect.Interface.String()
+	case *types.Map:
+		return reflect.Map.String()
+	case *types.Signature:
+		return reflect.Func.String()
+	case *types.Struct:
+		return reflect.Struct.String()
+	default:
+		return reflect.Invalid.String()
+	}
+}
+
+// Excluded returns true if the i'th field of the struct has a
+// `walkabout:"-"` tag, which prevents it from being visited.
+func (t namedStruct) Excluded(i int) bool {
+	return reflect.StructTag(t.Tag(i)).Get("walkabout") == "-"
+}
+
+// fieldOptions are the options in the walkabout tag of a field.
+type fieldOptions struct {
+	// Name replaces the name of the field in paths, queries, and the
+	// serialized forms of the struct. It is empty if the field is not
+	// renamed.
+	Name string
+	// Order controls the order in which the fields are visited. Fields
+	// without the option have an order of zero.
+	Order int
+}
+
+// Options parses the comma-separated `name=N` and `order=N` options in
+// the walkabout tag of the i'th field. If an option is repeated, the
+// last one wins.
+func (t namedStruct) Options(i int) (fieldOptions, error) {
+	var ret fieldOptions
+	tag := reflect.StructTag(t.Tag(i)).Get("walkabout")
+	if tag == "" || tag == "-" {
+		return ret, nil
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		if value := strings.TrimPrefix(opt, "name="); value != opt {
+			if !token.IsIdentifier(value) {
+				return ret, fmt.Errorf("name must be an identifier, not %q", value)
+			}
+			ret.Name = value
+		} else if value := strings.TrimPrefix(opt, "order="); value != opt {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return ret, fmt.Errorf("order must be an integer, not %q", value)
+			}
+			ret.Order = n
+		} else {
+			return ret, fmt.Errorf("unknown option %q", opt)
+		}
+	}
+	return ret, nil
+}
+
+// SerializedName returns the name of the i'th field which is recorded
+// by the engine, which may be changed by a `name=N` option.
+func (t namedStruct) SerializedName(i int) string {
+	// Invalid tags are reported by checkTags.
+	if opts, _ := t.Options(i); opts.Name != "" {
+		return opts.Name
+	}
+	return t.Field(i).Name()
+}
+
+// checkTags returns an error if the walkabout tag of any field of the
+// visitable structs is invalid, or if two fields would be recorded
+// with the same name.
+func (v *visitation) checkTags() error {
+	var names []string
+	for name := range v.SourceTypes {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s, ok := v.SourceTypes[SourceName(name)].(namedStruct)
+		if !ok {
+			continue
+		}
+		seen := make(map[string]string)
+		for i, j := 0, s.NumFields(); i < j; i++ {
+			f := s.Field(i)
+			if _, err := s.Options(i); err != nil {
+				return errors.Errorf("%s: field %s.%s has an invalid walkabout tag: %v",
+					v.gen.fileSet.Position(f.Pos()), s, f.Name(), err)
+			}
+			if !f.Exported() || s.Excluded(i) {
+				continue
+			}
+			serialized := s.SerializedName(i)
+			if other, ok := seen[serialized]; ok {
+				return errors.Errorf("%s: fields %s.%s and %s.%s are both named %s",
+					v.gen.fileSet.Position(f.Pos()), s, other, s, f.Name(), serialized)
+			}
+			seen[serialized] = f.Name()
+		}
+	}
+	return nil
+}
+
+// Fields returns the visitable fields of the struct, in the order in
+// which they are visited. Fields are sorted by the order option of
+// their tags, and then by their declaration.
 func (t namedStruct) Fields() []fieldInfo {
 	ret := make([]fieldInfo, 0, t.NumFields())
+	var orders []int
 
 	for a, j := 0, t.NumFields(); a < j; a++ {
 		f := t.Field(a)
-		// Ignore un-exported fields.
-		if !f.Exported() {
+		// Ignore un-exported and excluded fields.
+		if !f.Exported() || t.Excluded(a) {
 			continue
 		}
 
 		// Look up `field Something` to visitableType.
 		if found, ok := t.v.visitableType(f.Type(), true); ok {
 			ret = append(ret, fieldInfo{
-				Name:   f.Name(),
-				Parent: &t,
-				Target: found,
+				Name:       f.Name(),
+				Parent:     &t,
+				Serialized: t.SerializedName(a),
+				Tag:        t.Tag(a),
+				Target:     found,
 			})
+			// Invalid tags are reported by checkTags.
+			opts, _ := t.Options(a)
+			orders = append(orders, opts.Order)
 		}
 	}
 
+	sort.Stable(fieldsByOrder{ret, orders})
+	return ret
+}
+
+// fieldsByOrder sorts fields by the order option of their tags.
+type fieldsByOrder struct {
+	fields []fieldInfo
+	orders []int
+}
+
+func (s fieldsByOrder) Len() int           { return len(s.fields) }
+func (s fieldsByOrder) Less(i, j int) bool { return s.orders[i] < s.orders[j] }
+func (s fieldsByOrder) Swap(i, j int) {
+	s.fields[i], s.fields[j] = s.fields[j], s.fields[i]
+	s.orders[i], s.orders[j] = s.orders[j], s.orders[i]
+}
+
+// Scalars returns the exported, non-excluded fields of the struct which
+// are not visitable, but which have a basic type that the engine can encode.
+func (t namedStruct) Scalars() []scalarInfo {
+	var ret []scalarInfo
+	for a, j := 0, t.NumFields(); a < j; a++ {
+		f := t.Field(a)
+		if !f.Exported() || t.Excluded(a) {
+			continue
+		}
+		if _, ok := t.v.visitableType(f.Type(), true); ok {
+			continue
+		}
+		switch u := f.Type().Underlying().(type) {
+		case *types.Basic:
+			if kind, ok := scalarKinds[u.Kind()]; ok {
+				ret = append(ret, scalarInfo{Name: f.Name(), Kind: kind, Serialized: t.SerializedName(a), Tag: t.Tag(a)})
+			}
+		case *types.Slice:
+			if b, ok := u.Elem().(*types.Basic); ok && b.Kind() == types.Uint8 {
+				ret = append(ret, scalarInfo{Name: f.Name(), Kind: "ScalarBytes", Serialized: t.SerializedName(a), Tag: t.Tag(a)})
+			}
+		}
+	}
 	return ret
 }
 
@@ -199,11 +471,46 @@ func (t unionInterface) Visitation() *visitation {
 	return t.v
 }
 
+// scalarKinds maps basic types to the names of the engine's
+// ScalarKind constants.
+var scalarKinds = map[types.BasicKind]string{
+	types.Bool:    "ScalarBool",
+	types.Int:     "ScalarInt",
+	types.Int8:    "ScalarInt8",
+	types.Int16:   "ScalarInt16",
+	types.Int32:   "ScalarInt32",
+	types.Int64:   "ScalarInt64",
+	types.Uint:    "ScalarUint",
+	types.Uint8:   "ScalarUint8",
+	types.Uint16:  "ScalarUint16",
+	types.Uint32:  "ScalarUint32",
+	types.Uint64:  "ScalarUint64",
+	types.Uintptr: "ScalarUintptr",
+	types.Float32: "ScalarFloat32",
+	types.Float64: "ScalarFloat64",
+	types.String:  "ScalarString",
+}
+
+// scalarInfo describes a field containing a basic type.
+type scalarInfo struct {
+	Name string
+	// The name of the engine's ScalarKind constant.
+	Kind string
+	// The name recorded by the engine, which may differ from Name.
+	Serialized string
+	// The complete tag of the field.
+	Tag string
+}
+
 // fieldInfo describes a field containing a visitable type.
 type fieldInfo struct {
 	Name string
 	// The structInfo that contains this fieldInfo.
 	Parent *namedStruct
+	// The name recorded by the engine, which may differ from Name.
+	Serialized string
+	// The complete tag of the field.
+	Tag string
 	// The contents of the field.
 	Target visitableType
 }
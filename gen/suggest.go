@@ -0,0 +1,110 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxSuggestions limits the number of near-matches that are reported
+// for an unknown type name.
+const maxSuggestions = 3
+
+// unknownType returns an error for a type name which could not be
+// found in any of the scopes. The error will suggest similarly-named
+// types and list the exported interfaces which could be used as seeds.
+func unknownType(name string, scopes []*types.Scope) error {
+	type candidate struct {
+		name string
+		dist int
+	}
+	var candidates []candidate
+	var intfs []string
+	seen := make(map[string]bool)
+
+	// Anything more than a third of the name is unlikely to be a typo.
+	limit := len(name) / 3
+	if limit < 2 {
+		limit = 2
+	}
+
+	for _, scope := range scopes {
+		for _, n := range scope.Names() {
+			obj, ok := scope.Lookup(n).(*types.TypeName)
+			if !ok || seen[n] {
+				continue
+			}
+			seen[n] = true
+			if _, isIntf := obj.Type().Underlying().(*types.Interface); isIntf && obj.Exported() {
+				intfs = append(intfs, n)
+			}
+			if d := editDistance(strings.ToLower(name), strings.ToLower(n)); d <= limit {
+				candidates = append(candidates, candidate{n, d})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].dist != candidates[j].dist {
+			return candidates[i].dist < candidates[j].dist
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	if len(candidates) > maxSuggestions {
+		candidates = candidates[:maxSuggestions]
+	}
+	sort.Strings(intfs)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "unknown type %q", name)
+	if len(candidates) > 0 {
+		quoted := make([]string, len(candidates))
+		for i, c := range candidates {
+			quoted[i] = fmt.Sprintf("%q", c.name)
+		}
+		fmt.Fprintf(&sb, " (did you mean %s?)", strings.Join(quoted, " or "))
+	}
+	if len(intfs) > 0 {
+		fmt.Fprintf(&sb, "; exported interfaces: %s", strings.Join(intfs, ", "))
+	}
+	return errors.New(sb.String())
+}
+
+// editDistance computes the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
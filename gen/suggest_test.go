@@ -0,0 +1,45 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(0, editDistance("Target", "Target"))
+	a.Equal(1, editDistance("Targt", "Target"))
+	a.Equal(2, editDistance("Tagret", "Target"))
+	a.Equal(6, editDistance("", "Target"))
+}
+
+func TestUnknownTypeSuggestions(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := Generate(Config{Dir: "../demo", TypeNames: []string{"targt"}})
+	if a.Error(err) {
+		a.Contains(err.Error(), `unknown type "targt" (did you mean "Target" or "Targets"?)`)
+		a.Contains(err.Error(), "exported interfaces: Calc, CalcAbstract, EmbedsTarget, Expr, Target,")
+	}
+
+	_, err = Generate(Config{Dir: "../demo", TypeNames: []string{"Nothing"}})
+	if a.Error(err) {
+		a.NotContains(err.Error(), "did you mean")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldTags(t *testing.T) {
+	extra, err := filepath.Abs("../demo/tags_extra.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tcs := []struct {
+		tags       [4]string
+		expected   []string
+		serialized []string
+		err        string
+	}{
+		{
+			// Untagged fields keep their declaration order.
+			tags:       [4]string{`walkabout:"order=1,name=a"`, "", `walkabout:"name=c,order=-1"`, ""},
+			expected:   []string{"C", "B", "D", "A"},
+			serialized: []string{"c", "B", "D", "a"},
+		},
+		{
+			tags: [4]string{"", `walkabout:"order=x"`, "", ""},
+			err:  `tags_extra.go:5:2: field TaggedType.B has an invalid walkabout tag: order must be an integer, not "x"`,
+		},
+		{
+			tags: [4]string{`walkabout:"first"`, "", "", ""},
+			err:  `tags_extra.go:4:2: field TaggedType.A has an invalid walkabout tag: unknown option "first"`,
+		},
+		{
+			tags: [4]string{"", "", `walkabout:"name=c-d"`, ""},
+			err:  `tags_extra.go:6:2: field TaggedType.C has an invalid walkabout tag: name must be an identifier, not "c-d"`,
+		},
+		{
+			tags: [4]string{`walkabout:"name=B"`, "", "", ""},
+			err:  `tags_extra.go:5:2: fields TaggedType.A and TaggedType.B are both named B`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(strings.Join(tc.tags[:], " "), func(t *testing.T) {
+			a := assert.New(t)
+			g, err := newGeneration(configs["single"])
+			if !a.NoError(err) {
+				return
+			}
+			g.extraTestSource = map[string][]byte{
+				extra: []byte(`package demo
+
+type TaggedType struct {
+	A *ByRefType ` + "`" + tc.tags[0] + "`" + `
+	B *ByRefType ` + "`" + tc.tags[1] + "`" + `
+	C *ByRefType ` + "`" + tc.tags[2] + "`" + `
+	D *ByRefType ` + "`" + tc.tags[3] + "`" + `
+}
+
+func (*TaggedType) Value() string { return "" }
+`),
+			}
+			v, err := g.analyze()
+			if tc.err != "" {
+				if a.Error(err) {
+					a.Contains(err.Error(), tc.err)
+				}
+				return
+			}
+			if !a.NoError(err) {
+				return
+			}
+			var fields, serialized []string
+			for _, f := range v.SourceTypes["TaggedType"].(namedStruct).Fields() {
+				fields = append(fields, f.Name)
+				serialized = append(serialized, f.Serialized)
+			}
+			a.Equal(tc.expected, fields)
+			a.Equal(tc.serialized, serialized)
+		})
+	}
+}
@@ -18,8 +18,7 @@ package gen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
-	"go/types"
+	"os"
 	"path"
 	"path/filepath"
 	"sort"
@@ -32,6 +31,10 @@ import (
 
 var allTemplates = make(map[string]*template.Template)
 
+// Interfaces with at least this many implementations will be
+// dispatched by the engine using a map.
+const mapDispatchThreshold = 16
+
 // Register all templates to be generated.
 func init() {
 	for name, src := range templates.TemplateSources {
@@ -52,20 +55,7 @@ var funcMap = template.FuncMap{
 	// Implementors returns a sortable map of types which implement
 	// the interface.
 	"Implementors": func(t namedInterfaceType) map[string]implementor {
-		ret := make(map[string]implementor)
-		isUnion := t.Union != "" && t.Union == t.Visitation().Root.Union
-		for _, typ := range t.Visitation().Types {
-			if s, ok := typ.(namedStruct); ok {
-				if !isUnion && types.Implements(s.Named, t.Interface) {
-					ret[s.String()] = implementor{t, s, s}
-				}
-				if isUnion || types.Implements(types.NewPointer(s.Named), t.Interface) {
-					p := pointerType{s}
-					ret[s.String()+"*"] = implementor{t, p, s}
-				}
-			}
-		}
-		return ret
+		return t.Implementors()
 	},
 	// Intfs returns a sortable map of all interface types used.
 	"Intfs": func(v *visitation) map[string]namedInterfaceType {
@@ -77,6 +67,9 @@ var funcMap = template.FuncMap{
 		}
 		return ret
 	},
+	// Qualified returns the name of a type, including the import path
+	// of each named type.
+	"Qualified": qualifiedName,
 	// IsPointer returns true if the type is a pointer or resolves
 	// to a pointer type.
 	"IsPointer": func(v visitableType) bool {
@@ -91,8 +84,32 @@ var funcMap = template.FuncMap{
 			}
 		}
 	},
+	// Inline returns true if the type is a struct, or a pointer to a
+	// struct, which has a specialized walker.
+	"Inline": func(t visitableType) bool { return t.Visitation().isInline(t) },
+	// MapDispatch returns true if the interface has enough
+	// implementations that the engine should use a map, rather than a
+	// type-switch, to identify the values that it contains.
+	"MapDispatch": func(t namedInterfaceType) bool {
+		return len(t.Implementors()) >= mapDispatchThreshold
+	},
+	// Line returns a //line directive which attributes the following
+	// code to the declaration of a struct, if enabled.
+	"Line": func(s namedStruct) string { return s.v.lineDirective(s) },
+	// LineEnd ends a section of code started by Line.
+	"LineEnd": func(v *visitation) string { return v.lineEnd() },
+	// Used returns true if the type can appear at runtime and has an
+	// entry in the type map.
+	"Used": func(t visitableType) bool { return t.Visitation().isUsed(t) },
+	// Ordinal returns the stable value of a TypeID from the manifest.
+	"Ordinal": func(t visitableType) int {
+		v := t.Visitation()
+		return v.Manifest.ordinal(v.ensureTypeID(t))
+	},
 	// Package returns the name of the package we're working in.
 	"Package": func(v *visitation) string { return path.Base(v.packagePath) },
+	// Ptr returns a pointer to the given type.
+	"Ptr": func(t visitableType) visitableType { return pointerType{Elem: t} },
 	// Pointers returns a sortable map of all pointer types used.
 	"Pointers": func(v *visitation) map[string]pointerType {
 		ret := make(map[string]pointerType)
@@ -115,10 +132,14 @@ var funcMap = template.FuncMap{
 	},
 	// SourceFile returns the name of the file that defines the interface.
 	"SourceFile": func(v *visitation) string {
-		if v.Root.Named == nil {
+		obj := v.unionDecl
+		if v.Root.Named != nil {
+			obj = v.Root.Obj()
+		}
+		if obj == nil {
 			return ""
 		}
-		return filepath.Base(v.gen.fileSet.Position(v.Root.Obj().Pos()).Filename)
+		return filepath.Base(v.gen.fileSet.Position(obj.Pos()).Filename)
 	},
 	// Structs returns a sortable map of all slice types used.
 	"Structs": func(v *visitation) map[string]namedStruct {
@@ -130,14 +151,41 @@ var funcMap = template.FuncMap{
 		}
 		return ret
 	},
+	// Version returns the version of walkabout.
+	"Version": version,
+	// EID returns an expression for the engine's type token for a type.
+	"EID": func(t visitableType) string {
+		v := t.Visitation()
+		id := v.ensureTypeID(t)
+		if v.StringIDs() {
+			return v.engineID(t)
+		}
+		return fmt.Sprintf("e.TypeID(%s)", id)
+	},
+	// Ident concatenates its arguments into an identifier which is
+	// exported, unless an un-exported API has been requested.
+	"Ident": func(v *visitation, parts ...interface{}) string {
+		var sb strings.Builder
+		for _, part := range parts {
+			sb.WriteString(fmt.Sprint(part))
+		}
+		return v.identifier(sb.String())
+	},
 	// t returns an un-exported named based on the visitable interface name.
 	"t": func(v *visitation, name string) string {
 		intfName := v.Root.String()
-		return fmt.Sprintf("%s%s%s", strings.ToLower(intfName[:1]), intfName[1:], name)
+		ret := fmt.Sprintf("%s%s%s", strings.ToLower(intfName[:1]), intfName[1:], name)
+		// Avoid colliding with a public name when the visitable
+		// interface or the generated API is un-exported.
+		if ret == v.identifier(v.Root.String()+name) {
+			ret += "Impl"
+		}
+		return ret
 	},
-	// T returns an exported named based on the visitable interface name.
+	// T returns an exported named based on the visitable interface name,
+	// unless an un-exported API has been requested.
 	"T": func(v *visitation, name string) string {
-		return fmt.Sprintf("%s%s", v.Root, name)
+		return v.identifier(v.Root.String() + name)
 	},
 	// TypeID generates a reasonable description of a type.
 	"TypeID": func(t visitableType) TypeID {
@@ -145,41 +193,129 @@ var funcMap = template.FuncMap{
 	},
 }
 
+// templates returns the templates to execute, keyed by name. Any
+// user-provided templates in the configured template directory will
+// replace or extend the built-in templates.
+func (g *generation) templates() (map[string]*template.Template, error) {
+	if g.TemplateDir == "" {
+		return allTemplates, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(g.TemplateDir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("no *.tmpl files found in %s", g.TemplateDir)
+	}
+
+	ret := make(map[string]*template.Template, len(allTemplates)+len(files))
+	for name, tmpl := range allTemplates {
+		ret[name] = tmpl
+	}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		tmpl, err := template.New(name).Funcs(funcMap).Parse(string(src))
+		if err != nil {
+			return nil, errors.Wrap(err, file)
+		}
+		ret[name] = tmpl
+	}
+	return ret, nil
+}
+
 // generateAPI is the main code-generation function. It evaluates
 // the embedded template and then calls go/format on the resulting
 // code.
 func (v *visitation) generateAPI() error {
+	outName, formatted, err := v.render()
+	if err != nil {
+		return err
+	}
+	return v.writeAPI(outName, formatted)
+}
+
+// render evaluates the embedded template and any plugins, and returns
+// the formatted code and the name of the file to which it should be
+// written.
+func (v *visitation) render() (outName string, formatted []byte, err error) {
+	tmpls, err := v.gen.templates()
+	if err != nil {
+		return "", nil, err
+	}
+
+	v.markUsed()
+	v.markInline()
 
-	// Parse each template and sort the keys.
-	sorted := make([]string, 0, len(allTemplates))
-	var err error
-	for key := range allTemplates {
+	// Sort the template keys.
+	sorted := make([]string, 0, len(tmpls))
+	for key := range tmpls {
 		sorted = append(sorted, key)
 	}
 	sort.Strings(sorted)
 
 	// Execute each template in sorted order.
 	var buf bytes.Buffer
+	done := v.gen.timed(Verbose, "template execution")
+	v.gen.progress.setPhase("executing templates")
+	v.gen.progress.setTemplates(len(sorted))
 	for _, key := range sorted {
-		if err := allTemplates[key].ExecuteTemplate(&buf, key, v); err != nil {
-			return errors.Wrap(err, key)
+		tmplDone := v.gen.timed(Debug, "template "+key)
+		if err := tmpls[key].ExecuteTemplate(&buf, key, v); err != nil {
+			return "", nil, errors.Wrap(err, key)
 		}
+		tmplDone()
+		v.gen.progress.addTemplate()
 	}
+	done()
 
-	formatted, err := format.Source(buf.Bytes())
+	// Allow plugins to append to the output.
+	if len(v.gen.Plugins) > 0 {
+		view := v.view()
+		v.gen.logf(Debug, "view contains %d types", len(view.Types))
+		for _, plugin := range v.gen.Plugins {
+			pluginDone := v.gen.timed(Debug, fmt.Sprintf("plugin %T", plugin))
+			if err := plugin.Emit(view, &buf); err != nil {
+				return "", nil, errors.Wrapf(err, "plugin %T", plugin)
+			}
+			pluginDone()
+		}
+	}
+
+	outName = v.outName()
+	done = v.gen.timed(Verbose, "formatting")
+	v.gen.progress.setPhase("formatting")
+	formatted, err = v.gen.format(outName, buf.Bytes())
 	if err != nil {
-		println(buf.String())
-		return err
+		v.gen.logf(Debug, "unformatted source of %s:\n%s", outName, buf.String())
+		return "", nil, err
 	}
+	done()
+	return outName, formatted, nil
+}
 
-	outName := v.gen.outFile
-	if outName == "" {
-		outName = strings.ToLower(v.Root.String()) + "_walkabout.g"
-		if v.inTest {
-			outName += "_test"
+// writeAPI writes the formatted code, after any requested checks, and
+// the other files which were requested.
+func (v *visitation) writeAPI(outName string, formatted []byte) error {
+	if err := v.checkCollisions(outName, formatted); err != nil {
+		return err
+	}
+	if v.gen.LineDirectives {
+		formatted = resetLineDirectives(formatted, outName)
+	}
+	if v.gen.Check {
+		if err := v.check(outName, formatted); err != nil {
+			return err
+		}
+	}
+	if v.gen.ReportSize {
+		if err := v.reportSize(outName, formatted, v.gen.logWriter()); err != nil {
+			return err
 		}
-		outName += ".go"
-		outName = filepath.Join(v.gen.dir, outName)
 	}
 
 	out, err := v.gen.writeCloser(outName)
@@ -191,5 +327,72 @@ func (v *visitation) generateAPI() error {
 	if x := out.Close(); x != nil && err == nil {
 		err = x
 	}
+	if err != nil {
+		return err
+	}
+
+	if v.Manifest != nil {
+		out, err = v.gen.writeCloser(v.gen.Manifest)
+		if err != nil {
+			return err
+		}
+		_, err = v.Manifest.WriteTo(out)
+		if x := out.Close(); x != nil && err == nil {
+			err = x
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.gen.Proto != "" {
+		var numbers *protoNumbers
+		numbers, err = readProtoNumbers(v.gen.Proto)
+		if err != nil {
+			return err
+		}
+		out, err = v.gen.writeCloser(v.gen.Proto)
+		if err != nil {
+			return err
+		}
+		err = v.writeProto(out, numbers)
+		if x := out.Close(); x != nil && err == nil {
+			err = x
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.gen.JSONSchema != "" {
+		out, err = v.gen.writeCloser(v.gen.JSONSchema)
+		if err != nil {
+			return err
+		}
+		err = v.writeJSONSchema(out)
+		if x := out.Close(); x != nil && err == nil {
+			err = x
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if v.gen.Examples {
+		err = v.writeExamples()
+	}
 	return err
 }
+
+// outName returns the name of the file to generate.
+func (v *visitation) outName() string {
+	if v.gen.OutFile != "" {
+		return v.gen.OutFile
+	}
+	outName := strings.ToLower(v.Root.String()) + "_walkabout.g"
+	if v.inTest {
+		outName += "_test"
+	}
+	outName += ".go"
+	return filepath.Join(v.dir, outName)
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package templates
+
+func init() {
+	TemplateSources["60adapter"] = `
+{{- $v := . -}}
+{{- $Context := T $v "Context" -}}
+{{- $Decision := T $v "Decision" -}}
+{{- $Root := $v.Root -}}
+{{- $WalkerFn := T $v "WalkerFn" -}}
+{{- if $v.Adapters }}
+// ------ Visitor Adapters ------
+{{- range $a := $v.Adapters }}
+{{- $Adapter := Ident $v $a "Adapter" }}
+
+// {{ $Adapter }} returns a {{ $WalkerFn }} which delegates to an
+// implementation of {{ $a }}.
+{{- if $a.Pre }}
+// Children will not be visited if VisitPre returns false.
+{{- end }}
+func {{ $Adapter }}(visitor {{ $a }}) {{ $WalkerFn }} {
+	{{- if $a.Post }}
+	post := func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		{{- if $a.PostReplaces }}
+		if y := visitor.VisitPost(x); y != nil {
+			return ctx.Continue().Replace(y)
+		}
+		{{- else }}
+		visitor.VisitPost(x)
+		{{- end }}
+		return ctx.Continue()
+	}
+	{{- end }}
+	return func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		{{- if $a.PreReplaces }}
+		recurse, y := visitor.VisitPre(x)
+		if !recurse {
+			if y != nil {
+				return ctx.Skip().Replace(y)
+			}
+			return ctx.Skip()
+		}
+		{{- else if $a.Pre }}
+		if !visitor.VisitPre(x) {
+			return ctx.Skip()
+		}
+		{{- end }}
+		d := ctx.Continue()
+		{{- if $a.PreReplaces }}
+		if y != nil {
+			d = d.Replace(y)
+		}
+		{{- end }}
+		{{- if $a.Post }}
+		d = d.Post(post)
+		{{- end }}
+		return d
+	}
+}
+{{- end }}
+{{ end -}}
+`
+}
@@ -23,16 +23,27 @@ func init() {
 {{- $ChildAt := T $v "At" -}}
 {{- $Context := T $v "Context" -}}
 {{- $Decision := T $v "Decision" -}}
+{{- $Each := T $v "Each" -}}
+{{- $Field := T $v "Field" -}}
+{{- $identifier := t $v "Identifier" -}}
 {{- $identify := t $v "Identify" -}}
+{{- $Kind := T $v "Kind" -}}
 {{- $NumChildren := T $v "Count" -}}
+{{- $ReplacementError := T $v "ReplacementError" -}}
 {{- $Root := $v.Root -}}
 {{- $TypeID := T $v "TypeID" -}}
+{{- $TypeName := T $v "TypeName" -}}
 {{- $WalkerFn := T $v "WalkerFn" -}}
 {{- $wrap := t $v "Wrap" -}}
 // ------ API and public types ------
 
 // {{ $TypeID }} is a lightweight type token.
-type {{ $TypeID }} e.TypeID
+type {{ $TypeID }} {{ if $v.StringIDs }}string{{ else }}e.TypeID{{ end }}
+{{- with $v.RootImport }}
+
+// {{ $Root }} is the visitable interface declared in package {{ .Name }}.
+type {{ $Root }} = {{ .Name }}.{{ $Root }}
+{{- end }}
 
 // {{ $Abstract }} allows users to treat a {{ $Root }} as an abstract
 // tree of nodes. All visitable struct types will have generated methods
@@ -40,24 +51,84 @@ type {{ $TypeID }} e.TypeID
 type {{ $Abstract }} interface {
 	// {{ $ChildAt }} returns the nth field of a struct or nth element of a
 	// slice. If the child is a type which directly implements
-	// {{ $Abstract }}, it will be returned. If the child is of a pointer or
-	// interface type, the value will be automatically dereferenced if it
-	// is non-nil. If the child is a slice type, a {{ $Abstract }} wrapper
-	// around the slice will be returned.
+	// {{ $Abstract }}, it will be returned, unless the child must record
+	// its path. If the child is of a pointer or interface type, the value
+	// will be automatically dereferenced if it is non-nil. Otherwise, a
+	// {{ $Abstract }} wrapper around the child will be returned.
 	{{ $ChildAt }}(index int) {{ $Abstract }}
+	// {{ $Each }} calls yield with each index for which {{ $ChildAt }}
+	// would return a non-nil value, and that value, until yield returns
+	// false. This does not allocate for each child, so a {{ $Abstract }}
+	// wrapper around a slice will be reused and must not be retained
+	// after yield returns.
+	{{ $Each }}(yield func(index int, child {{ $Abstract }}) bool)
+	// {{ $Field }} returns the name and the complete tag of the nth field
+	// of a struct, or empty values for a slice. The name is the one used
+	// in paths and serialized forms.
+	{{ $Field }}(index int) (name string, tag reflect.StructTag)
+	// {{ $Kind }} returns whether the value is a struct or a slice.
+	{{ $Kind }}() {{ $Kind }}
 	// {{ $NumChildren }} returns the number of visitable fields in a struct,
 	// or the length of a slice.
 	{{ $NumChildren }}() int
+	// {{ T $v "Path" }} returns the location of the value, using the same
+	// syntax as {{ $Context }}.Path, if it was obtained from
+	// {{ Ident $v "Abstract" $Root "At" }} or is a descendant of such a value.
+	// Otherwise, it returns an empty string.
+	{{ T $v "Path" }}() string
 	// {{ $TypeID }} returns a type token.
 	{{ $TypeID }}() {{ $TypeID }}
+	// {{ $TypeName }} returns a human-readable name for the type of the
+	// value, e.g. "Foo" or "[]Foo".
+	{{ $TypeName }}() string
 }
 
+// {{ $Kind }} describes the shape of a visitable type.
+type {{ $Kind }} = e.Kind
+
+// The kinds of visitable types.
+const (
+	{{ T $v "KindInterface" }} = e.KindInterface
+	{{ T $v "KindPointer" }} = e.KindPointer
+	{{ T $v "KindSlice" }} = e.KindSlice
+	{{ T $v "KindStruct" }} = e.KindStruct
+)
+
+{{- if not $v.ExplicitEngine }}
+
 var (
 {{- range $s := Structs $v -}}
 _ {{ $Abstract }} = &{{ $s }}{};
 {{- end -}}
 )
+{{- end }}
+
+{{- if $v.Generics }}
 
+// {{ $WalkerFn }} is used to implement a visitor pattern over
+// types which implement {{ $Root }}.
+type {{ $WalkerFn }} = e.TypedWalkerFn[{{ $Root }}, {{ $identifier }}]
+
+// {{ $Context }} is provided to {{ $WalkerFn }} and acts as a factory
+// for constructing {{ $Decision }} instances.
+type {{ $Context }} = e.TypedContext[{{ $Root }}, {{ $identifier }}]
+
+// {{ $Decision }} is used by {{ $WalkerFn }} to control visitation.
+type {{ $Decision }} = e.TypedDecision[{{ $Root }}, {{ $identifier }}]
+
+// {{ $Action }} is used by {{ $Context }}.Actions() and allows users
+// to have fine-grained control over traversal.
+type {{ $Action }} = e.TypedAction[{{ $Root }}, {{ $identifier }}]
+
+// {{ $identifier }} allows the engine's generic types to identify
+// a {{ $Root }}.
+type {{ $identifier }} struct{}
+
+// Identify implements e.Identifier.
+func ({{ $identifier }}) Identify(x {{ $Root }}) (e.TypeID, e.Ptr) {
+	return {{ $identify }}(x)
+}
+{{ else }}
 // {{ $WalkerFn }} is used to implement a visitor pattern over
 // types which implement {{ $Root }}.
 //
@@ -99,6 +170,14 @@ func (c *{{ $Context }}) Continue() {{ $Decision }} {
 	return {{ $Decision }}(c.impl.Continue())
 }
 
+// Defer registers a function to be called after the walk completes,
+// even if it is halted or fails. Functions are called in the reverse
+// order of their registration. If the walk succeeds, the first error
+// returned by a deferred function is returned from the walk.
+func (c *{{ $Context }}) Defer(fn func() error) {
+	c.impl.Defer(fn)
+}
+
 // Error returns a {{ $Decision }} which will cause the given error
 // to be returned from the Walk() function. Post-visit functions
 // will not be called.
@@ -113,6 +192,12 @@ func (c *{{ $Context }}) Halt() {{ $Decision }} {
 }
 
 
+// Path returns the location of the current object, e.g.
+// "Parent/Slice[1]/Field".
+func (c *{{ $Context }}) Path() string {
+	return c.impl.Path()
+}
+
 // Skip will not traverse the fields of the current object.
 func (c *{{ $Context }}) Skip() {{ $Decision }} {
 	return {{ $Decision }}(c.impl.Skip())
@@ -139,18 +224,36 @@ func (d {{ $Decision }}) Post(fn {{ $WalkerFn }}) {{ $Decision }} {
 }
 
 // Replace allows the currently-visited value to be replaced. All
-// parent nodes will be cloned.
+// parent nodes will be cloned. If the replacement cannot be stored in
+// the value's location, the walk returns a *{{ $ReplacementError }}.
 func (d {{ $Decision }}) Replace(x {{ $Root }}) {{ $Decision }} {
 	return {{ $Decision }}((e.Decision)(d).Replace({{ $identify }}(x)))
 }
 
+{{ range $imp := Implementors $Root -}}
+{{- if not (IsPointer $imp.Actual) }}
+// Replace{{ $imp.Actual }} is equivalent to Replace, but avoids the
+// allocations required to pass a {{ $imp.Actual }} as a {{ $Root }}.
+// The value must not be modified until the visitation has completed.
+func (d {{ $Decision }}) Replace{{ $imp.Actual }}(x *{{ $imp.Actual }}) {{ $Decision }} {
+	return {{ $Decision }}((e.Decision)(d).Replace({{ EID $imp.Underlying }}, e.Ptr(x)))
+}
+{{ end -}}
+{{- end }}
+{{- end }}
+
+// {{ $ReplacementError }} is returned from a walk when a value is
+// replaced by a value whose type is not assignable to the value's
+// location. It identifies the path of the value and both types.
+type {{ $ReplacementError }} = e.ReplacementError
+
 // {{ $identify }} is a utility function to map a {{ $Root }} into
 // its generated type id and a pointer to the data. 
 func {{ $identify }}(x {{ $Root }}) (typeId e.TypeID, data e.Ptr) {
 	switch t := x.(type) {
 		{{ range $imp := Implementors $Root -}}
 		case {{ $imp.Actual }}:
-			typeId = e.TypeID({{ TypeID $imp.Underlying }});
+			typeId = {{ EID $imp.Underlying }};
 			{{ if IsPointer $imp.Actual }}data = e.Ptr(t);
 			{{ else }}data = e.Ptr(&t);
 			{{ end }}
@@ -167,11 +270,13 @@ func {{ $identify }}(x {{ $Root }}) (typeId e.TypeID, data e.Ptr) {
 // {{ $wrap }} is a utility function to reconstitute a {{ $Root }}
 // from an internal type token and a pointer to the value.
 func {{ $wrap }}(typeId e.TypeID, x e.Ptr) {{ $Root }} {
-	switch {{ $TypeID }}(typeId) {
+	switch typeId {
 	{{ range $imp := Implementors $Root -}}
 		{{- if IsPointer $imp.Actual -}}
-			case {{ TypeID $imp.Actual.Elem }}: return (*{{ $imp.Actual.Elem }})(x);
-			case {{ TypeID $imp.Actual }}: return *(*{{ $imp.Actual }})(x);
+			case {{ EID $imp.Actual.Elem }}: return (*{{ $imp.Actual.Elem }})(x);
+			{{- if Used $imp.Actual }}
+			case {{ EID $imp.Actual }}: return *(*{{ $imp.Actual }})(x);
+			{{- end -}}
 		{{- end -}}
 	{{- end }}
 	default:
@@ -179,6 +284,7 @@ func {{ $wrap }}(typeId e.TypeID, x e.Ptr) {{ $Root }} {
 		panic(fmt.Sprintf("unhandled TypeID %d", typeId))
 	}
 }
+{{- if not $v.Generics }}
 
 // {{ $Action }} is used by {{ $Context }}.Actions() and allows users
 // to have fine-grained control over traversal.
@@ -189,9 +295,19 @@ func (c *{{ $Context }}) ActionVisit(x {{ $Root }}) {{ $Action }} {
 	return {{ $Action }} (c.impl.ActionVisitTypeID({{ $identify }}(x)))
 }
 
+{{ range $imp := Implementors $Root -}}
+{{- if not (IsPointer $imp.Actual) }}
+// ActionVisit{{ $imp.Actual }} is equivalent to ActionVisit, but avoids
+// the allocations required to pass a {{ $imp.Actual }} as a {{ $Root }}.
+func (c *{{ $Context }}) ActionVisit{{ $imp.Actual }}(x *{{ $imp.Actual }}) {{ $Action }} {
+	return {{ $Action }}(c.impl.ActionVisitTypeID({{ EID $imp.Underlying }}, e.Ptr(x)))
+}
+{{ end -}}
+{{- end }}
 // ActionCall constructs a {{ $Action }} that will invoke the given callback.
 func (c *{{ $Context }}) ActionCall(fn func()error) {{ $Action }} {
 	return {{ $Action }} (c.impl.ActionCall(fn))
 }
+{{- end }}
 `
 }
@@ -19,14 +19,70 @@ func init() {
 	TemplateSources["50enhancements"] = `
 {{- $v := . -}}
 {{- $abstract := t $v "Abstract" -}}
+{{- $abstractOf := t $v "AbstractOf" -}}
 {{- $Abstract := T $v "Abstract" -}}
+{{- $Arena := T $v "Arena" -}}
 {{- $ChildAt := T $v "At" -}}
-{{- $Engine := t $v "Engine" -}}
+{{- $Engine := T $v "Engine" -}}
+{{- $engine := printf "%s()" (t $v "Engine") -}}
+{{- if $v.ExplicitEngine }}{{ $engine = "eng.impl" }}{{ end -}}
+{{- $eng := "" -}}
+{{- if $v.ExplicitEngine }}{{ $eng = printf "(eng *%s) " $Engine }}{{ end -}}
+{{- $engParam := "" -}}
+{{- if $v.ExplicitEngine }}{{ $engParam = printf "eng *%s, " $Engine }}{{ end -}}
 {{- $NumChildren := T $v "Count" -}}
+{{- $PanicError := T $v "PanicError" -}}
+{{- $PathError := T $v "PathError" -}}
+{{- $Stack := T $v "Stack" -}}
 {{- $identify := t $v "Identify" -}}
 {{- $Root := $v.Root -}}
+{{- $AbstractAt := Ident $v "Abstract" $Root "At" -}}
+{{- $Path := T $v "Path" -}}
 {{- $TypeID := T $v "TypeID" -}}
+{{- $TypeName := T $v "TypeName" -}}
+{{- $Compare := Ident $v "Compare" $Root -}}
+{{- $Diff := Ident $v "Diff" $Root -}}
+{{- $Equal := Ident $v "Equal" $Root -}}
+{{- $Merge := Ident $v "Merge" $Root -}}
+{{- $Conflict := T $v "Conflict" -}}
+{{- $Zip := Ident $v "Zip" $Root -}}
+{{- $ZipFn := T $v "ZipFn" -}}
+{{- $Context := T $v "Context" -}}
+{{- $Decision := T $v "Decision" -}}
+{{- $Decode := Ident $v "Decode" $Root -}}
+{{- $Dump := Ident $v "Dump" $Root -}}
+{{- $DecodeMap := Ident $v "Decode" $Root "Map" -}}
+{{- $Each := T $v "Each" -}}
+{{- $Field := T $v "Field" -}}
+{{- $Encode := Ident $v "Encode" $Root -}}
+{{- $ErrNil := Ident $v "ErrNil" $Root -}}
+{{- if $v.ExplicitEngine }}{{ $ErrNil = "e.ErrNilRoot" }}{{ end -}}
+{{- $ErrMaxDepth := Ident $v "ErrMaxDepth" $Root -}}
+{{- if $v.ExplicitEngine }}{{ $ErrMaxDepth = "e.ErrMaxDepth" }}{{ end -}}
+{{- $EncodeMap := Ident $v "Encode" $Root "Map" -}}
+{{- $identifier := t $v "Identifier" -}}
+{{- $inline := t $v "Inline" -}}
+{{- $Kind := T $v "Kind" -}}
+{{- $Match := T $v "Match" -}}
+{{- $Parse := Ident $v "Parse" $Root -}}
+{{- $Query := Ident $v "Query" $Root -}}
+{{- $Random := Ident $v "Random" $Root -}}
+{{- $RandomConfig := T $v "RandomConfig" -}}
+{{- $Event := T $v "Event" -}}
+{{- $EventKind := T $v "EventKind" -}}
+{{- $NewRecorder := Ident $v "NewRecording" $Root "Walker" -}}
+{{- $Recorder := T $v "Recorder" -}}
+{{- $Shrink := Ident $v "Shrink" $Root -}}
+{{- $Walk := Ident $v "Walk" $Root -}}
+{{- $WalkAll := Ident $v "Walk" $Root "All" -}}
+{{- $WalkAs := Ident $v "Walk" $Root "As" -}}
+{{- $WalkAccum := Ident $v "Walk" $Root "Accum" -}}
+{{- $Accumulator := T $v "Accumulator" -}}
+{{- $AccumulatorFn := T $v "AccumulatorFn" -}}
+{{- $WalkFrom := Ident $v "Walk" $Root "From" -}}
+{{- $WalkAbstract := Ident $v "Walk" $Root "Abstract" -}}
 {{- $WalkerFn := T $v "WalkerFn" -}}
+{{- $WalkOptions := T $v "WalkOptions" -}}
 {{- $wrap := t $v "Wrap" -}}
 
 // ------ Type Enhancements ------
@@ -38,20 +94,68 @@ type {{ $abstract }} struct {
 var _ {{ $Abstract }} = &{{ $abstract }}{}
 
 // {{ $ChildAt }} implements {{ $Abstract }}.
-func (a *{{ $abstract }}) {{ $ChildAt }}(index int) (ret {{ $Abstract }}) {
+func (a *{{ $abstract }}) {{ $ChildAt }}(index int) {{ $Abstract }} {
 	impl := a.delegate.ChildAt(index)
 	if impl == nil {
 		return nil
 	}
-	switch {{ $TypeID }}(impl.TypeID()) {
-	{{ range $s := Structs $v -}}
-	case {{ TypeID $s }}: ret = (*{{ $s }})(impl.Ptr());
-	case {{ TypeID $s }}Ptr: ret = *(**{{ $s }})(impl.Ptr());
+	return {{ $abstractOf }}(impl, nil)
+}
+
+// {{ $Each }} implements {{ $Abstract }}.
+func (a *{{ $abstract }}) {{ $Each }}(yield func(index int, child {{ $Abstract }}) bool) {
+	var reuse {{ $abstract }}
+	a.delegate.Each(func(index int, impl *e.Abstract) bool {
+		return yield(index, {{ $abstractOf }}(impl, &reuse))
+	})
+}
+
+// {{ $Field }} implements {{ $Abstract }}.
+func (a *{{ $abstract }}) {{ $Field }}(index int) (name string, tag reflect.StructTag) {
+	f, _ := a.delegate.Field(index)
+	return f.Name, f.Tag
+}
+
+// {{ $abstractOf }} returns the struct that impl refers to, or a
+// facade around impl. If reuse is non-nil, it will be used as the
+// facade instead of allocating a new one.
+func {{ $abstractOf }}(impl *e.Abstract, reuse *{{ $abstract }}) {{ $Abstract }} {
+	{{- if not $v.ExplicitEngine }}
+	// Structs cannot record their path, so they are returned only if
+	// there is no path to record.
+	if impl.Path() == "" {
+		switch impl.TypeID() {
+		{{ range $s := Structs $v -}}
+		case {{ EID $s }}: return (*{{ $s }})(impl.Ptr());
+		{{- if Used (Ptr $s) }}
+		case {{ EID (Ptr $s) }}: return *(**{{ $s }})(impl.Ptr());
+		{{- end }}
+		{{- end }}
+		}
+	}
 	{{- end }}
-	default:
-		ret = &{{ $abstract}}{impl}
+	if reuse == nil {
+		return &{{ $abstract}}{impl}
+	}
+	reuse.delegate = impl
+	return reuse
+}
+
+// {{ $AbstractAt }} returns an accessor for the value being visited,
+// which records the value's path, as do its descendants. It returns nil
+// if the value is not being visited by the engine, as is the case for
+// some values of types which are walked inline.
+func {{ $AbstractAt }}(ctx {{ $Context }}) {{ $Abstract }} {
+	impl := ctx.{{ if not $v.Generics }}impl.{{ end }}Abstract()
+	if impl == nil {
+		return nil
 	}
-	return
+	return &{{ $abstract }}{impl}
+}
+
+// {{ $Kind }} implements {{ $Abstract }}.
+func (a *{{ $abstract }}) {{ $Kind }}() {{ $Kind }} {
+	return a.delegate.Kind()
 }
 
 // {{ $NumChildren }} implements {{ $Abstract }}.
@@ -61,37 +165,423 @@ func (a *{{ $abstract }}) {{ $NumChildren }} () int {
 
 // {{ $TypeID }} implements {{ $Abstract }}.
 func (a *{{ $abstract }}) {{ $TypeID }}() {{ $TypeID }} {
+	{{ if $v.StringIDs -}}
+	return {{ t $v "TypeIDs" }}[a.delegate.TypeID()]
+	{{- else -}}
 	return {{ $TypeID }}(a.delegate.TypeID())
+	{{- end }}
+}
+
+// {{ $Path }} implements {{ $Abstract }}.
+func (a *{{ $abstract }}) {{ $Path }}() string {
+	return a.delegate.Path()
+}
+
+// {{ $TypeName }} implements {{ $Abstract }}.
+func (a *{{ $abstract }}) {{ $TypeName }}() string {
+	return a.delegate.TypeName()
 }
 
 {{ range $s := Structs $v }}
+{{ Line $s -}}
+{{- if not $v.ExplicitEngine -}}
 // {{ $ChildAt }} implements {{ $Abstract }}.
 func (x *{{ $s }}) {{ $ChildAt }}(index int) {{ $Abstract }} {
-	self := {{ $abstract }}{ {{ $Engine }}.Abstract(e.TypeID({{ TypeID $s }}), e.Ptr(x)) }
+	self := {{ $abstract }}{ {{ $engine }}.Abstract({{ EID $s }}, e.Ptr(x)) }
 	return self.{{ $ChildAt }}(index)
 }
 
+// {{ $Each }} implements {{ $Abstract }}.
+func (x *{{ $s }}) {{ $Each }}(yield func(index int, child {{ $Abstract }}) bool) {
+	self := {{ $abstract }}{ {{ $engine }}.Abstract({{ EID $s }}, e.Ptr(x)) }
+	self.{{ $Each }}(yield)
+}
+
+// {{ $Field }} implements {{ $Abstract }}.
+func (x *{{ $s }}) {{ $Field }}(index int) (name string, tag reflect.StructTag) {
+	self := {{ $abstract }}{ {{ $engine }}.Abstract({{ EID $s }}, e.Ptr(x)) }
+	return self.{{ $Field }}(index)
+}
+
+// {{ $Path }} returns an empty string, because a struct does not record
+// its location.
+func (*{{ $s }}) {{ $Path }}() string { return "" }
+
+{{ end -}}
+// {{ $Kind }} returns {{ T $v "KindStruct" }}.
+func (*{{ $s }}) {{ $Kind }}() {{ $Kind }} { return {{ T $v "KindStruct" }} }
+
 // {{ $NumChildren }} returns {{ len $s.Fields }}.
 func (x *{{ $s }}) {{ $NumChildren }}() int { return {{ len $s.Fields }} }
 
 // {{ $TypeID }} returns {{ TypeID $s }}.
 func (*{{ $s }}) {{ $TypeID }}() {{ $TypeID }} { return {{ TypeID $s }} }
 
-// Walk{{ $Root }} visits the receiver with the provided callback. 
-func (x *{{ $s }}) Walk{{ $Root }}(fn {{ $WalkerFn }}) (_ *{{ $s }}, changed bool, err error) {
+// {{ $TypeName }} returns "{{ $s }}".
+func (*{{ $s }}) {{ $TypeName }}() string { return "{{ $s }}" }
+{{- if not $v.ExplicitEngine }}
+
+// {{ $Walk }} visits the receiver with the provided callback. 
+func (x *{{ $s }}) {{ $Walk }}(fn {{ $WalkerFn }}) (_ *{{ $s }}, changed bool, err error) {
+	if x == nil {
+		return nil, false, {{ $ErrNil }}
+	}
+{{- if Inline $s }}
+	// The scope runs deferred functions even if fn panics.
+	scope := e.AcquireScope()
+	defer scope.Release()
+	x, changed, _, err = {{ $inline }}{{ $s }}(scope, fn, x)
+	if err = scope.Close(err); err != nil {
+		return nil, false, err
+	}
+	return x, changed, nil
+{{- else }}
 	var y e.Ptr
-	_, y, changed, err = {{ $Engine }}.Execute(fn, e.TypeID({{ TypeID $s }}), e.Ptr(x), e.TypeID({{ TypeID $s }}))
+	_, y, changed, err = {{ $engine }}.Execute(fn, {{ EID $s }}, e.Ptr(x), {{ EID $s }})
 	if err != nil {
 		return nil, false, err
 	}
 	return (*{{ $s }})(y), changed, nil
+{{- end }}
+}
+
+// {{ $WalkFrom }} is equivalent to {{ $Walk }}, but the receiver may be
+// replaced by any {{ $Root }}, rather than only by a *{{ $s }}.
+func (x *{{ $s }}) {{ $WalkFrom }}(fn {{ $WalkerFn }}) (_ {{ $Root }}, changed bool, err error) {
+	if x == nil {
+		return nil, false, {{ $ErrNil }}
+	}
+	id, y, changed, err := {{ $engine }}.Execute(fn, {{ EID $s }}, e.Ptr(x), {{ EID $Root }})
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return {{ $wrap }}(id, y), true, nil
+	}
+	return x, false, nil
+}
+{{- end }}
+{{- if Inline $s }}
+
+// {{ $inline }}{{ $s }} visits a {{ $s }} without using the engine's
+// stack. Any decision other than continuing, skipping, or halting is
+// handed off to the engine, as are fields which are not structs.
+// Deferred functions are recorded in the scope.
+func {{ $inline }}{{ $s }}(scope *e.Scope, fn {{ $WalkerFn }}, x *{{ $s }}) (_ *{{ $s }}, changed, halted bool, err error) {
+	{{- if $v.Generics }}
+	d := e.Decision(fn(e.NewTypedContext[{{ $Root }}, {{ $identifier }}](scope.Context()), x))
+	{{- else }}
+	d := e.Decision(fn({{ $Context }}{scope.Context()}, x))
+	{{- end }}
+	skip, halt, ok := d.Inline()
+	if !ok {
+		var y e.Ptr
+		_, y, changed, halted, err = {{ $engine }}.ResumeScope(scope, fn, &d, {{ EID $s }}, e.Ptr(x), {{ EID $s }})
+		return (*{{ $s }})(y), changed, halted, err
+	}
+	if skip || halt {
+		return x, false, halt, nil
+	}
+	{{- if not $s.Fields }}
+	return x, false, false, nil
+	{{- else }}
+	next := x
+	{{- range $f := $s.Fields }}
+	{{ if Inline $f.Target -}}
+	{{ if IsPointer $f.Target }}if x.{{ $f }} != nil {{ end }}{
+		y, dirty, stop, err := {{ $inline }}{{ $f.InlineTarget }}(scope, fn, {{ if not (IsPointer $f.Target) }}&{{ end }}x.{{ $f }})
+	{{- else -}}
+	{{ with $f.Present }}if {{ . }} {{ end }}{
+		_, y, dirty, stop, err := {{ $engine }}.ResumeScope(scope, fn, nil, {{ EID $f.Target }}, e.Ptr(&x.{{ $f }}), {{ EID $f.Target }})
+	{{- end }}
+		if err != nil {
+			return nil, false, false, err
+		}
+		if dirty {
+			if !changed {
+				cp := *x
+				next, changed = &cp, true
+			}
+			next.{{ $f }} = {{ if Inline $f.Target }}{{ if not (IsPointer $f.Target) }}*{{ end }}y{{ else }}*(*{{ $f.Target }})(y){{ end }}
+		}
+		if stop {
+			return next, changed, true, nil
+		}
+	}
+	{{- end }}
+	return next, changed, false, nil
+	{{- end }}
 }
+{{- end }}
+{{ LineEnd $v -}}
 {{ end }}
 
-// Walk{{ $Root }} visits the receiver with the provided callback. 
-func Walk{{ $Root }}(x {{ $Root }}, fn {{ $WalkerFn }}) (_ {{ $Root }}, changed bool, err error) {
-  id, ptr := {{ $identify }}(x)
-	id, ptr, changed, err = {{ $Engine }}.Execute(fn, id, ptr, e.TypeID({{ TypeID $Root }}))
+{{- if not $v.ExplicitEngine }}
+// {{ $ErrNil }} is returned when a nil {{ $Root }} is walked.
+var {{ $ErrNil }} = e.ErrNilRoot
+
+// {{ $ErrMaxDepth }} is wrapped by the error returned from
+// {{ $WalkOptions }}.{{ $Walk }} when MaxDepth is exceeded.
+var {{ $ErrMaxDepth }} = e.ErrMaxDepth
+{{ end }}
+// {{ $Walk }} visits the receiver with the provided callback. It
+// returns {{ $ErrNil }} if x is nil.
+func {{ $eng }}{{ $Walk }}(x {{ $Root }}, fn {{ $WalkerFn }}) (_ {{ $Root }}, changed bool, err error) {
+	if x == nil {
+		return nil, false, {{ $ErrNil }}
+	}
+	id, ptr := {{ $identify }}(x)
+	id, ptr, changed, err = {{ $engine }}.Execute(fn, id, ptr, {{ EID $Root }})
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return {{ $wrap }}(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// {{ $WalkAbstract }} visits the struct or slice which a refers to,
+// such as a value returned from {{ $ChildAt }}, with the provided
+// callback. Paths are relative to that value, which may only be
+// replaced by one of the same type. If the value is changed, a
+// {{ $Abstract }} for the new value is returned, and the caller may
+// place it into its own copy of the enclosing values. It returns
+// {{ $ErrNil }} if a is nil.
+func {{ $eng }}{{ $WalkAbstract }}(a {{ $Abstract }}, fn {{ $WalkerFn }}) (_ {{ $Abstract }}, changed bool, err error) {
+	var impl *e.Abstract
+	switch t := a.(type) {
+	case *{{ $abstract }}:
+		impl = t.delegate
+	{{- if not $v.ExplicitEngine }}{{ range $s := Structs $v }}
+	case *{{ $s }}:
+		if t != nil {
+			impl = {{ $engine }}.Abstract({{ EID $s }}, e.Ptr(t))
+		}
+	{{- end }}{{ end }}
+	}
+	if impl == nil {
+		return nil, false, {{ $ErrNil }}
+	}
+	id, ptr, changed, err := {{ $engine }}.Execute(fn, impl.TypeID(), impl.Ptr(), impl.TypeID())
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return {{ $abstractOf }}({{ $engine }}.Abstract(id, ptr), nil), true, nil
+	}
+	return a, false, nil
+}
+
+// {{ $WalkAll }} visits x once, calling each of the walkers for every
+// value, as though each walker had walked x by itself. A walker's
+// decisions affect only that walker: if it skips a value, it does not
+// see the value's fields, and if it halts, it sees no further values.
+// The walk stops at the first error. The walkers may not replace
+// values, or return actions or interceptors. It returns {{ $ErrNil }} if
+// x is nil.
+func {{ $eng }}{{ $WalkAll }}(x {{ $Root }}, fns ...{{ $WalkerFn }}) error {
+	if x == nil {
+		return {{ $ErrNil }}
+	}
+	f := e.NewFusion(len(fns))
+	post := func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		for idx, fn, ok := f.NextPost(); ok; idx, fn, ok = f.NextPost() {
+			if err := f.Decide(idx, e.Decision(fn.({{ $WalkerFn }})(ctx, x))); err != nil {
+				return ctx.Error(err)
+			}
+		}
+		return {{ $Decision }}(f.Exit())
+	}
+	id, ptr := {{ $identify }}(x)
+	_, _, _, err := {{ $engine }}.Execute({{ $WalkerFn }}(func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		f.Enter()
+		for idx, fn := range fns {
+			if !f.Active(idx) {
+				continue
+			}
+			if err := f.Decide(idx, e.Decision(fn(ctx, x))); err != nil {
+				return ctx.Error(err)
+			}
+		}
+		return {{ $Decision }}(f.Next()).Post(post)
+	}), id, ptr, {{ EID $Root }})
+	return err
+}
+{{- if $v.ExplicitEngine }}
+
+// {{ Ident $v "Abstract" $Root }} returns an abstract accessor around
+// the value.
+func (eng *{{ $Engine }}) {{ Ident $v "Abstract" $Root }}(x {{ $Root }}) {{ $Abstract }} {
+	if x == nil {
+		return nil
+	}
+	id, ptr := {{ $identify }}(x)
+	return &{{ $abstract }}{eng.impl.Abstract(id, ptr)}
+}
+{{- end }}
+
+// {{ $Arena }} allocates the values which are cloned by its {{ $Walk }}
+// method in batches, which reduces the number of allocations made by
+// large rewrites. The zero value is ready to use. An {{ $Arena }} is
+// not safe for concurrent use.
+type {{ $Arena }} struct {
+	impl e.Arena
+}
+
+// Release allows the memory used by the values returned from
+// {{ $Walk }} to be reused. Those values must not be used after calling
+// Release.
+func (a *{{ $Arena }}) Release() {
+	a.impl.Release()
+}
+
+// {{ $Walk }} is equivalent to the top-level {{ $Walk }} function, but
+// any values which are cloned are allocated from the arena.
+func (a *{{ $Arena }}) {{ $Walk }}({{ $engParam }}x {{ $Root }}, fn {{ $WalkerFn }}) (_ {{ $Root }}, changed bool, err error) {
+	if x == nil {
+		return nil, false, {{ $ErrNil }}
+	}
+	id, ptr := {{ $identify }}(x)
+	id, ptr, changed, err = {{ $engine }}.ExecuteArena(&a.impl, fn, id, ptr, {{ EID $Root }})
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return {{ $wrap }}(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// {{ $Stack }} holds the working state of its {{ $Walk }} method, and
+// may be reused across walks, instead of using the shared pool of
+// stacks. The zero value is ready to use. A {{ $Stack }} is not safe
+// for concurrent use.
+type {{ $Stack }} struct {
+	impl e.Stack
+}
+
+// Grow preallocates space for walks of the given depth.
+func (s *{{ $Stack }}) Grow(depth int) {
+	s.impl.Grow(depth)
+}
+
+// {{ $Walk }} is equivalent to the top-level {{ $Walk }} function, but
+// uses the receiver to hold its working state.
+func (s *{{ $Stack }}) {{ $Walk }}({{ $engParam }}x {{ $Root }}, fn {{ $WalkerFn }}) (_ {{ $Root }}, changed bool, err error) {
+	if x == nil {
+		return nil, false, {{ $ErrNil }}
+	}
+	id, ptr := {{ $identify }}(x)
+	id, ptr, changed, err = {{ $engine }}.ExecuteWith(&s.impl, fn, id, ptr, {{ EID $Root }})
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		return {{ $wrap }}(id, ptr), true, nil
+	}
+	return x, false, nil
+}
+
+// {{ $WalkOptions }} controls a single call to its {{ $Walk }} method.
+// The zero value is equivalent to the top-level {{ $Walk }} function.
+type {{ $WalkOptions }} struct {
+	// Recover converts a panic in a {{ $WalkerFn }}, post-visit
+	// function, or action into a *{{ $PanicError }}, which describes the
+	// location of the value being visited.
+	Recover bool
+	// MaxDepth is optional and limits the depth of the walk, which
+	// grows by one for each struct, pointer, slice, interface, or set of
+	// actions which is entered. A walk which would exceed the limit
+	// returns a *{{ $PathError }} which wraps {{ $ErrMaxDepth }}.
+	MaxDepth int
+	// CheckRaces panics if a walk which replaces a value overlaps
+	// another walk of the same root which also sets CheckRaces. If the
+	// race detector is enabled, the walk also reports reads of the
+	// values it visits, so that in-place mutations by other goroutines
+	// are flagged.
+	CheckRaces bool
+	// VisitTypedNils calls the {{ $WalkerFn }} with the nil pointer held
+	// by a "typed nil" interface field, so that it may be rejected or
+	// replaced. The fields of a typed nil are never visited. Otherwise,
+	// typed nils are skipped.
+	VisitTypedNils bool
+	// NormalizeTypedNils sets an interface field which holds a nil
+	// pointer to nil, unless the {{ $WalkerFn }} replaces the typed nil
+	// with another value.
+	NormalizeTypedNils bool
+	// Counts is optional and is incremented once for each struct which
+	// is visited, keyed by the struct's type. It can be reused across
+	// walks to profile which types dominate a traversal.
+	Counts map[{{ $TypeID }}]int
+	// Only is optional and restricts the walk to structs of the given
+	// types. The {{ $WalkerFn }} is called only for those structs, and
+	// values which cannot contain one of them, based on the types that
+	// were generated, are not entered.
+	Only []{{ $TypeID }}
+	// Tracing is optional and opens spans around the walk.
+	Tracing *e.Tracing
+	// Context is optional and is passed to Tracing.Tracer when the span
+	// of the walk is opened. It does not cancel the walk.
+	Context context.Context
+}
+
+// {{ $PathError }} records the location of the value which caused an
+// error. Errors returned from a {{ $WalkerFn }}, post-visit function, or
+// action are wrapped in a *{{ $PathError }}.
+type {{ $PathError }} = e.PathError
+
+// {{ $PanicError }} is returned from {{ $WalkOptions }}.{{ $Walk }} when
+// Recover is set and a callback panics.
+type {{ $PanicError }} = e.PanicError
+
+// {{ $Walk }} is equivalent to the top-level {{ $Walk }} function, but
+// applies the options.
+func (o {{ $WalkOptions }}) {{ $Walk }}({{ $engParam }}x {{ $Root }}, fn {{ $WalkerFn }}) (_ {{ $Root }}, changed bool, err error) {
+	if x == nil {
+		return nil, false, {{ $ErrNil }}
+	}
+	id, ptr := {{ $identify }}(x)
+	var counts map[e.TypeID]int
+	if o.Counts != nil {
+		counts = make(map[e.TypeID]int)
+		defer func() {
+			for id, count := range counts {
+				{{- if $v.StringIDs }}
+				o.Counts[{{ t $v "TypeIDs" }}[id]] += count
+				{{- else }}
+				o.Counts[{{ $TypeID }}(id)] += count
+				{{- end }}
+			}
+		}()
+	}
+	var only []e.TypeID
+	for _, id := range o.Only {
+		{{- if $v.StringIDs }}
+		// Unknown types are mapped to the invalid TypeID, which is
+		// ignored by the engine.
+		var found e.TypeID
+		for eid, candidate := range {{ t $v "TypeIDs" }} {
+			if candidate == id {
+				found = e.TypeID(eid)
+			}
+		}
+		only = append(only, found)
+		{{- else }}
+		only = append(only, e.TypeID(id))
+		{{- end }}
+	}
+	id, ptr, changed, err = {{ $engine }}.ExecuteOptions(e.Options{
+		CheckRaces:         o.CheckRaces,
+		Context:            o.Context,
+		Counts:             counts,
+		MaxDepth:           o.MaxDepth,
+		NormalizeTypedNils: o.NormalizeTypedNils,
+		Only:               only,
+		Recover:            o.Recover,
+		Tracing:            o.Tracing,
+		VisitTypedNils:     o.VisitTypedNils,
+	}, fn, id, ptr, {{ EID $Root }})
 	if err != nil {
 		return nil, false, err
 	}
@@ -100,5 +590,344 @@ func Walk{{ $Root }}(x {{ $Root }}, fn {{ $WalkerFn }}) (_ {{ $Root }}, changed
 	}
 	return x, false, nil
 }
+
+// {{ $WalkAs }} is equivalent to {{ $Walk }}, but calls fn only for the
+// values of type T and continues past all others. T is usually a
+// pointer to a struct; a struct type also matches the pointers to it
+// which the walk provides. Unless T is an interface, the walk is
+// restricted as if by {{ $WalkOptions }}.Only, so values which cannot
+// contain a T are not entered.
+func {{ $WalkAs }}[T {{ $Root }}]({{ $engParam }}x {{ $Root }}, fn func(ctx {{ $Context }}, x T) {{ $Decision }}) (_ {{ $Root }}, changed bool, err error) {
+	var opts {{ $WalkOptions }}
+	var zero T
+	if any(zero) != nil {
+		id, _ := {{ $identify }}(zero)
+		{{- if $v.StringIDs }}
+		opts.Only = []{{ $TypeID }}{ {{- t $v "TypeIDs" }}[id]}
+		{{- else }}
+		opts.Only = []{{ $TypeID }}{ {{- $TypeID }}(id)}
+		{{- end }}
+	}
+	return opts.{{ $Walk }}({{ if $v.ExplicitEngine }}eng, {{ end }}x, func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		if t, ok := x.(T); ok {
+			return fn(ctx, t)
+		}
+		if t, ok := any(x).(*T); ok {
+			return fn(ctx, *t)
+		}
+		return ctx.Continue()
+	})
+}
+
+// {{ $Accumulator }} holds the results of a walk by {{ $WalkAccum }}, so
+// that a walker function may be reused without capturing its results.
+type {{ $Accumulator }}[A any] struct {
+	// Value is updated by the walker.
+	Value A
+}
+
+// {{ Ident $v "New" $Accumulator }} returns an accumulator which holds the
+// zero value of A.
+func {{ Ident $v "New" $Accumulator }}[A any]() *{{ $Accumulator }}[A] {
+	return &{{ $Accumulator }}[A]{}
+}
+
+// {{ $AccumulatorFn }} is a {{ $WalkerFn }} which also receives the
+// accumulator passed to {{ $WalkAccum }}.
+type {{ $AccumulatorFn }}[A any] func(ctx {{ $Context }}, x {{ $Root }}, acc *{{ $Accumulator }}[A]) {{ $Decision }}
+
+// {{ $WalkAccum }} is equivalent to {{ $Walk }}, but passes acc to fn,
+// which may record the results of the walk in acc.Value.
+func {{ $WalkAccum }}[A any]({{ $engParam }}x {{ $Root }}, acc *{{ $Accumulator }}[A], fn {{ $AccumulatorFn }}[A]) (_ {{ $Root }}, changed bool, err error) {
+	return {{ if $v.ExplicitEngine }}eng.{{ end }}{{ $Walk }}(x, func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		return fn(ctx, x, acc)
+	})
+}
+
+// {{ $Compare }} reports the number of structs reachable from after,
+// which is typically the result of calling {{ $Walk }} on before, that
+// were cloned or replaced, and the number which are shared with before.
+func {{ $eng }}{{ $Compare }}(before, after {{ $Root }}) (cloned, shared int) {
+	var beforeID, afterID e.TypeID
+	var beforePtr, afterPtr e.Ptr
+	if before != nil {
+		beforeID, beforePtr = {{ $identify }}(before)
+	}
+	if after != nil {
+		afterID, afterPtr = {{ $identify }}(after)
+	}
+	s := {{ $engine }}.Sharing(beforeID, beforePtr, afterID, afterPtr)
+	return s.Cloned, s.Shared
+}
+
+// {{ $ZipFn }} is called by {{ $Zip }} with each pair of values which
+// occupy the same location in two trees. If only one of the trees has a
+// value at the location, the other value is nil. The function returns
+// true to visit the fields of the pair, which only happens if both
+// values are present and have the same type.
+type {{ $ZipFn }} func(path string, a, b {{ $Root }}) (descend bool, err error)
+
+// {{ $Zip }} visits a and b in lockstep, in the same order as {{ $Walk }},
+// and calls fn with each pair of corresponding values. Elements of
+// slices are paired by index. The path of each pair uses the same
+// syntax as {{ $Context }}.Path. It returns the first error returned by
+// fn.
+func {{ $eng }}{{ $Zip }}(a, b {{ $Root }}, fn {{ $ZipFn }}) error {
+	var aID, bID e.TypeID
+	var aPtr, bPtr e.Ptr
+	if a != nil {
+		aID, aPtr = {{ $identify }}(a)
+	}
+	if b != nil {
+		bID, bPtr = {{ $identify }}(b)
+	}
+	return {{ $engine }}.Zip(aID, aPtr, bID, bPtr, func(path string, aID e.TypeID, aPtr e.Ptr, bID e.TypeID, bPtr e.Ptr) (bool, error) {
+		var x, y {{ $Root }}
+		if aPtr != nil {
+			x = {{ $wrap }}(aID, aPtr)
+		}
+		if bPtr != nil {
+			y = {{ $wrap }}(bID, bPtr)
+		}
+		return fn(path, x, y)
+	})
+}
+
+// {{ $Diff }} returns the paths at which a and b differ: where only one
+// of them has a value, where the values have different types, or where
+// the values have different basic fields. The fields of a value which
+// differs are still compared.
+func {{ $eng }}{{ $Diff }}(a, b {{ $Root }}) []string {
+	var ret []string
+	_ = {{ if $v.ExplicitEngine }}eng.{{ end }}{{ $Zip }}(a, b, func(path string, x, y {{ $Root }}) (bool, error) {
+		if x == nil || y == nil {
+			ret = append(ret, path)
+			return false, nil
+		}
+		xID, xPtr := {{ $identify }}(x)
+		yID, yPtr := {{ $identify }}(y)
+		if xID != yID || !{{ $engine }}.ShallowEqual(xID, xPtr, yPtr) {
+			ret = append(ret, path)
+		}
+		return true, nil
+	})
+	return ret
+}
+
+// {{ $Equal }} reports whether {{ $Diff }} would find no differences
+// between a and b.
+func {{ $eng }}{{ $Equal }}(a, b {{ $Root }}) bool {
+	return len({{ if $v.ExplicitEngine }}eng.{{ end }}{{ $Diff }}(a, b)) == 0
+}
+
+// {{ $Conflict }} describes a location which was changed in different
+// ways by both sides of a merge.
+type {{ $Conflict }} = e.Conflict
+
+// {{ $Merge }} performs a three-way merge of left and right, which were
+// both derived from base. A value which is unchanged on one side takes
+// the changes made by the other side. Values which are changed on both
+// sides are merged field by field, as are slices which have the same
+// length in all three trees. The paths of any other values or basic
+// fields which were changed differently by both sides are reported as
+// conflicts, and those values are taken from left. The trees are not
+// modified, and unchanged values are shared with the result. An error
+// is returned if a cycle would need to be merged. As in {{ $Equal }},
+// only the visitable fields and the exported fields of basic types are
+// compared, so a change to any other field is kept only if its struct
+// is taken from the side which made it.
+func {{ $eng }}{{ $Merge }}(base, left, right {{ $Root }}) (_ {{ $Root }}, conflicts []{{ $Conflict }}, err error) {
+	var out {{ $Root }}
+	conflicts, err = {{ $engine }}.Merge({{ EID $Root }}, e.Ptr(&base), e.Ptr(&left), e.Ptr(&right), e.Ptr(&out))
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, conflicts, nil
+}
+
+// {{ $Encode }} appends a compact, binary encoding of x to buf, which
+// may be decoded by {{ $Decode }}. Only the visitable fields and the
+// exported fields of basic types are encoded.
+func {{ $eng }}{{ $Encode }}(buf []byte, x {{ $Root }}) ([]byte, error) {
+	return {{ $engine }}.Encode(buf, {{ EID $Root }}, e.Ptr(&x))
+}
+
+// {{ $Decode }} decodes a value which was encoded by {{ $Encode }}.
+func {{ $eng }}{{ $Decode }}(data []byte) (x {{ $Root }}, err error) {
+	err = {{ $engine }}.Decode(data, {{ EID $Root }}, e.Ptr(&x))
+	return x, err
+}
+
+// {{ $EncodeMap }} converts x to a tree of maps, slices, and basic
+// values, which may be decoded by {{ $DecodeMap }}. Each struct is
+// a map which contains the name of its type under the "$type" key, its
+// visitable fields, and its exported fields of basic types. A nil value
+// is converted to a nil map.
+func {{ $eng }}{{ $EncodeMap }}(x {{ $Root }}) (map[string]interface{}, error) {
+	m, err := {{ $engine }}.ToMap({{ EID $Root }}, e.Ptr(&x))
+	if m == nil {
+		return nil, err
+	}
+	return m.(map[string]interface{}), err
+}
+
+// {{ $DecodeMap }} constructs a value from a tree of maps, such as
+// one produced by {{ $EncodeMap }} or by decoding JSON into an
+// interface{}.
+func {{ $eng }}{{ $DecodeMap }}(m map[string]interface{}) (x {{ $Root }}, err error) {
+	if m == nil {
+		return nil, nil
+	}
+	err = {{ $engine }}.FromMap(m, {{ EID $Root }}, e.Ptr(&x))
+	return x, err
+}
+
+// {{ $Dump }} returns a human-readable, s-expression form of x, which
+// may be read by {{ $Parse }}. The output is stable, which makes it
+// suitable for golden files.
+func {{ $eng }}{{ $Dump }}(x {{ $Root }}) (string, error) {
+	return {{ $engine }}.Dump({{ EID $Root }}, e.Ptr(&x))
+}
+
+// {{ $Parse }} constructs a value from its s-expression form, such as
+// one produced by {{ $Dump }}.
+func {{ $eng }}{{ $Parse }}(text string) (x {{ $Root }}, err error) {
+	err = {{ $engine }}.Parse(text, {{ EID $Root }}, e.Ptr(&x))
+	return x, err
+}
+
+// {{ $Match }} is a value which was selected by {{ $Query }}.
+type {{ $Match }} struct {
+	// Path is the location of the value, e.g. "Parent/Slice[1]/Field".
+	Path  string
+	Value {{ $Abstract }}
+}
+
+// {{ $Query }} returns the values within x which are selected by a
+// path expression, such as "Parent/Slice[*]/Field". Each step of the
+// expression is a field name, "*" to select any field or element, or
+// "**" to select a value and all of its descendants, and may be
+// followed by "[n]" or "[*]" to select elements of a slice. The first
+// step must be "*", "**", or the name of the type of x.
+func {{ $eng }}{{ $Query }}(x {{ $Root }}, expr string) ([]{{ $Match }}, error) {
+	q, err := e.ParseQuery(expr)
+	if err != nil || x == nil {
+		return nil, err
+	}
+	id, ptr := {{ $identify }}(x)
+	matches := q.Select({{ $engine }}.Abstract(id, ptr))
+	ret := make([]{{ $Match }}, len(matches))
+	for i, m := range matches {
+		ret[i] = {{ $Match }}{Path: m.Path, Value: {{ $abstractOf }}(m.Value, nil)}
+	}
+	return ret, nil
+}
+
+// {{ $RandomConfig }} controls the shape of the trees built by
+// {{ $Random }}.
+type {{ $RandomConfig }} = e.RandomConfig
+
+// {{ $Random }} builds a random tree for property-based testing of
+// walkers and rewrites. The tree is determined by the values from r,
+// so a failing case may be reproduced from its seed.
+func {{ $eng }}{{ $Random }}(r *rand.Rand, cfg {{ $RandomConfig }}) (x {{ $Root }}) {
+	{{ $engine }}.Random(r, cfg, {{ EID $Root }}, e.Ptr(&x))
+	return x
+}
+
+// {{ $Shrink }} returns a minimal value for which fails returns true,
+// such as the smallest tree which reproduces the failure of a property
+// test that uses {{ $Random }}. Elements of slices are removed and
+// fields are reset to their zero values, while the failure is
+// preserved. An error is returned if x does not fail.
+func {{ $eng }}{{ $Shrink }}(x {{ $Root }}, fails func({{ $Root }}) bool) ({{ $Root }}, error) {
+	err := {{ $engine }}.Shrink({{ EID $Root }}, e.Ptr(&x), func(p e.Ptr) bool {
+		return fails(*(*{{ $Root }})(p))
+	})
+	return x, err
+}
+
+// {{ $EventKind }} distinguishes the events recorded by a {{ $Recorder }}.
+type {{ $EventKind }} int
+
+// These are the kinds of events recorded by a {{ $Recorder }}.
+const (
+	// {{ $EventKind }}Pre is recorded before the fields of a value are visited.
+	{{ $EventKind }}Pre {{ $EventKind }} = 0
+	// {{ $EventKind }}Post is recorded after the fields of a value are visited.
+	{{ $EventKind }}Post {{ $EventKind }} = 1
+)
+
+// String returns "pre" or "post".
+func (k {{ $EventKind }}) String() string {
+	if k == {{ $EventKind }}Post {
+		return "post"
+	}
+	return "pre"
+}
+
+// {{ $Event }} records the visitation of a value.
+type {{ $Event }} struct {
+	Kind   {{ $EventKind }}
+	// Path is the location of the value, e.g. "Parent/Slice[1]/Field".
+	Path   string
+	TypeID {{ $TypeID }}
+}
+
+// String returns a description of the event, e.g.
+// "pre Parent/Slice[1]/Field Type".
+func (v {{ $Event }}) String() string {
+	return fmt.Sprintf("%s %s %s", v.Kind, v.Path, v.TypeID)
+}
+
+// {{ $Recorder }} is a walker which records the order in which values
+// are visited, for use in assertions about traversal behavior. Its
+// Walk method should be passed as the {{ $WalkerFn }}.
+type {{ $Recorder }} struct {
+	Events []{{ $Event }}
+}
+
+// {{ $NewRecorder }} returns a {{ $Recorder }} which has not recorded
+// any events.
+func {{ $NewRecorder }}() *{{ $Recorder }} {
+	return &{{ $Recorder }}{}
+}
+
+// Walk records a pre-visit event and registers a post-visit function
+// which records a post-visit event. It always continues visitation.
+func (r *{{ $Recorder }}) Walk(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+	r.record(ctx, {{ $EventKind }}Pre, x)
+	return ctx.Continue().Post(r.post)
+}
+
+// post is registered by Walk.
+func (r *{{ $Recorder }}) post(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+	r.record(ctx, {{ $EventKind }}Post, x)
+	return ctx.Continue()
+}
+
+// record appends an event.
+func (r *{{ $Recorder }}) record(ctx {{ $Context }}, kind {{ $EventKind }}, x {{ $Root }}) {
+	id, _ := {{ $identify }}(x)
+	{{- if $v.StringIDs }}
+	r.Events = append(r.Events, {{ $Event }}{Kind: kind, Path: ctx.Path(), TypeID: {{ t $v "TypeIDs" }}[id]})
+	{{- else }}
+	r.Events = append(r.Events, {{ $Event }}{Kind: kind, Path: ctx.Path(), TypeID: {{ $TypeID }}(id)})
+	{{- end }}
+}
+
+// Reset discards the recorded events.
+func (r *{{ $Recorder }}) Reset() {
+	r.Events = r.Events[:0]
+}
+
+// Strings returns the String form of each recorded event.
+func (r *{{ $Recorder }}) Strings() []string {
+	ret := make([]string, len(r.Events))
+	for i, v := range r.Events {
+		ret[i] = v.String()
+	}
+	return ret
+}
 `
 }
@@ -0,0 +1,97 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package templates
+
+// ExampleSource is the template for the runnable examples which are
+// written to a separate _test.go file when requested. It is not
+// included in TemplateSources.
+const ExampleSource = `
+{{- $v := .V -}}
+{{- $s := .Struct -}}
+{{- $Root := $v.Root -}}
+{{- $Context := T $v "Context" -}}
+{{- $Decision := T $v "Decision" -}}
+{{- $Walk := Ident $v "Walk" $Root -}}
+// Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.
+// source: {{ SourceFile $v }}
+// walkabout version: {{ Version }}
+
+package {{ Package $v }}
+
+import "fmt"
+
+// Example{{ $Walk }} prints the type of each value which is visited in
+// a {{ $s }}.
+func Example{{ $Walk }}() {
+	var x {{ $Root }} = &{{ $s }}{}
+	_, _, err := {{ $Walk }}(x, func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		fmt.Printf("%T\n", x)
+		return ctx.Continue()
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+{{- range .Visits }}
+	// {{ . }}
+{{- end }}
+}
+
+// Example{{ $Walk }}_replace replaces a {{ $s }} with a new value. The
+// value which was walked is not modified.
+func Example{{ $Walk }}_replace() {
+	var x {{ $Root }} = &{{ $s }}{}
+	y, _, err := {{ $Walk }}(x, func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		if _, ok := x.(*{{ $s }}); ok {
+			return ctx.Continue().Replace(&{{ $s }}{})
+		}
+		return ctx.Continue()
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%T\n", y)
+	// Output: {{ index .Visits 0 }}
+}
+
+// Example{{ $Walk }}_actions calls functions in place of visiting the
+// fields of a {{ $s }}, and then calls a post-visit function.
+func Example{{ $Walk }}_actions() {
+	var x {{ $Root }} = &{{ $s }}{}
+	_, _, err := {{ $Walk }}(x, func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+		return ctx.Actions(
+			ctx.ActionCall(func() error {
+				fmt.Println("first")
+				return nil
+			}),
+			ctx.ActionCall(func() error {
+				fmt.Println("second")
+				return nil
+			}),
+		).Post(func(ctx {{ $Context }}, x {{ $Root }}) {{ $Decision }} {
+			fmt.Printf("post %T\n", x)
+			return ctx.Continue()
+		})
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// first
+	// second
+	// post {{ index .Visits 0 }}
+}
+`
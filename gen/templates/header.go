@@ -23,14 +23,24 @@ func init() {
 	TemplateSources["00header"] = `
 // Code generated by github.com/cockroachdb/walkabout. DO NOT EDIT.
 // source: {{ SourceFile . }}
+// walkabout version: {{ Version }}
 
 package {{ Package . }}
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"reflect"
+{{- if not .ExplicitEngine }}
+	"sync"
+{{- end }}
 	"unsafe"
 
 	e "github.com/cockroachdb/walkabout/engine"
+{{- with .RootImport }}
+	{{ .Name }} "{{ .Path }}"
+{{- end }}
 )
 `
 }
@@ -20,30 +20,81 @@ func init() {
 {{- $v := . -}}
 {{- $Context := T $v "Context" -}}
 {{- $Engine := t $v "Engine" -}}
+{{- $identifier := t $v "Identifier" -}}
 {{- $TypeID := T $v "TypeID" -}}
 {{- $WalkerFn := T $v "WalkerFn" -}}
 // ------ Type Mapping ------
-var {{ $Engine }} = e.New(e.TypeMap {
+{{- if $v.ExplicitEngine }}
+{{- $EngineT := T $v "Engine" }}
+
+// {{ $EngineT }} is required to walk the generated types. Each
+// {{ $EngineT }} is independent, and there is no package-level state.
+type {{ $EngineT }} struct {
+	impl *e.Engine
+}
+
+// {{ Ident $v "New" $EngineT }} constructs an {{ $EngineT }}.
+func {{ Ident $v "New" $EngineT }}() *{{ $EngineT }} {
+	return &{{ $EngineT }}{e.New({{ t $v "TypeMap" }}())}
+}
+{{- else }}
+var (
+	{{ t $v "EngineImpl" }} *e.Engine
+	{{ t $v "EngineOnce" }} sync.Once
+)
+
+// {{ $Engine }} returns the engine for the generated types, which is
+// constructed on first use.
+func {{ $Engine }}() *e.Engine {
+	{{ t $v "EngineOnce" }}.Do(func() { {{ t $v "EngineImpl" }} = e.New({{ t $v "TypeMap" }}()) })
+	return {{ t $v "EngineImpl" }}
+}
+{{- end }}
+
+// {{ t $v "TypeMap" }} describes the generated types to the engine.
+func {{ t $v "TypeMap" }}() e.TypeMap {
+return e.TypeMap {
 // ------ Structs ------
-{{ range $s := Structs $v }}{{ TypeID $s }}: {
+{{ range $s := Structs $v }}{{ EID $s }}: {
 	Copy: func(dest, from e.Ptr) { *(*{{ $s }})(dest) = *(*{{ $s }})(from) },
+	{{- if $v.EqualMethods }}{{ with $s.Equal "a" "b" }}
+	Equal: func(a, b e.Ptr) bool { return {{ . }} },
+	{{- end }}{{ end }}
 	Facade: func(impl e.Context, fn e.FacadeFn, x e.Ptr) e.Decision {
+		{{- if $v.Generics }}
+		return e.TypedFacade[{{ $v.Root }}, {{ $identifier }}](impl, fn, (*{{ $s }})(x))
+		{{- else }}
 		return e.Decision(fn.({{ $WalkerFn }})({{ $Context }}{impl}, (*{{ $s }})(x)))
+		{{- end }}
 	},
 	Fields: []e.FieldInfo {
 		{{ range $f := $s.Fields -}}
-		{ Name: "{{ $f }}", Offset: unsafe.Offsetof({{ $s }}{}.{{ $f }}), Target: e.TypeID({{ TypeID $f.Target }})},
+		{ Name: "{{ $f.Serialized }}", {{ if ne $f.Serialized $f.Name }}Field: "{{ $f.Name }}", {{ end }}Offset: unsafe.Offsetof({{ $s }}{}.{{ $f }}), {{ with $f.Tag }}Tag: {{ printf "%q" . }}, {{ end }}Target: {{ EID $f.Target }}},
 		{{ end }}
 	},
 	Name: "{{ $s }}",
+	PkgPath: "{{ $s.PkgPath }}",
+	{{- with $s.Scalars }}
+	Scalars: []e.ScalarInfo {
+		{{ range $f := . -}}
+		{ Kind: e.{{ $f.Kind }}, Name: "{{ $f.Serialized }}", {{ if ne $f.Serialized $f.Name }}Field: "{{ $f.Name }}", {{ end }}Offset: unsafe.Offsetof({{ $s }}{}.{{ $f.Name }}){{ with $f.Tag }}, Tag: {{ printf "%q" . }}{{ end }}},
+		{{ end }}
+	},
+	{{- end }}
 	NewStruct: func() e.Ptr { return e.Ptr(&{{ $s }}{}) },
+	NewStructs: func(count int) e.Ptr {
+		x := make([]{{ $s }}, count)
+		return e.Ptr(&x[0])
+	},
+	Shape: {{ $s.Shape }},
 	SizeOf: unsafe.Sizeof({{ $s }}{}),
 	Kind: e.KindStruct,
-	TypeID: e.TypeID({{ TypeID $s }}),
+	Type: reflect.TypeOf({{ $s }}{}),
+	TypeID: {{ EID $s }},
 },
 {{ end }}
 // ------ Interfaces ------
-{{ range $s := Intfs $v }}{{ TypeID $s }}: {
+{{ range $s := Intfs $v }}{{ EID $s }}: {
 	Copy: func(dest, from e.Ptr) {
 		*(*{{ $s }})(dest) = *(*{{ $s }})(from)
 	},
@@ -51,19 +102,29 @@ var {{ $Engine }} = e.New(e.TypeMap {
 		d := *(*{{ $s }})(x)
 		switch d.(type) {
 		{{ range $imp := Implementors $s -}}
-		case {{ $imp.Actual }}: return e.TypeID({{ TypeID $imp.Underlying }});
+		case {{ $imp.Actual }}: return {{ EID $imp.Underlying }};
 		{{- end }}
 		default:
 			return 0
 		}
 	},
+	{{- if MapDispatch $s }}
+	IntfImpls: []e.IntfImpl{
+		{{ range $imp := Implementors $s -}}
+		{ Sample: e.Ptr(&[]{{ $s }}{ *new({{ $imp.Actual }}) }[0]), TypeID: {{ EID $imp.Underlying }} },
+		{{ end }}
+	},
+	{{- end }}
+	Impls: []e.TypeID{ {{- range $imp := Implementors $s }}{{ if IsPointer $imp.Actual }}{{ EID $imp.Actual.Elem }}, {{ end }}{{ end -}} },
 	IntfWrap: func(id e.TypeID, x e.Ptr) e.Ptr {
 		var d {{ $s }}
-		switch {{ $TypeID }}(id) {
+		switch id {
 		{{ range $imp := Implementors $s -}}
 			{{- if IsPointer $imp.Actual -}}
-				case {{ TypeID $imp.Actual.Elem }}: d = (*{{ $imp.Actual.Elem }})(x);
-				case {{ TypeID $imp.Actual }}: d = *(*{{ $imp.Actual }})(x);
+				case {{ EID $imp.Actual.Elem }}: d = (*{{ $imp.Actual.Elem }})(x);
+				{{- if Used $imp.Actual }}
+				case {{ EID $imp.Actual }}: d = *(*{{ $imp.Actual }})(x);
+				{{- end -}}
 			{{- end -}}
 		{{- end }}
 		default:
@@ -73,47 +134,105 @@ var {{ $Engine }} = e.New(e.TypeMap {
 	},
 	Kind: e.KindInterface,
 	Name: "{{ $s }}",
+	PkgPath: "{{ $s.PkgPath }}",
 	SizeOf: unsafe.Sizeof({{ $s }}(nil)),
-	TypeID: e.TypeID({{ TypeID $s }}),
+	TypeID: {{ EID $s }},
 },
 {{ end }}
 // ------ Pointers ------
-{{ range $s := Pointers $v }}{{ TypeID $s }}: {
+{{ range $s := Pointers $v }}{{ EID $s }}: {
 	Copy: func(dest, from e.Ptr) {
 		*(*{{ $s }})(dest) = *(*{{ $s }})(from)
 	},
-	Elem: e.TypeID({{ TypeID $s.Elem }}),
+	Elem: {{ EID $s.Elem }},
 	SizeOf: unsafe.Sizeof(({{ $s }})(nil)),
 	Kind: e.KindPointer,
-	TypeID: e.TypeID({{ TypeID $s }}),
+	TypeID: {{ EID $s }},
 },
 {{ end }}
 // ------ Slices ------
-{{ range $s := Slices $v }}{{ TypeID $s }}: {
+{{ range $s := Slices $v }}{{ EID $s }}: {
 	Copy: func(dest, from e.Ptr) {
 		*(*{{ $s }})(dest) = *(*{{ $s }})(from)
 	},
-	Elem: e.TypeID({{ TypeID $s.Elem }}),
+	Elem: {{ EID $s.Elem }},
 	Kind: e.KindSlice,
 	NewSlice: func(size int) e.Ptr {
 		x := make({{ $s }}, size)
 		return e.Ptr(&x)
 	},
 	SizeOf: unsafe.Sizeof(({{ $s }})(nil)),
-	TypeID: e.TypeID({{ TypeID $s }}),
+	TypeID: {{ EID $s }},
 },
 {{ end }}
-})
+}
+}
 
 // These are lightweight type tokens. 
+{{ if $v.StringIDs -}}
+// Their values are the names of the types and are safe to persist.
+const (
+{{ range $t := $v.Types }}{{ TypeID $t }} {{ $TypeID }} = "{{ $t.Implementation }}";{{ end }}
+)
+
+// These are the type tokens used by the engine.
+const (
+	_ e.TypeID = iota
+{{ range $t := $v.Types }}{{ EID $t }};{{ end }}
+)
+
+// {{ t $v "TypeIDs" }} maps the engine's type tokens to {{ $TypeID }}.
+var {{ t $v "TypeIDs" }} = [...]{{ $TypeID }} {
+{{ range $t := $v.Types }}{{ EID $t }}: {{ TypeID $t }},
+{{ end }}
+}
+
+// String returns the name of the type.
+func (t {{ $TypeID }}) String() string {
+	return string(t)
+}
+{{- else -}}
+{{ if $v.Manifest -}}
+// Their values are recorded in a manifest and should not be changed.
 const (
-	_ {{ T $v "TypeID" }} = iota
+{{ range $t := $v.Types }}{{ TypeID $t }} {{ $TypeID }} = {{ Ordinal $t }};{{ end }}
+)
+{{- else -}}
+const (
+	_ {{ $TypeID }} = iota
 {{ range $t := $v.Types }}{{ TypeID $t }};{{ end }}
 )
+{{- end }}
 
 // String is for debugging use only.
 func (t {{ $TypeID }}) String() string {
-	return {{ $Engine }}.Stringify(e.TypeID(t))
+	{{- if $v.ExplicitEngine }}
+	switch t {
+	{{ range $t := $v.Types }}case {{ TypeID $t }}: return "{{ $t.Implementation }}";
+	{{ end -}}
+	default:
+		return "<NIL>"
+	}
+	{{- else }}
+	return {{ $Engine }}().Stringify(e.TypeID(t))
+	{{- end }}
+}
+{{- end }}
+
+// QualifiedString returns the name of the type, including the import
+// path of the package which declares each named type. It is for
+// debugging use only.
+func (t {{ $TypeID }}) QualifiedString() string {
+	{{- if or $v.StringIDs $v.ExplicitEngine }}
+	switch t {
+	{{ range $t := $v.Types }}case {{ TypeID $t }}: return "{{ Qualified $t }}";
+	{{ end -}}
+	default:
+		return "<NIL>"
+	}
+	{{- else }}
+	return {{ $Engine }}().StringifyQualified(e.TypeID(t))
+	{{- end }}
 }
 `
 }
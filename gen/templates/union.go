@@ -18,13 +18,18 @@ package templates
 func init() {
 	TemplateSources["50union"] = `
 {{- $v := . -}}
+{{- $Abstract := T $v "Abstract" -}}
 {{- $Union := $v.Root.Union -}}
 {{- if $Union -}}
 // ------ Union Support -----
+{{- if not $v.UnionDeclared }}
 type {{ $Union }} interface {
-	{{ $Union }}Abstract
+	{{- if not $v.ExplicitEngine }}
+	{{ $Abstract }}
+	{{- end }}
 	is{{ $Union }}Type()
 }
+{{- end }}
 
 var (
 {{- range $s := Structs $v }}
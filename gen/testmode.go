@@ -0,0 +1,94 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// TestMode controls whether the generated code is written to a
+// _test.go file.
+type TestMode int
+
+// The available test modes.
+const (
+	// TestAuto generates a _test.go file if any of the seed types are
+	// declared in a test file.
+	TestAuto TestMode = iota
+	// TestAlways always generates a _test.go file.
+	TestAlways
+	// TestNever never generates a _test.go file.
+	TestNever
+)
+
+// String is for debugging use only.
+func (m TestMode) String() string {
+	switch m {
+	case TestAuto:
+		return "auto"
+	case TestAlways:
+		return "always"
+	case TestNever:
+		return "never"
+	default:
+		return "unknown"
+	}
+}
+
+// applyTestMode overrides the automatic decision made by
+// findSeedTypes().
+func (v *visitation) applyTestMode() {
+	switch v.gen.Test {
+	case TestAlways:
+		v.inTest = true
+	case TestNever:
+		if v.inTest {
+			v.gen.logf(Verbose, "seed types are declared in test files, but --no-test is set")
+		}
+		v.inTest = false
+	}
+}
+
+// testModeFlag is a boolean-style pflag.Value which sets a TestMode.
+type testModeFlag struct {
+	mode   TestMode
+	target *TestMode
+}
+
+// String implements pflag.Value.
+func (f testModeFlag) String() string {
+	return strconv.FormatBool(f.target != nil && *f.target == f.mode)
+}
+
+// Set implements pflag.Value.
+func (f testModeFlag) Set(s string) error {
+	b, err := strconv.ParseBool(s)
+	if err != nil || !b {
+		return err
+	}
+	if *f.target != TestAuto && *f.target != f.mode {
+		return errors.New("--test and --no-test cannot be used together")
+	}
+	*f.target = f.mode
+	return nil
+}
+
+// Type implements pflag.Value.
+func (f testModeFlag) Type() string {
+	return "bool"
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestMode(t *testing.T) {
+	tcs := []struct {
+		seed   string
+		mode   TestMode
+		expect string
+	}{
+		{seed: "Target", mode: TestAuto, expect: "target_walkabout.g.go"},
+		{seed: "Target", mode: TestAlways, expect: "target_walkabout.g_test.go"},
+		{seed: "Target", mode: TestNever, expect: "target_walkabout.g.go"},
+		// Expr is declared in a test file.
+		{seed: "Expr", mode: TestAuto, expect: "expr_walkabout.g_test.go"},
+		{seed: "Expr", mode: TestAlways, expect: "expr_walkabout.g_test.go"},
+		{seed: "Expr", mode: TestNever, expect: "expr_walkabout.g.go"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.seed+"/"+tc.mode.String(), func(t *testing.T) {
+			a := assert.New(t)
+			outputs, err := Generate(Config{
+				Dir:       "../demo",
+				Test:      tc.mode,
+				TypeNames: []string{tc.seed},
+			})
+			if a.NoError(err) {
+				a.Contains(outputs, filepath.Join("../demo", tc.expect))
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"go/types"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// findUnionDeclaration looks for an existing declaration of the
+// --union interface in the package. This allows the union interface to
+// be declared in source, e.g. to provide documentation, in which case
+// only its methods will be generated. A declaration in the file that
+// we are about to overwrite is ignored.
+func (v *visitation) findUnionDeclaration() error {
+	name := v.Root.Union
+	outName, err := filepath.Abs(v.outName())
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range v.scopes {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if obj.Pos().IsValid() {
+			if filepath.Clean(v.gen.fileSet.Position(obj.Pos()).Filename) == outName {
+				continue
+			}
+		}
+		if _, ok := obj.Type().Underlying().(*types.Interface); !ok {
+			return errors.Errorf("%s already exists and is not an interface", name)
+		}
+		v.unionDecl = obj
+		v.gen.logf(Verbose, "using existing declaration of %s", name)
+		return nil
+	}
+	return nil
+}
+
+// isUnion returns true if the named type is the --union interface.
+func (v *visitation) isUnion(t *types.Named) bool {
+	return v.Root.Union != "" &&
+		t.Obj().Name() == v.Root.Union &&
+		t.Obj().Pkg() != nil &&
+		t.Obj().Pkg().Path() == v.packagePath
+}
+
+// UnionDeclared returns true if the --union interface is declared in
+// the package's source, rather than by the generated code.
+func (v *visitation) UnionDeclared() bool {
+	return v.unionDecl != nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionDeclaration(t *testing.T) {
+	declared, err := filepath.Abs("../demo/union_declared.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := filepath.Abs("../demo/union_walkabout.g.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tcs := []struct {
+		name     string
+		extra    map[string]string
+		decl     bool
+		err      string
+		compiles bool
+	}{
+		{
+			name: "generated",
+			decl: true,
+		},
+		{
+			name: "declared",
+			extra: map[string]string{
+				declared: `package demo
+
+// Union is documented here.
+type Union interface {
+	UnionAbstract
+	isUnionType()
+}
+`,
+			},
+			compiles: true,
+		},
+		{
+			name: "previously generated",
+			extra: map[string]string{
+				previous: "package demo\n\ntype Union interface{ isUnionType() }\n",
+			},
+			decl: true,
+		},
+		{
+			name: "not an interface",
+			extra: map[string]string{
+				declared: "package demo\n\ntype Union struct{}\n",
+			},
+			err: "Union already exists and is not an interface",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+			cfg := configs["union"]
+			outputs := make(map[string][]byte)
+			g, err := newGenerationForTesting(cfg, outputs)
+			if !a.NoError(err) {
+				return
+			}
+			g.extraTestSource = make(map[string][]byte)
+			for name, src := range tc.extra {
+				g.extraTestSource[name] = []byte(src)
+			}
+
+			err = g.Execute()
+			if tc.err != "" {
+				a.EqualError(err, tc.err)
+				return
+			}
+			if !a.NoError(err) {
+				return
+			}
+
+			src := string(outputs[previous])
+			a.Equal(tc.decl, strings.Contains(src, "type Union interface"))
+			a.Contains(src, "func (*ContainerType) isUnionType() {}")
+
+			if tc.compiles {
+				for name, src := range tc.extra {
+					outputs[name] = []byte(src)
+				}
+				checkOutputs(a, cfg, outputs, true)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+// markUsed records the types which can actually appear at runtime.
+// These are the visitable structs and interfaces, and any pointer or
+// slice types which are the targets of a field. A composite type which
+// is never referenced by a field, such as a pointer to a struct which
+// implements the visitable interface, does not need to be emitted into
+// the type map, since the engine will never see its TypeID.
+func (v *visitation) markUsed() {
+	v.used = make(map[TypeID]bool)
+
+	var mark func(t visitableType)
+	mark = func(t visitableType) {
+		id := v.typeID(t)
+		if v.used[id] {
+			return
+		}
+		v.used[id] = true
+		switch t := t.(type) {
+		case pointerType:
+			mark(t.Elem)
+		case namedSliceType:
+			mark(t.Elem)
+		case namedVisitableType:
+			mark(t.Underlying)
+		}
+	}
+
+	mark(v.Root)
+	sources := make([]visitableType, 0, len(v.SourceTypes))
+	for _, t := range v.SourceTypes {
+		sources = append(sources, t)
+	}
+	for _, t := range sources {
+		switch t := t.(type) {
+		case namedInterfaceType:
+			mark(t)
+		case namedStruct:
+			mark(t)
+			for _, f := range t.Fields() {
+				mark(f.Target)
+			}
+		}
+	}
+}
+
+// isUsed returns true if the type may appear at runtime. Unlike the
+// TypeID template function, this does not add the type to the
+// visitation.
+func (v *visitation) isUsed(t visitableType) bool {
+	return v.used[v.typeID(t)]
+}
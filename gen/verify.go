@@ -0,0 +1,70 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Verify runs the code generator in-process and compares its outputs
+// to the files on disk. An error will be returned which names any
+// file that is missing or out of date. This is intended for use in
+// CI to ensure that generated code has been checked in.
+func Verify(cfg Config) error {
+	outputs, err := Generate(cfg)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var stale []string
+	for _, name := range names {
+		existing, err := os.ReadFile(name)
+		if os.IsNotExist(err) {
+			stale = append(stale, name+" (missing)")
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !bytes.Equal(existing, outputs[name]) {
+			// Report files which were generated by another version
+			// of walkabout, which may explain the difference.
+			if was, is := headerVersion(existing), headerVersion(outputs[name]); was != is {
+				if was == "" {
+					was = "an unknown version"
+				}
+				name += fmt.Sprintf(" (generated by walkabout %s, this is %s)", was, is)
+			}
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) > 0 {
+		return errors.Errorf("generated files are out of date, re-run walkabout: %s",
+			strings.Join(stale, ", "))
+	}
+	return nil
+}
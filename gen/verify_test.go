@@ -0,0 +1,45 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	a := assert.New(t)
+
+	// The demo package has checked-in outputs.
+	a.NoError(Verify(configs["single"]))
+
+	cfg := configs["single"]
+	cfg.OutFile = filepath.Join(t.TempDir(), "missing.go")
+	err := Verify(cfg)
+	if a.Error(err) {
+		a.Contains(err.Error(), "missing.go (missing)")
+	}
+
+	// Files which were generated by another version are identified.
+	defer func(id string) { buildID = id }(buildID)
+	buildID = "v9.9.9"
+	err = Verify(configs["single"])
+	if a.Error(err) {
+		a.Contains(err.Error(), "target_walkabout.g.go (generated by walkabout dev, this is v9.9.9)")
+	}
+}
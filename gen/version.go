@@ -0,0 +1,122 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// buildID is set by a linker flag.
+var buildID = ""
+
+// modulePath is the path of the walkabout module.
+const modulePath = "github.com/cockroachdb/walkabout"
+
+// versionPrefix starts the line of the generated file's header which
+// records the version of walkabout that generated it.
+const versionPrefix = "// walkabout version: "
+
+// buildInfo is replaced by tests.
+var buildInfo = debug.ReadBuildInfo
+
+// pseudoVersion matches the versions which the go command assigns to
+// untagged commits, e.g. v0.0.0-20190101000000-0123456789ab.
+var pseudoVersion = regexp.MustCompile(
+	`^v\d+\.(0\.0-|\d+\.\d+-([^+]*\.)?0\.)\d{14}-[0-9a-f]{12}(\+.*)?$`)
+
+// version returns the version of walkabout, which is stamped into the
+// header of the generated code. It is the value of the linker flag
+// that is set by the Makefile, or the version of the walkabout module
+// if it was installed or required by a released version, or "dev".
+// When walkabout is built from a checkout, the go command derives its
+// version from the commit; that is only used if the commit is tagged
+// and unmodified, so that the header of the generated code does not
+// change with every commit to walkabout.
+func version() string {
+	if buildID != "" {
+		return buildID
+	}
+	if bi, ok := buildInfo(); ok {
+		mods := append([]*debug.Module{&bi.Main}, bi.Deps...)
+		for idx, mod := range mods {
+			if mod.Path != modulePath {
+				continue
+			}
+			if mod.Replace != nil {
+				mod = mod.Replace
+			}
+			if idx == 0 && (pseudoVersion.MatchString(mod.Version) ||
+				strings.HasSuffix(mod.Version, "+dirty")) {
+				continue
+			}
+			if mod.Version != "" && mod.Version != "(devel)" {
+				return mod.Version
+			}
+		}
+	}
+	return "dev"
+}
+
+// printVersion implements the version command. The commit is only
+// reported if walkabout was built from a checkout of its repository.
+func printVersion(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "walkabout version %s; %s\n", version(), runtime.Version()); err != nil {
+		return err
+	}
+	bi, ok := buildInfo()
+	if !ok || bi.Main.Path != modulePath {
+		return nil
+	}
+	var revision, modified string
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				modified = " (modified)"
+			}
+		}
+	}
+	if revision == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "commit %s%s\n", revision, modified)
+	return err
+}
+
+// headerVersion returns the version of walkabout which is recorded in
+// the header of a generated file, or an empty string.
+func headerVersion(src []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		if v := strings.TrimPrefix(line, versionPrefix); v != line {
+			return v
+		}
+	}
+	return ""
+}
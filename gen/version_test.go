@@ -0,0 +1,121 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion(t *testing.T) {
+	defer func(fn func() (*debug.BuildInfo, bool)) { buildInfo = fn }(buildInfo)
+	defer func(id string) { buildID = id }(buildID)
+
+	tcs := []struct {
+		name     string
+		buildID  string
+		info     *debug.BuildInfo
+		expected string
+		output   string
+	}{
+		{
+			name:     "none",
+			expected: "dev",
+			output:   "walkabout version dev; " + runtime.Version() + "\n",
+		},
+		{
+			name:     "linker",
+			buildID:  "v1.0.0-3-gabcdef",
+			info:     &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.0.0"}},
+			expected: "v1.0.0-3-gabcdef",
+		},
+		{
+			name: "checkout",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: modulePath, Version: "(devel)"},
+				Settings: []debug.BuildSetting{
+					{Key: "vcs.revision", Value: "0123456789abcdef"},
+					{Key: "vcs.modified", Value: "true"},
+				},
+			},
+			expected: "dev",
+			output: "walkabout version dev; " + runtime.Version() + "\n" +
+				"commit 0123456789abcdef (modified)\n",
+		},
+		{
+			name:     "pseudo",
+			info:     &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.2.4-0.20260101000000-0123456789ab"}},
+			expected: "dev",
+			output:   "walkabout version dev; " + runtime.Version() + "\n",
+		},
+		{
+			name:     "untagged",
+			info:     &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v0.0.0-20260101000000-0123456789ab+dirty"}},
+			expected: "dev",
+		},
+		{
+			name:     "modified",
+			info:     &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.2.3+dirty"}},
+			expected: "dev",
+		},
+		{
+			name:     "installed",
+			info:     &debug.BuildInfo{Main: debug.Module{Path: modulePath, Version: "v1.2.3"}},
+			expected: "v1.2.3",
+			output:   "walkabout version v1.2.3; " + runtime.Version() + "\n",
+		},
+		{
+			name: "dependency",
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "example.com/tools", Version: "(devel)"},
+				Deps: []*debug.Module{
+					{Path: "example.com/other", Version: "v0.1.0"},
+					{Path: modulePath, Version: "v1.2.3", Replace: &debug.Module{Path: modulePath, Version: "v1.2.4"}},
+				},
+				Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "0123456789abcdef"}},
+			},
+			expected: "v1.2.4",
+			output:   "walkabout version v1.2.4; " + runtime.Version() + "\n",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			a := assert.New(t)
+			buildID = tc.buildID
+			buildInfo = func() (*debug.BuildInfo, bool) { return tc.info, tc.info != nil }
+			a.Equal(tc.expected, version())
+
+			if tc.output != "" {
+				var sb strings.Builder
+				a.NoError(printVersion(&sb))
+				a.Equal(tc.output, sb.String())
+			}
+
+			outputs, err := Generate(configs["single"])
+			if !a.NoError(err) {
+				return
+			}
+			for _, src := range outputs {
+				a.Equal(tc.expected, headerVersion(src))
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package view contains a read-only description of the types that
+// the code generator has decided to make visitable. A View is
+// computed once per generation and is provided to plugins so that
+// they can emit additional code without re-analyzing the package.
+package view
+
+import (
+	"go/types"
+	"reflect"
+)
+
+// Kind describes the shape of a visitable type.
+type Kind int
+
+// The kinds of visitable types.
+const (
+	_ Kind = iota
+	KindInterface
+	KindPointer
+	KindSlice
+	KindStruct
+)
+
+// String is for debugging use only.
+func (k Kind) String() string {
+	switch k {
+	case KindInterface:
+		return "interface"
+	case KindPointer:
+		return "pointer"
+	case KindSlice:
+		return "slice"
+	case KindStruct:
+		return "struct"
+	default:
+		return "unknown"
+	}
+}
+
+// View describes all of the types that will be visitable from a
+// single root interface.
+type View struct {
+	// Package is the name of the package that the code is generated into.
+	Package string
+	// PackagePath is the import path of the package.
+	PackagePath string
+	// Root is the name of the visitable interface.
+	Root string
+	// Union is true if the Root interface is generated by walkabout.
+	Union bool
+	// Types contains every visitable type, sorted by TypeID.
+	Types []*Type
+}
+
+// Type describes a single visitable type.
+type Type struct {
+	// Elem is the element type of a pointer or slice.
+	Elem *Type
+	// Fields contains the visitable fields of a struct.
+	Fields []Field
+	// Implementors contains the types which implement an interface.
+	Implementors []*Type
+	Kind         Kind
+	// Name is the codegen-safe representation of the type,
+	// e.g. "*Foo" or "[]Foo".
+	Name string
+	// Obj is the declaration of a named struct or interface type. It
+	// will be nil for pointers, slices, and a generated union interface.
+	Obj *types.TypeName
+	// TypeID is the name of the generated TypeID constant.
+	TypeID string
+}
+
+// Field describes a visitable field within a struct.
+type Field struct {
+	Name string
+	// Tag is the complete tag of the field.
+	Tag    reflect.StructTag
+	Target *Type
+}
+
+// Type returns the Type with the given codegen-safe name, or nil
+// if no such type is visitable.
+func (v *View) Type(name string) *Type {
+	for _, t := range v.Types {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
@@ -38,17 +38,38 @@ func (s SourceName) String() string { return string(s) }
 // API template and exposes many convenience functions to keep
 // the template simple.
 type visitation struct {
+	// The visitor interfaces for which adapters will be generated.
+	adapters []adapter
+	// The directory containing the package's sources.
+	dir string
 	// The interfaces that are used to select structs to be included
 	// in the visitation.
 	filters []visitableType
 	gen     *generation
+	// The minor version of Go that the generated code must support.
+	goMinor int
+	// The TypeIDs of structs which have specialized walkers.
+	inline map[TypeID]bool
 	// If true, any struct that is in the same package will be eligible
 	// for inclusion.
 	includeReachable bool
 	inTest           bool
-	packagePath      string
+	// If present, provides stable values for the generated TypeIDs.
+	Manifest    *manifest
+	packagePath string
 	// The root visitable interface.
 	Root namedInterfaceType
+	// The package which declares the root interface, if it is imported
+	// and an alias must be generated.
+	rootImport *types.Package
+	// The scopes of the packages that were loaded.
+	scopes []*types.Scope
+	// The scope of an imported package which declares qualified seeds.
+	seedScope *types.Scope
+	// An existing declaration of the --union interface.
+	unionDecl *types.TypeName
+	// The TypeIDs of the types which can appear at runtime.
+	used map[TypeID]bool
 	// types collects all referenced types, indexed by their type id.
 	Types       map[TypeID]visitableType
 	SourceTypes map[SourceName]visitableType
@@ -59,8 +80,15 @@ func (v *visitation) findSeedTypes(scopes []*types.Scope) error {
 
 	// Resolve all of the specified type names to an interface or struct.
 name:
-	for _, name := range g.typeNames {
-		for _, scope := range scopes {
+	for _, name := range g.TypeNames {
+		// Qualified names are only retained if they refer to an imported
+		// package.
+		searchScopes := scopes
+		if q, typeName := splitQualified(name); q != "" {
+			name = typeName
+			searchScopes = []*types.Scope{v.seedScope}
+		}
+		for _, scope := range searchScopes {
 			obj := scope.Lookup(name)
 			if obj == nil {
 				continue
@@ -75,16 +103,23 @@ name:
 						Interface: u,
 						v:         v,
 					}
-					if g.union == "" && len(g.typeNames) == 1 {
+					if g.Union == "" && len(g.TypeNames) == 1 {
 						v.Root = intf
+						if obj.Pkg().Path() != v.packagePath {
+							v.rootImport = obj.Pkg()
+						}
 					}
 					filter = intf
 				case *types.Struct:
 					// If we're generating the visitable interface with --union,
 					// we'll allow structs to be specified, too.
-					if g.union == "" {
+					if g.Union == "" {
 						return errors.Errorf("structs may only be used with --union")
 					}
+					if obj.Pkg().Path() != v.packagePath {
+						return errors.Errorf("%s.%s is a struct; only interfaces may be used as seeds from another package",
+							obj.Pkg().Name(), name)
+					}
 					filter = namedStruct{
 						Named:  named,
 						Struct: u,
@@ -107,7 +142,7 @@ name:
 				continue name
 			}
 		}
-		return errors.Errorf("unknown type %q", name)
+		return unknownType(name, searchScopes)
 	}
 	return nil
 }
@@ -140,14 +175,26 @@ func (v *visitation) ensureTypeID(i visitableType) TypeID {
 	return ret
 }
 
+// engineID returns the name of the constant which holds the engine's
+// type token for a type when string-valued TypeIDs are in use.
+func (v *visitation) engineID(i visitableType) string {
+	root := v.Root.String()
+	return fmt.Sprintf("%s%sEngineID%s", strings.ToLower(root[:1]), root[1:], v.typeName(i))
+}
+
 // typeID generates a reasonable description of a type. Generated tokens
 // are attached to the underlying visitation so that we can be sure
 // to actually generate them in a subsequent pass.
+func (v *visitation) typeID(i visitableType) TypeID {
+	return TypeID(v.identifier(fmt.Sprintf("%sType%s", v.Root, v.typeName(i))))
+}
+
+// typeName generates a codegen-safe name for a type.
 //   *Foo -> FooPtr
 //   []Foo -> FooSlice
 //   []*Foo -> FooPtrSlice
 //   *[]Foo -> FooSlicePtr
-func (v *visitation) typeID(i visitableType) TypeID {
+func (v *visitation) typeName(i visitableType) string {
 	suffix := ""
 	for {
 		switch t := i.(type) {
@@ -160,7 +207,7 @@ func (v *visitation) typeID(i visitableType) TypeID {
 		case namedVisitableType:
 			i = t.Underlying
 		default:
-			return TypeID(fmt.Sprintf("%sType%s%s", v.Root, t, suffix))
+			return fmt.Sprintf("%s%s", t, suffix)
 		}
 	}
 }
@@ -168,8 +215,19 @@ func (v *visitation) typeID(i visitableType) TypeID {
 // visitableType extracts the type information that we care about
 // from typ. This handles named and anonymous types that are visitable.
 func (v *visitation) visitableType(typ types.Type, isReachable bool) (visitableType, bool) {
-	switch t := typ.(type) {
+	switch t := types.Unalias(typ).(type) {
 	case *types.Named:
+		// References to the union interface, which may be declared in
+		// source or by a previous run of the generator.
+		if v.isUnion(t) {
+			return v.Root, true
+		}
+		// References to a root interface declared in another package.
+		if v.isImportedRoot(t) {
+			v.ensureTypeID(v.Root)
+			return v.Root, true
+		}
+
 		// Ignore un-exported types or those from other packages.
 		if !t.Obj().Exported() || t.Obj().Pkg().Path() != v.packagePath {
 			return nil, false
@@ -211,6 +269,8 @@ func (v *visitation) visitableType(typ types.Type, isReachable bool) (visitableT
 				}
 				v.SourceTypes[sourceName] = ret
 				v.ensureTypeID(ret)
+				v.gen.progress.addType()
+				v.gen.logf(Debug, "struct %s is visitable (reachable=%t)", sourceName, isReachable)
 				ret.Fields()
 				return ret, true
 			}
@@ -235,6 +295,8 @@ func (v *visitation) visitableType(typ types.Type, isReachable bool) (visitableT
 				}
 				v.SourceTypes[sourceName] = ret
 				v.ensureTypeID(ret)
+				v.gen.progress.addType()
+				v.gen.logf(Debug, "interface %s is visitable (reachable=%t)", sourceName, isReachable)
 
 				// If we've added an interface because it's reachable, we need
 				// to also go back and look for any structs that may be implied
@@ -269,6 +331,41 @@ func (v *visitation) visitableType(typ types.Type, isReachable bool) (visitableT
 	return nil, false
 }
 
+// identifier returns the name, adjusted for the configured visibility
+// of the generated API and any requested renames.
+func (v *visitation) identifier(name string) string {
+	if v.gen.Unexported {
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	if to, ok := v.gen.Renames[name]; ok {
+		return to
+	}
+	return name
+}
+
+// EqualMethods returns true if the Equal methods of structs should be
+// used to detect replacements which are equal to the original value.
+func (v *visitation) EqualMethods() bool {
+	return v.gen.EqualMethods
+}
+
+// ExplicitEngine returns true if the generated code should not have any
+// package-level state.
+func (v *visitation) ExplicitEngine() bool {
+	return v.gen.ExplicitEngine
+}
+
+// Generics returns true if the generated facade types should be
+// aliases of the engine's generic types.
+func (v *visitation) Generics() bool {
+	return v.gen.Generics
+}
+
+// StringIDs returns true if the generated TypeIDs should be strings.
+func (v *visitation) StringIDs() bool {
+	return v.gen.StringTypeIDs
+}
+
 // String is for debugging use only.
 func (v *visitation) String() string {
 	return v.Root.String()
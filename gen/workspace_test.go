@@ -0,0 +1,66 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestWorkspace verifies that a package may be named explicitly from
+// any directory within a go.work workspace.
+func TestWorkspace(t *testing.T) {
+	a := assert.New(t)
+	t.Setenv("GOWORK", "")
+	t.Setenv("GOFLAGS", "")
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.work":              "go 1.21\n\nuse (\n\t./a\n\t./b\n)\n",
+		"a/go.mod":             "module example.com/a\n\ngo 1.21\n",
+		"a/model/model.go":     "package model\n\ntype Node interface{ isNode() }\n\ntype Leaf struct{}\n\nfunc (*Leaf) isNode() {}\n",
+		"b/go.mod":             "module example.com/b\n\ngo 1.21\n",
+		"b/other/other.go":     "package other\n",
+		"b/other/more/more.go": "package more\n",
+	}
+	for name, src := range files {
+		name = filepath.Join(dir, name)
+		a.NoError(os.MkdirAll(filepath.Dir(name), 0755))
+		a.NoError(os.WriteFile(name, []byte(src), 0644))
+	}
+
+	for _, from := range []string{dir, filepath.Join(dir, "b", "other")} {
+		outputs, err := Generate(Config{
+			Dir:       from,
+			Package:   "example.com/a/model",
+			TypeNames: []string{"Node"},
+		})
+		if a.NoError(err, from) {
+			a.Contains(outputs, filepath.Join(dir, "a", "model", "node_walkabout.g.go"))
+		}
+	}
+
+	_, err := Generate(Config{
+		Dir:       dir,
+		Package:   "example.com/b/...",
+		TypeNames: []string{"Node"},
+	})
+	a.EqualError(err, "example.com/b/... matches multiple packages: "+
+		"example.com/b/other, example.com/b/other/more")
+}
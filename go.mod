@@ -1,15 +1,22 @@
 module github.com/cockroachdb/walkabout
 
+go 1.25.0
+
 require (
-	github.com/BurntSushi/toml v0.3.1 // indirect
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/pkg/errors v0.8.0
-	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/cobra v0.0.3
-	github.com/spf13/pflag v1.0.3 // indirect
+	github.com/spf13/pflag v1.0.3
 	github.com/stretchr/testify v1.2.2
 	golang.org/x/lint v0.0.0-20181217174547-8f45f776aaf1
-	golang.org/x/tools v0.0.0-20190107155254-e063def13b29
+	golang.org/x/tools v0.45.0
 	honnef.co/go/tools v0.0.0-20190106161140-3f1c8253044a
 )
+
+require (
+	github.com/BurntSushi/toml v0.3.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/inconshreveable/mousetrap v1.0.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/mod v0.36.0 // indirect
+	golang.org/x/sync v0.22.0 // indirect
+)
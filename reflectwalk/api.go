@@ -0,0 +1,256 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package reflectwalk implements the visitation semantics of the
+// generated code by using reflection, instead of generated code and
+// the unsafe engine. It allows a walker to be prototyped before any
+// code is generated, and it serves as a reference implementation to
+// test the generated code against.
+//
+// The type parameter R is the visitable interface. The values which
+// are visited, and the fields which are traversed, are the same as
+// for code generated for R with the default flags: the exported
+// structs, declared in the same package as R, whose pointers implement
+// R, and their exported fields whose types are such structs, or
+// interfaces which extend R, or pointers or slices of those types.
+// Fields with a `walkabout:"-"` tag are not visited, fields are
+// visited in the order given by `walkabout:"order=N"` tags, and
+// `walkabout:"name=N"` tags change the name of a field in paths.
+//
+// Each type has an equivalent in the generated code:
+//
+//	Walk       WalkTarget
+//	WalkerFn   TargetWalkerFn
+//	Context    TargetContext
+//	Decision   TargetDecision
+//	Action     TargetAction
+//
+// This package is much slower than the generated code, and allocates
+// when visiting each value.
+package reflectwalk
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// WalkerFn is used to implement a visitor pattern over types which
+// implement R.
+//
+// Implementations of this function return a Decision, which allows
+// the function to control traversal. The zero value of Decision means
+// "continue". Other values can be obtained from the provided Context
+// to stop or to return an error.
+//
+// A Decision can also specify a post-visit function to execute or can
+// be used to replace the value being visited.
+type WalkerFn[R any] func(ctx Context[R], x R) Decision[R]
+
+// ErrNilRoot is returned when Walk is given a nil value. It has the
+// same message as the generated ErrNil errors.
+var ErrNilRoot = errors.New("cannot walk a nil value")
+
+// PathError records the location of the value which caused an error.
+// Errors returned by a WalkerFn or an action are wrapped in a
+// PathError. It has the same fields and message as the generated
+// PathError types.
+type PathError struct {
+	// Path is the location of the value, using the same syntax as
+	// Context.Path.
+	Path string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements error.
+func (e *PathError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// ReplacementError is returned from Walk when a value is replaced by a
+// value which cannot be stored in the value's location. It has the same
+// fields and message as the generated ReplacementError types.
+type ReplacementError struct {
+	// Path is the location of the value which was replaced, using the
+	// same syntax as Context.Path.
+	Path string
+	// Slot is the name of the type of the value's location. It is empty
+	// if the value was visited by an action, and has no location.
+	Slot string
+	// Type is the name of the replacement's type. If the children of a
+	// value visited by an action were replaced, it is the name of the
+	// value's type.
+	Type string
+}
+
+// Error implements error.
+func (e *ReplacementError) Error() string {
+	if e.Slot == "" {
+		return fmt.Sprintf("%s: a value visited by an action cannot be replaced by %s",
+			e.Path, e.Type)
+	}
+	return fmt.Sprintf("%s: type %s is not assignable to %s", e.Path, e.Type, e.Slot)
+}
+
+// Walk visits x and the visitable values that it contains, in
+// depth-first order. If any value is replaced, Walk returns a copy of
+// x with the replacement, and changed is true. It returns ErrNilRoot if
+// x is nil.
+func Walk[R any](x R, fn WalkerFn[R]) (_ R, changed bool, err error) {
+	var zero R
+	if reflect.ValueOf(&x).Elem().IsNil() {
+		return zero, false, ErrNilRoot
+	}
+	w := newWalker[R](fn)
+	typ, ptr := w.types.identify(x)
+	if ptr.IsNil() {
+		return zero, false, ErrNilRoot
+	}
+	ret, changed, err := w.execute(typ, ptr, w.types.root)
+	if err = w.runDeferred(err); err != nil {
+		return zero, false, err
+	}
+	if changed {
+		return ret.Interface().(R), true, nil
+	}
+	return x, false, nil
+}
+
+// Context is provided to WalkerFn and acts as a factory for
+// constructing Decision instances.
+type Context[R any] struct {
+	w *walker[R]
+}
+
+// Actions will perform the given actions in place of visiting values
+// that would normally be visited. This allows callers to control
+// specific field visitation order or to insert additional callbacks
+// between visiting certain values.
+func (c *Context[R]) Actions(actions ...Action[R]) Decision[R] {
+	if len(actions) == 0 {
+		return c.Skip()
+	}
+	return Decision[R]{actions: actions}
+}
+
+// Continue returns the zero-value of Decision. It exists only for
+// cases where it improves the readability of code.
+func (c *Context[R]) Continue() Decision[R] {
+	return Decision[R]{}
+}
+
+// Defer registers a function to be called after the walk completes,
+// even if it is halted or fails. Functions are called in the reverse
+// order of their registration. If the walk succeeds, the first error
+// returned by a deferred function is returned from the walk.
+func (c *Context[R]) Defer(fn func() error) {
+	c.w.defers = append(c.w.defers, fn)
+}
+
+// Error returns a Decision which will cause the given error to be
+// returned from the Walk() function. Post-visit functions will not be
+// called.
+func (c *Context[R]) Error(err error) Decision[R] {
+	return Decision[R]{error: err}
+}
+
+// Halt will end a visitation early and return from the Walk() function.
+// Any registered post-visit functions will be called.
+func (c *Context[R]) Halt() Decision[R] {
+	return Decision[R]{halt: true}
+}
+
+// Path returns the location of the current object, e.g.
+// "Parent/Slice[1]/Field".
+func (c *Context[R]) Path() string {
+	if c.w == nil {
+		return ""
+	}
+	return c.w.path()
+}
+
+// Skip will not traverse the fields of the current object.
+func (c *Context[R]) Skip() Decision[R] {
+	return Decision[R]{skip: true}
+}
+
+// ActionVisit constructs an Action that will visit the given value.
+func (c *Context[R]) ActionVisit(x R) Action[R] {
+	typ, ptr := c.w.types.identify(x)
+	return Action[R]{typ: typ, value: ptr}
+}
+
+// ActionCall constructs an Action that will invoke the given callback.
+func (c *Context[R]) ActionCall(fn func() error) Action[R] {
+	return Action[R]{call: fn}
+}
+
+// Decision is used by WalkerFn to control visitation. The Context
+// provided to a WalkerFn acts as a factory for Decision instances. In
+// general, the factory methods choose a traversal strategy and
+// additional methods on the Decision can achieve a variety of
+// side-effects.
+type Decision[R any] struct {
+	actions         []Action[R]
+	error           error
+	halt            bool
+	intercept       WalkerFn[R]
+	post            WalkerFn[R]
+	replacement     reflect.Value
+	replacementType reflect.Type
+	skip            bool
+}
+
+// isZero returns true if the decision is equivalent to Continue().
+func (d *Decision[R]) isZero() bool {
+	return d.actions == nil && d.error == nil && !d.halt && d.intercept == nil &&
+		d.post == nil && !d.replacement.IsValid() && !d.skip
+}
+
+// Intercept registers a function to be called immediately before
+// visiting each field or element of the current value.
+func (d Decision[R]) Intercept(fn WalkerFn[R]) Decision[R] {
+	d.intercept = fn
+	return d
+}
+
+// Post registers a post-visit function, which will be called after the
+// fields of the current object. The function can make another decision
+// about the current value.
+func (d Decision[R]) Post(fn WalkerFn[R]) Decision[R] {
+	d.post = fn
+	return d
+}
+
+// Replace allows the currently-visited value to be replaced. All
+// parent nodes will be cloned.
+func (d Decision[R]) Replace(x R) Decision[R] {
+	d.replacementType, d.replacement = newTypes[R]().identify(x)
+	return d
+}
+
+// Action is used by Context.Actions() and allows users to have
+// fine-grained control over traversal.
+type Action[R any] struct {
+	call  func() error
+	typ   reflect.Type
+	value reflect.Value
+}
@@ -0,0 +1,193 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package reflectwalk
+
+// This file contains the rules which determine the types that are
+// visited, which mirror those of the code generator.
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// field describes a visitable field of a struct.
+type field struct {
+	index int
+	name  string
+	order int
+}
+
+// fieldCache holds the visitable fields of struct types, keyed by a
+// typeKey.
+var fieldCache sync.Map
+
+// typeKey identifies a type which is visited as part of the visitable
+// interface root.
+type typeKey struct {
+	root, typ reflect.Type
+}
+
+// types describes the visitable types of an interface.
+type types struct {
+	root reflect.Type
+}
+
+// newTypes returns the visitable types of the interface R.
+func newTypes[R any]() *types {
+	root := reflect.TypeOf((*R)(nil)).Elem()
+	if root.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("%s is not an interface", root))
+	}
+	return &types{root: root}
+}
+
+// declared returns true if the named type is exported and is declared
+// in the same package as the root.
+func (t *types) declared(typ reflect.Type) bool {
+	return typ.PkgPath() == t.root.PkgPath() && token.IsExported(typ.Name())
+}
+
+// isStruct returns true if values of the type are passed to a WalkerFn.
+func (t *types) isStruct(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && t.declared(typ) &&
+		reflect.PointerTo(typ).Implements(t.root)
+}
+
+// visitable returns true if values of the type are traversed.
+func (t *types) visitable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface:
+		return typ == t.root || (t.declared(typ) && typ.Implements(t.root))
+	case reflect.Ptr, reflect.Slice:
+		return t.visitable(typ.Elem())
+	case reflect.Struct:
+		return t.isStruct(typ)
+	default:
+		return false
+	}
+}
+
+// fields returns the visitable fields of a struct type.
+func (t *types) fields(typ reflect.Type) []field {
+	key := typeKey{t.root, typ}
+	if found, ok := fieldCache.Load(key); ok {
+		return found.([]field)
+	}
+	var ret []field
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if tag := f.Tag.Get("walkabout"); f.IsExported() && tag != "-" && t.visitable(f.Type) {
+			ret = append(ret, tagField(i, f.Name, tag))
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool { return ret[i].order < ret[j].order })
+	fieldCache.Store(key, ret)
+	return ret
+}
+
+// tagField returns the field, applying the name and order options in
+// its walkabout tag. The code generator rejects invalid tags.
+func tagField(index int, name, tag string) field {
+	ret := field{index: index, name: name}
+	for _, opt := range strings.Split(tag, ",") {
+		if value := strings.TrimPrefix(opt, "name="); value != opt {
+			ret.name = value
+		} else if value := strings.TrimPrefix(opt, "order="); value != opt {
+			ret.order, _ = strconv.Atoi(value)
+		}
+	}
+	return ret
+}
+
+// identify returns the struct type of x and a pointer to its value.
+// Like the generated code, it panics if x does not hold a visitable
+// struct, or a pointer to one.
+func (t *types) identify(x interface{}) (reflect.Type, reflect.Value) {
+	v := reflect.ValueOf(x)
+	switch {
+	case !v.IsValid():
+	case t.isStruct(v.Type()):
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return v.Type(), ptr
+	case v.Kind() == reflect.Ptr && t.isStruct(v.Type().Elem()):
+		return v.Type().Elem(), v
+	}
+	panic(fmt.Sprintf("unhandled value of type: %T", x))
+}
+
+// intfValue returns the struct type of the value in an interface, and
+// a pointer to the value. It returns false if the interface is nil, or
+// holds a nil pointer or a type which is not visitable.
+func (t *types) intfValue(intf reflect.Value) (reflect.Type, reflect.Value, bool) {
+	if intf.IsNil() {
+		return nil, reflect.Value{}, false
+	}
+	v := intf.Elem()
+	switch {
+	case t.isStruct(v.Type()):
+		// The value in an interface is not addressable.
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return v.Type(), ptr, true
+	case v.Kind() == reflect.Ptr && t.isStruct(v.Type().Elem()) && !v.IsNil():
+		return v.Type().Elem(), v, true
+	default:
+		return nil, reflect.Value{}, false
+	}
+}
+
+// equal returns true if the structs pointed to by a and b are
+// equivalent, according to an Equal method which accepts either the
+// struct or a pointer to it.
+func (t *types) equal(typ reflect.Type, a, b reflect.Value) bool {
+	m, ok := reflect.PointerTo(typ).MethodByName("Equal")
+	if !ok || m.Type.NumIn() != 2 || m.Type.NumOut() != 1 ||
+		m.Type.Out(0).Kind() != reflect.Bool {
+		return false
+	}
+	switch m.Type.In(1) {
+	case typ:
+		return m.Func.Call([]reflect.Value{a, b.Elem()})[0].Bool()
+	case reflect.PointerTo(typ):
+		return m.Func.Call([]reflect.Value{a, b})[0].Bool()
+	default:
+		return false
+	}
+}
+
+// typeString returns a description of the type, in the same form as
+// the engine's Stringify method.
+func typeString(typ reflect.Type) string {
+	var sb strings.Builder
+	for {
+		switch typ.Kind() {
+		case reflect.Ptr:
+			sb.WriteString("*")
+		case reflect.Slice:
+			sb.WriteString("[]")
+		default:
+			sb.WriteString(typ.Name())
+			return sb.String()
+		}
+		typ = typ.Elem()
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package reflectwalk
+
+// The walker is a recursive translation of engine.Execute. It uses the
+// same frames and slots, so that the order of visitation, the paths
+// reported by a Context, and the handling of each Decision are
+// identical. Each slot holds a pointer to the value that it visits.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// slot holds a value to be visited, or an action.
+type slot[R any] struct {
+	// assignableTo is the type of the location which holds the value.
+	// It is nil if the value cannot be replaced, which is the case for
+	// values that are visited by an action.
+	assignableTo reflect.Type
+	call         func() error
+	// childDirty is set if the value's children have changed and must
+	// be copied into a replacement for the value.
+	childDirty bool
+	dirty      bool
+	post       WalkerFn[R]
+	// typ is the type of the value, which is always a struct, pointer,
+	// slice, or interface.
+	typ reflect.Type
+	// value is a pointer to the value.
+	value reflect.Value
+}
+
+// frame represents the visitation of a single struct, interface,
+// pointer, or slice.
+type frame[R any] struct {
+	// actions is set if the slots hold the actions of a Decision,
+	// rather than the fields of a struct.
+	actions   bool
+	idx       int
+	intercept WalkerFn[R]
+	slots     []slot[R]
+}
+
+// active returns the slot which is being visited.
+func (f *frame[R]) active() *slot[R] {
+	return &f.slots[f.idx]
+}
+
+// walker holds the state of a single call to Walk.
+type walker[R any] struct {
+	// defers holds the functions passed to Context.Defer.
+	defers  []func() error
+	fn      WalkerFn[R]
+	halting bool
+	stack   []*frame[R]
+	types   *types
+}
+
+// newWalker constructs a walker which will call fn.
+func newWalker[R any](fn WalkerFn[R]) *walker[R] {
+	return &walker[R]{fn: fn, types: newTypes[R]()}
+}
+
+// runDeferred calls the deferred functions in reverse order. It
+// returns err if it is non-nil, otherwise the first error returned by
+// a deferred function.
+func (w *walker[R]) runDeferred(err error) error {
+	for i := len(w.defers) - 1; i >= 0; i-- {
+		if deferErr := w.defers[i](); deferErr != nil && err == nil {
+			err = deferErr
+		}
+	}
+	w.defers = nil
+	return err
+}
+
+// execute visits the struct of the given type, which is pointed to by
+// ptr. Any replacement must be assignable to the given type. It returns
+// a pointer to the value, or to its replacement.
+func (w *walker[R]) execute(
+	typ reflect.Type, ptr reflect.Value, assignableTo reflect.Type,
+) (ret reflect.Value, changed bool, err error) {
+	root := &frame[R]{slots: []slot[R]{{assignableTo: assignableTo, typ: typ, value: ptr}}}
+	if err := w.run(root); err != nil {
+		return reflect.Value{}, false, err
+	}
+	z := root.slots[0]
+	return z.value, z.dirty, nil
+}
+
+// run visits each slot in the frame.
+func (w *walker[R]) run(f *frame[R]) error {
+	w.stack = append(w.stack, f)
+	defer func() { w.stack = w.stack[:len(w.stack)-1] }()
+	for f.idx = 0; f.idx < len(f.slots); f.idx++ {
+		if err := w.visit(f, f.active()); err != nil {
+			return err
+		}
+		// If the user wants to stop early, the remaining slots are
+		// abandoned while the stack unwinds.
+		if w.halting {
+			break
+		}
+	}
+	return nil
+}
+
+// visit visits the slot and, unless the decision was to skip the value,
+// its children.
+func (w *walker[R]) visit(f *frame[R], s *slot[R]) error {
+	if s.call != nil {
+		if err := s.call(); err != nil {
+			return &PathError{Path: w.path(), Err: err}
+		}
+		return nil
+	}
+	if w.contains(s) {
+		return nil
+	}
+
+	var child *frame[R]
+	switch s.typ.Kind() {
+	case reflect.Ptr:
+		if ptr := s.value.Elem(); !ptr.IsNil() {
+			elem := s.typ.Elem()
+			child = &frame[R]{
+				intercept: f.intercept,
+				slots:     []slot[R]{{assignableTo: elem, typ: elem, value: ptr}},
+			}
+		}
+
+	case reflect.Struct:
+		ctx := Context[R]{w}
+		// Allow parent frames to intercept child values.
+		if f.intercept != nil {
+			if d := f.intercept(ctx, s.value.Interface().(R)); !d.isZero() {
+				if _, err := w.apply(s, d); err != nil {
+					return err
+				}
+				if d.halt {
+					w.halting = true
+				}
+				// Allow interceptors to replace themselves.
+				if d.intercept != nil {
+					f.intercept = d.intercept
+				}
+			}
+		}
+
+		d := w.fn(ctx, s.value.Interface().(R))
+		if _, err := w.apply(s, d); err != nil {
+			return err
+		}
+		if d.halt {
+			w.halting = true
+		}
+		switch {
+		case w.halting, d.skip:
+
+		case d.actions != nil:
+			child = &frame[R]{actions: true, intercept: d.intercept}
+			for _, a := range d.actions {
+				child.slots = append(child.slots, slot[R]{call: a.call, typ: a.typ, value: a.value})
+			}
+
+		default:
+			fields := w.types.fields(s.typ)
+			if len(fields) == 0 {
+				break
+			}
+			child = &frame[R]{intercept: d.intercept, slots: make([]slot[R], len(fields))}
+			for i, fld := range fields {
+				typ := s.typ.Field(fld.index).Type
+				child.slots[i] = slot[R]{
+					assignableTo: typ,
+					typ:          typ,
+					value:        s.value.Elem().Field(fld.index).Addr(),
+				}
+			}
+		}
+
+	case reflect.Slice:
+		elems := s.value.Elem()
+		if elems.Len() == 0 {
+			break
+		}
+		elem := s.typ.Elem()
+		child = &frame[R]{intercept: f.intercept, slots: make([]slot[R], elems.Len())}
+		for i := range child.slots {
+			child.slots[i] = slot[R]{assignableTo: elem, typ: elem, value: elems.Index(i).Addr()}
+		}
+
+	case reflect.Interface:
+		if typ, ptr, ok := w.types.intfValue(s.value.Elem()); ok {
+			child = &frame[R]{
+				intercept: f.intercept,
+				slots:     []slot[R]{{assignableTo: s.typ, typ: typ, value: ptr}},
+			}
+		}
+
+	default:
+		panic(fmt.Errorf("unexpected kind: %s", s.typ.Kind()))
+	}
+
+	if child != nil {
+		if err := w.run(child); err != nil {
+			return err
+		}
+	}
+	return w.unwind(s, child)
+}
+
+// unwind calls any post-visit function for the slot and propagates
+// changes to the parent slot. The frame holds the slot's children, and
+// may be nil.
+func (w *walker[R]) unwind(s *slot[R], children *frame[R]) error {
+	if s.post != nil {
+		d := s.post(Context[R]{w}, s.value.Interface().(R))
+		replaced, err := w.apply(s, d)
+		if err != nil {
+			return err
+		}
+		if replaced {
+			// The post-visit replacement supersedes any changes to the
+			// children of the previous value.
+			s.childDirty = false
+		}
+		if d.halt {
+			w.halting = true
+		}
+	}
+
+	if !s.dirty {
+		return nil
+	}
+	// A value visited by an action has no location in its parent, so
+	// changes to its children cannot be applied.
+	if s.assignableTo == nil {
+		return w.replacementError(nil, s.typ)
+	}
+	if len(w.stack) > 1 {
+		parent := w.stack[len(w.stack)-2].active()
+		parent.dirty = true
+		parent.childDirty = true
+	}
+	if !s.childDirty {
+		return nil
+	}
+
+	// Fold the children into a copy of the value.
+	next := reflect.New(s.typ)
+	switch s.typ.Kind() {
+	case reflect.Struct:
+		// Perform a shallow copy to catch non-visitable fields.
+		next.Elem().Set(s.value.Elem())
+		for i, fld := range w.types.fields(s.typ) {
+			next.Elem().Field(fld.index).Set(children.slots[i].value.Elem())
+		}
+
+	case reflect.Ptr:
+		next.Elem().Set(children.slots[0].value)
+
+	case reflect.Slice:
+		elems := reflect.MakeSlice(s.typ, len(children.slots), len(children.slots))
+		for i := range children.slots {
+			elems.Index(i).Set(children.slots[i].value.Elem())
+		}
+		next.Elem().Set(elems)
+
+	case reflect.Interface:
+		// A struct is stored in an interface as a pointer.
+		next.Elem().Set(children.slots[0].value)
+
+	default:
+		panic(fmt.Errorf("unimplemented: %s", s.typ.Kind()))
+	}
+	s.value = next
+	return nil
+}
+
+// apply updates the slot with information from a decision and reports
+// whether the value was replaced.
+func (w *walker[R]) apply(s *slot[R], d Decision[R]) (replaced bool, err error) {
+	if d.error != nil {
+		return false, &PathError{Path: w.path(), Err: d.error}
+	}
+	if d.post != nil {
+		s.post = d.post
+	}
+	if !d.replacement.IsValid() {
+		return false, nil
+	}
+	if w.identical(s, d.replacementType, d.replacement) {
+		return false, nil
+	}
+	if s.assignableTo == nil {
+		return false, w.replacementError(nil, d.replacementType)
+	}
+	if s.typ != d.replacementType {
+		// The type of the value can only be changed if it's being
+		// assigned to an interface.
+		if s.assignableTo.Kind() != reflect.Interface ||
+			!reflect.PointerTo(d.replacementType).Implements(s.assignableTo) {
+			return false, w.replacementError(s.assignableTo, d.replacementType)
+		}
+		s.typ = d.replacementType
+	}
+	s.dirty = true
+	s.value = d.replacement
+	return true, nil
+}
+
+// replacementError describes the replacement of the active slot of the
+// top frame with a value of the given type. The slot's type is nil if
+// the value has no location.
+func (w *walker[R]) replacementError(slot, typ reflect.Type) *ReplacementError {
+	ret := &ReplacementError{Path: w.path(), Type: typeString(typ)}
+	if slot != nil {
+		ret.Slot = typeString(slot)
+	}
+	return ret
+}
+
+// identical returns true if the replacement is the same as, or is
+// equal to, the value in an otherwise-unmodified slot.
+func (w *walker[R]) identical(s *slot[R], typ reflect.Type, ptr reflect.Value) bool {
+	if s.dirty || s.typ != typ {
+		return false
+	}
+	return s.value.Pointer() == ptr.Pointer() || w.types.equal(typ, s.value, ptr)
+}
+
+// contains returns true if the slot's value is being visited by one of
+// the frames beneath the top of the stack, which breaks cycles.
+func (w *walker[R]) contains(s *slot[R]) bool {
+	for _, f := range w.stack[:len(w.stack)-1] {
+		onStack := f.active()
+		if onStack.typ == s.typ && onStack.value.Pointer() == s.value.Pointer() {
+			return true
+		}
+	}
+	return false
+}
+
+// path describes the location of the active slot of the top frame.
+// See Context.Path.
+func (w *walker[R]) path() string {
+	if len(w.stack) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(w.stack[0].active().typ.Name())
+	for l := 1; l < len(w.stack); l++ {
+		f := w.stack[l]
+		parent := w.stack[l-1].active().typ
+		switch {
+		case f.actions:
+			fmt.Fprintf(&sb, "/action[%d]", f.idx)
+		case parent.Kind() == reflect.Struct:
+			sb.WriteString("/")
+			sb.WriteString(w.types.fields(parent)[f.idx].name)
+		case parent.Kind() == reflect.Slice:
+			fmt.Fprintf(&sb, "[%d]", f.idx)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package testing
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	gotesting "testing"
+
+	"github.com/cockroachdb/walkabout/reflectwalk"
+)
+
+// WalkContext is satisfied by a pointer to a generated Context type.
+type WalkContext[C any, D any] interface {
+	*C
+	Continue() D
+	Path() string
+}
+
+// WalkDecision is satisfied by a generated Decision type.
+type WalkDecision[R any, D any] interface {
+	Replace(x R) D
+}
+
+// CheckWalk validates the generated walker against the reference
+// implementation in package reflectwalk. The tree is walked by the
+// generated Walk function and by reflectwalk.Walk. Each walk calls the
+// rewrite function, which may be nil, before visiting the fields of a
+// struct, and replaces the struct with the result. The test fails
+// unless both walks visit the same paths in the same order, and
+// produce equal results. For example:
+//
+//	wt.CheckWalk(t, WalkTarget, x, func(x Target) Target { ... })
+func CheckWalk[R any, C any, D WalkDecision[R, D], F ~func(C, R) D, PC WalkContext[C, D]](
+	t gotesting.TB,
+	walk func(x R, fn F) (R, bool, error),
+	x R,
+	rewrite func(R) R,
+) {
+	t.Helper()
+
+	var generated []string
+	got, gotChanged, err := walk(x, F(func(ctx C, x R) D {
+		pc := PC(&ctx)
+		generated = append(generated, pc.Path()+" "+typeName(reflect.TypeOf(x)))
+		if rewrite != nil {
+			return pc.Continue().Replace(rewrite(x))
+		}
+		return pc.Continue()
+	}))
+	if err != nil {
+		t.Fatalf("generated walk failed: %v", err)
+	}
+
+	var events []string
+	want, wantChanged, err := reflectwalk.Walk(x, func(ctx reflectwalk.Context[R], x R) reflectwalk.Decision[R] {
+		events = append(events, ctx.Path()+" "+typeName(reflect.TypeOf(x)))
+		if rewrite != nil {
+			return ctx.Continue().Replace(rewrite(x))
+		}
+		return ctx.Continue()
+	})
+	if err != nil {
+		t.Fatalf("reference walk failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(events, generated) {
+		t.Errorf("visits differ\nreference:\n\t%s\ngenerated:\n\t%s",
+			strings.Join(events, "\n\t"), strings.Join(generated, "\n\t"))
+	}
+	if wantChanged != gotChanged {
+		t.Errorf("reference changed %t, generated changed %t", wantChanged, gotChanged)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("results differ\nreference: %s\ngenerated: %s", describe(want), describe(got))
+	}
+}
+
+// describe formats a value for an error message, following pointers.
+func describe(x interface{}) string {
+	var sb strings.Builder
+	var visit func(v reflect.Value, depth int)
+	visit = func(v reflect.Value, depth int) {
+		if depth > 32 {
+			sb.WriteString("...")
+			return
+		}
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if v.IsNil() {
+				sb.WriteString("nil")
+				return
+			}
+			if v.Kind() == reflect.Ptr {
+				sb.WriteString("&")
+			}
+			visit(v.Elem(), depth+1)
+		case reflect.Struct:
+			sb.WriteString(v.Type().Name())
+			sb.WriteString("{")
+			sep := ""
+			for i := 0; i < v.NumField(); i++ {
+				if !v.Type().Field(i).IsExported() || v.Field(i).IsZero() {
+					continue
+				}
+				fmt.Fprintf(&sb, "%s%s: ", sep, v.Type().Field(i).Name)
+				visit(v.Field(i), depth+1)
+				sep = ", "
+			}
+			sb.WriteString("}")
+		case reflect.Slice:
+			sb.WriteString("[")
+			for i := 0; i < v.Len(); i++ {
+				if i > 0 {
+					sb.WriteString(", ")
+				}
+				visit(v.Index(i), depth+1)
+			}
+			sb.WriteString("]")
+		default:
+			fmt.Fprintf(&sb, "%#v", v.Interface())
+		}
+	}
+	visit(reflect.ValueOf(x), 0)
+	return sb.String()
+}
+
+// typeName returns the name of a struct type, or of the struct to which
+// a pointer type refers.
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
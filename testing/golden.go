@@ -0,0 +1,141 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+// Package testing contains helpers for testing rewrites against
+// golden files, which contain the s-expression form of a tree as
+// produced by a generated Dump function. Since its name collides with
+// the standard library, it is usually imported with an alias:
+//
+//	import wt "github.com/cockroachdb/walkabout/testing"
+//
+//	func TestRewrite(t *testing.T) {
+//		out := rewrite(input)
+//		wt.GoldenTree(t, wt.GoldenFile(t), DumpTarget, out)
+//	}
+//
+// Golden files are rewritten, rather than compared, when the test
+// binary is run with -walkabout.update or when the WALKABOUT_UPDATE
+// environment variable is set to a non-empty value:
+//
+//	WALKABOUT_UPDATE=1 go test ./...
+package testing
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	gotesting "testing"
+)
+
+// UpdateEnv is the environment variable which causes golden files to
+// be rewritten.
+const UpdateEnv = "WALKABOUT_UPDATE"
+
+var updateFlag = flag.Bool("walkabout.update", false,
+	"rewrite golden files instead of comparing against them")
+
+// Update returns true if golden files should be rewritten.
+func Update() bool {
+	return *updateFlag || os.Getenv(UpdateEnv) != ""
+}
+
+// GoldenFile returns the conventional location of the golden file for
+// the test: testdata/<name>.golden, where the name of a subtest has
+// its slashes replaced.
+func GoldenFile(t gotesting.TB) string {
+	name := strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		}
+		return r
+	}, t.Name())
+	return filepath.Join("testdata", name+".golden")
+}
+
+// Dump returns the output of the dump function, which is usually a
+// generated Dump function, and fails the test if it returns an error.
+func Dump[T any](t gotesting.TB, dump func(T) (string, error), x T) string {
+	t.Helper()
+	text, err := dump(x)
+	if err != nil {
+		t.Fatalf("could not dump value: %v", err)
+	}
+	return text
+}
+
+// Golden compares the text to the contents of the golden file at path.
+// If golden files are being updated, the file and any missing parent
+// directories will be created instead.
+func Golden(t gotesting.TB, path, got string) {
+	t.Helper()
+	if !strings.HasSuffix(got, "\n") {
+		got += "\n"
+	}
+
+	if Update() {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("could not create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("could not write golden file: %v", err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %s does not exist; set %s=1 to create it", path, UpdateEnv)
+	} else if err != nil {
+		t.Fatalf("could not read golden file: %v", err)
+	}
+	if want := string(data); want != got {
+		t.Errorf("%s does not match; set %s=1 to update it\n%s", path, UpdateEnv, diff(want, got))
+	}
+}
+
+// GoldenTree dumps the tree and compares it to the golden file at
+// path.
+func GoldenTree[T any](t gotesting.TB, path string, dump func(T) (string, error), x T) {
+	t.Helper()
+	Golden(t, path, Dump(t, dump, x))
+}
+
+// diffContext is the number of matching lines which are shown around
+// the first difference.
+const diffContext = 3
+
+// diff describes the first line at which the texts differ.
+func diff(want, got string) string {
+	wantLines := strings.Split(want, "\n")
+	gotLines := strings.Split(got, "\n")
+	idx := 0
+	for idx < len(wantLines) && idx < len(gotLines) && wantLines[idx] == gotLines[idx] {
+		idx++
+	}
+
+	var sb strings.Builder
+	for i := max(0, idx-diffContext); i < idx; i++ {
+		sb.WriteString("  " + wantLines[i] + "\n")
+	}
+	for i := idx; i < min(len(wantLines), idx+diffContext); i++ {
+		sb.WriteString("- " + wantLines[i] + "\n")
+	}
+	for i := idx; i < min(len(gotLines), idx+diffContext); i++ {
+		sb.WriteString("+ " + gotLines[i] + "\n")
+	}
+	return sb.String()
+}